@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	html "html/template"
 	"os"
 	"sync"
@@ -36,6 +37,7 @@ import (
 	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/tick/ast"
 	"github.com/influxdata/kapacitor/tick/stateful"
@@ -69,6 +71,7 @@ type AlertNode struct {
 	idTmpl      *text.Template
 	messageTmpl *text.Template
 	detailsTmpl *html.Template
+	runbookTmpl *text.Template
 
 	alertsTriggered *expvar.Int
 	alertsInhibited *expvar.Int
@@ -82,6 +85,17 @@ type AlertNode struct {
 
 	levelResets  []stateful.Expression
 	lrScopePools []stateful.ScopePool
+
+	// Levels that only send a single event when entered and no recovery event.
+	triggerLevels map[alert.Level]bool
+
+	// Duration each level must be sustained before it is entered or left, nil if no level has one.
+	levelFor []time.Duration
+
+	// Group that first rendered each alert ID, used to keep IDs unique across groups.
+	idGroups map[string]models.GroupID
+	// Groups whose rendered ID collided with another group.
+	idCollisions map[models.GroupID]bool
 }
 
 // Create a new  AlertNode which caches the most recent item and exposes it over the HTTP API.
@@ -109,17 +123,24 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 	}
 
 	// Parse templates
-	an.idTmpl, err = text.New("id").Parse(n.Id)
+	an.idTmpl, err = text.New("id").Funcs(alertTemplateFuncs).Parse(n.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	an.messageTmpl, err = text.New("message").Parse(n.Message)
+	an.messageTmpl, err = text.New("message").Funcs(alertTemplateFuncs).Parse(n.Message)
 	if err != nil {
 		return nil, err
 	}
 
-	an.detailsTmpl, err = html.New("details").Funcs(html.FuncMap{
+	if n.Runbook != "" {
+		an.runbookTmpl, err = text.New("runbook").Funcs(alertTemplateFuncs).Parse(n.Runbook)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	an.detailsTmpl, err = html.New("details").Funcs(alertTemplateFuncs).Funcs(html.FuncMap{
 		"jsonCompact": func(v interface{}) html.JS {
 			tmpBuffer := an.bufPool.Get().(*bytes.Buffer)
 			tmpBuffer2 := an.bufPool.Get().(*bytes.Buffer)
@@ -208,6 +229,28 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		an.handlers = append(an.handlers, h)
 	}
 
+	for _, f := range n.FileHandlers {
+		c := alertservice.DefaultFileHandlerConfig()
+		c.Path = f.FilePath
+		if f.Mode != 0 {
+			c.Mode = os.FileMode(f.Mode)
+		}
+		if f.Format != "" {
+			c.Format = f.Format
+		}
+		c.Fields = f.FieldsList
+		c.Header = f.HeaderFlag
+		c.Template = f.Template
+		c.MaxSize = f.MaxSize
+		c.MaxAge = f.MaxAge
+		c.MaxBackups = int(f.MaxBackups)
+		h, err := alertservice.NewFileHandler(c, an.diag)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create file alert handler")
+		}
+		an.handlers = append(an.handlers, h)
+	}
+
 	for _, vo := range n.VictorOpsHandlers {
 		c := victorops.HandlerConfig{
 			RoutingKey: vo.RoutingKey,
@@ -223,7 +266,8 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 
 	for _, pd := range n.PagerDutyHandlers {
 		c := pagerduty.HandlerConfig{
-			ServiceKey: pd.ServiceKey,
+			ServiceKey:     pd.ServiceKey,
+			MaxPayloadSize: int(pd.MaxPayloadSize),
 		}
 		h := et.tm.PagerDutyService.Handler(c, ctx...)
 		an.handlers = append(an.handlers, h)
@@ -242,9 +286,21 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 				Text: l.Text,
 			}
 		}
+		var details map[string]string
+		if len(pd.CustomDetails) > 0 {
+			details = make(map[string]string, len(pd.CustomDetails))
+			for _, d := range pd.CustomDetails {
+				details[d.Key] = d.Value
+			}
+		}
 		c := pagerduty2.HandlerConfig{
-			RoutingKey: pd.RoutingKey,
-			Links:      links,
+			RoutingKey:     pd.RoutingKey,
+			Links:          links,
+			Failover:       pd.FailoverURLs,
+			Retries:        int(pd.Retries),
+			Timeout:        pd.Timeout,
+			CustomDetails:  details,
+			MaxPayloadSize: int(pd.MaxPayloadSize),
 		}
 		h, err := et.tm.PagerDuty2Service.Handler(c, ctx...)
 		if err != nil {
@@ -277,10 +333,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 
 	for _, s := range n.SlackHandlers {
 		c := slack.HandlerConfig{
-			Workspace: s.Workspace,
-			Channel:   s.Channel,
-			Username:  s.Username,
-			IconEmoji: s.IconEmoji,
+			Workspace:      s.Workspace,
+			Channel:        s.Channel,
+			Username:       s.Username,
+			IconEmoji:      s.IconEmoji,
+			MaxPayloadSize: int(s.MaxPayloadSize),
 		}
 		h := et.tm.SlackService.Handler(c, ctx...)
 		an.handlers = append(an.handlers, h)
@@ -450,6 +507,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Headers:         p.Headers,
 			CaptureResponse: p.CaptureResponseFlag,
 			Timeout:         p.Timeout,
+			Failover:        p.FailoverEndpoints,
+			Retries:         int(p.Retries),
+			MaxPayloadSize:  int(p.MaxPayloadSize),
 		}
 		h, err := et.tm.HTTPPostService.Handler(c, ctx...)
 		if err != nil {
@@ -507,10 +567,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 
 	for _, s := range n.DiscordHandlers {
 		c := discord.HandlerConfig{
-			Workspace:  s.Workspace,
-			Username:   s.Username,
-			AvatarURL:  s.AvatarURL,
-			EmbedTitle: s.EmbedTitle,
+			Workspace:      s.Workspace,
+			Username:       s.Username,
+			AvatarURL:      s.AvatarURL,
+			EmbedTitle:     s.EmbedTitle,
+			MaxPayloadSize: int(s.MaxPayloadSize),
 		}
 		h, err := et.tm.DiscordService.Handler(c, ctx...)
 		if err != nil {
@@ -536,7 +597,8 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 
 	for _, t := range n.TeamsHandlers {
 		c := teams.HandlerConfig{
-			ChannelURL: t.ChannelURL,
+			ChannelURL:     t.ChannelURL,
+			MaxPayloadSize: int(t.MaxPayloadSize),
 		}
 		h := et.tm.TeamsService.Handler(c, ctx...)
 		an.handlers = append(an.handlers, h)
@@ -606,6 +668,20 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		n.IsStateChangesOnly = true
 	}
 
+	for _, z := range n.ZabbixHandlers {
+		c := zabbix.HandlerConfig{
+			Server: z.Server,
+			Host:   z.Host,
+			Key:    z.Key,
+			Value:  z.Value,
+		}
+		h, err := et.tm.ZabbixService.Handler(c, ctx...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create zabbix handler")
+		}
+		an.handlers = append(an.handlers, h)
+	}
+
 	for _, s := range n.ZenossHandlers {
 		c := zenoss.HandlerConfig{
 			Action:        s.Action,
@@ -635,12 +711,19 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		n.IsStateChangesOnly = true
 	}
 
+	// Append the runbook to the message for handlers without native link support.
+	if n.Runbook != "" {
+		for i, h := range an.handlers {
+			an.handlers[i] = alert.WithRunbook(h)
+		}
+	}
+
 	// Parse level expressions
-	an.levels = make([]stateful.Expression, alert.Critical+1)
-	an.scopePools = make([]stateful.ScopePool, alert.Critical+1)
+	an.levels = make([]stateful.Expression, alert.MaxLevel()+1)
+	an.scopePools = make([]stateful.ScopePool, alert.MaxLevel()+1)
 
-	an.levelResets = make([]stateful.Expression, alert.Critical+1)
-	an.lrScopePools = make([]stateful.ScopePool, alert.Critical+1)
+	an.levelResets = make([]stateful.Expression, alert.MaxLevel()+1)
+	an.lrScopePools = make([]stateful.ScopePool, alert.MaxLevel()+1)
 
 	if n.Info != nil {
 		statefulExpression, expressionCompileError := stateful.NewExpression(n.Info.Expression)
@@ -694,6 +777,49 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		}
 	}
 
+	for _, cl := range n.Levels {
+		l, err := alert.ParseLevel(cl.Name)
+		if err != nil || l <= alert.Critical {
+			return nil, fmt.Errorf("invalid level %q, must be one of the custom-levels of the alert configuration", cl.Name)
+		}
+		statefulExpression, expressionCompileError := stateful.NewExpression(cl.Lambda.Expression)
+		if expressionCompileError != nil {
+			return nil, fmt.Errorf("Failed to compile stateful expression for level %s: %s", l, expressionCompileError)
+		}
+		an.levels[l] = statefulExpression
+		an.scopePools[l] = stateful.NewScopePool(ast.FindReferenceVariables(cl.Lambda.Expression))
+	}
+
+	// Configure sustained levels
+	if n.InfoFor > 0 || n.WarnFor > 0 || n.CritFor > 0 {
+		an.levelFor = make([]time.Duration, alert.MaxLevel()+1)
+		an.levelFor[alert.Info] = n.InfoFor
+		an.levelFor[alert.Warning] = n.WarnFor
+		an.levelFor[alert.Critical] = n.CritFor
+	}
+
+	// Configure trigger levels
+	if n.IsTrigger {
+		an.triggerLevels = make(map[alert.Level]bool)
+		if len(n.TriggerLevels) == 0 {
+			for l := alert.Info; l <= alert.MaxLevel(); l++ {
+				an.triggerLevels[l] = true
+			}
+		}
+		for _, tl := range n.TriggerLevels {
+			l, err := alert.ParseLevel(tl)
+			if err != nil || l == alert.OK {
+				return nil, fmt.Errorf("invalid trigger level %q", tl)
+			}
+			an.triggerLevels[l] = true
+		}
+	}
+
+	if n.UniqueIdFlag {
+		an.idGroups = make(map[string]models.GroupID)
+		an.idCollisions = make(map[models.GroupID]bool)
+	}
+
 	// Setup states
 	if n.History < 2 {
 		n.History = 2
@@ -879,7 +1005,8 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	}
 
 	n.alertsTriggered.Add(1)
-	switch event.State.Level {
+	// The custom levels are counted with the critical alerts.
+	switch event.State.Level.Builtin() {
 	case alert.OK:
 		n.oksTriggered.Add(1)
 	case alert.Info:
@@ -894,7 +1021,9 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	// If we have anon handlers, emit event to the anonTopic
 	if n.hasAnonTopic() {
 		event.Topic = n.anonTopic
+		n.timer.Pause()
 		err := n.et.tm.AlertService.Collect(event)
+		n.timer.Resume()
 		if err != nil {
 			n.eventsDropped.Add(1)
 			n.diag.Error("encountered error collecting event", err)
@@ -904,7 +1033,9 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	// If we have a user define topic, emit event to the topic.
 	if n.hasTopic() {
 		event.Topic = n.topic
+		n.timer.Pause()
 		err := n.et.tm.AlertService.Collect(event)
+		n.timer.Resume()
 		if err != nil {
 			n.eventsDropped.Add(1)
 			n.diag.Error("encountered error collecting event", err)
@@ -913,7 +1044,7 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 }
 
 func (n *AlertNode) determineLevel(p edge.FieldsTagsTimeGetter, currentLevel alert.Level) alert.Level {
-	if higherLevel, found := n.findFirstMatchLevel(alert.Critical, currentLevel-1, p); found {
+	if higherLevel, found := n.findFirstMatchLevel(alert.MaxLevel(), currentLevel-1, p); found {
 		return higherLevel
 	}
 	if rse := n.levelResets[currentLevel]; rse != nil {
@@ -957,20 +1088,39 @@ func (n *AlertNode) event(
 	t time.Time,
 	d time.Duration,
 	result models.Result,
+	recent *models.Row,
 ) (alert.Event, error) {
-	msg, details, err := n.renderMessageAndDetails(id, name, t, group, tags, fields, level, d)
+	if n.a.TimeSource == pipeline.AlertTimeSourceWall {
+		t = time.Now().UTC()
+	}
+	// The fingerprint only depends on the tags of the data.
+	fingerprint := alert.Fingerprint(id, n.a.FingerprintKeys, tags, fields)
+	if len(n.et.tm.GlobalTags) > 0 {
+		tags = n.withGlobalTags(tags)
+		// The series of the result carry the tags to the handlers posting the alert data.
+		series := make(models.Rows, len(result.Series))
+		for i, row := range result.Series {
+			r := *row
+			r.Tags = n.withGlobalTags(row.Tags)
+			series[i] = &r
+		}
+		result.Series = series
+	}
+	msg, details, runbook, err := n.renderTemplates(id, name, t, group, tags, fields, level, d, recent)
 	if err != nil {
 		return alert.Event{}, err
 	}
 	event := alert.Event{
 		Topic: n.anonTopic,
 		State: alert.EventState{
-			ID:       id,
-			Message:  msg,
-			Details:  details,
-			Time:     t,
-			Duration: d,
-			Level:    level,
+			ID:          id,
+			Message:     msg,
+			Details:     details,
+			Runbook:     runbook,
+			Fingerprint: fingerprint,
+			Time:        t,
+			Duration:    d,
+			Level:       level,
 		},
 		Data: alert.EventData{
 			Name:        name,
@@ -980,12 +1130,26 @@ func (n *AlertNode) event(
 			Tags:        tags,
 			Fields:      fields,
 			Result:      result,
+			Recent:      recent,
 			Recoverable: !n.a.NoRecoveriesFlag,
 		},
 	}
 	return event, nil
 }
 
+// withGlobalTags returns the tags with the global tags of the task master added,
+// the tags of the data take precedence.
+func (n *AlertNode) withGlobalTags(tags map[string]string) map[string]string {
+	withGlobal := make(map[string]string, len(n.et.tm.GlobalTags)+len(tags))
+	for k, v := range n.et.tm.GlobalTags {
+		withGlobal[k] = v
+	}
+	for k, v := range tags {
+		withGlobal[k] = v
+	}
+	return withGlobal
+}
+
 type alertState struct {
 	n *AlertNode
 
@@ -1005,6 +1169,55 @@ type alertState struct {
 	expired       bool
 
 	inhibitors []*alert.Inhibitor
+
+	// The latest point or batch, retained until the next evaluation when evaluating every interval.
+	latest edge.Message
+	// Time of the next evaluation when evaluating every interval.
+	nextEval time.Time
+
+	// Times since which the determined level has been continuously at or above, or below, each level.
+	// Zero when the determined level is currently on the other side of the level.
+	aboveSince []time.Time
+	belowSince []time.Time
+	// Time since which the alert has been continuously OK while waiting to resolve.
+	clearSince time.Time
+
+	// The most recent points of the group, only retained when attaching them to events.
+	recent []edge.BatchPointMessage
+}
+
+// addRecent retains the point, keeping only the number of recent points to attach.
+func (a *alertState) addRecent(p edge.PointMessage) {
+	count := int(a.n.a.AttachRecentCount)
+	if count == 0 {
+		return
+	}
+	if len(a.recent) == count {
+		copy(a.recent, a.recent[1:])
+		a.recent = a.recent[:count-1]
+	}
+	a.recent = append(a.recent, edge.NewBatchPointMessage(p.Fields(), p.Tags(), p.Time()))
+}
+
+// recentRow returns the recent points to attach to an event for the point or batch, if any.
+// A batch is its own recent data, limited to its last points.
+func (a *alertState) recentRow(m edge.PointMeta) *models.Row {
+	count := int(a.n.a.AttachRecentCount)
+	if count == 0 {
+		return nil
+	}
+	points := a.recent
+	if b, ok := m.(edge.BufferedBatchMessage); ok {
+		points = b.Points()
+		if len(points) > count {
+			points = points[len(points)-count:]
+		}
+	}
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(m.Name(), m.Tags(), m.Dimensions().ByName, m.Time(), len(points)),
+		points,
+		edge.NewEndBatchMessage(),
+	).ToRow()
 }
 
 func (a *alertState) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -1020,6 +1233,13 @@ func (a *alertState) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
 }
 
 func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, error) {
+	if a.n.a.Every > 0 {
+		return a.retain(b, b.Begin().Time())
+	}
+	return a.bufferedBatch(b)
+}
+
+func (a *alertState) bufferedBatch(b edge.BufferedBatchMessage) (edge.Message, error) {
 	begin := b.Begin()
 	id, err := a.n.renderID(begin.Name(), begin.GroupID(), begin.Tags())
 	if err != nil {
@@ -1029,7 +1249,7 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 		return nil, nil
 	}
 	// Keep track of lowest level for any point
-	lowestLevel := alert.Critical
+	lowestLevel := alert.MaxLevel()
 	// Keep track of highest level and point
 	highestLevel := alert.OK
 	var highestPoint edge.BatchPointMessage
@@ -1052,6 +1272,8 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	if !a.n.a.AllFlag {
 		l = highestLevel
 	}
+	l = a.sustainedLevel(l, begin.Time())
+	l = a.delayedResolve(l, begin.Time())
 	// Create alert Data
 	t := highestPoint.Time()
 	if a.n.a.AllFlag || l == alert.OK {
@@ -1077,9 +1299,12 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	if a.n.a.NoRecoveriesFlag && l == alert.OK {
 		return nil, nil
 	}
+	if a.held(l) {
+		return nil, nil
+	}
 
 	duration := a.duration()
-	event, err := a.n.event(id, begin.Name(), begin.GroupID(), begin.Tags(), highestPoint.Fields(), l, t, duration, b.ToResult())
+	event, err := a.n.event(id, begin.Name(), begin.GroupID(), begin.Tags(), highestPoint.Fields(), l, t, duration, b.ToResult(), a.recentRow(b))
 	if err != nil {
 		return nil, err
 	}
@@ -1112,11 +1337,21 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 }
 
 func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
+	a.addRecent(p)
+	if a.n.a.Every > 0 {
+		return a.retain(p, p.Time())
+	}
+	return a.point(p)
+}
+
+func (a *alertState) point(p edge.PointMessage) (edge.Message, error) {
 	id, err := a.n.renderID(p.Name(), p.GroupID(), p.Tags())
 	if err != nil {
 		return nil, err
 	}
 	l := a.n.determineLevel(p, a.currentLevel())
+	l = a.sustainedLevel(l, p.Time())
+	l = a.delayedResolve(l, p.Time())
 
 	a.addEvent(p.Time(), l)
 
@@ -1130,6 +1365,9 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 		if a.n.a.NoRecoveriesFlag && l == alert.OK {
 			return nil, nil
 		}
+		if a.held(l) {
+			return nil, nil
+		}
 		// Create an alert event
 		duration := a.duration()
 		event, err := a.n.event(
@@ -1142,6 +1380,7 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 			p.Time(),
 			duration,
 			p.ToResult(),
+			a.recentRow(p),
 		)
 		if err != nil {
 			return nil, err
@@ -1191,9 +1430,49 @@ func (a *alertState) augmentFieldsWithEventState(p edge.FieldSetter, eventState
 }
 
 func (a *alertState) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if a.latest != nil && !b.Time().Before(a.nextEval) {
+		msg, err := a.evaluateLatest(b.Time())
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			a.n.timer.Pause()
+			err := edge.Forward(a.n.outs, msg)
+			a.n.timer.Resume()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	return b, nil
 }
 
+// retain keeps m as the latest message of the group,
+// evaluating the previously retained message if t is past the next evaluation.
+func (a *alertState) retain(m edge.Message, t time.Time) (msg edge.Message, err error) {
+	if a.nextEval.IsZero() {
+		a.nextEval = t.Truncate(a.n.a.Every).Add(a.n.a.Every)
+	} else if !t.Before(a.nextEval) {
+		msg, err = a.evaluateLatest(t)
+	}
+	a.latest = m
+	return msg, err
+}
+
+// evaluateLatest evaluates the retained message and schedules the next evaluation after t.
+func (a *alertState) evaluateLatest(t time.Time) (edge.Message, error) {
+	latest := a.latest
+	a.latest = nil
+	a.nextEval = t.Truncate(a.n.a.Every).Add(a.n.a.Every)
+	switch m := latest.(type) {
+	case edge.PointMessage:
+		return a.point(m)
+	case edge.BufferedBatchMessage:
+		return a.bufferedBatch(m)
+	}
+	return nil, nil
+}
+
 func (a *alertState) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
 	return d, nil
 }
@@ -1242,11 +1521,97 @@ func (a *alertState) addEvent(t time.Time, level alert.Level) {
 
 }
 
+// sustainedLevel returns the level to enter given the determined level l at time t.
+// A level is entered only once l has been at or above it for the duration of the level,
+// and the current level is left only once l has been below it for the same duration.
+func (a *alertState) sustainedLevel(l alert.Level, t time.Time) alert.Level {
+	levelFor := a.n.levelFor
+	if levelFor == nil {
+		return l
+	}
+	if a.aboveSince == nil {
+		a.aboveSince = make([]time.Time, len(levelFor))
+		a.belowSince = make([]time.Time, len(levelFor))
+	}
+	for level := alert.Info; level <= alert.MaxLevel(); level++ {
+		if l >= level {
+			a.belowSince[level] = time.Time{}
+			if a.aboveSince[level].IsZero() {
+				a.aboveSince[level] = t
+			}
+		} else {
+			a.aboveSince[level] = time.Time{}
+			if a.belowSince[level].IsZero() {
+				a.belowSince[level] = t
+			}
+		}
+	}
+	sustained := func(level alert.Level) bool {
+		return a.n.levels[level] != nil && l >= level && t.Sub(a.aboveSince[level]) >= levelFor[level]
+	}
+
+	current := a.currentLevel()
+	for level := alert.MaxLevel(); level > current; level-- {
+		if sustained(level) {
+			return level
+		}
+	}
+	if current == alert.OK || l >= current || t.Sub(a.belowSince[current]) < levelFor[current] {
+		return current
+	}
+	for level := current - 1; level > alert.OK; level-- {
+		if sustained(level) {
+			return level
+		}
+	}
+	return alert.OK
+}
+
+// delayedResolve returns the level to enter given the level l at time t.
+// The current level is kept until l has been OK for the resolve delay.
+func (a *alertState) delayedResolve(l alert.Level, t time.Time) alert.Level {
+	delay := a.n.a.ResolveDelay
+	if delay == 0 {
+		return l
+	}
+	current := a.currentLevel()
+	if l != alert.OK || current == alert.OK {
+		a.clearSince = time.Time{}
+		return l
+	}
+	if a.clearSince.IsZero() {
+		a.clearSince = t
+	}
+	if t.Sub(a.clearSince) < delay {
+		return current
+	}
+	a.clearSince = time.Time{}
+	return alert.OK
+}
+
 // Return current level of this state
 func (a *alertState) currentLevel() alert.Level {
 	return a.history[a.idx]
 }
 
+// Return the level of this state before the current level
+func (a *alertState) previousLevel() alert.Level {
+	p := a.idx - 1
+	if p == -1 {
+		p = len(a.history) - 1
+	}
+	return a.history[p]
+}
+
+// held reports whether the event for the current level l is suppressed by the trigger mode,
+// either because the alert did not just enter the trigger level or because it is recovering from it.
+func (a *alertState) held(l alert.Level) bool {
+	if l == alert.OK {
+		return a.changed && a.n.triggerLevels[a.previousLevel()]
+	}
+	return !a.changed && a.n.triggerLevels[l]
+}
+
 // Compute the percentage change in the alert history.
 func (a *alertState) percentChange() float64 {
 	l := len(a.history)
@@ -1329,6 +1694,9 @@ type messageInfo struct {
 
 	// Duration of the alert
 	Duration time.Duration
+
+	// The most recent points of the group, nil unless attached.
+	Recent *models.Row `json:",omitempty"`
 }
 
 type detailsInfo struct {
@@ -1367,10 +1735,36 @@ func (n *AlertNode) renderID(name string, group models.GroupID, tags models.Tags
 	if err != nil {
 		return "", err
 	}
+	if n.a.UniqueIdFlag {
+		return n.uniqueID(id.String(), group), nil
+	}
 	return id.String(), nil
 }
 
-func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, level alert.Level, d time.Duration) (string, string, error) {
+// uniqueID returns the ID for the group, appending a hash of the group
+// if the ID was already rendered by a different group.
+func (n *AlertNode) uniqueID(id string, group models.GroupID) string {
+	owner, ok := n.idGroups[id]
+	if !ok {
+		n.idGroups[id] = group
+		return id
+	}
+	if owner == group {
+		return id
+	}
+	if !n.idCollisions[group] {
+		n.idCollisions[group] = true
+		n.diag.Error("alert ID collision, appending group hash to ID",
+			fmt.Errorf("alert ID %q of group %q is already used by group %q", id, group, owner),
+			keyvalue.KV("id", id))
+	}
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	return fmt.Sprintf("%s:%08x", id, h.Sum32())
+}
+
+// renderTemplates renders the message, details and runbook templates of the alert.
+func (n *AlertNode) renderTemplates(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, level alert.Level, d time.Duration, recent *models.Row) (string, string, string, error) {
 	g := string(group)
 	if group == models.NilGroup {
 		g = "nil"
@@ -1388,6 +1782,7 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 		Level:    level.String(),
 		Time:     t,
 		Duration: d,
+		Recent:   recent,
 	}
 
 	// Grab a buffer for the message template and the details template
@@ -1400,7 +1795,7 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 
 	err := n.messageTmpl.Execute(tmpBuffer, minfo)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
 	msg := tmpBuffer.String()
@@ -1413,9 +1808,19 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 	tmpBuffer.Reset()
 	err = n.detailsTmpl.Execute(tmpBuffer, dinfo)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
 	details := tmpBuffer.String()
-	return msg, details, nil
+
+	var runbook string
+	if n.runbookTmpl != nil {
+		tmpBuffer.Reset()
+		err = n.runbookTmpl.Execute(tmpBuffer, minfo)
+		if err != nil {
+			return "", "", "", err
+		}
+		runbook = tmpBuffer.String()
+	}
+	return msg, details, runbook, nil
 }
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+const (
+	// Name of the statistic of the endpoints of failover lists.
+	endpointsStatName = "alert_endpoints"
+
+	statEndpointSuccesses = "successes"
+	statEndpointFailures  = "failures"
+	statEndpointTimeouts  = "timeouts"
+)
+
+// FailoverRetryDelay is the time waited before retrying to deliver to the same endpoint.
+var FailoverRetryDelay = time.Second
+
+// endpointStats count the deliveries to an endpoint by all of the handlers delivering to it.
+type endpointStats struct {
+	successes *kexpvar.Int
+	failures  *kexpvar.Int
+	// timeouts counts the attempts that timed out, each of which is also a failed attempt.
+	timeouts *kexpvar.Int
+}
+
+var (
+	endpointStatsMu sync.Mutex
+	// endpointStatsByKey are the stats of the endpoints by service and endpoint name.
+	endpointStatsByKey = make(map[[2]string]*endpointStats)
+)
+
+// statsOfEndpoint returns the stats of the endpoint of the service,
+// which are reported in the alert_endpoints statistic tagged by service and endpoint.
+func statsOfEndpoint(service, endpoint string) *endpointStats {
+	endpointStatsMu.Lock()
+	defer endpointStatsMu.Unlock()
+	key := [2]string{service, endpoint}
+	if s, ok := endpointStatsByKey[key]; ok {
+		return s
+	}
+	s := &endpointStats{
+		successes: &kexpvar.Int{},
+		failures:  &kexpvar.Int{},
+		timeouts:  &kexpvar.Int{},
+	}
+	_, statMap := vars.NewStatistic(endpointsStatName, map[string]string{
+		"service":  service,
+		"endpoint": endpoint,
+	})
+	statMap.Set(statEndpointSuccesses, s.successes)
+	statMap.Set(statEndpointFailures, s.failures)
+	statMap.Set(statEndpointTimeouts, s.timeouts)
+	endpointStatsByKey[key] = s
+	return s
+}
+
+// Failover delivers alerts to the first endpoint of an ordered list that succeeds,
+// i.e. a primary endpoint followed by secondary endpoints in other regions.
+// Delivery to an endpoint is retried and once all attempts failed the next endpoint is tried.
+//
+// The deliveries that succeeded and the endpoints that failed after all attempts are counted by endpoint,
+// so that failover activity shows in the alert_endpoints statistic.
+// The attempts that timed out are counted separately, they are failed attempts and count towards the retries.
+type Failover struct {
+	endpoints []string
+	retries   int
+	stats     []*endpointStats
+}
+
+// NewFailover returns a failover list of the named endpoints of the service,
+// attempting delivery to each endpoint up to retries + 1 times.
+// The names of the endpoints are used as tags of the stats and must not contain secrets.
+func NewFailover(service string, endpoints []string, retries int) *Failover {
+	stats := make([]*endpointStats, len(endpoints))
+	for i, e := range endpoints {
+		stats[i] = statsOfEndpoint(service, e)
+	}
+	return &Failover{
+		endpoints: endpoints,
+		retries:   retries,
+		stats:     stats,
+	}
+}
+
+// Deliver calls send with the index of each endpoint in order until a call succeeds,
+// returning the index of the endpoint that succeeded.
+// The error of each failed attempt is passed to failed with the endpoint name,
+// the error of the last attempt is returned if all of the endpoints failed.
+func (f *Failover) Deliver(send func(i int) error, failed func(endpoint string, err error)) (int, error) {
+	var err error
+	for i, endpoint := range f.endpoints {
+		for attempt := 0; attempt <= f.retries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(FailoverRetryDelay)
+			}
+			if err = send(i); err == nil {
+				f.stats[i].successes.Add(1)
+				return i, nil
+			}
+			if IsTimeout(err) {
+				f.stats[i].timeouts.Add(1)
+			}
+			failed(endpoint, err)
+		}
+		f.stats[i].failures.Add(1)
+	}
+	return -1, err
+}
+
+// Endpoint returns the name of the i-th endpoint.
+func (f *Failover) Endpoint(i int) string {
+	return f.endpoints[i]
+}
+
+// IsTimeout reports whether the error of a delivery attempt is a timeout,
+// such as the timeout of an HTTP client or the deadline of the context of a request.
+// The timeouts are only counted for the handlers delivering with a Failover, i.e. the httppost and pagerduty2 handlers.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,123 @@
+package alert_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+func TestFailover_Deliver(t *testing.T) {
+	defer func(d time.Duration) { alert.FailoverRetryDelay = d }(alert.FailoverRetryDelay)
+	alert.FailoverRetryDelay = 0
+
+	testCases := []struct {
+		name    string
+		retries int
+		// fails is the number of attempts failing at each endpoint.
+		fails    []int
+		want     int
+		attempts []int
+		failed   []string
+	}{
+		{
+			name:     "primary",
+			retries:  1,
+			fails:    []int{0, 0},
+			want:     0,
+			attempts: []int{1, 0},
+		},
+		{
+			name:     "retried primary",
+			retries:  1,
+			fails:    []int{1, 0},
+			want:     0,
+			attempts: []int{2, 0},
+			failed:   []string{"primary"},
+		},
+		{
+			name:     "secondary",
+			retries:  1,
+			fails:    []int{2, 0},
+			want:     1,
+			attempts: []int{2, 1},
+			failed:   []string{"primary", "primary"},
+		},
+		{
+			name:     "no retries",
+			fails:    []int{1, 1},
+			want:     -1,
+			attempts: []int{1, 1},
+			failed:   []string{"primary", "secondary"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := alert.NewFailover("test", []string{"primary", "secondary"}, tc.retries)
+			attempts := make([]int, len(tc.fails))
+			var failed []string
+			got, err := f.Deliver(func(i int) error {
+				attempts[i]++
+				if attempts[i] <= tc.fails[i] {
+					return errors.New("unavailable")
+				}
+				return nil
+			}, func(endpoint string, err error) {
+				failed = append(failed, endpoint)
+			})
+			if got != tc.want {
+				t.Errorf("unexpected endpoint got %d exp %d", got, tc.want)
+			}
+			if tc.want < 0 && err == nil {
+				t.Error("expected error")
+			}
+			if tc.want >= 0 && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(attempts, tc.attempts) {
+				t.Errorf("unexpected attempts got %v exp %v", attempts, tc.attempts)
+			}
+			if !reflect.DeepEqual(failed, tc.failed) {
+				t.Errorf("unexpected failed endpoints got %v exp %v", failed, tc.failed)
+			}
+		})
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+	_, clientErr := client.Get(ts.URL)
+	if clientErr == nil {
+		t.Fatal("expected the request to time out")
+	}
+
+	testCases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "client timeout", err: clientErr, exp: true},
+		{name: "wrapped client timeout", err: fmt.Errorf("endpoint: %w", clientErr), exp: true},
+		{name: "deadline", err: context.DeadlineExceeded, exp: true},
+		{name: "canceled", err: context.Canceled, exp: false},
+		{name: "other", err: errors.New("POST returned non 2xx status code 500"), exp: false},
+	}
+	for _, tc := range testCases {
+		if got := alert.IsTimeout(tc.err); got != tc.exp {
+			t.Errorf("%s: unexpected IsTimeout got %v exp %v", tc.name, got, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Fingerprint returns the fingerprint of an alert event,
+// a stable identifier of the logical alert for deduplication by external systems.
+//
+// The fingerprint is the first 16 hex encoded characters of the SHA-256 hash of its input.
+// Without keys the input is the alert ID.
+// Otherwise the input is a line of the form key=value for each key in sorted order,
+// using the value of the tag if the key is a tag and the value of the field otherwise.
+// Field values are formatted as Go formats them with strconv, floats using the shortest representation.
+// Keys that are neither tags nor fields are skipped.
+//
+// The fingerprint only depends on its input so it is the same across restarts and versions of Kapacitor.
+func Fingerprint(id string, keys []string, tags map[string]string, fields map[string]interface{}) string {
+	h := sha256.New()
+	if len(keys) == 0 {
+		h.Write([]byte(id))
+	} else {
+		sorted := make([]string, len(keys))
+		copy(sorted, keys)
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			v, ok := tags[k]
+			if !ok {
+				f, ok := fields[k]
+				if !ok {
+					continue
+				}
+				v = formatFingerprintValue(f)
+			}
+			h.Write([]byte(k))
+			h.Write([]byte{'='})
+			h.Write([]byte(v))
+			h.Write([]byte{'\n'})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func formatFingerprintValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,58 @@
+package alert_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+func TestFingerprint(t *testing.T) {
+	tags := map[string]string{
+		"host": "serverA",
+		"cpu":  "cpu0",
+	}
+	fields := map[string]interface{}{
+		"value": 97.5,
+		"count": int64(3),
+		"ok":    true,
+	}
+	// The expected fingerprints are fixed, so that a change of the hashing is caught.
+	testCases := []struct {
+		name string
+		keys []string
+		want string
+	}{
+		{
+			name: "id",
+			want: "0ec75275dfde8278",
+		},
+		{
+			name: "tag and float field",
+			keys: []string{"value", "host"},
+			want: "69be180d03c51009",
+		},
+		{
+			name: "int and bool fields",
+			keys: []string{"ok", "host", "count"},
+			want: "22310db72afa8d72",
+		},
+		{
+			name: "missing keys",
+			keys: []string{"missing"},
+			want: "e3b0c44298fc1c14",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := alert.Fingerprint("cpu:host=serverA", tc.keys, tags, fields)
+			if got != tc.want {
+				t.Errorf("unexpected fingerprint got %s exp %s", got, tc.want)
+			}
+		})
+	}
+
+	// The fingerprint does not depend on the ID when computed from keys.
+	if a, b := alert.Fingerprint("a", []string{"host"}, tags, nil), alert.Fingerprint("b", []string{"host"}, tags, nil); a != b {
+		t.Errorf("expected the same fingerprint for different IDs got %s and %s", a, b)
+	}
+}
@@ -0,0 +1,312 @@
+package alert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Journal is a write-ahead log of alert events.
+//
+// Events are journaled before they are passed to the handlers of their topic
+// and are marked delivered once every handler has handled them.
+// Events that were not delivered when Kapacitor stopped are redelivered by Topics.Replay,
+// as such handlers must tolerate receiving an event more than once.
+//
+// Journaled events are synced to disk before they are delivered.
+// Records marking events delivered are synced along with the next journaled event,
+// losing them only results in the events being redelivered.
+type Journal struct {
+	path      string
+	maxSize   int64
+	retention time.Duration
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	nextID  uint64
+	pending map[uint64]*journalRecord
+	// IDs of the events that were not delivered before the journal was opened.
+	replay []uint64
+	// Time of the last compaction, without a maximum size the journal is compacted periodically.
+	compacted time.Time
+}
+
+// journalCompactInterval is the interval at which a journal without a maximum size is compacted.
+const journalCompactInterval = 10 * time.Minute
+
+type journalRecord struct {
+	ID uint64 `json:"id"`
+	// Event is only set for journaled events, records marking an event delivered contain only the ID.
+	Event *journalEvent `json:"event,omitempty"`
+
+	size int64
+}
+
+type journalEvent struct {
+	Journaled     time.Time  `json:"journaled"`
+	Topic         string     `json:"topic"`
+	State         EventState `json:"state"`
+	PreviousState EventState `json:"previousState"`
+	Data          EventData  `json:"data"`
+	NoExternal    bool       `json:"noExternal"`
+}
+
+// NewJournal creates a journal stored at path.
+// The journal is compacted once its size exceeds maxSize bytes,
+// or every 10 minutes if maxSize is zero meaning no limit.
+// Undelivered events older than retention are dropped and not redelivered, a zero retention means no limit.
+func NewJournal(path string, maxSize int64, retention time.Duration) *Journal {
+	return &Journal{
+		path:      path,
+		maxSize:   maxSize,
+		retention: retention,
+		pending:   make(map[uint64]*journalRecord),
+	}
+}
+
+// Open reads the undelivered events from the journal file and opens it for writing.
+func (j *Journal) Open() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create journal directory")
+	}
+	if err := j.read(); err != nil {
+		return err
+	}
+	j.expire()
+	for id := range j.pending {
+		j.replay = append(j.replay, id)
+	}
+	sort.Slice(j.replay, func(a, b int) bool { return j.replay[a] < j.replay[b] })
+	return j.compact()
+}
+
+// read loads the records of the journal file, a truncated final record is ignored.
+func (j *Journal) read() error {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to open journal")
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to read journal")
+		}
+		rec := new(journalRecord)
+		if err := json.Unmarshal(line, rec); err != nil {
+			return errors.Wrap(err, "invalid journal record")
+		}
+		if rec.ID >= j.nextID {
+			j.nextID = rec.ID + 1
+		}
+		if rec.Event == nil {
+			delete(j.pending, rec.ID)
+			continue
+		}
+		rec.size = int64(len(line))
+		j.pending[rec.ID] = rec
+	}
+}
+
+// Close closes the journal file, undelivered events remain in the journal.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+// append journals the event and returns its journal ID.
+func (j *Journal) append(event Event) (uint64, error) {
+	rec := &journalRecord{
+		Event: &journalEvent{
+			Journaled:     time.Now().UTC(),
+			Topic:         event.Topic,
+			State:         event.State,
+			PreviousState: event.previousState,
+			Data:          event.Data,
+			NoExternal:    event.NoExternal,
+		},
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	rec.ID = j.nextID
+	j.nextID++
+	if err := j.write(rec); err != nil {
+		return 0, err
+	}
+	if err := j.f.Sync(); err != nil {
+		return 0, errors.Wrap(err, "failed to sync journal")
+	}
+	j.pending[rec.ID] = rec
+	if j.needsCompaction() {
+		if err := j.compact(); err != nil {
+			return rec.ID, err
+		}
+	}
+	return rec.ID, nil
+}
+
+// delivered marks the event with the journal ID as delivered.
+func (j *Journal) delivered(id uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.pending[id]; !ok {
+		// The event was dropped during a compaction.
+		return nil
+	}
+	delete(j.pending, id)
+	if err := j.write(&journalRecord{ID: id}); err != nil {
+		return err
+	}
+	if j.needsCompaction() {
+		return j.compact()
+	}
+	return nil
+}
+
+// needsCompaction reports whether the journal exceeds its maximum size,
+// or without a maximum size whether the compaction interval has elapsed.
+func (j *Journal) needsCompaction() bool {
+	if j.maxSize > 0 {
+		return j.size > j.maxSize
+	}
+	return time.Since(j.compacted) > journalCompactInterval
+}
+
+// expire drops the undelivered events older than the retention.
+func (j *Journal) expire() {
+	if j.retention <= 0 {
+		return
+	}
+	for id, rec := range j.pending {
+		if time.Since(rec.Event.Journaled) > j.retention {
+			delete(j.pending, id)
+		}
+	}
+}
+
+// undelivered returns the events that were not delivered before the journal was opened
+// along with their journal IDs. Each event is returned only once.
+func (j *Journal) undelivered() ([]uint64, []Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ids := make([]uint64, 0, len(j.replay))
+	events := make([]Event, 0, len(j.replay))
+	for _, id := range j.replay {
+		rec, ok := j.pending[id]
+		if !ok {
+			continue
+		}
+		ids = append(ids, id)
+		events = append(events, Event{
+			Topic:         rec.Event.Topic,
+			State:         rec.Event.State,
+			Data:          rec.Event.Data,
+			NoExternal:    rec.Event.NoExternal,
+			previousState: rec.Event.PreviousState,
+		})
+	}
+	j.replay = nil
+	return ids, events
+}
+
+func (j *Journal) write(rec *journalRecord) error {
+	if j.f == nil {
+		return errors.New("journal is closed")
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode journal record")
+	}
+	line = append(line, '\n')
+	n, err := j.f.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "failed to write journal record")
+	}
+	rec.size = int64(n)
+	return nil
+}
+
+// compact rewrites the journal file with only the undelivered events within the retention.
+// If the undelivered events exceed half of the maximum size the oldest events are dropped.
+func (j *Journal) compact() error {
+	j.expire()
+	ids := make([]uint64, 0, len(j.pending))
+	var size int64
+	for id, rec := range j.pending {
+		ids = append(ids, id)
+		size += rec.size
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	for j.maxSize > 0 && size > j.maxSize/2 && len(ids) > 0 {
+		size -= j.pending[ids[0]].size
+		delete(j.pending, ids[0])
+		ids = ids[1:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		if err := enc.Encode(j.pending[id]); err != nil {
+			return errors.Wrap(err, "failed to encode journal record")
+		}
+	}
+	tmp := j.path + ".tmp"
+	if err := writeSynced(tmp, buf.Bytes()); err != nil {
+		return errors.Wrap(err, "failed to write journal")
+	}
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return errors.Wrap(err, "failed to replace journal")
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open journal")
+	}
+	j.f = f
+	j.size = int64(buf.Len())
+	j.compacted = time.Now()
+	return nil
+}
+
+// writeSynced writes data to the file at path and syncs it to disk.
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
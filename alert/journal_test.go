@@ -0,0 +1,268 @@
+package alert_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []alert.Event
+	block  chan struct{}
+}
+
+func (h *recordingHandler) Handle(event alert.Event) {
+	if h.block != nil {
+		<-h.block
+	}
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+}
+
+func (h *recordingHandler) IDs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, len(h.events))
+	for i, e := range h.events {
+		ids[i] = e.State.ID
+	}
+	return ids
+}
+
+// failingHandler reports that it failed to deliver every event.
+type failingHandler struct {
+	recordingHandler
+}
+
+func (h *failingHandler) Deliver(event alert.Event) error {
+	h.Handle(event)
+	return errors.New("failed to deliver")
+}
+
+func newJournalEvent(id string, level alert.Level) alert.Event {
+	return alert.Event{
+		Topic: "topic",
+		State: alert.EventState{
+			ID:      id,
+			Message: id + " is " + level.String(),
+			Time:    time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name: "cpu",
+			Tags: map[string]string{"host": "serverA"},
+		},
+	}
+}
+
+func TestJournal_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.journal")
+
+	// Deliver one event and leave one event undelivered.
+	j := alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	delivered := &recordingHandler{}
+	topics.RegisterHandler("topic", delivered)
+	if err := topics.Collect(newJournalEvent("delivered", alert.Warning)); err != nil {
+		t.Fatal(err)
+	}
+	topics.DeregisterHandler("topic", delivered)
+	blocked := &recordingHandler{block: make(chan struct{})}
+	topics.RegisterHandler("topic", blocked)
+	if err := topics.Collect(newJournalEvent("undelivered", alert.Critical)); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the journal as if after a restart.
+	j = alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	topics = alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	h := &recordingHandler{}
+	topics.RegisterHandler("topic", h)
+	n, err := topics.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("unexpected number of replayed events got %d exp 1", n)
+	}
+	topics.Close()
+
+	if got, exp := h.IDs(), []string{"undelivered"}; len(got) != 1 || got[0] != exp[0] {
+		t.Fatalf("unexpected replayed events got %v exp %v", got, exp)
+	}
+	e := h.events[0]
+	if got, exp := e.State.Message, "undelivered is CRITICAL"; got != exp {
+		t.Errorf("unexpected message got %q exp %q", got, exp)
+	}
+	if got, exp := e.Data.Tags["host"], "serverA"; got != exp {
+		t.Errorf("unexpected host tag got %q exp %q", got, exp)
+	}
+
+	// Replaying again is a no-op, the replayed event has been delivered.
+	if n, err := topics.Replay(); err != nil || n != 0 {
+		t.Errorf("unexpected second replay got %d, %v", n, err)
+	}
+	j.Close()
+	j = alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	topics = alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	if n, err := topics.Replay(); err != nil || n != 0 {
+		t.Errorf("unexpected replay after delivery got %d, %v", n, err)
+	}
+}
+
+func TestJournal_Retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.journal")
+	j := alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	topics.RegisterHandler("topic", &recordingHandler{block: make(chan struct{})})
+	if err := topics.Collect(newJournalEvent("old", alert.Critical)); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	time.Sleep(10 * time.Millisecond)
+	j = alert.NewJournal(path, 0, time.Millisecond)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	topics = alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	if n, err := topics.Replay(); err != nil || n != 0 {
+		t.Errorf("expected expired event not to be replayed, got %d, %v", n, err)
+	}
+}
+
+func TestJournal_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.journal")
+	const maxSize = 4096
+	j := alert.NewJournal(path, maxSize, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	h := &recordingHandler{}
+	topics.RegisterHandler("topic", h)
+	for i := 0; i < 1000; i++ {
+		if err := topics.Collect(newJournalEvent("delivered", alert.Critical)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Closing the topics waits for the delivery of all events.
+	topics.Close()
+	j.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > maxSize {
+		t.Errorf("journal exceeds maximum size got %d exp <= %d", fi.Size(), maxSize)
+	}
+	if got, exp := len(h.IDs()), 1000; got != exp {
+		t.Errorf("unexpected number of events got %d exp %d", got, exp)
+	}
+}
+
+// replayed reopens the journal at path and returns the number of replayed events.
+func replayed(t *testing.T, path string) int {
+	t.Helper()
+	j := alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	// Buffer all replayed events so that none are dropped.
+	topics := alert.NewTopics(2 * alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	topics.RegisterHandler("topic", &recordingHandler{})
+	defer topics.Close()
+	n, err := topics.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestJournal_DeliveryFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.journal")
+	j := alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	h := &failingHandler{}
+	topics.RegisterHandler("topic", h)
+	topics.RegisterHandler("topic", &recordingHandler{})
+	if err := topics.Collect(newJournalEvent("failed", alert.Critical)); err != nil {
+		t.Fatal(err)
+	}
+	// Closing the topics waits for the delivery of all events.
+	topics.Close()
+	j.Close()
+
+	if got, exp := h.IDs(), []string{"failed"}; len(got) != 1 || got[0] != exp[0] {
+		t.Fatalf("unexpected handled events got %v exp %v", got, exp)
+	}
+	if got, exp := replayed(t, path), 1; got != exp {
+		t.Errorf("unexpected number of replayed events got %d exp %d", got, exp)
+	}
+}
+
+func TestJournal_Dropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.journal")
+	j := alert.NewJournal(path, 0, 0)
+	if err := j.Open(); err != nil {
+		t.Fatal(err)
+	}
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetJournal(j)
+	topics.RegisterHandler("topic", &recordingHandler{block: make(chan struct{})})
+	// The handler blocks on the first event, the remaining events fill its buffer and are then dropped.
+	count := alert.DefaultEventBufferSize + 10
+	dropped := 0
+	for i := 0; i < count; i++ {
+		if err := topics.Collect(newJournalEvent(fmt.Sprintf("event%d", i), alert.Critical)); err != nil {
+			dropped++
+		}
+	}
+	j.Close()
+
+	if dropped == 0 {
+		t.Fatal("expected events to be dropped")
+	}
+	if got, exp := replayed(t, path), count; got != exp {
+		t.Errorf("unexpected number of replayed events got %d exp %d", got, exp)
+	}
+}
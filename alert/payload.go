@@ -0,0 +1,119 @@
+package alert
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+const (
+	// Name of the statistic of the payloads of handlers.
+	payloadsStatName = "alert_payloads"
+
+	statPayloadTruncations = "truncations"
+)
+
+// TruncatedMarker ends the details and messages truncated to fit the max payload size of a handler.
+const TruncatedMarker = "…truncated"
+
+var (
+	payloadStatsMu sync.Mutex
+	// payloadTruncations count the truncated payloads by service.
+	payloadTruncations = make(map[string]*kexpvar.Int)
+)
+
+// truncationsOf returns the count of the truncated payloads of the service,
+// which is reported in the alert_payloads statistic tagged by service.
+func truncationsOf(service string) *kexpvar.Int {
+	payloadStatsMu.Lock()
+	defer payloadStatsMu.Unlock()
+	if c, ok := payloadTruncations[service]; ok {
+		return c
+	}
+	c := &kexpvar.Int{}
+	_, statMap := vars.NewStatistic(payloadsStatName, map[string]string{
+		"service": service,
+	})
+	statMap.Set(statPayloadTruncations, c)
+	payloadTruncations[service] = c
+	return c
+}
+
+// PayloadLimit truncates the events of a handler so that their payloads fit a maximum size,
+// rather than the delivery failing because the payload is larger than the service accepts.
+//
+// The attached data of the event, its result and recent points, is dropped first.
+// If the payload is still too large, the details and then the message are truncated and end with TruncatedMarker.
+// The payload is sent even if it does not fit once both are truncated.
+// A nil PayloadLimit does not limit the size of payloads.
+type PayloadLimit struct {
+	max         int
+	truncations *kexpvar.Int
+}
+
+// NewPayloadLimit returns the limit of the payloads of a handler of the service to max bytes,
+// nil if max is not positive.
+func NewPayloadLimit(service string, max int) *PayloadLimit {
+	if max <= 0 {
+		return nil
+	}
+	return &PayloadLimit{
+		max:         max,
+		truncations: truncationsOf(service),
+	}
+}
+
+// Fit returns the event truncated to fit the limit and its payload, encoded by encode.
+func (l *PayloadLimit) Fit(event Event, encode func(Event) ([]byte, error)) (Event, []byte, error) {
+	b, err := encode(event)
+	if err != nil || l == nil || len(b) <= l.max {
+		return event, b, err
+	}
+	l.truncations.Add(1)
+
+	if len(event.Data.Result.Series) > 0 || event.Data.Recent != nil {
+		event.Data.Result.Series = nil
+		event.Data.Recent = nil
+		if b, err = encode(event); err != nil || len(b) <= l.max {
+			return event, b, err
+		}
+	}
+
+	for _, s := range []*string{&event.State.Details, &event.State.Message} {
+		original := *s
+		for len(b) > l.max && *s != "" {
+			size := len(b)
+			*s = truncate(*s, size-l.max)
+			if b, err = encode(event); err != nil {
+				return event, nil, err
+			}
+			if len(b) >= size {
+				// The payload does not contain the string, leave it as is.
+				*s = original
+				if b, err = encode(event); err != nil {
+					return event, nil, err
+				}
+				break
+			}
+		}
+	}
+	return event, b, nil
+}
+
+// truncate returns s shortened by at least excess bytes, ending with TruncatedMarker,
+// or the marker alone, or the empty string once even the marker is too long.
+func truncate(s string, excess int) string {
+	keep := len(s) - excess - len(TruncatedMarker)
+	if keep <= 0 {
+		if s == TruncatedMarker {
+			return ""
+		}
+		return TruncatedMarker
+	}
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+	return s[:keep] + TruncatedMarker
+}
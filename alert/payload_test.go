@@ -0,0 +1,142 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestPayloadLimit_Fit(t *testing.T) {
+	encode := func(e alert.Event) ([]byte, error) {
+		return json.Marshal(e.AlertData())
+	}
+	event := func() alert.Event {
+		return alert.Event{
+			State: alert.EventState{
+				ID:      "cpu",
+				Message: "cpu is " + strings.Repeat("high ", 40),
+				Details: strings.Repeat("<p>details</p>", 40),
+			},
+			Data: alert.EventData{
+				Result: models.Result{
+					Series: models.Rows{{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  [][]interface{}{{"2026-10-14T00:00:00Z", strings.Repeat("9", 500)}},
+					}},
+				},
+			},
+		}
+	}
+	full, _ := encode(event())
+	noData := event()
+	noData.Data.Result.Series = nil
+	withoutData, _ := encode(noData)
+
+	testCases := []struct {
+		name string
+		max  int
+		// check verifies the fitted event.
+		check func(t *testing.T, e alert.Event)
+	}{
+		{
+			name: "fits",
+			max:  len(full),
+			check: func(t *testing.T, e alert.Event) {
+				if len(e.Data.Result.Series) != 1 || strings.HasSuffix(e.State.Details, alert.TruncatedMarker) {
+					t.Error("unexpected truncation of an event that fits")
+				}
+			},
+		},
+		{
+			name: "drops data",
+			max:  len(withoutData),
+			check: func(t *testing.T, e alert.Event) {
+				if e.Data.Result.Series != nil {
+					t.Error("expected the data to be dropped")
+				}
+				if e.State.Details != event().State.Details || e.State.Message != event().State.Message {
+					t.Error("unexpected truncation of the details or message")
+				}
+			},
+		},
+		{
+			name: "truncates details",
+			max:  len(withoutData) - 100,
+			check: func(t *testing.T, e alert.Event) {
+				if !strings.HasSuffix(e.State.Details, alert.TruncatedMarker) {
+					t.Errorf("expected truncated details, got %q", e.State.Details)
+				}
+				if e.State.Message != event().State.Message {
+					t.Error("unexpected truncation of the message")
+				}
+			},
+		},
+		{
+			name: "truncates message",
+			max:  200,
+			check: func(t *testing.T, e alert.Event) {
+				if e.State.Details != "" {
+					t.Errorf("expected the details to be dropped, got %q", e.State.Details)
+				}
+				if !strings.HasPrefix(e.State.Message, "cpu is high") || !strings.HasSuffix(e.State.Message, alert.TruncatedMarker) {
+					t.Errorf("expected a truncated message, got %q", e.State.Message)
+				}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := alert.NewPayloadLimit("test", tc.max)
+			e, b, err := l.Fit(event(), encode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(b) > tc.max {
+				t.Errorf("payload of %d bytes exceeds the max of %d", len(b), tc.max)
+			}
+			if exp, _ := encode(e); string(exp) != string(b) {
+				t.Error("payload is not the encoding of the fitted event")
+			}
+			tc.check(t, e)
+		})
+	}
+}
+
+func TestPayloadLimit_FitUnlimited(t *testing.T) {
+	var l *alert.PayloadLimit
+	if alert.NewPayloadLimit("test", 0) != l {
+		t.Fatal("expected no limit for a max of 0")
+	}
+	event := alert.Event{State: alert.EventState{Message: strings.Repeat("x", 1000)}}
+	e, b, err := l.Fit(event, func(e alert.Event) ([]byte, error) {
+		return []byte(e.State.Message), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.State.Message != event.State.Message || len(b) != 1000 {
+		t.Error("unexpected truncation without a limit")
+	}
+}
+
+func TestPayloadLimit_FitUnencodedDetails(t *testing.T) {
+	// The details are not part of the payload, truncating them does not help.
+	details := strings.Repeat("d", 1000)
+	event := alert.Event{State: alert.EventState{Message: strings.Repeat("m", 100), Details: details}}
+	e, b, err := alert.NewPayloadLimit("test", 50).Fit(event, func(e alert.Event) ([]byte, error) {
+		return []byte(e.State.Message), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.State.Details != details {
+		t.Error("unexpected truncation of details which are not in the payload")
+	}
+	if len(b) > 50 || !strings.HasSuffix(e.State.Message, alert.TruncatedMarker) {
+		t.Errorf("unexpected payload %q", b)
+	}
+}
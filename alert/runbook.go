@@ -0,0 +1,37 @@
+package alert
+
+// RunbookRenderer is implemented by handlers that render the runbook of an event natively,
+// for example as a link or a dedicated field of the notification.
+type RunbookRenderer interface {
+	RendersRunbook() bool
+}
+
+// RendersRunbook reports whether the handler renders the runbook of an event natively.
+func RendersRunbook(h Handler) bool {
+	r, ok := h.(RunbookRenderer)
+	return ok && r.RendersRunbook()
+}
+
+// WithRunbook wraps a handler so that the runbook of an event is appended to its message,
+// unless the handler renders the runbook natively.
+func WithRunbook(h Handler) Handler {
+	if RendersRunbook(h) {
+		return h
+	}
+	return &runbookHandler{h: h}
+}
+
+type runbookHandler struct {
+	h Handler
+}
+
+func (h *runbookHandler) Handle(event Event) {
+	h.Deliver(event)
+}
+
+func (h *runbookHandler) Deliver(event Event) error {
+	if event.State.Runbook != "" {
+		event.State.Message += "\nRunbook: " + event.State.Runbook
+	}
+	return Deliver(h.h, event)
+}
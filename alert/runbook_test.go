@@ -0,0 +1,53 @@
+package alert_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type messageHandler struct {
+	messages []string
+	native   bool
+}
+
+func (h *messageHandler) Handle(event alert.Event) {
+	h.messages = append(h.messages, event.State.Message)
+}
+
+func (h *messageHandler) RendersRunbook() bool {
+	return h.native
+}
+
+func TestWithRunbook(t *testing.T) {
+	testCases := []struct {
+		native  bool
+		runbook string
+		want    string
+	}{
+		{
+			runbook: "https://wiki.example.com/cpu",
+			want:    "cpu is CRITICAL\nRunbook: https://wiki.example.com/cpu",
+		},
+		{
+			want: "cpu is CRITICAL",
+		},
+		{
+			native:  true,
+			runbook: "https://wiki.example.com/cpu",
+			want:    "cpu is CRITICAL",
+		},
+	}
+	for _, tc := range testCases {
+		h := &messageHandler{native: tc.native}
+		alert.WithRunbook(h).Handle(alert.Event{
+			State: alert.EventState{
+				Message: "cpu is CRITICAL",
+				Runbook: tc.runbook,
+			},
+		})
+		if got := h.messages[0]; got != tc.want {
+			t.Errorf("unexpected message native: %v runbook: %q got %q exp %q", tc.native, tc.runbook, got, tc.want)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package alert
 
 import (
 	"fmt"
+	"hash/fnv"
 	"path"
 	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/server/vars"
@@ -14,12 +16,26 @@ const (
 	// DefaultEventBufferSize is the default number of events to buffer to each handler per topic.
 	DefaultEventBufferSize = 5000
 	MinimumEventBufferSize = 1000
+
+	// DefaultHandlerConcurrency is the default number of events each handler of a topic handles at the same time.
+	DefaultHandlerConcurrency = 1
 )
 
 type Topics struct {
 	mu              sync.RWMutex
 	eventBufferSize int
 	topics          map[string]*Topic
+	silencer        Silencer
+	journal         *Journal
+
+	handlerConcurrency      int
+	topicHandlerConcurrency map[string]int
+}
+
+// Silencer decides whether delivery of an event to its handlers should be suppressed.
+type Silencer interface {
+	// Silenced reports whether the event should be recorded but not delivered.
+	Silenced(event Event) bool
 }
 
 // NewTopics creates a new Topics struct with a minimum bufferSize of 500.
@@ -28,8 +44,9 @@ func NewTopics(bufferSize int) *Topics {
 		bufferSize = DefaultEventBufferSize
 	}
 	s := &Topics{
-		eventBufferSize: bufferSize,
-		topics:          make(map[string]*Topic),
+		eventBufferSize:    bufferSize,
+		topics:             make(map[string]*Topic),
+		handlerConcurrency: DefaultHandlerConcurrency,
 	}
 	return s
 }
@@ -48,6 +65,85 @@ func (s *Topics) Close() error {
 	return nil
 }
 
+// SetSilencer sets the silencer that is consulted before delivering any events.
+func (s *Topics) SetSilencer(silencer Silencer) {
+	s.mu.Lock()
+	s.silencer = silencer
+	s.mu.Unlock()
+}
+
+// SetJournal sets the journal that events are written to before they are delivered.
+func (s *Topics) SetJournal(journal *Journal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = journal
+	for _, t := range s.topics {
+		t.mu.Lock()
+		t.journal = journal
+		t.mu.Unlock()
+	}
+}
+
+// SetHandlerConcurrency sets the number of events each handler of a topic handles at the same time,
+// overridden by the concurrency of the topic if any.
+// It applies to the handlers registered afterwards, a concurrency below 1 means the default.
+//
+// Each handler handles the events independently of the other handlers of the topic.
+// Events with the same ID are handled by a handler in the order they are collected,
+// with a concurrency above 1 events with different IDs may be handled in any order.
+func (s *Topics) SetHandlerConcurrency(concurrency int, topics map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if concurrency < 1 {
+		concurrency = DefaultHandlerConcurrency
+	}
+	s.handlerConcurrency = concurrency
+	s.topicHandlerConcurrency = topics
+	for id, t := range s.topics {
+		t.mu.Lock()
+		t.handlerConcurrency = s.topicConcurrency(id)
+		t.mu.Unlock()
+	}
+}
+
+// topicConcurrency returns the handler concurrency of the topic.
+func (s *Topics) topicConcurrency(id string) int {
+	if c, ok := s.topicHandlerConcurrency[id]; ok && c > 0 {
+		return c
+	}
+	return s.handlerConcurrency
+}
+
+// Replay redelivers the journaled events that were not delivered before the journal was opened
+// and returns the number of redelivered events.
+// The events are delivered to the current handlers of their topics without updating the event states.
+func (s *Topics) Replay() (int, error) {
+	s.mu.RLock()
+	journal := s.journal
+	s.mu.RUnlock()
+	if journal == nil {
+		return 0, nil
+	}
+	var errs multiError
+	ids, events := journal.undelivered()
+	for i, event := range events {
+		s.mu.Lock()
+		topic := s.topics[event.Topic]
+		if topic == nil {
+			topic = s.newTopic(event.Topic)
+			s.topics[event.Topic] = topic
+		}
+		s.mu.Unlock()
+		if err := topic.deliver(event, journal, ids[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return len(events), errs
+	}
+	return len(events), nil
+}
+
 func (s *Topics) Topic(id string) (*Topic, bool) {
 	s.mu.RLock()
 	t, ok := s.topics[id]
@@ -91,6 +187,7 @@ func (s *Topics) EventState(topic, event string) (EventState, bool) {
 func (s *Topics) Collect(event Event) error {
 	s.mu.RLock()
 	topic := s.topics[event.Topic]
+	silencer := s.silencer
 	s.mu.RUnlock()
 
 	if topic == nil {
@@ -105,7 +202,8 @@ func (s *Topics) Collect(event Event) error {
 		s.mu.Unlock()
 	}
 
-	return topic.collect(event)
+	silenced := silencer != nil && silencer.Silenced(event)
+	return topic.collect(event, silenced)
 }
 
 func (s *Topics) DeleteTopic(topic string) {
@@ -198,22 +296,33 @@ type Topic struct {
 	sorted       []*EventState
 
 	collected *expvar.Int
-	statsKey  string
+	silenced  *expvar.Int
+	// Number of events waiting to be handled by the handlers of the topic.
+	queued   *expvar.Int
+	statsKey string
 
-	handlers []*bufHandler
+	handlers           []*bufHandler
+	handlerConcurrency int
+	journal            *Journal
 }
 
 func (s *Topics) newTopic(id string) *Topic {
 	t := &Topic{
-		id:           id,
-		events:       make(map[string]*EventState),
-		collected:    new(expvar.Int),
-		bufferLength: s.eventBufferSize,
+		id:                 id,
+		events:             make(map[string]*EventState),
+		collected:          new(expvar.Int),
+		silenced:           new(expvar.Int),
+		queued:             new(expvar.Int),
+		bufferLength:       s.eventBufferSize,
+		handlerConcurrency: s.topicConcurrency(id),
+		journal:            s.journal,
 	}
 	statsKey, statsMap := vars.NewStatistic("topics", map[string]string{
 		"id": id,
 	})
 	statsMap.Set("collected", t.collected)
+	statsMap.Set("silenced", t.silenced)
+	statsMap.Set("queued", t.queued)
 	t.statsKey = statsKey
 	return t
 }
@@ -246,7 +355,7 @@ func (t *Topic) addHandler(h Handler) {
 			return
 		}
 	}
-	hdlr := newHandler(h, t.bufferLength)
+	hdlr := newHandler(h, t.bufferLength, t.handlerConcurrency, t.queued)
 	t.handlers = append(t.handlers, hdlr)
 }
 
@@ -314,24 +423,71 @@ func (t *Topic) close() {
 	vars.DeleteStatistic(t.statsKey)
 }
 
-func (t *Topic) collect(event Event) error {
+// collect records the event state and delivers the event to all handlers,
+// unless the event has been silenced.
+func (t *Topic) collect(event Event, silenced bool) error {
 	prev, ok := t.updateEvent(event.State)
 	if ok {
 		event.previousState = prev
 	}
 
 	t.collected.Add(1)
+	if silenced {
+		t.silenced.Add(1)
+		return nil
+	}
 	return t.handleEvent(event)
 }
 
 func (t *Topic) handleEvent(event Event) error {
+	t.mu.RLock()
+	journal := t.journal
+	t.mu.RUnlock()
+	if journal == nil {
+		return t.deliver(event, nil, 0)
+	}
+	id, err := journal.append(event)
+	if err != nil {
+		// Deliver the event regardless, it is not redelivered after a restart.
+		if derr := t.deliver(event, nil, 0); derr != nil {
+			return multiError{err, derr}
+		}
+		return err
+	}
+	return t.deliver(event, journal, id)
+}
+
+// deliver passes the event to all handlers.
+// If a journal is given, the event is marked delivered once all handlers have delivered it,
+// otherwise it remains pending and is redelivered after a restart.
+func (t *Topic) deliver(event Event, journal *Journal, id uint64) error {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	// Handle event
 	var errs multiError
+	var done func(delivered bool)
+	if journal != nil {
+		remaining := int32(len(t.handlers))
+		var failed int32
+		done = func(delivered bool) {
+			if !delivered {
+				atomic.StoreInt32(&failed, 1)
+			}
+			if atomic.AddInt32(&remaining, -1) == 0 && atomic.LoadInt32(&failed) == 0 {
+				// A failure to record the delivery results in the event being redelivered after a restart.
+				journal.delivered(id)
+			}
+		}
+		if remaining == 0 {
+			if err := journal.delivered(id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// Handle event
 	for _, h := range t.handlers {
-		err := h.Handle(event)
+		err := h.handle(event, done)
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -347,6 +503,11 @@ func (t *Topic) Collected() int64 {
 	return t.collected.IntValue()
 }
 
+// Queued returns the number of events waiting to be handled by the handlers of the topic.
+func (t *Topic) Queued() int64 {
+	return t.queued.IntValue()
+}
+
 // updateEvent will store the latest state for the given ID.
 func (t *Topic) updateEvent(state EventState) (EventState, bool) {
 	var hasPrev, needSort bool
@@ -384,27 +545,45 @@ func (e sortedStates) Less(i int, j int) bool {
 }
 
 // bufHandler wraps a Handler implementation in order to provide buffering and non-blocking event handling.
+// Events are handled by a number of workers, the events with the same ID are always handled by the same worker.
 type bufHandler struct {
 	h        Handler
-	events   chan Event
+	workers  []chan delivery
 	aborting chan struct{}
+	queued   *expvar.Int
 	wg       sync.WaitGroup
 }
 
-func newHandler(h Handler, bufferSize int) *bufHandler {
+func newHandler(h Handler, bufferSize, concurrency int, queued *expvar.Int) *bufHandler {
 	if bufferSize < MinimumEventBufferSize {
 		bufferSize = DefaultEventBufferSize
 	}
+	if concurrency < 1 {
+		concurrency = DefaultHandlerConcurrency
+	}
+	if queued == nil {
+		queued = new(expvar.Int)
+	}
+	// The workers share the buffer.
+	workerBufferSize := bufferSize / concurrency
+	if workerBufferSize < 1 {
+		workerBufferSize = 1
+	}
 	hdlr := &bufHandler{
 		h:        h,
-		events:   make(chan Event, bufferSize),
+		workers:  make([]chan delivery, concurrency),
 		aborting: make(chan struct{}),
+		queued:   queued,
+	}
+	for i := range hdlr.workers {
+		events := make(chan delivery, workerBufferSize)
+		hdlr.workers[i] = events
+		hdlr.wg.Add(1)
+		go func() {
+			defer hdlr.wg.Done()
+			hdlr.run(events)
+		}()
 	}
-	hdlr.wg.Add(1)
-	go func() {
-		defer hdlr.wg.Done()
-		hdlr.run()
-	}()
 	return hdlr
 }
 
@@ -421,32 +600,69 @@ func (h *bufHandler) Equal(o Handler) (b bool) {
 }
 
 func (h *bufHandler) Close() {
-	close(h.events)
+	for _, events := range h.workers {
+		close(events)
+	}
 	h.wg.Wait()
 }
 
 func (h *bufHandler) Abort() {
 	close(h.aborting)
 	h.wg.Wait()
+	// The remaining events are never handled.
+	for _, events := range h.workers {
+		h.queued.Add(-int64(len(events)))
+	}
+}
+
+// delivery is an event along with a function to call once it has been handled, if any.
+// The function is passed whether the event was delivered.
+type delivery struct {
+	event Event
+	done  func(delivered bool)
 }
 
 func (h *bufHandler) Handle(event Event) error {
+	return h.handle(event, nil)
+}
+
+// worker returns the events of the worker handling the event.
+func (h *bufHandler) worker(event Event) chan delivery {
+	if len(h.workers) == 1 {
+		return h.workers[0]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(event.State.ID))
+	return h.workers[hash.Sum32()%uint32(len(h.workers))]
+}
+
+func (h *bufHandler) handle(event Event, done func(delivered bool)) error {
+	h.queued.Add(1)
 	select {
-	case h.events <- event:
+	case h.worker(event) <- delivery{event: event, done: done}:
 		return nil
 	default:
+		h.queued.Add(-1)
+		// The event is dropped, it remains pending delivery.
+		if done != nil {
+			done(false)
+		}
 		return fmt.Errorf("failed to deliver event %q to handler", event.State.ID)
 	}
 }
 
-func (h *bufHandler) run() {
+func (h *bufHandler) run(events <-chan delivery) {
 	for {
 		select {
-		case event, ok := <-h.events:
+		case d, ok := <-events:
 			if !ok {
 				return
 			}
-			h.h.Handle(event)
+			h.queued.Add(-1)
+			err := Deliver(h.h, d.event)
+			if d.done != nil {
+				d.done(err == nil)
+			}
 		case <-h.aborting:
 			return
 		}
@@ -0,0 +1,111 @@
+package alert_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// waitFor polls the condition until it holds or a timeout.
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for", msg)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// slowHandler blocks handling the events with the slow ID until it is released.
+type slowHandler struct {
+	recordingHandler
+	slow    string
+	release chan struct{}
+}
+
+func (h *slowHandler) Handle(event alert.Event) {
+	if event.State.ID == h.slow {
+		<-h.release
+	}
+	h.recordingHandler.Handle(event)
+}
+
+func TestTopics_HandlerConcurrency(t *testing.T) {
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetHandlerConcurrency(1, map[string]int{"concurrent": 16})
+	defer topics.Close()
+
+	serial := &slowHandler{slow: "slow", release: make(chan struct{})}
+	concurrent := &slowHandler{slow: "slow", release: make(chan struct{})}
+	topics.RegisterHandler("serial", serial)
+	topics.RegisterHandler("concurrent", concurrent)
+
+	for _, topic := range []string{"serial", "concurrent"} {
+		for i := 0; i < 10; i++ {
+			id := "slow"
+			if i > 0 {
+				id = fmt.Sprintf("fast%d", i)
+			}
+			event := newJournalEvent(id, alert.Critical)
+			event.Topic = topic
+			if err := topics.Collect(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Some of the events with other IDs do not wait for the slow event.
+	waitFor(t, "concurrent events", func() bool { return len(concurrent.IDs()) > 0 })
+	if ids := serial.IDs(); len(ids) != 0 {
+		t.Errorf("unexpected events handled before the slow event %v", ids)
+	}
+	topic, ok := topics.Topic("serial")
+	if !ok {
+		t.Fatal("missing topic")
+	}
+	// The slow event is being handled, the others wait for it.
+	waitFor(t, "queued events", func() bool { return topic.Queued() == 9 })
+
+	close(serial.release)
+	close(concurrent.release)
+	waitFor(t, "all events", func() bool { return len(serial.IDs()) == 10 && len(concurrent.IDs()) == 10 })
+	if got := topic.Queued(); got != 0 {
+		t.Errorf("unexpected queued events got %d exp 0", got)
+	}
+}
+
+func TestTopics_HandlerConcurrencyOrder(t *testing.T) {
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetHandlerConcurrency(8, nil)
+	defer topics.Close()
+
+	h := &recordingHandler{}
+	topics.RegisterHandler("topic", h)
+	const count = 100
+	for i := 0; i < count; i++ {
+		event := newJournalEvent(fmt.Sprintf("id%d", i%4), alert.Critical)
+		event.State.Message = fmt.Sprint(i)
+		if err := topics.Collect(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "all events", func() bool { return len(h.IDs()) == count })
+
+	// Events with the same ID are handled in order.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	last := make(map[string]int)
+	for _, e := range h.events {
+		var i int
+		fmt.Sscan(e.State.Message, &i)
+		if prev, ok := last[e.State.ID]; ok && prev > i {
+			t.Errorf("event %d of %s handled after event %d", i, e.State.ID, prev)
+		}
+		last[e.State.ID] = i
+	}
+}
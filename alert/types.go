@@ -1,8 +1,8 @@
 package alert
 
 import (
-	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -22,10 +22,13 @@ func (e Event) AlertData() Data {
 		ID:            e.State.ID,
 		Message:       e.State.Message,
 		Details:       e.State.Details,
+		Runbook:       e.State.Runbook,
+		Fingerprint:   e.State.Fingerprint,
 		Time:          e.State.Time,
 		Duration:      e.State.Duration,
 		Level:         e.State.Level,
 		Data:          e.Data.Result,
+		Recent:        e.Data.Recent,
 		PreviousLevel: e.previousState.Level,
 		Recoverable:   e.Data.Recoverable,
 	}
@@ -37,17 +40,20 @@ func (e Event) PreviousState() EventState {
 
 func (e Event) TemplateData() TemplateData {
 	return TemplateData{
-		ID:       e.State.ID,
-		Message:  e.State.Message,
-		Level:    e.State.Level.String(),
-		Time:     e.State.Time,
-		Duration: e.State.Duration,
-		Details:  e.State.Details,
-		Name:     e.Data.Name,
-		TaskName: e.Data.TaskName,
-		Group:    e.Data.Group,
-		Tags:     e.Data.Tags,
-		Fields:   e.Data.Fields,
+		ID:          e.State.ID,
+		Message:     e.State.Message,
+		Level:       e.State.Level.String(),
+		Time:        e.State.Time,
+		Duration:    e.State.Duration,
+		Details:     e.State.Details,
+		Runbook:     e.State.Runbook,
+		Fingerprint: e.State.Fingerprint,
+		Name:        e.Data.Name,
+		TaskName:    e.Data.TaskName,
+		Group:       e.Data.Group,
+		Tags:        e.Data.Tags,
+		Fields:      e.Data.Fields,
+		Recent:      e.Data.Recent,
 	}
 }
 
@@ -56,13 +62,34 @@ type Handler interface {
 	Handle(event Event)
 }
 
+// DeliveryHandler is implemented by handlers that report whether they delivered an event.
+// A journaled event is only marked delivered once every handler of its topic delivered it,
+// handlers that do not implement DeliveryHandler are assumed to deliver every event they handle.
+type DeliveryHandler interface {
+	Handler
+	// Deliver takes action on the event and returns an error if the event was not delivered.
+	Deliver(event Event) error
+}
+
+// Deliver passes the event to the handler and returns an error if the handler reports the event was not delivered.
+func Deliver(h Handler, event Event) error {
+	if d, ok := h.(DeliveryHandler); ok {
+		return d.Deliver(event)
+	}
+	h.Handle(event)
+	return nil
+}
+
 type EventState struct {
 	ID       string
 	Message  string
 	Details  string
+	Runbook  string
 	Time     time.Time
 	Duration time.Duration
 	Level    Level
+	// Fingerprint of the alert, see Fingerprint.
+	Fingerprint string
 }
 
 type EventData struct {
@@ -88,6 +115,9 @@ type EventData struct {
 	Recoverable bool
 
 	Result models.Result
+
+	// The most recent points of the group, nil unless attached by the alert.
+	Recent *models.Row
 }
 
 // TemplateData is a structure containing all information available to use in templates for an Event.
@@ -98,7 +128,7 @@ type TemplateData struct {
 	// The Message of the Alert
 	Message string
 
-	// Alert Level, one of: INFO, WARNING, CRITICAL.
+	// Alert Level, one of: OK, INFO, WARNING, CRITICAL or a custom level.
 	Level string
 
 	// Time the event occurred.
@@ -110,6 +140,12 @@ type TemplateData struct {
 	// Details
 	Details string
 
+	// URL of the runbook for the alert
+	Runbook string
+
+	// Stable fingerprint of the alert for deduplication by external systems.
+	Fingerprint string
+
 	// Measurement name
 	Name string
 
@@ -125,6 +161,9 @@ type TemplateData struct {
 
 	// Fields of alerting data point.
 	Fields map[string]interface{}
+
+	// The most recent points of the group, nil unless attached by the alert.
+	Recent *models.Row `json:",omitempty"`
 }
 
 type Level int
@@ -137,15 +176,68 @@ const (
 	maxLevel
 )
 
-const levelStrings = "OKINFOWARNINGCRITICAL"
+// MaxCustomLevels is the maximum number of custom levels.
+const MaxCustomLevels = 16
+
+var builtinLevelNames = []string{"OK", "INFO", "WARNING", "CRITICAL"}
+
+// levelNames are the names of the levels in increasing order of severity,
+// the built-in levels followed by the custom levels.
+var levelNames = builtinLevelNames
+
+var customLevelNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// ValidateCustomLevels checks that the names are valid names of custom levels.
+func ValidateCustomLevels(names []string) error {
+	if len(names) > MaxCustomLevels {
+		return fmt.Errorf("at most %d custom levels can be defined, got %d", MaxCustomLevels, len(names))
+	}
+	seen := make(map[string]bool, len(builtinLevelNames)+len(names))
+	for _, name := range builtinLevelNames {
+		seen[name] = true
+	}
+	for _, name := range names {
+		if !customLevelNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid custom level %q, must be upper case letters, digits and underscores starting with a letter", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("custom level %q is defined more than once or is a built-in level", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
 
-var levelBytes = []byte(levelStrings)
+// SetCustomLevels defines the custom levels in increasing order of severity.
+// Custom levels can only be above CRITICAL: the first custom level is more severe than CRITICAL,
+// there can be no custom level between the built-in levels.
+// The custom levels replace any previously defined custom levels,
+// they must be set before any alert is created.
+func SetCustomLevels(names []string) error {
+	if err := ValidateCustomLevels(names); err != nil {
+		return err
+	}
+	levelNames = append(builtinLevelNames[:maxLevel:maxLevel], names...)
+	return nil
+}
+
+// MaxLevel returns the most severe level, CRITICAL unless custom levels are defined.
+func MaxLevel() Level {
+	return Level(len(levelNames) - 1)
+}
 
-var levelOffsets = []int{0, 2, 6, 13, 21}
+// Builtin returns the built-in level of the level.
+// The custom levels are all more severe than CRITICAL, so handlers with a fixed set of severities treat them as CRITICAL.
+func (l Level) Builtin() Level {
+	if l > Critical {
+		return Critical
+	}
+	return l
+}
 
 func (l Level) String() string {
-	if l < maxLevel {
-		return levelStrings[levelOffsets[l]:levelOffsets[l+1]]
+	if l >= OK && int(l) < len(levelNames) {
+		return levelNames[l]
 	}
 	return "unknown"
 }
@@ -155,16 +247,12 @@ func (l Level) MarshalText() ([]byte, error) {
 }
 
 func (l *Level) UnmarshalText(text []byte) error {
-	idx := bytes.Index(levelBytes, text)
-	if idx >= 0 {
-		for i := 0; i < int(maxLevel); i++ {
-			if idx == levelOffsets[i] {
-				*l = Level(i)
-				return nil
-			}
+	for i, name := range levelNames {
+		if string(text) == name {
+			*l = Level(i)
+			return nil
 		}
 	}
-
 	return fmt.Errorf("unknown alert level '%s'", text)
 }
 
@@ -184,10 +272,13 @@ type Data struct {
 	ID            string        `json:"id"`
 	Message       string        `json:"message"`
 	Details       string        `json:"details"`
+	Runbook       string        `json:"runbook,omitempty"`
+	Fingerprint   string        `json:"fingerprint"`
 	Time          time.Time     `json:"time"`
 	Duration      time.Duration `json:"duration"`
 	Level         Level         `json:"level"`
 	Data          models.Result `json:"data"`
+	Recent        *models.Row   `json:"recent,omitempty"`
 	PreviousLevel Level         `json:"previousLevel"`
 	Recoverable   bool          `json:"recoverable"`
 }
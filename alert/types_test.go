@@ -0,0 +1,74 @@
+package alert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+func TestCustomLevels(t *testing.T) {
+	if err := alert.SetCustomLevels([]string{"FATAL", "DOOM"}); err != nil {
+		t.Fatal(err)
+	}
+	defer alert.SetCustomLevels(nil)
+
+	if got, exp := alert.MaxLevel(), alert.Critical+2; got != exp {
+		t.Errorf("unexpected max level got %v exp %v", got, exp)
+	}
+	for i, name := range []string{"OK", "INFO", "WARNING", "CRITICAL", "FATAL", "DOOM"} {
+		l, err := alert.ParseLevel(strings.ToLower(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l != alert.Level(i) {
+			t.Errorf("unexpected level for %s got %d exp %d", name, l, i)
+		}
+		if got := l.String(); got != name {
+			t.Errorf("unexpected level name got %s exp %s", got, name)
+		}
+	}
+	doom, _ := alert.ParseLevel("DOOM")
+	if got := doom.Builtin(); got != alert.Critical {
+		t.Errorf("unexpected builtin level got %v exp %v", got, alert.Critical)
+	}
+	if got := alert.Warning.Builtin(); got != alert.Warning {
+		t.Errorf("unexpected builtin level got %v exp %v", got, alert.Warning)
+	}
+
+	if err := alert.SetCustomLevels(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alert.ParseLevel("FATAL"); err == nil {
+		t.Error("expected FATAL to be unknown once the custom levels are removed")
+	}
+	if got := doom.String(); got != "unknown" {
+		t.Errorf("unexpected level name got %s exp unknown", got)
+	}
+}
+
+func TestValidateCustomLevels(t *testing.T) {
+	testCases := []struct {
+		names []string
+		err   string
+	}{
+		{names: []string{"FATAL", "EMERGENCY_2"}},
+		{names: []string{"fatal"}, err: `invalid custom level "fatal"`},
+		{names: []string{"2FATAL"}, err: `invalid custom level "2FATAL"`},
+		{names: []string{"CRITICAL"}, err: `custom level "CRITICAL" is defined more than once or is a built-in level`},
+		{names: []string{"FATAL", "FATAL"}, err: `custom level "FATAL" is defined more than once or is a built-in level`},
+		{names: make([]string, alert.MaxCustomLevels+1), err: "at most 16 custom levels"},
+	}
+	for _, tc := range testCases {
+		err := alert.ValidateCustomLevels(tc.names)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("unexpected error for %v: %v", tc.names, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("unexpected error for %v got %v exp %q", tc.names, err, tc.err)
+		}
+	}
+}
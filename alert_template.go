@@ -0,0 +1,154 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// alertTemplateFuncs are the helper functions available in the alert id, message and details templates.
+// All functions render nil or missing values as an empty string.
+var alertTemplateFuncs = map[string]interface{}{
+	"humanizeBytes":    humanizeBytes,
+	"humanizeDuration": humanizeDuration,
+	"humanizeNumber":   humanizeNumber,
+	"formatTime":       formatTime,
+}
+
+var (
+	byteUnits   = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	numberUnits = []string{"", "k", "M", "G", "T", "P", "E"}
+)
+
+// humanizeBytes formats a number of bytes using SI units, i.e. 1500000000 is 1.5GB.
+func humanizeBytes(v interface{}) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return toString(v)
+	}
+	return humanizeSI(f, byteUnits)
+}
+
+// humanizeNumber formats a number using SI suffixes, i.e. 1500 is 1.5k.
+func humanizeNumber(v interface{}) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return toString(v)
+	}
+	return humanizeSI(f, numberUnits)
+}
+
+func humanizeSI(f float64, units []string) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64) + units[0]
+	}
+	i := 0
+	for math.Abs(f) >= 1000 && i < len(units)-1 {
+		f /= 1000
+		i++
+	}
+	return formatFloat(f) + units[i]
+}
+
+// humanizeDuration formats a duration as days, hours, minutes and seconds, i.e. 1d 2h 3m 4s.
+// Numbers are interpreted as a number of seconds.
+func humanizeDuration(v interface{}) string {
+	var d time.Duration
+	switch value := v.(type) {
+	case time.Duration:
+		d = value
+	default:
+		f, ok := toFloat(v)
+		if !ok {
+			return toString(v)
+		}
+		d = time.Duration(f * float64(time.Second))
+	}
+	if d == 0 {
+		return "0s"
+	}
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d < time.Second {
+		return sign + formatFloat(float64(d)/float64(time.Millisecond)) + "ms"
+	}
+	parts := make([]string, 0, 4)
+	for _, u := range []struct {
+		d    time.Duration
+		unit string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+	} {
+		if d >= u.d {
+			parts = append(parts, strconv.FormatInt(int64(d/u.d), 10)+u.unit)
+			d %= u.d
+		}
+	}
+	if d > 0 {
+		parts = append(parts, formatFloat(d.Seconds())+"s")
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// formatTime formats a time using the Go reference time layout, i.e. "2006-01-02 15:04:05".
+// The layout is the first argument so that the function can be used in a pipeline.
+func formatTime(layout string, v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.Format(layout)
+	}
+	return toString(v)
+}
+
+// formatFloat formats f with at most two decimal places.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case uint:
+		return float64(value), true
+	case uint32:
+		return float64(value), true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
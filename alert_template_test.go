@@ -0,0 +1,56 @@
+package kapacitor
+
+import (
+	"bytes"
+	"testing"
+	text "text/template"
+	"time"
+)
+
+func TestAlertTemplateFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"Fields": map[string]interface{}{
+			"used":  1.5e9,
+			"total": int64(2e9),
+			"count": 1234.0,
+			"str":   "a",
+			"nil":   nil,
+		},
+		"Time":     time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+		"Duration": 26*time.Hour + 3*time.Minute + 4*time.Second,
+	}
+	testCases := []struct {
+		tmpl string
+		exp  string
+	}{
+		{tmpl: `{{ index .Fields "used" | humanizeBytes }} of {{ index .Fields "total" | humanizeBytes }}`, exp: "1.5GB of 2GB"},
+		{tmpl: `{{ humanizeBytes 512 }}`, exp: "512B"},
+		{tmpl: `{{ index .Fields "count" | humanizeNumber }}`, exp: "1.23k"},
+		{tmpl: `{{ humanizeNumber -2500000 }}`, exp: "-2.5M"},
+		{tmpl: `{{ humanizeNumber 0.125 }}`, exp: "0.13"},
+		{tmpl: `{{ humanizeDuration .Duration }}`, exp: "1d 2h 3m 4s"},
+		{tmpl: `{{ humanizeDuration 90.5 }}`, exp: "1m 30.5s"},
+		{tmpl: `{{ humanizeDuration 0.25 }}`, exp: "250ms"},
+		{tmpl: `{{ humanizeDuration 0 }}`, exp: "0s"},
+		{tmpl: `{{ .Time | formatTime "2006-01-02 15:04" }}`, exp: "2017-01-02 03:04"},
+		{tmpl: `{{ index .Fields "str" | humanizeBytes }}`, exp: "a"},
+		// Nil and missing values render as empty strings
+		{tmpl: `[{{ index .Fields "nil" | humanizeBytes }}]`, exp: "[]"},
+		{tmpl: `[{{ index .Fields "missing" | humanizeNumber }}]`, exp: "[]"},
+		{tmpl: `[{{ index .Fields "missing" | humanizeDuration }}]`, exp: "[]"},
+		{tmpl: `[{{ index .Fields "missing" | formatTime "2006" }}]`, exp: "[]"},
+	}
+	for _, tc := range testCases {
+		tmpl, err := text.New("test").Funcs(alertTemplateFuncs).Parse(tc.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, data); err != nil {
+			t.Fatalf("%s: %v", tc.tmpl, err)
+		}
+		if got := buf.String(); got != tc.exp {
+			t.Errorf("%s: unexpected result got %q exp %q", tc.tmpl, got, tc.exp)
+		}
+	}
+}
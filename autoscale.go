@@ -1,7 +1,11 @@
 package kapacitor
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
@@ -186,7 +190,9 @@ func (n *AutoscaleNode) handlePoint(streamName string, dims models.Dimensions, p
 	state, ok := n.resourceStates[id.ID()]
 	if !ok {
 		// If we haven't seen this resource before, get its state
+		n.timer.Pause()
 		replicas, err := n.a.Replicas(id)
+		n.timer.Resume()
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not determine initial scale for %q", id)
 		}
@@ -284,7 +290,9 @@ func (n *AutoscaleNode) handlePoint(streamName string, dims models.Dimensions, p
 
 func (n *AutoscaleNode) applyEvent(e event) error {
 	n.diag.SettingReplicas(e.New, e.Old, e.ID.ID())
+	n.timer.Pause()
 	err := n.a.SetReplicas(e.ID, e.New)
+	n.timer.Resume()
 	return errors.Wrapf(err, "failed to set new replica count for %q", e.ID)
 }
 
@@ -625,3 +633,117 @@ func (a *ec2Autoscaler) SetResourceIDOnTags(id resourceID, tags models.Tags) {
 		tags[a.outputGroupNameTag] = id.ID()
 	}
 }
+
+/////////////////////////////////////////////
+// Webhook implementation of Autoscaler
+
+type webhookAutoscaler struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+
+	resourceName          string
+	resourceNameTag       string
+	outputResourceNameTag string
+}
+
+func newWebhookAutoscaleNode(et *ExecutingTask, n *pipeline.AutoscaleNode, d NodeDiagnostic) (*AutoscaleNode, error) {
+	outputResourceNameTag := n.OutputResourceNameTag
+	if outputResourceNameTag == "" {
+		outputResourceNameTag = n.ResourceNameTag
+	}
+	a := &webhookAutoscaler{
+		client: &http.Client{
+			Timeout: n.Timeout,
+		},
+		url:     n.URL,
+		headers: n.Headers,
+
+		resourceName:          n.ResourceName,
+		resourceNameTag:       n.ResourceNameTag,
+		outputResourceNameTag: outputResourceNameTag,
+	}
+	return newAutoscaleNode(
+		et,
+		d,
+		n,
+		a,
+		int(n.Min),
+		int(n.Max),
+		n.IncreaseCooldown,
+		n.DecreaseCooldown,
+		n.CurrentField,
+		n.Replicas,
+	)
+}
+
+type webhookResourceID string
+
+func (id webhookResourceID) ID() string {
+	return string(id)
+}
+
+func (a *webhookAutoscaler) ResourceIDFromTags(tags models.Tags) (resourceID, error) {
+	// Get the name of the resource
+	var name string
+	switch {
+	case a.resourceName != "":
+		name = a.resourceName
+	case a.resourceNameTag != "":
+		t, ok := tags[a.resourceNameTag]
+		if ok {
+			name = t
+		}
+	default:
+		return nil, errors.New("expected one of ResourceName or ResourceNameTag to be set")
+	}
+	if name == "" {
+		return nil, errors.New("could not determine the name of the resource")
+	}
+	return webhookResourceID(name), nil
+}
+
+// Replicas always returns 0 as the webhook does not report the current number of replicas,
+// so that the first desired number of replicas of a resource is always posted.
+func (a *webhookAutoscaler) Replicas(id resourceID) (int, error) {
+	return 0, nil
+}
+
+type webhookScaleEvent struct {
+	Resource string `json:"resource"`
+	Replicas int    `json:"replicas"`
+}
+
+func (a *webhookAutoscaler) SetReplicas(id resourceID, replicas int) error {
+	body, err := json.Marshal(webhookScaleEvent{
+		Resource: id.ID(),
+		Replicas: replicas,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range a.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+func (a *webhookAutoscaler) SetResourceIDOnTags(id resourceID, tags models.Tags) {
+	if a.outputResourceNameTag != "" {
+		tags[a.outputResourceNameTag] = id.ID()
+	}
+}
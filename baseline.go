@@ -0,0 +1,157 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type BaselineNode struct {
+	node
+	b *pipeline.BaselineNode
+}
+
+// Create a new baseline node.
+func newBaselineNode(et *ExecutingTask, n *pipeline.BaselineNode, d NodeDiagnostic) (*BaselineNode, error) {
+	bn := &BaselineNode{
+		node: node{Node: n, et: et, diag: d},
+		b:    n,
+	}
+	bn.node.runF = bn.runBaseline
+	return bn, nil
+}
+
+func (n *BaselineNode) runBaseline([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *BaselineNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &baselineGroup{
+			n:       n,
+			buckets: make(map[int64][]baselineSeason),
+		}),
+	), nil
+}
+
+// baselineSeason is the sum and count of the values of a bucket in a season.
+type baselineSeason struct {
+	season int64
+	sum    float64
+	count  int64
+}
+
+func (s baselineSeason) mean() float64 {
+	return s.sum / float64(s.count)
+}
+
+// baselineGroup retains the seasons of each bucket of a group, oldest first.
+type baselineGroup struct {
+	n *BaselineNode
+
+	buckets map[int64][]baselineSeason
+}
+
+func (g *baselineGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *baselineGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return bp, nil
+}
+
+func (g *baselineGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *baselineGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	value, ok := numToFloat(p.Fields()[g.n.b.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute baseline",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", g.n.b.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.b.Field])),
+		)
+		return nil, nil
+	}
+	season, bucket := g.key(p.Time().UnixNano())
+	baseline, ok := g.add(bucket, season, value)
+	if !ok {
+		return p, nil
+	}
+
+	p = p.ShallowCopy()
+	fields := p.Fields().Copy()
+	fields[g.n.b.BaselineAs] = baseline
+	fields[g.n.b.As] = value - baseline
+	p.SetFields(fields)
+	return p, nil
+}
+
+// key returns the season of the time and its bucket within the season,
+// the seasons are counted from the Unix epoch also before it.
+func (g *baselineGroup) key(ns int64) (season, bucket int64) {
+	period := int64(g.n.b.Period)
+	season = ns / period
+	if ns%period < 0 {
+		season--
+	}
+	bucket = (ns - season*period) / int64(g.n.b.Bucket)
+	return season, bucket
+}
+
+// add adds the value to the season of the bucket, drops the seasons older than the trailing seasons,
+// and returns the baseline, the mean of the means of the trailing seasons before the season.
+// It reports false if none of the trailing seasons has values.
+func (g *baselineGroup) add(bucket, season int64, value float64) (float64, bool) {
+	seasons := g.buckets[bucket]
+	first := season - g.n.b.Seasons
+	i := 0
+	for i < len(seasons) && seasons[i].season < first {
+		i++
+	}
+	seasons = seasons[i:]
+
+	var sum float64
+	count := 0
+	// j is the index of the season, or where it is inserted if it has no values yet.
+	j := sort.Search(len(seasons), func(k int) bool { return seasons[k].season >= season })
+	for _, s := range seasons[:j] {
+		sum += s.mean()
+		count++
+	}
+	if j < len(seasons) && seasons[j].season == season {
+		seasons[j].sum += value
+		seasons[j].count++
+	} else {
+		seasons = append(seasons, baselineSeason{})
+		copy(seasons[j+1:], seasons[j:])
+		seasons[j] = baselineSeason{season: season, sum: value, count: 1}
+	}
+	g.buckets[bucket] = seasons
+
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+func (g *baselineGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *baselineGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (g *baselineGroup) Done() {}
@@ -2,6 +2,8 @@ package kapacitor
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -12,12 +14,18 @@ import (
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/server/vars"
 	"github.com/pkg/errors"
 )
 
 const (
-	statsBatchesQueried = "batches_queried"
-	statsPointsQueried  = "points_queried"
+	statsBatchesQueried  = "batches_queried"
+	statsPointsQueried   = "points_queried"
+	statsQueriesCanceled = "queries_canceled"
+	// statsQueryPriority is the priority of the queries of the task.
+	statsQueryPriority = "query_priority"
+	// statsQueryQueuePosition is the position in line of the query waiting for a free query slot.
+	statsQueryQueuePosition = "query_queue_position"
 )
 
 type BatchNode struct {
@@ -114,6 +122,258 @@ func (n *BatchNode) Abort() {
 	}
 }
 
+// CancelQueries cancels the queries currently executing,
+// returning the number of queries that were canceled.
+func (n *BatchNode) CancelQueries() int {
+	count := 0
+	for _, b := range n.children {
+		switch b := b.(type) {
+		case *QueryNode:
+			count += b.CancelQueries()
+		case *FluxQueryNode:
+			count += b.CancelQueries()
+		default:
+			panic("BatchNode shouldn't be followed by anything except QueryNode or QueryFluxNode")
+		}
+	}
+	return count
+}
+
+// runningQueries tracks the queries being executed so that they can be canceled.
+type runningQueries struct {
+	mu      sync.Mutex
+	next    int
+	cancels map[int]context.CancelFunc
+
+	canceled *expvar.Int
+	// queuePosition is the position in line of the query waiting for a free query slot, 0 if none waits.
+	queuePosition *expvar.Int
+}
+
+func newRunningQueries() *runningQueries {
+	return &runningQueries{
+		cancels:       make(map[int]context.CancelFunc),
+		canceled:      &expvar.Int{},
+		queuePosition: &expvar.Int{},
+	}
+}
+
+// start returns the context of a new query and a function to call once the query has finished.
+func (r *runningQueries) start() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.next
+	r.next++
+	r.cancels[id] = cancel
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, id)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels all running queries and returns the number of queries canceled.
+func (r *runningQueries) cancel() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := len(r.cancels)
+	for id, cancel := range r.cancels {
+		cancel()
+		delete(r.cancels, id)
+	}
+	return count
+}
+
+// failed reports the error of a query, queries that were canceled are not reported as errors.
+func (r *runningQueries) failed(ctx context.Context, diag NodeDiagnostic, q string, err error) {
+	if ctx.Err() == context.Canceled {
+		r.canceled.Add(1)
+		diag.CanceledBatchQuery(q)
+		return
+	}
+	if err == errBatchQueryStopped {
+		return
+	}
+	diag.Error("error executing query", err)
+}
+
+// errBatchQueryStopped is returned when a node stops while its query waits for a free slot.
+var errBatchQueryStopped = errors.New("stopped while waiting to execute batch query")
+
+// DefaultBatchQueryPriorityAging is the default time a query waits for its priority to increase by one.
+const DefaultBatchQueryPriorityAging = 10 * time.Second
+
+// BatchQueryLimiter limits the number of batch queries executing at once across all tasks.
+// Queries wait in line for a free slot once the limit is reached.
+// A nil BatchQueryLimiter does not limit queries.
+//
+// Free slots go to the waiting query of the highest priority, the query waiting the longest among equal priorities.
+// The priority of a waiting query increases by one every aging interval,
+// so that queries of low priority tasks eventually run even while queries of higher priority tasks keep coming.
+type BatchQueryLimiter struct {
+	max   int
+	aging time.Duration
+
+	mu      sync.Mutex
+	running int
+	waiting []*batchQueryWaiter
+}
+
+// batchQueryWaiter is a query waiting for a free slot.
+type batchQueryWaiter struct {
+	priority int64
+	enqueued time.Time
+	// position in line of the query, updated as queries join and leave the line.
+	position *expvar.Int
+	// ready is closed once the query is given a slot.
+	ready chan struct{}
+}
+
+// NewBatchQueryLimiter returns a limiter of at most max concurrent queries, no limit if max is zero.
+// Waiting queries gain one priority every aging interval, no aging if aging is zero.
+func NewBatchQueryLimiter(max int, aging time.Duration) *BatchQueryLimiter {
+	return &BatchQueryLimiter{
+		max:   max,
+		aging: aging,
+	}
+}
+
+// do executes the query f once a slot is free, giving slots to queries of higher priority first.
+// The position in line of the query is reported in position while it waits, if not nil.
+// It gives up waiting if the context is canceled or closing is closed.
+func (l *BatchQueryLimiter) do(ctx context.Context, closing <-chan struct{}, priority int64, position *expvar.Int, f func() (*influxdb.Response, error)) (*influxdb.Response, error) {
+	if l == nil {
+		return f()
+	}
+	if l.max > 0 {
+		if err := l.acquire(ctx, closing, priority, position); err != nil {
+			return nil, err
+		}
+		defer l.release()
+	}
+	vars.BatchQueriesRunningVar.Add(1)
+	defer vars.BatchQueriesRunningVar.Add(-1)
+	return f()
+}
+
+func (l *BatchQueryLimiter) acquire(ctx context.Context, closing <-chan struct{}, priority int64, position *expvar.Int) error {
+	l.mu.Lock()
+	if l.running < l.max && len(l.waiting) == 0 {
+		l.running++
+		l.mu.Unlock()
+		return nil
+	}
+	w := &batchQueryWaiter{
+		priority: priority,
+		enqueued: time.Now(),
+		position: position,
+		ready:    make(chan struct{}),
+	}
+	l.waiting = append(l.waiting, w)
+	vars.BatchQueriesQueuedVar.Add(1)
+	l.updatePositions(w.enqueued)
+	l.mu.Unlock()
+
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-closing:
+		err = errBatchQueryStopped
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.ready:
+		// The query was given a slot while giving up, pass it on.
+		l.running--
+		l.grant()
+	default:
+		l.remove(w)
+	}
+	return err
+}
+
+func (l *BatchQueryLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	l.grant()
+}
+
+// grant gives the free slots to the waiting queries of the highest priority.
+// The caller must hold the lock.
+func (l *BatchQueryLimiter) grant() {
+	now := time.Now()
+	for l.running < l.max && len(l.waiting) > 0 {
+		best := 0
+		for i, w := range l.waiting[1:] {
+			if l.before(w, l.waiting[best], now) {
+				best = i + 1
+			}
+		}
+		w := l.waiting[best]
+		l.remove(w)
+		l.running++
+		close(w.ready)
+	}
+	l.updatePositions(now)
+}
+
+// remove removes the waiting query from the line. The caller must hold the lock.
+func (l *BatchQueryLimiter) remove(w *batchQueryWaiter) {
+	for i, other := range l.waiting {
+		if other == w {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			vars.BatchQueriesQueuedVar.Add(-1)
+			if w.position != nil {
+				w.position.Set(0)
+			}
+			l.updatePositions(time.Now())
+			return
+		}
+	}
+}
+
+// updatePositions reports the positions in line of the waiting queries, starting at 1.
+// The caller must hold the lock.
+func (l *BatchQueryLimiter) updatePositions(now time.Time) {
+	for _, w := range l.waiting {
+		if w.position == nil {
+			continue
+		}
+		position := int64(1)
+		for _, other := range l.waiting {
+			if other != w && l.before(other, w, now) {
+				position++
+			}
+		}
+		w.position.Set(position)
+	}
+}
+
+// before reports whether the query a gets a slot before the query b.
+func (l *BatchQueryLimiter) before(a, b *batchQueryWaiter, now time.Time) bool {
+	pa, pb := l.effectivePriority(a, now), l.effectivePriority(b, now)
+	if pa != pb {
+		return pa > pb
+	}
+	return a.enqueued.Before(b.enqueued)
+}
+
+// effectivePriority is the priority of the query increased by the aging of the time it has waited.
+func (l *BatchQueryLimiter) effectivePriority(w *batchQueryWaiter, now time.Time) int64 {
+	if l.aging <= 0 {
+		return w.priority
+	}
+	return w.priority + int64(now.Sub(w.enqueued)/l.aging)
+}
+
 type BatchQueries struct {
 	Queries            []*Query
 	FluxQueries        []*QueryFlux
@@ -178,6 +438,16 @@ type QueryNode struct {
 	batchesQueried *expvar.Int
 	pointsQueried  *expvar.Int
 	byName         bool
+	running        *runningQueries
+
+	// Time of the tick of the last successful query, saved in snapshots for backfill.
+	lastTickMu sync.Mutex
+	lastTick   time.Time
+}
+
+// querySnapshot is the snapshot of a QueryNode.
+type querySnapshot struct {
+	LastTick time.Time `json:"lastTick"`
 }
 
 func newQueryNode(et *ExecutingTask, n *pipeline.QueryNode, d NodeDiagnostic) (*QueryNode, error) {
@@ -187,6 +457,7 @@ func newQueryNode(et *ExecutingTask, n *pipeline.QueryNode, d NodeDiagnostic) (*
 		closing:  make(chan struct{}),
 		aborting: make(chan struct{}),
 		byName:   n.GroupByMeasurementFlag,
+		running:  newRunningQueries(),
 	}
 	bn.node.runF = bn.runBatch
 	bn.node.stopF = bn.stopBatch
@@ -270,11 +541,23 @@ func (n *QueryNode) Abort() {
 	close(n.aborting)
 }
 
+// CancelQueries cancels the queries currently executing,
+// returning the number of queries that were canceled.
+func (n *QueryNode) CancelQueries() int {
+	return n.running.cancel()
+}
+
 func (n *QueryNode) Cluster() string {
 	return n.b.Cluster
 }
 
 func (n *QueryNode) Queries(start, stop time.Time) ([]*Query, error) {
+	queries, _, err := n.queries(start, stop)
+	return queries, err
+}
+
+// queries returns the queries of the ticks of the schedule after start until stop, along with their ticks.
+func (n *QueryNode) queries(start, stop time.Time) ([]*Query, []time.Time, error) {
 	now := time.Now()
 	if stop.IsZero() {
 		stop = now
@@ -283,6 +566,7 @@ func (n *QueryNode) Queries(start, stop time.Time) ([]*Query, error) {
 	// Make sure we are using local time.
 	current := start.Local()
 	queries := make([]*Query, 0)
+	var ticks []time.Time
 	for {
 		current = n.ticker.Next(current)
 		if current.IsZero() || current.After(stop) {
@@ -295,13 +579,49 @@ func (n *QueryNode) Queries(start, stop time.Time) ([]*Query, error) {
 
 		q, err := n.query.Clone()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		q.SetStartTime(qstop.Add(-1 * n.b.Period))
 		q.SetStopTime(qstop)
 		queries = append(queries, q)
+		ticks = append(ticks, current)
+	}
+	return queries, ticks, nil
+}
+
+func (n *QueryNode) start(snapshot []byte) {
+	// Restore before the queries start, the node is started before the batch source.
+	if len(snapshot) > 0 {
+		var qs querySnapshot
+		if err := json.Unmarshal(snapshot, &qs); err != nil {
+			n.diag.Error("failed to restore query snapshot", err)
+		} else {
+			n.setLastTick(qs.LastTick)
+		}
+	}
+	n.node.start(snapshot)
+}
+
+func (n *QueryNode) snapshot() ([]byte, error) {
+	last := n.getLastTick()
+	if last.IsZero() {
+		return nil, nil
+	}
+	return json.Marshal(querySnapshot{LastTick: last})
+}
+
+func (n *QueryNode) getLastTick() time.Time {
+	n.lastTickMu.Lock()
+	defer n.lastTickMu.Unlock()
+	return n.lastTick
+}
+
+func (n *QueryNode) setLastTick(t time.Time) {
+	n.lastTickMu.Lock()
+	defer n.lastTickMu.Unlock()
+	if t.After(n.lastTick) {
+		n.lastTick = t
 	}
-	return queries, nil
 }
 
 // Query InfluxDB and collect batches on batch collector.
@@ -312,6 +632,9 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 
 	n.statMap.Set(statsBatchesQueried, n.batchesQueried)
 	n.statMap.Set(statsPointsQueried, n.pointsQueried)
+	n.statMap.Set(statsQueriesCanceled, n.running.canceled)
+	n.statMap.Set(statsQueryPriority, expvar.NewIntFuncGauge(n.et.queryPriority))
+	n.statMap.Set(statsQueryQueuePosition, n.running.queuePosition)
 
 	if n.et.tm.InfluxDBService == nil {
 		return errors.New("InfluxDB not configured, cannot query InfluxDB for batch query")
@@ -321,6 +644,11 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to get InfluxDB client")
 	}
+	if n.b.Backfill > 0 {
+		if err := n.backfill(in, con); err != nil {
+			return err
+		}
+	}
 	tickC := n.ticker.Start()
 	for {
 		select {
@@ -339,42 +667,163 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 			n.diag.StartingBatchQuery(qStr)
 
 			// Execute query
+			ctx, done := n.running.start()
 			q := influxdb.Query{
 				Command: qStr,
+				Context: ctx,
 			}
-			resp, err := con.Query(q)
+			n.timer.Pause()
+			resp, err := n.et.tm.BatchQueryLimiter.do(ctx, n.closing, n.et.queryPriority(), n.running.queuePosition, func() (*influxdb.Response, error) {
+				return con.Query(q)
+			})
+			n.timer.Resume()
+			done()
 			if err != nil {
-				n.diag.Error("error executing query", err)
+				n.running.failed(ctx, n.diag, qStr, err)
 				n.timer.Stop()
 				break
 			}
 
-			// Collect batches
-			for _, res := range resp.Results {
-				batches, err := edge.ResultToBufferedBatches(res, n.byName)
-				if err != nil {
-					n.diag.Error("failed to understand query result", err)
-					continue
-				}
-				for _, bch := range batches {
-					// Set stop time based off query bounds
-					if bch.Begin().Time().IsZero() || !n.query.IsGroupedByTime() {
-						bch.Begin().SetTime(stop)
-					}
+			if err := n.collect(in, n.query, resp); err != nil {
+				return err
+			}
+			n.setLastTick(now)
+			n.timer.Stop()
+		}
+	}
+}
 
-					n.batchesQueried.Add(1)
-					n.pointsQueried.Add(int64(len(bch.Points())))
+// collect collects the batches of the response to the query.
+func (n *QueryNode) collect(in edge.Edge, query *Query, resp *influxdb.Response) error {
+	stop := query.StopTime()
+	for _, res := range resp.Results {
+		batches, err := edge.ResultToBufferedBatches(res, n.byName)
+		if err != nil {
+			n.diag.Error("failed to understand query result", err)
+			continue
+		}
+		for _, bch := range batches {
+			// Set stop time based off query bounds
+			if bch.Begin().Time().IsZero() || !query.IsGroupedByTime() {
+				bch.Begin().SetTime(stop)
+			}
 
-					n.timer.Pause()
-					if err := in.Collect(bch); err != nil {
-						return err
-					}
-					n.timer.Resume()
+			n.batchesQueried.Add(1)
+			n.pointsQueried.Add(int64(len(bch.Points())))
+
+			n.timer.Pause()
+			if err := in.Collect(bch); err != nil {
+				return err
+			}
+			n.timer.Resume()
+		}
+	}
+	return nil
+}
+
+type backfillResult struct {
+	resp *influxdb.Response
+	ctx  context.Context
+	err  error
+}
+
+// backfill executes the queries of the ticks missed since the last successful query,
+// going back at most Backfill, until it has caught up with the present.
+func (n *QueryNode) backfill(in edge.Edge, con influxdb.Client) error {
+	start := time.Now().Add(-1 * n.b.Backfill)
+	if last := n.getLastTick(); last.After(start) {
+		start = last
+	}
+	for {
+		select {
+		case <-n.closing:
+			return nil
+		default:
+		}
+		queries, ticks, err := n.queries(start, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(queries) == 0 {
+			return nil
+		}
+		if err := n.backfillQueries(in, con, queries, ticks); err != nil {
+			return err
+		}
+		start = ticks[len(ticks)-1]
+	}
+}
+
+// backfillQueries executes the queries, at most BackfillConcurrency at once,
+// and collects their batches in order.
+func (n *QueryNode) backfillQueries(in edge.Edge, con influxdb.Client, queries []*Query, ticks []time.Time) error {
+	concurrency := int(n.b.BackfillConcurrency)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// Slots bound the number of queries executing or waiting to be collected.
+	slots := make(chan struct{}, concurrency)
+	results := make([]chan backfillResult, len(queries))
+	for i := range results {
+		results[i] = make(chan backfillResult, 1)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var rate <-chan time.Time
+		if n.b.BackfillInterval > 0 {
+			t := time.NewTicker(n.b.BackfillInterval)
+			defer t.Stop()
+			rate = t.C
+		}
+		for i, q := range queries {
+			if i > 0 && rate != nil {
+				select {
+				case <-rate:
+				case <-done:
+					return
 				}
 			}
-			n.timer.Stop()
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			qStr := q.String()
+			n.diag.StartingBatchQuery(qStr)
+			go func(result chan<- backfillResult) {
+				ctx, done := n.running.start()
+				resp, err := n.et.tm.BatchQueryLimiter.do(ctx, n.closing, n.et.queryPriority(), n.running.queuePosition, func() (*influxdb.Response, error) {
+					return con.Query(influxdb.Query{Command: qStr, Context: ctx})
+				})
+				done()
+				result <- backfillResult{resp: resp, ctx: ctx, err: err}
+			}(results[i])
+		}
+	}()
+
+	for i, q := range queries {
+		var r backfillResult
+		select {
+		case r = <-results[i]:
+		case <-n.closing:
+			return nil
+		case <-n.aborting:
+			return errors.New("batch backfill aborted")
+		}
+		<-slots
+		if r.err != nil {
+			n.running.failed(r.ctx, n.diag, q.String(), r.err)
+			continue
 		}
+		n.timer.Start()
+		if err := n.collect(in, q, r.resp); err != nil {
+			return err
+		}
+		n.setLastTick(ticks[i])
+		n.timer.Stop()
 	}
+	return nil
 }
 
 func (n *QueryNode) runBatch([]byte) error {
@@ -387,6 +836,12 @@ func (n *QueryNode) runBatch([]byte) error {
 			}
 		}()
 		for bt, ok := n.ins[0].Emit(); ok; bt, ok = n.ins[0].Emit() {
+			if admitted, err := n.et.quota.admitMessage(bt); err != nil {
+				errC <- err
+				return
+			} else if !admitted {
+				continue
+			}
 			for _, child := range n.outs {
 				err := child.Collect(bt)
 				if err != nil {
@@ -577,6 +1032,7 @@ type FluxQueryNode struct {
 	batchesQueried *expvar.Int
 	pointsQueried  *expvar.Int
 	byName         bool
+	running        *runningQueries
 }
 
 func newQueryFluxNode(et *ExecutingTask, n *pipeline.QueryFluxNode, d NodeDiagnostic) (*FluxQueryNode, error) {
@@ -585,6 +1041,7 @@ func newQueryFluxNode(et *ExecutingTask, n *pipeline.QueryFluxNode, d NodeDiagno
 		b:        n,
 		closing:  make(chan struct{}),
 		aborting: make(chan struct{}),
+		running:  newRunningQueries(),
 	}
 	bn.node.runF = bn.runBatch
 	bn.node.stopF = bn.stopBatch
@@ -630,6 +1087,12 @@ func (n *FluxQueryNode) Abort() {
 	close(n.aborting)
 }
 
+// CancelQueries cancels the queries currently executing,
+// returning the number of queries that were canceled.
+func (n *FluxQueryNode) CancelQueries() int {
+	return n.running.cancel()
+}
+
 func (n *FluxQueryNode) Cluster() string {
 	return n.b.Cluster
 }
@@ -671,6 +1134,9 @@ func (n *FluxQueryNode) doQuery(in edge.Edge) (err error) {
 
 	n.statMap.Set(statsBatchesQueried, n.batchesQueried)
 	n.statMap.Set(statsPointsQueried, n.pointsQueried)
+	n.statMap.Set(statsQueriesCanceled, n.running.canceled)
+	n.statMap.Set(statsQueryPriority, expvar.NewIntFuncGauge(n.et.queryPriority))
+	n.statMap.Set(statsQueryQueuePosition, n.running.queuePosition)
 
 	if n.et.tm.InfluxDBService == nil {
 		return errors.New("InfluxDB not configured, cannot query InfluxDB for batch query")
@@ -694,14 +1160,21 @@ func (n *FluxQueryNode) doQuery(in edge.Edge) (err error) {
 			n.diag.StartingBatchQuery(n.query.stmt)
 
 			// Execute query
-			resp, err := con.QueryFluxResponse(influxdb.FluxQuery{
-				Query: n.query.stmt,
-				Org:   n.query.org,
-				OrgID: n.query.orgID,
-				Now:   n.query.Now,
+			ctx, done := n.running.start()
+			n.timer.Pause()
+			resp, err := n.et.tm.BatchQueryLimiter.do(ctx, n.closing, n.et.queryPriority(), n.running.queuePosition, func() (*influxdb.Response, error) {
+				return con.QueryFluxResponse(influxdb.FluxQuery{
+					Query:   n.query.stmt,
+					Org:     n.query.org,
+					OrgID:   n.query.orgID,
+					Now:     n.query.Now,
+					Context: ctx,
+				})
 			})
+			n.timer.Resume()
+			done()
 			if err != nil {
-				n.diag.Error("error executing query", err)
+				n.running.failed(ctx, n.diag, n.query.stmt, err)
 				n.timer.Stop()
 				break
 			}
@@ -742,6 +1215,12 @@ func (n *FluxQueryNode) runBatch([]byte) error {
 			}
 		}()
 		for bt, ok := n.ins[0].Emit(); ok; bt, ok = n.ins[0].Emit() {
+			if admitted, err := n.et.quota.admitMessage(bt); err != nil {
+				errC <- err
+				return
+			} else if !admitted {
+				continue
+			}
 			for _, child := range n.outs {
 				err := child.Collect(bt)
 				if err != nil {
@@ -0,0 +1,210 @@
+package kapacitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+func TestBatchQueryLimiter(t *testing.T) {
+	l := NewBatchQueryLimiter(1, 0)
+	closing := make(chan struct{})
+
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := l.do(context.Background(), closing, 0, nil, func() (*influxdb.Response, error) {
+			close(running)
+			<-finish
+			return &influxdb.Response{}, nil
+		})
+		firstDone <- err
+	}()
+	<-running
+	if got, exp := vars.BatchQueriesRunningVar.IntValue(), int64(1); got != exp {
+		t.Fatalf("unexpected running queries got %d exp %d", got, exp)
+	}
+
+	// The second query waits for the first one to finish.
+	secondDone := make(chan error, 1)
+	executed := false
+	go func() {
+		_, err := l.do(context.Background(), closing, 0, nil, func() (*influxdb.Response, error) {
+			executed = true
+			return &influxdb.Response{}, nil
+		})
+		secondDone <- err
+	}()
+	waitForQueued(t, 1)
+
+	close(finish)
+	if err := <-firstDone; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-secondDone; err != nil {
+		t.Fatal(err)
+	}
+	if !executed {
+		t.Error("expected second query to be executed")
+	}
+	if got, exp := vars.BatchQueriesRunningVar.IntValue(), int64(0); got != exp {
+		t.Errorf("unexpected running queries got %d exp %d", got, exp)
+	}
+	waitForQueued(t, 0)
+}
+
+func TestBatchQueryLimiter_Canceled(t *testing.T) {
+	l := NewBatchQueryLimiter(1, 0)
+	closing := make(chan struct{})
+	finish := make(chan struct{})
+	defer close(finish)
+	running := make(chan struct{})
+	go l.do(context.Background(), closing, 0, nil, func() (*influxdb.Response, error) {
+		close(running)
+		<-finish
+		return &influxdb.Response{}, nil
+	})
+	<-running
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := l.do(ctx, closing, 0, nil, func() (*influxdb.Response, error) {
+			t.Error("canceled query should not be executed")
+			return nil, nil
+		})
+		canceled <- err
+	}()
+	waitForQueued(t, 1)
+	cancel()
+	if got, exp := <-canceled, context.Canceled; got != exp {
+		t.Errorf("unexpected error got %v exp %v", got, exp)
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		_, err := l.do(context.Background(), closing, 0, nil, func() (*influxdb.Response, error) {
+			t.Error("stopped query should not be executed")
+			return nil, nil
+		})
+		stopped <- err
+	}()
+	waitForQueued(t, 1)
+	close(closing)
+	if got, exp := <-stopped, errBatchQueryStopped; got != exp {
+		t.Errorf("unexpected error got %v exp %v", got, exp)
+	}
+	waitForQueued(t, 0)
+}
+
+func TestBatchQueryLimiter_Unlimited(t *testing.T) {
+	for _, l := range []*BatchQueryLimiter{nil, NewBatchQueryLimiter(0, 0)} {
+		// Queries do not wait on each other.
+		inner := make(chan error, 1)
+		_, err := l.do(context.Background(), nil, 0, nil, func() (*influxdb.Response, error) {
+			_, err := l.do(context.Background(), nil, 0, nil, func() (*influxdb.Response, error) {
+				return &influxdb.Response{}, nil
+			})
+			inner <- err
+			return &influxdb.Response{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := <-inner; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatchQueryLimiter_Priority(t *testing.T) {
+	testCases := []struct {
+		name  string
+		aging time.Duration
+		// wait is the time the low priority query waits before the high priority query is queued.
+		wait time.Duration
+		exp  []string
+	}{
+		{
+			name: "higher priority first",
+			exp:  []string{"high", "low"},
+		},
+		{
+			name:  "aging",
+			aging: 10 * time.Millisecond,
+			wait:  100 * time.Millisecond,
+			exp:   []string{"low", "high"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewBatchQueryLimiter(1, tc.aging)
+			finish := make(chan struct{})
+			running := make(chan struct{})
+			go l.do(context.Background(), nil, 0, nil, func() (*influxdb.Response, error) {
+				close(running)
+				<-finish
+				return &influxdb.Response{}, nil
+			})
+			<-running
+
+			order := make(chan string, 2)
+			done := make(chan error, 2)
+			positions := make(map[string]*expvar.Int)
+			queue := func(name string, priority int64) {
+				positions[name] = &expvar.Int{}
+				go func(position *expvar.Int) {
+					_, err := l.do(context.Background(), nil, priority, position, func() (*influxdb.Response, error) {
+						order <- name
+						return &influxdb.Response{}, nil
+					})
+					done <- err
+				}(positions[name])
+			}
+			queue("low", 0)
+			waitForQueued(t, 1)
+			time.Sleep(tc.wait)
+			queue("high", 2)
+			waitForQueued(t, 2)
+
+			for i, name := range tc.exp {
+				if got, exp := positions[name].IntValue(), int64(i+1); got != exp {
+					t.Errorf("unexpected queue position of %s query got %d exp %d", name, got, exp)
+				}
+			}
+
+			close(finish)
+			for range tc.exp {
+				if err := <-done; err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, exp := range tc.exp {
+				if got := <-order; got != exp {
+					t.Errorf("unexpected query executed got %s exp %s", got, exp)
+				}
+			}
+			for name, position := range positions {
+				if got := position.IntValue(); got != 0 {
+					t.Errorf("unexpected queue position of %s query after execution got %d exp 0", name, got)
+				}
+			}
+		})
+	}
+}
+
+func waitForQueued(t *testing.T, exp int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for vars.BatchQueriesQueuedVar.IntValue() != exp {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected queued queries got %d exp %d", vars.BatchQueriesQueuedVar.IntValue(), exp)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,7 +1,9 @@
 package kapacitor
 
 import (
+	"container/list"
 	"fmt"
+	"math"
 
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/keyvalue"
@@ -12,6 +14,9 @@ import (
 type ChangeDetectNode struct {
 	node
 	d *pipeline.ChangeDetectNode
+	// tracked are the groups with previous values, least recently changed first.
+	// They are only tracked if the number of groups is limited.
+	tracked *list.List
 }
 
 // Create a new changeDetect node.
@@ -20,6 +25,9 @@ func newChangeDetectNode(et *ExecutingTask, n *pipeline.ChangeDetectNode, d Node
 		node: node{Node: n, et: et, diag: d},
 		d:    n,
 	}
+	if n.MaxGroups > 0 {
+		dn.tracked = list.New()
+	}
 	// Create stateful expressions
 	dn.node.runF = dn.runChangeDetect
 	return dn, nil
@@ -48,8 +56,11 @@ func (n *ChangeDetectNode) newGroup() *changeDetectGroup {
 }
 
 type changeDetectGroup struct {
-	n        *ChangeDetectNode
-	previous edge.FieldsTagsTimeGetter
+	n *ChangeDetectNode
+	// Values of the fields of the last emitted point.
+	previous models.Fields
+	// tracked is the element of the group in the tracked groups of the node.
+	tracked *list.Element
 }
 
 func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -57,16 +68,20 @@ func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Messa
 		begin = begin.ShallowCopy()
 		begin.SetSizeHint(0)
 	}
-	g.previous = nil
+	g.forget()
 	return begin, nil
 }
 
 func (g *changeDetectGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
-	changed := g.doChangeDetect(bp)
-	if changed {
-		return bp, nil
+	prev, changed := g.doChangeDetect(bp)
+	if !changed {
+		return nil, nil
+	}
+	if g.n.d.PreviousPrefix != "" && prev != nil {
+		bp = bp.ShallowCopy()
+		bp.SetFields(g.n.addPrevious(bp.Fields(), prev))
 	}
-	return nil, nil
+	return bp, nil
 }
 
 func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
@@ -74,34 +89,68 @@ func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, er
 }
 
 func (g *changeDetectGroup) Point(p edge.PointMessage) (edge.Message, error) {
-	changed := g.doChangeDetect(p)
-	if changed {
-		return p, nil
+	prev, changed := g.doChangeDetect(p)
+	if !changed {
+		return nil, nil
+	}
+	if g.n.d.PreviousPrefix != "" && prev != nil {
+		p = p.ShallowCopy()
+		p.SetFields(g.n.addPrevious(p.Fields(), prev))
 	}
-	return nil, nil
+	return p, nil
 }
 
 // doChangeDetect computes the changeDetect with respect to g.previous and p.
-// The resulting changeDetect value will be set on n.
-func (g *changeDetectGroup) doChangeDetect(p edge.FieldsTagsTimeGetter) bool {
-	var prevFields, currFields models.Fields
-	if g.previous != nil {
-		prevFields = g.previous.Fields()
+// If p changed, the previous values are returned and the values of p are kept
+// as the new previous values.
+func (g *changeDetectGroup) doChangeDetect(p edge.FieldsTagsTimeGetter) (models.Fields, bool) {
+	currFields := p.Fields()
+	if !g.n.changeDetect(g.previous, currFields) {
+		return nil, false
 	}
-	currFields = p.Fields()
-	changed := g.n.changeDetect(prevFields, currFields)
+	prev := g.previous
+	// Only keep the values of the fields, not the entire point.
+	g.previous = make(models.Fields, len(g.n.d.Fields))
+	for _, field := range g.n.d.Fields {
+		if value, ok := currFields[field]; ok {
+			g.previous[field] = value
+		}
+	}
+	g.track()
+	return prev, true
+}
 
-	if !changed {
-		return false
+// track marks the group as the most recently changed group,
+// the previous values of the least recently changed group are forgotten beyond the maximum number of groups.
+func (g *changeDetectGroup) track() {
+	tracked := g.n.tracked
+	if tracked == nil {
+		return
+	}
+	if g.tracked != nil {
+		tracked.MoveToBack(g.tracked)
+		return
+	}
+	g.tracked = tracked.PushBack(g)
+	if int64(tracked.Len()) > g.n.d.MaxGroups {
+		tracked.Front().Value.(*changeDetectGroup).forget()
+	}
+}
+
+// forget removes the previous values of the group.
+func (g *changeDetectGroup) forget() {
+	g.previous = nil
+	if g.tracked != nil {
+		g.n.tracked.Remove(g.tracked)
+		g.tracked = nil
 	}
-	g.previous = p
-	return true
 }
 
 func (g *changeDetectGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 	return b, nil
 }
 func (g *changeDetectGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.forget()
 	return d, nil
 }
 func (g *changeDetectGroup) Done() {}
@@ -116,9 +165,40 @@ func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) bool {
 				keyvalue.KV("field", field))
 			continue
 		}
-		if prev[field] != value {
+		if n.valueChanged(prev[field], value) {
 			return true
 		}
 	}
 	return false
 }
+
+// valueChanged reports whether the value changed, using the tolerance for numeric values.
+func (n *ChangeDetectNode) valueChanged(prev, curr interface{}) bool {
+	if n.d.Tolerance > 0 {
+		p, pok := changeDetectFloat(prev)
+		c, cok := changeDetectFloat(curr)
+		if pok && cok {
+			return math.Abs(c-p) > n.d.Tolerance
+		}
+	}
+	return prev != curr
+}
+
+func changeDetectFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// addPrevious returns a copy of the fields with the previous values added.
+func (n *ChangeDetectNode) addPrevious(fields, prev models.Fields) models.Fields {
+	fields = fields.Copy()
+	for field, value := range prev {
+		fields[n.d.PreviousPrefix+field] = value
+	}
+	return fields
+}
@@ -43,6 +43,7 @@ const (
 	recordStreamPath  = basePath + "/recordings/stream"
 	recordBatchPath   = basePath + "/recordings/batch"
 	recordQueryPath   = basePath + "/recordings/query"
+	recordCSVPath     = basePath + "/recordings/csv"
 	replaysPath       = basePath + "/replays"
 	replayBatchPath   = basePath + "/replays/batch"
 	replayQueryPath   = basePath + "/replays/query"
@@ -53,6 +54,8 @@ const (
 	topicsPath        = alertsPath + "/topics"
 	topicEventsPath   = "events"
 	topicHandlersPath = "handlers"
+	topicEventAckPath = "ack"
+	silencesPath      = alertsPath + "/silences"
 	storagePath       = basePath + "/storage"
 	storesPath        = storagePath + "/stores"
 	backupPath        = storagePath + "/backup"
@@ -591,6 +594,7 @@ const (
 	Failed Status = iota
 	Running
 	Finished
+	Canceled
 )
 
 func (s Status) MarshalText() ([]byte, error) {
@@ -601,6 +605,8 @@ func (s Status) MarshalText() ([]byte, error) {
 		return []byte("running"), nil
 	case Finished:
 		return []byte("finished"), nil
+	case Canceled:
+		return []byte("canceled"), nil
 	default:
 		return nil, fmt.Errorf("unknown Status %d", s)
 	}
@@ -614,6 +620,8 @@ func (s *Status) UnmarshalText(text []byte) error {
 		*s = Running
 	case "finished":
 		*s = Finished
+	case "canceled":
+		*s = Canceled
 	default:
 		return fmt.Errorf("unknown Status %s", t)
 	}
@@ -830,21 +838,37 @@ type Var struct {
 
 // A Task plus its read-only attributes.
 type Task struct {
-	Link           Link           `json:"link"`
-	ID             string         `json:"id"`
-	TemplateID     string         `json:"template-id"`
-	Type           TaskType       `json:"type"`
-	DBRPs          []DBRP         `json:"dbrps"`
-	TICKscript     string         `json:"script"`
-	Vars           Vars           `json:"vars"`
-	Dot            string         `json:"dot"`
-	Status         TaskStatus     `json:"status"`
-	Executing      bool           `json:"executing"`
-	Error          string         `json:"error"`
-	ExecutionStats ExecutionStats `json:"stats"`
-	Created        time.Time      `json:"created"`
-	Modified       time.Time      `json:"modified"`
-	LastEnabled    time.Time      `json:"last-enabled,omitempty"`
+	Link           Link              `json:"link"`
+	ID             string            `json:"id"`
+	TemplateID     string            `json:"template-id"`
+	Type           TaskType          `json:"type"`
+	DBRPs          []DBRP            `json:"dbrps"`
+	TICKscript     string            `json:"script"`
+	Vars           Vars              `json:"vars"`
+	LogLevel       string            `json:"log-level"`
+	NodeLogLevels  map[string]string `json:"node-log-levels"`
+	Quotas         TaskQuotas        `json:"quotas"`
+	Priority       int64             `json:"priority"`
+	Dot            string            `json:"dot"`
+	Status         TaskStatus        `json:"status"`
+	Executing      bool              `json:"executing"`
+	Error          string            `json:"error"`
+	ExecutionStats ExecutionStats    `json:"stats"`
+	Created        time.Time         `json:"created"`
+	Modified       time.Time         `json:"modified"`
+	LastEnabled    time.Time         `json:"last-enabled,omitempty"`
+}
+
+// TaskQuotas limit the resources used by a task, a zero limit means no limit.
+type TaskQuotas struct {
+	// Maximum rate of points ingested by the task.
+	MaxPointsPerSecond int64 `json:"max-points-per-second" yaml:"max-points-per-second"`
+	// Maximum number of groups, summed over the nodes of the task.
+	MaxCardinality int64 `json:"max-cardinality" yaml:"max-cardinality"`
+	// Maximum estimated memory of the task in bytes.
+	MaxMemoryBytes int64 `json:"max-memory-bytes" yaml:"max-memory-bytes"`
+	// What happens once a quota is exceeded, either throttle, dropping points, or error, stopping the task.
+	Enforcement string `json:"enforcement" yaml:"enforcement"`
 }
 
 // A Template plus its read-only attributes.
@@ -870,6 +894,7 @@ type Recording struct {
 	Error    string    `json:"error"`
 	Status   Status    `json:"status"`
 	Progress float64   `json:"progress"`
+	Pinned   bool      `json:"pinned"`
 }
 
 // Information about a replay.
@@ -880,10 +905,14 @@ type Replay struct {
 	Recording      string         `json:"recording"`
 	RecordingTime  bool           `json:"recording-time"`
 	Clock          Clock          `json:"clock"`
+	Speed          float64        `json:"speed"`
 	Date           time.Time      `json:"date"`
 	Error          string         `json:"error"`
 	Status         Status         `json:"status"`
 	Progress       float64        `json:"progress"`
+	Paused         bool           `json:"paused"`
+	Position       Duration       `json:"position"`
+	Emitted        int64          `json:"emitted"`
 	ExecutionStats ExecutionStats `json:"stats,omitempty"`
 }
 
@@ -1076,6 +1105,14 @@ func (c *Client) TopicHandlersLink(topic string) Link {
 func (c *Client) TopicHandlerLink(topic, id string) Link {
 	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath, id)}
 }
+func (c *Client) TopicEventAckLink(topic, event string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicEventsPath, event, topicEventAckPath)}
+}
+
+func (c *Client) SilenceLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(silencesPath, id)}
+}
+
 func (c *Client) StorageLink(name string) Link {
 	return Link{Relation: Self, Href: path.Join(storesPath, name)}
 }
@@ -1088,6 +1125,14 @@ type CreateTaskOptions struct {
 	TICKscript string     `json:"script,omitempty"`
 	Status     TaskStatus `json:"status,omitempty"`
 	Vars       Vars       `json:"vars,omitempty" yaml:"vars"`
+	// Log level of the task, one of debug, info or error.
+	LogLevel string `json:"log-level,omitempty" yaml:"log-level"`
+	// Log levels of nodes of the task by node name, i.e. alert2.
+	NodeLogLevels map[string]string `json:"node-log-levels,omitempty" yaml:"node-log-levels"`
+	// Resource quotas of the task, zero quotas use the default quotas of the server.
+	Quotas *TaskQuotas `json:"quotas,omitempty" yaml:"quotas"`
+	// Priority of the batch queries of the task waiting for a free query slot, higher first, defaults to 0.
+	Priority *int64 `json:"priority,omitempty" yaml:"priority"`
 }
 
 // Create a new task.
@@ -1122,6 +1167,16 @@ type UpdateTaskOptions struct {
 	TICKscript string     `json:"script,omitempty"`
 	Status     TaskStatus `json:"status,omitempty"`
 	Vars       Vars       `json:"vars,omitempty" yaml:"vars"`
+	// Log level of the task, one of debug, info or error.
+	// The level default removes the log level of the task.
+	LogLevel string `json:"log-level,omitempty" yaml:"log-level"`
+	// Log levels of nodes of the task by node name, merged with the existing node log levels.
+	// The level default removes the log level of a node.
+	NodeLogLevels map[string]string `json:"node-log-levels,omitempty" yaml:"node-log-levels"`
+	// Resource quotas of the task, replacing all of the existing quotas of the task.
+	Quotas *TaskQuotas `json:"quotas,omitempty" yaml:"quotas"`
+	// Priority of the batch queries of the task waiting for a free query slot, higher first.
+	Priority *int64 `json:"priority,omitempty" yaml:"priority"`
 }
 
 // Update an existing task.
@@ -1227,6 +1282,35 @@ func (c *Client) DeleteTask(link Link) error {
 	return err
 }
 
+// CanceledQueries is the result of canceling the queries of a task.
+type CanceledQueries struct {
+	// Number of queries that were canceled.
+	Canceled int `json:"canceled"`
+}
+
+// CancelTaskQueries cancels the batch queries a task is currently executing.
+// Canceled queries are not reported as errors of the task.
+func (c *Client) CancelTaskQueries(link Link) (CanceledQueries, error) {
+	r := CanceledQueries{}
+	if link.Href == "" {
+		return r, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, "cancel-query")
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return r, err
+	}
+
+	_, err = c.Do(req, &r, http.StatusAccepted)
+	if err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
 type ListTasksOptions struct {
 	TaskOptions
 	Pattern string
@@ -1617,6 +1701,67 @@ func (c *Client) RecordQuery(opt RecordQueryOptions) (Recording, error) {
 	return r, nil
 }
 
+type RecordCSVOptions struct {
+	ID   string   `json:"id,omitempty"`
+	Type TaskType `json:"type"`
+	// The CSV data, the first row must be a header naming the columns.
+	Data string `json:"data"`
+
+	// Database and retention policy of the points, required for stream recordings.
+	Database        string `json:"database,omitempty"`
+	RetentionPolicy string `json:"retention-policy,omitempty"`
+
+	// Measurement name of all points.
+	// If empty the measurement is read from the MeasurementColumn.
+	Measurement string `json:"measurement,omitempty"`
+	// Column containing the measurement name, defaults to "name".
+	MeasurementColumn string `json:"measurement-column,omitempty"`
+
+	// Column containing the time of the point, defaults to "time".
+	TimeColumn string `json:"time-column,omitempty"`
+	// Format of the time column.
+	// One of "rfc3339", "epoch-s", "epoch-ms", "epoch-us", "epoch-ns" or a Go time layout.
+	// Defaults to "rfc3339".
+	TimeFormat string `json:"time-format,omitempty"`
+
+	// Columns to use as tags.
+	// A column named "tags" is always read as a comma separated list of key=value tags,
+	// as exported by the InfluxDB CLI.
+	Tags []string `json:"tags,omitempty"`
+	// Columns to use as fields.
+	// If empty all remaining columns are fields.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Record the rows of CSV data.
+// The recording type must be one of "stream", or "batch".
+// Returns once the recording is finished.
+func (c *Client) RecordCSV(opt RecordCSVOptions) (Recording, error) {
+	r := Recording{}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return r, err
+	}
+
+	u := *c.url
+	u.Path = recordCSVPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusCreated)
+	if err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
 // Delete a recording.
 func (c *Client) DeleteRecording(link Link) error {
 	if link.Href == "" {
@@ -1634,6 +1779,42 @@ func (c *Client) DeleteRecording(link Link) error {
 	return err
 }
 
+type UpdateRecordingOptions struct {
+	// Pinned recordings are never deleted by the retention of the recordings.
+	Pinned *bool `json:"pinned,omitempty" yaml:"pinned"`
+}
+
+// Update an existing recording.
+// Only fields that are set will be updated.
+func (c *Client) UpdateRecording(link Link, opt UpdateRecordingOptions) (Recording, error) {
+	r := Recording{}
+	if link.Href == "" {
+		return r, fmt.Errorf("invalid link %v", link)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return r, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("PATCH", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	if err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
 type ListRecordingsOptions struct {
 	Pattern string
 	Fields  []string
@@ -1697,6 +1878,10 @@ type CreateReplayOptions struct {
 	Task          string `json:"task"`
 	RecordingTime bool   `json:"recording-time"`
 	Clock         Clock  `json:"clock"`
+	// Speed multiplier of the real clock, i.e. 2 replays twice as fast. Defaults to 1.
+	Speed float64 `json:"speed,omitempty"`
+	// Start the replay paused, so that it can be stepped.
+	Paused bool `json:"paused,omitempty"`
 }
 
 func (o *CreateReplayOptions) Default() {
@@ -1738,6 +1923,10 @@ type ReplayBatchOptions struct {
 	Stop          time.Time `json:"stop"`
 	RecordingTime bool      `json:"recording-time"`
 	Clock         Clock     `json:"clock"`
+	// Speed multiplier of the real clock, i.e. 2 replays twice as fast. Defaults to 1.
+	Speed float64 `json:"speed,omitempty"`
+	// Start the replay paused, so that it can be stepped.
+	Paused bool `json:"paused,omitempty"`
 }
 
 // Replay a query against a task.
@@ -1774,6 +1963,10 @@ type ReplayQueryOptions struct {
 	Cluster       string `json:"cluster,omitempty"`
 	RecordingTime bool   `json:"recording-time"`
 	Clock         Clock  `json:"clock"`
+	// Speed multiplier of the real clock, i.e. 2 replays twice as fast. Defaults to 1.
+	Speed float64 `json:"speed,omitempty"`
+	// Start the replay paused, so that it can be stepped.
+	Paused bool `json:"paused,omitempty"`
 }
 
 // Replay a query against a task.
@@ -1825,6 +2018,48 @@ func (c *Client) Replay(link Link) (Replay, error) {
 	return r, nil
 }
 
+// PauseReplay pauses a running replay.
+func (c *Client) PauseReplay(link Link) (Replay, error) {
+	return c.controlReplay(link, "pause", nil)
+}
+
+// ResumeReplay resumes a paused replay.
+func (c *Client) ResumeReplay(link Link) (Replay, error) {
+	return c.controlReplay(link, "resume", nil)
+}
+
+// CancelReplay cancels a running replay, including any queries it is executing.
+// The replay finishes with the canceled status.
+func (c *Client) CancelReplay(link Link) (Replay, error) {
+	return c.controlReplay(link, "cancel", nil)
+}
+
+// StepReplay replays the next count points of a replay and then pauses the replay.
+func (c *Client) StepReplay(link Link, count int) (Replay, error) {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(count))
+	return c.controlReplay(link, "step", v)
+}
+
+func (c *Client) controlReplay(link Link, action string, v url.Values) (Replay, error) {
+	r := Replay{}
+	if link.Href == "" {
+		return r, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, action)
+	u.RawQuery = v.Encode()
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return r, err
+	}
+
+	_, err = c.Do(req, &r, http.StatusAccepted)
+	return r, err
+}
+
 // Delete a replay. This will cancel a running replay.
 func (c *Client) DeleteReplay(link Link) error {
 	if link.Href == "" {
@@ -2221,6 +2456,8 @@ type EventState struct {
 	Time     time.Time `json:"time"`
 	Duration Duration  `json:"duration"`
 	Level    string    `json:"level"`
+	// Fingerprint of the alert, for deduplication by external systems.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 // TopicEvent retrieves details for a single event of a topic
@@ -2296,6 +2533,9 @@ type TopicHandler struct {
 	Kind    string                 `json:"kind"`
 	Options map[string]interface{} `json:"options"`
 	Match   string                 `json:"match"`
+	// MinLevel is the minimum level of the events routed to the handler.
+	// Empty if the handler receives events of all levels.
+	MinLevel string `json:"min-level"`
 }
 
 // TopicHandler retrieves an alert handler.
@@ -2324,6 +2564,9 @@ type TopicHandlerOptions struct {
 	Kind    string                 `json:"kind" yaml:"kind"`
 	Options map[string]interface{} `json:"options" yaml:"options"`
 	Match   string                 `json:"match" yaml:"match"`
+	// MinLevel is the minimum level of the events routed to the handler, i.e. WARNING or CRITICAL.
+	// Recovery events of alerts that met the minimum level are also routed to the handler.
+	MinLevel string `json:"min-level" yaml:"min-level"`
 }
 
 // CreateTopicHandler creates a new alert handler.
@@ -2454,6 +2697,187 @@ func (c *Client) ListTopicHandlers(link Link, opt *ListTopicHandlersOptions) (To
 	return handlers, nil
 }
 
+type Acknowledgement struct {
+	Link    Link      `json:"link"`
+	Topic   string    `json:"topic"`
+	Event   string    `json:"event"`
+	Level   string    `json:"level"`
+	Comment string    `json:"comment"`
+	Time    time.Time `json:"time"`
+}
+
+type AcknowledgeOptions struct {
+	Comment string `json:"comment" yaml:"comment"`
+}
+
+// AcknowledgeTopicEvent acknowledges an event, delivery of the event
+// is suppressed until the event changes level.
+// The link should be created with TopicEventAckLink.
+func (c *Client) AcknowledgeTopicEvent(link Link, opt AcknowledgeOptions) (Acknowledgement, error) {
+	a := Acknowledgement{}
+	if link.Href == "" {
+		return a, fmt.Errorf("invalid link %v", link)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return a, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return a, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &a, http.StatusOK)
+	return a, err
+}
+
+// TopicEventAcknowledgement retrieves the acknowledgement of an event.
+// Errors if the event is not acknowledged.
+func (c *Client) TopicEventAcknowledgement(link Link) (Acknowledgement, error) {
+	a := Acknowledgement{}
+	if link.Href == "" {
+		return a, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return a, err
+	}
+
+	_, err = c.Do(req, &a, http.StatusOK)
+	return a, err
+}
+
+// UnacknowledgeTopicEvent removes the acknowledgement of an event.
+func (c *Client) UnacknowledgeTopicEvent(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type Silences struct {
+	Link     Link      `json:"link"`
+	Silences []Silence `json:"silences"`
+}
+
+type Silence struct {
+	Link    Link              `json:"link"`
+	ID      string            `json:"id"`
+	Topic   string            `json:"topic"`
+	Tags    map[string]string `json:"tags"`
+	Comment string            `json:"comment"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+}
+
+type SilenceOptions struct {
+	ID string `json:"id" yaml:"id"`
+	// Topic is a glob pattern of topics to silence, empty matches all topics.
+	Topic string `json:"topic" yaml:"topic"`
+	// Tags that an event must have to be silenced.
+	Tags    map[string]string `json:"tags" yaml:"tags"`
+	Comment string            `json:"comment" yaml:"comment"`
+	// Start of the silence, defaults to now.
+	Start    time.Time `json:"start" yaml:"start"`
+	Duration Duration  `json:"duration" yaml:"duration"`
+}
+
+// CreateSilence creates a new silence.
+// Errors if the silence already exists.
+func (c *Client) CreateSilence(opt SilenceOptions) (Silence, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return Silence{}, err
+	}
+
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return Silence{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	s := Silence{}
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// Silence retrieves a silence.
+// Errors if no silence exists.
+func (c *Client) Silence(link Link) (Silence, error) {
+	s := Silence{}
+	if link.Href == "" {
+		return s, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return s, err
+	}
+
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// ListSilences returns all silences that have not expired.
+func (c *Client) ListSilences() (Silences, error) {
+	silences := Silences{}
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return silences, err
+	}
+
+	_, err = c.Do(req, &silences, http.StatusOK)
+	return silences, err
+}
+
+// DeleteSilence deletes a silence.
+func (c *Client) DeleteSilence(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
 type StorageList struct {
 	Link    Link      `json:"link"`
 	Storage []Storage `json:"storage"`
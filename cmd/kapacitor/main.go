@@ -237,6 +237,7 @@ func init() {
 	recordStreamFlags.Usage = recordStreamUsage
 	recordBatchFlags.Usage = recordBatchUsage
 	recordQueryFlags.Usage = recordQueryUsage
+	recordCSVFlags.Usage = recordCSVUsage
 
 	replayLiveBatchFlags.Usage = replayLiveBatchUsage
 	replayLiveQueryFlags.Usage = replayLiveQueryUsage
@@ -344,12 +345,25 @@ var (
 	rqCluster = recordQueryFlags.String("cluster", "", "Optional named InfluxDB cluster from configuration.")
 	rqNowait  = recordQueryFlags.Bool("no-wait", false, "Do not wait for the recording to finish.")
 	rqId      = recordQueryFlags.String("recording-id", "", "The ID to give to this recording. If not set an random ID is chosen.")
+
+	recordCSVFlags      = flag.NewFlagSet("record-csv", flag.ExitOnError)
+	rcFile              = recordCSVFlags.String("file", "", "Path to the CSV file to record.")
+	rcType              = recordCSVFlags.String("type", "", "The type of the recording to save (stream|batch).")
+	rcDatabase          = recordCSVFlags.String("database", "", "The database of the points, required for stream recordings.")
+	rcRetentionPolicy   = recordCSVFlags.String("retention-policy", "", "The retention policy of the points, required for stream recordings.")
+	rcMeasurement       = recordCSVFlags.String("measurement", "", "The measurement of all points. If not set the measurement is read from the measurement column.")
+	rcMeasurementColumn = recordCSVFlags.String("measurement-column", "", "The column containing the measurement (default \"name\").")
+	rcTimeColumn        = recordCSVFlags.String("time-column", "", "The column containing the time (default \"time\").")
+	rcTimeFormat        = recordCSVFlags.String("time-format", "", "The format of the time column, one of rfc3339, epoch-s, epoch-ms, epoch-us, epoch-ns or a Go time layout (default rfc3339).")
+	rcTags              = recordCSVFlags.String("tags", "", "Comma separated list of columns to use as tags.")
+	rcFields            = recordCSVFlags.String("fields", "", "Comma separated list of columns to use as fields. If not set all remaining columns are fields.")
+	rcId                = recordCSVFlags.String("recording-id", "", "The ID to give to this recording. If not set an random ID is chosen.")
 )
 
 func recordUsage() {
-	var u = `Usage: kapacitor record [batch|stream|query] [options]
+	var u = `Usage: kapacitor record [batch|stream|query|csv] [options]
 
-	Record the result of a InfluxDB query or a snapshot of the live data stream.
+	Record the result of a InfluxDB query, a snapshot of the live data stream or the rows of a CSV file.
 
 	Prints the recording ID on exit.
 
@@ -432,6 +446,41 @@ Options:
 	recordQueryFlags.PrintDefaults()
 }
 
+func recordCSVUsage() {
+	var u = `Usage: kapacitor record csv [options]
+
+	Record the rows of a CSV file.
+
+	Prints the recording ID on exit.
+
+	The first row of the file must be a header naming the columns.
+	A column named 'tags' is read as a comma separated list of key=value tags as exported by the InfluxDB CLI.
+
+	See 'kapacitor help replay' for how to replay a recording.
+
+Examples:
+
+	$ kapacitor record csv -file cpu.csv -type stream -database telegraf -retention-policy autogen
+
+		This records the rows of a CSV file exported from InfluxDB as a stream recording.
+
+	$ kapacitor record csv -file cpu.csv -type batch -measurement cpu -time-column ts -time-format epoch-ms -tags host
+
+		This records the rows of the file as a batch recording, with a batch per host.
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	recordCSVFlags.PrintDefaults()
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 func doRecord(args []string) error {
 	var recording client.Recording
 	var err error
@@ -526,8 +575,41 @@ func doRecord(args []string) error {
 		if err != nil {
 			return err
 		}
+	case "csv":
+		recordCSVFlags.Parse(args[1:])
+		if *rcFile == "" || *rcType == "" {
+			recordCSVFlags.Usage()
+			return errors.New("both file and type are required")
+		}
+		var typ client.TaskType
+		switch *rcType {
+		case "stream":
+			typ = client.StreamTask
+		case "batch":
+			typ = client.BatchTask
+		}
+		data, err := os.ReadFile(*rcFile)
+		if err != nil {
+			return err
+		}
+		recording, err = kCli.RecordCSV(client.RecordCSVOptions{
+			ID:                *rcId,
+			Type:              typ,
+			Data:              string(data),
+			Database:          *rcDatabase,
+			RetentionPolicy:   *rcRetentionPolicy,
+			Measurement:       *rcMeasurement,
+			MeasurementColumn: *rcMeasurementColumn,
+			TimeColumn:        *rcTimeColumn,
+			TimeFormat:        *rcTimeFormat,
+			Tags:              splitList(*rcTags),
+			Fields:            splitList(*rcFields),
+		})
+		if err != nil {
+			return err
+		}
 	default:
-		return fmt.Errorf("Unknown record type %q, expected 'stream', 'batch' or 'query'", args[0])
+		return fmt.Errorf("Unknown record type %q, expected 'stream', 'batch', 'query' or 'csv'", args[0])
 	}
 	if noWait {
 		return nil
@@ -1560,6 +1642,7 @@ func doShowTopicHandler(args []string) error {
 	fmt.Println("Topic:", topic)
 	fmt.Println("Kind:", h.Kind)
 	fmt.Println("Match:", h.Match)
+	fmt.Println("Min Level:", h.MinLevel)
 	fmt.Println("Options:", string(options))
 	return nil
 }
@@ -149,6 +149,10 @@ func (n *DerivativeNode) derivative(prev, curr models.Fields, prevTime, currTime
 		return 0, true, false
 	}
 	diff := f1 - f0
+	// A decrease in a counter means it was reset, count up from zero
+	if n.d.CounterResetFlag && diff < 0 {
+		diff = f1
+	}
 	// Drop negative values for non-negative derivatives
 	if n.d.NonNegativeFlag && diff < 0 {
 		return 0, true, false
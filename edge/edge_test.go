@@ -110,6 +110,77 @@ func TestEdge_CollectBatch(t *testing.T) {
 	}
 }
 
+type countingReceiver struct {
+	points int
+}
+
+func (r *countingReceiver) BeginBatch(edge.BeginBatchMessage) error { return nil }
+func (r *countingReceiver) BatchPoint(edge.BatchPointMessage) error {
+	r.points++
+	return nil
+}
+func (r *countingReceiver) EndBatch(edge.EndBatchMessage) error { return nil }
+func (r *countingReceiver) Point(edge.PointMessage) error {
+	r.points++
+	return nil
+}
+func (r *countingReceiver) Barrier(edge.BarrierMessage) error         { return nil }
+func (r *countingReceiver) DeleteGroup(edge.DeleteGroupMessage) error { return nil }
+func (r *countingReceiver) Done()                                     {}
+
+// limitedReceiver creates groups until it has max groups.
+type limitedReceiver struct {
+	max     int
+	groups  []*countingReceiver
+	refused int
+}
+
+func (r *limitedReceiver) NewGroup(edge.GroupInfo, edge.PointMeta) (edge.Receiver, error) {
+	g := new(countingReceiver)
+	r.groups = append(r.groups, g)
+	return g, nil
+}
+
+func (r *limitedReceiver) AllowNewGroup(points int) bool {
+	if len(r.groups) < r.max {
+		return true
+	}
+	r.refused += points
+	return false
+}
+
+func TestGroupedConsumer_RefusedGroup(t *testing.T) {
+	e := edge.NewChannelEdge(pipeline.StreamEdge, defaultEdgeBufferSize)
+	r := &limitedReceiver{max: 1}
+	c := edge.NewGroupedConsumer(e, r)
+
+	other := point.ShallowCopy()
+	other.SetTags(models.Tags{"tag1": "other", "tag2": "value2"})
+	otherBatch := batch.ShallowCopy()
+	otherBatch.SetBegin(otherBatch.Begin().ShallowCopy())
+	otherBatch.Begin().SetTags(models.Tags{"tag1": "other", "tag2": "value2"})
+	for _, m := range []edge.Message{point, other, point, otherBatch} {
+		e.Collect(m)
+	}
+	e.Close()
+	if err := c.Consume(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := len(r.groups), 1; got != exp {
+		t.Fatalf("unexpected number of groups got %d exp %d", got, exp)
+	}
+	if got, exp := r.groups[0].points, 2; got != exp {
+		t.Errorf("unexpected points of the existing group got %d exp %d", got, exp)
+	}
+	if got, exp := r.refused, 3; got != exp {
+		t.Errorf("unexpected refused points got %d exp %d", got, exp)
+	}
+	if got, exp := c.CardinalityVar().IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected cardinality got %d exp %d", got, exp)
+	}
+}
+
 var emittedMsg edge.Message
 var emittedOK bool
 
@@ -21,6 +21,13 @@ type GroupedReceiver interface {
 	NewGroup(group GroupInfo, first PointMeta) (Receiver, error)
 }
 
+// GroupLimiter is implemented by grouped receivers that may refuse to create new groups.
+type GroupLimiter interface {
+	// AllowNewGroup reports whether a new group may be created for a message of the given number of points.
+	// The messages of a refused group are dropped.
+	AllowNewGroup(points int) bool
+}
+
 // GroupInfo identifies and contians information about a specific group.
 type GroupInfo struct {
 	ID         models.GroupID
@@ -29,10 +36,12 @@ type GroupInfo struct {
 }
 
 type groupedConsumer struct {
-	consumer    Consumer
-	gr          GroupedReceiver
-	groups      map[models.GroupID]Receiver
-	current     Receiver
+	consumer Consumer
+	gr       GroupedReceiver
+	groups   map[models.GroupID]Receiver
+	current  Receiver
+	// refused is whether the current batch belongs to a refused group.
+	refused     bool
 	cardinality *expvar.Int
 }
 
@@ -54,9 +63,13 @@ func (c *groupedConsumer) CardinalityVar() expvar.IntVar {
 	return c.cardinality
 }
 
-func (c *groupedConsumer) getOrCreateGroup(group GroupInfo, first PointMeta) (Receiver, error) {
+// getOrCreateGroup returns the receiver of the group, or nil if the group is refused by the grouped receiver.
+func (c *groupedConsumer) getOrCreateGroup(group GroupInfo, first PointMeta, points int) (Receiver, error) {
 	r, ok := c.groups[group.ID]
 	if !ok {
+		if l, ok := c.gr.(GroupLimiter); ok && !l.AllowNewGroup(points) {
+			return nil, nil
+		}
 		c.cardinality.Add(1)
 		recv, err := c.gr.NewGroup(group, first)
 		if err != nil {
@@ -69,15 +82,22 @@ func (c *groupedConsumer) getOrCreateGroup(group GroupInfo, first PointMeta) (Re
 }
 
 func (c *groupedConsumer) BeginBatch(begin BeginBatchMessage) error {
-	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin, begin.SizeHint())
 	if err != nil {
 		return err
 	}
 	c.current = r
+	c.refused = r == nil
+	if c.refused {
+		return nil
+	}
 	return r.BeginBatch(begin)
 }
 
 func (c *groupedConsumer) BatchPoint(p BatchPointMessage) error {
+	if c.refused {
+		return nil
+	}
 	if c.current == nil {
 		return errors.New("received batch point without batch")
 	}
@@ -85,6 +105,10 @@ func (c *groupedConsumer) BatchPoint(p BatchPointMessage) error {
 }
 
 func (c *groupedConsumer) EndBatch(end EndBatchMessage) error {
+	if c.refused {
+		c.refused = false
+		return nil
+	}
 	err := c.current.EndBatch(end)
 	c.current = nil
 	return err
@@ -92,24 +116,24 @@ func (c *groupedConsumer) EndBatch(end EndBatchMessage) error {
 
 func (c *groupedConsumer) BufferedBatch(batch BufferedBatchMessage) error {
 	begin := batch.Begin()
-	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
-	if err != nil {
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin, len(batch.Points()))
+	if err != nil || r == nil {
 		return err
 	}
 	return receiveBufferedBatch(r, batch)
 }
 
 func (c *groupedConsumer) Point(p PointMessage) error {
-	r, err := c.getOrCreateGroup(p.GroupInfo(), p)
-	if err != nil {
+	r, err := c.getOrCreateGroup(p.GroupInfo(), p, 1)
+	if err != nil || r == nil {
 		return err
 	}
 	return r.Point(p)
 }
 
 func (c *groupedConsumer) Barrier(b BarrierMessage) error {
-	r, err := c.getOrCreateGroup(b.GroupInfo(), b)
-	if err != nil {
+	r, err := c.getOrCreateGroup(b.GroupInfo(), b, 0)
+	if err != nil || r == nil {
 		return err
 	}
 	return r.Barrier(b)
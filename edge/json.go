@@ -0,0 +1,199 @@
+package edge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+// Field value types of a TypedValue, named the same as the InfluxDB field types.
+const (
+	FloatFieldType    = "float"
+	IntegerFieldType  = "integer"
+	UnsignedFieldType = "unsigned"
+	StringFieldType   = "string"
+	BooleanFieldType  = "boolean"
+)
+
+// TypedValue is a field value along with its type,
+// so that integers are not confused with floats once serialized.
+type TypedValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+func (v *TypedValue) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw.Value))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	var err error
+	switch raw.Type {
+	case FloatFieldType:
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("invalid float value %s", raw.Value)
+		}
+		value, err = n.Float64()
+	case IntegerFieldType:
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("invalid integer value %s", raw.Value)
+		}
+		value, err = n.Int64()
+	case UnsignedFieldType:
+		var u uint64
+		err = json.Unmarshal(raw.Value, &u)
+		value = u
+	case StringFieldType:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("invalid string value %s", raw.Value)
+		}
+	case BooleanFieldType:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("invalid boolean value %s", raw.Value)
+		}
+	default:
+		return fmt.Errorf("unknown field type %q", raw.Type)
+	}
+	if err != nil {
+		return err
+	}
+	v.Type = raw.Type
+	v.Value = value
+	return nil
+}
+
+// TypedFields are fields serialized with the type of each value.
+type TypedFields map[string]TypedValue
+
+// NewTypedFields returns the typed fields of fields.
+// Values of types that are not valid field types are formatted as strings.
+func NewTypedFields(fields models.Fields) TypedFields {
+	typed := make(TypedFields, len(fields))
+	for k, v := range fields {
+		var t string
+		switch value := v.(type) {
+		case float64:
+			t = FloatFieldType
+		case int64:
+			t = IntegerFieldType
+		case int:
+			t = IntegerFieldType
+			v = int64(value)
+		case uint64:
+			t = UnsignedFieldType
+		case string:
+			t = StringFieldType
+		case bool:
+			t = BooleanFieldType
+		default:
+			t = StringFieldType
+			v = fmt.Sprint(value)
+		}
+		typed[k] = TypedValue{Type: t, Value: v}
+	}
+	return typed
+}
+
+// Fields returns the fields without their types.
+func (f TypedFields) Fields() models.Fields {
+	fields := make(models.Fields, len(f))
+	for k, v := range f {
+		fields[k] = v.Value
+	}
+	return fields
+}
+
+// PointJSON is the full JSON serialization of a point, including all tags and typed fields.
+type PointJSON struct {
+	Name            string      `json:"name,omitempty"`
+	Database        string      `json:"database,omitempty"`
+	RetentionPolicy string      `json:"retentionPolicy,omitempty"`
+	Tags            models.Tags `json:"tags"`
+	Fields          TypedFields `json:"fields"`
+	Time            time.Time   `json:"time"`
+}
+
+// NewPointJSON returns the JSON serialization of the point.
+func NewPointJSON(p PointMessage) PointJSON {
+	return PointJSON{
+		Name:            p.Name(),
+		Database:        p.Database(),
+		RetentionPolicy: p.RetentionPolicy(),
+		Tags:            p.Tags(),
+		Fields:          NewTypedFields(p.Fields()),
+		Time:            p.Time(),
+	}
+}
+
+// PointMessage returns the point, grouped by all of its tags.
+// The point can be written to a stream recording, see kapacitor.WritePointForRecording.
+func (p PointJSON) PointMessage() PointMessage {
+	return NewPointMessage(
+		p.Name,
+		p.Database,
+		p.RetentionPolicy,
+		models.Dimensions{TagNames: models.SortedKeys(p.Tags)},
+		p.Fields.Fields(),
+		p.Tags,
+		p.Time.UTC(),
+	)
+}
+
+// BatchJSON is the full JSON serialization of a batch, including all tags and typed fields.
+type BatchJSON struct {
+	Name   string      `json:"name"`
+	TMax   time.Time   `json:"tmax"`
+	Tags   models.Tags `json:"tags"`
+	Points []PointJSON `json:"points"`
+}
+
+// NewBatchJSON returns the JSON serialization of the batch.
+func NewBatchJSON(b BufferedBatchMessage) BatchJSON {
+	points := b.Points()
+	bj := BatchJSON{
+		Name:   b.Name(),
+		TMax:   b.Time(),
+		Tags:   b.Tags(),
+		Points: make([]PointJSON, len(points)),
+	}
+	for i, p := range points {
+		bj.Points[i] = PointJSON{
+			Tags:   p.Tags(),
+			Fields: NewTypedFields(p.Fields()),
+			Time:   p.Time(),
+		}
+	}
+	return bj
+}
+
+// BufferedBatchMessage returns the batch.
+// The batch can be written to a batch recording, see kapacitor.WriteBatchForRecording.
+func (b BatchJSON) BufferedBatchMessage() BufferedBatchMessage {
+	points := make([]BatchPointMessage, len(b.Points))
+	for i, p := range b.Points {
+		tags := p.Tags
+		if len(tags) == 0 {
+			tags = b.Tags
+		}
+		points[i] = NewBatchPointMessage(p.Fields.Fields(), tags, p.Time.UTC())
+	}
+	return NewBufferedBatchMessage(
+		NewBeginBatchMessage(b.Name, b.Tags, false, b.TMax.UTC(), len(points)),
+		points,
+		NewEndBatchMessage(),
+	)
+}
@@ -0,0 +1,63 @@
+package edge_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestBatchJSON_RoundTrip(t *testing.T) {
+	tmax := time.Date(2020, 1, 1, 0, 0, 10, 0, time.UTC)
+	tags := models.Tags{"host": "serverA"}
+	fields := models.Fields{
+		"float":    1.0,
+		"integer":  int64(1),
+		"unsigned": uint64(1),
+		"string":   "1",
+		"boolean":  true,
+	}
+	batch := edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage("cpu", tags, false, tmax, 1),
+		[]edge.BatchPointMessage{
+			edge.NewBatchPointMessage(fields, tags, tmax.Add(-time.Second)),
+		},
+		edge.NewEndBatchMessage(),
+	)
+
+	data, err := json.Marshal(edge.NewBatchJSON(batch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bj edge.BatchJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		t.Fatal(err)
+	}
+	got := bj.BufferedBatchMessage()
+	if got.Name() != "cpu" || !got.Time().Equal(tmax) || !reflect.DeepEqual(got.Tags(), tags) {
+		t.Errorf("unexpected batch got %s %v %v", got.Name(), got.Time(), got.Tags())
+	}
+	if len(got.Points()) != 1 {
+		t.Fatalf("unexpected number of points %d", len(got.Points()))
+	}
+	if !reflect.DeepEqual(got.Points()[0].Fields(), fields) {
+		t.Errorf("unexpected fields:\ngot\n%#v\nexp\n%#v", got.Points()[0].Fields(), fields)
+	}
+}
+
+func TestTypedValue_UnmarshalJSON_Errors(t *testing.T) {
+	for _, data := range []string{
+		`{"type":"integer","value":1.5}`,
+		`{"type":"float","value":"1"}`,
+		`{"type":"boolean","value":1}`,
+		`{"type":"duration","value":1}`,
+	} {
+		var v edge.TypedValue
+		if err := json.Unmarshal([]byte(data), &v); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
@@ -3,14 +3,30 @@ package kapacitor
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"text/template"
 
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/tick/ast"
 	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
+const (
+	statsNanResults          = "nan_results"
+	statsDynamicNames        = "dynamic_names"
+	statsDynamicNamesDropped = "dynamic_names_dropped"
+)
+
+// errNanResult is returned when a point is dropped because of a NaN or Inf result.
+var errNanResult = errors.New("expression result is NaN or Inf")
+
+// errDynamicNamesLimit is returned when a point is dropped because it would exceed the maxDynamicNames limit.
+var errDynamicNamesLimit = errors.New("too many dynamic field names")
+
 type EvalNode struct {
 	node
 	e           *pipeline.EvalNode
@@ -18,6 +34,22 @@ type EvalNode struct {
 	refVarList  [][]string
 	scopePool   stateful.ScopePool
 	tags        map[string]bool
+	nanTag      string
+
+	// multiNames are the element names of the [name1, name2, ...] .as() names, nil for the other names.
+	multiNames [][]string
+
+	// asTemplates are the templates of the dynamic .as() names, nil for the static names.
+	asTemplates     []*template.Template
+	maxDynamicNames int
+	// dynamicNames are the distinct field names rendered from the templates.
+	dynamicNames map[string]bool
+	// dynamicIndexes are the indexes of the dynamic .as() names, to render them when they are kept.
+	dynamicIndexes map[string]int
+
+	nanResults          *expvar.Int
+	dynamicNamesVar     *expvar.Int
+	dynamicNamesDropped *expvar.Int
 }
 
 // Create a new  EvalNode which applies a transformation func to each point in a stream and returns a single point.
@@ -55,11 +87,51 @@ func newEvalNode(et *ExecutingTask, n *pipeline.EvalNode, d NodeDiagnostic) (*Ev
 		}
 	}
 
+	en.nanTag = n.NanTag
+	if en.nanTag == "" {
+		en.nanTag = pipeline.DefaultNanTag
+	}
+
+	for i, as := range n.AsList {
+		if names, ok := pipeline.SplitMultiName(as); ok {
+			if en.multiNames == nil {
+				en.multiNames = make([][]string, len(n.AsList))
+			}
+			en.multiNames[i] = names
+			continue
+		}
+		if !pipeline.IsDynamicName(as) {
+			continue
+		}
+		tmpl, err := template.New("as").Option("missingkey=error").Parse(as)
+		if err != nil {
+			return nil, fmt.Errorf("invalid .as() name template %q: %v", as, err)
+		}
+		if en.asTemplates == nil {
+			en.asTemplates = make([]*template.Template, len(n.AsList))
+			en.dynamicNames = make(map[string]bool)
+			en.dynamicIndexes = make(map[string]int)
+		}
+		en.asTemplates[i] = tmpl
+		en.dynamicIndexes[as] = i
+	}
+	en.maxDynamicNames = int(n.MaxDynamicNames)
+	if en.maxDynamicNames == 0 {
+		en.maxDynamicNames = pipeline.DefaultMaxDynamicNames
+	}
+
 	en.node.runF = en.runEval
 	return en, nil
 }
 
 func (n *EvalNode) runEval(snapshot []byte) error {
+	n.nanResults = &expvar.Int{}
+	n.statMap.Set(statsNanResults, n.nanResults)
+	n.dynamicNamesVar = &expvar.Int{}
+	n.statMap.Set(statsDynamicNames, n.dynamicNamesVar)
+	n.dynamicNamesDropped = &expvar.Int{}
+	n.statMap.Set(statsDynamicNamesDropped, n.dynamicNamesDropped)
+
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -91,7 +163,16 @@ func (n *EvalNode) newGroup() *evalGroup {
 func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTimeSetter) (err error) {
 	vars := n.scopePool.Get()
 	defer n.scopePool.Put(vars)
+	if n.e.NowPointTimeFlag {
+		vars.SetNow(p.Time().Local())
+	}
 
+	names, err := n.fieldNames(p)
+	if err != nil {
+		return err
+	}
+
+	var nanNames []string
 	for i, expr := range expressions {
 		err := fillScope(vars, n.refVarList[i], p)
 		if err != nil {
@@ -101,14 +182,36 @@ func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTime
 		if err != nil {
 			return err
 		}
-		name := n.e.AsList[i]
-		vars.Set(name, v)
+		if multi := n.multiNameList(i); multi != nil {
+			a, ok := v.([]float64)
+			if !ok {
+				return fmt.Errorf("result of expression %q must be an array, got %T", n.e.AsList[i], v)
+			}
+			if len(a) != len(multi) {
+				return fmt.Errorf("result of expression %q must have %d elements, got %d", n.e.AsList[i], len(multi), len(a))
+			}
+			for j, name := range multi {
+				if nanNames, err = n.setResult(vars, name, a[j], nanNames); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if nanNames, err = n.setResult(vars, n.e.AsList[i], v, nanNames); err != nil {
+			return err
+		}
 	}
 	fields := p.Fields()
 	tags := p.Tags()
 	newTags := tags
-	if len(n.tags) > 0 {
+	tagNan := n.e.NanPolicy == pipeline.NanPolicyTag && len(nanNames) > 0
+	if tagNan || len(n.tags) > 0 {
 		newTags = newTags.Copy()
+	}
+	if tagNan {
+		newTags[n.nanTag] = strings.Join(nanNames, ",")
+	}
+	if len(n.tags) > 0 {
 		for tag := range n.tags {
 			v, err := vars.Get(tag)
 			if err != nil {
@@ -132,7 +235,15 @@ func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTime
 					if err != nil {
 						return err
 					}
-					newFields[f] = v
+					if v == ast.MissingValue {
+						continue
+					}
+					if i, ok := n.dynamicIndexes[f]; ok {
+						// Keep the result of a dynamic name with the name rendered for the point.
+						newFields[names[i]] = v
+					} else {
+						newFields[f] = v
+					}
 				} else if v, ok := fields[f]; ok {
 					// Try the raw fields next, since it may not have been a referenced var.
 					newFields[f] = v
@@ -145,25 +256,14 @@ func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTime
 			for f, v := range fields {
 				newFields[f] = v
 			}
-			for _, f := range n.e.AsList {
-				v, err := vars.Get(f)
-				if err != nil {
-					return err
-				}
-				newFields[f] = v
+			if err := n.setFields(vars, newFields, names, false); err != nil {
+				return err
 			}
 		}
 	} else {
 		newFields = make(models.Fields, len(n.e.AsList)-len(n.tags))
-		for _, f := range n.e.AsList {
-			if n.tags[f] {
-				continue
-			}
-			v, err := vars.Get(f)
-			if err != nil {
-				return err
-			}
-			newFields[f] = v
+		if err := n.setFields(vars, newFields, names, true); err != nil {
+			return err
 		}
 	}
 	p.SetFields(newFields)
@@ -171,6 +271,106 @@ func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTime
 	return
 }
 
+// setResult sets the result of an expression on the scope, applying the NaN policy.
+// It returns the names of the NaN results so far.
+func (n *EvalNode) setResult(vars *stateful.Scope, name string, v interface{}, nanNames []string) ([]string, error) {
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		if nanNames == nil {
+			n.nanResults.Add(1)
+		}
+		switch n.e.NanPolicy {
+		case pipeline.NanPolicyReplace:
+			v = n.e.NanReplacement
+		case pipeline.NanPolicyTag:
+		case pipeline.NanPolicyOmit:
+			v = ast.MissingValue
+		default:
+			return nil, errNanResult
+		}
+		nanNames = append(nanNames, name)
+	}
+	vars.Set(name, v)
+	return nanNames, nil
+}
+
+// setFields sets the results of the expressions as the fields named by names, without the tags if skipTags.
+func (n *EvalNode) setFields(vars *stateful.Scope, fields models.Fields, names []string, skipTags bool) error {
+	for i, f := range n.e.AsList {
+		if skipTags && n.tags[f] {
+			continue
+		}
+		if multi := n.multiNameList(i); multi != nil {
+			for _, name := range multi {
+				v, err := vars.Get(name)
+				if err != nil {
+					return err
+				}
+				if v != ast.MissingValue {
+					fields[name] = v
+				}
+			}
+			continue
+		}
+		v, err := vars.Get(f)
+		if err != nil {
+			return err
+		}
+		if v != ast.MissingValue {
+			fields[names[i]] = v
+		}
+	}
+	return nil
+}
+
+// multiNameList returns the element names of the ith .as() name, nil if it does not name the elements of an array.
+func (n *EvalNode) multiNameList(i int) []string {
+	if n.multiNames == nil {
+		return nil
+	}
+	return n.multiNames[i]
+}
+
+// fieldNames returns the names of the output fields of the expressions,
+// which are the .as() names with the dynamic names rendered for the point.
+func (n *EvalNode) fieldNames(p edge.FieldsTagsTimeSetter) ([]string, error) {
+	if n.asTemplates == nil {
+		return n.e.AsList, nil
+	}
+	names := make([]string, len(n.e.AsList))
+	copy(names, n.e.AsList)
+	data := struct {
+		Tags   map[string]string
+		Fields map[string]interface{}
+	}{
+		Tags:   p.Tags(),
+		Fields: p.Fields(),
+	}
+	var buf strings.Builder
+	for i, tmpl := range n.asTemplates {
+		if tmpl == nil {
+			continue
+		}
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render .as() name %q: %v", n.e.AsList[i], err)
+		}
+		name := buf.String()
+		if name == "" {
+			return nil, fmt.Errorf(".as() name %q rendered an empty name", n.e.AsList[i])
+		}
+		if !n.dynamicNames[name] {
+			if len(n.dynamicNames) >= n.maxDynamicNames {
+				n.dynamicNamesDropped.Add(1)
+				return nil, errDynamicNamesLimit
+			}
+			n.dynamicNames[name] = true
+			n.dynamicNamesVar.Set(int64(len(n.dynamicNames)))
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
 type evalGroup struct {
 	n           *EvalNode
 	expressions []stateful.Expression
@@ -204,6 +404,10 @@ func (g *evalGroup) Point(p edge.PointMessage) (edge.Message, error) {
 
 func (g *evalGroup) doEval(p edge.FieldsTagsTimeSetter) bool {
 	err := g.n.eval(g.expressions, p)
+	if err == errNanResult || err == errDynamicNamesLimit {
+		// Counted in the nan_results or dynamic_names_dropped statistics, drop the point.
+		return false
+	}
 	if err != nil {
 		if !g.n.e.QuietFlag {
 			g.n.diag.Error("error evaluating expression", err)
@@ -0,0 +1,240 @@
+package kapacitor
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+const (
+	executionPoolStatName = "execution_pools"
+
+	statPoolSize     = "size"
+	statPoolBusy     = "busy"
+	statPoolWaiting  = "waiting"
+	statPoolBusyTime = "busy_time_ns"
+	statPoolTasks    = "tasks"
+)
+
+// ExecutionPoolConfig configures an execution pool,
+// bounding the number of nodes of the tasks of the pool processing data at once.
+type ExecutionPoolConfig struct {
+	// Name of the pool, reported in the pool tag of its statistics.
+	Name string `toml:"name"`
+	// Size is the number of nodes of the tasks of the pool processing data at once.
+	Size int `toml:"size"`
+	// Tasks are the IDs of the tasks of the pool, or glob patterns matching the IDs.
+	Tasks []string `toml:"tasks"`
+}
+
+// Validate returns an error if the pool is invalid.
+func (c ExecutionPoolConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("must specify the name of the execution pool")
+	}
+	if c.Size < 1 {
+		return fmt.Errorf("size of execution pool %q must be at least 1", c.Name)
+	}
+	for _, pattern := range c.Tasks {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid task pattern %q of execution pool %q: %v", pattern, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateExecutionPools returns an error if a pool is invalid or two pools have the same name.
+func ValidateExecutionPools(configs []ExecutionPoolConfig) error {
+	names := make(map[string]bool, len(configs))
+	for _, c := range configs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate execution pool %q", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return nil
+}
+
+// ExecutionPools isolate the execution of groups of tasks from each other.
+// Each pool has a fixed number of slots, a node of a task of the pool holds a slot while it processes a message,
+// so the tasks of a pool use at most as many CPUs as the pool has slots,
+// and a busy pool does not delay the tasks of the other pools waiting for the CPU.
+// Tasks which do not match any pool are not limited.
+// A nil ExecutionPools does not assign tasks to pools.
+//
+// A node holds a slot while its timer is started, so nodes pause their timer, which frees the slot,
+// while they forward data to the next node from within the processing of a message
+// and while they wait on I/O, like queries, HTTP requests and the writes of a buffer.
+// Otherwise a node waiting for a full edge would keep the next node from getting a slot.
+// The nodes of UDFs do not hold slots, the UDF processes the data in its own process.
+type ExecutionPools struct {
+	pools []*executionPool
+}
+
+// NewExecutionPools returns the pools of the configs, tasks are assigned to the first pool matching their ID.
+func NewExecutionPools(configs []ExecutionPoolConfig) *ExecutionPools {
+	p := &ExecutionPools{
+		pools: make([]*executionPool, len(configs)),
+	}
+	for i, c := range configs {
+		p.pools[i] = newExecutionPool(c)
+	}
+	return p
+}
+
+// poolOf returns the pool of the task, nil if the task is not assigned to a pool.
+func (p *ExecutionPools) poolOf(taskID string) *executionPool {
+	if p == nil {
+		return nil
+	}
+	for _, pool := range p.pools {
+		if pool.matches(taskID) {
+			return pool
+		}
+	}
+	return nil
+}
+
+// Close deletes the statistics of the pools.
+func (p *ExecutionPools) Close() {
+	if p == nil {
+		return
+	}
+	for _, pool := range p.pools {
+		vars.DeleteStatistic(pool.statsKey)
+	}
+}
+
+// executionPool bounds the number of nodes processing data at once.
+type executionPool struct {
+	patterns []string
+	// slots holds a value for each slot in use.
+	slots chan struct{}
+
+	statsKey string
+	busy     *kexpvar.Int
+	waiting  *kexpvar.Int
+	busyTime *kexpvar.Int
+	tasks    *kexpvar.Int
+}
+
+func newExecutionPool(c ExecutionPoolConfig) *executionPool {
+	p := &executionPool{
+		patterns: c.Tasks,
+		slots:    make(chan struct{}, c.Size),
+		busy:     &kexpvar.Int{},
+		waiting:  &kexpvar.Int{},
+		busyTime: &kexpvar.Int{},
+		tasks:    &kexpvar.Int{},
+	}
+	size := &kexpvar.Int{}
+	size.Set(int64(c.Size))
+	var statMap *kexpvar.Map
+	p.statsKey, statMap = vars.NewStatistic(executionPoolStatName, map[string]string{
+		"pool": c.Name,
+	})
+	statMap.Set(statPoolSize, size)
+	statMap.Set(statPoolBusy, p.busy)
+	statMap.Set(statPoolWaiting, p.waiting)
+	statMap.Set(statPoolBusyTime, p.busyTime)
+	statMap.Set(statPoolTasks, p.tasks)
+	return p
+}
+
+func (p *executionPool) matches(taskID string) bool {
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, taskID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire waits for a free slot and returns the time it got it.
+func (p *executionPool) acquire() time.Time {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		p.waiting.Add(1)
+		p.slots <- struct{}{}
+		p.waiting.Add(-1)
+	}
+	p.busy.Add(1)
+	return time.Now()
+}
+
+// release frees the slot acquired at since.
+func (p *executionPool) release(since time.Time) {
+	p.busyTime.Add(int64(time.Since(since)))
+	p.busy.Add(-1)
+	<-p.slots
+}
+
+// timer returns a timer of a node of the pool, which holds a slot of the pool while it is started.
+func (p *executionPool) timer(t timer.Timer) timer.Timer {
+	return &poolTimer{
+		Timer: t,
+		pool:  p,
+	}
+}
+
+// poolTimer holds a slot of its pool from Start to Stop, except while paused.
+// Pause and Resume do nothing while the timer is stopped,
+// so nodes can pause it around forwarding which is not always timed.
+// Like the timers it wraps, it is used by a single goroutine.
+type poolTimer struct {
+	timer.Timer
+	pool *executionPool
+
+	started bool
+	held    bool
+	since   time.Time
+}
+
+func (t *poolTimer) Start() {
+	t.started = true
+	t.acquire()
+	t.Timer.Start()
+}
+
+func (t *poolTimer) Pause() {
+	t.Timer.Pause()
+	t.release()
+}
+
+func (t *poolTimer) Resume() {
+	if !t.started {
+		return
+	}
+	t.acquire()
+	t.Timer.Resume()
+}
+
+func (t *poolTimer) Stop() {
+	t.Timer.Stop()
+	t.started = false
+	t.release()
+}
+
+func (t *poolTimer) acquire() {
+	if t.held {
+		return
+	}
+	t.since = t.pool.acquire()
+	t.held = true
+}
+
+func (t *poolTimer) release() {
+	if !t.held {
+		return
+	}
+	t.held = false
+	t.pool.release(t.since)
+}
@@ -0,0 +1,161 @@
+package kapacitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countTimer counts the calls to the methods of a timer.
+type countTimer struct {
+	starts int
+	stops  int
+}
+
+func (t *countTimer) Start()  { t.starts++ }
+func (t *countTimer) Pause()  {}
+func (t *countTimer) Resume() {}
+func (t *countTimer) Stop()   { t.stops++ }
+
+func TestExecutionPools_PoolOf(t *testing.T) {
+	pools := NewExecutionPools([]ExecutionPoolConfig{
+		{Name: "critical", Size: 1, Tasks: []string{"cpu_alert"}},
+		{Name: "heavy", Size: 2, Tasks: []string{"rollup_*", "cpu_*"}},
+	})
+	defer pools.Close()
+
+	testCases := []struct {
+		task string
+		pool *executionPool
+	}{
+		{task: "cpu_alert", pool: pools.pools[0]},
+		{task: "cpu_rollup", pool: pools.pools[1]},
+		{task: "rollup_1h", pool: pools.pools[1]},
+		{task: "mem_alert", pool: nil},
+	}
+	for _, tc := range testCases {
+		if got := pools.poolOf(tc.task); got != tc.pool {
+			t.Errorf("%s: unexpected pool got %p exp %p", tc.task, got, tc.pool)
+		}
+	}
+
+	var nilPools *ExecutionPools
+	if got := nilPools.poolOf("cpu_alert"); got != nil {
+		t.Errorf("unexpected pool of nil pools %p", got)
+	}
+}
+
+func TestExecutionPool_Size(t *testing.T) {
+	pools := NewExecutionPools([]ExecutionPoolConfig{{Name: "TestExecutionPool_Size", Size: 2, Tasks: []string{"*"}}})
+	defer pools.Close()
+	pool := pools.pools[0]
+
+	var running, maxRunning int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tm := pool.timer(&countTimer{})
+			for j := 0; j < 10; j++ {
+				tm.Start()
+				r := atomic.AddInt64(&running, 1)
+				for {
+					m := atomic.LoadInt64(&maxRunning)
+					if r <= m || atomic.CompareAndSwapInt64(&maxRunning, m, r) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt64(&running, -1)
+				tm.Stop()
+			}
+		}()
+	}
+	wg.Wait()
+	if maxRunning != 2 {
+		t.Errorf("unexpected max running nodes got %d exp 2", maxRunning)
+	}
+	if got := pool.busy.IntValue(); got != 0 {
+		t.Errorf("unexpected busy slots got %d exp 0", got)
+	}
+	if got := pool.busyTime.IntValue(); got < int64(80*time.Millisecond) {
+		t.Errorf("unexpected busy time got %v exp at least 80ms", time.Duration(got))
+	}
+}
+
+func TestExecutionPool_PauseReleasesSlot(t *testing.T) {
+	pools := NewExecutionPools([]ExecutionPoolConfig{{Name: "TestExecutionPool_PauseReleasesSlot", Size: 1, Tasks: []string{"*"}}})
+	defer pools.Close()
+	pool := pools.pools[0]
+
+	inner := &countTimer{}
+	first := pool.timer(inner)
+	first.Start()
+	first.Pause()
+
+	// The paused timer does not hold its slot, the other timer gets it.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		second := pool.timer(&countTimer{})
+		second.Start()
+		second.Stop()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the slot of the paused timer")
+	}
+
+	first.Resume()
+	first.Stop()
+	if inner.starts != 1 || inner.stops != 1 {
+		t.Errorf("unexpected calls of the wrapped timer starts %d stops %d", inner.starts, inner.stops)
+	}
+	if got := pool.busy.IntValue(); got != 0 {
+		t.Errorf("unexpected busy slots got %d exp 0", got)
+	}
+}
+
+func TestExecutionPool_PauseStopped(t *testing.T) {
+	pools := NewExecutionPools([]ExecutionPoolConfig{{Name: "TestExecutionPool_PauseStopped", Size: 1, Tasks: []string{"*"}}})
+	defer pools.Close()
+	pool := pools.pools[0]
+
+	// Forwarding outside of a timed section pauses and resumes a stopped timer, which must not take a slot.
+	first := pool.timer(&countTimer{})
+	first.Pause()
+	first.Resume()
+	if got := pool.busy.IntValue(); got != 0 {
+		t.Fatalf("unexpected busy slots got %d exp 0", got)
+	}
+	first.Start()
+	first.Pause()
+	first.Resume()
+	first.Stop()
+	first.Resume()
+	if got := pool.busy.IntValue(); got != 0 {
+		t.Errorf("unexpected busy slots got %d exp 0", got)
+	}
+}
+
+func TestValidateExecutionPools(t *testing.T) {
+	testCases := []struct {
+		pools []ExecutionPoolConfig
+		valid bool
+	}{
+		{pools: nil, valid: true},
+		{pools: []ExecutionPoolConfig{{Name: "a", Size: 1, Tasks: []string{"a_*"}}}, valid: true},
+		{pools: []ExecutionPoolConfig{{Size: 1}}},
+		{pools: []ExecutionPoolConfig{{Name: "a"}}},
+		{pools: []ExecutionPoolConfig{{Name: "a", Size: 1, Tasks: []string{"a_["}}}},
+		{pools: []ExecutionPoolConfig{{Name: "a", Size: 1}, {Name: "a", Size: 2}}},
+	}
+	for i, tc := range testCases {
+		if err := ValidateExecutionPools(tc.pools); (err == nil) != tc.valid {
+			t.Errorf("%d: unexpected validation error %v", i, err)
+		}
+	}
+}
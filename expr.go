@@ -30,6 +30,7 @@ func fillScope(vars *stateful.Scope, referenceVariables []string, p edge.FieldsT
 	now := p.Time()
 	fields := p.Fields()
 	tags := p.Tags()
+	vars.SetTags(tags)
 	for _, refVariableName := range referenceVariables {
 		if refVariableName == "time" {
 			vars.Set("time", now.Local())
@@ -1,6 +1,7 @@
 #!/bin/bash -e
 
 go install google.golang.org/protobuf/cmd/protoc-gen-go
+go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.2.0
 go install github.com/benbjohnson/tmpl
 go install github.com/mailru/easyjson/easyjson
 
@@ -1,12 +1,21 @@
 package kapacitor
 
 import (
+	"bufio"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"mime"
 	"net/http"
 	"path"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	imodels "github.com/influxdata/influxdb/models"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
@@ -19,10 +28,16 @@ type HTTPOutNode struct {
 
 	endpoint string
 
+	fields map[string]bool
+	tags   map[string]bool
+
 	mu      sync.RWMutex
 	routes  []httpd.Route
 	result  *models.Result
 	indexes []*httpOutGroup
+
+	// points of each group, only cached with fieldsAndTags.
+	points [][]edge.PointJSON
 }
 
 // Create a new  HTTPOutNode which caches the most recent item and exposes it over the HTTP API.
@@ -32,6 +47,18 @@ func newHTTPOutNode(et *ExecutingTask, n *pipeline.HTTPOutNode, d NodeDiagnostic
 		c:      n,
 		result: new(models.Result),
 	}
+	if len(n.FieldsList) > 0 {
+		hn.fields = make(map[string]bool, len(n.FieldsList))
+		for _, f := range n.FieldsList {
+			hn.fields[f] = true
+		}
+	}
+	if len(n.TagsList) > 0 {
+		hn.tags = make(map[string]bool, len(n.TagsList))
+		for _, t := range n.TagsList {
+			hn.tags[t] = true
+		}
+	}
 	et.registerOutput(hn.c.Endpoint, hn)
 	hn.node.runF = hn.runOut
 	hn.node.stopF = hn.stopOut
@@ -44,10 +71,48 @@ func (n *HTTPOutNode) Endpoint() string {
 
 func (n *HTTPOutNode) runOut([]byte) error {
 	hndl := func(w http.ResponseWriter, req *http.Request) {
+		format, err := httpOutFormat(req)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+			return
+		}
+		switch {
+		case format == httpOutFormatCSV:
+			w.Header().Set("Content-Type", "text/csv")
+			if err := writeResultCSV(w, n.series()); err != nil {
+				n.diag.Error("failed to write CSV result", err)
+			}
+			return
+		case format == httpOutFormatLine:
+			w.Header().Set("Content-Type", httpd.LineProtocolContentType)
+			if err := writeResultLineProtocol(w, n.series()); err != nil {
+				n.diag.Error("failed to write line protocol result", err)
+			}
+			return
+		case !n.c.FieldsAndTagsFlag && !n.c.PrettyFlag:
+			if err := writeResultJSON(w, n.series()); err != nil {
+				n.diag.Error("failed to write JSON result", err)
+			}
+			return
+		}
+
 		n.mu.RLock()
 		defer n.mu.RUnlock()
-
-		if b, err := json.Marshal(n.result); err != nil {
+		var v interface{} = n.result
+		if n.c.FieldsAndTagsFlag {
+			points := make([]edge.PointJSON, 0, len(n.points))
+			for _, ps := range n.points {
+				points = append(points, ps...)
+			}
+			v = points
+		}
+		var b []byte
+		if n.c.PrettyFlag {
+			b, err = json.MarshalIndent(v, "", "  ")
+		} else {
+			b, err = json.Marshal(v)
+		}
+		if err != nil {
 			httpd.HttpError(
 				w,
 				err.Error(),
@@ -87,7 +152,12 @@ func (n *HTTPOutNode) runOut([]byte) error {
 }
 
 // Update the result structure with a row.
-func (n *HTTPOutNode) updateResultWithRow(idx int, row *models.Row) {
+// The points are only used with fieldsAndTags.
+func (n *HTTPOutNode) updateResultWithRow(idx int, row *models.Row, points []edge.PointJSON) {
+	row = n.filterRow(row)
+	for i := range points {
+		points[i] = n.filterPoint(points[i])
+	}
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	if idx >= len(n.result.Series) {
@@ -96,6 +166,278 @@ func (n *HTTPOutNode) updateResultWithRow(idx int, row *models.Row) {
 		return
 	}
 	n.result.Series[idx] = row
+	n.points[idx] = points
+}
+
+// filterPoint removes any fields and tags from the point that were not selected.
+func (n *HTTPOutNode) filterPoint(p edge.PointJSON) edge.PointJSON {
+	if n.tags != nil {
+		tags := make(models.Tags, len(n.tags))
+		for k, v := range p.Tags {
+			if n.tags[k] {
+				tags[k] = v
+			}
+		}
+		p.Tags = tags
+	}
+	if n.fields != nil {
+		fields := make(edge.TypedFields, len(n.fields))
+		for k, v := range p.Fields {
+			if n.fields[k] {
+				fields[k] = v
+			}
+		}
+		p.Fields = fields
+	}
+	return p
+}
+
+// filterRow removes any fields and tags from the row that were not selected.
+func (n *HTTPOutNode) filterRow(row *models.Row) *models.Row {
+	if n.fields == nil && n.tags == nil {
+		return row
+	}
+	filtered := &models.Row{
+		Name:    row.Name,
+		Tags:    row.Tags,
+		Columns: row.Columns,
+		Values:  row.Values,
+	}
+	if n.tags != nil {
+		filtered.Tags = make(map[string]string, len(n.tags))
+		for k, v := range row.Tags {
+			if n.tags[k] {
+				filtered.Tags[k] = v
+			}
+		}
+	}
+	if n.fields != nil {
+		// Always keep the time column
+		keep := make([]int, 0, len(row.Columns))
+		for i, c := range row.Columns {
+			if c == "time" || n.fields[c] {
+				keep = append(keep, i)
+			}
+		}
+		filtered.Columns = make([]string, len(keep))
+		for i, k := range keep {
+			filtered.Columns[i] = row.Columns[k]
+		}
+		filtered.Values = make([][]interface{}, len(row.Values))
+		for j, values := range row.Values {
+			v := make([]interface{}, len(keep))
+			for i, k := range keep {
+				v[i] = values[k]
+			}
+			filtered.Values[j] = v
+		}
+	}
+	return filtered
+}
+
+// Formats of the data served by the endpoint.
+const (
+	httpOutFormatJSON = "json"
+	httpOutFormatCSV  = "csv"
+	httpOutFormatLine = "line"
+)
+
+// httpOutFormat returns the format requested with the format query parameter,
+// or else negotiated from the Accept header, JSON by default.
+func httpOutFormat(req *http.Request) (string, error) {
+	if f := req.URL.Query().Get("format"); f != "" {
+		switch f {
+		case httpOutFormatJSON, httpOutFormatCSV, httpOutFormatLine:
+			return f, nil
+		}
+		return "", fmt.Errorf("invalid format %q, must be one of %s, %s or %s", f, httpOutFormatJSON, httpOutFormatCSV, httpOutFormatLine)
+	}
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return httpOutFormatJSON, nil
+		case "text/csv":
+			return httpOutFormatCSV, nil
+		case "text/plain":
+			return httpOutFormatLine, nil
+		}
+	}
+	return httpOutFormatJSON, nil
+}
+
+// series returns the cached series.
+// The rows are replaced and not modified as the data is updated,
+// so the series can be written without holding the lock while the client reads them.
+func (n *HTTPOutNode) series() models.Rows {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.result.Series == nil {
+		return nil
+	}
+	series := make(models.Rows, len(n.result.Series))
+	copy(series, n.result.Series)
+	return series
+}
+
+// flush sends the data written so far to the client, so that large results are streamed.
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeResultJSON writes the series as the JSON of a result, flushing after each series.
+func writeResultJSON(w http.ResponseWriter, series models.Rows) error {
+	if series == nil {
+		_, err := io.WriteString(w, `{"series":null}`)
+		return err
+	}
+	if _, err := io.WriteString(w, `{"series":[`); err != nil {
+		return err
+	}
+	for i, row := range series {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b = append([]byte{','}, b...)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		flush(w)
+	}
+	_, err := io.WriteString(w, `]}`)
+	return err
+}
+
+// writeResultLineProtocol writes the series as line protocol, flushing after each series.
+// The values that cannot be written as fields, nil, arrays, NaN and infinite floats, are skipped,
+// as well as the points without any other field.
+func writeResultLineProtocol(w http.ResponseWriter, series models.Rows) error {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	for _, row := range series {
+		if row == nil {
+			continue
+		}
+		tags := imodels.NewTags(row.Tags)
+		for _, values := range row.Values {
+			var t time.Time
+			fields := make(imodels.Fields, len(values))
+			for i, v := range values {
+				if i >= len(row.Columns) {
+					break
+				}
+				switch v := v.(type) {
+				case time.Time:
+					if row.Columns[i] == "time" {
+						t = v
+					}
+				case float64:
+					if !math.IsNaN(v) && !math.IsInf(v, 0) {
+						fields[row.Columns[i]] = v
+					}
+				case int64, string, bool:
+					fields[row.Columns[i]] = v
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			p, err := imodels.NewPoint(row.Name, tags, fields, t)
+			if err != nil {
+				return err
+			}
+			buf = append(p.AppendString(buf[:0]), '\n')
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		flush(w)
+	}
+	return bw.Flush()
+}
+
+// writeResultCSV writes the series as CSV, using the same layout as InfluxDB, flushing after each series.
+// A header row is written for the first series and whenever the columns change.
+func writeResultCSV(w http.ResponseWriter, series models.Rows) error {
+	cw := csv.NewWriter(w)
+	var lastColumns []string
+	for _, row := range series {
+		if row == nil {
+			continue
+		}
+		if !equalColumns(lastColumns, row.Columns) {
+			header := append([]string{"name", "tags"}, row.Columns...)
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+			lastColumns = row.Columns
+		}
+		tags := formatTags(row.Tags)
+		for _, values := range row.Values {
+			record := make([]string, 0, 2+len(values))
+			record = append(record, row.Name, tags)
+			for _, v := range values {
+				record = append(record, formatCSVValue(v))
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		flush(w)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func equalColumns(a, b []string) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func formatCSVValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
 }
 
 func (n *HTTPOutNode) stopOut() {
@@ -117,6 +459,7 @@ func (n *HTTPOutNode) newGroup(groupID models.GroupID) *httpOutGroup {
 
 	idx := len(n.result.Series)
 	n.result.Series = append(n.result.Series, nil)
+	n.points = append(n.points, nil)
 	g := &httpOutGroup{
 		n:      n,
 		idx:    idx,
@@ -134,6 +477,7 @@ func (n *HTTPOutNode) deleteGroup(idx int) {
 	}
 	n.indexes = append(n.indexes[0:idx], n.indexes[idx+1:]...)
 	n.result.Series = append(n.result.Series[0:idx], n.result.Series[idx+1:]...)
+	n.points = append(n.points[0:idx], n.points[idx+1:]...)
 }
 
 type httpOutGroup struct {
@@ -156,13 +500,25 @@ func (g *httpOutGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error)
 
 func (g *httpOutGroup) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
 	row := batch.ToRow()
-	g.n.updateResultWithRow(g.idx, row)
+	var points []edge.PointJSON
+	if g.n.c.FieldsAndTagsFlag {
+		// Flatten the batch into points named after the batch.
+		points = edge.NewBatchJSON(batch).Points
+		for i := range points {
+			points[i].Name = batch.Name()
+		}
+	}
+	g.n.updateResultWithRow(g.idx, row, points)
 	return batch, nil
 }
 
 func (g *httpOutGroup) Point(p edge.PointMessage) (edge.Message, error) {
 	row := p.ToRow()
-	g.n.updateResultWithRow(g.idx, row)
+	var points []edge.PointJSON
+	if g.n.c.FieldsAndTagsFlag {
+		points = []edge.PointJSON{edge.NewPointJSON(p)}
+	}
+	g.n.updateResultWithRow(g.idx, row, points)
 	return p, nil
 }
 
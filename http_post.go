@@ -8,8 +8,10 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/keyvalue"
 	"github.com/influxdata/kapacitor/models"
@@ -23,6 +25,9 @@ type HTTPPostNode struct {
 	c        *pipeline.HTTPPostNode
 	endpoint *httppost.Endpoint
 	timeout  time.Duration
+
+	// batcher accumulates rows when posting in batches, nil otherwise.
+	batcher *httpPostBatcher
 }
 
 // Create a new  HTTPPostNode which submits received items via POST to an HTTP endpoint
@@ -55,11 +60,26 @@ func newHTTPPostNode(et *ExecutingTask, n *pipeline.HTTPPostNode, d NodeDiagnost
 		hn.endpoint = e
 	}
 
+	if n.BatchSize > 0 {
+		if hn.endpoint != nil && hn.endpoint.RowTemplate() != nil {
+			return nil, fmt.Errorf("the row template of endpoint '%s' cannot be used with a batchSize", n.Endpoints[0])
+		}
+		interval := n.BatchInterval
+		if interval <= 0 {
+			interval = pipeline.DefaultHTTPPostBatchInterval
+		}
+		hn.batcher = newHTTPPostBatcher(hn, int(n.BatchSize), interval, n.RetryTimeout)
+		hn.node.stopF = hn.stopPost
+	}
+
 	hn.node.runF = hn.runPost
 	return hn, nil
 }
 
 func (n *HTTPPostNode) runPost([]byte) error {
+	if n.batcher != nil {
+		n.batcher.start()
+	}
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -70,9 +90,15 @@ func (n *HTTPPostNode) runPost([]byte) error {
 
 }
 
+func (n *HTTPPostNode) stopPost() {
+	n.batcher.flush()
+	n.batcher.abort()
+}
+
 func (n *HTTPPostNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
 	g := &httpPostGroup{
 		n:      n,
+		group:  group.ID,
 		buffer: new(edge.BatchBuffer),
 	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
@@ -83,6 +109,7 @@ func (n *HTTPPostNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edg
 
 type httpPostGroup struct {
 	n      *HTTPPostNode
+	group  models.GroupID
 	buffer *edge.BatchBuffer
 }
 
@@ -100,6 +127,10 @@ func (g *httpPostGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error)
 
 func (g *httpPostGroup) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
 	row := batch.ToRow()
+	if g.n.batcher != nil {
+		g.n.batcher.enqueue(g.group, row)
+		return batch, nil
+	}
 	code := g.n.doPost(row)
 	if g.n.c.CodeField != "" {
 		//Add code to all points
@@ -121,6 +152,10 @@ func (g *httpPostGroup) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Mes
 
 func (g *httpPostGroup) Point(p edge.PointMessage) (edge.Message, error) {
 	row := p.ToRow()
+	if g.n.batcher != nil {
+		g.n.batcher.enqueue(g.group, row)
+		return p, nil
+	}
 	code := g.n.doPost(row)
 	if g.n.c.CodeField != "" {
 		//Add code to point
@@ -141,29 +176,33 @@ func (g *httpPostGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, er
 func (g *httpPostGroup) Done() {}
 
 func (n *HTTPPostNode) doPost(row *models.Row) int {
+	n.timer.Pause()
 	resp, err := n.postRow(row)
+	n.timer.Resume()
 	if err != nil {
 		n.diag.Error("failed to POST data", err)
 		return 0
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode/100 != 2 {
-		var err error
-		if n.c.CaptureResponseFlag {
-			var body []byte
-			body, err = io.ReadAll(resp.Body)
-			if err == nil {
-				// Use the body content as the error
-				err = errors.New(string(body))
-			}
-		} else {
-			err = errors.New("unknown error, use .captureResponse() to capture the HTTP response")
-		}
-		n.diag.Error("POST returned non 2xx status code", err, keyvalue.KV("code", strconv.Itoa(resp.StatusCode)))
+		n.diag.Error("POST returned non 2xx status code", n.responseError(resp), keyvalue.KV("code", strconv.Itoa(resp.StatusCode)))
 	}
 	return resp.StatusCode
 }
 
+// responseError returns the error of a non 2xx response.
+func (n *HTTPPostNode) responseError(resp *http.Response) error {
+	if n.c.CaptureResponseFlag {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		// Use the body content as the error
+		return errors.New(string(body))
+	}
+	return errors.New("unknown error, use .captureResponse() to capture the HTTP response")
+}
+
 func (n *HTTPPostNode) postRow(row *models.Row) (*http.Response, error) {
 	body := new(bytes.Buffer)
 
@@ -190,7 +229,32 @@ func (n *HTTPPostNode) postRow(row *models.Row) (*http.Response, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to marshal row data json")
 	}
+	return n.do(req, contentType)
+}
+
+// postRows posts the rows as a JSON array.
+// The URL template of the endpoint is rendered with the first row.
+func (n *HTTPPostNode) postRows(rows []*models.Row) (*http.Response, error) {
+	// The endpoint may have been updated with a row template since the node was created.
+	if n.endpoint.RowTemplate() != nil {
+		return nil, errors.New("row templates cannot be used with a batchSize")
+	}
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(rows); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal rows data json")
+	}
+	var mr *mappedRow
+	if n.endpoint.URL() != nil {
+		mr = newMappedRow(rows[0])
+	}
+	req, err := n.endpoint.NewHTTPRequest(body, mr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	return n.do(req, "application/json")
+}
 
+func (n *HTTPPostNode) do(req *http.Request, contentType string) (*http.Response, error) {
 	// Set content type and other headers
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
@@ -213,6 +277,141 @@ func (n *HTTPPostNode) postRow(row *models.Row) (*http.Response, error) {
 	return resp, nil
 }
 
+// httpPostBatcher accumulates rows and posts them once the batch size is reached
+// or the batch interval has elapsed.
+type httpPostBatcher struct {
+	n            *HTTPPostNode
+	size         int
+	interval     time.Duration
+	retryTimeout time.Duration
+	queue        chan httpPostEntry
+	buffer       map[models.GroupID][]*models.Row
+
+	flushing chan struct{}
+	flushed  chan struct{}
+
+	stopping chan struct{}
+	wg       sync.WaitGroup
+}
+
+type httpPostEntry struct {
+	group models.GroupID
+	row   *models.Row
+}
+
+func newHTTPPostBatcher(n *HTTPPostNode, size int, interval, retryTimeout time.Duration) *httpPostBatcher {
+	return &httpPostBatcher{
+		n:            n,
+		size:         size,
+		interval:     interval,
+		retryTimeout: retryTimeout,
+		queue:        make(chan httpPostEntry),
+		buffer:       make(map[models.GroupID][]*models.Row),
+		flushing:     make(chan struct{}),
+		flushed:      make(chan struct{}),
+		stopping:     make(chan struct{}),
+	}
+}
+
+func (b *httpPostBatcher) enqueue(group models.GroupID, row *models.Row) {
+	if !b.n.c.BatchByGroupFlag {
+		// Accumulate all groups together.
+		group = ""
+	}
+	// The batcher does not receive while it posts.
+	b.n.timer.Pause()
+	defer b.n.timer.Resume()
+	select {
+	case b.queue <- httpPostEntry{group: group, row: row}:
+	case <-b.stopping:
+	}
+}
+
+func (b *httpPostBatcher) start() {
+	b.wg.Add(1)
+	go b.run()
+}
+
+func (b *httpPostBatcher) flush() {
+	select {
+	case b.flushing <- struct{}{}:
+		<-b.flushed
+	case <-b.stopping:
+	}
+}
+
+func (b *httpPostBatcher) abort() {
+	close(b.stopping)
+	b.wg.Wait()
+}
+
+func (b *httpPostBatcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-b.queue:
+			rows := append(b.buffer[e.group], e.row)
+			if len(rows) >= b.size {
+				b.post(rows)
+				delete(b.buffer, e.group)
+			} else {
+				b.buffer[e.group] = rows
+			}
+		case <-b.flushing:
+			b.postAll()
+			b.flushed <- struct{}{}
+		case <-ticker.C:
+			b.postAll()
+		case <-b.stopping:
+			return
+		}
+	}
+}
+
+func (b *httpPostBatcher) postAll() {
+	for group, rows := range b.buffer {
+		b.post(rows)
+		delete(b.buffer, group)
+	}
+}
+
+// post posts the rows, retrying failed posts with exponential backoff until the retry timeout.
+// The rows are dropped if all attempts fail.
+func (b *httpPostBatcher) post(rows []*models.Row) {
+	var bo backoff.BackOff = &backoff.StopBackOff{}
+	if b.retryTimeout > 0 {
+		eb := backoff.NewExponentialBackOff()
+		eb.MaxElapsedTime = b.retryTimeout
+		bo = eb
+	}
+	err := backoff.RetryNotify(
+		func() error {
+			resp, err := b.n.postRows(rows)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode/100 == 2 {
+				return nil
+			}
+			err = errors.Wrapf(b.n.responseError(resp), "POST returned status code %d", resp.StatusCode)
+			if resp.StatusCode/100 != 5 {
+				return backoff.Permanent(err)
+			}
+			return err
+		},
+		bo,
+		func(err error, next time.Duration) {
+			b.n.diag.Error("failed to POST data, retrying", err, keyvalue.KV("retry", next.String()))
+		},
+	)
+	if err != nil {
+		b.n.diag.Error("failed to POST data", err, keyvalue.KV("rows", strconv.Itoa(len(rows))))
+	}
+}
+
 type mappedRow struct {
 	Name   string
 	Tags   map[string]string
@@ -76,6 +76,16 @@ type BatchPointsConfig struct {
 
 	// Write consistency is the number of servers required to confirm write
 	WriteConsistency string
+
+	// Bucket is the InfluxDB 2.x bucket to write points to,
+	// if set the points are written using the 2.x write API.
+	// Defaults to the database and retention policy, as database/retention-policy, for 2.x connections.
+	Bucket string
+
+	// Org and OrgID are the InfluxDB 2.x organization of the bucket.
+	// Default to the organization of the connection.
+	Org   string
+	OrgID string
 }
 
 // Query defines a query to send to the server
@@ -83,6 +93,8 @@ type Query struct {
 	Command   string
 	Database  string
 	Precision string
+	// Context of the query request, if not nil it can be used to cancel the query.
+	Context context.Context
 }
 
 type FluxQuery struct {
@@ -90,6 +102,8 @@ type FluxQuery struct {
 	OrgID string
 	Query string
 	Now   time.Time
+	// Context of the query request, if not nil it can be used to cancel the query.
+	Context context.Context
 }
 
 // HTTPConfig is the config data needed to create an HTTP Client
@@ -112,6 +126,15 @@ type Config struct {
 
 	// Which compression should we use for writing to influxdb, defaults to "gzip".
 	Compression string
+
+	// APIVersion is the version of the InfluxDB API, either 1 or 2, defaults to 1.
+	// Version 2 writes using the /api/v2/write API,
+	// InfluxQL queries use the 1.x compatible /query API of InfluxDB 2.x.
+	APIVersion int
+
+	// Org and OrgID are the default InfluxDB 2.x organization of writes and Flux queries.
+	Org   string
+	OrgID string
 }
 
 // AuthenticationMethod defines the type of authentication used.
@@ -544,7 +567,10 @@ func (c *HTTPClient) Ping(ctx context.Context) (time.Duration, string, error) {
 }
 
 func (c *HTTPClient) Write(bp BatchPoints) error {
-	b := &bytes.Buffer{}
+	config := c.loadConfig()
+	if config.APIVersion == 2 || bp.Bucket() != "" {
+		return c.writeV2Points(config, bp)
+	}
 	precision := bp.Precision()
 
 	u := c.url()
@@ -556,22 +582,81 @@ func (c *HTTPClient) Write(bp BatchPoints) error {
 	v.Set("consistency", bp.WriteConsistency())
 	u.RawQuery = v.Encode()
 
+	return c.writePoints(u, bp.Points(), precision, 0)
+}
+
+// writeV2Points writes the points using the 2.x write API.
+// The bucket defaults to database/retention-policy,
+// the naming of the buckets mapped to the 1.x databases and retention policies.
+func (c *HTTPClient) writeV2Points(config Config, bp BatchPoints) error {
+	bucket := bp.Bucket()
+	if bucket == "" {
+		bucket = bp.Database()
+		if rp := bp.RetentionPolicy(); rp != "" {
+			bucket += "/" + rp
+		}
+	}
+	org, orgID := bp.Org(), bp.OrgID()
+	if org == "" && orgID == "" {
+		org, orgID = config.Org, config.OrgID
+	}
+	precision, truncate := v2Precision(bp.Precision())
+
+	u := c.url()
+	u.Path = "api/v2/write"
+	v := url.Values{}
+	if org != "" {
+		v.Set("org", org)
+	}
+	if orgID != "" {
+		v.Set("orgID", orgID)
+	}
+	v.Set("bucket", bucket)
+	v.Set("precision", precision)
+	u.RawQuery = v.Encode()
+
+	return c.writePoints(u, bp.Points(), precision, truncate)
+}
+
+// v2Precision returns the 2.x write precision of a 1.x write precision.
+// The 2.x write API has no minute or hour precision,
+// those points are truncated to the precision and written with second precision.
+func v2Precision(precision string) (string, time.Duration) {
+	switch precision {
+	case "", "n", "ns":
+		return "ns", 0
+	case "u", "us", "µs":
+		return "us", 0
+	case "m":
+		return "s", time.Minute
+	case "h":
+		return "s", time.Hour
+	default:
+		return precision, 0
+	}
+}
+
+// writePoints writes the points as line protocol to the write API at u.
+// The times of the points are truncated to truncate if not zero.
+func (c *HTTPClient) writePoints(u url.URL, points []Point, precision string, truncate time.Duration) error {
+	b := &bytes.Buffer{}
+	var w io.Writer = b
+	var gz *gzip.Writer
 	if c.compression == "gzip" {
-		bodyWriteCloser := gzip.NewWriter(b)
-		for _, p := range bp.Points() {
-			if _, err := bodyWriteCloser.Write(p.BytesWithLineFeed(precision)); err != nil {
-				return err
-			}
+		gz = gzip.NewWriter(b)
+		w = gz
+	}
+	for _, p := range points {
+		if truncate > 0 {
+			p.Time = p.Time.Truncate(truncate)
 		}
-		if err := bodyWriteCloser.Close(); err != nil {
+		if _, err := w.Write(p.BytesWithLineFeed(precision)); err != nil {
 			return err
 		}
-
-	} else {
-		for _, p := range bp.Points() {
-			if _, err := b.Write(p.BytesWithLineFeed(precision)); err != nil {
-				return err
-			}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
 		}
 	}
 
@@ -580,7 +665,7 @@ func (c *HTTPClient) Write(bp BatchPoints) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
-	if c.compression == "gzip" {
+	if gz != nil {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
 
@@ -661,6 +746,10 @@ func (c *HTTPClient) buildFluxRequest(q FluxQuery) (*http.Request, error) {
 	u := c.url()
 	u.Path = "/api/v2/query"
 
+	if q.Org == "" && q.OrgID == "" {
+		config := c.loadConfig()
+		q.Org, q.OrgID = config.Org, config.OrgID
+	}
 	v := url.Values{}
 	if q.Org != "" {
 		v.Set("org", q.Org)
@@ -705,6 +794,9 @@ func (c *HTTPClient) buildFluxRequest(q FluxQuery) (*http.Request, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept-Encoding", "gzip")
+	if q.Context != nil {
+		req = req.WithContext(q.Context)
+	}
 	return req, nil
 }
 
@@ -759,6 +851,9 @@ func (c *HTTPClient) Query(q Query) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if q.Context != nil {
+		req = req.WithContext(q.Context)
+	}
 
 	response := &Response{}
 	_, err = c.do(req, response, http.StatusOK)
@@ -801,21 +896,29 @@ type BatchPoints interface {
 	RetentionPolicy() string
 	// SetRetentionPolicy sets the retention policy of this Batch
 	SetRetentionPolicy(s string)
+
+	// Bucket returns the InfluxDB 2.x bucket of this Batch
+	Bucket() string
+	// Org returns the InfluxDB 2.x organization of this Batch
+	Org() string
+	// OrgID returns the InfluxDB 2.x organization ID of this Batch
+	OrgID() string
 }
 
 // NewBatchPoints returns a BatchPoints interface based on the given config.
 func NewBatchPoints(conf BatchPointsConfig) (BatchPoints, error) {
-	if conf.Precision == "" {
-		conf.Precision = "ns"
-	}
-	if _, err := time.ParseDuration("1" + conf.Precision); err != nil {
+	precision, err := normalizePrecision(conf.Precision)
+	if err != nil {
 		return nil, err
 	}
 	bp := &batchpoints{
 		database:         conf.Database,
-		precision:        conf.Precision,
+		precision:        precision,
 		retentionPolicy:  conf.RetentionPolicy,
 		writeConsistency: conf.WriteConsistency,
+		bucket:           conf.Bucket,
+		org:              conf.Org,
+		orgID:            conf.OrgID,
 	}
 	return bp, nil
 }
@@ -826,6 +929,9 @@ type batchpoints struct {
 	precision        string
 	retentionPolicy  string
 	writeConsistency string
+	bucket           string
+	org              string
+	orgID            string
 }
 
 func (bp *batchpoints) AddPoint(p Point) {
@@ -856,14 +962,69 @@ func (bp *batchpoints) RetentionPolicy() string {
 	return bp.retentionPolicy
 }
 
+func (bp *batchpoints) Bucket() string {
+	return bp.bucket
+}
+
+func (bp *batchpoints) Org() string {
+	return bp.org
+}
+
+func (bp *batchpoints) OrgID() string {
+	return bp.orgID
+}
+
 func (bp *batchpoints) SetPrecision(p string) error {
-	if _, err := time.ParseDuration("1" + p); err != nil {
+	precision, err := normalizePrecision(p)
+	if err != nil {
 		return err
 	}
-	bp.precision = p
+	bp.precision = precision
 	return nil
 }
 
+// normalizePrecision returns the write precision of the 1.x write API for a precision,
+// which may also be named as a Go duration unit, i.e. us for microseconds.
+// The precision defaults to "ns".
+func normalizePrecision(precision string) (string, error) {
+	switch precision {
+	case "", "n", "ns":
+		return "ns", nil
+	case "u", "us", "µs":
+		return "u", nil
+	case "ms", "s", "m", "h":
+		return precision, nil
+	default:
+		return "", fmt.Errorf("invalid precision %q, must be one of ns, u, ms, s, m or h", precision)
+	}
+}
+
+// precisionTimestamp returns the timestamp of t in units of the precision.
+// The times between units are rounded down, also before the epoch, as they are by truncating to the precision.
+func precisionTimestamp(t time.Time, precision string) int64 {
+	var unit int64
+	switch precision {
+	case "u", "us", "µs":
+		unit = int64(time.Microsecond)
+	case "ms":
+		unit = int64(time.Millisecond)
+	case "s":
+		unit = int64(time.Second)
+	case "m":
+		unit = int64(time.Minute)
+	case "h":
+		unit = int64(time.Hour)
+	default:
+		return t.UnixNano()
+	}
+	ns := t.UnixNano()
+	ts := ns / unit
+	if ns%unit < 0 {
+		ts--
+	}
+	return ts
+}
+
 func (bp *batchpoints) SetDatabase(db string) {
 	bp.database = db
 }
@@ -897,7 +1058,7 @@ func (p Point) Bytes(precision string) []byte {
 		bytes[kl] = ' '
 		copy(bytes[kl+1:], fields)
 	} else {
-		timeStr := strconv.FormatInt(p.Time.UnixNano()/imodels.GetPrecisionMultiplier(precision), 10)
+		timeStr := strconv.FormatInt(precisionTimestamp(p.Time, precision), 10)
 		tl := len(timeStr)
 		bytes = make([]byte, fl+kl+tl+2)
 		copy(bytes, key)
@@ -923,7 +1084,7 @@ func (p Point) BytesWithLineFeed(precision string) []byte {
 		bytes[kl] = ' '
 		copy(bytes[kl+1:], fields)
 	} else {
-		timeStr := strconv.FormatInt(p.Time.UnixNano()/imodels.GetPrecisionMultiplier(precision), 10)
+		timeStr := strconv.FormatInt(precisionTimestamp(p.Time, precision), 10)
 		tl := len(timeStr)
 		bytes = make([]byte, fl+kl+tl+3)
 		copy(bytes, key)
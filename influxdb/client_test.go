@@ -5,9 +5,12 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -77,6 +80,28 @@ func TestClient_Query(t *testing.T) {
 	}
 }
 
+func TestClient_Query_Cancel(t *testing.T) {
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	config := Config{URLs: []string{ts.URL}}
+	c, _ := NewHTTPClient(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := c.Query(Query{Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error got %v exp %v", err, context.Canceled)
+	}
+}
+
 func TestClient_BasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		u, p, ok := r.BasicAuth()
@@ -243,6 +268,99 @@ func TestClient_Write(t *testing.T) {
 	}
 }
 
+func TestClient_WriteV2Mode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bpc       BatchPointsConfig
+		expQuery  url.Values
+		expPoints string
+	}{
+		{
+			name: "database and retention policy",
+			bpc:  BatchPointsConfig{Database: "db", RetentionPolicy: "rp"},
+			expQuery: url.Values{
+				"org":       []string{"myorg"},
+				"bucket":    []string{"db/rp"},
+				"precision": []string{"ns"},
+			},
+			expPoints: "testpt,tag1=tag1 value=1i 942106500000000003\n",
+		},
+		{
+			name: "bucket and org",
+			bpc:  BatchPointsConfig{Bucket: "mybucket", OrgID: "0123", Precision: "s"},
+			expQuery: url.Values{
+				"orgID":     []string{"0123"},
+				"bucket":    []string{"mybucket"},
+				"precision": []string{"s"},
+			},
+			expPoints: "testpt,tag1=tag1 value=1i 942106500\n",
+		},
+		{
+			name: "minute precision",
+			bpc:  BatchPointsConfig{Database: "db", Precision: "m"},
+			expQuery: url.Values{
+				"org":       []string{"myorg"},
+				"bucket":    []string{"db"},
+				"precision": []string{"s"},
+			},
+			expPoints: "testpt,tag1=tag1 value=1i 942106500\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got, exp := r.URL.Path, "/api/v2/write"; got != exp {
+					t.Errorf("unexpected path got %s exp %s", got, exp)
+				}
+				if got, exp := r.Header.Get("Authorization"), "Token mytoken"; got != exp {
+					t.Errorf("unexpected authorization got %s exp %s", got, exp)
+				}
+				if got := r.URL.Query(); !reflect.DeepEqual(got, tc.expQuery) {
+					t.Errorf("unexpected query got %v exp %v", got, tc.expQuery)
+				}
+				body, err := gzip.NewReader(r.Body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				points, err := io.ReadAll(body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if string(points) != tc.expPoints {
+					t.Errorf("unexpected points got %q exp %q", string(points), tc.expPoints)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			c, err := NewHTTPClient(Config{
+				URLs:        []string{ts.URL},
+				Credentials: Credentials{Method: TokenAuthentication, Token: "mytoken"},
+				APIVersion:  2,
+				Org:         "myorg",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bp, err := NewBatchPoints(tc.bpc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bp.AddPoint(Point{
+				Name:   "testpt",
+				Tags:   map[string]string{"tag1": "tag1"},
+				Fields: map[string]interface{}{"value": 1},
+				Time:   time.Date(1999, 11, 9, 0, 15, 0, 3, time.UTC),
+			})
+			if err := c.Write(bp); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestClient_WriteLarge(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping slow test that writes a batch of 100,000 points")
@@ -413,6 +531,44 @@ func TestClient_UserAgent(t *testing.T) {
 	}
 }
 
+func TestBatchPoints_Precision(t *testing.T) {
+	testCases := []struct {
+		precision string
+		exp       string
+	}{
+		{precision: "", exp: "ns"},
+		{precision: "n", exp: "ns"},
+		{precision: "ns", exp: "ns"},
+		{precision: "u", exp: "u"},
+		{precision: "us", exp: "u"},
+		{precision: "µs", exp: "u"},
+		{precision: "ms", exp: "ms"},
+		{precision: "s", exp: "s"},
+		{precision: "m", exp: "m"},
+		{precision: "h", exp: "h"},
+	}
+	for _, tc := range testCases {
+		bp, err := NewBatchPoints(BatchPointsConfig{Precision: tc.precision})
+		if err != nil {
+			t.Fatalf("precision %q: unexpected error %v", tc.precision, err)
+		}
+		if got := bp.Precision(); got != tc.exp {
+			t.Errorf("precision %q: unexpected precision got %q exp %q", tc.precision, got, tc.exp)
+		}
+		if err := bp.SetPrecision(tc.precision); err != nil {
+			t.Fatalf("precision %q: unexpected error %v", tc.precision, err)
+		}
+		if got := bp.Precision(); got != tc.exp {
+			t.Errorf("precision %q: unexpected precision got %q exp %q", tc.precision, got, tc.exp)
+		}
+	}
+	for _, p := range []string{"d", "1s", "x"} {
+		if _, err := NewBatchPoints(BatchPointsConfig{Precision: p}); err == nil {
+			t.Errorf("precision %q: expected error", p)
+		}
+	}
+}
+
 func TestBatchPoints_SettersGetters(t *testing.T) {
 	bp, _ := NewBatchPoints(BatchPointsConfig{
 		Precision:        "ns",
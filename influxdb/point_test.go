@@ -40,6 +40,12 @@ func TestPoint_Bytes(t *testing.T) {
 			exp:       "cpu,dc=nyc,host=serverA another=42i,value=1 946730096789012",
 			t:         tm,
 		},
+		{
+			name:      "microsecond precision as duration unit",
+			precision: "us",
+			exp:       "cpu,dc=nyc,host=serverA another=42i,value=1 946730096789012",
+			t:         tm,
+		},
 		{
 			name:      "millisecond precision",
 			precision: "ms",
@@ -64,6 +70,18 @@ func TestPoint_Bytes(t *testing.T) {
 			exp:       "cpu,dc=nyc,host=serverA another=42i,value=1 262980",
 			t:         tm,
 		},
+		{
+			name:      "second precision before epoch",
+			precision: "s",
+			exp:       "cpu,dc=nyc,host=serverA another=42i,value=1 -2",
+			t:         time.Unix(-1, -500000000),
+		},
+		{
+			name:      "hour precision before epoch",
+			precision: "h",
+			exp:       "cpu,dc=nyc,host=serverA another=42i,value=1 -1",
+			t:         time.Unix(-1, 0),
+		},
 	}
 
 	for _, test := range tests {
@@ -5,18 +5,22 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/influxdata/influxql"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/pkg/errors"
 )
 
 const (
-	statsInfluxDBPointsWritten = "points_written"
-	statsInfluxDBWriteErrors   = "write_errors"
+	statsInfluxDBPointsWritten  = "points_written"
+	statsInfluxDBWriteErrors    = "write_errors"
+	statsInfluxDBPointsDropped  = "points_dropped"
+	statsInfluxDBPointsBuffered = "points_buffered"
 )
 
 type InfluxDBOutNode struct {
@@ -24,8 +28,10 @@ type InfluxDBOutNode struct {
 	i  *pipeline.InfluxDBOutNode
 	wb *writeBuffer
 
-	pointsWritten *expvar.Int
-	writeErrors   *expvar.Int
+	pointsWritten  *expvar.Int
+	writeErrors    *expvar.Int
+	pointsDropped  *expvar.Int
+	pointsBuffered *expvar.Int
 
 	batchBuffer *edge.BatchBuffer
 }
@@ -41,7 +47,7 @@ func newInfluxDBOutNode(et *ExecutingTask, n *pipeline.InfluxDBOutNode, d NodeDi
 	in := &InfluxDBOutNode{
 		node:        node{Node: n, et: et, diag: d},
 		i:           n,
-		wb:          newWriteBuffer(int(n.Buffer), n.FlushInterval, cli),
+		wb:          newWriteBuffer(int(n.Buffer), n.FlushInterval, n.RetryTimeout, cli),
 		batchBuffer: new(edge.BatchBuffer),
 	}
 	in.node.runF = in.runOut
@@ -53,9 +59,13 @@ func newInfluxDBOutNode(et *ExecutingTask, n *pipeline.InfluxDBOutNode, d NodeDi
 func (n *InfluxDBOutNode) runOut([]byte) error {
 	n.pointsWritten = &expvar.Int{}
 	n.writeErrors = &expvar.Int{}
+	n.pointsDropped = &expvar.Int{}
+	n.pointsBuffered = &expvar.Int{}
 
 	n.statMap.Set(statsInfluxDBPointsWritten, n.pointsWritten)
 	n.statMap.Set(statsInfluxDBWriteErrors, n.writeErrors)
+	n.statMap.Set(statsInfluxDBPointsDropped, n.pointsDropped)
+	n.statMap.Set(statsInfluxDBPointsBuffered, n.pointsBuffered)
 
 	// Start the write buffer
 	n.wb.start()
@@ -67,6 +77,9 @@ func (n *InfluxDBOutNode) runOut([]byte) error {
 			if err != nil {
 				return err
 			}
+			if n.i.Bucket != "" {
+				return cli.CreateBucketV2(n.i.Bucket, n.i.Org, n.i.OrgID)
+			}
 			var createDb bytes.Buffer
 			createDb.WriteString("CREATE DATABASE ")
 			createDb.WriteString(influxql.QuoteIdent(n.i.Database))
@@ -81,7 +94,7 @@ func (n *InfluxDBOutNode) runOut([]byte) error {
 			return nil
 		}()
 		if err != nil {
-			n.diag.Error("failed to create database", err, keyvalue.KV("database", n.i.Database), keyvalue.KV("cluster", n.i.Cluster))
+			n.diag.Error("failed to create database", err, keyvalue.KV("database", n.i.Database), keyvalue.KV("bucket", n.i.Bucket), keyvalue.KV("cluster", n.i.Cluster))
 		}
 	}
 
@@ -160,11 +173,19 @@ func (n *InfluxDBOutNode) write(db, rp string, batch edge.BufferedBatchMessage)
 		name = batch.Name()
 	}
 
-	points := make([]influxdb.Point, len(batch.Points()))
-	for j, p := range batch.Points() {
+	points := make([]influxdb.Point, 0, len(batch.Points()))
+	for _, p := range batch.Points() {
+		fields := scalarFields(p.Fields())
+		if len(fields) == 0 {
+			// The point only has array fields, which InfluxDB cannot store.
+			continue
+		}
 		var tags map[string]string
-		if len(n.i.Tags) > 0 {
-			tags = make(map[string]string, len(p.Tags())+len(n.i.Tags))
+		if len(n.i.Tags) > 0 || len(n.et.tm.GlobalTags) > 0 {
+			tags = make(map[string]string, len(n.et.tm.GlobalTags)+len(p.Tags())+len(n.i.Tags))
+			for k, v := range n.et.tm.GlobalTags {
+				tags[k] = v
+			}
 			for k, v := range p.Tags() {
 				tags[k] = v
 			}
@@ -174,28 +195,58 @@ func (n *InfluxDBOutNode) write(db, rp string, batch edge.BufferedBatchMessage)
 		} else {
 			tags = p.Tags()
 		}
-		points[j] = influxdb.Point{
+		points = append(points, influxdb.Point{
 			Name:   name,
 			Tags:   tags,
-			Fields: p.Fields(),
+			Fields: fields,
 			Time:   p.Time(),
-		}
+		})
+	}
+	if len(points) == 0 {
+		return nil
 	}
 	bpc := influxdb.BatchPointsConfig{
 		Database:         db,
 		RetentionPolicy:  rp,
 		WriteConsistency: n.i.WriteConsistency,
 		Precision:        n.i.Precision,
+		Bucket:           n.i.Bucket,
+		Org:              n.i.Org,
+		OrgID:            n.i.OrgID,
 	}
 	n.wb.enqueue(bpc, points)
 	return nil
 }
 
+// scalarFields returns the fields without the array fields, since InfluxDB fields are scalar.
+// The fields are returned as is if there are no array fields.
+func scalarFields(fields models.Fields) models.Fields {
+	arrays := 0
+	for _, v := range fields {
+		if _, ok := v.([]float64); ok {
+			arrays++
+		}
+	}
+	if arrays == 0 {
+		return fields
+	}
+	scalar := make(models.Fields, len(fields)-arrays)
+	for k, v := range fields {
+		if _, ok := v.([]float64); !ok {
+			scalar[k] = v
+		}
+	}
+	return scalar
+}
+
 type writeBuffer struct {
 	size          int
 	flushInterval time.Duration
+	retryTimeout  time.Duration
 	queue         chan queueEntry
 	buffer        map[influxdb.BatchPointsConfig]influxdb.BatchPoints
+	// Number of points in the buffer
+	buffered int
 
 	flushing chan struct{}
 	flushed  chan struct{}
@@ -212,11 +263,12 @@ type queueEntry struct {
 	points []influxdb.Point
 }
 
-func newWriteBuffer(size int, flushInterval time.Duration, cli influxdb.Client) *writeBuffer {
+func newWriteBuffer(size int, flushInterval, retryTimeout time.Duration, cli influxdb.Client) *writeBuffer {
 	return &writeBuffer{
 		cli:           cli,
 		size:          size,
 		flushInterval: flushInterval,
+		retryTimeout:  retryTimeout,
 		flushing:      make(chan struct{}),
 		flushed:       make(chan struct{}),
 		queue:         make(chan queueEntry),
@@ -230,6 +282,9 @@ func (w *writeBuffer) enqueue(bpc influxdb.BatchPointsConfig, points []influxdb.
 		bpc:    bpc,
 		points: points,
 	}
+	// The buffer does not receive while it writes.
+	w.i.timer.Pause()
+	defer w.i.timer.Resume()
 	select {
 	case w.queue <- qe:
 	case <-w.stopping:
@@ -264,12 +319,14 @@ func (w *writeBuffer) run() {
 			if !ok {
 				bp, err = influxdb.NewBatchPoints(qe.bpc)
 				if err != nil {
+					w.i.pointsDropped.Add(int64(len(qe.points)))
 					w.i.diag.Error("failed to write points to InfluxDB", err)
 					break
 				}
 				w.buffer[qe.bpc] = bp
 			}
 			bp.AddPoints(qe.points)
+			w.setBuffered(w.buffered + len(qe.points))
 			// Check if we hit buffer size
 			if len(bp.Points()) >= w.size {
 				err = w.write(bp)
@@ -277,6 +334,7 @@ func (w *writeBuffer) run() {
 					w.i.diag.Error("failed to write points to InfluxDB", err)
 				}
 				delete(w.buffer, qe.bpc)
+				w.setBuffered(w.buffered - len(bp.Points()))
 			}
 		case <-w.flushing:
 			// Explicit flush called
@@ -299,12 +357,38 @@ func (w *writeBuffer) writeAll() {
 		}
 		delete(w.buffer, bpc)
 	}
+	w.setBuffered(0)
 }
 
+func (w *writeBuffer) setBuffered(n int) {
+	w.buffered = n
+	w.i.pointsBuffered.Set(int64(n))
+}
+
+// write writes the points, retrying failed writes with exponential backoff until the retry timeout.
+// The points are dropped if all attempts fail.
 func (w *writeBuffer) write(bp influxdb.BatchPoints) error {
-	err := w.cli.Write(bp)
+	var b backoff.BackOff = &backoff.StopBackOff{}
+	if w.retryTimeout > 0 {
+		eb := backoff.NewExponentialBackOff()
+		eb.MaxElapsedTime = w.retryTimeout
+		b = eb
+	}
+	err := backoff.RetryNotify(
+		func() error {
+			err := w.cli.Write(bp)
+			if err != nil {
+				w.i.writeErrors.Add(1)
+			}
+			return err
+		},
+		b,
+		func(err error, next time.Duration) {
+			w.i.diag.Error("failed to write points to InfluxDB, retrying", err, keyvalue.KV("retry", next.String()))
+		},
+	)
 	if err != nil {
-		w.i.writeErrors.Add(1)
+		w.i.pointsDropped.Add(int64(len(bp.Points())))
 		return err
 	}
 	w.i.pointsWritten.Add(int64(len(bp.Points())))
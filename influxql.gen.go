@@ -77,6 +77,13 @@ func (a *floatPointAggregator) AggregatePoint(name string, p edge.FieldsTagsTime
 	return nil
 }
 
+// SetEnd passes the end of the reduced batch to the aggregator if it uses it.
+func (a *floatPointAggregator) SetEnd(end time.Time) {
+	if s, ok := a.aggregator.(pipeline.EndSetter); ok {
+		s.SetEnd(end.UnixNano())
+	}
+}
+
 type floatPointEmitter struct {
 	baseReduceContext
 	emitter          query.FloatPointEmitter
@@ -234,6 +241,13 @@ func (a *integerPointAggregator) AggregatePoint(name string, p edge.FieldsTagsTi
 	return nil
 }
 
+// SetEnd passes the end of the reduced batch to the aggregator if it uses it.
+func (a *integerPointAggregator) SetEnd(end time.Time) {
+	if s, ok := a.aggregator.(pipeline.EndSetter); ok {
+		s.SetEnd(end.UnixNano())
+	}
+}
+
 type integerPointEmitter struct {
 	baseReduceContext
 	emitter          query.IntegerPointEmitter
@@ -391,6 +405,13 @@ func (a *stringPointAggregator) AggregatePoint(name string, p edge.FieldsTagsTim
 	return nil
 }
 
+// SetEnd passes the end of the reduced batch to the aggregator if it uses it.
+func (a *stringPointAggregator) SetEnd(end time.Time) {
+	if s, ok := a.aggregator.(pipeline.EndSetter); ok {
+		s.SetEnd(end.UnixNano())
+	}
+}
+
 type stringPointEmitter struct {
 	baseReduceContext
 	emitter          query.StringPointEmitter
@@ -548,6 +569,13 @@ func (a *booleanPointAggregator) AggregatePoint(name string, p edge.FieldsTagsTi
 	return nil
 }
 
+// SetEnd passes the end of the reduced batch to the aggregator if it uses it.
+func (a *booleanPointAggregator) SetEnd(end time.Time) {
+	if s, ok := a.aggregator.(pipeline.EndSetter); ok {
+		s.SetEnd(end.UnixNano())
+	}
+}
+
 type booleanPointEmitter struct {
 	baseReduceContext
 	emitter          query.BooleanPointEmitter
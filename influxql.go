@@ -8,6 +8,8 @@ import (
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
 	"github.com/pkg/errors"
 )
 
@@ -22,6 +24,10 @@ type InfluxQLNode struct {
 	createFn               createReduceContextFunc
 	isStreamTransformation bool
 
+	// predicate selects the aggregated points, if set.
+	predicate stateful.Expression
+	scopePool stateful.ScopePool
+
 	currentKind reflect.Kind
 }
 
@@ -31,12 +37,21 @@ func newInfluxQLNode(et *ExecutingTask, n *pipeline.InfluxQLNode, d NodeDiagnost
 		n:                      n,
 		isStreamTransformation: n.ReduceCreater.IsStreamTransformation,
 	}
+	if n.Predicate != nil {
+		expr, err := stateful.NewExpression(n.Predicate.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile predicate of %s: %v", n.Method, err)
+		}
+		m.predicate = expr
+		m.scopePool = stateful.NewScopePool(ast.FindReferenceVariables(n.Predicate.Expression))
+	}
 	m.node.runF = m.runInfluxQL
 	return m, nil
 }
 
 type reduceContext interface {
 	AggregatePoint(name string, p edge.FieldsTagsTimeGetter) error
+	SetEnd(end time.Time)
 	EmitPoint() (edge.PointMessage, error)
 	EmitBatch() edge.BufferedBatchMessage
 }
@@ -79,6 +94,9 @@ func (n *InfluxQLNode) newGroup(first edge.PointMeta) edge.ForwardReceiver {
 		n:  n,
 		bc: bc,
 	}
+	if n.predicate != nil {
+		g.predicate = n.predicate.CopyReset()
+	}
 	if n.isStreamTransformation {
 		return &influxqlStreamingTransformGroup{
 			influxqlGroup: g,
@@ -93,6 +111,8 @@ type influxqlGroup struct {
 	bc baseReduceContext
 	rc reduceContext
 
+	predicate stateful.Expression
+
 	batchSize int
 	begin     edge.BeginBatchMessage
 }
@@ -106,13 +126,17 @@ func (g *influxqlGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message,
 }
 
 func (g *influxqlGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	p, ok := g.filter(bp)
+	if !ok {
+		return nil, nil
+	}
 	if g.rc == nil {
-		if err := g.realizeReduceContextFromFields(bp.Fields()); err != nil {
+		if err := g.realizeReduceContextFromFields(p.Fields()); err != nil {
 			g.n.diag.Error("failed to realize reduce context from fields", err)
 			return nil, nil
 		}
 	}
-	if err := g.rc.AggregatePoint(g.begin.Name(), bp); err != nil {
+	if err := g.rc.AggregatePoint(g.begin.Name(), p); err != nil {
 		g.n.diag.Error("failed to aggregate point in batch", err)
 	}
 	g.batchSize++
@@ -130,6 +154,8 @@ func (g *influxqlGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error)
 			return nil, err
 		}
 	}
+	// The time of the batch is the end of its window.
+	g.rc.SetEnd(g.bc.time)
 	m, err := g.n.emit(g.rc)
 	if err != nil {
 		g.n.diag.Error("failed to emit batch", err)
@@ -166,18 +192,54 @@ func (g *influxqlGroup) Point(p edge.PointMessage) (edge.Message, error) {
 }
 
 func (g *influxqlGroup) aggregatePoint(p edge.PointMessage) {
+	fp, ok := g.filter(p)
+	if !ok {
+		return
+	}
 	if g.rc == nil {
-		if err := g.realizeReduceContextFromFields(p.Fields()); err != nil {
+		if err := g.realizeReduceContextFromFields(fp.Fields()); err != nil {
 			g.n.diag.Error("failed to realize reduce context from fields", err)
 			return
 		}
 	}
-	err := g.rc.AggregatePoint(p.Name(), p)
+	err := g.rc.AggregatePoint(p.Name(), fp)
 	if err != nil {
 		g.n.diag.Error("failed to aggregate point", err)
 	}
 }
 
+// countIfFields are the fields aggregated for each point that matches the predicate of countIf,
+// since countIf has no field.
+var countIfFields = models.Fields{"": true}
+
+// countIfPoint is a point matching the predicate of countIf.
+type countIfPoint struct {
+	edge.FieldsTagsTimeGetter
+}
+
+func (countIfPoint) Fields() models.Fields {
+	return countIfFields
+}
+
+// filter returns the point to aggregate and whether it matches the predicate, if any.
+func (g *influxqlGroup) filter(p edge.FieldsTagsTimeGetter) (edge.FieldsTagsTimeGetter, bool) {
+	if g.predicate == nil {
+		return p, true
+	}
+	pass, err := EvalPredicate(g.predicate, g.n.scopePool, p)
+	if err != nil {
+		g.n.diag.Error("error while evaluating predicate", err)
+		return nil, false
+	}
+	if !pass {
+		return nil, false
+	}
+	if g.bc.field == "" {
+		return countIfPoint{p}, true
+	}
+	return p, true
+}
+
 func (g *influxqlGroup) getFieldKind(fields models.Fields) (reflect.Kind, error) {
 	f, exists := fields[g.bc.field]
 	if !exists {
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -813,6 +814,112 @@ batch
 	testBatcherWithOutput(t, "TestBatch_CountEmptyBatch", script, 30*time.Second, er, false)
 }
 
+func TestBatch_CountIf(t *testing.T) {
+	var script = `
+batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "host" = 'serverA'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('cpu')
+	|countIf(lambda: "mean" > 90)
+		.as('idle')
+	|httpOut('TestBatch_CountEmptyBatch')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu-total"},
+				Columns: []string{"time", "idle"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					2.0,
+				}},
+			},
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu0"},
+				Columns: []string{"time", "idle"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					0.0,
+				}},
+			},
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu1"},
+				Columns: []string{"time", "idle"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					5.0,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_CountEmptyBatch", script, 30*time.Second, er, false)
+}
+
+func TestBatch_SumIfMeanIf(t *testing.T) {
+	var script = `
+var data = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "host" = 'serverA'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('cpu')
+
+var sum = data
+	|sumIf('mean', lambda: "mean" > 90)
+		.as('sum')
+
+var mean = data
+	|meanIf('mean', lambda: "mean" > 90)
+		.as('mean')
+
+sum
+	|join(mean)
+		.as('s', 'm')
+	|httpOut('TestBatch_CountEmptyBatch')
+`
+
+	// No point of cpu0 matches, so its mean is null and nothing is joined.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu-total"},
+				Columns: []string{"time", "m.mean", "s.sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					90.8470101311789,
+					181.6940202623578,
+				}},
+			},
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu1"},
+				Columns: []string{"time", "m.mean", "s.sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					93.7948294829408,
+					468.97414741470396,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_CountEmptyBatch", script, 30*time.Second, er, false)
+}
+
 func TestBatch_SumEmptyBatch(t *testing.T) {
 
 	var script = `
@@ -1586,6 +1693,7 @@ func TestBatch_AlertStateChangesOnly(t *testing.T) {
 			expAd := alert.Data{
 				ID:            "cpu_usage_idle:cpu=cpu-total",
 				Message:       "cpu_usage_idle:cpu=cpu-total is CRITICAL",
+				Fingerprint:   "129a37f93ad56baa",
 				Time:          time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 				Level:         alert.Critical,
 				PreviousLevel: alert.OK,
@@ -1599,6 +1707,7 @@ func TestBatch_AlertStateChangesOnly(t *testing.T) {
 			expAd := alert.Data{
 				ID:            "cpu_usage_idle:cpu=cpu-total",
 				Message:       "cpu_usage_idle:cpu=cpu-total is OK",
+				Fingerprint:   "129a37f93ad56baa",
 				Time:          time.Date(1971, 1, 1, 0, 0, 38, 0, time.UTC),
 				Duration:      38 * time.Second,
 				Level:         alert.OK,
@@ -1660,6 +1769,7 @@ func TestBatch_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu_usage_idle:cpu=cpu-total",
 				Message:       "cpu_usage_idle:cpu=cpu-total is CRITICAL",
+				Fingerprint:   "129a37f93ad56baa",
 				Time:          time.Date(1971, 1, 1, 0, 0, int(rc-1)*20, 0, time.UTC),
 				Duration:      time.Duration(rc-1) * 20 * time.Second,
 				Level:         alert.Critical,
@@ -1670,6 +1780,7 @@ func TestBatch_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu_usage_idle:cpu=cpu-total",
 				Message:       "cpu_usage_idle:cpu=cpu-total is CRITICAL",
+				Fingerprint:   "129a37f93ad56baa",
 				Time:          time.Date(1971, 1, 1, 0, 0, int(rc-1)*20, 0, time.UTC),
 				Duration:      time.Duration(rc-1) * 20 * time.Second,
 				Level:         alert.Critical,
@@ -1680,6 +1791,7 @@ func TestBatch_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu_usage_idle:cpu=cpu-total",
 				Message:       "cpu_usage_idle:cpu=cpu-total is OK",
+				Fingerprint:   "129a37f93ad56baa",
 				Time:          time.Date(1971, 1, 1, 0, 0, 38, 0, time.UTC),
 				Duration:      38 * time.Second,
 				Level:         alert.OK,
@@ -3755,6 +3867,91 @@ batch
 	}
 }
 
+func TestBatch_QueryBackfill(t *testing.T) {
+	var script = `
+batch
+	|query('SELECT mean("value") FROM "dbname"."rpname".cpu')
+		.period(10s)
+		.every(10s)
+		.align()
+		.backfill(1m)
+		.backfillConcurrency(3)
+`
+	var mu sync.Mutex
+	var queries []string
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("q"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+
+	tm, err := createTaskMaster("TestBatch_QueryBackfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.InfluxDBService = influxdb
+	if err := tm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Close()
+
+	task, err := tm.NewTask("TestBatch_QueryBackfill", script, kapacitor.BatchTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	et, err := tm.StartTask(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.StartBatching(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait until the backfill has caught up to the last tick before the task started.
+	var qs struct {
+		LastTick time.Time `json:"lastTick"`
+	}
+	timeout := time.After(5 * time.Second)
+	for start.Sub(qs.LastTick) > 10*time.Second {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out waiting for backfill, last tick %v", qs.LastTick)
+		case <-time.After(10 * time.Millisecond):
+		}
+		snapshot, err := et.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data := snapshot.NodeSnapshots["query1"]; len(data) > 0 {
+			if err := json.Unmarshal(data, &qs); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if qs.LastTick.UnixNano()%int64(10*time.Second) != 0 {
+		t.Errorf("last tick %v is not aligned", qs.LastTick)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The minute before the task started has five or six aligned ticks.
+	if got := len(queries); got < 5 {
+		t.Errorf("unexpected number of backfill queries got %d exp at least 5", got)
+	}
+	for i := 1; i < len(queries); i++ {
+		if queries[i] == queries[i-1] {
+			t.Errorf("query %d repeats the previous query %q", i, queries[i])
+		}
+	}
+}
+
 // Helper test function for batcher
 func testBatcher(t *testing.T, name, script string) (clock.Setter, *kapacitor.ExecutingTask, <-chan error, *kapacitor.TaskMaster) {
 	t.Helper()
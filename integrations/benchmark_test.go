@@ -64,6 +64,18 @@ m1
 		.tolerance(1s)
 	|eval(lambda: "m1.value" * "m2.value")
 		.as('value')
+`
+	evalSeparateM1Task = `stream
+	|from()
+		.measurement('m1')
+	|eval(lambda: "value" * 2.0, lambda: "value" + 1.0, lambda: "value" - 1.0)
+		.as('double', 'next', 'prev')
+`
+	evalMultiNamesM1Task = `stream
+	|from()
+		.measurement('m1')
+	|eval(lambda: array("value" * 2.0, "value" + 1.0, "value" - 1.0))
+		.as('[double, next, prev]')
 `
 )
 
@@ -163,6 +175,109 @@ func BenchmarkJoinTask_T100_P5000(b *testing.B) {
 	Bench(b, 100, 5000, 10000, joinM12Task, "dbname", "rpname", "m1", "m2")
 }
 
+//----------------------------
+// Eval Task Benchmarks
+
+// Several fields computed by separate expressions
+func BenchmarkEvalSeparateTask_T10_P50000(b *testing.B) {
+	Bench(b, 10, 50000, 50000, evalSeparateM1Task, "dbname", "rpname", "m1")
+}
+
+// The same fields computed by a single expression with named array elements
+func BenchmarkEvalMultiNamesTask_T10_P50000(b *testing.B) {
+	Bench(b, 10, 50000, 50000, evalMultiNamesM1Task, "dbname", "rpname", "m1")
+}
+
+//----------------------------
+// Routing Benchmarks
+
+// Many tasks each reading its own measurement, the points of a measurement are only routed to its task.
+func BenchmarkRoutedTask_T500_P50(b *testing.B) {
+	BenchRouted(b, 500, 50)
+}
+
+func BenchmarkRoutedTask_T100_P500(b *testing.B) {
+	BenchRouted(b, 100, 500)
+}
+
+// BenchRouted benchmarks tasks reading a measurement each, with pointCount points written to each measurement.
+func BenchRouted(b *testing.B, tasksCount, pointCount int) {
+	config := httpd.NewConfig()
+	config.BindAddress = ":0" // Choose port dynamically
+	config.LogEnabled = false
+
+	db, rp := "dbname", "rpname"
+	dbrps := []kapacitor.DBRP{{Database: db, RetentionPolicy: rp}}
+
+	writes := make([]struct {
+		request *http.Request
+		seeker  io.Seeker
+	}, tasksCount)
+	for i := range writes {
+		writes[i].request, writes[i].seeker = createWriteRequest(b, db, rp, fmt.Sprintf("m%d", i), pointCount)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// Do not time setup
+		b.StopTimer()
+		tm, err := createTaskMaster("testStreamer")
+		if err != nil {
+			b.Fatal(err)
+		}
+		tm.Open()
+		httpdService := tm.HTTPDService.(*httpd.Service)
+
+		httpdService.Handler.PointsWriter = tm
+		tasks := make([]*kapacitor.ExecutingTask, tasksCount)
+		for j := range tasks {
+			task, err := tm.NewTask(
+				fmt.Sprintf("task_%v", j),
+				fmt.Sprintf("stream\n\t|from()\n\t\t.measurement('m%d')\n", j),
+				kapacitor.StreamTask,
+				dbrps,
+				0,
+				nil,
+			)
+			if err != nil {
+				b.Fatal(err)
+			}
+			tasks[j], err = tm.StartTask(task)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		for _, write := range writes {
+			write.seeker.Seek(0, 0)
+		}
+
+		// Time how long it takes to process all data
+		b.StartTimer()
+		for _, write := range writes {
+			responseRecorder := httptest.NewRecorder()
+			httpdService.Handler.ServeHTTP(responseRecorder, write.request)
+			if responseRecorder.Code != http.StatusNoContent {
+				b.Errorf("failed to write test data %s", responseRecorder.Body.String())
+			}
+		}
+
+		tm.Drain()
+		for _, t := range tasks {
+			t.Wait()
+		}
+
+		// Do not time cleanup
+		b.StopTimer()
+		validateTasks(b, tm, tasks, pointCount)
+
+		tm.Close()
+		httpdService.Close()
+	}
+}
+
 // Generic Benchmark method
 func Bench(b *testing.B, tasksCount, pointCount, expectedProcessedCount int, tickScript, db, rp string, measurements ...string) {
 	// Setup HTTPD service
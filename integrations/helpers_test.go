@@ -175,6 +175,7 @@ type deadman struct {
 	id        string
 	message   string
 	global    bool
+	maxGroups int64
 }
 
 func (d deadman) Interval() time.Duration { return d.interval }
@@ -182,6 +183,7 @@ func (d deadman) Threshold() float64      { return d.threshold }
 func (d deadman) Id() string              { return d.id }
 func (d deadman) Message() string         { return d.message }
 func (d deadman) Global() bool            { return d.global }
+func (d deadman) MaxGroups() int64        { return d.maxGroups }
 
 type serverInfo struct {
 	clusterID,
@@ -17,6 +17,9 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -83,6 +86,8 @@ import (
 	"github.com/influxdata/kapacitor/services/telegram/telegramtest"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/influxdata/kapacitor/services/victorops/victoropstest"
+	"github.com/influxdata/kapacitor/services/zabbix"
+	"github.com/influxdata/kapacitor/services/zabbix/zabbixtest"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/services/zenoss/zenosstest"
 	"github.com/influxdata/kapacitor/tick/stateful"
@@ -258,6 +263,228 @@ func TestStream_ChangeDetect_Many(t *testing.T) {
 	testStreamerWithOutput(t, "TestStream_ChangeDetect_Many", script, 15*time.Second, er, false, nil)
 }
 
+func TestStream_ChangeDetect_Tolerance(t *testing.T) {
+
+	var script = `stream
+	|from().measurement('temperature')
+	|changeDetect('value')
+		.tolerance(0.5)
+		.previousPrefix('previous_')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ChangeDetect_Tolerance')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "temperature",
+				Tags:    nil,
+				Columns: []string{"time", "previous_value", "value"},
+				Values: [][]interface{}{
+					[]interface{}{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						10.0,
+						10.6,
+					},
+					[]interface{}{
+						time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
+						10.6,
+						11.5,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ChangeDetect_Tolerance", script, 25*time.Second, er, false, nil)
+}
+
+func TestStream_ChangeDetect_MaxGroups(t *testing.T) {
+
+	var script = `stream
+	|from().measurement('status')
+		.groupBy('host')
+	|changeDetect('value')
+		.maxGroups(1)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ChangeDetect_MaxGroups')
+`
+
+	// The values of each host are forgotten once the other host changes,
+	// so every point is emitted.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "status",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					[]interface{}{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					[]interface{}{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1.0},
+				},
+			},
+			{
+				Name:    "status",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					[]interface{}{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1.0},
+					[]interface{}{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ChangeDetect_MaxGroups", script, 13*time.Second, er, true, nil)
+}
+
+func TestStream_Reorder(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+	|reorder(2s)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Reorder')
+`
+	values := []float64{1, 2, 3, 4, 5, 7, 8}
+	times := []int{0, 1, 2, 3, 4, 6, 7}
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+			},
+		},
+	}
+	for i, v := range values {
+		er.Series[0].Values = append(er.Series[0].Values, []interface{}{
+			time.Date(1971, 1, 1, 0, 0, times[i], 0, time.UTC),
+			v,
+		})
+	}
+
+	testStreamerWithOutput(t, "TestStream_Reorder", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_TrendSlope(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('disk')
+	|trendSlope('used', 2s)
+		.unit(1m)
+		.r2('r2')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TrendSlope')
+`
+	// The first point has no line to fit, the others fit the points within the last 2s.
+	values := []float64{0, 2, 4, 6, 8, 8, 8, 8, 9, 12}
+	slopes := []float64{0, 120, 120, 120, 120, 60, 0, 0, 30, 120}
+	r2s := []float64{0, 1, 1, 1, 1, 0.7499999999999999, 1, 1, 0.75, 0.9230769230769229}
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "disk",
+				Tags:    nil,
+				Columns: []string{"time", "r2", "slope", "used"},
+			},
+		},
+	}
+	for i, v := range values {
+		er.Series[0].Values = append(er.Series[0].Values, []interface{}{
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+			r2s[i],
+			slopes[i],
+			v,
+		})
+	}
+
+	testStreamerWithOutput(t, "TestStream_TrendSlope", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Baseline(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|baseline('rate', 10s)
+		.bucket(5s)
+		.seasons(2)
+	|where(lambda: isPresent("baseline"))
+	|window()
+		.period(40s)
+		.every(40s)
+		.align()
+	|httpOut('TestStream_Baseline')
+`
+	// The points of the first season at 0s and 5s have no baseline,
+	// the point at 30s only averages the two seasons before it.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "baseline", "deviation", "rate"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 10.0, 4.0, 14.0},
+					{time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC), 20.0, 6.0, 26.0},
+					{time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), 12.0, 1.0, 13.0},
+					{time.Date(1971, 1, 1, 0, 0, 21, 0, time.UTC), 12.0, 3.0, 15.0},
+					{time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), 14.0, 6.0, 20.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Baseline", script, 45*time.Second, er, false, nil)
+}
+
+func TestStream_Rolling(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|rollingSum('value', 3)
+		.period(2s)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Rolling')
+`
+	// There is no point at 5s, so the points at 6s and 7s only aggregate the points within 2s.
+	values := []float64{1, 2, 3, 4, 5, 7, 8, 9, 10}
+	sums := []float64{1, 3, 6, 9, 12, 12, 15, 24, 27}
+	times := []int{0, 1, 2, 3, 4, 6, 7, 8, 9}
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "sum", "value"},
+			},
+		},
+	}
+	for i, v := range values {
+		er.Series[0].Values = append(er.Series[0].Values, []interface{}{
+			time.Date(1971, 1, 1, 0, 0, times[i], 0, time.UTC),
+			sums[i],
+			v,
+		})
+	}
+
+	testStreamerWithOutput(t, "TestStream_Rolling", script, 15*time.Second, er, false, nil)
+}
+
 func TestStream_Derivative(t *testing.T) {
 
 	var script = `
@@ -448,6 +675,36 @@ stream
 	testStreamerWithOutput(t, "TestStream_DerivativeNN", script, 15*time.Second, er, false, nil)
 }
 
+func TestStream_DerivativeCounterReset(t *testing.T) {
+
+	var script = `
+stream
+	|from().measurement('packets')
+	|derivative('value')
+		.counterReset()
+	|window()
+		.period(10s)
+		.every(10s)
+	|sum('value')
+	|httpOut('TestStream_DerivativeCounterReset')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					14.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DerivativeCounterReset", script, 15*time.Second, er, false, nil)
+}
+
 func TestStream_DerivativeN(t *testing.T) {
 
 	var script = `
@@ -915,6 +1172,52 @@ stream
 	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
 }
 
+func TestStream_Window_Partial(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+		.partialEvery(3s)
+	|count('value')
+	|httpOut('TestStream_Window')
+`
+
+	// The partial windows are a separate series from the complete windows.
+	// The last partial window at 9s contains the same points as the complete window.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"partial": "true"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, true, nil)
+}
+
 func TestStream_Window_Count(t *testing.T) {
 
 	var script = `
@@ -2919,94 +3222,1297 @@ stream
 	testStreamerWithOutput(t, "TestStream_Eval_Tags", script, 2*time.Second, er, true, nil)
 }
 
-func TestStream_EvalGroups(t *testing.T) {
+func TestStream_EvalDistanceFromLast(t *testing.T) {
+
 	var script = `
 stream
 	|from()
-		.measurement('types')
-		.groupBy('group')
-	|eval(lambda: count())
-		.as('count')
-	|httpOut('TestStream_EvalGroups')
+		.measurement('location')
+		.groupBy('asset')
+	|eval(lambda: distanceFromLast("lat", "lon", 'km'), lambda: haversine("lat", "lon", 0.0, 0.0, 'km'))
+		.as('moved', 'distance')
+		.keep('moved', 'distance')
+	|eval(lambda: floor("moved"), lambda: floor("distance"))
+		.as('moved', 'distance')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_EvalDistanceFromLast')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "types",
-				Tags:    map[string]string{"group": "A"},
-				Columns: []string{"time", "count"},
+				Name:    "location",
+				Tags:    map[string]string{"asset": "a"},
+				Columns: []string{"time", "distance", "moved"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-						2.0,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 111.0, 111.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 157.0, 111.0},
 				},
 			},
 			{
-				Name:    "types",
-				Tags:    map[string]string{"group": "B"},
-				Columns: []string{"time", "count"},
+				Name:    "location",
+				Tags:    map[string]string{"asset": "b"},
+				Columns: []string{"time", "distance", "moved"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-						2.0,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1568.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1568.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1648.0, 111.0},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_EvalDistanceFromLast", script, 13*time.Second, er, true, nil)
 }
 
-func TestStream_Eval_Time(t *testing.T) {
+func TestStream_EvalAccumulate(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('types')
-		.groupBy('group')
-	|eval(lambda: hour("time"))
-		.as('hour')
-	|httpOut('TestStream_Eval_Time')
+		.measurement('requests')
+		.groupBy('host')
+	|eval(lambda: accumulate("status" != 200, "status" == 200))
+		.as('failures')
+		.keep('failures')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_EvalAccumulate')
 `
-	hour := float64(time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC).Local().Hour())
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "types",
-				Tags:    map[string]string{"group": "A"},
-				Columns: []string{"time", "hour"},
+				Name:    "requests",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "failures"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
-						hour,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 3.0},
 				},
 			},
 			{
-				Name:    "types",
-				Tags:    map[string]string{"group": "B"},
-				Columns: []string{"time", "hour"},
+				Name:    "requests",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "failures"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
-						hour,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 0.0},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Eval_Time", script, 2*time.Hour, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_EvalAccumulate", script, 13*time.Second, er, true, nil)
 }
 
-func TestStream_Eval_Missing(t *testing.T) {
+func TestStream_EvalConvert(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('missing')
-	|eval(lambda: "or_not_to_be")
-		.as('that_is_the_question')
+		.measurement('disk')
+	|eval(lambda: convert("used", 'B', 'GiB'), lambda: convert("temp", 'F', 'C'))
+		.as('used_gib', 'temp_c')
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalConvert')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "disk",
+				Tags:    nil,
+				Columns: []string{"time", "temp_c", "used_gib"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 100.0, 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.0, 1.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalConvert", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_EvalConvert_IncompatibleUnits(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('disk')
+	|eval(lambda: convert("used", 'B', 'C'))
+		.as('used')
+`
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+	task, err := tm.NewTask("TestStream_EvalConvert_IncompatibleUnits", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tm.StartTask(task)
+	if err == nil {
+		t.Fatal("expected the task to fail to start")
+	}
+	if exp := `cannot convert data size unit "B" to temperature unit "C"`; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err.Error(), exp)
+	}
+}
+
+func TestStream_EvalPercentileRank(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|eval(lambda: percentileRank("value", 2))
+		.as('rank')
+	|window()
+		.period(4s)
+		.every(4s)
+	|httpOut('TestStream_EvalPercentileRank')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "A"},
+				Columns: []string{"time", "rank"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.5},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.5},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 0.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "B"},
+				Columns: []string{"time", "rank"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.5},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.5},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.5},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalPercentileRank", script, 6*time.Second, er, false, nil)
+}
+
+func TestStream_EvalDebounce(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|eval(lambda: debounce("value", 2))
+		.as('value')
+	|window()
+		.period(6s)
+		.every(6s)
+	|httpOut('TestStream_EvalDebounce')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "A"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 5.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 5.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "B"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 3.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalDebounce", script, 8*time.Second, er, false, nil)
+}
+
+func TestStream_Split(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|split()
+		.point()
+			.tag('mode', 'user')
+			.field('usage', lambda: "usage_user")
+		.point()
+			.tag('mode', 'system')
+			.field('usage', lambda: "usage_system")
+			.field('missing', lambda: "missing")
+		.point()
+			.measurement('cpu_system')
+			.tag('mode', 'system')
+			.field('usage', lambda: "usage_system" * 2.0)
+	|groupBy('host', 'mode')
+		.byMeasurement()
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_Split')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "A", "mode": "user"},
+				Columns: []string{"time", "usage"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 20.0},
+				},
+			},
+			{
+				Name:    "cpu_system",
+				Tags:    map[string]string{"host": "A", "mode": "system"},
+				Columns: []string{"time", "usage"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 4.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Split", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Interpolate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+		.groupBy('sensor')
+	|window()
+		.period(5s)
+		.every(5s)
+	|interpolate('temperature', 'humidity', 'pressure')
+		.edge('nearest')
+	|httpOut('TestStream_Interpolate')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "sensors",
+				Tags:    map[string]string{"sensor": "A"},
+				Columns: []string{"time", "humidity", "temperature"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 50.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 51.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 52.0, 13.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 53.0, 16.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 54.0, 16.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Interpolate", script, 6*time.Second, er, false, nil)
+}
+
+func TestStream_Limit(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('errors')
+		.groupBy('host')
+	|limit(1, 2s)
+	|window()
+		.period(6s)
+		.every(6s)
+	|httpOut('TestStream_Limit')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"host": "A"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 5.0},
+				},
+			},
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"host": "B"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 40.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Limit", script, 7*time.Second, er, false, nil)
+}
+
+func TestStream_Outliers(t *testing.T) {
+	testCases := []struct {
+		name   string
+		script string
+		tagged bool
+	}{
+		{
+			name: "iqr",
+			script: `
+stream
+	|from()
+		.measurement('latency')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|outliers('value')
+		.method('iqr')
+	|where(lambda: "outlier" == 'false')
+	|httpOut('TestStream_Outliers')
+`,
+			tagged: true,
+		},
+		{
+			// With the default threshold of 3 the outlier inflates the standard deviation enough to hide itself.
+			name: "zscore",
+			script: `
+stream
+	|from()
+		.measurement('latency')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|outliers('value')
+		.threshold(2.0)
+		.drop()
+	|httpOut('TestStream_Outliers')
+`,
+		},
+	}
+
+	values := []float64{10, 11, 9, 10, 12, 10, 11, 9, 10}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			columns := []string{"time", "value"}
+			if tc.tagged {
+				columns = []string{"time", "outlier", "value"}
+			}
+			rows := make([][]interface{}, len(values))
+			for i, v := range values {
+				ts := time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC)
+				if tc.tagged {
+					rows[i] = []interface{}{ts, "false", v}
+				} else {
+					rows[i] = []interface{}{ts, v}
+				}
+			}
+			er := models.Result{
+				Series: models.Rows{
+					{
+						Name:    "latency",
+						Tags:    map[string]string{"host": "A"},
+						Columns: columns,
+						Values:  rows,
+					},
+				},
+			}
+			testStreamerWithOutput(t, "TestStream_Outliers", tc.script, 11*time.Second, er, false, nil)
+		})
+	}
+}
+
+func TestStream_EvalDynamicNames(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('region')
+	|eval(lambda: "value" * 10.0)
+		.as('value_{{ .Tags.region }}')
+		.maxDynamicNames(2)
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalDynamicNames')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"region": "us"},
+				Columns: []string{"time", "value_us"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 30.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"region": "eu"},
+				Columns: []string{"time", "value_eu"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 20.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalDynamicNames", script, 4*time.Second, er, false, nil)
+}
+
+func TestStream_EvalDynamicNames_Keep(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('region')
+	|eval(lambda: "value" * 10.0)
+		.as('value_{{ .Tags.region }}')
+		.keep('value', 'value_{{ .Tags.region }}')
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalDynamicNames_Keep')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"region": "us"},
+				Columns: []string{"time", "value", "value_us"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 3.0, 30.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"region": "eu"},
+				Columns: []string{"time", "value", "value_eu"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 2.0, 20.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalDynamicNames_Keep", script, 4*time.Second, er, false, nil)
+}
+
+func TestStream_EvalDynamicNames_InvalidTemplate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|eval(lambda: "value")
+		.as('value_{{ .Tags.region ')
+`
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+	_, err = tm.NewTask("TestStream_EvalDynamicNames_InvalidTemplate", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err == nil {
+		t.Fatal("expected the task to be invalid")
+	}
+	if exp := "invalid .as() name template"; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err.Error(), exp)
+	}
+}
+
+func TestStream_EvalHash(t *testing.T) {
+	stateful.SetHMACKeys(map[string]string{"users": "secret"})
+	defer stateful.SetHMACKeys(nil)
+
+	var script = `
+stream
+	|from()
+		.measurement('logins')
+	|eval(lambda: sha256("user"), lambda: hmac("user", 'users'))
+		.as('user_sha256', 'user_hmac')
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalHash')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "logins",
+				Tags:    nil,
+				Columns: []string{"time", "user_hmac", "user_sha256"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"4360c67bc81025114044578d7c4e8e0f02fd0cae99f22d603390e8f9dc9888f8",
+						"2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"9c90819f883772660da011f41042fabea4a174e2873386b30949f106dbac797e",
+						"81b637d8fcd2c6da6359e6963113a1170de795e4b725b84d1e0b4cfd9ec58ce9",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalHash", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_EvalArray(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('latency')
+	|eval(lambda: array("p50", "p90", "p99"))
+		.as('quantiles')
+	|eval(lambda: arrayLen("quantiles"), lambda: arrayMax("quantiles"), lambda: arrayIndex("quantiles", -1))
+		.as('count', 'worst', 'last')
+		.keep()
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalArray')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "latency",
+				Tags:    nil,
+				Columns: []string{"time", "count", "last", "quantiles", "worst"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 3.0, 2.5, []interface{}{1.5, 4.0, 2.5}, 4.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 3.0, 7.0, []interface{}{2.0, 3.0, 7.0}, 7.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalArray", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_EvalMultiNames(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('latency')
+	|eval(lambda: array("p50", "p90" - "p50"), lambda: "low" + "spread")
+		.as('[low, spread]', 'high')
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalMultiNames')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "latency",
+				Tags:    nil,
+				Columns: []string{"time", "high", "low", "spread"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 4.0, 1.5, 2.5},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 3.0, 2.0, 1.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalMultiNames", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_EvalCalendar(t *testing.T) {
+	// The points are on Friday 1971-01-01 in UTC, which is still Thursday in New York.
+	var script = `
+var holidays = '1970-12-25,1971-01-01'
+
+stream
+	|from()
+		.measurement('latency')
+	|eval(
+		lambda: isBusinessHours("time", 'UTC', '00:00', '09:00', 'Mon-Fri'),
+		lambda: isBusinessHours("time", 'America/New_York', '09:00', '18:00', 'Mon-Fri'),
+		lambda: isHoliday("time", holidays),
+		lambda: isHoliday("time", holidays, 'America/New_York'),
+	)
+		.as('business_utc', 'business_ny', 'holiday_utc', 'holiday_ny')
+	|window()
+		.period(2s)
+		.every(2s)
+	|httpOut('TestStream_EvalCalendar')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "latency",
+				Tags:    nil,
+				Columns: []string{"time", "business_ny", "business_utc", "holiday_ny", "holiday_utc"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), false, true, false, true},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), false, true, false, true},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalCalendar", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_EvalCountTrue(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('checks')
+		.groupBy('host')
+	|eval(lambda: countTrue(!"ok", 3), lambda: boolToInt("ok"))
+		.as('failures', 'ok_int')
+		.keep()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_EvalCountTrue')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "checks",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "failures", "ok", "ok_int"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0, false, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 2.0, false, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 2.0, true, 1.0},
+					// The first failure is not among the last 3 checks.
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 2.0, false, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 1.0, true, 1.0},
+				},
+			},
+			{
+				Name:    "checks",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "failures", "ok", "ok_int"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.0, true, 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1.0, false, 0.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalCountTrue", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_EvalEdge(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|where(lambda: edge("value" > 80, 'both'))
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_EvalEdge')
+`
+
+	// The first points of the groups are not edges.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 90.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 70.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 85.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 50.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalEdge", script, 14*time.Second, er, false, nil)
+}
+
+func TestStream_EvalRate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('host')
+	|eval(lambda: rate("count", "time", 1s, 'counter', 5s))
+		.as('per_second')
+		.nanPolicy('omit')
+		.keep()
+	|window()
+		.period(20s)
+		.every(20s)
+	|httpOut('TestStream_EvalRate')
+`
+
+	// The first points of the groups, without a rate, are in the previous window.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "count", "per_second"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), 20.0, 5.0},
+					{time.Date(1971, 1, 1, 0, 0, 21, 0, time.UTC), 35.0, 15.0},
+					// The counter was reset.
+					{time.Date(1971, 1, 1, 0, 0, 22, 0, time.UTC), 5.0, 5.0},
+					// The point is more than 5s after the previous point.
+					{time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), 15.0, nil},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "count", "per_second"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), 90.0, 90.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalRate", script, 43*time.Second, er, false, nil)
+}
+
+func TestStream_EvalMultiNames_Invalid(t *testing.T) {
+	testCases := []struct {
+		lambdas string
+		as      string
+		exp     string
+	}{
+		{
+			lambdas: `lambda: array("p50", "p90")`,
+			as:      `'[low, low]'`,
+			exp:     `name "low" is used more than once`,
+		},
+		{
+			lambdas: `lambda: array("p50", "p90"), lambda: "p99"`,
+			as:      `'[low, spread]', 'low'`,
+			exp:     `name "low" is used more than once`,
+		},
+		{
+			lambdas: `lambda: array("p50", "p90")`,
+			as:      `'[low, ]'`,
+			exp:     "names of array elements must not be empty",
+		},
+		{
+			lambdas: `lambda: array("p50", "p90")`,
+			as:      `'[low, {{ .Tags.host }}]'`,
+			exp:     "names of array elements cannot be dynamic",
+		},
+	}
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+	for _, tc := range testCases {
+		script := fmt.Sprintf(`
+stream
+	|from()
+		.measurement('latency')
+	|eval(%s)
+		.as(%s)
+`, tc.lambdas, tc.as)
+		_, err := tm.NewTask("TestStream_EvalMultiNames_Invalid", script, kapacitor.StreamTask, dbrps, 0, nil)
+		if err == nil {
+			t.Errorf("%s: expected the task to be invalid", tc.as)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.exp) {
+			t.Errorf("%s: unexpected error got %q exp %q", tc.as, err.Error(), tc.exp)
+		}
+	}
+}
+
+func TestStream_EvalLookup(t *testing.T) {
+	var script = `
+var weights = '{"us": 1, "eu": 2}'
+
+stream
+	|from()
+		.measurement('hosts')
+		.groupBy('region')
+	|eval(lambda: lookup("region", weights, 0))
+		.as('weight')
+	|window()
+		.period(1s)
+		.every(1s)
+	|httpOut('TestStream_EvalLookup')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "hosts",
+				Tags:    map[string]string{"region": "asia"},
+				Columns: []string{"time", "weight"},
+				Values:  [][]interface{}{{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 0.0}},
+			},
+			{
+				Name:    "hosts",
+				Tags:    map[string]string{"region": "eu"},
+				Columns: []string{"time", "weight"},
+				Values:  [][]interface{}{{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 2.0}},
+			},
+			{
+				Name:    "hosts",
+				Tags:    map[string]string{"region": "us"},
+				Columns: []string{"time", "weight"},
+				Values:  [][]interface{}{{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalLookup", script, 2*time.Second, er, true, nil)
+}
+
+func TestStream_EvalNanPolicy(t *testing.T) {
+	testCases := []struct {
+		policy string
+		er     models.Result
+	}{
+		{
+			policy: "",
+			er: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "rates",
+						Columns: []string{"time", "rate"},
+						Values: [][]interface{}{
+							{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.25},
+						},
+					},
+				},
+			},
+		},
+		{
+			policy: ".nanPolicy('replace').nanReplacement(-1.0)",
+			er: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "rates",
+						Columns: []string{"time", "rate"},
+						Values: [][]interface{}{
+							{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), -1.0},
+							{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), -1.0},
+							{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.25},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		script := `
+stream
+	|from()
+		.measurement('rates')
+	|eval(lambda: float("errors") / float("total"))
+		.as('rate')
+		` + tc.policy + `
+	|window()
+		.period(3s)
+		.every(3s)
+		.align()
+	|httpOut('TestStream_EvalNanPolicy')
+`
+		testStreamerWithOutput(t, "TestStream_EvalNanPolicy", script, 5*time.Second, tc.er, false, nil)
+	}
+}
+
+func TestStream_EvalNanPolicyTag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('rates')
+	|eval(lambda: float("errors") / float("total"), lambda: "rate" * 100.0)
+		.as('rate', 'percent')
+		.nanPolicy('tag')
+		.keep('errors', 'total')
+	|where(lambda: "eval_error" == 'rate,percent')
+	|window()
+		.period(3s)
+		.every(3s)
+		.align()
+	|httpOut('TestStream_EvalNanPolicy')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "rates",
+				Columns: []string{"time", "errors", "eval_error", "total"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0, "rate,percent", 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.0, "rate,percent", 0.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalNanPolicy", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_EvalNowPointTime(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('rates')
+	|eval(lambda: timeDiff(now(), "time") == 0s, lambda: timeDiff(now(), "time") > 1s)
+		.as('current', 'old')
+		.nowPointTime()
+	|window()
+		.period(2s)
+		.every(2s)
+		.align()
+	|httpOut('TestStream_EvalNanPolicy')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "rates",
+				Columns: []string{"time", "current", "old"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), true, false},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), true, false},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalNanPolicy", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_EvalGroups(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: count())
+		.as('count')
+	|httpOut('TestStream_EvalGroups')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Eval_TagFunction(t *testing.T) {
+	var script = `
+var factor = lambda: if(tag('group') == 'A', 2.0, 3.0)
+
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: "value" * factor, lambda: tag('missing') == '')
+		.as('scaled', 'missing')
+	|httpOut('TestStream_EvalGroups')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "missing", "scaled"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						true,
+						48.0,
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "missing", "scaled"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						true,
+						72.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Eval_Sprintf(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: sprintf('%s is at %.1f%%', tag('group'), "value"))
+		.as('summary')
+	|httpOut('TestStream_EvalGroups')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "summary"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"A is at 24.0%",
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "summary"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"B is at 24.0%",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Eval_TagSetFunctions(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: tagCount(), lambda: tagKeys(), lambda: tagsString(';'), lambda: hasTagMatching(/^gr/), lambda: hasTagMatching(/^host$/))
+		.as('count', 'keys', 'tags', 'group_tag', 'host_tag')
+	|httpOut('TestStream_EvalGroups')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "count", "group_tag", "host_tag", "keys", "tags"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						true,
+						false,
+						"group",
+						"group=A",
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "count", "group_tag", "host_tag", "keys", "tags"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						true,
+						false,
+						"group",
+						"group=B",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Eval_StrDistance(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: strLevenshtein(tag('group'), 'A'), lambda: strJaroWinkler(tag('group'), 'A'))
+		.as('distance', 'similarity')
+	|httpOut('TestStream_EvalGroups')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "distance", "similarity"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						0.0,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "distance", "similarity"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						0.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, false, nil)
+}
+
+func TestStream_Eval_Time(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+	|eval(lambda: hour("time"))
+		.as('hour')
+	|httpOut('TestStream_Eval_Time')
+`
+	hour := float64(time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC).Local().Hour())
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "hour"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
+						hour,
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "hour"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
+						hour,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Eval_Time", script, 2*time.Hour, er, false, nil)
+}
+
+func TestStream_Eval_Missing(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('missing')
+	|eval(lambda: "or_not_to_be")
+		.as('that_is_the_question')
 	|httpOut('TestStream_Eval_Missing')
 `
 	er := models.Result{
@@ -3057,6 +4563,37 @@ stream
 	testStreamerWithOutput(t, "TestStream_Eval_Missing", script, 2*time.Hour, er, false, nil)
 }
 
+func TestStream_Eval_Exists(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|eval(lambda: exists("latency"), lambda: isNull("latency"), lambda: if(exists("latency"), "latency", -1.0))
+		.as('has_latency', 'null_latency', 'latency')
+		.keep('latency', 'has_latency', 'null_latency')
+	|window()
+		.period(3s)
+		.every(3s)
+	|httpOut('TestStream_Eval_Exists')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "has_latency", "latency", "null_latency"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), true, 12.5, false},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), false, -1.0, false},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), true, 7.5, false},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Eval_Exists", script, 4*time.Second, er, false, nil)
+}
+
 func TestStream_Default(t *testing.T) {
 	var script = `
 stream
@@ -3380,6 +4917,106 @@ stream
 	}
 }
 
+func TestStream_HttpPost_BatchSize(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]*models.Row
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			// The first post is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var rows []*models.Row
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, rows)
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost('` + ts.URL + `')
+		.batchSize(4)
+		.batchInterval(1h)
+		.retryTimeout(10s)
+`
+
+	testStreamerNoOutput(t, "TestStream_HttpPost", script, 13*time.Second, nil)
+
+	row := func(sec int, value float64) *models.Row {
+		return &models.Row{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "serverA", "type": "idle"},
+			Columns: []string{"time", "value"},
+			Values: [][]interface{}{{
+				time.Date(1971, 1, 1, 0, 0, sec, 0, time.UTC),
+				value,
+			}},
+		}
+	}
+	exp := [][]*models.Row{
+		{row(0, 97.1), row(1, 92.6), row(2, 95.6), row(3, 93.1)},
+		// The remaining rows are posted when the task stops.
+		{row(4, 92.6), row(5, 95.8)},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("unexpected number of attempts got %d exp %d", attempts, 3)
+	}
+	if !reflect.DeepEqual(requests, exp) {
+		t.Errorf("unexpected requests:\ngot\n%s\nexp\n%s", spew.Sdump(requests), spew.Sdump(exp))
+	}
+}
+
+func TestStream_HttpPost_BatchSizeRowTemplate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|httpPost()
+		.endpoint('test')
+		.batchSize(4)
+`
+
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := httppost.Config{}
+	c.URLTemplate = "http://localhost"
+	c.Endpoint = "test"
+	c.RowTemplate = `{{.Name}}`
+	tm.HTTPPostService, err = httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+
+	task, err := tm.NewTask("TestStream_HttpPost_BatchSizeRowTemplate", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The task fails to start rather than failing every post.
+	_, err = tm.StartTask(task)
+	if err == nil {
+		t.Fatal("expected error starting a task posting batches with a row template")
+	}
+	if exp := "the row template of endpoint 'test' cannot be used with a batchSize"; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err, exp)
+	}
+}
+
 func TestStream_HttpPost_URL_Template(t *testing.T) {
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3848,51 +5485,274 @@ stream
 			},
 		},
 	}
-
-	tmInit := func(tm *kapacitor.TaskMaster) {
-		c := httppost.Config{}
-		c.URLTemplate = ts.URL
-		c.Endpoint = "test"
-		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
-		tm.HTTPPostService = sl
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URLTemplate = ts.URL
+		c.Endpoint = "test"
+		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
+		tm.HTTPPostService = sl
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
+		t.Errorf("got %v exp %v", rc, 6)
+	}
+}
+
+func TestStream_HttpOutPassThrough(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('unused')
+	|httpOut('TestStream_SimpleMR')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_HttpOutFieldsTags(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|groupBy('host', 'type')
+	|httpOut('TestStream_HttpOutFieldsTags')
+		.fields('value')
+		.tags('host')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					97.1,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpOutFieldsTags", script, 2*time.Second, er, false, nil)
+}
+
+func TestStream_HttpOutCSV(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|groupBy('host', 'type')
+	|httpOut('TestStream_HttpOutFieldsTags')
+		.fields('value')
+`
+	name := "TestStream_HttpOutFieldsTags"
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 2*time.Second); err != nil {
+		t.Error(err)
+	}
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("GET", output.Endpoint(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got, exp := resp.Header.Get("Content-Type"), "text/csv"; got != exp {
+		t.Errorf("unexpected content type: got %q exp %q", got, exp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "name,tags,time,value\ncpu,\"host=serverA,type=idle\",1971-01-01T00:00:00Z,97.1\n"
+	if got := string(b); got != exp {
+		t.Errorf("unexpected CSV:\ngot\n%s\nexp\n%s", got, exp)
+	}
+}
+
+func TestStream_HttpOutFormats(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|groupBy('host', 'type')
+	|httpOut('TestStream_HttpOutFieldsTags')
+		.fields('value')
+`
+	name := "TestStream_HttpOutFieldsTags"
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 2*time.Second); err != nil {
+		t.Error(err)
+	}
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		query       string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			accept:      "text/plain",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "cpu,host=serverA,type=idle value=97.1 31536000000000000\n",
+		},
+		{
+			query:       "?format=line",
+			accept:      "text/csv",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "cpu,host=serverA,type=idle value=97.1 31536000000000000\n",
+		},
+		{
+			query:       "?format=csv",
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "name,tags,time,value\ncpu,\"host=serverA,type=idle\",1971-01-01T00:00:00Z,97.1\n",
+		},
+		{
+			accept:      "application/json",
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"series":[{"name":"cpu","tags":{"host":"serverA","type":"idle"},"columns":["time","value"],"values":[["1971-01-01T00:00:00Z",97.1]]}]}`,
+		},
+		{
+			query:  "?format=xml",
+			status: http.StatusBadRequest,
+		},
 	}
-
-	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
-
-	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
-		t.Errorf("got %v exp %v", rc, 6)
+	for _, tc := range testCases {
+		req, err := http.NewRequest("GET", output.Endpoint()+tc.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: unexpected status got %d exp %d", tc.query, tc.accept, resp.StatusCode, tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		if got := resp.Header.Get("Content-Type"); got != tc.contentType {
+			t.Errorf("%s %s: unexpected content type got %q exp %q", tc.query, tc.accept, got, tc.contentType)
+		}
+		if got := string(b); got != tc.body {
+			t.Errorf("%s %s: unexpected body:\ngot\n%s\nexp\n%s", tc.query, tc.accept, got, tc.body)
+		}
 	}
 }
 
-func TestStream_HttpOutPassThrough(t *testing.T) {
-
+func TestStream_HttpOutFieldsAndTags(t *testing.T) {
 	var script = `
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
-	|httpOut('unused')
-	|httpOut('TestStream_SimpleMR')
+	|groupBy('host', 'type')
+	|httpOut('TestStream_HttpOutFieldsAndTags')
+		.fieldsAndTags()
+		.pretty()
 `
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
-				}},
-			},
-		},
+	name := "TestStream_HttpOutFieldsAndTags"
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 2*time.Second); err != nil {
+		t.Error(err)
+	}
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		resp.Body, err = gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var points []edge.PointJSON
+	if err := json.NewDecoder(resp.Body).Decode(&points); err != nil {
+		t.Fatal(err)
+	}
+	exp := []edge.PointJSON{{
+		Name:            "cpu",
+		Database:        "dbname",
+		RetentionPolicy: "rpname",
+		Tags:            models.Tags{"host": "serverA", "type": "idle"},
+		Fields: edge.TypedFields{
+			"value": {Type: edge.FloatFieldType, Value: 97.1},
+			"count": {Type: edge.IntegerFieldType, Value: int64(3)},
+			"ok":    {Type: edge.BooleanFieldType, Value: true},
+			"msg":   {Type: edge.StringFieldType, Value: "hi"},
+		},
+		Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	if !cmp.Equal(points, exp) {
+		t.Fatalf("unexpected points:\n%s", cmp.Diff(points, exp))
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	// The points round-trip into a recording.
+	var buf bytes.Buffer
+	if err := kapacitor.WritePointForRecording(&buf, points[0].PointMessage(), "s"); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := buf.String(), "dbname\nrpname\ncpu,host=serverA,type=idle count=3i,msg=\"hi\",ok=true,value=97.1 31536000\n"; got != exp {
+		t.Errorf("unexpected recording:\ngot\n%s\nexp\n%s", got, exp)
+	}
 }
 
 func TestStream_BatchGroupBy(t *testing.T) {
@@ -4062,6 +5922,45 @@ stream
 	testStreamerWithOutput(t, "TestStream_GroupByExclude", script, 5*time.Second, er, true, nil)
 }
 
+func TestStream_FromGroupByAllExcept(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('mock')
+		.groupByAllExcept('s')
+	|window()
+		.period(2s)
+		.every(2s)
+	|count('value')
+	|httpOut('TestStream_GroupByExclude')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "mock",
+				Tags:    map[string]string{"t": "A"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					4.0,
+				}},
+			},
+			{
+				Name:    "mock",
+				Tags:    map[string]string{"t": "B"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					4.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_GroupByExclude", script, 5*time.Second, er, true, nil)
+}
+
 func TestStream_SimpleWhere(t *testing.T) {
 
 	var script = `
@@ -4920,6 +6819,97 @@ errorCounts
 	testStreamerWithOutput(t, "TestStream_Join", script, 13*time.Second, er, true, nil)
 }
 
+func TestStream_Join_PrefixCollisionsOnly(t *testing.T) {
+
+	var script = `
+var cpu = stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+
+var mem = stream
+	|from()
+		.measurement('mem')
+		.groupBy('host')
+
+cpu
+	|join(mem)
+		.as('cpu', 'mem')
+		.delimiter('_')
+		.suffix()
+		.prefixCollisionsOnly()
+		.rename('mem', 'total', 'mem_total')
+		.streamName('cpu_mem')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Join_PrefixCollisionsOnly')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_mem",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "mem_total", "usage_idle", "value_cpu", "value_mem"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1000.0, 90.0, 1.0, 100.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 1000.0, 80.0, 2.0, 200.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1000.0, 70.0, 3.0, 300.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Join_PrefixCollisionsOnly", script, 13*time.Second, er, true, nil)
+}
+
+func TestStream_Join_PrefixCollisionsOnly_Fill(t *testing.T) {
+
+	var script = `
+var cpu = stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+
+var mem = stream
+	|from()
+		.measurement('mem')
+		.groupBy('host')
+
+cpu
+	|join(mem)
+		.as('cpu', 'mem')
+		.delimiter('_')
+		.suffix()
+		.prefixCollisionsOnly()
+		.rename('mem', 'total', 'mem_total')
+		.fill(0.0)
+		.streamName('cpu_mem')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Join_PrefixCollisionsOnly_Fill')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_mem",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "mem_total", "usage_idle", "value_cpu", "value_mem"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1000.0, 90.0, 1.0, 100.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.0, 80.0, 2.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1000.0, 0.0, 0.0, 300.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Join_PrefixCollisionsOnly_Fill", script, 13*time.Second, er, true, nil)
+}
+
 func TestStream_JoinTolerance(t *testing.T) {
 
 	var script = `
@@ -5998,6 +7988,83 @@ cpuT
 	testStreamerWithSteppedOutput(t, "TestStream_Union_Stepped", script, steps, er, true, nil)
 }
 
+func TestStream_ForkRouting(t *testing.T) {
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+
+	dbrps := []kapacitor.DBRP{
+		{Database: "dbname", RetentionPolicy: "rpname"},
+		{Database: "dbname", RetentionPolicy: "other"},
+	}
+	testCases := []struct {
+		script string
+		// The number of points received by the task.
+		exp int64
+	}{
+		{
+			script: `stream|from().measurement('cpu')`,
+			exp:    2,
+		},
+		{
+			script: `stream|from().measurement('cpu').retentionPolicy('rpname')`,
+			exp:    1,
+		},
+		{
+			// Points of the cpu measurement are received once per matching fork key, as with the broadcast to all tasks.
+			script: `
+var all = stream|from()
+stream|from().measurement('cpu')
+`,
+			exp: 6,
+		},
+		{
+			// The task matches none of its dbrps, it receives the points of the measurement and filters them out.
+			script: `stream|from().measurement('cpu').database('other')`,
+			exp:    2,
+		},
+	}
+	var tasks []*kapacitor.ExecutingTask
+	for i, tc := range testCases {
+		task, err := tm.NewTask(fmt.Sprintf("task_%d", i), tc.script, kapacitor.StreamTask, dbrps, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		et, err := tm.StartTask(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, et)
+	}
+
+	for _, rp := range []string{"rpname", "other"} {
+		points, err := imodels.ParsePointsString("cpu value=1 0000000001\nmem value=1 0000000001")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tm.WritePoints("dbname", rp, imodels.ConsistencyLevelAny, points); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tm.Drain()
+	for _, et := range tasks {
+		et.Wait()
+	}
+
+	for i, tc := range testCases {
+		stats, err := tm.ExecutionStats(tasks[i].Task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stats.NodeStats["stream0"]["collected"]; got != tc.exp {
+			t.Errorf("%d: unexpected points received by %q got %v exp %d", i, tc.script, got, tc.exp)
+		}
+	}
+}
+
 func TestStream_InfluxQL_Float(t *testing.T) {
 
 	type testCase struct {
@@ -6005,6 +8072,8 @@ func TestStream_InfluxQL_Float(t *testing.T) {
 		Args          string
 		ER            models.Result
 		UsePointTimes bool
+		Approximate   string
+		Precision     string
 	}
 
 	var scriptTmpl = `
@@ -6018,6 +8087,8 @@ stream
 		.every(10s)
 	|{{ .Method }}({{ .Args }})
 		{{ if .UsePointTimes }}.usePointTimes(){{ end }}
+		{{ if .Approximate }}.approximate({{ .Approximate }}){{ end }}
+		{{ if .Precision }}.precision({{ .Precision }}){{ end }}
 	|httpOut('TestStream_InfluxQL_Float')
 `
 	endTime := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
@@ -6092,6 +8163,71 @@ stream
 				},
 			},
 		},
+		testCase{
+			Method: "distinctCount",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "distinctCount"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							6.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method:    "distinctCount",
+			Precision: "14",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "distinctCount"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							6.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "entropy",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "entropy"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							2.4464393446710155,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "timeWeightedMean",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "timeWeightedMean"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
 		testCase{
 			Method: "mean",
 			ER: models.Result{
@@ -6321,6 +8457,41 @@ stream
 				},
 			},
 		},
+		testCase{
+			Method:      "percentile",
+			Args:        "'value', 50.0",
+			Approximate: "100.0",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "percentile"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method:      "median",
+			Approximate: "100.0",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "median"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
 		testCase{
 			Method:        "top",
 			UsePointTimes: true,
@@ -7425,6 +9596,7 @@ func TestStream_Alert(t *testing.T) {
 			ID:          "kapacitor/cpu/serverA",
 			Message:     "kapacitor/cpu/serverA is CRITICAL",
 			Details:     "details",
+			Fingerprint: "05b90508581f7b8f",
 			Time:        time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 			Level:       alert.Critical,
 			Recoverable: true,
@@ -7501,6 +9673,126 @@ stream
 	}
 }
 
+func TestStream_AlertFingerprint(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+			return
+		}
+		atomic.AddInt32(&requestCount, 1)
+		// The fingerprint is the hash of "count=10\nhost=serverA\n".
+		if got, exp := ad.Fingerprint, "7272fb7bea1a4e69"; got != exp {
+			t.Errorf("unexpected fingerprint got %s exp %s", got, exp)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.crit(lambda: "count" > 8.0)
+		.fingerprint('host', 'count', 'missing')
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+		t.Errorf("got %v exp %v", rc, 1)
+	}
+}
+
+func TestStream_AlertAttachRecent(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := atomic.AddInt32(&requestCount, 1)
+		var exp alert.Data
+		switch rc {
+		case 1:
+			exp = alert.Data{
+				ID:          "cpu:nil",
+				Message:     "cpu:nil is CRITICAL",
+				Details:     "97.1,",
+				Fingerprint: "45e4ed06369c0a6c",
+				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+				Level:       alert.Critical,
+				Recoverable: true,
+				Recent: &models.Row{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverA", "type": "idle"},
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 97.1},
+					},
+				},
+			}
+		case 3:
+			exp = alert.Data{
+				ID:            "cpu:nil",
+				Message:       "cpu:nil is CRITICAL",
+				Details:       "95.8,92.7,96,",
+				Fingerprint:   "45e4ed06369c0a6c",
+				Time:          time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+				Level:         alert.Critical,
+				PreviousLevel: alert.OK,
+				Recoverable:   true,
+				Recent: &models.Row{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverA", "type": "idle"},
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 95.8},
+						{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 92.7},
+						{time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), 96.0},
+					},
+				},
+			}
+		default:
+			return
+		}
+		ad.Data = models.Result{}
+		if !reflect.DeepEqual(exp, ad) {
+			t.Errorf("unexpected alert data for request: %d\ngot %v\nexp %v", rc, ad, exp)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|alert()
+		.crit(lambda: "value" > 95.9)
+		.attachRecent(3)
+		.details('{{ range .Recent.Values }}{{ index . 1 }},{{ end }}')
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	if rc := atomic.LoadInt32(&requestCount); rc < 3 {
+		t.Errorf("got %v exp at least %v", rc, 3)
+	}
+}
+
 func TestStream_Alert_NoRecoveries(t *testing.T) {
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -7518,6 +9810,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:          "kapacitor/cpu/serverA",
 				Message:     "kapacitor/cpu/serverA is WARNING",
+				Fingerprint: "05b90508581f7b8f",
 				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 				Duration:    0,
 				Level:       alert.Warning,
@@ -7540,6 +9833,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
 				Duration:      0,
 				Level:         alert.Info,
@@ -7563,6 +9857,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
 				Duration:      time.Second,
 				Level:         alert.Warning,
@@ -7586,6 +9881,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Warning,
@@ -7609,6 +9905,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is CRITICAL",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.Critical,
@@ -7632,6 +9929,7 @@ func TestStream_Alert_NoRecoveries(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
 				Duration:      0,
 				Level:         alert.Info,
@@ -7717,6 +10015,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:          "kapacitor/cpu/serverA",
 				Message:     "kapacitor/cpu/serverA is INFO",
 				Details:     "details",
+				Fingerprint: "05b90508581f7b8f",
 				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 				Level:       alert.Info,
 				Recoverable: true,
@@ -7739,6 +10038,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
 				Duration:      time.Second,
 				Level:         alert.Info,
@@ -7763,6 +10063,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Info,
@@ -7787,6 +10088,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -7811,6 +10113,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
 				Duration:      0 * time.Second,
 				Level:         alert.Info,
@@ -7835,6 +10138,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
 				Duration:      1 * time.Second,
 				Level:         alert.Warning,
@@ -7859,6 +10163,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Warning,
@@ -7883,6 +10188,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -7907,6 +10213,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
 				Duration:      0 * time.Second,
 				Level:         alert.Info,
@@ -7931,6 +10238,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
 				Duration:      1 * time.Second,
 				Level:         alert.Warning,
@@ -7955,6 +10263,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is CRITICAL",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Critical,
@@ -7979,6 +10288,7 @@ func TestStream_Alert_WithReset_0(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -8078,6 +10388,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:          "kapacitor/cpu/serverA",
 				Message:     "kapacitor/cpu/serverA is INFO",
 				Details:     "details",
+				Fingerprint: "05b90508581f7b8f",
 				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 				Level:       alert.Info,
 				Recoverable: true,
@@ -8100,6 +10411,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
 				Duration:      time.Second,
 				Level:         alert.Info,
@@ -8124,6 +10436,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Info,
@@ -8148,6 +10461,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -8172,6 +10486,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
 				Duration:      0 * time.Second,
 				Level:         alert.Info,
@@ -8196,6 +10511,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
 				Duration:      1 * time.Second,
 				Level:         alert.Warning,
@@ -8220,6 +10536,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Info,
@@ -8244,6 +10561,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -8268,6 +10586,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
 				Duration:      0 * time.Second,
 				Level:         alert.Info,
@@ -8292,6 +10611,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
 				Duration:      1 * time.Second,
 				Level:         alert.Warning,
@@ -8316,6 +10636,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is CRITICAL",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Critical,
@@ -8340,6 +10661,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.Warning,
@@ -8364,6 +10686,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
 				Duration:      4 * time.Second,
 				Level:         alert.Warning,
@@ -8388,6 +10711,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is INFO",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
 				Duration:      5 * time.Second,
 				Level:         alert.Info,
@@ -8412,6 +10736,7 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
 				Duration:      6 * time.Second,
 				Level:         alert.OK,
@@ -8511,6 +10836,7 @@ func TestStream_AlertDuration(t *testing.T) {
 				ID:          "kapacitor/cpu/serverA",
 				Message:     "kapacitor/cpu/serverA is CRITICAL",
 				Details:     "details",
+				Fingerprint: "05b90508581f7b8f",
 				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 				Duration:    0,
 				Level:       alert.Critical,
@@ -8534,6 +10860,7 @@ func TestStream_AlertDuration(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
 				Duration:      2 * time.Second,
 				Level:         alert.Warning,
@@ -8558,6 +10885,7 @@ func TestStream_AlertDuration(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
 				Duration:      4 * time.Second,
 				Level:         alert.OK,
@@ -8582,6 +10910,7 @@ func TestStream_AlertDuration(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
 				Duration:      0,
 				Level:         alert.Warning,
@@ -8606,6 +10935,7 @@ func TestStream_AlertDuration(t *testing.T) {
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
+				Fingerprint:   "05b90508581f7b8f",
 				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
 				Duration:      3 * time.Second,
 				Level:         alert.OK,
@@ -8732,10 +11062,176 @@ stream
 	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
 		t.Error(err)
 	}
-
+
+}
+
+func TestStream_AlertZabbix(t *testing.T) {
+	ts, err := zabbixtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.zabbix()
+			.host('{{ index .Tags "host" }}')
+		.zabbix()
+			.server('proxy')
+			.key('kapacitor.{{ .Name }}')
+			.value('{{ .Message }}')
+`
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c1 := zabbix.NewConfig()
+		c1.Enabled = true
+		c1.Addr = ts.Addr
+		c2 := zabbix.NewConfig()
+		c2.Enabled = true
+		c2.ID = "proxy"
+		c2.Addr = ts.Addr
+		c2.Host = "kapacitor"
+		tm.ZabbixService = zabbix.NewService(zabbix.Configs{c1, c2}, diagService.NewZabbixHandler())
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	clock := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC).Unix()
+	exp := []interface{}{
+		zabbix.Item{
+			Host:  "serverA",
+			Key:   "kapacitor.alert",
+			Value: "3",
+			Clock: clock,
+		},
+		zabbix.Item{
+			Host:  "kapacitor",
+			Key:   "kapacitor.cpu",
+			Value: "kapacitor.cpu.serverA is CRITICAL",
+			Clock: clock,
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, r := range ts.Requests() {
+		for _, item := range r.Data {
+			got = append(got, item)
+		}
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStream_AlertSlack(t *testing.T) {
+	ts := slacktest.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.slack()
+		.workspace('company_private')
+		.channel('#alerts')
+		.slack()
+		.channel('@jim')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c1 := slack.NewConfig()
+		c1.Default = true
+		c1.Enabled = true
+		c1.URL = ts.URL + "/test/slack/url"
+		c1.Channel = "#channel"
+		c2 := slack.NewConfig()
+		c2.Workspace = "company_private"
+		c2.Enabled = true
+		c2.URL = ts.URL + "/test/slack/url2"
+		c2.Token = "my_secret_token"
+		c2.Channel = "#channel"
+		d := diagService.NewSlackHandler().WithContext(keyvalue.KV("test", "slack"))
+		sl, err := slack.NewService([]slack.Config{c1, c2}, d)
+		if err != nil {
+			t.Error(err)
+		}
+		tm.SlackService = sl
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		slacktest.Request{
+			URL:        "/test/slack/url",
+			AuthHeader: "",
+			PostData: slacktest.PostData{
+				Channel:  "@jim",
+				Username: "kapacitor",
+				Text:     "",
+				Attachments: []slacktest.Attachment{
+					{
+						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
+						Color:     "danger",
+						Text:      "kapacitor/cpu/serverA is CRITICAL",
+						Mrkdwn_in: []string{"text"},
+					},
+				},
+			},
+		},
+		slacktest.Request{
+			URL:        "/test/slack/url2",
+			AuthHeader: "Bearer my_secret_token",
+			PostData: slacktest.PostData{
+				Channel:  "#alerts",
+				Username: "kapacitor",
+				Text:     "",
+				Attachments: []slacktest.Attachment{
+					{
+						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
+						Color:     "danger",
+						Text:      "kapacitor/cpu/serverA is CRITICAL",
+						Mrkdwn_in: []string{"text"},
+					},
+				},
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
 }
 
-func TestStream_AlertSlack(t *testing.T) {
+func TestStream_AlertRunbook(t *testing.T) {
 	ts := slacktest.NewServer()
 	defer ts.Close()
 
@@ -8751,30 +11247,21 @@ stream
 	|count('value')
 	|alert()
 		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.runbook('https://wiki.example.com/runbooks/{{ .Name }}?host={{ index .Tags "host" }}')
 		.info(lambda: "count" > 6.0)
 		.warn(lambda: "count" > 7.0)
 		.crit(lambda: "count" > 8.0)
 		.slack()
-		.workspace('company_private')
-		.channel('#alerts')
-		.slack()
-		.channel('@jim')
 `
 
 	tmInit := func(tm *kapacitor.TaskMaster) {
-		c1 := slack.NewConfig()
-		c1.Default = true
-		c1.Enabled = true
-		c1.URL = ts.URL + "/test/slack/url"
-		c1.Channel = "#channel"
-		c2 := slack.NewConfig()
-		c2.Workspace = "company_private"
-		c2.Enabled = true
-		c2.URL = ts.URL + "/test/slack/url2"
-		c2.Token = "my_secret_token"
-		c2.Channel = "#channel"
+		c := slack.NewConfig()
+		c.Default = true
+		c.Enabled = true
+		c.URL = ts.URL + "/test/slack/url"
+		c.Channel = "#channel"
 		d := diagService.NewSlackHandler().WithContext(keyvalue.KV("test", "slack"))
-		sl, err := slack.NewService([]slack.Config{c1, c2}, d)
+		sl, err := slack.NewService([]slack.Config{c}, d)
 		if err != nil {
 			t.Error(err)
 		}
@@ -8787,24 +11274,7 @@ stream
 			URL:        "/test/slack/url",
 			AuthHeader: "",
 			PostData: slacktest.PostData{
-				Channel:  "@jim",
-				Username: "kapacitor",
-				Text:     "",
-				Attachments: []slacktest.Attachment{
-					{
-						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
-						Color:     "danger",
-						Text:      "kapacitor/cpu/serverA is CRITICAL",
-						Mrkdwn_in: []string{"text"},
-					},
-				},
-			},
-		},
-		slacktest.Request{
-			URL:        "/test/slack/url2",
-			AuthHeader: "Bearer my_secret_token",
-			PostData: slacktest.PostData{
-				Channel:  "#alerts",
+				Channel:  "#channel",
 				Username: "kapacitor",
 				Text:     "",
 				Attachments: []slacktest.Attachment{
@@ -8813,6 +11283,11 @@ stream
 						Color:     "danger",
 						Text:      "kapacitor/cpu/serverA is CRITICAL",
 						Mrkdwn_in: []string{"text"},
+						Actions: []slacktest.Action{{
+							Type: "button",
+							Text: "Runbook",
+							URL:  "https://wiki.example.com/runbooks/cpu?host=serverA",
+						}},
 					},
 				},
 			},
@@ -9077,6 +11552,7 @@ stream
 		alert.Data{
 			ID:          "kapacitor.cpu.serverA",
 			Message:     "kapacitor.cpu.serverA is CRITICAL",
+			Fingerprint: "e4a832caba8246ea",
 			Time:        time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 			Level:       alert.Critical,
 			Recoverable: true,
@@ -10111,6 +12587,126 @@ stream
 	}
 }
 
+func TestStream_AlertPagerDuty2_CustomDetails(t *testing.T) {
+	ts := pagerduty2test.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.message('{{ .Level }} alert for {{ .ID }}')
+		.crit(lambda: "count" > 8.0)
+		.pagerDuty2()
+			.customDetail('host', '{{ index .Tags "host" }}')
+			.customDetail('count', '{{ index .Fields "count" }}')
+			.customDetail('threshold', '8')
+	`
+
+	var kapacitorURL string
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := pagerduty2.NewConfig()
+		c.Enabled = true
+		c.URL = ts.URL
+		c.RoutingKey = "routing_key"
+		pd := pagerduty2.NewService(c, diagService.NewPagerDuty2Handler())
+		pd.HTTPDService = tm.HTTPDService
+		tm.PagerDuty2Service = pd
+
+		kapacitorURL = tm.HTTPDService.URL()
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		pagerduty2test.Request{
+			URL: "/",
+			PostData: pagerduty2test.PostData{
+				Client:      "kapacitor",
+				ClientURL:   kapacitorURL,
+				EventAction: "trigger",
+				DedupKey:    "kapacitor/cpu/serverA",
+				Payload: &pagerduty2test.PDCEF{
+					Summary:  "CRITICAL alert for kapacitor/cpu/serverA",
+					Source:   "serverA",
+					Severity: "critical",
+					Class:    "TestStream_Alert",
+					CustomDetails: map[string]interface{}{
+						"result": map[string]interface{}{
+							"series": []interface{}{
+								map[string]interface{}{
+									"name": "cpu",
+									"tags": map[string]interface{}{
+										"host": "serverA",
+									},
+									"columns": []interface{}{"time", "count"},
+									"values": []interface{}{
+										[]interface{}{"1971-01-01T00:00:10Z", float64(10)},
+									},
+								},
+							},
+						},
+						"host":      "serverA",
+						"count":     "10",
+						"threshold": "8",
+					},
+					Timestamp: "1971-01-01T00:00:10.000000000Z",
+				},
+				RoutingKey: "routing_key",
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStream_AlertPagerDuty2_InvalidCustomDetail(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" > 8.0)
+		.pagerDuty2()
+			.customDetail('host', '{{ index .Tags "host" ')
+	`
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := pagerduty2.NewConfig()
+	c.Enabled = true
+	tm.PagerDuty2Service = pagerduty2.NewService(c, diagService.NewPagerDuty2Handler())
+	tm.Open()
+	defer tm.Close()
+	task, err := tm.NewTask("TestStream_AlertPagerDuty2_InvalidCustomDetail", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tm.StartTask(task)
+	if err == nil {
+		t.Fatal("expected the task to fail to start")
+	}
+	if exp := `invalid template of custom detail "host"`; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err.Error(), exp)
+	}
+}
+
 func TestStream_AlertHTTPPost(t *testing.T) {
 	ts := httpposttest.NewAlertServer(nil, false)
 	defer ts.Close()
@@ -10142,6 +12738,7 @@ stream
 			Data: alert.Data{
 				ID:          "kapacitor.cpu.serverA",
 				Message:     "kapacitor.cpu.serverA is CRITICAL",
+				Fingerprint: "e4a832caba8246ea",
 				Time:        time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 				Level:       alert.Critical,
 				Recoverable: true,
@@ -10173,6 +12770,154 @@ stream
 	}
 }
 
+func TestStream_AlertHTTPPostFailover(t *testing.T) {
+	defer func(d time.Duration) { alert.FailoverRetryDelay = d }(alert.FailoverRetryDelay)
+	alert.FailoverRetryDelay = 0
+
+	var primaryRequests int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryRequests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	ts := httpposttest.NewAlertServer(nil, false)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.crit(lambda: "count" > 8.0)
+		.details('')
+		.post('` + primary.URL + `')
+			.failover('` + ts.URL + `')
+			.retries(1)
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	ts.Close()
+	primary.Close()
+	if got, exp := atomic.LoadInt32(&primaryRequests), int32(2); got != exp {
+		t.Errorf("unexpected requests to the primary endpoint got %d exp %d", got, exp)
+	}
+	data := ts.Data()
+	if len(data) != 1 {
+		t.Fatalf("unexpected requests to the failover endpoint got %d exp 1", len(data))
+	}
+	if got, exp := data[0].Data.ID, "kapacitor.cpu.serverA"; got != exp {
+		t.Errorf("unexpected alert ID got %s exp %s", got, exp)
+	}
+}
+
+func TestStream_AlertHTTPPostTimeout(t *testing.T) {
+	defer func(d time.Duration) { alert.FailoverRetryDelay = d }(alert.FailoverRetryDelay)
+	alert.FailoverRetryDelay = 0
+
+	var primaryRequests int32
+	release := make(chan struct{})
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryRequests, 1)
+		// Stall longer than the timeout of the attempts.
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer primary.Close()
+	ts := httpposttest.NewAlertServer(nil, false)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.crit(lambda: "count" > 8.0)
+		.details('')
+		.post('` + primary.URL + `')
+			.timeout(50ms)
+			.failover('` + ts.URL + `')
+			.retries(1)
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	close(release)
+	ts.Close()
+	primary.Close()
+	if got, exp := atomic.LoadInt32(&primaryRequests), int32(2); got != exp {
+		t.Errorf("unexpected requests to the primary endpoint got %d exp %d", got, exp)
+	}
+	data := ts.Data()
+	if len(data) != 1 {
+		t.Fatalf("unexpected requests to the failover endpoint got %d exp 1", len(data))
+	}
+	if got, exp := data[0].Data.ID, "kapacitor.cpu.serverA"; got != exp {
+		t.Errorf("unexpected alert ID got %s exp %s", got, exp)
+	}
+}
+
+func TestStream_AlertHTTPPostMaxPayloadSize(t *testing.T) {
+	ts := httpposttest.NewAlertServer(nil, false)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.message('{{ .ID }} is {{ .Level }}')
+		.details('{{ json . }}')
+		.crit(lambda: "count" > 8.0)
+		.post('` + ts.URL + `')
+			.maxPayloadSize(600)
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	ts.Close()
+	data := ts.Data()
+	if len(data) != 1 {
+		t.Fatalf("unexpected requests got %d exp 1", len(data))
+	}
+	if got := len(data[0].Raw); got > 600 {
+		t.Errorf("unexpected payload size got %d exp at most 600", got)
+	}
+	ad := data[0].Data
+	if len(ad.Data.Series) != 0 {
+		t.Errorf("expected the data to be dropped, got %v", ad.Data.Series)
+	}
+	if !strings.HasSuffix(ad.Details, alert.TruncatedMarker) {
+		t.Errorf("expected truncated details, got %q", ad.Details)
+	}
+	if got, exp := ad.Message, "kapacitor.cpu.serverA is CRITICAL"; got != exp {
+		t.Errorf("unexpected message got %q exp %q", got, exp)
+	}
+}
+
 func TestStream_AlertHTTPPostEndpoint(t *testing.T) {
 	headers := map[string]string{"Authorization": "works"}
 	ts := httpposttest.NewAlertServer(headers, false)
@@ -10213,6 +12958,7 @@ stream
 			Data: alert.Data{
 				ID:          "kapacitor.cpu.serverA",
 				Message:     "kapacitor.cpu.serverA is CRITICAL",
+				Fingerprint: "e4a832caba8246ea",
 				Time:        time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 				Level:       alert.Critical,
 				Recoverable: true,
@@ -10635,6 +13381,7 @@ stream
 	expAD := []alert.Data{{
 		ID:          "kapacitor.cpu.serverA",
 		Message:     "kapacitor.cpu.serverA is CRITICAL",
+		Fingerprint: "e4a832caba8246ea",
 		Time:        time.Date(1971, 01, 01, 0, 0, 10, 0, time.UTC),
 		Level:       alert.Critical,
 		Recoverable: true,
@@ -10673,13 +13420,53 @@ stream
 		return nil
 	}
 
-	if err := testLog("normal", expAD, 0600, normal); err != nil {
-		t.Error(err)
+	if err := testLog("normal", expAD, 0600, normal); err != nil {
+		t.Error(err)
+	}
+	if err := testLog("mode", expAD, 0644, mode); err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestStream_AlertFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "alerts.csv")
+
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.details('')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.file('%s')
+			.format('csv')
+			.fields('time', 'level', 'id', 'tags.host', 'fields.count')
+			.header()
+`, path)
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if err := testLog("mode", expAD, 0644, mode); err != nil {
-		t.Error(err)
+	exp := `time,level,id,tags.host,fields.count
+1971-01-01T00:00:10Z,CRITICAL,kapacitor.cpu.serverA,serverA,10
+`
+	if got := string(data); got != exp {
+		t.Errorf("unexpected file content got\n%s\nexp\n%s", got, exp)
 	}
-
 }
 
 func TestStream_AlertExec(t *testing.T) {
@@ -10706,6 +13493,7 @@ stream
 	expAD := alert.Data{
 		ID:          "kapacitor.cpu.serverA",
 		Message:     "kapacitor.cpu.serverA is CRITICAL",
+		Fingerprint: "e4a832caba8246ea",
 		Time:        time.Date(1971, 01, 01, 0, 0, 10, 0, time.UTC),
 		Level:       alert.Critical,
 		Recoverable: true,
@@ -11160,6 +13948,7 @@ func TestStream_AlertSigma(t *testing.T) {
 				ID:          "cpu:nil",
 				Message:     "cpu:nil is INFO",
 				Details:     "cpu:nil is INFO",
+				Fingerprint: "45e4ed06369c0a6c",
 				Time:        time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
 				Level:       alert.Info,
 				Recoverable: true,
@@ -11183,6 +13972,7 @@ func TestStream_AlertSigma(t *testing.T) {
 				ID:            "cpu:nil",
 				Message:       "cpu:nil is OK",
 				Details:       "cpu:nil is OK",
+				Fingerprint:   "45e4ed06369c0a6c",
 				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
 				Duration:      time.Second,
 				Level:         alert.OK,
@@ -11248,6 +14038,7 @@ func TestStream_AlertComplexWhere(t *testing.T) {
 			ID:          "cpu:nil",
 			Message:     "cpu:nil is CRITICAL",
 			Details:     "",
+			Fingerprint: "45e4ed06369c0a6c",
 			Time:        time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
 			Level:       alert.Critical,
 			Recoverable: true,
@@ -11332,6 +14123,7 @@ func TestStream_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu:nil",
 				Message:       "cpu:nil is CRITICAL",
+				Fingerprint:   "45e4ed06369c0a6c",
 				Time:          time.Date(1971, 1, 1, 0, 0, int(rc)*2-1, 0, time.UTC),
 				Duration:      time.Duration(rc-1) * 2 * time.Second,
 				Level:         alert.Critical,
@@ -11342,6 +14134,7 @@ func TestStream_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu:nil",
 				Message:       "cpu:nil is CRITICAL",
+				Fingerprint:   "45e4ed06369c0a6c",
 				Time:          time.Date(1971, 1, 1, 0, 0, int(rc)*2-1, 0, time.UTC),
 				Duration:      time.Duration(rc-1) * 2 * time.Second,
 				Level:         alert.Critical,
@@ -11352,6 +14145,7 @@ func TestStream_AlertStateChangesOnlyExpired(t *testing.T) {
 			expAd = alert.Data{
 				ID:            "cpu:nil",
 				Message:       "cpu:nil is OK",
+				Fingerprint:   "45e4ed06369c0a6c",
 				Time:          time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 				Duration:      9 * time.Second,
 				Level:         alert.OK,
@@ -11359,27 +14153,593 @@ func TestStream_AlertStateChangesOnlyExpired(t *testing.T) {
 				Recoverable:   true,
 			}
 		}
-		if eq, msg := compareAlertData(expAd, ad); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		if eq, msg := compareAlertData(expAd, ad); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" < 97)
+		.details('')
+		.stateChangesOnly(2s)
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertStateChangesOnlyExpired", script, 13*time.Second, nil)
+
+	// 9 points below 97 once per second so 5 alerts + 1 recovery
+	if exp, rc := 6, int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("got %v exp %v", rc, exp)
+	}
+}
+
+func TestStream_AlertEvery(t *testing.T) {
+	var mu sync.Mutex
+	var events []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, ad)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" < 95.7)
+		.every(3s)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertStateChangesOnly", script, 13*time.Second, nil)
+
+	// Only the latest point of each 3s interval is evaluated,
+	// and the point of the last interval is never evaluated.
+	exp := []struct {
+		level alert.Level
+		t     time.Time
+	}{
+		{level: alert.Critical, t: time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC)},
+		{level: alert.OK, t: time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC)},
+		{level: alert.Critical, t: time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC)},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := len(events), len(exp); got != exp {
+		t.Fatalf("unexpected number of events got %d exp %d", got, exp)
+	}
+	for i, e := range exp {
+		if got := events[i]; got.Level != e.level || !got.Time.Equal(e.t) {
+			t.Errorf("%d: unexpected event got %v at %v exp %v at %v", i, got.Level, got.Time, e.level, e.t)
+		}
+	}
+}
+
+func TestStream_ExecutionPool_Blocking(t *testing.T) {
+	// More groups than the buffers of the edges after the alert node,
+	// so the alert node blocks forwarding a point it evaluates on a barrier.
+	// The default node keeps one more message, so the point and not the barrier is blocked.
+	const groups = 2500
+	release := make(chan struct{})
+	after := make(chan struct{})
+	var mu sync.Mutex
+	posts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		posts++
+		// The point written while the query is executing is the last one.
+		if posts == groups+1 {
+			close(after)
+		}
+	}))
+	defer ts.Close()
+
+	queried := make(chan struct{})
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		select {
+		case <-queried:
+		default:
+			// The stream task processes points while the first query is executing.
+			close(queried)
+			select {
+			case <-after:
+			case <-time.After(10 * time.Second):
+				t.Error("timed out executing the query waiting for the stream task")
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+
+	tm, err := createTaskMaster("TestStream_ExecutionPool_Blocking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.InfluxDBService = influxdb
+	tm.ExecutionPools = kapacitor.NewExecutionPools([]kapacitor.ExecutionPoolConfig{
+		{Name: "TestStream_ExecutionPool_Blocking", Size: 1, Tasks: []string{"*"}},
+	})
+	defer tm.ExecutionPools.Close()
+	if err := tm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Close()
+
+	streamTask, err := tm.NewTask("stream", `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|barrier()
+		.idle(1s)
+	|alert()
+		.crit(lambda: TRUE)
+		.every(1s)
+	|default()
+	|httpPost('`+ts.URL+`')
+`, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(streamTask); err != nil {
+		t.Fatal(err)
+	}
+	points := make([]imodels.Point, groups)
+	for i := range points {
+		points[i] = imodels.MustNewPoint("cpu", imodels.NewTags(map[string]string{"host": strconv.Itoa(i)}), imodels.Fields{"value": 1.0}, time.Unix(0, 0))
+	}
+	if err := tm.WritePoints("dbname", "rpname", imodels.ConsistencyLevelAny, points); err != nil {
+		t.Fatal(err)
+	}
+	// Let the edges fill up while the httpPost node waits for its first post.
+	time.Sleep(2 * time.Second)
+	close(release)
+
+	timeout := time.After(10 * time.Second)
+	for done := false; !done; {
+		select {
+		case <-timeout:
+			mu.Lock()
+			defer mu.Unlock()
+			t.Fatalf("timed out waiting for the posts of the barriers got %d exp %d", posts, groups)
+		case <-time.After(10 * time.Millisecond):
+		}
+		mu.Lock()
+		done = posts == groups
+		mu.Unlock()
+	}
+
+	batchTask, err := tm.NewTask("batch", `
+batch
+	|query('SELECT mean("value") FROM "dbname"."rpname".cpu')
+		.period(10s)
+		.every(100ms)
+`, kapacitor.BatchTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	et, err := tm.StartTask(batchTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.StartBatching(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-queried:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the query")
+	}
+	p := imodels.MustNewPoint("cpu", imodels.NewTags(map[string]string{"host": "after"}), imodels.Fields{"value": 1.0}, time.Unix(0, 0))
+	if err := tm.WritePoints("dbname", "rpname", imodels.ConsistencyLevelAny, []imodels.Point{p}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-after:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the stream task while the query is executing")
+	}
+}
+
+func TestStream_AlertCritFor(t *testing.T) {
+	var mu sync.Mutex
+	var events []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, ad)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" < 96)
+		.critFor(1s)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertStateChangesOnlyExpired", script, 13*time.Second, nil)
+
+	// The first point below 96 does not enter the critical level,
+	// and the single point at 96 does not leave it.
+	exp := []struct {
+		level alert.Level
+		t     time.Time
+	}{
+		{level: alert.Critical, t: time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC)},
+		{level: alert.OK, t: time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC)},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := len(events), len(exp); got != exp {
+		t.Fatalf("unexpected number of events got %d exp %d", got, exp)
+	}
+	for i, e := range exp {
+		if got := events[i]; got.Level != e.level || !got.Time.Equal(e.t) {
+			t.Errorf("%d: unexpected event got %v at %v exp %v at %v", i, got.Level, got.Time, e.level, e.t)
+		}
+	}
+}
+
+func TestStream_AlertResolveDelay(t *testing.T) {
+	var mu sync.Mutex
+	var events []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, ad)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" < 96)
+		.resolveDelay(1s)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertStateChangesOnlyExpired", script, 13*time.Second, nil)
+
+	// The single point at 96 does not resolve the alert,
+	// it is only resolved once the value has been above 96 for 1s.
+	exp := []struct {
+		level alert.Level
+		t     time.Time
+	}{
+		{level: alert.Critical, t: time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC)},
+		{level: alert.OK, t: time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC)},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := len(events), len(exp); got != exp {
+		t.Fatalf("unexpected number of events got %d exp %d", got, exp)
+	}
+	for i, e := range exp {
+		if got := events[i]; got.Level != e.level || !got.Time.Equal(e.t) {
+			t.Errorf("%d: unexpected event got %v at %v exp %v at %v", i, got.Level, got.Time, e.level, e.t)
+		}
+	}
+}
+
+func TestStream_AlertTimeSource(t *testing.T) {
+	testCases := []struct {
+		name       string
+		timeSource string
+		wall       bool
+	}{
+		{name: "default"},
+		{name: "point", timeSource: "point"},
+		{name: "wall", timeSource: "wall", wall: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []alert.Data
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ad := alert.Data{}
+				if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				events = append(events, ad)
+				mu.Unlock()
+			}))
+			defer ts.Close()
+			timeSource := ""
+			if tc.timeSource != "" {
+				timeSource = ".timeSource('" + tc.timeSource + "')"
+			}
+			var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.crit(lambda: "value" < 96)
+		.stateChangesOnly()
+		` + timeSource + `
+		.post('` + ts.URL + `')
+`
+
+			start := time.Now()
+			testStreamerNoOutput(t, "TestStream_AlertStateChangesOnlyExpired", script, 13*time.Second, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) == 0 {
+				t.Fatal("expected events")
+			}
+			// The first event is triggered by the point at 1s.
+			got := events[0].Time
+			if tc.wall {
+				if got.Before(start) || got.After(time.Now()) {
+					t.Errorf("unexpected event time got %v exp wall clock time after %v", got, start)
+				}
+			} else if exp := time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC); !got.Equal(exp) {
+				t.Errorf("unexpected event time got %v exp %v", got, exp)
+			}
+		})
+	}
+}
+
+func TestStream_AlertGlobalTags(t *testing.T) {
+	var mu sync.Mutex
+	var events []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, ad)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.message('{{ index .Tags "kapacitor_instance" }}')
+		.crit(lambda: "count" > 8.0)
+		.post('` + ts.URL + `')
+`
+
+	var fingerprint string
+	for _, globalTags := range []map[string]string{nil, {"kapacitor_instance": "east-1", "host": "global"}} {
+		events = nil
+		tmInit := func(tm *kapacitor.TaskMaster) {
+			tm.GlobalTags = globalTags
+		}
+		testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+		mu.Lock()
+		if len(events) != 1 {
+			mu.Unlock()
+			t.Fatalf("unexpected number of events got %d exp 1", len(events))
+		}
+		e := events[0]
+		mu.Unlock()
+		if globalTags == nil {
+			fingerprint = e.Fingerprint
+			continue
+		}
+		if got, exp := e.Message, "east-1"; got != exp {
+			t.Errorf("unexpected message got %q exp %q", got, exp)
+		}
+		// The tags of the data take precedence.
+		exp := map[string]string{"kapacitor_instance": "east-1", "host": "serverA"}
+		if got := e.Data.Series[0].Tags; !reflect.DeepEqual(got, exp) {
+			t.Errorf("unexpected tags got %v exp %v", got, exp)
+		}
+		// The global tags do not change the fingerprint.
+		if e.Fingerprint != fingerprint {
+			t.Errorf("unexpected fingerprint got %s exp %s", e.Fingerprint, fingerprint)
+		}
+	}
+}
+
+func TestStream_AlertTrigger(t *testing.T) {
+	var mu sync.Mutex
+	var levels []alert.Level
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		levels = append(levels, ad.Level)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.warn(lambda: "value" < 96)
+		.crit(lambda: "value" < 94)
+		.trigger('critical')
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertTrigger", script, 13*time.Second, nil)
+
+	// CRITICAL is sent once each time it is entered and its recovery to OK is not sent,
+	// while every WARNING event is sent.
+	exp := []alert.Level{
+		alert.Critical,
+		alert.Warning,
+		alert.Critical,
+		alert.Warning,
+		alert.Critical,
+		alert.Critical,
+		alert.Warning,
+		alert.Critical,
+		alert.Warning,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(levels, exp) {
+		t.Errorf("unexpected levels got %v exp %v", levels, exp)
+	}
+}
+
+func TestStream_AlertCustomLevels(t *testing.T) {
+	if err := alert.SetCustomLevels([]string{"FATAL", "DOOM"}); err != nil {
+		t.Fatal(err)
+	}
+	defer alert.SetCustomLevels(nil)
+	fatal, _ := alert.ParseLevel("FATAL")
+	doom, _ := alert.ParseLevel("DOOM")
+
+	var mu sync.Mutex
+	var levels []alert.Level
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		levels = append(levels, ad.Level)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.level('DOOM', lambda: "value" > 99.9)
+		.level('fatal', lambda: "value" > 98)
+		.crit(lambda: "value" > 90)
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertCustomLevels", script, 9*time.Second, nil)
+
+	// The levels follow the order of the custom levels, not the order of the expressions.
+	exp := []alert.Level{
+		alert.Critical,
+		fatal,
+		doom,
+		fatal,
+		alert.Critical,
+		alert.OK,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(levels, exp) {
+		t.Errorf("unexpected levels got %v exp %v", levels, exp)
+	}
+}
+
+func TestStream_AlertUnknownCustomLevel(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.level('FATAL', lambda: "value" > 98)
+`
+
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+	task, err := tm.NewTask("TestStream_AlertUnknownCustomLevel", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tm.StartTask(task)
+	if err == nil {
+		t.Fatal("expected the task to fail to start")
+	}
+	if exp := `invalid level "FATAL"`; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err.Error(), exp)
+	}
+}
+
+func TestStream_AlertUniqueId(t *testing.T) {
+	var mu sync.Mutex
+	ids := make(map[string]map[string]bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		host := ad.Data.Series[0].Tags["host"]
+		mu.Lock()
+		if ids[host] == nil {
+			ids[host] = make(map[string]bool)
 		}
+		ids[host][ad.ID] = true
+		mu.Unlock()
 	}))
 	defer ts.Close()
 	var script = `
 stream
 	|from()
 		.measurement('cpu')
+		.groupBy('host')
 	|alert()
-		.crit(lambda: "value" < 97)
-		.details('')
-		.stateChangesOnly(2s)
+		// The ID does not include the host
+		.id('{{ .Name }}')
+		.uniqueId()
+		.info(lambda: TRUE)
 		.post('` + ts.URL + `')
 `
 
-	testStreamerNoOutput(t, "TestStream_AlertStateChangesOnlyExpired", script, 13*time.Second, nil)
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
 
-	// 9 points below 97 once per second so 5 alerts + 1 recovery
-	if exp, rc := 6, int(atomic.LoadInt32(&requestCount)); rc != exp {
-		t.Errorf("got %v exp %v", rc, exp)
+	// The first group keeps the rendered ID while the others get a hash of their group appended.
+	exp := map[string]map[string]bool{
+		"serverA": {"cpu": true},
+		"serverB": {"cpu:56d22549": true},
+		"serverC": {"cpu:55d223b6": true},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(ids, exp) {
+		t.Errorf("unexpected ids got %v exp %v", ids, exp)
 	}
 }
 
@@ -11962,6 +15322,134 @@ stream
 	}
 }
 
+func TestStream_AutoscaleWebhook(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options string
+		result  models.Result
+		exp     map[string][]int
+	}{
+		{
+			name: "bounds",
+			result: models.Result{
+				Series: models.Rows{
+					{
+						Name: "scale",
+						Tags: map[string]string{
+							"deployment": "serviceA",
+						},
+						Columns: []string{"time", "new", "old"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+							2.0,
+							1000.0,
+						}},
+					},
+					{
+						Name: "scale",
+						Tags: map[string]string{
+							"deployment": "serviceB",
+						},
+						Columns: []string{"time", "new", "old"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+							20.0,
+							1000.0,
+						}},
+					},
+				},
+			},
+			// The current replicas are not known so the first desired replicas are always posted.
+			exp: map[string][]int{
+				"serviceA": []int{1, 2, 1, 1000, 2},
+				"serviceB": []int{10, 20, 1, 1000, 20},
+			},
+		},
+		{
+			name:    "cooldown",
+			options: ".increaseCooldown(10s)",
+			result: models.Result{
+				Series: models.Rows{
+					{
+						Name: "scale",
+						Tags: map[string]string{
+							"deployment": "serviceA",
+						},
+						Columns: []string{"time", "new", "old"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+							1.0,
+							0.0,
+						}},
+					},
+					{
+						Name: "scale",
+						Tags: map[string]string{
+							"deployment": "serviceB",
+						},
+						Columns: []string{"time", "new", "old"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+							1.0,
+							10.0,
+						}},
+					},
+				},
+			},
+			// Increases are dropped within the cooldown of each resource.
+			exp: map[string][]int{
+				"serviceA": []int{1},
+				"serviceB": []int{10, 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			updates := make(map[string][]int)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got, exp := r.Header.Get("Authorization"), "Bearer secret"; got != exp {
+					t.Errorf("unexpected Authorization header got %q exp %q", got, exp)
+				}
+				event := struct {
+					Resource string `json:"resource"`
+					Replicas int    `json:"replicas"`
+				}{}
+				if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				updates[event.Resource] = append(updates[event.Resource], event.Replicas)
+				mu.Unlock()
+			}))
+			defer ts.Close()
+
+			script := fmt.Sprintf(`
+stream
+	|from()
+		.measurement('scale')
+		.groupBy('deployment')
+	|autoscale('%s')
+		.header('Authorization', 'Bearer secret')
+		.resourceNameTag('deployment')
+		.replicas(lambda: int("replicas"))
+		%s
+	|httpOut('TestStream_Autoscale')
+`, ts.URL, tc.options)
+
+			testStreamerWithOutput(t, "TestStream_Autoscale", script, 13*time.Second, tc.result, false, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(updates, tc.exp) {
+				t.Errorf("unexpected updates\ngot\n%v\nexp\n%v\n", updates, tc.exp)
+			}
+		})
+	}
+}
+
 func TestStream_KapacitorLoopback_PreventLoop(t *testing.T) {
 
 	var script = `
@@ -11993,6 +15481,91 @@ stream
 	}
 }
 
+func TestStream_KapacitorLoopback_RestartBuffer(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|kapacitorLoopback()
+		.database('new-dbname')
+		.retentionPolicy('new-rpname')
+`
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// More points than the buffer of the edge of the task.
+	const count = 3000
+	tm.RestartBufferSize = count
+	tm.Open()
+	defer tm.Close()
+
+	task, err := tm.NewTask("KapacitorLoopback-RestartBuffer", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.StopTaskForRestart(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	points := make([]imodels.Point, count)
+	for i := range points {
+		points[i] = imodels.MustNewPoint("cpu", nil, imodels.Fields{"value": float64(i)}, time.Unix(int64(i), 0))
+	}
+	if err := tm.WritePoints("dbname", "rpname", imodels.ConsistencyLevelAny, points); err != nil {
+		t.Fatal(err)
+	}
+
+	// The writes of the loopback are not blocked while the buffered points are replayed.
+	done := make(chan error, 1)
+	go func() {
+		if _, err := tm.StartTask(task); err != nil {
+			done <- err
+			return
+		}
+		done <- tm.WritePoints("dbname", "rpname", imodels.ConsistencyLevelAny, points[:1])
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out starting the task while replaying its restart buffer")
+	}
+	if err := tm.StopTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStream_StartTaskAlreadyRunning(t *testing.T) {
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+
+	task, err := tm.NewTask("StartTaskAlreadyRunning", `stream|from().measurement('cpu')`, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err != kapacitor.ErrTaskAlreadyRunning {
+		t.Fatalf("unexpected error starting the running task got %v exp %v", err, kapacitor.ErrTaskAlreadyRunning)
+	}
+	if err := tm.StopTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestStream_KapacitorLoopback(t *testing.T) {
 	var scriptLoop = `
 stream
@@ -12237,24 +15810,96 @@ stream
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	resp, err := http.Get(output.Endpoint())
-	if err != nil {
-		t.Fatal(err)
+
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert we got the expected result
+	result := models.Result{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+}
+
+func TestStream_Sideload(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('m')
+		.groupBy('t0', 't1', 't2')
+	|sideload()
+		.source('file://%s/testdata/sideload')
+		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
+		.field('f1', 0)
+		.field('f2', 0.0)
+		.tag('t3', 'one')
+	|log()
+	|httpOut('TestStream_Sideload')
+`, wd)
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "a", "t1": "m", "t2": "x", "t3": "one"},
+				Columns: []string{"time", "f1", "f2", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+						0.0,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "b", "t1": "n", "t2": "y", "t3": "why"},
+				Columns: []string{"time", "f1", "f2", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						2.0,
+						3.5,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "c", "t1": "o", "t2": "y", "t3": "why"},
+				Columns: []string{"time", "f1", "f2", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						12.0,
+						13.5,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.SideloadService = sideload.NewService(diagService.NewSideloadHandler())
 	}
 
-	// Assert we got the expected result
-	result := models.Result{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if eq, msg := compareResults(er, result); !eq {
-		t.Error(msg)
-	}
+	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
 }
 
-func TestStream_Sideload(t *testing.T) {
+func TestStream_Sideload_JSON(t *testing.T) {
 	wd, err := os.Getwd()
 	if err != nil {
 		t.Fatal(err)
@@ -12268,7 +15913,7 @@ stream
 		.groupBy('t0', 't1', 't2')
 	|sideload()
 		.source('file://%s/testdata/sideload')
-		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
+		.order('t0/{{.t0}}.json', 't1/{{.t1}}.json', 't2/{{.t2}}.yml')
 		.field('f1', 0)
 		.field('f2', 0.0)
 		.tag('t3', 'one')
@@ -12326,7 +15971,92 @@ stream
 	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
 }
 
-func TestStream_Sideload_JSON(t *testing.T) {
+func TestStream_QueryLookup(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|query('SELECT last("cores") FROM "inventory"."autogen"."hosts" WHERE "host" = \'{{.host}}\'')
+		.as('cores')
+		.errorTag('query_error')
+	|httpOut('TestStream_QueryLookup')
+`
+	var mu sync.Mutex
+	queries := make(map[string]int)
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		mu.Lock()
+		queries[q]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(q, "'serverA'"):
+			w.Write([]byte(`{"results":[{"series":[{"name":"hosts","columns":["time","last"],"values":[["1971-01-01T00:00:00Z",4]]}]}]}`))
+		case strings.Contains(q, "'serverB'"):
+			w.Write([]byte(`{"results":[{"series":[{"name":"hosts","columns":["time","last"],"values":[["1971-01-01T00:00:00Z",8]]}]}]}`))
+		default:
+			w.Write([]byte(`{"results":[{"error":"host not found"}]}`))
+		}
+	}))
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "cores", "value"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					4.0,
+					14.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "cores", "value"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					8.0,
+					24.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverC", "query_error": "failed to execute lookup query: host not found"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					34.0,
+				}},
+			},
+		},
+	}
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+	}
+	testStreamerWithOutput(t, "TestStream_QueryLookup", script, 5*time.Second, er, true, tmInit)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Successful lookups are cached, failed lookups are retried for every point.
+	for q, count := range queries {
+		exp := 1
+		if strings.Contains(q, "'serverC'") {
+			exp = 5
+		}
+		if count != exp {
+			t.Errorf("unexpected number of queries for %q: got %d exp %d", q, count, exp)
+		}
+	}
+	if got, exp := len(queries), 3; got != exp {
+		t.Errorf("unexpected number of distinct queries: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_Sideload_Multiple(t *testing.T) {
 	wd, err := os.Getwd()
 	if err != nil {
 		t.Fatal(err)
@@ -12339,11 +16069,15 @@ stream
 		.measurement('m')
 		.groupBy('t0', 't1', 't2')
 	|sideload()
-		.source('file://%s/testdata/sideload')
-		.order('t0/{{.t0}}.json', 't1/{{.t1}}.json', 't2/{{.t2}}.yml')
+		.source('file://%[1]s/testdata/sideload')
+		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
 		.field('f1', 0)
 		.field('f2', 0.0)
 		.tag('t3', 'one')
+	|sideload()
+		.source('file://%[1]s/testdata/sideload')
+		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
+		.field('other', -1.0)
 	|log()
 	|httpOut('TestStream_Sideload')
 `, wd)
@@ -12353,12 +16087,13 @@ stream
 			{
 				Name:    "m",
 				Tags:    map[string]string{"t0": "a", "t1": "m", "t2": "x", "t3": "one"},
-				Columns: []string{"time", "f1", "f2", "value"},
+				Columns: []string{"time", "f1", "f2", "other", "value"},
 				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 						0.0,
 						0.0,
+						-1.0,
 						1.0,
 					},
 				},
@@ -12366,12 +16101,13 @@ stream
 			{
 				Name:    "m",
 				Tags:    map[string]string{"t0": "b", "t1": "n", "t2": "y", "t3": "why"},
-				Columns: []string{"time", "f1", "f2", "value"},
+				Columns: []string{"time", "f1", "f2", "other", "value"},
 				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 						2.0,
 						3.5,
+						56.0,
 						1.0,
 					},
 				},
@@ -12379,12 +16115,13 @@ stream
 			{
 				Name:    "m",
 				Tags:    map[string]string{"t0": "c", "t1": "o", "t2": "y", "t3": "why"},
-				Columns: []string{"time", "f1", "f2", "value"},
+				Columns: []string{"time", "f1", "f2", "other", "value"},
 				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 						12.0,
 						13.5,
+						56.0,
 						1.0,
 					},
 				},
@@ -12395,89 +16132,310 @@ stream
 		tm.SideloadService = sideload.NewService(diagService.NewSideloadHandler())
 	}
 
-	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
+	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
+}
+
+func TestStream_InfluxDBOut(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|influxDBOut()
+		.database('db')
+		.retentionPolicy('rp')
+		.measurement('m')
+		.precision('s')
+		.tag('key', 'value')
+		.flushInterval(1ms)
+`
+	done := make(chan error, 1)
+	var points []imodels.Point
+	var database string
+	var rp string
+	var precision string
+
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		//Respond
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+		//Get request data
+		database = r.URL.Query().Get("db")
+		rp = r.URL.Query().Get("rp")
+		precision = r.URL.Query().Get("precision")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			done <- err
+			return
+		}
+		points, err = imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), precision)
+		done <- err
+	}))
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+	}
+	testStreamerNoOutput(t, "TestStream_InfluxDBOut", script, 15*time.Second, tmInit)
+
+	if database != "db" {
+		t.Errorf("got %v exp %v", database, "db")
+	}
+	if rp != "rp" {
+		t.Errorf("got %v exp %v", rp, "rp")
+	}
+	if precision != "s" {
+		t.Errorf("got %v exp %v", precision, "s")
+	}
+	if len(points) != 1 {
+		t.Errorf("got %v exp %v", len(points), 1)
+	} else {
+		p := points[0]
+		if string(p.Name()) != "m" {
+			t.Errorf("got %v exp %v", p.Name(), "m")
+		}
+		if fields, err := p.Fields(); fields["count"] != int64(10) {
+			t.Errorf("got %v exp %v", fields["count"], 10.0)
+		} else if err != nil {
+			t.Errorf("expected no error on fields but got %v", err)
+		}
+		if len(p.Tags()) != 1 {
+			t.Errorf("got %v exp %v", len(p.Tags()), 1)
+		}
+		if got, exp := p.Tags().GetString("key"), "value"; got != exp {
+			t.Errorf("got %s exp %s", got, exp)
+		}
+		tm := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
+		if !tm.Equal(p.Time()) {
+			t.Errorf("times are not equal exp %s got %s", tm, p.Time())
+		}
+	}
+}
+func TestStream_InfluxDBOut_Precision(t *testing.T) {
+	testCases := []struct {
+		precision    string
+		expPrecision string
+		expTime      time.Time
+	}{
+		{precision: "", expPrecision: "ns", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "ns", expPrecision: "ns", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "u", expPrecision: "u", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "us", expPrecision: "u", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "ms", expPrecision: "ms", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "s", expPrecision: "s", expTime: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{precision: "m", expPrecision: "m", expTime: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{precision: "h", expPrecision: "h", expTime: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		precision := ""
+		if tc.precision != "" {
+			precision = fmt.Sprintf(".precision('%s')", tc.precision)
+		}
+		var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|influxDBOut()
+		.database('db')
+		.measurement('m')
+		%s
+		.flushInterval(1ms)
+`, precision)
+		done := make(chan error, 1)
+		var points []imodels.Point
+		var gotPrecision string
+
+		influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ping" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			var data client.Response
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(data)
+			gotPrecision = r.URL.Query().Get("precision")
+
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				done <- err
+				return
+			}
+			points, err = imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), gotPrecision)
+			done <- err
+		}))
+
+		tmInit := func(tm *kapacitor.TaskMaster) {
+			tm.InfluxDBService = influxdb
+		}
+		testStreamerNoOutput(t, "TestStream_InfluxDBOut", script, 15*time.Second, tmInit)
+
+		if gotPrecision != tc.expPrecision {
+			t.Errorf("precision %q: got precision %q exp %q", tc.precision, gotPrecision, tc.expPrecision)
+		}
+		if len(points) != 1 {
+			t.Errorf("precision %q: got %d points exp 1", tc.precision, len(points))
+		} else if got := points[0].Time(); !got.Equal(tc.expTime) {
+			t.Errorf("precision %q: got time %s exp %s", tc.precision, got, tc.expTime)
+		}
+	}
+}
+
+func TestStream_InfluxDBOut_InvalidPrecision(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|influxDBOut()
+		.database('db')
+		.precision('d')
+`
+	tm, err := createTaskMaster("testStreamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+	_, err = tm.NewTask("TestStream_InfluxDBOut_InvalidPrecision", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err == nil {
+		t.Fatal("expected the task to be invalid")
+	}
+	if exp := `invalid precision "d"`; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp %q", err.Error(), exp)
+	}
+}
+
+func TestStream_InfluxDBOut_Arrays(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('latency')
+	|eval(lambda: array("p50", "p90", "p99"), lambda: arraySum(array("p50", "p90", "p99")))
+		.as('quantiles', 'total')
+	|influxDBOut()
+		.database('db')
+		.retentionPolicy('rp')
+		.flushInterval(1ms)
+`
+	var mu sync.Mutex
+	var points []imodels.Point
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		ps, err := imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), r.URL.Query().Get("precision"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		points = append(points, ps...)
+		mu.Unlock()
+	}))
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+	}
+	testStreamerNoOutput(t, "TestStream_EvalArray", script, 3*time.Second, tmInit)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(points) == 0 {
+		t.Fatal("expected points to be written")
+	}
+	// The array field is not written.
+	for _, p := range points {
+		fields, err := p.Fields()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := fields["quantiles"]; ok {
+			t.Errorf("unexpected array field written %v", fields)
+		}
+		if _, ok := fields["total"]; !ok {
+			t.Errorf("expected total field written %v", fields)
+		}
+	}
 }
 
-func TestStream_Sideload_Multiple(t *testing.T) {
-	wd, err := os.Getwd()
-	if err != nil {
-		t.Fatal(err)
-	}
-	var script = fmt.Sprintf(`
+func TestStream_InfluxDBOut_GlobalTags(t *testing.T) {
+	var script = `
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('m')
-		.groupBy('t0', 't1', 't2')
-	|sideload()
-		.source('file://%[1]s/testdata/sideload')
-		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
-		.field('f1', 0)
-		.field('f2', 0.0)
-		.tag('t3', 'one')
-	|sideload()
-		.source('file://%[1]s/testdata/sideload')
-		.order('t0/{{.t0}}.yml', 't1/{{.t1}}.yml', 't2/{{.t2}}.yml')
-		.field('other', -1.0)
-	|log()
-	|httpOut('TestStream_Sideload')
-`, wd)
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|influxDBOut()
+		.database('db')
+		.retentionPolicy('rp')
+		.tag('region', 'us')
+		.flushInterval(1ms)
+`
+	done := make(chan error, 1)
+	var points []imodels.Point
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			done <- err
+			return
+		}
+		points, err = imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), r.URL.Query().Get("precision"))
+		done <- err
+	}))
 
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "m",
-				Tags:    map[string]string{"t0": "a", "t1": "m", "t2": "x", "t3": "one"},
-				Columns: []string{"time", "f1", "f2", "other", "value"},
-				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						0.0,
-						0.0,
-						-1.0,
-						1.0,
-					},
-				},
-			},
-			{
-				Name:    "m",
-				Tags:    map[string]string{"t0": "b", "t1": "n", "t2": "y", "t3": "why"},
-				Columns: []string{"time", "f1", "f2", "other", "value"},
-				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						2.0,
-						3.5,
-						56.0,
-						1.0,
-					},
-				},
-			},
-			{
-				Name:    "m",
-				Tags:    map[string]string{"t0": "c", "t1": "o", "t2": "y", "t3": "why"},
-				Columns: []string{"time", "f1", "f2", "other", "value"},
-				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						12.0,
-						13.5,
-						56.0,
-						1.0,
-					},
-				},
-			},
-		},
-	}
 	tmInit := func(tm *kapacitor.TaskMaster) {
-		tm.SideloadService = sideload.NewService(diagService.NewSideloadHandler())
+		tm.InfluxDBService = influxdb
+		// The tags of the points and of the node take precedence.
+		tm.GlobalTags = map[string]string{"kapacitor_instance": "east-1", "host": "global", "region": "global"}
 	}
+	testStreamerNoOutput(t, "TestStream_InfluxDBOut", script, 15*time.Second, tmInit)
 
-	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
+	if len(points) != 1 {
+		t.Fatalf("got %v exp %v", len(points), 1)
+	}
+	exp := map[string]string{"kapacitor_instance": "east-1", "host": "serverA", "region": "us"}
+	if got := points[0].Tags().Map(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected tags got %v exp %v", got, exp)
+	}
 }
 
-func TestStream_InfluxDBOut(t *testing.T) {
+func TestStream_InfluxDBOut_Bucket(t *testing.T) {
 
 	var script = `
 stream
@@ -12489,31 +16447,26 @@ stream
 		.every(10s)
 	|count('value')
 	|influxDBOut()
-		.database('db')
-		.retentionPolicy('rp')
+		.bucket('mybucket')
+		.org('myorg')
 		.measurement('m')
 		.precision('s')
-		.tag('key', 'value')
 		.flushInterval(1ms)
 `
 	done := make(chan error, 1)
 	var points []imodels.Point
-	var database string
-	var rp string
-	var precision string
+	var path, bucket, org, precision string
 
 	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/ping" {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		//Respond
-		var data client.Response
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(data)
+		w.WriteHeader(http.StatusNoContent)
 		//Get request data
-		database = r.URL.Query().Get("db")
-		rp = r.URL.Query().Get("rp")
+		path = r.URL.Path
+		bucket = r.URL.Query().Get("bucket")
+		org = r.URL.Query().Get("org")
 		precision = r.URL.Query().Get("precision")
 
 		b, err := io.ReadAll(r.Body)
@@ -12530,39 +16483,101 @@ stream
 	}
 	testStreamerNoOutput(t, "TestStream_InfluxDBOut", script, 15*time.Second, tmInit)
 
-	if database != "db" {
-		t.Errorf("got %v exp %v", database, "db")
+	if got, exp := path, "/api/v2/write"; got != exp {
+		t.Errorf("unexpected path got %s exp %s", got, exp)
 	}
-	if rp != "rp" {
-		t.Errorf("got %v exp %v", rp, "rp")
+	if got, exp := bucket, "mybucket"; got != exp {
+		t.Errorf("unexpected bucket got %s exp %s", got, exp)
 	}
-	if precision != "s" {
-		t.Errorf("got %v exp %v", precision, "s")
+	if got, exp := org, "myorg"; got != exp {
+		t.Errorf("unexpected org got %s exp %s", got, exp)
+	}
+	if got, exp := precision, "s"; got != exp {
+		t.Errorf("unexpected precision got %s exp %s", got, exp)
 	}
 	if len(points) != 1 {
-		t.Errorf("got %v exp %v", len(points), 1)
-	} else {
-		p := points[0]
-		if string(p.Name()) != "m" {
-			t.Errorf("got %v exp %v", p.Name(), "m")
-		}
-		if fields, err := p.Fields(); fields["count"] != int64(10) {
-			t.Errorf("got %v exp %v", fields["count"], 10.0)
-		} else if err != nil {
-			t.Errorf("expected no error on fields but got %v", err)
+		t.Fatalf("got %v exp %v", len(points), 1)
+	}
+	if got, exp := string(points[0].Name()), "m"; got != exp {
+		t.Errorf("unexpected measurement got %s exp %s", got, exp)
+	}
+	tm := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
+	if !tm.Equal(points[0].Time()) {
+		t.Errorf("times are not equal exp %s got %s", tm, points[0].Time())
+	}
+}
+
+func TestStream_InfluxDBOut_Retry(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|influxDBOut()
+		.database('db')
+		.retentionPolicy('rp')
+		.measurement('m')
+		.precision('s')
+		.flushInterval(1ms)
+		.retryTimeout(10s)
+`
+	var mu sync.Mutex
+	var attempts int
+	var points []imodels.Point
+
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
 		}
-		if len(p.Tags()) != 1 {
-			t.Errorf("got %v exp %v", len(p.Tags()), 1)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		// Fail the first write so that it is retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
-		if got, exp := p.Tags().GetString("key"), "value"; got != exp {
-			t.Errorf("got %s exp %s", got, exp)
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
 		}
-		tm := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
-		if !tm.Equal(p.Time()) {
-			t.Errorf("times are not equal exp %s got %s", tm, p.Time())
+		points, err = imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), r.URL.Query().Get("precision"))
+		if err != nil {
+			t.Error(err)
 		}
+	}))
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+	}
+	testStreamerNoOutput(t, "TestStream_InfluxDBOut", script, 15*time.Second, tmInit)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 {
+		t.Errorf("unexpected number of write attempts got %d exp 2", attempts)
+	}
+	if len(points) != 1 {
+		t.Fatalf("got %v exp %v", len(points), 1)
+	}
+	if fields, err := points[0].Fields(); err != nil {
+		t.Errorf("expected no error on fields but got %v", err)
+	} else if fields["count"] != int64(10) {
+		t.Errorf("got %v exp %v", fields["count"], 10)
 	}
 }
+
 func TestStream_InfluxDBOut_CreateDatabase(t *testing.T) {
 
 	var script = `
@@ -12978,6 +16993,7 @@ stream
 			"avg_exec_time_ns":    int64(0),
 			"errors":              int64(0),
 			"collected":           int64(90),
+			"buffered_points":     int64(0),
 		},
 	}
 
@@ -13059,6 +17075,7 @@ stream
 			"avg_exec_time_ns":    int64(0),
 			"errors":              int64(0),
 			"collected":           int64(90),
+			"buffered_points":     int64(0),
 		},
 		"max3": map[string]interface{}{
 			"emitted":             int64(0),
@@ -13100,11 +17117,14 @@ stream
 			"emitted":             int64(90),
 		},
 		"eval2": map[string]interface{}{
-			"emitted":             int64(0),
-			"working_cardinality": int64(9),
-			"avg_exec_time_ns":    int64(0),
-			"errors":              int64(0),
-			"collected":           int64(90),
+			"emitted":               int64(0),
+			"working_cardinality":   int64(9),
+			"avg_exec_time_ns":      int64(0),
+			"errors":                int64(0),
+			"collected":             int64(90),
+			"nan_results":           int64(0),
+			"dynamic_names":         int64(0),
+			"dynamic_names_dropped": int64(0),
 		},
 	}
 
@@ -13184,6 +17204,7 @@ stream
 			"avg_exec_time_ns":    int64(0),
 			"errors":              int64(0),
 			"collected":           int64(90),
+			"buffered_points":     int64(0),
 		},
 		"groupby3": map[string]interface{}{
 			"emitted":             int64(0),
@@ -13241,6 +17262,55 @@ stream
 	testStreamerCardinality(t, "TestStream_Cardinality", script, es, nil)
 }
 
+func TestStream_AlertCustomLevelStats(t *testing.T) {
+	if err := alert.SetCustomLevels([]string{"FATAL"}); err != nil {
+		t.Fatal(err)
+	}
+	defer alert.SetCustomLevels(nil)
+
+	var script = `
+stream
+    |from()
+        .measurement('cpu')
+        .groupBy('host','cpu')
+    |alert()
+        .level('FATAL', lambda: TRUE)
+`
+
+	// The alerts of the custom levels are counted as critical alerts.
+	es := map[string]map[string]interface{}{
+		"stream0": map[string]interface{}{
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(0),
+			"working_cardinality": int64(0),
+			"collected":           int64(90),
+			"emitted":             int64(90),
+		},
+		"from1": map[string]interface{}{
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(0),
+			"working_cardinality": int64(0),
+			"collected":           int64(90),
+			"emitted":             int64(90),
+		},
+		"alert2": map[string]interface{}{
+			"emitted":             int64(0),
+			"working_cardinality": int64(9),
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(0),
+			"collected":           int64(90),
+			"warns_triggered":     int64(0),
+			"crits_triggered":     int64(90),
+			"alerts_triggered":    int64(90),
+			"alerts_inhibited":    int64(0),
+			"oks_triggered":       int64(0),
+			"infos_triggered":     int64(0),
+		},
+	}
+
+	testStreamerCardinality(t, "TestStream_Cardinality", script, es, nil)
+}
+
 func TestStream_HTTPOutCardinality(t *testing.T) {
 
 	var script = `
@@ -13480,11 +17550,14 @@ stream
 			"emitted":             int64(90),
 		},
 		"eval3": map[string]interface{}{
-			"avg_exec_time_ns":    int64(0),
-			"errors":              int64(0),
-			"working_cardinality": int64(9),
-			"collected":           int64(90),
-			"emitted":             int64(90),
+			"avg_exec_time_ns":      int64(0),
+			"errors":                int64(0),
+			"working_cardinality":   int64(9),
+			"collected":             int64(90),
+			"emitted":               int64(90),
+			"nan_results":           int64(0),
+			"dynamic_names":         int64(0),
+			"dynamic_names_dropped": int64(0),
 		},
 		"where4": map[string]interface{}{
 			"avg_exec_time_ns":    int64(0),
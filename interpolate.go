@@ -0,0 +1,152 @@
+package kapacitor
+
+import (
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsInterpolateValuesFilled = "values_filled"
+)
+
+type InterpolateNode struct {
+	node
+	i *pipeline.InterpolateNode
+
+	batchBuffer *edge.BatchBuffer
+
+	valuesFilled *expvar.Int
+}
+
+// Create a new InterpolateNode which fills the missing values of fields of batches.
+func newInterpolateNode(et *ExecutingTask, n *pipeline.InterpolateNode, d NodeDiagnostic) (*InterpolateNode, error) {
+	in := &InterpolateNode{
+		node:         node{Node: n, et: et, diag: d},
+		i:            n,
+		batchBuffer:  new(edge.BatchBuffer),
+		valuesFilled: new(expvar.Int),
+	}
+	in.node.runF = in.runInterpolate
+	return in, nil
+}
+
+func (n *InterpolateNode) runInterpolate([]byte) error {
+	n.statMap.Set(statsInterpolateValuesFilled, n.valuesFilled)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *InterpolateNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, n.batchBuffer.BeginBatch(begin)
+}
+
+func (n *InterpolateNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return nil, n.batchBuffer.BatchPoint(bp)
+}
+
+func (n *InterpolateNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return n.BufferedBatch(n.batchBuffer.BufferedBatchMessage(end))
+}
+
+func (n *InterpolateNode) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
+	points := batch.Points()
+	// The fields of the points with filled values, copied when the first value of the point is filled.
+	fields := make([]models.Fields, len(points))
+	filled := 0
+	for _, field := range n.i.Fields {
+		filled += n.fill(field, points, fields)
+	}
+	if filled == 0 {
+		return batch, nil
+	}
+	n.valuesFilled.Add(int64(filled))
+
+	filledPoints := make([]edge.BatchPointMessage, len(points))
+	for i, bp := range points {
+		if fields[i] != nil {
+			bp = bp.ShallowCopy()
+			bp.SetFields(fields[i])
+		}
+		filledPoints[i] = bp
+	}
+	batch = batch.ShallowCopy()
+	batch.SetPoints(filledPoints)
+	return batch, nil
+}
+
+// fill fills the missing values of the field in the copies of the fields of the points,
+// and returns the number of values filled.
+func (n *InterpolateNode) fill(field string, points []edge.BatchPointMessage, fields []models.Fields) int {
+	// Indexes of the points with a numeric value, in batch order.
+	var known []int
+	for i, bp := range points {
+		if _, ok := numToFloat(bp.Fields()[field]); ok {
+			known = append(known, i)
+		}
+	}
+	if len(known) == 0 {
+		return 0
+	}
+
+	set := func(i int, v interface{}) {
+		if fields[i] == nil {
+			fields[i] = points[i].Fields().Copy()
+		}
+		fields[i][field] = v
+	}
+	nearest := n.i.Edge == pipeline.InterpolateEdgeNearest
+	filled := 0
+	// k is the index in known of the next point with a value.
+	k := 0
+	for i, bp := range points {
+		if k < len(known) && known[k] == i {
+			k++
+			continue
+		}
+		if v, ok := bp.Fields()[field]; ok && v != nil {
+			// Values of other types are left as is.
+			continue
+		}
+		switch {
+		case k > 0 && k < len(known):
+			prev, next := points[known[k-1]], points[known[k]]
+			v0, _ := numToFloat(prev.Fields()[field])
+			v1, _ := numToFloat(next.Fields()[field])
+			frac := 0.0
+			if d := next.Time().Sub(prev.Time()); d != 0 {
+				frac = float64(bp.Time().Sub(prev.Time())) / float64(d)
+			}
+			set(i, v0+(v1-v0)*frac)
+		case !nearest:
+			continue
+		case k == 0:
+			set(i, points[known[0]].Fields()[field])
+		default:
+			set(i, points[known[len(known)-1]].Fields()[field])
+		}
+		filled++
+	}
+	return filled
+}
+
+func (n *InterpolateNode) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (n *InterpolateNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (n *InterpolateNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (n *InterpolateNode) Done() {}
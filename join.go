@@ -2,6 +2,7 @@ package kapacitor
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,6 +33,17 @@ type JoinNode struct {
 
 	reported    map[int]bool
 	allReported bool
+
+	// Explicit field names indexed by parent
+	renames       []map[string]string
+	renameTargets map[string]bool
+
+	// Field names of each parent used to find colliding fields,
+	// either declared or seen so far, indexed by parent.
+	fieldSets []map[string]bool
+	declared  []bool
+	// Number of parents providing each field name, renamed fields count for their new name.
+	fieldCounts map[string]int
 }
 
 // Create a new JoinNode, which takes pairs from parent streams combines them into a single point.
@@ -62,6 +74,42 @@ func newJoinNode(et *ExecutingTask, n *pipeline.JoinNode, d NodeDiagnostic) (*Jo
 	default:
 		jn.fill = influxql.NoFill
 	}
+	jn.renames = make([]map[string]string, len(n.Names))
+	jn.renameTargets = make(map[string]bool, len(n.Renames))
+	for _, r := range n.Renames {
+		for i, name := range n.Names {
+			if name != r.As {
+				continue
+			}
+			if jn.renames[i] == nil {
+				jn.renames[i] = make(map[string]string)
+			}
+			jn.renames[i][r.Field] = r.Name
+		}
+		jn.renameTargets[r.Name] = true
+	}
+	if n.PrefixCollisionsOnlyFlag {
+		jn.fieldSets = make([]map[string]bool, len(n.Names))
+		jn.declared = make([]bool, len(n.Names))
+		jn.fieldCounts = make(map[string]int)
+		for name := range jn.renameTargets {
+			jn.fieldCounts[name]++
+		}
+		for i := range jn.fieldSets {
+			jn.fieldSets[i] = make(map[string]bool)
+		}
+		for _, fs := range n.FieldSets {
+			for i, name := range n.Names {
+				if name != fs.As {
+					continue
+				}
+				jn.declared[i] = true
+				for _, f := range fs.Fields {
+					jn.addField(i, f)
+				}
+			}
+		}
+	}
 	jn.node.runF = jn.runJoin
 	return jn, nil
 }
@@ -510,7 +558,10 @@ func (g *joinGroup) emitJoinedSet(set *joinset) error {
 			return errors.Wrap(err, "failed to join into point")
 		}
 		if p != nil {
-			if err := edge.Forward(g.n.outs, p); err != nil {
+			g.n.timer.Pause()
+			err := edge.Forward(g.n.outs, p)
+			g.n.timer.Resume()
+			if err != nil {
 				return err
 			}
 		}
@@ -520,7 +571,10 @@ func (g *joinGroup) emitJoinedSet(set *joinset) error {
 			return errors.Wrap(err, "failed to join into batch")
 		}
 		if b != nil {
-			if err := edge.Forward(g.n.outs, b); err != nil {
+			g.n.timer.Pause()
+			err := edge.Forward(g.n.outs, b)
+			g.n.timer.Resume()
+			if err != nil {
 				return err
 			}
 		}
@@ -611,19 +665,20 @@ func (js *joinset) JoinIntoPoint() (edge.PointMessage, error) {
 	if !ok {
 		return nil, fmt.Errorf("unexpected type of first value %T", js.First())
 	}
-	firstFields := first.Fields()
-	fields := make(models.Fields, js.size*len(firstFields))
+	var fillNames []string
+	if js.size < js.expected {
+		for k := range first.Fields() {
+			fillNames = append(fillNames, k)
+		}
+	}
+	parents := make([]models.Fields, len(js.values))
 	for i, v := range js.values {
 		if v == nil {
 			switch js.fill {
 			case influxql.NullFill:
-				for k := range firstFields {
-					fields[js.prefixes[i]+js.delimiter+k] = nil
-				}
+				parents[i] = fillFields(js.j.parentFields(i, fillNames), nil)
 			case influxql.NumberFill:
-				for k := range firstFields {
-					fields[js.prefixes[i]+js.delimiter+k] = js.fillValue
-				}
+				parents[i] = fillFields(js.j.parentFields(i, fillNames), js.fillValue)
 			default:
 				// inner join no valid point possible
 				return nil, nil
@@ -633,11 +688,11 @@ func (js *joinset) JoinIntoPoint() (edge.PointMessage, error) {
 			if !ok {
 				return nil, fmt.Errorf("unexpected type %T", v)
 			}
-			for k, v := range p.Fields() {
-				fields[js.prefixes[i]+js.delimiter+k] = v
-			}
+			parents[i] = p.Fields()
+			js.j.observeFields(i, parents[i])
 		}
 	}
+	fields := js.mergeFields(parents)
 	np := edge.NewPointMessage(
 		js.name, "", "",
 		first.Dimensions(),
@@ -724,30 +779,25 @@ BATCH_POINT:
 			continue
 		}
 		// Join all batch points in set
-		fields := make(models.Fields, js.expected*len(fieldNames))
+		parents := make([]models.Fields, len(set))
 		for i, bp := range set {
 			if bp == nil {
 				switch js.fill {
 				case influxql.NullFill:
-					for _, k := range fieldNames {
-						fields[js.prefixes[i]+js.delimiter+k] = nil
-					}
+					parents[i] = fillFields(js.j.parentFields(i, fieldNames), nil)
 				case influxql.NumberFill:
-					for _, k := range fieldNames {
-						fields[js.prefixes[i]+js.delimiter+k] = js.fillValue
-					}
+					parents[i] = fillFields(js.j.parentFields(i, fieldNames), js.fillValue)
 				default:
 					// inner join no valid point possible
 					continue BATCH_POINT
 				}
 			} else {
-				for k, v := range bp.Fields() {
-					fields[js.prefixes[i]+js.delimiter+k] = v
-				}
+				parents[i] = bp.Fields()
+				js.j.observeFields(i, parents[i])
 			}
 		}
 		bp := edge.NewBatchPointMessage(
-			fields,
+			js.mergeFields(parents),
 			newBegin.Tags(),
 			setTime,
 		)
@@ -760,3 +810,88 @@ BATCH_POINT:
 		edge.NewEndBatchMessage(),
 	), nil
 }
+
+func fillFields(names []string, value interface{}) models.Fields {
+	fields := make(models.Fields, len(names))
+	for _, k := range names {
+		fields[k] = value
+	}
+	return fields
+}
+
+// observeFields adds the fields seen from parent i to its field set, unless its fields are declared.
+func (n *JoinNode) observeFields(i int, fields models.Fields) {
+	if n.fieldSets == nil || n.declared[i] {
+		return
+	}
+	for k := range fields {
+		n.addField(i, k)
+	}
+}
+
+func (n *JoinNode) addField(i int, k string) {
+	if n.fieldSets[i][k] {
+		return
+	}
+	n.fieldSets[i][k] = true
+	if _, ok := n.renames[i][k]; !ok {
+		n.fieldCounts[k]++
+	}
+}
+
+// parentFields returns the names of the fields to fill for the missing parent i.
+// The fields of the parent are used when known, otherwise the fields of another parent in the set.
+func (n *JoinNode) parentFields(i int, names []string) []string {
+	if n.fieldSets == nil || len(n.fieldSets[i]) == 0 {
+		return names
+	}
+	fields := make([]string, 0, len(n.fieldSets[i]))
+	for k := range n.fieldSets[i] {
+		fields = append(fields, k)
+	}
+	return fields
+}
+
+// mergeFields combines the fields of each parent into a single set of fields,
+// prefixing or renaming the field names as configured.
+func (js *joinset) mergeFields(parents []models.Fields) models.Fields {
+	size := 0
+	for _, f := range parents {
+		size += len(f)
+	}
+	fields := make(models.Fields, size)
+	for i, f := range parents {
+		for k, v := range f {
+			fields[js.fieldName(i, k)] = v
+		}
+	}
+	return fields
+}
+
+// fieldName returns the joined name of the field k from parent i.
+func (js *joinset) fieldName(i int, k string) string {
+	if name, ok := js.j.renames[i][k]; ok {
+		return name
+	}
+	if js.j.fieldSets != nil && js.j.fieldSets[i][k] && js.j.fieldCounts[k] == 1 && !js.isAffixed(k) {
+		return k
+	}
+	if js.j.j.SuffixFlag {
+		return k + js.delimiter + js.prefixes[i]
+	}
+	return js.prefixes[i] + js.delimiter + k
+}
+
+// isAffixed reports whether k could be the prefixed or suffixed name of a field of any parent.
+func (js *joinset) isAffixed(k string) bool {
+	for _, p := range js.prefixes {
+		if js.j.j.SuffixFlag {
+			if strings.HasSuffix(k, js.delimiter+p) {
+				return true
+			}
+		} else if strings.HasPrefix(k, p+js.delimiter) {
+			return true
+		}
+	}
+	return false
+}
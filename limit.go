@@ -0,0 +1,121 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsLimitPointsDropped = "points_dropped"
+)
+
+type LimitNode struct {
+	node
+	l *pipeline.LimitNode
+
+	pointsDropped *expvar.Int
+}
+
+// Create a new LimitNode which limits the rate of the points or batches of each group.
+func newLimitNode(et *ExecutingTask, n *pipeline.LimitNode, d NodeDiagnostic) (*LimitNode, error) {
+	ln := &LimitNode{
+		node:          node{Node: n, et: et, diag: d},
+		l:             n,
+		pointsDropped: new(expvar.Int),
+	}
+	ln.node.runF = ln.runLimit
+	return ln, nil
+}
+
+func (n *LimitNode) runLimit([]byte) error {
+	n.statMap.Set(statsLimitPointsDropped, n.pointsDropped)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *LimitNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &limitBucket{
+			n:      n,
+			tokens: float64(n.l.Burst),
+		}),
+	), nil
+}
+
+// limitBucket is the token bucket of a group.
+type limitBucket struct {
+	n *LimitNode
+
+	tokens float64
+	// Time of the latest point or batch, the bucket refills from it.
+	last time.Time
+
+	// Whether the points of the current batch are dropped.
+	dropping bool
+}
+
+// take refills the bucket up to time t and takes a token, it reports whether there was a token to take.
+func (b *limitBucket) take(t time.Time) bool {
+	if !b.last.IsZero() && t.After(b.last) {
+		b.tokens += float64(t.Sub(b.last)) * float64(b.n.l.Count) / float64(b.n.l.Interval)
+		if burst := float64(b.n.l.Burst); b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	if t.After(b.last) {
+		b.last = t
+	}
+	if b.tokens < 1 {
+		b.n.pointsDropped.Add(1)
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *limitBucket) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	b.dropping = !b.take(begin.Time())
+	if b.dropping {
+		return nil, nil
+	}
+	return begin, nil
+}
+
+func (b *limitBucket) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if b.dropping {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (b *limitBucket) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if b.dropping {
+		return nil, nil
+	}
+	return end, nil
+}
+
+func (b *limitBucket) Point(p edge.PointMessage) (edge.Message, error) {
+	if !b.take(p.Time()) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (b *limitBucket) Barrier(barrier edge.BarrierMessage) (edge.Message, error) {
+	return barrier, nil
+}
+
+func (b *limitBucket) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (b *limitBucket) Done() {}
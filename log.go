@@ -12,10 +12,11 @@ import (
 type LogNode struct {
 	node
 
-	level  string
-	prefix string
-	buf    bytes.Buffer
-	enc    *json.Encoder
+	level         string
+	prefix        string
+	fieldsAndTags bool
+	buf           bytes.Buffer
+	enc           *json.Encoder
 
 	batchBuffer *edge.BatchBuffer
 }
@@ -23,12 +24,16 @@ type LogNode struct {
 // Create a new  LogNode which logs all data it receives
 func newLogNode(et *ExecutingTask, n *pipeline.LogNode, d NodeDiagnostic) (*LogNode, error) {
 	nn := &LogNode{
-		node:        node{Node: n, et: et, diag: d},
-		level:       strings.ToUpper(n.Level),
-		prefix:      n.Prefix,
-		batchBuffer: new(edge.BatchBuffer),
+		node:          node{Node: n, et: et, diag: d},
+		level:         strings.ToUpper(n.Level),
+		prefix:        n.Prefix,
+		fieldsAndTags: n.FieldsAndTagsFlag,
+		batchBuffer:   new(edge.BatchBuffer),
 	}
 	nn.enc = json.NewEncoder(&nn.buf)
+	if n.PrettyFlag {
+		nn.enc.SetIndent("", "  ")
+	}
 	nn.node.runF = nn.runLog
 	return nn, nil
 }
@@ -58,15 +63,32 @@ func (n *LogNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
 }
 
 func (n *LogNode) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
-	n.diag.LogBatchData(n.level, n.prefix, batch)
+	if n.fieldsAndTags {
+		n.logJSON(edge.NewBatchJSON(batch))
+	} else {
+		n.diag.LogBatchData(n.level, n.prefix, batch)
+	}
 	return batch, nil
 }
 
 func (n *LogNode) Point(p edge.PointMessage) (edge.Message, error) {
-	n.diag.LogPointData(n.level, n.prefix, p)
+	if n.fieldsAndTags {
+		n.logJSON(edge.NewPointJSON(p))
+	} else {
+		n.diag.LogPointData(n.level, n.prefix, p)
+	}
 	return p, nil
 }
 
+func (n *LogNode) logJSON(v interface{}) {
+	n.buf.Reset()
+	if err := n.enc.Encode(v); err != nil {
+		n.diag.Error("failed to encode data as JSON", err)
+		return
+	}
+	n.diag.LogJSONData(n.level, n.prefix, bytes.TrimSuffix(n.buf.Bytes(), []byte("\n")))
+}
+
 func (n *LogNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 	return b, nil
 }
@@ -29,6 +29,9 @@ const (
 type NodeDiagnostic interface {
 	Error(msg string, err error, ctx ...keyvalue.T)
 
+	// SetLogLevel overrides the log level of the node, an empty level uses the level of the task.
+	SetLogLevel(level string) error
+
 	// AlertNode
 	AlertTriggered(level alert.Level, id string, message string, rows *models.Row)
 
@@ -37,10 +40,12 @@ type NodeDiagnostic interface {
 
 	// QueryNode
 	StartingBatchQuery(q string)
+	CanceledBatchQuery(q string)
 
 	// LogNode
 	LogPointData(key, prefix string, data edge.PointMessage)
 	LogBatchData(key, prefix string, data edge.BufferedBatchMessage)
+	LogJSONData(key, prefix string, data []byte)
 
 	//UDF
 	UDFLog(s string)
@@ -156,6 +161,10 @@ func (n *node) init(quiet bool) {
 	n.diag = newNodeDiagnostic(n, n.diag)
 	n.statMap.Set(statCardinalityGauge, kexpvar.NewIntFuncGauge(nil))
 	n.timer = n.et.tm.TimingService.NewTimer(avgExecVar)
+	// UDFs process the data in their own process, their nodes only wait for them.
+	if _, udf := n.Node.(*pipeline.UDFNode); n.et.pool != nil && !udf {
+		n.timer = n.et.pool.timer(n.timer)
+	}
 	n.errCh = make(chan error, 1)
 	n.quiet = quiet
 }
@@ -348,6 +357,15 @@ func (n *node) incrementErrorCount() {
 	n.nodeErrors.Add(1)
 }
 
+// AllowNewGroup implements edge.GroupLimiter,
+// new groups are refused while the task exceeds its cardinality quota.
+func (n *node) AllowNewGroup(points int) bool {
+	if n.et == nil || n.et.quota == nil {
+		return true
+	}
+	return n.et.quota.allowNewGroup(int64(points))
+}
+
 func (n *node) stats() map[string]interface{} {
 	stats := make(map[string]interface{})
 
@@ -0,0 +1,172 @@
+package kapacitor
+
+import (
+	"math"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsOutliers = "outliers"
+)
+
+type OutliersNode struct {
+	node
+	o *pipeline.OutliersNode
+
+	threshold float64
+
+	batchBuffer *edge.BatchBuffer
+
+	outliers *expvar.Int
+}
+
+// Create a new OutliersNode which tags or drops the outliers of batches.
+func newOutliersNode(et *ExecutingTask, n *pipeline.OutliersNode, d NodeDiagnostic) (*OutliersNode, error) {
+	threshold := n.Threshold
+	if threshold == 0 {
+		switch n.Method {
+		case pipeline.OutliersIQR:
+			threshold = pipeline.DefaultOutliersIQRThreshold
+		default:
+			threshold = pipeline.DefaultOutliersZScoreThreshold
+		}
+	}
+	on := &OutliersNode{
+		node:        node{Node: n, et: et, diag: d},
+		o:           n,
+		threshold:   threshold,
+		batchBuffer: new(edge.BatchBuffer),
+		outliers:    new(expvar.Int),
+	}
+	on.node.runF = on.runOutliers
+	return on, nil
+}
+
+func (n *OutliersNode) runOutliers([]byte) error {
+	n.statMap.Set(statsOutliers, n.outliers)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *OutliersNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, n.batchBuffer.BeginBatch(begin)
+}
+
+func (n *OutliersNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return nil, n.batchBuffer.BatchPoint(bp)
+}
+
+func (n *OutliersNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return n.BufferedBatch(n.batchBuffer.BufferedBatchMessage(end))
+}
+
+func (n *OutliersNode) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
+	points := batch.Points()
+	outliers := n.find(points)
+	count := 0
+	for _, outlier := range outliers {
+		if outlier {
+			count++
+		}
+	}
+	n.outliers.Add(int64(count))
+	if n.o.DropFlag && count == 0 {
+		return batch, nil
+	}
+
+	marked := make([]edge.BatchPointMessage, 0, len(points))
+	for i, bp := range points {
+		if n.o.DropFlag {
+			if !outliers[i] {
+				marked = append(marked, bp)
+			}
+			continue
+		}
+		tags := bp.Tags().Copy()
+		if outliers[i] {
+			tags[n.o.Tag] = "true"
+		} else {
+			tags[n.o.Tag] = "false"
+		}
+		bp = bp.ShallowCopy()
+		bp.SetTags(tags)
+		marked = append(marked, bp)
+	}
+	batch = batch.ShallowCopy()
+	batch.SetPoints(marked)
+	return batch, nil
+}
+
+// find returns whether each point is an outlier of the points.
+func (n *OutliersNode) find(points []edge.BatchPointMessage) []bool {
+	outliers := make([]bool, len(points))
+	values := make([]float64, 0, len(points))
+	for _, bp := range points {
+		if v, ok := numToFloat(bp.Fields()[n.o.Field]); ok && !math.IsNaN(v) {
+			values = append(values, v)
+		}
+	}
+	if int64(len(values)) < n.o.MinSamples {
+		return outliers
+	}
+
+	lower, upper := n.bounds(values)
+	for i, bp := range points {
+		if v, ok := numToFloat(bp.Fields()[n.o.Field]); ok && (v < lower || v > upper) {
+			outliers[i] = true
+		}
+	}
+	return outliers
+}
+
+// bounds returns the range of the values which are not outliers.
+func (n *OutliersNode) bounds(values []float64) (lower, upper float64) {
+	if n.o.Method == pipeline.OutliersIQR {
+		sort.Float64s(values)
+		q1, q3 := quantile(values, 0.25), quantile(values, 0.75)
+		iqr := q3 - q1
+		return q1 - n.threshold*iqr, q3 + n.threshold*iqr
+	}
+	var mean, m2 float64
+	for i, v := range values {
+		delta := v - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (v - mean)
+	}
+	stddev := math.Sqrt(m2 / float64(len(values)-1))
+	return mean - n.threshold*stddev, mean + n.threshold*stddev
+}
+
+// quantile returns the quantile of the sorted values, linearly interpolated between the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	rank := q * float64(len(sorted)-1)
+	i := int(rank)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (sorted[i+1]-sorted[i])*(rank-float64(i))
+}
+
+func (n *OutliersNode) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (n *OutliersNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (n *OutliersNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (n *OutliersNode) Done() {}
@@ -30,7 +30,7 @@ type AlertNode struct{ *AlertNodeData }
 // An AlertNode can trigger an event of varying severity levels,
 // and pass the event to alert handlers. The criteria for triggering
 // an alert is specified via a [lambda expression](/kapacitor/latest/tick/expr/).
-// See AlertNode.Info, AlertNode.Warn, and AlertNode.Crit below.
+// See AlertNode.Info, AlertNode.Warn, and AlertNode.Crit below, and AlertNode.Level for the custom levels.
 //
 // Different event handlers can be configured for each AlertNode.
 // Some handlers like Email, HipChat, Sensu, Slack, OpsGenie, VictorOps, PagerDuty, Telegram and Talk have a configuration
@@ -58,6 +58,7 @@ type AlertNode struct{ *AlertNodeData }
 //   - Teams -- Post alert message to Microsoft Teams.
 //   - Discord -- Post alert message to Discord webhook.
 //   - ServiceNow -- Post alert message to ServiceNow.
+//   - Zabbix -- Send alert level to a Zabbix trapper item.
 //
 // See below for more details on configuring each handler.
 //
@@ -75,6 +76,8 @@ type AlertNode struct{ *AlertNodeData }
 // or the alert just changed to the 'OK' state from a non 'OK' state (a.k.a. the alert recovered).
 // Using the AlertNode.StateChangesOnly property events will only be sent to handlers
 // if the alert changed state.
+// Using the AlertNode.Trigger property events will only be sent to handlers
+// when the alert enters a level, without a recovery event.
 //
 // It is valid to configure multiple alert handlers, even with the same type.
 //
@@ -130,7 +133,7 @@ type AlertNode struct{ *AlertNodeData }
 //   - oks_triggered -- Number of OK alerts triggered
 //   - infos_triggered -- Number of Info alerts triggered
 //   - warns_triggered -- Number of Warn alerts triggered
-//   - crits_triggered -- Number of Crit alerts triggered
+//   - crits_triggered -- Number of Crit alerts triggered, including the alerts of the custom levels
 type AlertNodeData struct {
 	chainnode
 
@@ -203,6 +206,15 @@ type AlertNodeData struct {
 	//    * Time -- The time of the point that triggered the event.
 	//    * Duration -- The duration of the alert.
 	//
+	// Available template functions, which render nil or missing values as an empty string:
+	//
+	//    * humanizeBytes -- Format a number of bytes using SI units, i.e. 1.5GB.
+	//    * humanizeNumber -- Format a number using SI suffixes, i.e. 1.5k.
+	//    * humanizeDuration -- Format a duration or a number of seconds, i.e. 1d 2h 3m 4s.
+	//    * formatTime -- Format a time using a Go time layout, i.e. '{{ .Time | formatTime "2006-01-02 15:04" }}'.
+	//
+	// These functions are also available in the AlertNode.Id and AlertNode.Details templates.
+	//
 	// Example:
 	//   stream
 	//       |from()
@@ -214,6 +226,16 @@ type AlertNodeData struct {
 	//
 	// Message: authentication/auth001.example.com is CRITICAL value:42
 	//
+	// Example:
+	//   stream
+	//       |from()
+	//           .measurement('disk')
+	//           .groupBy('host')
+	//       |alert()
+	//           .message('{{ .ID }} used {{ index .Fields "used" | humanizeBytes }} of {{ index .Fields "total" | humanizeBytes }} for {{ humanizeDuration .Duration }}')
+	//
+	// Message: disk:host=server01 used 1.5GB of 2GB for 1h 30m
+	//
 	// Default: {{ .ID }} is {{ .Level }}
 	Message string `json:"message"`
 
@@ -244,6 +266,21 @@ type AlertNodeData struct {
 	// Default: {{ json . }}
 	Details string `json:"details"`
 
+	// Template for the URL of the runbook of the alert.
+	// The same template data is available as the AlertNode.Message property.
+	//
+	// Handlers with native link support render the runbook as a link,
+	// i.e. the Slack message gets a Runbook button, PagerDuty2 incidents get a Runbook link
+	// and OpsGenie2 alerts get a Runbook detail.
+	// Other handlers append the runbook to the message.
+	// The runbook is also part of the alert data sent by the post, tcp, log and exec handlers.
+	//
+	// Example:
+	//    |alert()
+	//       .runbook('https://wiki.example.com/runbooks/{{ index .Tags "service" }}')
+	//       .slack()
+	Runbook string `json:"runbook"`
+
 	// Filter expression for the INFO alert level.
 	// An empty value indicates the level is invalid and is skipped.
 	Info *ast.LambdaNode `json:"info"`
@@ -261,6 +298,83 @@ type AlertNodeData struct {
 	// Filter expression for reseting the CRITICAL alert level to lower level.
 	CritReset *ast.LambdaNode `json:"critReset"`
 
+	// Filter expressions for the custom alert levels.
+	// tick:ignore
+	Levels []AlertLevel `tick:"Level" json:"levels"`
+
+	// Duration the INFO expression must be true continuously before the INFO level is entered.
+	// The level is left once lower levels have been true continuously for the same duration.
+	// Zero enters and leaves the level on the first matching point.
+	//
+	// Example:
+	//
+	//	stream
+	//	    |from()
+	//	        .measurement('cpu')
+	//	        .groupBy('host')
+	//	    |alert()
+	//	        .warn(lambda: "usage_idle" < 20)
+	//	        .warnFor(5m)
+	//	        .crit(lambda: "usage_idle" < 10)
+	//	        .critFor(2m)
+	//
+	// The WARNING level is entered once the idle usage of a host has been below 20 for 5m,
+	// and is left once it has been above 20 for 5m.
+	// The condition start times are tracked per group in the time of the data.
+	InfoFor time.Duration `json:"infoFor"`
+	// Duration the WARNING expression must be true continuously before the WARNING level is entered.
+	// See InfoFor.
+	WarnFor time.Duration `json:"warnFor"`
+	// Duration the CRITICAL expression must be true continuously before the CRITICAL level is entered.
+	// See InfoFor.
+	CritFor time.Duration `json:"critFor"`
+
+	// Duration the alert must be OK continuously before it is resolved and the OK event is sent.
+	// Until then the alert stays at its current level, so that a single good point does not
+	// resolve the alert only for it to fire again on the next bad point.
+	// Unlike the level durations, such as warnFor, only leaving the alert state is delayed,
+	// changes between the INFO, WARNING and CRITICAL levels are not.
+	// Zero resolves the alert on the first OK point.
+	//
+	// Example:
+	//
+	//	stream
+	//	    |from()
+	//	        .measurement('cpu')
+	//	        .groupBy('host')
+	//	    |alert()
+	//	        .crit(lambda: "usage_idle" < 10)
+	//	        .resolveDelay(5m)
+	//
+	// The alert of a host is only resolved once its idle usage has been above 10 for 5m.
+	// The time the alert became clear is tracked per group in the time of the data.
+	ResolveDelay time.Duration `json:"resolveDelay"`
+
+	// Source of the time of the alert events, either 'point' or 'wall'.
+	// With 'point' the time of an event is the time of the point or batch triggering it,
+	// so that replaying historical data produces the events at the time they would have occurred.
+	// With 'wall' the time of an event is the wall clock time it is triggered at, i.e. when Kapacitor noticed,
+	// which differs from the point time for delayed data and replays.
+	//
+	// The time source sets the time of the events passed to the handlers, i.e. .Time in the templates,
+	// and the time of the topic state.
+	// The points output by the node always keep their own time, so that the nodes downstream, such as windows
+	// and influxDBOut, see the time of the data.
+	// The levels, their durations and the resolve delay are always tracked in the time of the data.
+	//
+	// Default: 'point', which is the time of the events before the time source was configurable.
+	//
+	// Example:
+	//
+	//	stream
+	//	    |from()
+	//	        .measurement('cpu')
+	//	    |alert()
+	//	        .crit(lambda: "usage_idle" < 10)
+	//	        .timeSource('wall')
+	//	        .message('{{ .ID }} noticed at {{ .Time }}')
+	TimeSource string `json:"timeSource"`
+
 	//tick:ignore
 	UseFlapping bool `tick:"Flapping" json:"useFlapping"`
 	//tick:ignore
@@ -292,6 +406,10 @@ type AlertNodeData struct {
 	// Optional field key to add to the data, containing the alert ID as a string.
 	IdField string `json:"idField"`
 
+	// Ensure each group has a distinct alert ID.
+	// tick:ignore
+	UniqueIdFlag bool `tick:"UniqueId" json:"uniqueId"`
+
 	// Indicates an alert should trigger only if all points in a batch match the criteria
 	// tick:ignore
 	AllFlag bool `tick:"All" json:"all"`
@@ -308,6 +426,50 @@ type AlertNodeData struct {
 	// tick:ignore
 	StateChangesOnlyDuration time.Duration `json:"stateChangesOnlyDuration"`
 
+	// Evaluate the alert once per interval on the latest point or batch of each group,
+	// instead of evaluating every point or batch.
+	// Points and batches received between evaluations replace the retained one,
+	// reducing the number of events sent to handlers on high rate streams.
+	//
+	// The interval is measured in the time of the data, like a window.
+	// The retained point of a group is evaluated once a point, batch or barrier
+	// of the group arrives in a later interval.
+	// Use a barrier node to evaluate groups that have stopped receiving data.
+	//
+	// Example:
+	//
+	//	stream
+	//	    |from()
+	//	        .measurement('requests')
+	//	        .groupBy('host')
+	//	    |alert()
+	//	        .crit(lambda: "latency" > 500)
+	//	        .every(1m)
+	//	        .stateChangesOnly()
+	//	        .slack()
+	//
+	// StateChangesOnly, flapping detection and the alert history only consider the evaluated points,
+	// so a level that is entered and left within a single interval never sends an event.
+	// Without StateChangesOnly, an event is sent at most once per interval for each group.
+	Every time.Duration `json:"every"`
+
+	// Number of the most recent points of the group to attach to alert events.
+	// Zero does not attach any points.
+	// tick:ignore
+	AttachRecentCount int64 `tick:"AttachRecent" json:"attachRecent"`
+
+	// Send alerts only once when entering a level and never send the recovery.
+	// tick:ignore
+	IsTrigger bool `tick:"Trigger" json:"trigger"`
+
+	// Levels to which the trigger mode applies, all levels if empty.
+	// tick:ignore
+	TriggerLevels []string `json:"triggerLevels"`
+
+	// Tags and fields from which the fingerprint of the alert is computed, the alert ID if empty.
+	// tick:ignore
+	FingerprintKeys []string `tick:"Fingerprint" json:"fingerprint"`
+
 	// Inhibitors
 	// tick:ignore
 	Inhibitors []Inhibitor `tick:"Inhibit" json:"inhibitors"`
@@ -332,6 +494,10 @@ type AlertNodeData struct {
 	// tick:ignore
 	LogHandlers []*LogHandler `tick:"Log" json:"log"`
 
+	// Append alert events to a file as JSON or CSV lines.
+	// tick:ignore
+	FileHandlers []*FileHandler `tick:"File" json:"file"`
+
 	// Send alert to VictorOps.
 	// tick:ignore
 	VictorOpsHandlers []*VictorOpsHandler `tick:"VictorOps" json:"victorOps"`
@@ -411,6 +577,10 @@ type AlertNodeData struct {
 	// Send alert to Zenoss.
 	// tick:ignore
 	ZenossHandlers []*ZenossHandler `tick:"Zenoss" json:"zenoss"`
+
+	// Send alert to Zabbix.
+	// tick:ignore
+	ZabbixHandlers []*ZabbixHandler `tick:"Zabbix" json:"zabbix"`
 }
 
 func newAlertNode(wants EdgeType) *AlertNode {
@@ -473,6 +643,62 @@ func (n *AlertNodeData) ChainMethods() map[string]reflect.Value {
 }
 
 func (n *AlertNodeData) validate() error {
+	if n.Every < 0 {
+		return fmt.Errorf("every must be >= 0, got %v", n.Every)
+	}
+	if n.AttachRecentCount < 0 || n.AttachRecentCount > MaxAttachRecent {
+		return fmt.Errorf("attachRecent must be between 0 and %d, got %d", MaxAttachRecent, n.AttachRecentCount)
+	}
+	for _, f := range []struct {
+		name  string
+		d     time.Duration
+		level *ast.LambdaNode
+	}{
+		{"infoFor", n.InfoFor, n.Info},
+		{"warnFor", n.WarnFor, n.Warn},
+		{"critFor", n.CritFor, n.Crit},
+	} {
+		if f.d < 0 {
+			return fmt.Errorf("%s must be >= 0, got %v", f.name, f.d)
+		}
+		if f.d > 0 && f.level == nil {
+			return fmt.Errorf("%s requires the %s expression to be set", f.name, strings.TrimSuffix(f.name, "For"))
+		}
+	}
+	if n.ResolveDelay < 0 {
+		return fmt.Errorf("resolveDelay must be >= 0, got %v", n.ResolveDelay)
+	}
+	switch n.TimeSource {
+	case "", AlertTimeSourcePoint, AlertTimeSourceWall:
+	default:
+		return fmt.Errorf("invalid timeSource %q, must be one of %s or %s", n.TimeSource, AlertTimeSourcePoint, AlertTimeSourceWall)
+	}
+	names := make(map[string]bool, len(n.Levels))
+	for _, l := range n.Levels {
+		name := strings.ToUpper(l.Name)
+		switch name {
+		case "":
+			return errors.New("level name must not be empty")
+		case "OK", "INFO", "WARNING", "CRITICAL":
+			return fmt.Errorf("invalid level %q, use info, warn or crit for the built-in levels", l.Name)
+		}
+		if names[name] {
+			return fmt.Errorf("level %q is set more than once", l.Name)
+		}
+		names[name] = true
+		if l.Lambda == nil {
+			return fmt.Errorf("level %q must have an expression", l.Name)
+		}
+	}
+	for _, l := range n.TriggerLevels {
+		switch name := strings.ToUpper(l); name {
+		case "INFO", "WARNING", "CRITICAL":
+		default:
+			if !names[name] {
+				return fmt.Errorf("invalid trigger level %q, must be one of info, warning, critical or a level of the alert", l)
+			}
+		}
+	}
 	for _, snmp := range n.SNMPTrapHandlers {
 		if err := snmp.validate(); err != nil {
 			return errors.Wrapf(err, "invalid SNMP trap %q", snmp.TrapOid)
@@ -484,9 +710,98 @@ func (n *AlertNodeData) validate() error {
 			return errors.Wrap(err, "invalid post")
 		}
 	}
+
+	for _, pd2 := range n.PagerDuty2Handlers {
+		if pd2.Retries < 0 {
+			return fmt.Errorf("pagerDuty2 retries must be positive, got %d", pd2.Retries)
+		}
+		if pd2.Timeout < 0 {
+			return fmt.Errorf("pagerDuty2 timeout must be positive, got %v", pd2.Timeout)
+		}
+		if pd2.MaxPayloadSize < 0 {
+			return fmt.Errorf("pagerDuty2 maxPayloadSize must be positive, got %d", pd2.MaxPayloadSize)
+		}
+		keys := make(map[string]bool, len(pd2.CustomDetails))
+		for _, d := range pd2.CustomDetails {
+			if d.Key == "" {
+				return errors.New("pagerDuty2 custom detail key must not be empty")
+			}
+			if keys[d.Key] {
+				return fmt.Errorf("pagerDuty2 custom detail %q is set more than once", d.Key)
+			}
+			keys[d.Key] = true
+		}
+	}
+	for _, h := range n.SlackHandlers {
+		if h.MaxPayloadSize < 0 {
+			return fmt.Errorf("slack maxPayloadSize must be positive, got %d", h.MaxPayloadSize)
+		}
+	}
+	for _, h := range n.TeamsHandlers {
+		if h.MaxPayloadSize < 0 {
+			return fmt.Errorf("teams maxPayloadSize must be positive, got %d", h.MaxPayloadSize)
+		}
+	}
+	for _, h := range n.DiscordHandlers {
+		if h.MaxPayloadSize < 0 {
+			return fmt.Errorf("discord maxPayloadSize must be positive, got %d", h.MaxPayloadSize)
+		}
+	}
+	for _, h := range n.PagerDutyHandlers {
+		if h.MaxPayloadSize < 0 {
+			return fmt.Errorf("pagerDuty maxPayloadSize must be positive, got %d", h.MaxPayloadSize)
+		}
+	}
 	return nil
 }
 
+// Sources of the time of the alert events.
+const (
+	// AlertTimeSourcePoint uses the time of the triggering point or batch.
+	AlertTimeSourcePoint = "point"
+	// AlertTimeSourceWall uses the wall clock time the event is triggered at.
+	AlertTimeSourceWall = "wall"
+)
+
+const (
+	// DefaultAttachRecent is the number of recent points attached by attachRecent without a count.
+	DefaultAttachRecent = 10
+	// MaxAttachRecent is the maximum number of recent points that can be attached to an event.
+	MaxAttachRecent = 100
+)
+
+// Attach the most recent points of the triggering group to the alert events,
+// giving responders the data that caused the alert.
+// For stream tasks the last count points of the group are attached,
+// for batch tasks the last count points of the triggering batch, i.e. the window contents.
+// The count defaults to 10 and is capped at 100.
+//
+// The points are available to the message and details templates as `.Recent`,
+// a series with a time column followed by the fields and tags of the points,
+// and are included in the JSON alert data as `recent`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |alert()
+//	        .crit(lambda: "usage_idle" < 10)
+//	        .attachRecent(5)
+//	        .details('''{{ range .Recent.Values }}{{ index . 0 }} {{ index . 1 }}
+//	{{ end }}''')
+//	        .pagerDuty2()
+//
+// tick:property
+func (n *AlertNodeData) AttachRecent(count ...int64) *AlertNodeData {
+	n.AttachRecentCount = DefaultAttachRecent
+	if len(count) > 0 {
+		n.AttachRecentCount = count[0]
+	}
+	return n
+}
+
 // Indicates an alert should trigger only if all points in a batch match the criteria.
 // Does not apply to stream alerts.
 // tick:property
@@ -502,6 +817,31 @@ func (n *AlertNodeData) NoRecoveries() *AlertNodeData {
 	return n
 }
 
+// Ensure each group has a distinct alert ID.
+//
+// If the ID template does not include all of the group by dimensions,
+// different groups can render the same ID and would share the same alert state.
+// With UniqueId, the first group to render an ID keeps it and any other group rendering the same ID
+// has a hash of its group appended to the ID, i.e. "cpu:4f1c2a3b".
+// An error is logged when such a collision is detected.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('service', 'host')
+//	    |alert()
+//	        // Two hosts of the same service would render the same ID.
+//	        .id('kapacitor/{{ index .Tags "service" }}')
+//	        .uniqueId()
+//
+// tick:property
+func (n *AlertNodeData) UniqueId() *AlertNodeData {
+	n.UniqueIdFlag = true
+	return n
+}
+
 // Only sends events where the state changed.
 // Each different alert level OK, INFO, WARNING, and CRITICAL
 // are considered different states.
@@ -554,6 +894,103 @@ func (n *AlertNodeData) StateChangesOnly(maxInterval ...time.Duration) *AlertNod
 	return n
 }
 
+// Only sends a single event when the alert enters a level and never sends the recovery event.
+// Subsequent events at the same level are suppressed, as is the OK event when the alert leaves
+// the level, which is still tracked so that entering the level again sends an event.
+// This is useful for informational alerts, that do not need to be resolved.
+//
+// Unlike StateChangesOnly, only entering the level sends an event, not leaving it.
+// Optionally the levels to which the trigger mode applies can be provided,
+// otherwise the mode applies to all levels.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('deploys')
+//	    |alert()
+//	        .warn(lambda: "value" > 10)
+//	        .crit(lambda: "value" > 20)
+//	        .trigger('warning')
+//	        .slack()
+//
+// The above sends a single event to slack when the alert becomes WARNING and no event when it recovers,
+// while CRITICAL events are sent as usual, including their recovery.
+//
+// tick:property
+func (n *AlertNodeData) Trigger(levels ...string) *AlertNodeData {
+	n.IsTrigger = true
+	n.TriggerLevels = levels
+	return n
+}
+
+// Filter expression for a custom alert level.
+// Custom levels are more severe than CRITICAL,
+// they must be defined in increasing order of severity by the custom-levels of the [alert] configuration.
+// The most severe level with a matching expression is the level of the alert, whatever the order of the expressions.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('disk')
+//	        .groupBy('host', 'path')
+//	    |alert()
+//	        .warn(lambda: "used_percent" > 80)
+//	        .crit(lambda: "used_percent" > 90)
+//	        .level('FATAL', lambda: "used_percent" > 98)
+//	        .pagerDuty2()
+//
+// With custom-levels = ["FATAL"], the alert is FATAL once the disk is more than 98% full.
+// Handlers with a fixed set of severities treat the custom levels as CRITICAL,
+// the level name is available to the templates as `.Level`.
+//
+// tick:property
+func (n *AlertNodeData) Level(name string, expression *ast.LambdaNode) *AlertNodeData {
+	n.Levels = append(n.Levels, AlertLevel{
+		Name:   name,
+		Lambda: expression,
+	})
+	return n
+}
+
+// AlertLevel is the filter expression of a custom alert level.
+type AlertLevel struct {
+	// Name of the custom level.
+	Name string `json:"name"`
+	// Expression of the level.
+	Lambda *ast.LambdaNode `json:"lambda"`
+}
+
+// Compute the fingerprint of the alert events from the given tags and fields.
+// The fingerprint is a stable identifier of the logical alert,
+// for deduplication and correlation of the alerts by external systems such as Alertmanager or Jira.
+// It is available to the handler templates as `.Fingerprint`
+// and is included in the JSON alert data as `fingerprint`.
+//
+// The fingerprint is the first 16 hex characters of the SHA-256 hash of a line of the form key=value
+// for each of the keys in sorted order, using the value of the tag or else the value of the field.
+// Keys that are neither tags nor fields of the alert are skipped.
+// Without keys the fingerprint is the hash of the alert ID.
+// The hash only depends on the keys and values so it is the same across restarts and versions of Kapacitor.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host', 'cpu')
+//	    |alert()
+//	        .crit(lambda: "usage_idle" < 10)
+//	        .fingerprint('host', 'service')
+//	        .post('http://alertmanager-bridge.example.com/alerts')
+//
+// tick:property
+func (n *AlertNodeData) Fingerprint(keys ...string) *AlertNodeData {
+	n.FingerprintKeys = keys
+	return n
+}
+
 // Perform flap detection on the alerts.
 // The method used is similar method to Nagios:
 // https://assets.nagios.com/downloads/nagioscore/docs/nagioscore/3/en/flapping.html
@@ -665,11 +1102,26 @@ type AlertHTTPPostHandler struct {
 	// tick:ignore
 	CaptureResponseFlag bool `tick:"CaptureResponse" json:"captureResponse"`
 
-	// Timeout for HTTP Post
+	// Timeout of each attempt to POST, an attempt that times out counts towards the retries.
+	// The attempts that time out are counted in the timeouts of the alert_endpoints statistic.
+	// Only the post and pagerDuty2 handlers have a delivery timeout and count timeouts,
+	// the slack and discord handlers time out after 30s and the other HTTP handlers do not time out.
+	// Default: 30s
 	Timeout time.Duration `json:"timeout"`
 
 	// tick:ignore
 	SkipSSLVerificationFlag bool `tick:"SkipSSLVerification" json:"skipSSLVerification"`
+
+	// tick:ignore
+	FailoverEndpoints []string `tick:"Failover" json:"failover"`
+
+	// Number of times the POST to an endpoint is retried before failing over to the next endpoint.
+	Retries int64 `json:"retries"`
+
+	// Maximum size in bytes of the body of the POST.
+	// The data of larger alerts is dropped, then their details and message are truncated.
+	// Default: no limit
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
 }
 
 // Set a header key and value on the post request.
@@ -717,12 +1169,41 @@ func (a *AlertHTTPPostHandler) SkipSSLVerification() *AlertHTTPPostHandler {
 	return a
 }
 
+// Failover sets the ordered list of endpoints to POST to when the endpoint or URL fails,
+// for example the endpoints of other regions.
+// Each endpoint is either the name of an endpoint of the configuration file or a URL.
+// The POST to each endpoint is retried .retries() times before failing over to the next endpoint.
+//
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .post()
+//	             .endpoint('us-east')
+//	             .failover('us-west', 'https://eu.example.com/alerts')
+//	             .retries(2)
+//
+// tick:property
+func (a *AlertHTTPPostHandler) Failover(endpoints ...string) *AlertHTTPPostHandler {
+	a.FailoverEndpoints = append(a.FailoverEndpoints, endpoints...)
+	return a
+}
+
 func (a *AlertHTTPPostHandler) validate() error {
 	for k := range a.Headers {
 		if strings.ToUpper(k) == "AUTHENTICATE" {
 			return errors.New("cannot set 'authenticate' header")
 		}
 	}
+	if a.Retries < 0 {
+		return fmt.Errorf("post retries must be positive, got %d", a.Retries)
+	}
+	if a.Timeout < 0 {
+		return fmt.Errorf("post timeout must be positive, got %v", a.Timeout)
+	}
+	if a.MaxPayloadSize < 0 {
+		return fmt.Errorf("post maxPayloadSize must be positive, got %d", a.MaxPayloadSize)
+	}
 	return nil
 }
 
@@ -940,6 +1421,104 @@ type LogHandler struct {
 	Mode int64 `json:"mode"`
 }
 
+// Append alert events to a file, one event per line.
+// The file is created if it does not exist.
+// By default the lines are the alert data in JSON, like the log handler,
+// the format, fields and template properties change the content of the lines.
+//
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .file('/var/log/alerts.csv')
+//	             .format('csv')
+//	             .fields('time', 'level', 'id', 'tags.host', 'fields.value')
+//	             .header()
+//	             .maxSize(10485760)
+//	             .maxBackups(5)
+//
+// Append CSV lines of the time, level, ID, host tag and value field of the events,
+// rotating the file before it exceeds 10MB and keeping the 5 most recent rotated files.
+//
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .file('/var/log/alerts.log')
+//	             .template('{{ .Time }} {{ .Level }} {{ index .Tags "host" }} {{ .Message }}')
+//	             .maxAge(24h)
+//
+// Append lines rendered by the template and rotate the file every day.
+//
+// tick:property
+func (n *AlertNodeData) File(filepath string) *FileHandler {
+	f := &FileHandler{
+		AlertNodeData: n,
+		FilePath:      filepath,
+	}
+	n.FileHandlers = append(n.FileHandlers, f)
+	return f
+}
+
+// tick:embedded:AlertNode.File
+type FileHandler struct {
+	*AlertNodeData `json:"-"`
+
+	// Absolute path of the file.
+	// It will be created if it does not exist.
+	// tick:ignore
+	FilePath string `json:"filePath"`
+
+	// File's mode and permissions, default is 0600
+	// NOTE: The leading 0 is required to interpret the value as an octal integer.
+	Mode int64 `json:"mode"`
+
+	// Format of the lines, either 'json' or 'csv', default is 'json'.
+	Format string `json:"format"`
+
+	// Fields of each line.
+	// tick:ignore
+	FieldsList []string `tick:"Fields" json:"fields"`
+
+	// Write a line with the field names at the start of each CSV file.
+	// tick:ignore
+	HeaderFlag bool `tick:"Header" json:"header"`
+
+	// Template of each line, replaces the format and fields.
+	// The template is rendered with the alert data, along with the TaskName, Name, Category, Group, Tags and Fields of the alert.
+	Template string `json:"template"`
+
+	// Rotate the file before it exceeds this size in bytes.
+	// The file is not rotated by size if zero.
+	MaxSize int64 `json:"maxSize"`
+
+	// Rotate the file once it has been written to for this long.
+	// The file is not rotated by age if zero.
+	MaxAge time.Duration `json:"maxAge"`
+
+	// Number of rotated files to keep, the oldest are removed.
+	// All rotated files are kept if zero.
+	MaxBackups int64 `json:"maxBackups"`
+}
+
+// Fields of each line, in order.
+// A field is either an alert property: id, message, details, time, duration, level,
+// previousLevel, recoverable, task, name, category or group,
+// or a tag or field of the alert, prefixed with 'tags.' or 'fields.'.
+// The csv format requires fields, JSON lines only contain the fields if set.
+// tick:property
+func (f *FileHandler) Fields(fields ...string) *FileHandler {
+	f.FieldsList = fields
+	return f
+}
+
+// Header writes a line with the field names at the start of each CSV file.
+// tick:property
+func (f *FileHandler) Header() *FileHandler {
+	f.HeaderFlag = true
+	return f
+}
+
 // Send alert to VictorOps.
 // To use VictorOps alerting you must first enable the 'Alert Ingestion API'
 // in the 'Integrations' section of VictorOps.
@@ -1066,6 +1645,11 @@ type PagerDutyHandler struct {
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	ServiceKey string `json:"serviceKey"`
+
+	// Maximum size in bytes of the event sent to PagerDuty.
+	// The details and then the description of larger events are truncated.
+	// Default: no limit
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
 }
 
 // Send the alert to PagerDuty API v2.
@@ -1131,6 +1715,27 @@ type PagerDuty2Handler struct {
 	// tick:ignore
 	Links []Link `tick:"Link" json:"links"`
 
+	// tick:ignore
+	FailoverURLs []string `tick:"Failover" json:"failover"`
+
+	// Number of times the event is retried to a URL before failing over to the next URL.
+	Retries int64 `json:"retries"`
+
+	// Timeout of each attempt to send the event, an attempt that times out counts towards the retries.
+	// The attempts that time out are counted in the timeouts of the alert_endpoints statistic.
+	// Only the post and pagerDuty2 handlers have a delivery timeout and count timeouts,
+	// the slack and discord handlers time out after 30s and the other HTTP handlers do not time out.
+	// Default: no timeout
+	Timeout time.Duration `json:"timeout"`
+
+	// Maximum size in bytes of the event sent to PagerDuty.
+	// The result and recent points of larger events are dropped, then their summary is truncated.
+	// Default: no limit
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
+
+	// tick:ignore
+	CustomDetails []CustomDetail `tick:"CustomDetail" json:"customDetails"`
+
 	// tick:ignore
 	_ string `tick:"ServiceKey"`
 }
@@ -1141,6 +1746,12 @@ type Link struct {
 	Text string `json:"text"`
 }
 
+// tick:ignore
+type CustomDetail struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 // Allow ServiceKey as backwards compatible way to set the routing key
 // tick:property
 func (pd2 *PagerDuty2Handler) ServiceKey(serviceKey string) *PagerDuty2Handler {
@@ -1173,6 +1784,46 @@ func (pd2 *PagerDuty2Handler) Link(url string, text ...string) *PagerDuty2Handle
 	return pd2
 }
 
+// Failover sets the ordered list of URLs of the PagerDuty events API to send the event to
+// when the URL of the configuration file fails, for example the events API of another service region.
+// The event is retried .retries() times to each URL before failing over to the next URL.
+//
+// Example:
+//
+//	stream
+//	  |alert()
+//	    .pagerDuty2()
+//	      .failover('https://events.eu.pagerduty.com/v2/enqueue')
+//	      .retries(2)
+//
+// tick:property
+func (pd2 *PagerDuty2Handler) Failover(urls ...string) *PagerDuty2Handler {
+	pd2.FailoverURLs = append(pd2.FailoverURLs, urls...)
+	return pd2
+}
+
+// Set a custom detail of the events, which PagerDuty shows as structured context of the incident.
+// The value is a template with the same data as the message template.
+// The custom details of the events always contain the result of the alert, as the "result" detail.
+//
+// Example:
+//
+//	stream
+//	  |alert()
+//	    .pagerDuty2()
+//	      .customDetail('host', '{{ index .Tags "host" }}')
+//	      .customDetail('value', '{{ index .Fields "value" }}')
+//	      .customDetail('threshold', '90')
+//
+// tick:property
+func (pd2 *PagerDuty2Handler) CustomDetail(key, value string) *PagerDuty2Handler {
+	pd2.CustomDetails = append(pd2.CustomDetails, CustomDetail{
+		Key:   key,
+		Value: value,
+	})
+	return pd2
+}
+
 // Send the alert to HipChat.
 // For step-by-step instructions on setting up Kapacitor with HipChat, see the [Event Handler Setup Guide](https://docs.influxdata.com//kapacitor/latest/guides/event-handler-setup/#hipchat-setup).
 // To allow Kapacitor to post to HipChat,
@@ -1656,6 +2307,10 @@ type SlackHandler struct {
 	// IconEmoji is an emoji name surrounded in ':' characters.
 	// The emoji image will replace the normal user icon for the slack bot.
 	IconEmoji string `json:"iconEmoji"`
+
+	// Maximum size in bytes of the post, the message of larger posts is truncated.
+	// Default: no limit
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
 }
 
 // Send the alert to Discord.
@@ -1736,6 +2391,9 @@ type DiscordHandler struct {
 	// Embed title
 	// If empty uses the default config
 	EmbedTitle string `json:"embedTitle"`
+	// Maximum size in bytes of the post, the message of larger posts is truncated
+	// Default: no limit
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
 }
 
 // To allow Kapacitor to post to BigPanda,
@@ -2400,6 +3058,10 @@ type TeamsHandler struct {
 	// Teams channel webhook URL to post messages.
 	// If empty uses the URL from the configuration.
 	ChannelURL string `json:"channel_url"`
+
+	// Maximum size in bytes of the post, the message of larger posts is truncated.
+	// Default: no limit
+	MaxPayloadSize int64 `json:"max_payload_size"`
 }
 
 // Send the alert to ServiceNow.
@@ -2618,3 +3280,68 @@ func (s *ZenossHandler) CustomField(key string, value interface{}) *ZenossHandle
 	s.CustomFieldsMap[key] = value
 	return s
 }
+
+// Send the alert to a Zabbix trapper item using the Zabbix sender protocol.
+//
+// By default the value of the item is the alert level,
+// 0 for OK, 1 for INFO, 2 for WARNING and 3 for CRITICAL,
+// so that Zabbix triggers can evaluate the level, i.e. last(/host/kapacitor.alert)>=3.
+// The host, key and value are templates of the alert data.
+//
+// Example:
+//
+//	[[zabbix]]
+//	  enabled = true
+//	  id = "default"
+//	  addr = "zabbix.example.com:10051"
+//	  key = "kapacitor.alert"
+//
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .zabbix()
+//	             .host('{{ index .Tags "host" }}')
+//	             .key('kapacitor.cpu')
+//
+// Send the alert level of each host to the kapacitor.cpu item of the Zabbix host matching the host tag.
+//
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .zabbix()
+//	             .server('proxy')
+//	             .host('kapacitor')
+//	             .value('{{ .Message }}')
+//
+// Send the alert message to the Zabbix proxy configured with the id proxy.
+// tick:property
+func (n *AlertNodeData) Zabbix() *ZabbixHandler {
+	zabbix := &ZabbixHandler{
+		AlertNodeData: n,
+	}
+	n.ZabbixHandlers = append(n.ZabbixHandlers, zabbix)
+	return zabbix
+}
+
+// tick:embedded:AlertNode.Zabbix
+type ZabbixHandler struct {
+	*AlertNodeData `json:"-"`
+
+	// The id of the Zabbix server configuration.
+	// If empty uses the default configuration.
+	Server string `json:"server"`
+
+	// The Zabbix host of the item.
+	// If empty uses the host from the configuration.
+	Host string `json:"host"`
+
+	// The key of the Zabbix trapper item.
+	// If empty uses the key from the configuration.
+	Key string `json:"key"`
+
+	// The value of the item.
+	// If empty the value is the numeric alert level.
+	Value string `json:"value"`
+}
@@ -31,12 +31,19 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "alertId": "",
     "message": "",
     "details": "",
+    "runbook": "",
     "info": null,
     "warn": null,
     "crit": null,
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "levels": null,
+    "infoFor": 0,
+    "warnFor": 0,
+    "critFor": 0,
+    "resolveDelay": 0,
+    "timeSource": "",
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -47,10 +54,16 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "durationField": "",
     "idTag": "",
     "idField": "",
+    "uniqueId": false,
     "all": false,
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "every": 0,
+    "attachRecent": 0,
+    "trigger": false,
+    "triggerLevels": null,
+    "fingerprint": null,
     "inhibitors": null,
     "post": [
         {
@@ -59,13 +72,17 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
             "headers": null,
             "captureResponse": false,
             "timeout": 0,
-            "skipSSLVerification": false
+            "skipSSLVerification": false,
+            "failover": null,
+            "retries": 0,
+            "maxPayloadSize": 0
         }
     ],
     "tcp": null,
     "email": null,
     "exec": null,
     "log": null,
+    "file": null,
     "victorOps": null,
     "pagerDuty": null,
     "pagerDuty2": null,
@@ -85,7 +102,8 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "kafka": null,
     "teams": null,
     "serviceNow": null,
-    "zenoss": null
+    "zenoss": null,
+    "zabbix": null
 }`,
 		},
 		{
@@ -110,12 +128,19 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "alertId": "",
     "message": "",
     "details": "",
+    "runbook": "",
     "info": null,
     "warn": null,
     "crit": null,
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "levels": null,
+    "infoFor": 0,
+    "warnFor": 0,
+    "critFor": 0,
+    "resolveDelay": 0,
+    "timeSource": "",
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -126,16 +151,23 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "durationField": "",
     "idTag": "",
     "idField": "",
+    "uniqueId": false,
     "all": false,
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "every": 0,
+    "attachRecent": 0,
+    "trigger": false,
+    "triggerLevels": null,
+    "fingerprint": null,
     "inhibitors": null,
     "post": null,
     "tcp": null,
     "email": null,
     "exec": null,
     "log": null,
+    "file": null,
     "victorOps": null,
     "pagerDuty": null,
     "pagerDuty2": null,
@@ -162,7 +194,8 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     ],
     "teams": null,
     "serviceNow": null,
-    "zenoss": null
+    "zenoss": null,
+    "zabbix": null
 }`,
 		},
 		{
@@ -186,12 +219,19 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "alertId": "",
     "message": "",
     "details": "",
+    "runbook": "",
     "info": null,
     "warn": null,
     "crit": null,
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "levels": null,
+    "infoFor": 0,
+    "warnFor": 0,
+    "critFor": 0,
+    "resolveDelay": 0,
+    "timeSource": "",
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -202,16 +242,23 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "durationField": "",
     "idTag": "",
     "idField": "",
+    "uniqueId": false,
     "all": false,
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "every": 0,
+    "attachRecent": 0,
+    "trigger": false,
+    "triggerLevels": null,
+    "fingerprint": null,
     "inhibitors": null,
     "post": null,
     "tcp": null,
     "email": null,
     "exec": null,
     "log": null,
+    "file": null,
     "victorOps": null,
     "pagerDuty": null,
     "pagerDuty2": null,
@@ -237,7 +284,8 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     ],
     "teams": null,
     "serviceNow": null,
-    "zenoss": null
+    "zenoss": null,
+    "zabbix": null
 }`,
 		},
 	}
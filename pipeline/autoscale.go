@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// AutoscaleNode triggers autoscale events for a resource by posting the desired number of replicas
+// to a webhook, allowing custom scalers to be driven by Kapacitor.
+// The node also outputs points for the triggered events.
+//
+// Example:
+//
+//	// Target 100 requests per second per replica
+//	var target = 100.0
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |derivative('count')
+//	        .as('rate')
+//	        .unit(1s)
+//	        .nonNegative()
+//	    |autoscale('http://scaler.example.com/scale')
+//	        // Get the name of the resource from the "service" tag.
+//	        .resourceNameTag('service')
+//	        .min(2)
+//	        .max(20)
+//	        .increaseCooldown(1m)
+//	        .decreaseCooldown(5m)
+//	        // Set the desired number of replicas based on target.
+//	        .replicas(lambda: int(ceil("rate" / target)))
+//	    |influxDBOut()
+//	        .database('deployments')
+//	        .measurement('scale_events')
+//	        .precision('s')
+//
+// If the desired number of replicas has changed, Kapacitor posts a JSON object to the URL
+// containing the name of the resource and the desired number of replicas:
+//
+//	{"resource": "api", "replicas": 4}
+//
+// Any response status other than 2xx is considered an error.
+// The desired number of replicas is always within the min and max bounds,
+// and the cooldowns are enforced for each resource.
+// Since the current number of replicas is not known until the first scale event,
+// the first desired number of replicas of a resource is always posted and the current field is 0 until then.
+//
+// Any time the Autoscale node changes a replica count, it emits a point.
+// The point is tagged with the resource name, using the outputResourceNameTag,
+// in addition the group by tags will be preserved on the emitted point.
+// The point contains two fields: `old`, and `new` representing change in the replicas.
+//
+// Available Statistics:
+//
+//   - increase_events -- number of times the replica count was increased.
+//   - decrease_events -- number of times the replica count was decreased.
+//   - cooldown_drops  -- number of times an event was dropped because of a cooldown timer.
+//   - errors          -- number of errors encountered, typically related to posting to the webhook.
+type AutoscaleNode struct {
+	chainnode `json:"-"`
+
+	// URL of the webhook to which the desired number of replicas is posted.
+	// tick:ignore
+	URL string `json:"url"`
+
+	// Headers to set on the webhook request.
+	// tick:ignore
+	Headers map[string]string `tick:"Header" json:"headers"`
+
+	// Timeout for the webhook request.
+	// Default: 10s
+	Timeout time.Duration `json:"timeout"`
+
+	// ResourceName is the name of the resource to autoscale.
+	ResourceName string `json:"resourceName"`
+	// ResourceNameTag is the name of a tag which contains the name of the resource to autoscale.
+	ResourceNameTag string `json:"resourceNameTag"`
+	// OutputResourceNameTag is the name of a tag into which the resource name will be written for output autoscale events.
+	// Defaults to the value of ResourceNameTag if its not empty.
+	OutputResourceNameTag string `json:"outputResourceNameTag"`
+
+	// CurrentField is the name of a field into which the current replica count will be set as an int.
+	// If empty no field will be set.
+	// Useful for computing deltas on the current state.
+	//
+	// Example:
+	//    |autoscale('http://scaler.example.com/scale')
+	//        .currentField('replicas')
+	//        // Increase the replicas by 1 if the qps is over the threshold
+	//        .replicas(lambda: if("qps" > threshold, "replicas" + 1, "replicas"))
+	//
+	CurrentField string `json:"currentField"`
+
+	// The maximum scale factor to set.
+	// If 0 then there is no upper limit.
+	// Default: 0, a.k.a no limit.
+	Max int64 `json:"max"`
+
+	// The minimum scale factor to set.
+	// Default: 1
+	Min int64 `json:"min"`
+
+	// Replicas is a lambda expression that should evaluate to the desired number of replicas for the resource.
+	Replicas *ast.LambdaNode `json:"replicas"`
+
+	// Only one increase event can be triggered per resource every IncreaseCooldown interval.
+	IncreaseCooldown time.Duration `json:"increaseCooldown"`
+	// Only one decrease event can be triggered per resource every DecreaseCooldown interval.
+	DecreaseCooldown time.Duration `json:"decreaseCooldown"`
+}
+
+func newAutoscaleNode(e EdgeType, url string) *AutoscaleNode {
+	k := &AutoscaleNode{
+		chainnode: newBasicChainNode("autoscale", e, StreamEdge),
+		URL:       url,
+		Min:       1,
+		Timeout:   10 * time.Second,
+	}
+	return k
+}
+
+// Set a header key and value on the webhook request.
+//
+// Example:
+//
+//	stream
+//	    |autoscale('http://scaler.example.com/scale')
+//	        .header('Authorization', 'Bearer secret')
+//
+// tick:property
+func (n *AutoscaleNode) Header(k, v string) *AutoscaleNode {
+	if n.Headers == nil {
+		n.Headers = map[string]string{}
+	}
+	n.Headers[k] = v
+	return n
+}
+
+func (n *AutoscaleNode) validate() error {
+	if n.URL == "" {
+		return errors.New("must specify the webhook url")
+	}
+	if _, err := url.Parse(n.URL); err != nil {
+		return fmt.Errorf("invalid url %q: %v", n.URL, err)
+	}
+	if (n.ResourceName == "" && n.ResourceNameTag == "") ||
+		(n.ResourceName != "" && n.ResourceNameTag != "") {
+		return fmt.Errorf("must specify exactly one of ResourceName or ResourceNameTag")
+	}
+	if n.Min < 1 {
+		return fmt.Errorf("min must be >= 1, got %d", n.Min)
+	}
+	if n.Max != 0 && n.Max < n.Min {
+		return fmt.Errorf("max must be >= min, got max %d min %d", n.Max, n.Min)
+	}
+	if n.Replicas == nil {
+		return errors.New("must provide a replicas lambda expression")
+	}
+	return nil
+}
+
+// MarshalJSON converts AutoscaleNode to JSON
+// tick:ignore
+func (n *AutoscaleNode) MarshalJSON() ([]byte, error) {
+	type Alias AutoscaleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout          string `json:"timeout"`
+		IncreaseCooldown string `json:"increaseCooldown"`
+		DecreaseCooldown string `json:"decreaseCooldown"`
+	}{
+		TypeOf: TypeOf{
+			Type: "autoscale",
+			ID:   n.ID(),
+		},
+		Alias:            (*Alias)(n),
+		Timeout:          influxql.FormatDuration(n.Timeout),
+		IncreaseCooldown: influxql.FormatDuration(n.IncreaseCooldown),
+		DecreaseCooldown: influxql.FormatDuration(n.DecreaseCooldown),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an AutoscaleNode
+// tick:ignore
+func (n *AutoscaleNode) UnmarshalJSON(data []byte) error {
+	type Alias AutoscaleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout          string `json:"timeout"`
+		IncreaseCooldown string `json:"increaseCooldown"`
+		DecreaseCooldown string `json:"decreaseCooldown"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+
+	if raw.Type != "autoscale" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as AutoscaleNode", raw.ID, raw.Type)
+	}
+
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+
+	n.IncreaseCooldown, err = influxql.ParseDuration(raw.IncreaseCooldown)
+	if err != nil {
+		return err
+	}
+
+	n.DecreaseCooldown, err = influxql.ParseDuration(raw.DecreaseCooldown)
+	if err != nil {
+		return err
+	}
+
+	n.setID(raw.ID)
+	return nil
+}
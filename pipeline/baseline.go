@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// DefaultBaselineSeasons is the default number of trailing seasons averaged by a BaselineNode.
+const DefaultBaselineSeasons = 4
+
+// A BaselineNode compares a field of every point of a stream to the periodic baseline of its group,
+// the mean of the field at the same time of the period in the trailing periods, and adds the deviation from it.
+// The period, i.e. a day or a week, is split in buckets, such as the hours of the day,
+// and the baseline of a point is the mean of the values of its group in its bucket in each of the previous seasons,
+// a season being one occurrence of the period.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |baseline('rate', 1w)
+//	        .bucket(1h)
+//	        .seasons(4)
+//	    |alert()
+//	        .warn(lambda: isPresent("baseline") AND "deviation" > 0.5 * "baseline")
+//
+// Warn when the request rate of a service is 50% above its usual rate for the hour of the week,
+// as averaged over the last four weeks.
+//
+// Baselines are keyed by group and bucket.
+// The bucket of a point is the offset of its time into the period, counted from the Unix epoch in UTC, divided by the bucket duration.
+// So with a period of 24h the buckets are the times of the day in UTC,
+// and with a period of 1w the buckets are the times of the week starting on Thursday at midnight UTC.
+//
+// Baselines are refreshed continuously from the data:
+// the mean of each bucket of each season is kept, the current season is added as points arrive
+// and seasons older than the number of seasons are dropped, so the baseline follows slow changes of the data.
+// The baseline of a point only uses the previous seasons, never the current season,
+// a bucket without data in all of the previous seasons has no baseline.
+// Points without a baseline, such as during the first season of a group, are passed on without the baseline and deviation fields.
+// Points missing the field or with a non numeric field are dropped.
+// The baselines of a group are dropped when the group is deleted.
+//
+// Each group retains up to seasons + 1 means per bucket,
+// so the memory of the node is proportional to the cardinality and the number of buckets of the period.
+//
+// A baseline can be supplied instead of being computed, by sideloading it keyed by a tag of the time of the period.
+// For example with baselines of each hour of the day in files of each host:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |eval(lambda: string(hour("time")))
+//	        .as('hour')
+//	        .tags('hour')
+//	    |sideload()
+//	        .source('file:///path/to/baselines')
+//	        .order('{{.host}}/{{.hour}}.yml')
+//	        .field('baseline', 0.0)
+//	    |eval(lambda: "usage" - "baseline")
+//	        .as('deviation')
+//	        .keep()
+//
+// Sideloaded baselines are refreshed when the sideload source is reloaded.
+type BaselineNode struct {
+	chainnode `json:"-"`
+
+	// The field compared to the baseline.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The period of the baseline, the time to the same time of the next season.
+	// tick:ignore
+	Period time.Duration `json:"period"`
+
+	// The duration of the buckets of the period, the period must be a multiple of the bucket.
+	// Defaults to 1h.
+	Bucket time.Duration `json:"bucket"`
+
+	// The number of trailing seasons averaged by the baseline.
+	// Defaults to 4.
+	Seasons int64 `json:"seasons"`
+
+	// The name of the deviation field, the value of the field minus the baseline.
+	// Defaults to deviation.
+	As string `json:"as"`
+
+	// The name of the baseline field.
+	// Defaults to baseline.
+	BaselineAs string `json:"baselineAs"`
+}
+
+func newBaselineNode(field string, period time.Duration) *BaselineNode {
+	return &BaselineNode{
+		chainnode:  newBasicChainNode("baseline", StreamEdge, StreamEdge),
+		Field:      field,
+		Period:     period,
+		Bucket:     time.Hour,
+		Seasons:    DefaultBaselineSeasons,
+		As:         "deviation",
+		BaselineAs: "baseline",
+	}
+}
+
+// MarshalJSON converts BaselineNode to JSON
+// tick:ignore
+func (n *BaselineNode) MarshalJSON() ([]byte, error) {
+	type Alias BaselineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Bucket string `json:"bucket"`
+	}{
+		TypeOf: TypeOf{
+			Type: "baseline",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+		Bucket: influxql.FormatDuration(n.Bucket),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an BaselineNode
+// tick:ignore
+func (n *BaselineNode) UnmarshalJSON(data []byte) error {
+	type Alias BaselineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Bucket string `json:"bucket"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "baseline" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as BaselineNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.Bucket, err = influxql.ParseDuration(raw.Bucket)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *BaselineNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for the baseline")
+	}
+	if n.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %v", n.Period)
+	}
+	if n.Bucket <= 0 {
+		return fmt.Errorf("bucket must be positive, got %v", n.Bucket)
+	}
+	if n.Period%n.Bucket != 0 {
+		return fmt.Errorf("period %v must be a multiple of the bucket %v", n.Period, n.Bucket)
+	}
+	if n.Seasons <= 0 {
+		return fmt.Errorf("seasons must be positive, got %d", n.Seasons)
+	}
+	if n.As == "" {
+		return errors.New("must specify a name for the deviation")
+	}
+	if n.BaselineAs == "" {
+		return errors.New("must specify a name for the baseline")
+	}
+	if n.As == n.BaselineAs {
+		return fmt.Errorf("the deviation and baseline must have different names, got %q", n.As)
+	}
+	return nil
+}
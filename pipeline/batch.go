@@ -190,6 +190,37 @@ type QueryNode struct {
 	// The name of a configured InfluxDB cluster.
 	// If empty the default cluster will be used.
 	Cluster string `json:"cluster"`
+
+	// How far back to catch up on missed queries when the task starts.
+	//
+	// When set, every scheduled query between the start of the backfill and now
+	// is executed in time order before the regular schedule starts.
+	// The backfill starts from the last successful query saved in the task snapshot,
+	// or from Backfill ago if there is no snapshot or the snapshot is older.
+	// Queries are never backfilled further than Backfill ago.
+	// Zero disables backfill.
+	//
+	// Example:
+	//
+	//	batch
+	//	    |query('SELECT mean("value") FROM "telegraf"."default".cpu')
+	//	        .period(5m)
+	//	        .every(5m)
+	//	        .backfill(24h)
+	//	        .backfillConcurrency(2)
+	//	        .backfillInterval(1s)
+	//
+	// After a restart the missed 5m windows of the last day are queried, two at a time,
+	// starting at most one query per second, before querying every 5m again.
+	Backfill time.Duration `json:"backfill"`
+
+	// The maximum number of backfill queries executed concurrently.
+	// Batches are emitted in time order regardless of the concurrency.
+	// Zero executes backfill queries one at a time.
+	BackfillConcurrency int64 `json:"backfillConcurrency"`
+
+	// The minimum time between starting backfill queries, bounding the rate of backfill queries.
+	BackfillInterval time.Duration `json:"backfillInterval"`
 }
 
 func newQueryNode() *QueryNode {
@@ -206,18 +237,22 @@ func (n *QueryNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
-		Offset string `json:"offset"`
+		Period           string `json:"period"`
+		Every            string `json:"every"`
+		Offset           string `json:"offset"`
+		Backfill         string `json:"backfill"`
+		BackfillInterval string `json:"backfillInterval"`
 	}{
 		TypeOf: TypeOf{
 			Type: "query",
 			ID:   n.ID(),
 		},
-		Alias:  (*Alias)(n),
-		Period: influxql.FormatDuration(n.Period),
-		Every:  influxql.FormatDuration(n.Every),
-		Offset: influxql.FormatDuration(n.Offset),
+		Alias:            (*Alias)(n),
+		Period:           influxql.FormatDuration(n.Period),
+		Every:            influxql.FormatDuration(n.Every),
+		Offset:           influxql.FormatDuration(n.Offset),
+		Backfill:         influxql.FormatDuration(n.Backfill),
+		BackfillInterval: influxql.FormatDuration(n.BackfillInterval),
 	}
 	return json.Marshal(raw)
 }
@@ -229,9 +264,11 @@ func (n *QueryNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
-		Offset string `json:"offset"`
+		Period           string `json:"period"`
+		Every            string `json:"every"`
+		Offset           string `json:"offset"`
+		Backfill         string `json:"backfill"`
+		BackfillInterval string `json:"backfillInterval"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -258,10 +295,37 @@ func (n *QueryNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if raw.Backfill != "" {
+		n.Backfill, err = influxql.ParseDuration(raw.Backfill)
+		if err != nil {
+			return err
+		}
+	}
+
+	if raw.BackfillInterval != "" {
+		n.BackfillInterval, err = influxql.ParseDuration(raw.BackfillInterval)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.setID(raw.ID)
 	return nil
 }
 
+func (n *QueryNode) validate() error {
+	if n.Backfill < 0 {
+		return fmt.Errorf("backfill must be >= 0, got %v", n.Backfill)
+	}
+	if n.BackfillConcurrency < 0 {
+		return fmt.Errorf("backfillConcurrency must be >= 0, got %d", n.BackfillConcurrency)
+	}
+	if n.BackfillInterval < 0 {
+		return fmt.Errorf("backfillInterval must be >= 0, got %v", n.BackfillInterval)
+	}
+	return nil
+}
+
 //tick:ignore
 func (n *QueryNode) ChainMethods() map[string]reflect.Value {
 	return map[string]reflect.Value{
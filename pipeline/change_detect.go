@@ -60,12 +60,47 @@ import (
 // packets in=1,out=0 0000000001
 // packets in=1,out=1 0000000002
 // packets in=2,out=1 0000000004
+//
+// Numeric values can be compared with a tolerance, and the previous values
+// can be added to the emitted points to compute transitions downstream.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('temperature')
+//	    |changeDetect('value')
+//	        // Only emit when the value changed by more than 0.5
+//	        .tolerance(0.5)
+//	        // Add the previous value as the field 'previous_value'
+//	        .previousPrefix('previous_')
+//
+// Only the values of the fields are kept for each group, not the entire point.
+// The number of groups with kept values can be limited with the MaxGroups property.
 type ChangeDetectNode struct {
 	chainnode `json:"-"`
 
 	// The field to use when calculating the changeDetect
 	// tick:ignore
 	Fields []string `json:"fields"`
+
+	// Numeric values are considered changed only if they differ from the previous value by more than the tolerance.
+	// The previous value is the value of the last emitted point,
+	// so slow drifts are emitted once they exceed the tolerance.
+	// Default: 0
+	Tolerance float64 `json:"tolerance"`
+
+	// If set, the previous value of each field is added to the emitted points,
+	// using the field name with the prefix.
+	// The previous values are not added to the first emitted point of a group.
+	PreviousPrefix string `json:"previousPrefix"`
+
+	// Maximum number of groups for which the values of the last emitted point are kept.
+	// Beyond the limit the values of the least recently changed group are forgotten,
+	// so the next point of that group is emitted as the first point of a group.
+	// If 0 then there is no limit.
+	// Default: 0, a.k.a no limit.
+	MaxGroups int64 `json:"maxGroups"`
 }
 
 func newChangeDetectNode(wants EdgeType, fields []string) *ChangeDetectNode {
@@ -113,3 +148,13 @@ func (n *ChangeDetectNode) UnmarshalJSON(data []byte) error {
 	n.setID(raw.ID)
 	return nil
 }
+
+func (n *ChangeDetectNode) validate() error {
+	if n.Tolerance < 0 {
+		return fmt.Errorf("tolerance must be >= 0, got %v", n.Tolerance)
+	}
+	if n.MaxGroups < 0 {
+		return fmt.Errorf("maxGroups must be >= 0, got %d", n.MaxGroups)
+	}
+	return nil
+}
@@ -17,6 +17,12 @@ import (
 // The above example will set the field `value` to float64(0) if it does not already exist
 // It will also set the tag `host` to string("") if it does not already exist.
 //
+// To default a value within an expression instead, use the exists function:
+//
+//	stream
+//	    |eval(lambda: if(exists("value"), "value", 0.0))
+//	        .as('value')
+//
 // Available Statistics:
 //
 //   - fields_defaulted -- number of fields that were missing
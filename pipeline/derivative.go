@@ -32,6 +32,19 @@ import (
 // The derivative is computed for each point, and
 // because of boundary conditions the first point is
 // dropped.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('net_rx_packets')
+//	    |derivative('value')
+//	       .counterReset()
+//	    ...
+//
+// Treats the field as a monotonically increasing counter.
+// When the value decreases the counter is assumed to have reset to zero,
+// so the current value is used as the difference.
 type DerivativeNode struct {
 	chainnode `json:"-"`
 
@@ -51,6 +64,10 @@ type DerivativeNode struct {
 	// Where negative values are acceptable.
 	// tick:ignore
 	NonNegativeFlag bool `tick:"NonNegative" json:"nonNegative"`
+
+	// Whether a decrease in value is treated as a counter reset.
+	// tick:ignore
+	CounterResetFlag bool `tick:"CounterReset" json:"counterReset"`
 }
 
 func newDerivativeNode(wants EdgeType, field string) *DerivativeNode {
@@ -113,3 +130,12 @@ func (d *DerivativeNode) NonNegative() *DerivativeNode {
 	d.NonNegativeFlag = true
 	return d
 }
+
+// If called a decrease in value is treated as a counter reset.
+// The difference is then computed as if the counter had reset to zero,
+// i.e. the current value is used as the difference.
+// tick:property
+func (d *DerivativeNode) CounterReset() *DerivativeNode {
+	d.CounterResetFlag = true
+	return d
+}
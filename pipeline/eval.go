@@ -3,8 +3,11 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"text/template"
 
 	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/pkg/errors"
 )
 
 // Evaluates expressions on each data point it receives.
@@ -22,9 +25,237 @@ import (
 // data point with the result of `error_count / total_count` where
 // `error_count` and `total_count` are existing fields on the data point.
 //
+// Tag values, including the group by tags, can be accessed with the `tag` function.
+// Unlike a reference, `tag` evaluates to an empty string if the tag does not exist.
+// The lookup does not allocate, so it is safe to use on hot paths.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .groupBy('region')
+//	    |eval(lambda: "value" * if(tag('region') == 'eu', 1.2, 1.0))
+//	        .as('value')
+//
+// The whole tag set can be inspected with the functions:
+//
+//   - tagCount() -- the number of tags.
+//   - tagKeys([sep]) -- the sorted tag keys joined by sep, a comma by default.
+//   - tagsString([sep]) -- the key=value pairs of the tags sorted by key and joined by sep, a comma by default.
+//   - hasTagMatching(regex) -- whether the key of any tag matches the regex.
+//
+// The tags are sorted so that the strings are stable, to build composite keys for example.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |eval(lambda: tagsString(), lambda: hasTagMatching(/^debug_/))
+//	        .as('key', 'debug')
+//
+// Near-identical strings can be matched with the string distance functions, which compare runes:
+//
+//   - strLevenshtein(a, b) -- the Levenshtein distance, the minimum number of rune insertions, deletions or substitutions
+//     changing a into b, an int from 0 for equal strings to the length of the longer string.
+//   - strJaroWinkler(a, b) -- the Jaro-Winkler similarity, a float from 0 for strings without runes in common
+//     to 1 for equal strings, favoring strings with a common prefix.
+//
+// Two empty strings are equal, an empty and a non empty string have no runes in common.
+// The cost of both functions is proportional to the product of the lengths of the strings, so they are meant for tags and short fields.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('logs')
+//	    |eval(lambda: if(strJaroWinkler(tag('service'), 'payments') > 0.9, 'payments', tag('service')))
+//	        .as('service')
+//	        .tags('service')
+//
+// The age of a point can be computed with the `now` and `timeDiff` functions.
+// `timeDiff(a, b)` returns the duration from the time `b` to the time `a`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('events')
+//	    |eval(lambda: timeDiff(now(), "time"))
+//	        .as('age')
+//
+// See the property EvalNode.NowPointTime for how `now` behaves when replaying data.
+//
+// Business hours and holidays can be checked with the calendar functions, so that an alert can be downgraded
+// or suppressed outside business hours:
+//
+//   - isBusinessHours(time, tz, start, end, days) -- whether the time is from start to end on one of the days in the time zone tz.
+//   - isHoliday(date, holidays[, tz]) -- whether the date is in the holiday set.
+//
+// Pass the point time, `"time"`, so that the result does not depend on when the data is processed, when replaying for example.
+// The time zone is an IANA name such as 'America/New_York' or 'UTC', the time is converted to the time zone
+// so that the business hours follow its daylight saving time.
+// The start and end are times of day of the form HH:MM, the end is excluded and can be 24:00.
+// An end before the start spans midnight, for example '22:00' to '06:00', and the hours after midnight belong to the day they started.
+// The days are a comma separated list of days and ranges of days, Mon, Tue, Wed, Thu, Fri, Sat and Sun, for example 'Mon-Fri' or 'Mon,Wed-Fri'.
+//
+// The date of isHoliday is a time, whose date in the time zone tz is used, UTC by default, or a string of the form YYYY-MM-DD.
+// The holiday set is a string of dates of the form YYYY-MM-DD separated by commas or white space.
+// It is either a TICKscript var, updated by redefining the vars of the task,
+// or a field or tag loaded by a sideload node, updated by reloading the sideload sources.
+// Literal time zones, times, days and holiday sets are validated when the task starts.
+//
+// Example:
+//
+//	var holidays = '2026-12-25,2027-01-01'
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |eval(lambda: isBusinessHours("time", 'Europe/Paris', '09:00', '18:00', 'Mon-Fri') AND !isHoliday("time", holidays, 'Europe/Paris'))
+//	        .as('business')
+//	        .keep()
+//	    |alert()
+//	        .warn(lambda: "usage_idle" < 20)
+//	        .crit(lambda: "usage_idle" < 10 AND "business")
+//
+// The above example only raises critical alerts during business hours, warnings otherwise.
+//
+// The rate of change of a field can be computed inline with the stateful rate function,
+// instead of a derivative node:
+//
+//   - rate(value, time[, unit[, resetPolicy[, maxGap]]]) -- the change of the value since the previous point per unit of time,
+//     one second by default.
+//
+// Pass the point time, `"time"`, as the time.
+// The reset policy is what a decrease of the value means:
+//
+//   - counter -- the value is a counter which was reset, so it counted up from zero since the previous point
+//     and the rate is the value divided by the elapsed time. This is the default.
+//   - null -- the rate is null, like a non negative derivative.
+//   - none -- the rate is negative.
+//
+// The rate is null when the previous point is more than maxGap before the point, rather than a rate averaged over the gap.
+// A maxGap of 0, the default, never considers the points far apart.
+// The gap is checked before resets, so a decrease across a gap is not a reset, the rate is null either way.
+// The rate of the first point of a group is null since there is no previous point,
+// and after a null rate from a gap the point is the previous point of the next one.
+// A point which is not after the previous point has a null rate and is ignored.
+//
+// Only the previous value and time are kept for each group.
+// A null rate is a NaN result, handled by the nanPolicy property,
+// so by default the first point and the points after a gap are dropped, and with the omit policy they are kept without the rate field.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('host')
+//	    |eval(lambda: rate("count", "time", 1m, 'counter', 5m))
+//	        .as('per_minute')
+//	        .nanPolicy('omit')
+//	        .keep()
+//
+// Booleans and numbers are converted explicitly with the coercion functions:
+//
+//   - boolToInt(b) -- 1 if b is true, 0 if it is false.
+//   - intToBool(n) -- true if the int or float n is not zero, false if it is zero, NaN is an error.
+//   - countTrue(b, size) -- the number of true values among the last size points of the group, including the point.
+//
+// Unlike the bool conversion function, which only converts 0 and 1, intToBool converts any number.
+// The size of countTrue is an int literal from 1 to 10000, until the group has size points
+// the values of all of its points are counted.
+// Each group counts its own points.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('checks')
+//	        .groupBy('host')
+//	    |eval(lambda: countTrue(!"ok", 10))
+//	        .as('failures')
+//	        .keep()
+//	    |alert()
+//	        .crit(lambda: "failures" > 3)
+//
+// The above example alerts when more than 3 of the last 10 checks of a host failed.
+// To count the true values of time windows instead, sum the converted values:
+//
+//	stream
+//	    |from()
+//	        .measurement('checks')
+//	    |eval(lambda: boolToInt(!"ok"))
+//	        .as('failed')
+//	    |window()
+//	        .period(10m)
+//	        .every(1m)
+//	    |sum('failed')
+//	        .as('failures')
+//
+// Transitions of a condition are detected with the stateful edge function:
+//
+//   - edge(b[, kind]) -- true if b changed since the previous point of the group in the direction of the kind.
+//
+// The kinds are:
+//
+//   - rising -- b changed from false to true. This is the default.
+//   - falling -- b changed from true to false.
+//   - both -- b changed either way.
+//
+// The first point of a group only sets the previous value of b, it is never an edge,
+// so a group which starts with b true emits no rising edge until b has been false.
+// The state of each group is the previous value of b,
+// a group deleted when it is idle, see the barrier node, starts over when it has points again.
+// Unlike the alert node, which emits alerts, edge works anywhere in the pipeline,
+// used with a where node it emits a single point per transition.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |where(lambda: edge("usage_idle" < 10, 'rising'))
+//	    |httpPost('http://example.com/scale-up')
+//
+// The above example posts the first point of a host with less than 10% idle CPU,
+// and again only after the idle CPU of the host has been at least 10%.
+//
+// Multi-value results can be carried as array fields, which are arrays of floats.
+// Arrays are constructed with `array` from up to five floats or ints,
+// indexed with `arrayIndex`, where negative indexes count from the end,
+// and measured with `arrayLen`.
+// `arraySum`, `arrayMean`, `arrayMin` and `arrayMax` aggregate the elements of an array.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('latency')
+//	    |eval(lambda: array("p50", "p90", "p99"))
+//	        .as('quantiles')
+//	        .keep()
+//	    |eval(lambda: arrayMax("quantiles"), lambda: arrayIndex("quantiles", -1))
+//	        .as('worst', 'p99')
+//	        .keep()
+//
+// InfluxDB fields are scalar, so arrays cannot be persisted to InfluxDB directly:
+// the influxDBOut node drops the array fields of the points it writes,
+// and drops the points that only have array fields.
+// Use arrayIndex or an aggregate to write the values of an array.
+//
+// Float results that are NaN or Inf, for example from a division by zero
+// or the log of a negative value, are handled according to the nanPolicy property.
+// By default the point is dropped.
+//
 // Available Statistics:
 //
 //   - eval_errors -- number of errors evaluating any expressions.
+//   - nan_results -- number of points with a NaN or Inf result.
+//   - dynamic_names -- number of distinct field names rendered from .as() name templates.
+//   - dynamic_names_dropped -- number of points dropped because of the maxDynamicNames limit.
 type EvalNode struct {
 	chainnode `json:"-"`
 
@@ -46,8 +277,64 @@ type EvalNode struct {
 	// keep all fields.
 	// tick:ignore
 	KeepList []string `json:"keepList"`
+
+	// How to handle expression results that are NaN or Inf.
+	// One of:
+	//
+	//   - drop -- drop the point, this is the default.
+	//   - replace -- replace the result with the nanReplacement value.
+	//   - tag -- keep the result and set the nanTag tag on the point
+	//     to the comma separated names of the NaN or Inf results.
+	//   - omit -- keep the point without the fields of the NaN or Inf results.
+	//     Later expressions see the results as missing, see isPresent.
+	//
+	// In every case the point is counted in the nan_results statistic.
+	//
+	// Example:
+	//
+	//	stream
+	//	    |eval(lambda: "errors" / "total")
+	//	        .as('error_rate')
+	//	        .nanPolicy('replace')
+	//	        .nanReplacement(0.0)
+	//
+	NanPolicy string `json:"nanPolicy"`
+
+	// The value that replaces NaN or Inf results when the nanPolicy is replace.
+	// Later expressions see the replaced value.
+	NanReplacement float64 `json:"nanReplacement"`
+
+	// The name of the tag set when the nanPolicy is tag.
+	// Default: eval_error
+	NanTag string `json:"nanTag"`
+
+	// tick:ignore
+	NowPointTimeFlag bool `tick:"NowPointTime" json:"nowPointTime"`
+
+	// Maximum number of distinct field names rendered from the .as() names that are templates.
+	// Points that would add a name beyond the limit are dropped,
+	// so that a tag with unbounded values does not produce unbounded field names.
+	// Default: 100
+	MaxDynamicNames int64 `json:"maxDynamicNames"`
 }
 
+// DefaultMaxDynamicNames is the maximum number of distinct dynamic field names of an eval node when maxDynamicNames is not set.
+const DefaultMaxDynamicNames = 100
+
+const (
+	// NanPolicyDrop drops points with NaN or Inf results.
+	NanPolicyDrop = "drop"
+	// NanPolicyReplace replaces NaN or Inf results with a value.
+	NanPolicyReplace = "replace"
+	// NanPolicyTag tags points with NaN or Inf results.
+	NanPolicyTag = "tag"
+	// NanPolicyOmit leaves NaN or Inf results out of the fields of points.
+	NanPolicyOmit = "omit"
+
+	// DefaultNanTag is the tag set by the tag policy when no nanTag is given.
+	DefaultNanTag = "eval_error"
+)
+
 func newEvalNode(e EdgeType, exprs []*ast.LambdaNode) *EvalNode {
 	n := &EvalNode{
 		chainnode: newBasicChainNode("eval", e, e),
@@ -97,8 +384,31 @@ func (e *EvalNode) validate() error {
 	if asLen, lambdaLen := len(e.AsList), len(e.Lambdas); asLen != lambdaLen {
 		return fmt.Errorf("must specify same number of expressions and .as() names: got %d as names, and %d expressions.", asLen, lambdaLen)
 	}
+	for _, as := range e.AsList {
+		if _, ok := SplitMultiName(as); ok {
+			continue
+		}
+		if !IsDynamicName(as) {
+			continue
+		}
+		if _, err := template.New("as").Option("missingkey=error").Parse(as); err != nil {
+			return errors.Wrapf(err, "invalid .as() name template %q", as)
+		}
+	}
+	if err := e.validateMultiNames(); err != nil {
+		return err
+	}
+	if e.MaxDynamicNames < 0 {
+		return fmt.Errorf("maxDynamicNames must be positive, got %d", e.MaxDynamicNames)
+	}
 	// Validate tag names exist in As names list.
 	for _, tag := range e.TagsList {
+		if IsDynamicName(tag) {
+			return fmt.Errorf("invalid tag name %q, tags cannot have dynamic names", tag)
+		}
+		if _, ok := SplitMultiName(tag); ok {
+			return fmt.Errorf("invalid tag name %q, the elements of an array result cannot be tags", tag)
+		}
 		found := false
 		for _, as := range e.AsList {
 			if tag == as {
@@ -110,6 +420,40 @@ func (e *EvalNode) validate() error {
 			return fmt.Errorf("invalid tag name %q, name is not present is .as() names", tag)
 		}
 	}
+	switch e.NanPolicy {
+	case "", NanPolicyDrop, NanPolicyReplace, NanPolicyTag, NanPolicyOmit:
+	default:
+		return fmt.Errorf("invalid nanPolicy %q, must be one of %s", e.NanPolicy, strings.Join([]string{NanPolicyDrop, NanPolicyReplace, NanPolicyTag, NanPolicyOmit}, ", "))
+	}
+	return nil
+}
+
+// validateMultiNames validates the element names of the [name1, name2, ...] names,
+// which must be static and unique among all the names.
+func (e *EvalNode) validateMultiNames() error {
+	names := make(map[string]bool, len(e.AsList))
+	for _, as := range e.AsList {
+		if _, ok := SplitMultiName(as); !ok {
+			names[as] = true
+		}
+	}
+	for _, as := range e.AsList {
+		elements, ok := SplitMultiName(as)
+		if !ok {
+			continue
+		}
+		for _, name := range elements {
+			switch {
+			case name == "":
+				return fmt.Errorf("invalid .as() name %q, names of array elements must not be empty", as)
+			case IsDynamicName(name):
+				return fmt.Errorf("invalid .as() name %q, names of array elements cannot be dynamic", as)
+			case names[name]:
+				return fmt.Errorf("invalid .as() name %q, name %q is used more than once", as, name)
+			}
+			names[name] = true
+		}
+	}
 	return nil
 }
 
@@ -127,12 +471,72 @@ func (e *EvalNode) validate() error {
 // The above example calculates two fields from the value and names them
 // `value2` and `inv_value2` respectively.
 //
+// A name containing `{{` is a template rendered for each point, with the tags and fields
+// of the point as `.Tags` and `.Fields`, producing a dynamic field name per point.
+// Templates are validated when the task starts, and referencing a missing tag or field is an error.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('region')
+//	    |eval(lambda: "value")
+//	        .as('value_{{ .Tags.region }}')
+//
+// The above example writes the value of each region into the field `value_<region>`,
+// i.e. `value_us` and `value_eu`, reshaping the data into a wide format.
+//
+// A dynamic name is only the name of the output field:
+// later expressions cannot reference the result, and it cannot be converted to a tag.
+// The nodes downstream see different field names for each point,
+// so nodes expecting a fixed field name, for example `|mean('value')`, do not see the results.
+// The number of distinct dynamic names is bounded by maxDynamicNames,
+// and counted in the dynamic_names statistic.
+// To keep the result of a dynamic name with `.keep()`, pass the template as it is given to `.as()`,
+// the field is kept with the name rendered for the point.
+//
+// A name of the form `[name1, name2, ...]` names each element of the array returned by the expression,
+// so that a single expression computes several output fields in one pass.
+// The array must have exactly one element per name, otherwise the point is dropped.
+// The names are static, must be unique among all the names of the node, and are validated when the task starts.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |eval(lambda: array("user" + "system", "user" / ("user" + "system")))
+//	        .as('[busy, user_ratio]')
+//
+// The above example computes the fields `busy` and `user_ratio` from a single expression.
+// The NaN policy applies to each element, and later expressions can reference each name.
+// The elements are floats, so they cannot be converted to tags.
+//
 // tick:property
 func (e *EvalNode) As(names ...string) *EvalNode {
 	e.AsList = names
 	return e
 }
 
+// IsDynamicName returns whether an .as() name of an eval node is a template.
+func IsDynamicName(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// SplitMultiName returns the names of the elements of an .as() name of the form [name1, name2, ...],
+// and whether the name is of that form.
+func SplitMultiName(name string) ([]string, bool) {
+	if !strings.HasPrefix(name, "[") || !strings.HasSuffix(name, "]") {
+		return nil, false
+	}
+	names := strings.Split(name[1:len(name)-1], ",")
+	for i, n := range names {
+		names[i] = strings.TrimSpace(n)
+	}
+	return names, true
+}
+
 // Convert the result of an expression into a tag.
 // The result must be a string.
 // Use the `string()` expression function to convert types.
@@ -166,6 +570,29 @@ func (e *EvalNode) Tags(names ...string) *EvalNode {
 	return e
 }
 
+// If called the `now` function returns the time of the point being evaluated
+// instead of the current wall clock time.
+//
+// By default `now` is the time the point is processed.
+// Results based on it then depend on when the task runs, so replaying a recording
+// or testing a task with old data does not give the same results as when the data was live.
+// Use NowPointTime when the results must only depend on the data.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('events')
+//	    |eval(lambda: hour(now()))
+//	        .as('hour')
+//	        .nowPointTime()
+//
+// tick:property
+func (e *EvalNode) NowPointTime() *EvalNode {
+	e.NowPointTimeFlag = true
+	return e
+}
+
 // If called the existing fields will be preserved in addition
 // to the new fields being set.
 // If not called then only new fields are preserved. (Tags are
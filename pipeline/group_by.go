@@ -136,6 +136,15 @@ func (n *GroupByNode) ByMeasurement() *GroupByNode {
 }
 
 // Exclude removes any tags from the group.
+// Requires grouping by '*', the group of each point is then made of
+// the sorted names of the tags present on that point, minus the excluded tags.
+//
+// Example:
+//
+//	|groupBy(*)
+//	    .exclude('instance')
+//
+// tick:property
 func (n *GroupByNode) Exclude(dims ...string) *GroupByNode {
 	n.ExcludedDimensions = append(n.ExcludedDimensions, dims...)
 	return n
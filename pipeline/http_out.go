@@ -25,6 +25,44 @@ import (
 // Beware of adding a final slash ‘/’ to the URL. This will result in a 404 error for a
 // task that does not exist.
 //
+// The cached result can be limited to a subset of fields and tags using the
+// `fields` and `tags` properties.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |httpOut('cpu')
+//	        .fields('usage_idle', 'usage_user')
+//	        .tags('host')
+//
+// The cached data can be served as a list of points including the measurement,
+// time, all tags and the type of each field using the `fieldsAndTags` property.
+// The points can be copied into a test recording to replay the data.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |httpOut('cpu')
+//	        .fieldsAndTags()
+//	        .pretty()
+//
+// The endpoint serves JSON by default.
+// The format is requested with the `format` query parameter, one of `json`, `csv` or `line`,
+// or else negotiated from the Accept header of the request: `text/csv` serves CSV and `text/plain` serves line protocol.
+// The CSV has a header row of the name, the tags and the columns of the series, repeated whenever the columns change.
+// The line protocol skips the values which cannot be written as fields, i.e. arrays and NaN floats.
+// The data is streamed one series at a time, so that large results are not buffered,
+// except the JSON of the pretty and fieldsAndTags endpoints.
+//
+// Example:
+//
+//	curl 'http://localhost:9092/kapacitor/v1/tasks/<task_id>/cpu?format=line'
+//
 // Note that the example script above comes from the
 // [scores](https://github.com/influxdata/kapacitor/tree/master/examples/scores) example.
 // See the complete scores example for a concrete demonstration.
@@ -34,6 +72,24 @@ type HTTPOutNode struct {
 	// The relative path where the cached data is exposed
 	// tick:ignore
 	Endpoint string `json:"endpoint"`
+
+	// The fields to include in the cached data.
+	// If empty all fields are included.
+	// tick:ignore
+	FieldsList []string `tick:"Fields" json:"fields"`
+
+	// The tags to include in the cached data.
+	// If empty all tags are included.
+	// tick:ignore
+	TagsList []string `tick:"Tags" json:"tags"`
+
+	// Serve the cached data as points including all tags and typed fields.
+	// tick:ignore
+	FieldsAndTagsFlag bool `tick:"FieldsAndTags" json:"fieldsAndTags"`
+
+	// Indent the served JSON.
+	// tick:ignore
+	PrettyFlag bool `tick:"Pretty" json:"pretty"`
 }
 
 func newHTTPOutNode(wants EdgeType, endpoint string) *HTTPOutNode {
@@ -43,6 +99,34 @@ func newHTTPOutNode(wants EdgeType, endpoint string) *HTTPOutNode {
 	}
 }
 
+// Include only the specified fields in the cached data.
+// tick:property
+func (n *HTTPOutNode) Fields(fields ...string) *HTTPOutNode {
+	n.FieldsList = fields
+	return n
+}
+
+// Include only the specified tags in the cached data.
+// tick:property
+func (n *HTTPOutNode) Tags(tags ...string) *HTTPOutNode {
+	n.TagsList = tags
+	return n
+}
+
+// Serve the cached data as points including the measurement, time, all tags and typed fields.
+// tick:property
+func (n *HTTPOutNode) FieldsAndTags() *HTTPOutNode {
+	n.FieldsAndTagsFlag = true
+	return n
+}
+
+// Indent the served JSON.
+// tick:property
+func (n *HTTPOutNode) Pretty() *HTTPOutNode {
+	n.PrettyFlag = true
+	return n
+}
+
 // MarshalJSON converts HTTPOutNode to JSON
 // tick:ignore
 func (n *HTTPOutNode) MarshalJSON() ([]byte, error) {
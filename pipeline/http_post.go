@@ -34,6 +34,21 @@ import (
 //	    //Post the top 10 results over the last 10s updated every 5s.
 //	    |httpPost()
 //	        .endpoint('example')
+//
+// Each point or batch is posted in its own request by default.
+// For high rate streams the data can be accumulated and posted as a single JSON array of rows
+// once the batch size is reached or the batch interval has elapsed, whichever happens first.
+// Any accumulated rows are posted when the task is stopped.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |httpPost('http://example.com/api/bulk')
+//	        .batchSize(500)
+//	        .batchInterval(5s)
+//	        .retryTimeout(1m)
 type HTTPPostNode struct {
 	chainnode
 
@@ -56,12 +71,37 @@ type HTTPPostNode struct {
 
 	// Timeout for HTTP Post
 	Timeout time.Duration `json:"timeout"`
+
+	// Number of rows to accumulate before posting them together as a JSON array.
+	// Zero posts each point or batch in its own request.
+	// Default: 0
+	BatchSize int64 `json:"batchSize"`
+
+	// Post the accumulated rows after the interval even if the batch size has not been reached.
+	// Default: 10s
+	BatchInterval time.Duration `json:"batchInterval"`
+
+	// Accumulate the rows of each group separately instead of the rows of all groups together.
+	// tick:ignore
+	BatchByGroupFlag bool `tick:"BatchByGroup" json:"batchByGroup"`
+
+	// Maximum time to retry posting accumulated rows, with exponential backoff,
+	// before the rows are dropped.
+	// Posts are retried on connection errors and 5xx status codes.
+	// While retrying no new rows are accumulated.
+	// A value of zero disables retries.
+	// Default: 0s
+	RetryTimeout time.Duration `json:"retryTimeout"`
 }
 
+// DefaultHTTPPostBatchInterval is the default interval after which accumulated rows are posted.
+const DefaultHTTPPostBatchInterval = 10 * time.Second
+
 func newHTTPPostNode(wants EdgeType, urls ...string) *HTTPPostNode {
 	return &HTTPPostNode{
-		chainnode: newBasicChainNode("http_post", wants, wants),
-		URLs:      urls,
+		chainnode:     newBasicChainNode("http_post", wants, wants),
+		URLs:          urls,
+		BatchInterval: DefaultHTTPPostBatchInterval,
 	}
 }
 
@@ -72,14 +112,18 @@ func (n *HTTPPostNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Timeout string `json:"timeout"`
+		Timeout       string `json:"timeout"`
+		BatchInterval string `json:"batchInterval"`
+		RetryTimeout  string `json:"retryTimeout"`
 	}{
 		TypeOf: TypeOf{
 			Type: "httpPost",
 			ID:   n.ID(),
 		},
-		Alias:   (*Alias)(n),
-		Timeout: influxql.FormatDuration(n.Timeout),
+		Alias:         (*Alias)(n),
+		Timeout:       influxql.FormatDuration(n.Timeout),
+		BatchInterval: influxql.FormatDuration(n.BatchInterval),
+		RetryTimeout:  influxql.FormatDuration(n.RetryTimeout),
 	}
 	return json.Marshal(raw)
 }
@@ -91,6 +135,9 @@ func (n *HTTPPostNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
+		Timeout       string `json:"timeout"`
+		BatchInterval string `json:"batchInterval"`
+		RetryTimeout  string `json:"retryTimeout"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -101,6 +148,21 @@ func (n *HTTPPostNode) UnmarshalJSON(data []byte) error {
 	if raw.Type != "httpPost" {
 		return fmt.Errorf("error unmarshaling node %d of type %s as HTTPPostNode", raw.ID, raw.Type)
 	}
+	for _, d := range []struct {
+		s string
+		d *time.Duration
+	}{
+		{raw.Timeout, &n.Timeout},
+		{raw.BatchInterval, &n.BatchInterval},
+		{raw.RetryTimeout, &n.RetryTimeout},
+	} {
+		if d.s == "" {
+			continue
+		}
+		if *d.d, err = influxql.ParseDuration(d.s); err != nil {
+			return err
+		}
+	}
 	n.setID(raw.ID)
 	return nil
 }
@@ -129,6 +191,22 @@ func (p *HTTPPostNode) validate() error {
 		}
 	}
 
+	if p.BatchSize < 0 {
+		return fmt.Errorf("batchSize must be >= 0, got %d", p.BatchSize)
+	}
+	if p.BatchInterval < 0 {
+		return fmt.Errorf("batchInterval must be >= 0, got %v", p.BatchInterval)
+	}
+	if p.RetryTimeout < 0 {
+		return fmt.Errorf("retryTimeout must be >= 0, got %v", p.RetryTimeout)
+	}
+	if p.BatchSize == 0 && (p.BatchByGroupFlag || p.RetryTimeout > 0) {
+		return errors.New("batchByGroup and retryTimeout require a batchSize")
+	}
+	if p.BatchSize > 0 && p.CodeField != "" {
+		return errors.New("codeField cannot be used with a batchSize, the status code is not known until the rows are posted")
+	}
+
 	return nil
 }
 
@@ -165,6 +243,14 @@ func (p *HTTPPostNode) Header(k, v string) *HTTPPostNode {
 	return p
 }
 
+// Accumulate the rows of each group separately and post them in separate requests.
+// By default the rows of all groups are accumulated and posted together.
+// tick:property
+func (p *HTTPPostNode) BatchByGroup() *HTTPPostNode {
+	p.BatchByGroupFlag = true
+	return p
+}
+
 // CaptureResponse indicates that the HTTP response should be read and logged if
 // the status code was not an 2xx code.
 // tick:property
@@ -28,10 +28,32 @@ const DefaultFlushInterval = time.Second * 10
 //	        .tag('kapacitor', 'true')
 //	        .tag('version', '0.2')
 //
+// Points are written to InfluxDB 2.x using the bucket of the database and retention policy,
+// named database/retention-policy, when the cluster is configured with api-version 2.
+// A bucket and organization can be set explicitly instead:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |influxDBOut()
+//	        .cluster('influxdb2')
+//	        .bucket('requests')
+//	        .org('myorg')
+//	        .measurement('requests')
+//
+// Points are buffered and written to InfluxDB once the buffer is full
+// or the flush interval has elapsed, whichever happens first.
+// Any buffered points are written when the task is stopped.
+//
+// Array fields, see EvalNode, cannot be stored in InfluxDB and are not written.
+// Points that only have array fields are not written.
+//
 // Available Statistics:
 //
 //   - points_written -- number of points written to InfluxDB
 //   - write_errors -- number of errors attempting to write to InfluxDB
+//   - points_dropped -- number of points dropped after all write attempts failed
+//   - points_buffered -- number of points currently buffered waiting to be written
 type InfluxDBOutNode struct {
 	node `json:"-"`
 
@@ -42,11 +64,24 @@ type InfluxDBOutNode struct {
 	Database string `json:"database"`
 	// The name of the retention policy.
 	RetentionPolicy string `json:"retentionPolicy"`
+	// The name of the InfluxDB 2.x bucket.
+	// If set the points are written using the InfluxDB 2.x write API,
+	// the database and retention policy are ignored.
+	Bucket string `json:"bucket"`
+	// The InfluxDB 2.x organization of the bucket.
+	// If empty the organization of the cluster will be used.
+	Org string `json:"org"`
+	// The InfluxDB 2.x organization ID of the bucket.
+	// If empty the organization of the cluster will be used.
+	OrgID string `json:"orgid"`
 	// The name of the measurement.
 	Measurement string `json:"measurement"`
 	// The write consistency to use when writing the data.
 	WriteConsistency string `json:"writeConsistency"`
-	// The precision to use when writing the data.
+	// The precision to use when writing the data,
+	// one of ns, u, ms, s, m or h, u may also be written us.
+	// The times of the points are rounded down to the precision.
+	// Default: ns
 	Precision string `json:"precision"`
 	// Number of points to buffer when writing to InfluxDB.
 	// Default: 1000
@@ -54,6 +89,12 @@ type InfluxDBOutNode struct {
 	// Write points to InfluxDB after interval even if buffer is not full.
 	// Default: 10s
 	FlushInterval time.Duration `json:"flushInterval"`
+	// Maximum time to retry a failed write, with exponential backoff,
+	// before the points are dropped.
+	// While retrying no new points are buffered.
+	// A value of zero disables retries.
+	// Default: 0s
+	RetryTimeout time.Duration `json:"retryTimeout"`
 	// Static set of tags to add to all data points before writing them.
 	// tick:ignore
 	Tags map[string]string `tick:"Tag" json:"tags"`
@@ -83,6 +124,7 @@ func (n *InfluxDBOutNode) MarshalJSON() ([]byte, error) {
 		TypeOf
 		*Alias
 		FlushInterval string `json:"flushInterval"`
+		RetryTimeout  string `json:"retryTimeout"`
 	}{
 		TypeOf: TypeOf{
 			Type: "influxdbOut",
@@ -90,6 +132,7 @@ func (n *InfluxDBOutNode) MarshalJSON() ([]byte, error) {
 		},
 		Alias:         (*Alias)(n),
 		FlushInterval: influxql.FormatDuration(n.FlushInterval),
+		RetryTimeout:  influxql.FormatDuration(n.RetryTimeout),
 	}
 	return json.Marshal(raw)
 }
@@ -102,6 +145,7 @@ func (n *InfluxDBOutNode) UnmarshalJSON(data []byte) error {
 		TypeOf
 		*Alias
 		FlushInterval string `json:"flushInterval"`
+		RetryTimeout  string `json:"retryTimeout"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -116,10 +160,28 @@ func (n *InfluxDBOutNode) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if raw.RetryTimeout != "" {
+		n.RetryTimeout, err = influxql.ParseDuration(raw.RetryTimeout)
+		if err != nil {
+			return err
+		}
+	}
 	n.setID(raw.ID)
 	return nil
 }
 
+func (i *InfluxDBOutNode) validate() error {
+	switch i.Precision {
+	case "", "n", "ns", "u", "us", "µs", "ms", "s", "m", "h":
+	default:
+		return fmt.Errorf("invalid precision %q, must be one of ns, u, ms, s, m or h", i.Precision)
+	}
+	if i.RetryTimeout < 0 {
+		return fmt.Errorf("retryTimeout must be non-negative, got %v", i.RetryTimeout)
+	}
+	return nil
+}
+
 // Add a static tag to all data points.
 // Tag can be called more then once.
 //
@@ -134,6 +196,7 @@ func (i *InfluxDBOutNode) Tag(key, value string) *InfluxDBOutNode {
 // If the retention policy name is empty then no
 // retention policy will be specified and
 // the default retention policy name will be created.
+// If a bucket is set the bucket is created instead.
 //
 // If the database already exists nothing happens.
 //
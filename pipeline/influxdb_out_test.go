@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInfluxDBOutNode_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		i    *InfluxDBOutNode
+		err  string
+	}{
+		{
+			name: "defaults",
+			i:    &InfluxDBOutNode{},
+		},
+		{
+			name: "retry timeout",
+			i:    &InfluxDBOutNode{Precision: "s", RetryTimeout: time.Minute},
+		},
+		{
+			name: "invalid precision",
+			i:    &InfluxDBOutNode{Precision: "d"},
+			err:  `invalid precision "d"`,
+		},
+		{
+			name: "negative retry timeout",
+			i:    &InfluxDBOutNode{RetryTimeout: -time.Second},
+			err:  "retryTimeout must be non-negative, got -1s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.i.validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("unexpected error got %v exp %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/influxdb/query"
 	"github.com/influxdata/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
 )
 
 // tmpl -- go get github.com/benbjohnson/tmpl
@@ -53,6 +54,20 @@ type InfluxQLNode struct {
 
 	// tick:ignore
 	Args []interface{} `json:"args"`
+
+	// Compression of the t-digest used to approximate the median or percentile.
+	// If 0 the exact value is computed.
+	// tick:ignore
+	Compression float64 `tick:"Approximate" json:"compression"`
+
+	// Precision of the HyperLogLog sketch used to approximate distinctCount.
+	// If 0 the distinct values are counted exactly.
+	// tick:ignore
+	DistinctPrecision int64 `tick:"Precision" json:"precision"`
+
+	// The predicate selecting the points aggregated by countIf, sumIf and meanIf.
+	// tick:ignore
+	Predicate *ast.LambdaNode `json:"predicate"`
 }
 
 func newInfluxQLNode(method, field string, wants, provides EdgeType, reducer ReduceCreater) *InfluxQLNode {
@@ -109,6 +124,8 @@ func (n *InfluxQLNode) UnmarshalJSON(data []byte) error {
 	}
 	switch raw.Type {
 	case "count", "distinct", "mean", "median", "mode", "spread", "sum", "first":
+	case "distinctCount", "entropy", "timeWeightedMean":
+	case "countIf", "sumIf", "meanIf":
 	case "last", "min", "max", "stddev", "difference", "cumulativeSum":
 	case "top", "bottom", "movingAverage":
 		for i, arg := range raw.Args {
@@ -153,10 +170,102 @@ func (n *InfluxQLNode) UnmarshalJSON(data []byte) error {
 	}
 	n.Args = raw.Args
 	n.Method = raw.Type
+	if n.Compression != 0 {
+		n.Approximate(n.Compression)
+	}
+	if n.DistinctPrecision != 0 {
+		n.Precision(n.DistinctPrecision)
+	}
 	n.setID(raw.ID)
 	return nil
 }
 
+func (n *InfluxQLNode) validate() error {
+	switch n.Method {
+	case "countIf", "sumIf", "meanIf":
+		if n.Predicate == nil {
+			return fmt.Errorf("%s requires a predicate", n.Method)
+		}
+	}
+	if n.DistinctPrecision != 0 {
+		if n.Method != "distinctCount" {
+			return fmt.Errorf("precision is only supported by distinctCount, not %s", n.Method)
+		}
+		if n.DistinctPrecision < minDistinctCountPrecision || n.DistinctPrecision > maxDistinctCountPrecision {
+			return fmt.Errorf("precision must be between %d and %d, got %d", minDistinctCountPrecision, maxDistinctCountPrecision, n.DistinctPrecision)
+		}
+	}
+	if n.Compression == 0 {
+		return nil
+	}
+	if n.Method != "median" && n.Method != "percentile" {
+		return fmt.Errorf("approximate is only supported by median and percentile, not %s", n.Method)
+	}
+	if n.Compression < 0 {
+		return fmt.Errorf("compression must be positive, got %v", n.Compression)
+	}
+	return nil
+}
+
+// Approximate the median or percentile with a t-digest of the given compression instead of computing the exact value.
+//
+// Computing the exact value requires sorting the values of all points of a batch,
+// while the t-digest summarizes them in memory bounded by the compression.
+// This only bounds the memory of the reduction: the points are still folded into the digest batch by batch,
+// so the WindowNode, or the query of a batch task, still buffers every point of each window.
+// Higher compression is more accurate and uses more memory, a compression of 100 is a good default.
+// The error is smallest for quantiles close to 0 and 100 and largest for the median,
+// in the order of 1/compression of the range of the values.
+//
+// Approximate values are interpolated so percentile is no longer a selector,
+// the result is a float field with the time of the batch, like for median.
+//
+// Example:
+//
+//	stream
+//	    |window()
+//	        .period(1h)
+//	        .every(1m)
+//	    |percentile('latency', 99.0)
+//	        .approximate(100.0)
+//
+// tick:property
+func (n *InfluxQLNode) Approximate(compression float64) *InfluxQLNode {
+	n.Compression = compression
+	switch n.Method {
+	case "median":
+		n.ReduceCreater = approximateReduceCreater(0.5, compression)
+	case "percentile":
+		if len(n.Args) == 1 {
+			if percentile, ok := n.Args[0].(float64); ok {
+				n.ReduceCreater = approximateReduceCreater(percentile/100, compression)
+			}
+		}
+	}
+	return n
+}
+
+// Approximate the distinctCount with a HyperLogLog sketch of the given precision, between 4 and 18,
+// instead of counting the distinct values exactly, see DistinctCount.
+//
+// Example:
+//
+//	stream
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |distinctCount('source_ip')
+//	        .precision(14)
+//
+// tick:property
+func (n *InfluxQLNode) Precision(precision int64) *InfluxQLNode {
+	n.DistinctPrecision = precision
+	if n.Method == "distinctCount" && precision >= minDistinctCountPrecision && precision <= maxDistinctCountPrecision {
+		n.ReduceCreater = distinctCountReduceCreater(uint8(precision))
+	}
+	return n
+}
+
 // Use the time of the selected point instead of the time of the batch.
 //
 // Only applies to selector functions like first, last, top, bottom, etc.
@@ -173,7 +282,13 @@ func (n *InfluxQLNode) UsePointTimes() *InfluxQLNode {
 
 // Count the number of points.
 func (n *chainnode) Count(field string) *InfluxQLNode {
-	i := newInfluxQLNode("count", field, n.Provides(), StreamEdge, ReduceCreater{
+	i := newInfluxQLNode("count", field, n.Provides(), StreamEdge, countReduceCreater())
+	n.linkChild(i)
+	return i
+}
+
+func countReduceCreater() ReduceCreater {
+	return ReduceCreater{
 		CreateFloatIntegerReducer: func() (query.FloatPointAggregator, query.IntegerPointEmitter) {
 			fn := query.NewFloatFuncIntegerReducer(query.FloatCountReduce, &query.IntegerPoint{Value: 0})
 			return fn, fn
@@ -191,9 +306,7 @@ func (n *chainnode) Count(field string) *InfluxQLNode {
 			return fn, fn
 		},
 		IsEmptyOK: true,
-	})
-	n.linkChild(i)
-	return i
+	}
 }
 
 // Produce batch of only the distinct points.
@@ -220,9 +333,101 @@ func (n *chainnode) Distinct(field string) *InfluxQLNode {
 	return i
 }
 
+const (
+	minDistinctCountPrecision = 4
+	maxDistinctCountPrecision = 18
+)
+
+// Count the number of distinct values.
+// Values of any type are counted, if no point has a value the count is 0.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('connections')
+//	        .groupBy('destination')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |distinctCount('source_ip')
+//	        .as('sources')
+//	    |alert()
+//	        .warn(lambda: "sources" > 1000)
+//
+// Warn when more than a thousand distinct sources connect to a destination within a minute.
+//
+// The distinct values are counted exactly by default, which keeps every distinct value of the batch in memory.
+// With `.precision(precision)` large counts are approximated with a HyperLogLog sketch of the precision,
+// an integer between 4 and 18, so that the memory is bounded:
+// the values are counted exactly until there are more than 2^(precision-4) of them,
+// then the count is estimated by a sketch of 2^precision registers of a byte each.
+// The standard error of the estimation is about 1.04/sqrt(2^precision), i.e. 0.8% with a precision of 14 using 16KB.
+//
+// Note that the WindowNode still buffers the points of each window.
+func (n *chainnode) DistinctCount(field string) *InfluxQLNode {
+	i := newInfluxQLNode("distinctCount", field, n.Provides(), StreamEdge, distinctCountReduceCreater(0))
+	n.linkChild(i)
+	return i
+}
+
+// Compute the Shannon entropy of the values, in bits.
+//
+// The entropy is 0 when all values are equal and log2(n) when the values are n distinct values equally often,
+// so a sudden change of the entropy of a field shows a change of the distribution of its values,
+// such as many new sources or a single source flooding.
+// Values of any type are supported, if no point has a value no point is emitted.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |window()
+//	        .period(5m)
+//	        .every(1m)
+//	    |entropy('client_ip')
+//
+// The entropy is exact, it keeps each distinct value of the batch and its count in memory.
+func (n *chainnode) Entropy(field string) *InfluxQLNode {
+	i := newInfluxQLNode("entropy", field, n.Provides(), StreamEdge, entropyReduceCreater())
+	n.linkChild(i)
+	return i
+}
+
+// Compute the mean of the data weighted by time.
+// Each value is weighted by the duration until the time of the next point,
+// so that irregularly sampled data is not biased towards the periods with more samples.
+// The last value is held until the end of the batch, which is the end of the window,
+// and is not weighted if it is at or after the end, e.g. when there is no window.
+// With a single point, or if no value holds for any duration, the mean of the values is emitted.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('temperature')
+//	    |window()
+//	        .period(1h)
+//	        .every(1h)
+//	    |timeWeightedMean('value')
+//
+// Only float and integer fields are supported, if no point has a value no point is emitted.
+func (n *chainnode) TimeWeightedMean(field string) *InfluxQLNode {
+	i := newInfluxQLNode("timeWeightedMean", field, n.Provides(), StreamEdge, timeWeightedMeanReduceCreater())
+	n.linkChild(i)
+	return i
+}
+
 // Compute the mean of the data.
 func (n *chainnode) Mean(field string) *InfluxQLNode {
-	i := newInfluxQLNode("mean", field, n.Provides(), StreamEdge, ReduceCreater{
+	i := newInfluxQLNode("mean", field, n.Provides(), StreamEdge, meanReduceCreater())
+	n.linkChild(i)
+	return i
+}
+
+func meanReduceCreater() ReduceCreater {
+	return ReduceCreater{
 		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
 			fn := query.NewFloatMeanReducer()
 			return fn, fn
@@ -231,9 +436,7 @@ func (n *chainnode) Mean(field string) *InfluxQLNode {
 			fn := query.NewIntegerMeanReducer()
 			return fn, fn
 		},
-	})
-	n.linkChild(i)
-	return i
+	}
 }
 
 // Compute the median of the data. Note, this method is not a selector,
@@ -289,7 +492,13 @@ func (n *chainnode) Spread(field string) *InfluxQLNode {
 
 // Compute the sum of all values.
 func (n *chainnode) Sum(field string) *InfluxQLNode {
-	i := newInfluxQLNode("sum", field, n.Provides(), StreamEdge, ReduceCreater{
+	i := newInfluxQLNode("sum", field, n.Provides(), StreamEdge, sumReduceCreater())
+	n.linkChild(i)
+	return i
+}
+
+func sumReduceCreater() ReduceCreater {
+	return ReduceCreater{
 		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
 			fn := query.NewFloatFuncReducer(query.FloatSumReduce, &query.FloatPoint{Value: 0})
 			return fn, fn
@@ -299,7 +508,63 @@ func (n *chainnode) Sum(field string) *InfluxQLNode {
 			return fn, fn
 		},
 		IsEmptyOK: true,
-	})
+	}
+}
+
+//------------------------------------
+// Conditional Aggregation Functions
+//
+
+// Count the number of points for which the predicate is true.
+// If no point matches the count is 0.
+//
+// Example:
+//
+//	batch
+//	    |query('SELECT status FROM "telegraf"."autogen"."requests"')
+//	        .period(1m)
+//	        .every(1m)
+//	    |countIf(lambda: "status" >= 500)
+//	        .as('errors')
+func (n *chainnode) CountIf(predicate *ast.LambdaNode) *InfluxQLNode {
+	i := newInfluxQLNode("countIf", "", n.Provides(), StreamEdge, countReduceCreater())
+	i.Predicate = predicate
+	n.linkChild(i)
+	return i
+}
+
+// Compute the sum of the values of the points for which the predicate is true.
+// If no point matches the sum is 0.
+//
+// Example:
+//
+//	batch
+//	    |query('SELECT bytes, status FROM "telegraf"."autogen"."requests"')
+//	        .period(1m)
+//	        .every(1m)
+//	    |sumIf('bytes', lambda: "status" == 200)
+//	        .as('ok_bytes')
+func (n *chainnode) SumIf(field string, predicate *ast.LambdaNode) *InfluxQLNode {
+	i := newInfluxQLNode("sumIf", field, n.Provides(), StreamEdge, sumReduceCreater())
+	i.Predicate = predicate
+	n.linkChild(i)
+	return i
+}
+
+// Compute the mean of the values of the points for which the predicate is true.
+// If no point matches the mean is null, so no point is emitted.
+//
+// Example:
+//
+//	batch
+//	    |query('SELECT latency, status FROM "telegraf"."autogen"."requests"')
+//	        .period(1m)
+//	        .every(1m)
+//	    |meanIf('latency', lambda: "status" >= 500)
+//	        .as('error_latency')
+func (n *chainnode) MeanIf(field string, predicate *ast.LambdaNode) *InfluxQLNode {
+	i := newInfluxQLNode("meanIf", field, n.Provides(), StreamEdge, meanReduceCreater())
+	i.Predicate = predicate
 	n.linkChild(i)
 	return i
 }
@@ -476,12 +741,13 @@ func (n *chainnode) Bottom(num int64, field string, fieldsAndTags ...string) *In
 // Compute the standard deviation.
 func (n *chainnode) Stddev(field string) *InfluxQLNode {
 	i := newInfluxQLNode("stddev", field, n.Provides(), StreamEdge, ReduceCreater{
+		// The standard deviation is computed online so the points are not buffered.
 		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
-			fn := query.NewFloatSliceFuncReducer(query.FloatStddevReduceSlice)
+			fn := &floatStddevReducer{}
 			return fn, fn
 		},
 		CreateIntegerFloatReducer: func() (query.IntegerPointAggregator, query.FloatPointEmitter) {
-			fn := query.NewIntegerSliceFuncFloatReducer(query.IntegerStddevReduceSlice)
+			fn := &integerStddevReducer{}
 			return fn, fn
 		},
 	})
@@ -0,0 +1,393 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/influxdata/influxdb/pkg/estimator/hll"
+	"github.com/influxdata/influxdb/query"
+	"github.com/influxdata/tdigest"
+)
+
+// welford computes the mean and variance of values with Welford's online algorithm,
+// so that the values do not need to be buffered.
+type welford struct {
+	count float64
+	mean  float64
+	m2    float64
+}
+
+func (w *welford) add(v float64) {
+	w.count++
+	delta := v - w.mean
+	w.mean += delta / w.count
+	w.m2 += delta * (v - w.mean)
+}
+
+// stddev returns the sample standard deviation, or NaN if there are less than two values.
+func (w *welford) stddev() float64 {
+	if w.count < 2 {
+		return math.NaN()
+	}
+	return math.Sqrt(w.m2 / (w.count - 1))
+}
+
+// floatStddevReducer computes the sample standard deviation of float points.
+type floatStddevReducer struct {
+	w welford
+}
+
+func (r *floatStddevReducer) AggregateFloat(p *query.FloatPoint) {
+	if math.IsNaN(p.Value) {
+		return
+	}
+	r.w.add(p.Value)
+}
+
+func (r *floatStddevReducer) Emit() []query.FloatPoint {
+	return []query.FloatPoint{{
+		Time:  query.ZeroTime,
+		Value: r.w.stddev(),
+	}}
+}
+
+// integerStddevReducer computes the sample standard deviation of integer points.
+type integerStddevReducer struct {
+	w welford
+}
+
+func (r *integerStddevReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.w.add(float64(p.Value))
+}
+
+func (r *integerStddevReducer) Emit() []query.FloatPoint {
+	return []query.FloatPoint{{
+		Time:  query.ZeroTime,
+		Value: r.w.stddev(),
+	}}
+}
+
+// quantileReducer approximates a quantile of the aggregated points with a t-digest,
+// which uses bounded memory regardless of the number of points.
+type quantileReducer struct {
+	quantile float64
+	digest   *tdigest.TDigest
+}
+
+func newQuantileReducer(quantile, compression float64) *quantileReducer {
+	return &quantileReducer{
+		quantile: quantile,
+		digest:   tdigest.NewWithCompression(compression),
+	}
+}
+
+func (r *quantileReducer) AggregateFloat(p *query.FloatPoint) {
+	if math.IsNaN(p.Value) {
+		return
+	}
+	r.digest.Add(p.Value, 1)
+}
+
+func (r *quantileReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.digest.Add(float64(p.Value), 1)
+}
+
+func (r *quantileReducer) Emit() []query.FloatPoint {
+	if r.digest.Count() == 0 {
+		return nil
+	}
+	return []query.FloatPoint{{
+		Time:  query.ZeroTime,
+		Value: r.digest.Quantile(r.quantile),
+	}}
+}
+
+// approximateReduceCreater returns a ReduceCreater that approximates the quantile with the given compression.
+func approximateReduceCreater(quantile, compression float64) ReduceCreater {
+	return ReduceCreater{
+		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
+			fn := newQuantileReducer(quantile, compression)
+			return fn, fn
+		},
+		CreateIntegerFloatReducer: func() (query.IntegerPointAggregator, query.FloatPointEmitter) {
+			fn := newQuantileReducer(quantile, compression)
+			return fn, fn
+		},
+	}
+}
+
+// encodeFloat, encodeInteger and encodeBoolean encode values as the keys of distinctCount and entropy.
+func encodeFloat(v float64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+	return string(b[:])
+}
+
+func encodeInteger(v int64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	return string(b[:])
+}
+
+func encodeBoolean(v bool) string {
+	if v {
+		return "t"
+	}
+	return "f"
+}
+
+// distinctCountReducer counts the distinct values of the aggregated points.
+// The values are counted exactly, unless a precision is set:
+// then they are counted exactly until there are more than the exact limit of them,
+// and approximated with a HyperLogLog sketch of the precision from then on.
+type distinctCountReducer struct {
+	precision uint8
+	exact     map[string]struct{}
+	sketch    *hll.Plus
+}
+
+func newDistinctCountReducer(precision uint8) *distinctCountReducer {
+	return &distinctCountReducer{
+		precision: precision,
+		exact:     make(map[string]struct{}),
+	}
+}
+
+// distinctCountExactLimit returns the number of values counted exactly before switching to a sketch of the precision,
+// about the number of values whose set uses as much memory as the sketch.
+func distinctCountExactLimit(precision uint8) int {
+	return 1 << (precision - 4)
+}
+
+func (r *distinctCountReducer) add(key string) {
+	if r.sketch != nil {
+		r.sketch.Add([]byte(key))
+		return
+	}
+	r.exact[key] = struct{}{}
+	if r.precision == 0 || len(r.exact) <= distinctCountExactLimit(r.precision) {
+		return
+	}
+	sketch, err := hll.NewPlus(r.precision)
+	if err != nil {
+		// The precision is validated, keep counting exactly.
+		r.precision = 0
+		return
+	}
+	for k := range r.exact {
+		sketch.Add([]byte(k))
+	}
+	r.sketch = sketch
+	r.exact = nil
+}
+
+func (r *distinctCountReducer) AggregateFloat(p *query.FloatPoint) {
+	if math.IsNaN(p.Value) {
+		return
+	}
+	r.add(encodeFloat(p.Value))
+}
+
+func (r *distinctCountReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.add(encodeInteger(p.Value))
+}
+
+func (r *distinctCountReducer) AggregateString(p *query.StringPoint) {
+	r.add(p.Value)
+}
+
+func (r *distinctCountReducer) AggregateBoolean(p *query.BooleanPoint) {
+	r.add(encodeBoolean(p.Value))
+}
+
+func (r *distinctCountReducer) Emit() []query.IntegerPoint {
+	count := int64(len(r.exact))
+	if r.sketch != nil {
+		count = int64(r.sketch.Count())
+	}
+	return []query.IntegerPoint{{
+		Time:  query.ZeroTime,
+		Value: count,
+	}}
+}
+
+// distinctCountReduceCreater returns a ReduceCreater that counts the distinct values,
+// approximately from the exact limit of the precision if it is not 0.
+func distinctCountReduceCreater(precision uint8) ReduceCreater {
+	return ReduceCreater{
+		CreateFloatIntegerReducer: func() (query.FloatPointAggregator, query.IntegerPointEmitter) {
+			fn := newDistinctCountReducer(precision)
+			return fn, fn
+		},
+		CreateIntegerReducer: func() (query.IntegerPointAggregator, query.IntegerPointEmitter) {
+			fn := newDistinctCountReducer(precision)
+			return fn, fn
+		},
+		CreateStringIntegerReducer: func() (query.StringPointAggregator, query.IntegerPointEmitter) {
+			fn := newDistinctCountReducer(precision)
+			return fn, fn
+		},
+		CreateBooleanIntegerReducer: func() (query.BooleanPointAggregator, query.IntegerPointEmitter) {
+			fn := newDistinctCountReducer(precision)
+			return fn, fn
+		},
+		IsEmptyOK: true,
+	}
+}
+
+// entropyReducer computes the Shannon entropy in bits of the distribution of the values of the aggregated points.
+type entropyReducer struct {
+	counts map[string]int64
+	total  int64
+}
+
+func newEntropyReducer() *entropyReducer {
+	return &entropyReducer{
+		counts: make(map[string]int64),
+	}
+}
+
+func (r *entropyReducer) add(key string) {
+	r.counts[key]++
+	r.total++
+}
+
+func (r *entropyReducer) AggregateFloat(p *query.FloatPoint) {
+	if math.IsNaN(p.Value) {
+		return
+	}
+	r.add(encodeFloat(p.Value))
+}
+
+func (r *entropyReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.add(encodeInteger(p.Value))
+}
+
+func (r *entropyReducer) AggregateString(p *query.StringPoint) {
+	r.add(p.Value)
+}
+
+func (r *entropyReducer) AggregateBoolean(p *query.BooleanPoint) {
+	r.add(encodeBoolean(p.Value))
+}
+
+func (r *entropyReducer) Emit() []query.FloatPoint {
+	if r.total == 0 {
+		return nil
+	}
+	// Sum in the order of the counts so that the result does not depend on the order of the map.
+	counts := make([]int64, 0, len(r.counts))
+	for _, count := range r.counts {
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+	var entropy float64
+	total := float64(r.total)
+	for _, count := range counts {
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+	return []query.FloatPoint{{
+		Time:  query.ZeroTime,
+		Value: entropy,
+	}}
+}
+
+func entropyReduceCreater() ReduceCreater {
+	return ReduceCreater{
+		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
+			fn := newEntropyReducer()
+			return fn, fn
+		},
+		CreateIntegerFloatReducer: func() (query.IntegerPointAggregator, query.FloatPointEmitter) {
+			fn := newEntropyReducer()
+			return fn, fn
+		},
+		CreateStringFloatReducer: func() (query.StringPointAggregator, query.FloatPointEmitter) {
+			fn := newEntropyReducer()
+			return fn, fn
+		},
+		CreateBooleanFloatReducer: func() (query.BooleanPointAggregator, query.FloatPointEmitter) {
+			fn := newEntropyReducer()
+			return fn, fn
+		},
+	}
+}
+
+// EndSetter is implemented by reducers that use the end of the batch they reduce.
+// The end is set, in nanoseconds since the epoch, before the reducer emits.
+type EndSetter interface {
+	SetEnd(end int64)
+}
+
+type timeWeightedValue struct {
+	time  int64
+	value float64
+}
+
+// timeWeightedMeanReducer computes the mean of the values of the aggregated points,
+// each weighted by the duration it holds until the time of the next point.
+// The last value holds until the end of the batch if the end is after it.
+// If no value holds for any duration, e.g. a single point at the end of the batch, the mean is the arithmetic mean.
+type timeWeightedMeanReducer struct {
+	values []timeWeightedValue
+	end    int64
+}
+
+func (r *timeWeightedMeanReducer) SetEnd(end int64) {
+	r.end = end
+}
+
+func (r *timeWeightedMeanReducer) AggregateFloat(p *query.FloatPoint) {
+	if math.IsNaN(p.Value) {
+		return
+	}
+	r.values = append(r.values, timeWeightedValue{time: p.Time, value: p.Value})
+}
+
+func (r *timeWeightedMeanReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.values = append(r.values, timeWeightedValue{time: p.Time, value: float64(p.Value)})
+}
+
+func (r *timeWeightedMeanReducer) Emit() []query.FloatPoint {
+	if len(r.values) == 0 {
+		return nil
+	}
+	// Points of a batch are usually ordered, the sort is stable so that of points with the same time the last one holds.
+	sort.SliceStable(r.values, func(i, j int) bool { return r.values[i].time < r.values[j].time })
+	var sum, duration, total float64
+	for i, v := range r.values {
+		total += v.value
+		next := r.end
+		if i+1 < len(r.values) {
+			next = r.values[i+1].time
+		}
+		if next > v.time {
+			d := float64(next - v.time)
+			sum += v.value * d
+			duration += d
+		}
+	}
+	value := total / float64(len(r.values))
+	if duration > 0 {
+		value = sum / duration
+	}
+	return []query.FloatPoint{{
+		Time:  query.ZeroTime,
+		Value: value,
+	}}
+}
+
+func timeWeightedMeanReduceCreater() ReduceCreater {
+	return ReduceCreater{
+		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
+			fn := &timeWeightedMeanReducer{}
+			return fn, fn
+		},
+		CreateIntegerFloatReducer: func() (query.IntegerPointAggregator, query.FloatPointEmitter) {
+			fn := &timeWeightedMeanReducer{}
+			return fn, fn
+		},
+	}
+}
@@ -0,0 +1,186 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/influxdata/influxdb/query"
+)
+
+func TestStddevReducer(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	points := make([]query.FloatPoint, 1000)
+	for i := range points {
+		points[i] = query.FloatPoint{Value: 1e6 + r.NormFloat64()*10}
+	}
+
+	fr := &floatStddevReducer{}
+	for i := range points {
+		fr.AggregateFloat(&points[i])
+	}
+	exp := query.FloatStddevReduceSlice(points)[0].Value
+	if got := fr.Emit()[0].Value; math.Abs(got-exp) > 1e-9*exp {
+		t.Errorf("unexpected float stddev got %v exp %v", got, exp)
+	}
+
+	ints := make([]query.IntegerPoint, len(points))
+	ir := &integerStddevReducer{}
+	for i, p := range points {
+		ints[i] = query.IntegerPoint{Value: int64(p.Value)}
+		ir.AggregateInteger(&ints[i])
+	}
+	exp = query.IntegerStddevReduceSlice(ints)[0].Value
+	if got := ir.Emit()[0].Value; math.Abs(got-exp) > 1e-9*exp {
+		t.Errorf("unexpected integer stddev got %v exp %v", got, exp)
+	}
+
+	single := &floatStddevReducer{}
+	single.AggregateFloat(&query.FloatPoint{Value: 1})
+	if got := single.Emit()[0].Value; !math.IsNaN(got) {
+		t.Errorf("expected NaN stddev of a single point, got %v", got)
+	}
+}
+
+func TestQuantileReducer(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	points := make([]query.FloatPoint, 10000)
+	for i := range points {
+		points[i] = query.FloatPoint{Value: r.Float64() * 1000}
+	}
+
+	for _, percentile := range []float64{1, 50, 90, 99} {
+		qr := newQuantileReducer(percentile/100, 100)
+		for i := range points {
+			qr.AggregateFloat(&points[i])
+		}
+		exact := make([]query.FloatPoint, len(points))
+		copy(exact, points)
+		exp := query.NewFloatPercentileReduceSliceFunc(percentile)(exact)[0].Value
+		// Within 1% of the range of the values
+		if got := qr.Emit()[0].Value; math.Abs(got-exp) > 10 {
+			t.Errorf("unexpected %v percentile got %v exp %v", percentile, got, exp)
+		}
+	}
+
+	if got := newQuantileReducer(0.5, 100).Emit(); got != nil {
+		t.Errorf("expected no points for empty reducer, got %v", got)
+	}
+}
+
+func TestDistinctCountReducer(t *testing.T) {
+	exact := newDistinctCountReducer(0)
+	for i := 0; i < 10; i++ {
+		exact.AggregateInteger(&query.IntegerPoint{Value: int64(i % 4)})
+	}
+	if got := exact.Emit()[0].Value; got != 4 {
+		t.Errorf("unexpected exact distinct count got %d exp 4", got)
+	}
+
+	// Counted exactly until the exact limit.
+	small := newDistinctCountReducer(14)
+	for i := 0; i < distinctCountExactLimit(14); i++ {
+		small.AggregateString(&query.StringPoint{Value: fmt.Sprintf("10.0.%d.%d", i/256, i%256)})
+	}
+	if got, exp := small.Emit()[0].Value, int64(distinctCountExactLimit(14)); got != exp || small.sketch != nil {
+		t.Errorf("unexpected small distinct count got %d exp %d", got, exp)
+	}
+
+	approx := newDistinctCountReducer(14)
+	const n = 100000
+	for i := 0; i < 2*n; i++ {
+		approx.AggregateString(&query.StringPoint{Value: fmt.Sprintf("10.%d.%d", i%n/256, i%n%256)})
+	}
+	if approx.sketch == nil {
+		t.Fatal("expected the distinct count to be approximated")
+	}
+	// Within 3% of the count, several times the standard error.
+	if got := approx.Emit()[0].Value; math.Abs(float64(got-n)) > 0.03*n {
+		t.Errorf("unexpected approximate distinct count got %d exp about %d", got, n)
+	}
+
+	if got := newDistinctCountReducer(0).Emit()[0].Value; got != 0 {
+		t.Errorf("expected 0 distinct values for empty reducer, got %d", got)
+	}
+}
+
+func TestEntropyReducer(t *testing.T) {
+	uniform := newEntropyReducer()
+	for i := 0; i < 8; i++ {
+		uniform.AggregateFloat(&query.FloatPoint{Value: float64(i)})
+	}
+	if got := uniform.Emit()[0].Value; math.Abs(got-3) > 1e-12 {
+		t.Errorf("unexpected entropy of 8 distinct values got %v exp 3", got)
+	}
+
+	constant := newEntropyReducer()
+	for i := 0; i < 8; i++ {
+		constant.AggregateBoolean(&query.BooleanPoint{Value: true})
+	}
+	if got := constant.Emit()[0].Value; got != 0 {
+		t.Errorf("unexpected entropy of a constant got %v exp 0", got)
+	}
+
+	if got := newEntropyReducer().Emit(); got != nil {
+		t.Errorf("expected no points for empty reducer, got %v", got)
+	}
+}
+
+func TestTimeWeightedMeanReducer(t *testing.T) {
+	testCases := []struct {
+		name   string
+		points []query.FloatPoint
+		end    int64
+		exp    float64
+	}{
+		{
+			// 10 holds for 1s, 20 for 3s.
+			name:   "irregular",
+			points: []query.FloatPoint{{Time: 0, Value: 10}, {Time: 1, Value: 20}, {Time: 4, Value: 40}},
+			end:    4,
+			exp:    17.5,
+		},
+		{
+			// The last value holds until the end.
+			name:   "extrapolated",
+			points: []query.FloatPoint{{Time: 1, Value: 20}, {Time: 0, Value: 10}},
+			end:    3,
+			exp:    50.0 / 3,
+		},
+		{
+			name:   "single",
+			points: []query.FloatPoint{{Time: 5, Value: 42}},
+			end:    5,
+			exp:    42,
+		},
+		{
+			// No value holds for any duration.
+			name:   "same time",
+			points: []query.FloatPoint{{Time: 5, Value: 1}, {Time: 5, Value: 2}, {Time: 5, Value: math.NaN()}},
+			exp:    1.5,
+		},
+	}
+	for _, tc := range testCases {
+		r := &timeWeightedMeanReducer{}
+		for i := range tc.points {
+			r.AggregateFloat(&tc.points[i])
+		}
+		r.SetEnd(tc.end)
+		if got := r.Emit()[0].Value; math.Abs(got-tc.exp) > 1e-12 {
+			t.Errorf("%s: unexpected time weighted mean got %v exp %v", tc.name, got, tc.exp)
+		}
+	}
+
+	r := &timeWeightedMeanReducer{}
+	r.AggregateInteger(&query.IntegerPoint{Time: 0, Value: 1})
+	r.AggregateInteger(&query.IntegerPoint{Time: 3, Value: 5})
+	r.SetEnd(4)
+	if got := r.Emit()[0].Value; got != 2 {
+		t.Errorf("unexpected time weighted mean of integers got %v exp 2", got)
+	}
+
+	if got := (&timeWeightedMeanReducer{}).Emit(); got != nil {
+		t.Errorf("expected no points for empty reducer, got %v", got)
+	}
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Fills of the missing values at the edges of the batches of an InterpolateNode.
+const (
+	// InterpolateEdgeNull leaves the values before the first and after the last value of the batch missing.
+	InterpolateEdgeNull = "null"
+	// InterpolateEdgeNearest fills the values before the first and after the last value of the batch with the nearest value.
+	InterpolateEdgeNearest = "nearest"
+)
+
+// An InterpolateNode fills the missing values of fields of the points of batches,
+// by linear interpolation in time between the nearest points of the batch with a value.
+// A value is missing if the point does not have the field or the field is null.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('sensors')
+//	        .groupBy('sensor')
+//	    |window()
+//	        .period(10m)
+//	        .every(10m)
+//	    |interpolate('temperature', 'humidity')
+//	        .edge('nearest')
+//	    |mean('temperature')
+//
+// Fill the readings missing from some points of each sensor before computing the mean temperature.
+//
+// Interpolated values are floats, each is the value on the line between the values of the nearest
+// earlier and later points with a value, in proportion to the time of the point between theirs.
+// Only float and integer values are interpolated, points whose value is of another type
+// are left as is and are not used to interpolate the values of other points.
+//
+// The values missing before the first or after the last value of a batch have only one neighbour,
+// they are left missing unless the edge is nearest, which copies the nearest value.
+// If all values of a field are missing in a batch, such as when the field is null in every point,
+// the field stays missing in all the points of the batch, whatever the edge.
+//
+// The points and their order are unchanged, only the missing values are added,
+// so the batches are best sorted by time, as are the batches of windows and queries.
+type InterpolateNode struct {
+	chainnode `json:"-"`
+
+	// The fields to interpolate.
+	// tick:ignore
+	Fields []string `json:"fields"`
+
+	// How to fill the missing values at the edges of the batches, null or nearest.
+	// Defaults to null, which leaves them missing.
+	Edge string `json:"edge"`
+}
+
+func newInterpolateNode(fields []string) *InterpolateNode {
+	return &InterpolateNode{
+		chainnode: newBasicChainNode("interpolate", BatchEdge, BatchEdge),
+		Fields:    fields,
+		Edge:      InterpolateEdgeNull,
+	}
+}
+
+// MarshalJSON converts InterpolateNode to JSON
+// tick:ignore
+func (n *InterpolateNode) MarshalJSON() ([]byte, error) {
+	type Alias InterpolateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "interpolate",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an InterpolateNode
+// tick:ignore
+func (n *InterpolateNode) UnmarshalJSON(data []byte) error {
+	type Alias InterpolateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "interpolate" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as InterpolateNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *InterpolateNode) validate() error {
+	if len(n.Fields) == 0 {
+		return errors.New("must specify at least one field to interpolate")
+	}
+	fields := make(map[string]bool, len(n.Fields))
+	for _, f := range n.Fields {
+		if f == "" {
+			return errors.New("field to interpolate must not be empty")
+		}
+		if fields[f] {
+			return fmt.Errorf("field %q is interpolated more than once", f)
+		}
+		fields[f] = true
+	}
+	switch n.Edge {
+	case InterpolateEdgeNull, InterpolateEdgeNearest:
+	default:
+		return fmt.Errorf("invalid edge %q, must be one of %s or %s", n.Edge, InterpolateEdgeNull, InterpolateEdgeNearest)
+	}
+	return nil
+}
@@ -56,6 +56,27 @@ const (
 //
 // In the above example the `errors` and `requests` streams are joined
 // and then transformed to calculate a combined field.
+//
+// Prefixing can be limited to the fields that collide, and individual fields can be renamed.
+//
+// Example:
+//
+//	var cpu = stream
+//	    |from()
+//	        .measurement('cpu')
+//	var mem = stream
+//	    |from()
+//	        .measurement('mem')
+//	cpu
+//	    |join(mem)
+//	        .as('cpu', 'mem')
+//	        // Only fields present in both parents are prefixed.
+//	        .prefixCollisionsOnly()
+//	        // The "value" field of the mem parent is named "mem_used".
+//	        .rename('mem', 'value', 'mem_used')
+//	    // The "usage_idle" field is only present in the cpu parent and keeps its name.
+//	    |eval(lambda: 100.0 - "usage_idle")
+//	    ...
 type JoinNode struct {
 	chainnode `json:"-"`
 	// The alias names of the two parents.
@@ -73,6 +94,22 @@ type JoinNode struct {
 	// Can be the empty string.
 	Delimiter string `json:"delimiter"`
 
+	// Use the alias names as suffixes instead of prefixes.
+	// tick:ignore
+	SuffixFlag bool `tick:"Suffix" json:"suffix"`
+
+	// Only prefix fields whose names are present in more than one parent.
+	// tick:ignore
+	PrefixCollisionsOnlyFlag bool `tick:"PrefixCollisionsOnly" json:"prefixCollisionsOnly"`
+
+	// Explicit names of fields from the parents.
+	// tick:ignore
+	Renames []JoinRename `tick:"Rename" json:"renames"`
+
+	// Declared names of the fields of the parents.
+	// tick:ignore
+	FieldSets []JoinFields `tick:"Fields" json:"fields"`
+
 	// Deletes both sides of the join regardless what
 	// side receive the delete message.
 	DeleteAll bool `json:"deleteAll"`
@@ -201,6 +238,96 @@ func (j *JoinNode) As(names ...string) *JoinNode {
 	return j
 }
 
+// Use the names provided to .as() as suffixes instead of prefixes.
+// Each field from the parent nodes will be suffixed with the delimiter and the provided name.
+//
+// tick:property
+func (j *JoinNode) Suffix() *JoinNode {
+	j.SuffixFlag = true
+	return j
+}
+
+// Only prefix the names of fields that are present in more than one parent.
+// Fields unique to a single parent keep their original name.
+//
+// The fields of a parent are the fields declared with .fields(),
+// or if none are declared the fields seen so far from that parent.
+// A field that stops being unique because another parent starts providing it is prefixed from then on.
+// Fields of a parent that is filled, see .fill(), are named after the fields of that parent.
+//
+// Fields that would collide with a prefixed name, such as a field "mem.value" from a parent other than "mem",
+// are always prefixed.
+//
+// tick:property
+func (j *JoinNode) PrefixCollisionsOnly() *JoinNode {
+	j.PrefixCollisionsOnlyFlag = true
+	return j
+}
+
+// JoinFields is the declared set of fields of one of the joined parents.
+// tick:ignore
+type JoinFields struct {
+	As     string   `json:"as"`
+	Fields []string `json:"fields"`
+}
+
+// Declare the names of the fields of the parent with the alias as.
+// When only prefixing collisions, see .prefixCollisionsOnly(), the declared fields are used to find the
+// colliding fields instead of the fields seen from the parent, and the resulting field names are validated
+// when the task is defined.
+// Fields of the parent that are not declared are always prefixed.
+//
+// Example:
+//
+//	cpu
+//	    |join(mem)
+//	        .as('cpu', 'mem')
+//	        .prefixCollisionsOnly()
+//	        .fields('cpu', 'usage_idle', 'value')
+//	        .fields('mem', 'used', 'value')
+//
+// The joined fields are "usage_idle", "used", "cpu.value" and "mem.value".
+//
+// tick:property
+func (j *JoinNode) Fields(as string, fields ...string) *JoinNode {
+	j.FieldSets = append(j.FieldSets, JoinFields{
+		As:     as,
+		Fields: fields,
+	})
+	return j
+}
+
+// JoinRename is an explicit name for a field from one of the joined parents.
+// tick:ignore
+type JoinRename struct {
+	As    string `json:"as"`
+	Field string `json:"field"`
+	Name  string `json:"name"`
+}
+
+// Rename the field of the parent with the alias as to name.
+// Renamed fields are not prefixed.
+//
+// Example:
+//
+//	errors
+//	    |join(requests)
+//	        .as('errors', 'requests')
+//	        .rename('errors', 'value', 'error_count')
+//
+// The "value" field of the errors parent is named "error_count"
+// while the "value" field of the requests parent is named "requests.value".
+//
+// tick:property
+func (j *JoinNode) Rename(as, field, name string) *JoinNode {
+	j.Renames = append(j.Renames, JoinRename{
+		As:    as,
+		Field: field,
+		Name:  name,
+	})
+	return j
+}
+
 // Join on a subset of the group by dimensions.
 // This is a special case where you want a single point from one parent to join with multiple
 // points from a different parent.
@@ -266,5 +393,122 @@ func (j *JoinNode) validate() error {
 		names[name] = true
 	}
 
+	if err := j.validateRenames(names); err != nil {
+		return err
+	}
+	return j.validateFields(names)
+}
+
+// validateRenames checks that renamed fields cannot collide with each other
+// or with the prefixed names of other fields.
+func (j *JoinNode) validateRenames(names map[string]bool) error {
+	renamed := make(map[JoinRename]bool, len(j.Renames))
+	targets := make(map[string]bool, len(j.Renames))
+	for _, r := range j.Renames {
+		if !names[r.As] {
+			return fmt.Errorf("cannot rename field %q of unknown join name %q, see .as() property method", r.Field, r.As)
+		}
+		if r.Field == "" || r.Name == "" {
+			return fmt.Errorf("must provide a field and a new name to rename a field of %q", r.As)
+		}
+		key := JoinRename{As: r.As, Field: r.Field}
+		if renamed[key] {
+			return fmt.Errorf("field %q of %q is renamed more than once", r.Field, r.As)
+		}
+		renamed[key] = true
+		if targets[r.Name] {
+			return fmt.Errorf("cannot rename more than one field to %q", r.Name)
+		}
+		targets[r.Name] = true
+		for _, name := range j.Names {
+			if j.hasAffix(r.Name, name) {
+				return fmt.Errorf("cannot rename field %q of %q to %q, it collides with the fields of %q", r.Field, r.As, r.Name, name)
+			}
+		}
+	}
 	return nil
 }
+
+// validateFields checks the declared fields and that the declared fields cannot collide once joined.
+func (j *JoinNode) validateFields(names map[string]bool) error {
+	declared := make(map[string]map[string]bool, len(j.FieldSets))
+	for _, fs := range j.FieldSets {
+		if !names[fs.As] {
+			return fmt.Errorf("cannot declare fields of unknown join name %q, see .as() property method", fs.As)
+		}
+		if declared[fs.As] != nil {
+			return fmt.Errorf("fields of %q are declared more than once", fs.As)
+		}
+		if len(fs.Fields) == 0 {
+			return fmt.Errorf("must provide at least one field to declare the fields of %q", fs.As)
+		}
+		set := make(map[string]bool, len(fs.Fields))
+		for _, f := range fs.Fields {
+			if f == "" {
+				return fmt.Errorf("cannot declare an empty field name for %q", fs.As)
+			}
+			set[f] = true
+		}
+		declared[fs.As] = set
+	}
+	if len(declared) == 0 {
+		return nil
+	}
+
+	renames := make(map[JoinRename]string, len(j.Renames))
+	counts := make(map[string]int)
+	for _, r := range j.Renames {
+		renames[JoinRename{As: r.As, Field: r.Field}] = r.Name
+		counts[r.Name]++
+	}
+	for as, set := range declared {
+		for f := range set {
+			if _, ok := renames[JoinRename{As: as, Field: f}]; !ok {
+				counts[f]++
+			}
+		}
+	}
+	joined := make(map[string]string)
+	for _, as := range j.Names {
+		for f := range declared[as] {
+			name, ok := renames[JoinRename{As: as, Field: f}]
+			if !ok {
+				name = j.joinedName(as, f, counts)
+			}
+			if other, ok := joined[name]; ok {
+				return fmt.Errorf("field %q of %q and a field of %q are both joined as %q", f, as, other, name)
+			}
+			joined[name] = as
+		}
+	}
+	return nil
+}
+
+// joinedName returns the name of the declared field f of the parent as.
+func (j *JoinNode) joinedName(as, f string, counts map[string]int) string {
+	if j.PrefixCollisionsOnlyFlag && counts[f] == 1 && !j.isAffixed(f) {
+		return f
+	}
+	if j.SuffixFlag {
+		return f + j.Delimiter + as
+	}
+	return as + j.Delimiter + f
+}
+
+// isAffixed reports whether field could be the result of prefixing or suffixing a field with any of the names.
+func (j *JoinNode) isAffixed(field string) bool {
+	for _, name := range j.Names {
+		if j.hasAffix(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAffix reports whether field could be the result of prefixing or suffixing a field with name.
+func (j *JoinNode) hasAffix(field, name string) bool {
+	if j.SuffixFlag {
+		return strings.HasSuffix(field, j.Delimiter+name)
+	}
+	return strings.HasPrefix(field, name+j.Delimiter)
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJoinNode_ValidateFields(t *testing.T) {
+	tests := []struct {
+		name string
+		j    *JoinNode
+		err  string
+	}{
+		{
+			name: "declared fields",
+			j: &JoinNode{
+				Names:                    []string{"cpu", "mem"},
+				Delimiter:                ".",
+				PrefixCollisionsOnlyFlag: true,
+				FieldSets: []JoinFields{
+					{As: "cpu", Fields: []string{"usage_idle", "value"}},
+					{As: "mem", Fields: []string{"used", "value"}},
+				},
+			},
+		},
+		{
+			name: "unknown name",
+			j: &JoinNode{
+				Names:     []string{"cpu", "mem"},
+				Delimiter: ".",
+				FieldSets: []JoinFields{{As: "disk", Fields: []string{"value"}}},
+			},
+			err: `cannot declare fields of unknown join name "disk"`,
+		},
+		{
+			name: "declared twice",
+			j: &JoinNode{
+				Names:     []string{"cpu", "mem"},
+				Delimiter: ".",
+				FieldSets: []JoinFields{
+					{As: "cpu", Fields: []string{"value"}},
+					{As: "cpu", Fields: []string{"usage_idle"}},
+				},
+			},
+			err: `fields of "cpu" are declared more than once`,
+		},
+		{
+			name: "no fields",
+			j: &JoinNode{
+				Names:     []string{"cpu", "mem"},
+				Delimiter: ".",
+				FieldSets: []JoinFields{{As: "cpu"}},
+			},
+			err: `must provide at least one field to declare the fields of "cpu"`,
+		},
+		{
+			name: "prefixed collision",
+			j: &JoinNode{
+				Names: []string{"a", "ab"},
+				FieldSets: []JoinFields{
+					{As: "a", Fields: []string{"bx"}},
+					{As: "ab", Fields: []string{"x"}},
+				},
+			},
+			err: `field "x" of "ab" and a field of "a" are both joined as "abx"`,
+		},
+		{
+			name: "unique fields are not prefixed",
+			j: &JoinNode{
+				Names:                    []string{"a", "ab"},
+				PrefixCollisionsOnlyFlag: true,
+				FieldSets: []JoinFields{
+					{As: "a", Fields: []string{"bx"}},
+					{As: "ab", Fields: []string{"x"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make(map[string]bool, len(tt.j.Names))
+			for _, name := range tt.j.Names {
+				names[name] = true
+			}
+			err := tt.j.validateFields(names)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("unexpected error got %v exp %q", err, tt.err)
+			}
+		})
+	}
+}
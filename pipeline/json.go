@@ -188,6 +188,15 @@ func init() {
 		"shift":             func(parent chainnodeAlias) Node { return parent.Shift(0) },
 		"sideload":          func(parent chainnodeAlias) Node { return parent.Sideload() },
 		"sample":            func(parent chainnodeAlias) Node { return parent.Sample(0) },
+		"limit":             func(parent chainnodeAlias) Node { return parent.Limit(0, 0) },
+		"queryLookup":       func(parent chainnodeAlias) Node { return parent.Query("") },
+		"reorder":           func(parent chainnodeAlias) Node { return parent.Reorder(0) },
+		"rolling":           func(parent chainnodeAlias) Node { return parent.RollingMean("", 0) },
+		"trendSlope":        func(parent chainnodeAlias) Node { return parent.TrendSlope("", 0) },
+		"baseline":          func(parent chainnodeAlias) Node { return parent.Baseline("", 0) },
+		"split":             func(parent chainnodeAlias) Node { return parent.Split() },
+		"interpolate":       func(parent chainnodeAlias) Node { return parent.Interpolate() },
+		"outliers":          func(parent chainnodeAlias) Node { return parent.Outliers("") },
 		"log":               func(parent chainnodeAlias) Node { return parent.Log() },
 		"kapacitorLoopback": func(parent chainnodeAlias) Node { return parent.KapacitorLoopback() },
 		"k8sAutoscale":      func(parent chainnodeAlias) Node { return parent.K8sAutoscale() },
@@ -201,6 +210,7 @@ func init() {
 		"delete":            func(parent chainnodeAlias) Node { return parent.Delete() },
 		"default":           func(parent chainnodeAlias) Node { return parent.Default() },
 		"combine":           func(parent chainnodeAlias) Node { return parent.Combine(nil) },
+		"autoscale":         func(parent chainnodeAlias) Node { return parent.Autoscale("") },
 		"alert":             func(parent chainnodeAlias) Node { return parent.Alert() },
 	}
 
@@ -211,7 +221,12 @@ func init() {
 
 	influxFunctions = map[string]func(chainnodeAlias, string) *InfluxQLNode{
 		"count":         func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Count(field) },
+		"countIf":       func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.CountIf(nil) },
+		"sumIf":         func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.SumIf(field, nil) },
+		"meanIf":        func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.MeanIf(field, nil) },
 		"distinct":      func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Distinct(field) },
+		"distinctCount": func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.DistinctCount(field) },
+		"entropy":       func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Entropy(field) },
 		"mean":          func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Mean(field) },
 		"median":        func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Median(field) },
 		"mode":          func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Mode(field) },
@@ -231,6 +246,9 @@ func init() {
 		"holtWintersWithFit": func(parent chainnodeAlias, field string) *InfluxQLNode {
 			return parent.HoltWintersWithFit(field, 0, 0, 0)
 		},
+		"timeWeightedMean": func(parent chainnodeAlias, field string) *InfluxQLNode {
+			return parent.TimeWeightedMean(field)
+		},
 	}
 
 	uniqFunctions = map[string]func([]byte, []Node, TypeOf) (Node, error){
@@ -527,10 +545,13 @@ func isChainNode(node Node) (chainnodeAlias, bool) {
 // chainnodeAlias is used to check for the presence of a chain node
 type chainnodeAlias interface {
 	Alert() *AlertNode
+	Autoscale(string) *AutoscaleNode
+	Baseline(string, time.Duration) *BaselineNode
 	Bottom(int64, string, ...string) *InfluxQLNode
 	Children() []Node
 	Combine(...*ast.LambdaNode) *CombineNode
 	Count(string) *InfluxQLNode
+	CountIf(*ast.LambdaNode) *InfluxQLNode
 	CumulativeSum(string) *InfluxQLNode
 	Deadman(float64, time.Duration, ...*ast.LambdaNode) *AlertNode
 	Default() *DefaultNode
@@ -540,43 +561,58 @@ type chainnodeAlias interface {
 	Desc() string
 	Difference(string) *InfluxQLNode
 	Distinct(string) *InfluxQLNode
+	DistinctCount(string) *InfluxQLNode
 	Elapsed(string, time.Duration) *InfluxQLNode
+	Entropy(string) *InfluxQLNode
 	Eval(...*ast.LambdaNode) *EvalNode
 	First(string) *InfluxQLNode
 	Flatten() *FlattenNode
+	GroupDeadman(time.Duration, time.Duration, ...*ast.LambdaNode) *AlertNode
 	HoltWinters(string, int64, int64, time.Duration) *InfluxQLNode
 	HoltWintersWithFit(string, int64, int64, time.Duration) *InfluxQLNode
 	HttpOut(string) *HTTPOutNode
 	HttpPost(...string) *HTTPPostNode
 	ID() ID
 	InfluxDBOut() *InfluxDBOutNode
+	Interpolate(...string) *InterpolateNode
 	Join(...Node) *JoinNode
 	K8sAutoscale() *K8sAutoscaleNode
 	KapacitorLoopback() *KapacitorLoopbackNode
 	Last(string) *InfluxQLNode
+	Limit(int64, time.Duration) *LimitNode
 	Log() *LogNode
 	Max(string) *InfluxQLNode
 	Mean(string) *InfluxQLNode
+	MeanIf(string, *ast.LambdaNode) *InfluxQLNode
 	Median(string) *InfluxQLNode
 	Min(string) *InfluxQLNode
 	Mode(string) *InfluxQLNode
 	MovingAverage(string, int64) *InfluxQLNode
 	Name() string
+	Outliers(string) *OutliersNode
 	Parents() []Node
 	Percentile(string, float64) *InfluxQLNode
 	Provides() EdgeType
+	Query(string) *QueryLookupNode
+	Reorder(time.Duration) *ReorderNode
+	RollingMean(string, int64) *RollingNode
+	RollingSum(string, int64) *RollingNode
 	Sample(interface{}) *SampleNode
 	SetName(string)
 	Shift(time.Duration) *ShiftNode
 	Sideload() *SideloadNode
+	Split() *SplitNode
 	Spread(string) *InfluxQLNode
 	StateCount(*ast.LambdaNode) *StateCountNode
 	StateDuration(*ast.LambdaNode) *StateDurationNode
 	Stats(time.Duration) *StatsNode
 	Stddev(string) *InfluxQLNode
 	Sum(string) *InfluxQLNode
+	SumIf(string, *ast.LambdaNode) *InfluxQLNode
 	SwarmAutoscale() *SwarmAutoscaleNode
+	TimeWeightedMean(string) *InfluxQLNode
 	Top(int64, string, ...string) *InfluxQLNode
+	TrendSlope(string, time.Duration) *TrendSlopeNode
 	Union(...Node) *UnionNode
 	Wants() EdgeType
 	Window() *WindowNode
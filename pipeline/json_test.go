@@ -41,6 +41,7 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "where": null,
             "groupBy": null,
             "groupByMeasurement": false,
+            "groupByExclude": null,
             "database": "",
             "retentionPolicy": "",
             "measurement": "",
@@ -51,11 +52,14 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "typeOf": "window",
             "id": "2",
             "align": false,
+            "alignEpoch": "",
             "fillPeriod": false,
             "periodCount": 0,
             "everyCount": 0,
+            "partialTag": "",
             "period": "10s",
-            "every": "1s"
+            "every": "1s",
+            "partialEvery": "0s"
         }
     ],
     "edges": [
@@ -159,6 +163,7 @@ func TestPipeline_MarshalJSON(t *testing.T) {
                 "host"
             ],
             "groupByMeasurement": false,
+            "groupByExclude": null,
             "database": "telegraf",
             "retentionPolicy": "autogen",
             "measurement": "cpu",
@@ -182,7 +187,12 @@ func TestPipeline_MarshalJSON(t *testing.T) {
                 }
             ],
             "keep": false,
-            "keepList": null
+            "keepList": null,
+            "nanPolicy": "",
+            "nanReplacement": 0,
+            "nanTag": "",
+            "nowPointTime": false,
+            "maxDynamicNames": 0
         },
         {
             "typeOf": "alert",
@@ -192,6 +202,7 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "alertId": "Ruley McRuleface:{{.Group}}",
             "message": " {{.ID}} is  {{.Level}}",
             "details": "{{ json . }}",
+            "runbook": "",
             "info": null,
             "warn": null,
             "crit": {
@@ -216,6 +227,12 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "infoReset": null,
             "warnReset": null,
             "critReset": null,
+            "levels": null,
+            "infoFor": 0,
+            "warnFor": 0,
+            "critFor": 0,
+            "resolveDelay": 0,
+            "timeSource": "",
             "useFlapping": false,
             "flapLow": 0,
             "flapHigh": 0,
@@ -226,10 +243,16 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "durationField": "duration",
             "idTag": "alertID",
             "idField": "",
+            "uniqueId": false,
             "all": false,
             "noRecoveries": false,
             "stateChangesOnly": true,
             "stateChangesOnlyDuration": 0,
+            "every": 0,
+            "attachRecent": 0,
+            "trigger": false,
+            "triggerLevels": null,
+            "fingerprint": null,
             "inhibitors": null,
             "post": [
                 {
@@ -238,13 +261,17 @@ func TestPipeline_MarshalJSON(t *testing.T) {
                     "headers": null,
                     "captureResponse": false,
                     "timeout": 0,
-                    "skipSSLVerification": false
+                    "skipSSLVerification": false,
+                    "failover": null,
+                    "retries": 0,
+                    "maxPayloadSize": 0
                 }
             ],
             "tcp": null,
             "email": null,
             "exec": null,
             "log": null,
+            "file": null,
             "victorOps": null,
             "pagerDuty": null,
             "pagerDuty2": null,
@@ -264,12 +291,17 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "kafka": null,
             "teams": null,
             "serviceNow": null,
-            "zenoss": null
+            "zenoss": null,
+            "zabbix": null
         },
         {
             "typeOf": "httpOut",
             "id": "5",
-            "endpoint": "output"
+            "endpoint": "output",
+            "fields": null,
+            "tags": null,
+            "fieldsAndTags": false,
+            "pretty": false
         },
         {
             "typeOf": "influxdbOut",
@@ -277,6 +309,9 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "cluster": "",
             "database": "chronograf",
             "retentionPolicy": "autogen",
+            "bucket": "",
+            "org": "",
+            "orgid": "",
             "measurement": "alerts",
             "writeConsistency": "",
             "precision": "",
@@ -286,7 +321,8 @@ func TestPipeline_MarshalJSON(t *testing.T) {
                 "triggerType": "threshold"
             },
             "create": true,
-            "flushInterval": "10s"
+            "flushInterval": "10s",
+            "retryTimeout": "0s"
         }
     ],
     "edges": [
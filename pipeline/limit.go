@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// A LimitNode limits the rate of the points or batches of each group,
+// it passes at most count points or batches per group every interval and drops the rest.
+// The dropped points and batches are counted in the `points_dropped` statistic of the node.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('errors')
+//	        .groupBy('service')
+//	    |where(lambda: "status" >= 500)
+//	    |limit(1, 1m)
+//	    |httpPost('http://incidents.example.com/candidates')
+//
+// Post at most one error point per service and minute, however many errors the service has.
+//
+// The limit is a token bucket per group in the time of the data:
+// the bucket of a group holds up to burst tokens and refills at count tokens per interval,
+// each point or batch takes a token and is dropped when the bucket is empty.
+// The bucket of a new group starts full, so a quiet group may pass up to burst points at once.
+//
+// Unlike SampleNode, which keeps every Nth point or the points on the boundaries of a duration
+// whatever the rate of the data, a LimitNode passes all points as long as the group stays under the rate,
+// and only drops the points above it.
+//
+// The buckets are kept per group, so their number is bounded by the cardinality of the node,
+// which counts towards the max-cardinality quota of the task.
+// The bucket of a group is dropped when the group is deleted.
+type LimitNode struct {
+	chainnode `json:"-"`
+
+	// The number of points or batches passed per group every interval.
+	// tick:ignore
+	Count int64 `json:"count"`
+
+	// The interval of the rate.
+	// tick:ignore
+	Interval time.Duration `json:"interval"`
+
+	// The maximum number of points or batches passed at once by a group,
+	// after the group has been under the rate for a while.
+	// Defaults to the count.
+	Burst int64 `json:"burst"`
+}
+
+func newLimitNode(wants EdgeType, count int64, interval time.Duration) *LimitNode {
+	return &LimitNode{
+		chainnode: newBasicChainNode("limit", wants, wants),
+		Count:     count,
+		Interval:  interval,
+		Burst:     count,
+	}
+}
+
+// MarshalJSON converts LimitNode to JSON
+// tick:ignore
+func (n *LimitNode) MarshalJSON() ([]byte, error) {
+	type Alias LimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "limit",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an LimitNode
+// tick:ignore
+func (n *LimitNode) UnmarshalJSON(data []byte) error {
+	type Alias LimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "limit" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as LimitNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *LimitNode) validate() error {
+	if n.Count <= 0 {
+		return fmt.Errorf("count must be positive, got %d", n.Count)
+	}
+	if n.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %v", n.Interval)
+	}
+	if n.Burst <= 0 {
+		return fmt.Errorf("burst must be positive, got %d", n.Burst)
+	}
+	return nil
+}
@@ -15,6 +15,19 @@ import (
 //	      .every(10s)
 //	  |log()
 //	  |count('value')
+//
+// The data can be logged as JSON including the measurement, time, all tags
+// and the type of each field, instead of the default concise format.
+// The logged JSON can be copied into a test recording to replay the data.
+//
+// Example:
+//
+//	stream
+//	  |from()
+//	      .measurement('cpu')
+//	  |log()
+//	      .fieldsAndTags()
+//	      .pretty()
 type LogNode struct {
 	chainnode
 
@@ -24,6 +37,14 @@ type LogNode struct {
 	Level string `json:"level"`
 	// Optional prefix to add to all log messages
 	Prefix string `json:"prefix"`
+
+	// Log the data as JSON including all tags and typed fields.
+	// tick:ignore
+	FieldsAndTagsFlag bool `tick:"FieldsAndTags" json:"fieldsAndTags"`
+
+	// Indent the logged JSON.
+	// tick:ignore
+	PrettyFlag bool `tick:"Pretty" json:"pretty"`
 }
 
 func newLogNode(wants EdgeType) *LogNode {
@@ -33,6 +54,20 @@ func newLogNode(wants EdgeType) *LogNode {
 	}
 }
 
+// Log the data as JSON including the measurement, time, all tags and typed fields.
+// tick:property
+func (n *LogNode) FieldsAndTags() *LogNode {
+	n.FieldsAndTagsFlag = true
+	return n
+}
+
+// Indent the JSON logged with fieldsAndTags.
+// tick:property
+func (n *LogNode) Pretty() *LogNode {
+	n.PrettyFlag = true
+	return n
+}
+
 // MarshalJSON converts LogNode to JSON
 // tick:ignore
 func (n *LogNode) MarshalJSON() ([]byte, error) {
@@ -311,6 +311,80 @@ func (n *node) Deadman(threshold float64, interval time.Duration, expr ...*ast.L
 	return an
 }
 
+// Helper function for creating an alert for each group that stops emitting points, a.k.a. per group deadman's switch.
+//
+// - Timeout -- trigger alert if a group has not emitted any points for at least the timeout.
+// - Interval -- how often to check the groups.
+// - Expressions -- optional list of expressions to also evaluate. Useful for time of day alerting.
+//
+// The alert is cleared once the group emits points again.
+// The number of groups tracked for each switch is limited by the 'max-groups' option of the 'deadman' configuration section,
+// groups beyond the limit are not checked until tracked groups are deleted.
+//
+// Example:
+//
+//	var data = stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	// Trigger critical alert if a host has not reported for 5m, checked every 1m.
+//	data
+//	    |groupDeadman(5m, 1m)
+//	//Do normal processing of data
+//	data...
+//
+// The above is equivalent to this
+// Example:
+//
+//	var data = stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	data
+//	    |stats(1m)
+//	        .align()
+//	        .silence()
+//	        .maxGroups(10000)
+//	    |alert()
+//	        .id('{{ .Group }}:node \'from1\' in task \'{{ .TaskName }}\'')
+//	        .message('{{ .ID }} is {{ if eq .Level "OK" }}alive{{ else }}dead{{ end }}: silent for {{ index .Fields "silence" | humanizeDuration }}.')
+//	        .crit(lambda: "silence" >= 300.0)
+//	//Do normal processing of data
+//	data...
+//
+// The `id` alert property can be configured globally via the 'deadman' configuration section.
+func (n *node) GroupDeadman(timeout, interval time.Duration, expr ...*ast.LambdaNode) *AlertNode {
+	sn := n.Stats(interval).Align().Silence()
+	sn.MaxGroups = n.pipeline().deadman.MaxGroups()
+
+	an := sn.Alert()
+	critExpr := &ast.BinaryNode{
+		Operator: ast.TokenGreaterEqual,
+		Left: &ast.ReferenceNode{
+			Reference: "silence",
+		},
+		Right: &ast.NumberNode{
+			IsFloat: true,
+			Float64: timeout.Seconds(),
+		},
+	}
+	// Add any additional expressions
+	for _, e := range expr {
+		critExpr = &ast.BinaryNode{
+			Operator: ast.TokenAnd,
+			Left:     critExpr,
+			Right:    e.Expression,
+		}
+	}
+	an.Crit = &ast.LambdaNode{Expression: critExpr}
+	// Replace NODE_NAME with actual name of the node in the Id.
+	an.Id = strings.Replace(n.pipeline().deadman.Id(), nodeNameMarker, n.Name(), 1)
+	an.Message = groupDeadmanMessage
+	return an
+}
+
+const groupDeadmanMessage = `{{ .ID }} is {{ if eq .Level "OK" }}alive{{ else }}dead{{ end }}: silent for {{ index .Fields "silence" | humanizeDuration }}.`
+
 // ---------------------------------
 // Chaining methods
 //
@@ -455,6 +529,13 @@ func (n *chainnode) Sample(rate interface{}) *SampleNode {
 	return s
 }
 
+// Create a new node that passes at most count points or batches per group every interval.
+func (n *chainnode) Limit(count int64, interval time.Duration) *LimitNode {
+	l := newLimitNode(n.Provides(), count, interval)
+	n.linkChild(l)
+	return l
+}
+
 // Create a new node that computes the derivative of adjacent points.
 func (n *chainnode) Derivative(field string) *DerivativeNode {
 	s := newDerivativeNode(n.Provides(), field)
@@ -497,6 +578,13 @@ func (n *chainnode) Delete() *DeleteNode {
 	return s
 }
 
+// Create a node that can trigger autoscale events by posting to the webhook url.
+func (n *chainnode) Autoscale(url string) *AutoscaleNode {
+	k := newAutoscaleNode(n.Provides(), url)
+	n.linkChild(k)
+	return k
+}
+
 // Create a node that can trigger autoscale events for a kubernetes cluster.
 func (n *chainnode) K8sAutoscale() *K8sAutoscaleNode {
 	k := newK8sAutoscaleNode(n.Provides())
@@ -539,6 +627,102 @@ func (n *chainnode) Sideload() *SideloadNode {
 	return s
 }
 
+// Create a node that adds a field with a value queried from InfluxDB.
+// The query is a template evaluated with the tags of the data.
+func (n *chainnode) Query(q string) *QueryLookupNode {
+	l := newQueryLookupNode(n.provides, q)
+	n.linkChild(l)
+	return l
+}
+
+// Create a node that emits the points of a stream in time order per group,
+// holding points for the lateness in order for out of order points to arrive.
+func (n *chainnode) Reorder(lateness time.Duration) *ReorderNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot Reorder batch edge")
+	}
+
+	s := newReorderNode(lateness)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that adds the mean of the field over the last count points of each group to every point.
+func (n *chainnode) RollingMean(field string, count int64) *RollingNode {
+	return n.rolling(RollingMean, field, count)
+}
+
+// Create a node that adds the sum of the field over the last count points of each group to every point.
+func (n *chainnode) RollingSum(field string, count int64) *RollingNode {
+	return n.rolling(RollingSum, field, count)
+}
+
+func (n *chainnode) rolling(aggregate, field string, count int64) *RollingNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot compute a rolling " + aggregate + " of a batch edge")
+	}
+
+	s := newRollingNode(aggregate, field, count)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that adds the slope of the least squares regression line of the field
+// over the trailing window of each group to every point.
+func (n *chainnode) TrendSlope(field string, window time.Duration) *TrendSlopeNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot compute a trend slope of a batch edge")
+	}
+
+	s := newTrendSlopeNode(field, window)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that adds the deviation of the field from the periodic baseline of each group to every point.
+func (n *chainnode) Baseline(field string, period time.Duration) *BaselineNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot compute a baseline of a batch edge")
+	}
+
+	b := newBaselineNode(field, period)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that emits several points for each point.
+func (n *chainnode) Split() *SplitNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot split a batch edge")
+	}
+
+	s := newSplitNode()
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that fills the missing values of fields of batches by linear interpolation.
+func (n *chainnode) Interpolate(fields ...string) *InterpolateNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot interpolate a stream edge, use a window first")
+	}
+
+	i := newInterpolateNode(fields)
+	n.linkChild(i)
+	return i
+}
+
+// Create a node that tags or drops the points of batches whose field is an outlier of the batch.
+func (n *chainnode) Outliers(field string) *OutliersNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot find outliers of a stream edge, use a window first")
+	}
+
+	o := newOutliersNode(field)
+	n.linkChild(o)
+	return o
+}
+
 // Create a node that converts batches (such as windowed data) into non-batches.
 func (n *chainnode) Trickle() *TrickleNode {
 	if n.Provides() != BatchEdge {
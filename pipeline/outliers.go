@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Methods of an OutliersNode.
+const (
+	// OutliersZScore marks the values more than threshold standard deviations away from the mean.
+	OutliersZScore = "zscore"
+	// OutliersIQR marks the values more than threshold interquartile ranges below the first or above the third quartile.
+	OutliersIQR = "iqr"
+)
+
+const (
+	// DefaultOutliersZScoreThreshold is the default threshold of the zscore method of an OutliersNode.
+	DefaultOutliersZScoreThreshold = 3.0
+	// DefaultOutliersIQRThreshold is the default threshold of the iqr method of an OutliersNode.
+	DefaultOutliersIQRThreshold = 1.5
+	// DefaultOutliersMinSamples is the default number of values of a batch needed to mark outliers.
+	DefaultOutliersMinSamples = 10
+)
+
+// An OutliersNode marks the points of batches whose field is an outlier of the batch,
+// by tagging them or dropping them.
+// The bounds of the values are computed from the values of the field in each batch,
+// i.e. per window and group, with either the z-score or the interquartile range (IQR) method.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('latency')
+//	        .groupBy('host')
+//	    |window()
+//	        .period(5m)
+//	        .every(1m)
+//	    |outliers('value')
+//	        .method('iqr')
+//	        .threshold(3.0)
+//	    |where(lambda: "outlier" == 'false')
+//	    |mean('value')
+//
+// Compute the mean latency of each host without the values far outside its usual range.
+//
+// With the zscore method a value is an outlier if it is more than threshold sample standard deviations away from the mean,
+// the threshold defaults to 3.
+// With the iqr method a value is an outlier if it is more than threshold times the interquartile range
+// under the first quartile or over the third quartile, the threshold defaults to 1.5.
+// The zscore method suits normally distributed values, the iqr method is more robust,
+// since the outliers themselves shift the mean and inflate the standard deviation much more than the quartiles.
+//
+// Every point of the batch is tagged with the tag, outlier by default, with the value true or false,
+// so outliers can be routed with a where node.
+// With drop the outliers are dropped instead and the other points are not tagged.
+//
+// The bounds need enough values to be meaningful: batches with less than minSamples numeric values,
+// 10 by default, have no outliers.
+// So a window should hold at least minSamples points of each group, and a new group or a sparse window is not cleaned.
+// Only float and integer values are considered, points missing the field or with a value of another type are never outliers.
+type OutliersNode struct {
+	chainnode `json:"-"`
+
+	// The field whose outliers are marked.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The method computing the bounds of the values, zscore or iqr.
+	// Defaults to zscore.
+	Method string `json:"method"`
+
+	// The sensitivity of the method, lower thresholds mark more outliers.
+	// Defaults to 3 for zscore and 1.5 for iqr.
+	Threshold float64 `json:"threshold"`
+
+	// The minimum number of values of a batch to mark outliers.
+	// Defaults to 10.
+	MinSamples int64 `json:"minSamples"`
+
+	// The name of the tag marking outliers.
+	// Defaults to outlier.
+	Tag string `json:"tag"`
+
+	// Drop the outliers instead of tagging the points.
+	// tick:ignore
+	DropFlag bool `tick:"Drop" json:"drop"`
+}
+
+func newOutliersNode(field string) *OutliersNode {
+	return &OutliersNode{
+		chainnode:  newBasicChainNode("outliers", BatchEdge, BatchEdge),
+		Field:      field,
+		Method:     OutliersZScore,
+		MinSamples: DefaultOutliersMinSamples,
+		Tag:        "outlier",
+	}
+}
+
+// MarshalJSON converts OutliersNode to JSON
+// tick:ignore
+func (n *OutliersNode) MarshalJSON() ([]byte, error) {
+	type Alias OutliersNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "outliers",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an OutliersNode
+// tick:ignore
+func (n *OutliersNode) UnmarshalJSON(data []byte) error {
+	type Alias OutliersNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "outliers" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as OutliersNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Drop the outliers instead of tagging the points.
+// tick:property
+func (n *OutliersNode) Drop() *OutliersNode {
+	n.DropFlag = true
+	return n
+}
+
+func (n *OutliersNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for outliers")
+	}
+	switch n.Method {
+	case OutliersZScore, OutliersIQR:
+	default:
+		return fmt.Errorf("invalid method %q, must be one of %s or %s", n.Method, OutliersZScore, OutliersIQR)
+	}
+	if n.Threshold < 0 {
+		return fmt.Errorf("threshold must be positive, got %v", n.Threshold)
+	}
+	if n.MinSamples < 2 {
+		return fmt.Errorf("minSamples must be at least 2, got %d", n.MinSamples)
+	}
+	if !n.DropFlag && n.Tag == "" {
+		return errors.New("must specify a tag for outliers")
+	}
+	return nil
+}
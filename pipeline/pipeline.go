@@ -16,6 +16,7 @@ type DeadmanService interface {
 	Id() string
 	Message() string
 	Global() bool
+	MaxGroups() int64
 }
 
 // Create a template pipeline
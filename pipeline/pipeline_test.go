@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"bytes"
 	"encoding/json"
 	"reflect"
 	"testing"
@@ -16,6 +17,7 @@ type deadman struct {
 	id        string
 	message   string
 	global    bool
+	maxGroups int64
 }
 
 func (d deadman) Interval() time.Duration { return d.interval }
@@ -23,6 +25,7 @@ func (d deadman) Threshold() float64      { return d.threshold }
 func (d deadman) Id() string              { return d.id }
 func (d deadman) Message() string         { return d.message }
 func (d deadman) Global() bool            { return d.global }
+func (d deadman) MaxGroups() int64        { return d.maxGroups }
 
 func TestTICK_To_Pipeline_MultiLine(t *testing.T) {
 	var tickScript = `
@@ -67,6 +70,56 @@ w.every(1s)
 	}
 }
 
+func TestTICK_To_Pipeline_GroupDeadman(t *testing.T) {
+	var tickScript = `
+stream
+	|from()
+		.groupBy('host')
+	|groupDeadman(5m, 1m)
+`
+
+	d := deadman{
+		id:        "NODE_NAME is dead",
+		maxGroups: 100,
+	}
+
+	scope := stateful.NewScope()
+	p, err := CreatePipeline(tickScript, StreamEdge, scope, d, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sn *StatsNode
+	for _, s := range p.sources {
+		if n, ok := s.(*StatsNode); ok {
+			sn = n
+		}
+	}
+	if sn == nil {
+		t.Fatal("expected a StatsNode source")
+	}
+	if !sn.AlignFlag || !sn.SilenceFlag {
+		t.Errorf("expected aligned stats with silence, got align %v silence %v", sn.AlignFlag, sn.SilenceFlag)
+	}
+	if exp, got := time.Minute, sn.Interval; exp != got {
+		t.Errorf("unexpected interval exp %v got %v", exp, got)
+	}
+	if exp, got := int64(100), sn.MaxGroups; exp != got {
+		t.Errorf("unexpected max groups exp %d got %d", exp, got)
+	}
+	an, ok := sn.Children()[0].(*AlertNode)
+	if !ok {
+		t.Fatalf("unexpected node type: exp AlertNode got %T", sn.Children()[0])
+	}
+	if exp, got := "from1 is dead", an.Id; exp != got {
+		t.Errorf("unexpected id exp %q got %q", exp, got)
+	}
+	var crit bytes.Buffer
+	an.Crit.Expression.Format(&crit, "", false)
+	if exp, got := `"silence" >= 300.0`, crit.String(); exp != got {
+		t.Errorf("unexpected crit expression exp %s got %s", exp, got)
+	}
+}
+
 func TestPipelineSort(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+const (
+	// DefaultQueryLookupAs is the default field name of the looked up value.
+	DefaultQueryLookupAs = "value"
+	// DefaultQueryLookupCacheTTL is the default duration a looked up value is cached.
+	DefaultQueryLookupCacheTTL = 5 * time.Minute
+	// DefaultQueryLookupCacheSize is the default maximum number of cached query results.
+	DefaultQueryLookupCacheSize = 1000
+)
+
+// A QueryLookupNode enriches data with a value queried from InfluxDB.
+//
+// The query is a template that is evaluated using the tags of each point,
+// or the group tags of each batch, so that a different value can be looked up per series.
+// The first value of the first row returned by the query is added as a field.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |query('SELECT last("cores") FROM "inventory"."autogen"."hosts" WHERE "host" = \'{{.host}}\'')
+//	        .as('cores')
+//	        .cacheTTL(10m)
+//	        .errorTag('query_error')
+//	    |eval(lambda: "usage_total" / "cores")
+//	        .as('usage_per_core')
+//
+// Add the number of cores of each host as the field `cores`.
+//
+// The tag values are escaped for InfluxQL string literals, quotes and backslashes are escaped with backslashes,
+// so the values must be used within single quotes as in the example.
+// A tag value cannot end the string literal and change the query.
+//
+// Results are cached by the evaluated query text, so InfluxDB is only queried
+// once per distinct query within the cache TTL.
+//
+// By default a failed query fails the task.
+// If an error tag is set the error is instead recorded on the data in that tag
+// and the data is passed on without the looked up field.
+// Failed queries are counted in the `query_errors` statistic of the node.
+type QueryLookupNode struct {
+	chainnode `json:"-"`
+
+	// The query template
+	// tick:ignore
+	QueryStr string `json:"queryStr"`
+
+	// The name of the field to add.
+	// Defaults to "value".
+	As string `json:"as"`
+
+	// The name of a configured InfluxDB cluster.
+	// If empty the default cluster will be used.
+	Cluster string `json:"cluster"`
+
+	// How long query results are cached.
+	// A value of zero disables caching.
+	// Defaults to 5m.
+	CacheTTL time.Duration `json:"cacheTTL"`
+
+	// The maximum number of query results to cache.
+	// The least recently used result is removed when the cache is full.
+	// Defaults to 1000.
+	CacheSize int64 `json:"cacheSize"`
+
+	// The name of a tag in which to record query errors.
+	// If empty a query error fails the task.
+	ErrorTag string `json:"errorTag"`
+}
+
+func newQueryLookupNode(wants EdgeType, q string) *QueryLookupNode {
+	return &QueryLookupNode{
+		chainnode: newBasicChainNode("queryLookup", wants, wants),
+		QueryStr:  q,
+		As:        DefaultQueryLookupAs,
+		CacheTTL:  DefaultQueryLookupCacheTTL,
+		CacheSize: DefaultQueryLookupCacheSize,
+	}
+}
+
+// MarshalJSON converts QueryLookupNode to JSON
+// tick:ignore
+func (n *QueryLookupNode) MarshalJSON() ([]byte, error) {
+	type Alias QueryLookupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		CacheTTL string `json:"cacheTTL"`
+	}{
+		TypeOf: TypeOf{
+			Type: "queryLookup",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		CacheTTL: influxql.FormatDuration(n.CacheTTL),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an QueryLookupNode
+// tick:ignore
+func (n *QueryLookupNode) UnmarshalJSON(data []byte) error {
+	type Alias QueryLookupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		CacheTTL string `json:"cacheTTL"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "queryLookup" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as QueryLookupNode", raw.ID, raw.Type)
+	}
+	n.CacheTTL, err = influxql.ParseDuration(raw.CacheTTL)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *QueryLookupNode) validate() error {
+	if n.QueryStr == "" {
+		return errors.New("must provide a query")
+	}
+	if n.As == "" {
+		return errors.New("must provide a non empty as name")
+	}
+	if n.CacheTTL < 0 {
+		return fmt.Errorf("cacheTTL must be non-negative, got %v", n.CacheTTL)
+	}
+	if n.CacheTTL > 0 && n.CacheSize <= 0 {
+		return fmt.Errorf("cacheSize must be positive when caching is enabled, got %d", n.CacheSize)
+	}
+	return nil
+}
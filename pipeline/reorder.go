@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+const (
+	// DefaultReorderBufferSize is the default maximum number of points buffered per group.
+	DefaultReorderBufferSize = 1000
+)
+
+// A ReorderNode buffers points of a stream and emits them in time order per group.
+//
+// Points are held until a point at least the lateness later has arrived in the same group,
+// so that points arriving out of order within the lateness are emitted in order.
+// Points that arrive after a later point of the same group has already been emitted
+// are dropped and counted in the `points_dropped` statistic of the node.
+//
+// Nodes such as window and join assume points arrive in time order,
+// reorder points before them when the data source can deliver points out of order.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |reorder(10s)
+//	        .bufferSize(5000)
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |mean('usage_idle')
+//
+// Compute the mean per minute of points that arrive up to 10s out of order.
+//
+// Barriers flush all buffered points up to the time of the barrier,
+// and the remaining points of a group are emitted when the group is deleted or the task stops.
+type ReorderNode struct {
+	chainnode `json:"-"`
+
+	// How long to hold points for out of order points to arrive.
+	// tick:ignore
+	Lateness time.Duration `json:"lateness"`
+
+	// The maximum number of points buffered per group.
+	// When the buffer is full the oldest point is emitted regardless of the lateness.
+	// Defaults to 1000.
+	BufferSize int64 `json:"bufferSize"`
+}
+
+func newReorderNode(lateness time.Duration) *ReorderNode {
+	return &ReorderNode{
+		chainnode:  newBasicChainNode("reorder", StreamEdge, StreamEdge),
+		Lateness:   lateness,
+		BufferSize: DefaultReorderBufferSize,
+	}
+}
+
+// MarshalJSON converts ReorderNode to JSON
+// tick:ignore
+func (n *ReorderNode) MarshalJSON() ([]byte, error) {
+	type Alias ReorderNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lateness string `json:"lateness"`
+	}{
+		TypeOf: TypeOf{
+			Type: "reorder",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Lateness: influxql.FormatDuration(n.Lateness),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ReorderNode
+// tick:ignore
+func (n *ReorderNode) UnmarshalJSON(data []byte) error {
+	type Alias ReorderNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lateness string `json:"lateness"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "reorder" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ReorderNode", raw.ID, raw.Type)
+	}
+	n.Lateness, err = influxql.ParseDuration(raw.Lateness)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *ReorderNode) validate() error {
+	if n.Lateness <= 0 {
+		return fmt.Errorf("lateness must be positive, got %v", n.Lateness)
+	}
+	if n.BufferSize <= 0 {
+		return fmt.Errorf("bufferSize must be positive, got %d", n.BufferSize)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+const (
+	// RollingMean computes the mean of the rolling points.
+	RollingMean = "mean"
+	// RollingSum computes the sum of the rolling points.
+	RollingSum = "sum"
+)
+
+// A RollingNode adds a field with an aggregate of the last points of each group to every point of a stream.
+//
+// The aggregate is computed over the last count points of the group, including the current point.
+// If a period is set only the points no older than the period from the current point are aggregated,
+// at most count points are ever retained per group, so count bounds the memory used by each group.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |rollingMean('usage_idle', 100)
+//	        .period(5m)
+//	        .as('smoothed')
+//	    |alert()
+//	        .crit(lambda: "smoothed" < 10)
+//
+// Alert on the mean idle usage over the last 5m of each host, using at most the last 100 points.
+//
+// Until count points have been received, or while the points of the group span less than the period,
+// the aggregate is computed over the points available, starting with the first point of the group alone.
+// The points of a group are dropped when the group is deleted, a group that reappears starts again from a single point.
+// Points missing the field or with a non numeric field are dropped.
+//
+// Unlike movingAverage, every point is emitted with all of its fields and tags, during warm-up as well.
+type RollingNode struct {
+	chainnode `json:"-"`
+
+	// The field to aggregate.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The aggregate to compute, one of mean or sum.
+	// tick:ignore
+	Aggregate string `json:"aggregate"`
+
+	// The maximum number of points to aggregate.
+	// tick:ignore
+	Count int64 `json:"count"`
+
+	// The maximum age of the aggregated points relative to the current point.
+	// Zero aggregates the last count points regardless of their age.
+	Period time.Duration `json:"period"`
+
+	// The name of the aggregate field.
+	// Defaults to the name of the aggregate, i.e. mean.
+	As string `json:"as"`
+}
+
+func newRollingNode(aggregate, field string, count int64) *RollingNode {
+	return &RollingNode{
+		chainnode: newBasicChainNode("rolling", StreamEdge, StreamEdge),
+		Field:     field,
+		Aggregate: aggregate,
+		Count:     count,
+		As:        aggregate,
+	}
+}
+
+// MarshalJSON converts RollingNode to JSON
+// tick:ignore
+func (n *RollingNode) MarshalJSON() ([]byte, error) {
+	type Alias RollingNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "rolling",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an RollingNode
+// tick:ignore
+func (n *RollingNode) UnmarshalJSON(data []byte) error {
+	type Alias RollingNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "rolling" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as RollingNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *RollingNode) validate() error {
+	switch n.Aggregate {
+	case RollingMean, RollingSum:
+	default:
+		return fmt.Errorf("invalid rolling aggregate %q, must be one of %s or %s", n.Aggregate, RollingMean, RollingSum)
+	}
+	if n.Field == "" {
+		return fmt.Errorf("must specify a field for the rolling %s", n.Aggregate)
+	}
+	if n.Count <= 0 {
+		return fmt.Errorf("count must be positive, got %d", n.Count)
+	}
+	if n.Period < 0 {
+		return fmt.Errorf("period must be >= 0, got %v", n.Period)
+	}
+	if n.As == "" {
+		return fmt.Errorf("must specify a name for the rolling %s", n.Aggregate)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MaxSplitPoints is the maximum number of points a SplitNode emits for each point.
+const MaxSplitPoints = 100
+
+// A SplitNode emits several points for each point of a stream, one for each .point() of the node.
+// Each emitted point has its own fields, computed from the point by lambda expressions,
+// its own tags added to the tags of the point, and optionally its own measurement and time.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |split()
+//	        .point()
+//	            .tag('mode', 'user')
+//	            .field('usage', lambda: "usage_user")
+//	        .point()
+//	            .tag('mode', 'system')
+//	            .field('usage', lambda: "usage_system")
+//	    |influxDBOut()
+//	        .database('telegraf')
+//	        .measurement('cpu_by_mode')
+//
+// Normalize the cpu measurement, which has a field per mode, into a point per mode with a mode tag.
+//
+// The emitted points are in the group of the point, the tags they add are not dimensions of the group,
+// so use a groupBy node after the split node to group by them.
+// A point is not emitted if any of its fields fails to evaluate, i.e. because a field it references is missing,
+// the other points are still emitted.
+//
+// The number of points emitted for each point is at most the number of .point() of the node,
+// which is limited to 100.
+type SplitNode struct {
+	chainnode `json:"-"`
+
+	// tick:ignore
+	Points []*SplitPoint `tick:"Point" json:"points"`
+}
+
+func newSplitNode() *SplitNode {
+	return &SplitNode{
+		chainnode: newBasicChainNode("split", StreamEdge, StreamEdge),
+	}
+}
+
+// MarshalJSON converts SplitNode to JSON
+// tick:ignore
+func (n *SplitNode) MarshalJSON() ([]byte, error) {
+	type Alias SplitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "split",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SplitNode
+// tick:ignore
+func (n *SplitNode) UnmarshalJSON(data []byte) error {
+	type Alias SplitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "split" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SplitNode", raw.ID, raw.Type)
+	}
+	for _, p := range n.Points {
+		p.SplitNode = n
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Add a point emitted for each point.
+// The following properties up to the next .point() are the properties of the point.
+// tick:property
+func (n *SplitNode) Point() *SplitPoint {
+	p := &SplitPoint{
+		SplitNode: n,
+	}
+	n.Points = append(n.Points, p)
+	return p
+}
+
+func (n *SplitNode) validate() error {
+	if len(n.Points) == 0 {
+		return errors.New("must specify at least one point to split into")
+	}
+	if len(n.Points) > MaxSplitPoints {
+		return fmt.Errorf("cannot split into more than %d points, got %d", MaxSplitPoints, len(n.Points))
+	}
+	for i, p := range n.Points {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("invalid point %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// A point emitted by a SplitNode for each point.
+// tick:embedded:SplitNode.Point
+type SplitPoint struct {
+	*SplitNode `json:"-"`
+
+	// The measurement of the point.
+	// Defaults to the measurement of the point it is split from.
+	Measurement string `json:"measurement"`
+
+	// The offset of the time of the point from the time of the point it is split from.
+	// Use different offsets for points that would otherwise have the same measurement, tags and time.
+	Offset time.Duration `json:"offset"`
+
+	// tick:ignore
+	Tags map[string]string `tick:"Tag" json:"tags"`
+
+	// tick:ignore
+	Fields []SplitField `tick:"Field" json:"fields"`
+}
+
+// tick:ignore
+type SplitField struct {
+	Name   string          `json:"name"`
+	Lambda *ast.LambdaNode `json:"lambda"`
+}
+
+// splitPointJSON is the JSON of a SplitPoint.
+// It does not alias SplitPoint, which would promote the JSON methods of the embedded SplitNode.
+type splitPointJSON struct {
+	Measurement string            `json:"measurement"`
+	Offset      string            `json:"offset"`
+	Tags        map[string]string `json:"tags"`
+	Fields      []SplitField      `json:"fields"`
+}
+
+// MarshalJSON converts SplitPoint to JSON
+// tick:ignore
+func (p *SplitPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(splitPointJSON{
+		Measurement: p.Measurement,
+		Offset:      influxql.FormatDuration(p.Offset),
+		Tags:        p.Tags,
+		Fields:      p.Fields,
+	})
+}
+
+// UnmarshalJSON converts JSON to a SplitPoint
+// tick:ignore
+func (p *SplitPoint) UnmarshalJSON(data []byte) error {
+	var raw splitPointJSON
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	p.Measurement = raw.Measurement
+	p.Tags = raw.Tags
+	p.Fields = raw.Fields
+	p.Offset, err = influxql.ParseDuration(raw.Offset)
+	return err
+}
+
+// Add a tag to the point, replacing the tag of the same key of the point it is split from.
+// tick:property
+func (p *SplitPoint) Tag(key, value string) *SplitPoint {
+	if p.Tags == nil {
+		p.Tags = make(map[string]string)
+	}
+	p.Tags[key] = value
+	return p
+}
+
+// Add a field to the point, the result of the lambda expression evaluated on the point it is split from.
+// The point has only the fields of its .field() properties.
+//
+// Example:
+//
+//	|split()
+//	    .point()
+//	        .field('usage', lambda: "usage_user" + "usage_nice")
+//
+// tick:property
+func (p *SplitPoint) Field(name string, expression *ast.LambdaNode) *SplitPoint {
+	p.Fields = append(p.Fields, SplitField{
+		Name:   name,
+		Lambda: expression,
+	})
+	return p
+}
+
+func (p *SplitPoint) validate() error {
+	if len(p.Fields) == 0 {
+		return errors.New("must specify at least one field")
+	}
+	names := make(map[string]bool, len(p.Fields))
+	for _, f := range p.Fields {
+		if f.Name == "" {
+			return errors.New("field name must not be empty")
+		}
+		if names[f.Name] {
+			return fmt.Errorf("field %q is set more than once", f.Name)
+		}
+		names[f.Name] = true
+		if f.Lambda == nil {
+			return fmt.Errorf("field %q must have an expression", f.Name)
+		}
+	}
+	for k := range p.Tags {
+		if k == "" {
+			return errors.New("tag key must not be empty")
+		}
+	}
+	return nil
+}
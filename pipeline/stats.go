@@ -37,6 +37,20 @@ import (
 //	// Continue normal processing of the data stream
 //	data...
 //
+// Using the StatsNode.Silence property the StatsNode also tracks when each group last emitted a point.
+//
+// Example:
+//
+//	var data = stream
+//	    |from()
+//	        .groupBy('host')
+//	// Emit, every 1 minute, the number of seconds since each host last emitted a point.
+//	data
+//	    |stats(1m)
+//	        .silence()
+//	        .maxGroups(1000)
+//	    |httpOut('silence')
+//
 // WARNING: It is not recommended to join the stats stream with the original data stream.
 // Since they operate on different clocks you could potentially create a deadlock.
 // This is a limitation of the current implementation and may be removed in the future.
@@ -49,6 +63,15 @@ type StatsNode struct {
 
 	// tick:ignore
 	AlignFlag bool `tick:"Align" json:"align"`
+
+	// tick:ignore
+	SilenceFlag bool `tick:"Silence" json:"silence"`
+
+	// Maximum number of groups for which the silence is tracked.
+	// Groups beyond the limit are not emitted until tracked groups are deleted by the source node.
+	// If 0 then there is no limit.
+	// Default: 0, a.k.a no limit.
+	MaxGroups int64 `json:"maxGroups"`
 }
 
 func newStatsNode(n Node, interval time.Duration) *StatsNode {
@@ -110,3 +133,20 @@ func (n *StatsNode) Align() *StatsNode {
 	n.AlignFlag = true
 	return n
 }
+
+// Add the `silence` field with the number of seconds since each group last emitted a point.
+// A group is considered to have emitted a point if its emitted count changed since the previous interval,
+// so the silence has the granularity of the interval.
+// A group is considered to have just emitted a point the first time it is seen.
+// tick:property
+func (n *StatsNode) Silence() *StatsNode {
+	n.SilenceFlag = true
+	return n
+}
+
+func (n *StatsNode) validate() error {
+	if n.MaxGroups < 0 {
+		return fmt.Errorf("maxGroups must be >= 0, got %d", n.MaxGroups)
+	}
+	return nil
+}
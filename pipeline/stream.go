@@ -128,6 +128,10 @@ type FromNode struct {
 	// tick:ignore
 	GroupByMeasurementFlag bool `tick:"GroupByMeasurement" json:"groupByMeasurement"`
 
+	// The tags to exclude when grouping by all tags.
+	// tick:ignore
+	ExcludedDimensions []string `tick:"GroupByAllExcept" json:"groupByExclude"`
+
 	// The database name.
 	// If empty any database will be used.
 	Database string `json:"database"`
@@ -353,6 +357,28 @@ func (s *FromNode) GroupBy(tag ...interface{}) *FromNode {
 	return s
 }
 
+// Group the data by all tags except the given tags.
+// Since the tags can vary from point to point, the group of each point
+// is made of the sorted names of the tags present on that point,
+// minus the excluded tags.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupByAllExcept('instance')
+//
+// The above example groups the points by all of their tags except `instance`.
+// This is the same as `|groupBy(*).exclude('instance')`.
+//
+// tick:property
+func (s *FromNode) GroupByAllExcept(tags ...string) *FromNode {
+	s.Dimensions = []interface{}{&ast.StarNode{}}
+	s.ExcludedDimensions = tags
+	return s
+}
+
 // If set will include the measurement name in the group ID.
 // Along with any other group by dimensions.
 //
@@ -374,5 +400,5 @@ func (n *FromNode) GroupByMeasurement() *FromNode {
 }
 
 func (s *FromNode) validate() error {
-	return validateDimensions(s.Dimensions, nil)
+	return validateDimensions(s.Dimensions, s.ExcludedDimensions)
 }
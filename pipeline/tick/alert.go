@@ -28,12 +28,18 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		Dot("id", a.Id).
 		Dot("message", a.Message).
 		Dot("details", a.Details).
+		Dot("runbook", a.Runbook).
 		Dot("info", a.Info).
 		Dot("warn", a.Warn).
 		Dot("crit", a.Crit).
 		Dot("infoReset", a.InfoReset).
 		Dot("warnReset", a.WarnReset).
 		Dot("critReset", a.CritReset).
+		Dot("infoFor", a.InfoFor).
+		Dot("warnFor", a.WarnFor).
+		Dot("critFor", a.CritFor).
+		Dot("resolveDelay", a.ResolveDelay).
+		Dot("timeSource", a.TimeSource).
 		Dot("history", a.History).
 		Dot("levelTag", a.LevelTag).
 		Dot("levelField", a.LevelField).
@@ -41,9 +47,14 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		Dot("durationField", a.DurationField).
 		Dot("idTag", a.IdTag).
 		Dot("idField", a.IdField).
+		DotIf("uniqueId", a.UniqueIdFlag).
 		DotIf("all", a.AllFlag).
 		DotIf("noRecoveries", a.NoRecoveriesFlag)
 
+	for _, l := range a.Levels {
+		n.Dot("level", l.Name, l.Lambda)
+	}
+
 	for _, in := range a.Inhibitors {
 		args := make([]interface{}, len(in.EqualTags)+1)
 		args[0] = in.Category
@@ -61,6 +72,25 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		}
 	}
 
+	n.Dot("every", a.Every)
+	n.Dot("attachRecent", a.AttachRecentCount)
+
+	if a.IsTrigger {
+		args := make([]interface{}, len(a.TriggerLevels))
+		for i, l := range a.TriggerLevels {
+			args[i] = l
+		}
+		n.Dot("trigger", args...)
+	}
+
+	if len(a.FingerprintKeys) > 0 {
+		args := make([]interface{}, len(a.FingerprintKeys))
+		for i, k := range a.FingerprintKeys {
+			args[i] = k
+		}
+		n.Dot("fingerprint", args...)
+	}
+
 	if a.UseFlapping {
 		n.DotZeroValueOK("flapping", a.FlapLow, a.FlapHigh)
 	}
@@ -70,7 +100,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("endpoint", h.Endpoint).
 			DotIf("captureResponse", h.CaptureResponseFlag).
 			Dot("timeout", h.Timeout).
-			DotIf("skipSSLVerification", h.SkipSSLVerificationFlag)
+			DotIf("skipSSLVerification", h.SkipSSLVerificationFlag).
+			Dot("retries", h.Retries).
+			Dot("maxPayloadSize", h.MaxPayloadSize)
+		if len(h.FailoverEndpoints) > 0 {
+			n.Dot("failover", args(h.FailoverEndpoints)...)
+		}
 
 		var headers []string
 		for k := range h.Headers {
@@ -113,6 +148,27 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		}
 	}
 
+	for _, h := range a.FileHandlers {
+		n.Dot("file", h.FilePath)
+		if h.Mode != 0 {
+			mode := &ast.NumberNode{
+				IsInt: true,
+				Int64: h.Mode,
+				Base:  8,
+			}
+			n.Dot("mode", mode)
+		}
+		n.Dot("format", h.Format)
+		if len(h.FieldsList) != 0 {
+			n.Dot("fields", args(h.FieldsList)...)
+		}
+		n.DotIf("header", h.HeaderFlag).
+			Dot("template", h.Template).
+			Dot("maxSize", h.MaxSize).
+			Dot("maxAge", h.MaxAge).
+			Dot("maxBackups", h.MaxBackups)
+	}
+
 	for _, h := range a.VictorOpsHandlers {
 		n.Dot("victorOps").
 			Dot("routingKey", h.RoutingKey)
@@ -120,7 +176,8 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 
 	for _, h := range a.PagerDutyHandlers {
 		n.Dot("pagerDuty").
-			Dot("serviceKey", h.ServiceKey)
+			Dot("serviceKey", h.ServiceKey).
+			Dot("maxPayloadSize", h.MaxPayloadSize)
 	}
 
 	for _, h := range a.PagerDuty2Handlers {
@@ -133,6 +190,15 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 				n.Dot("link", l.Href)
 			}
 		}
+		if len(h.FailoverURLs) > 0 {
+			n.Dot("failover", args(h.FailoverURLs)...)
+		}
+		n.Dot("retries", h.Retries).
+			Dot("timeout", h.Timeout).
+			Dot("maxPayloadSize", h.MaxPayloadSize)
+		for _, d := range h.CustomDetails {
+			n.Dot("customDetail", d.Key, d.Value)
+		}
 	}
 
 	for _, h := range a.PushoverHandlers {
@@ -204,7 +270,8 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("workspace", h.Workspace).
 			Dot("channel", h.Channel).
 			Dot("username", h.Username).
-			Dot("iconEmoji", h.IconEmoji)
+			Dot("iconEmoji", h.IconEmoji).
+			Dot("maxPayloadSize", h.MaxPayloadSize)
 	}
 
 	for _, h := range a.TelegramHandlers {
@@ -309,7 +376,16 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 	}
 	for _, h := range a.TeamsHandlers {
 		n.Dot("teams").
-			Dot("channelURL", h.ChannelURL)
+			Dot("channelURL", h.ChannelURL).
+			Dot("maxPayloadSize", h.MaxPayloadSize)
+	}
+
+	for _, h := range a.ZabbixHandlers {
+		n.Dot("zabbix").
+			Dot("server", h.Server).
+			Dot("host", h.Host).
+			Dot("key", h.Key).
+			Dot("value", h.Value)
 	}
 
 	return n.prev, n.err
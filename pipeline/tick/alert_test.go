@@ -32,12 +32,16 @@ func TestAlert(t *testing.T) {
 	alert.Id = "id"
 	alert.Message = "Message"
 	alert.Details = "details"
+	alert.Runbook = "https://wiki.example.com/{{ .ID }}"
 	alert.Crit = newLambda(90)
 	alert.Warn = newLambda(80)
 	alert.Info = newLambda(70)
 	alert.CritReset = newLambda(50)
 	alert.WarnReset = newLambda(40)
 	alert.InfoReset = newLambda(30)
+	alert.WarnFor = 5 * time.Minute
+	alert.CritFor = time.Minute
+	alert.ResolveDelay = 10 * time.Minute
 	alert.Flapping(0.4, 0.7)
 	alert.History = 10
 	alert.LevelTag = "levelTag"
@@ -56,12 +60,16 @@ func TestAlert(t *testing.T) {
         .id('id')
         .message('Message')
         .details('details')
+        .runbook('https://wiki.example.com/{{ .ID }}')
         .info(lambda: "cpu" > 70)
         .warn(lambda: "cpu" > 80)
         .crit(lambda: "cpu" > 90)
         .infoReset(lambda: "cpu" > 30)
         .warnReset(lambda: "cpu" > 40)
         .critReset(lambda: "cpu" > 50)
+        .warnFor(5m)
+        .critFor(1m)
+        .resolveDelay(10m)
         .history(10)
         .levelTag('levelTag')
         .levelField('levelField')
@@ -94,6 +102,75 @@ func TestAlertStateChanges(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertTrigger(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().Trigger("info", "warning")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .trigger('info', 'warning')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertLevel(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().
+		Level("FATAL", newLambda(98)).
+		Level("DOOM", newLambda(99)).
+		Trigger("fatal")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .level('FATAL', lambda: "cpu" > 98)
+        .level('DOOM', lambda: "cpu" > 99)
+        .trigger('fatal')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertFingerprint(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().Fingerprint("host", "service")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .fingerprint('host', 'service')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertUniqueId(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().UniqueId()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .uniqueId()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertHTTPPost(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().Post("http://coinop.com", "http://polybius.gov")
@@ -188,6 +265,28 @@ func TestAlertTeams(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertZabbix(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().Zabbix()
+	handler.Server = "proxy"
+	handler.Host = `{{ index .Tags "host" }}`
+	handler.Key = "kapacitor.cpu"
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .zabbix()
+        .server('proxy')
+        .host('{{ index .Tags "host" }}')
+        .key('kapacitor.cpu')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertHTTPPostMultipleHeaders(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().Post("")
@@ -254,6 +353,45 @@ func TestAlertHTTPPostSkipSSLVerify(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertHTTPPostFailover(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().Post()
+	handler.Endpoint = "us-east"
+	handler.Failover("us-west", "https://eu.example.com/alerts")
+	handler.Retries = 2
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .post()
+        .endpoint('us-east')
+        .retries(2)
+        .failover('us-west', 'https://eu.example.com/alerts')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertTimeSource(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Alert()
+	a.TimeSource = "wall"
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .timeSource('wall')
+        .history(21)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertTCP(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	from.Alert().Tcp("echo:7")
@@ -288,6 +426,7 @@ func TestAlertTCPJSON(t *testing.T) {
         "email": null,
         "exec": null,
         "log": null,
+        "file": null,
         "victorOps": null,
         "pagerDuty": null,
         "pagerDuty2": null,
@@ -369,6 +508,54 @@ func TestAlertLog(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertFile(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().File("/var/log/alerts.csv")
+	handler.Mode = 420
+	handler.Format = "csv"
+	handler.Fields("time", "level", "tags.host")
+	handler.Header()
+	handler.MaxSize = 1024
+	handler.MaxAge = 24 * time.Hour
+	handler.MaxBackups = 5
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .file('/var/log/alerts.csv')
+        .mode(0644)
+        .format('csv')
+        .fields('time', 'level', 'tags.host')
+        .header()
+        .maxSize(1024)
+        .maxAge(1d)
+        .maxBackups(5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertFileTemplate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().File("/var/log/alerts.log")
+	handler.Template = "{{ .Level }} {{ .Message }}"
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .file('/var/log/alerts.log')
+        .template('{{ .Level }} {{ .Message }}')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertVictorOps(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().VictorOps()
@@ -425,6 +612,92 @@ func TestAlertPagerDuty2(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertPagerDuty2Failover(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().PagerDuty2()
+	handler.RoutingKey = "LeafsNation"
+	handler.Failover("https://events.eu.pagerduty.com/v2/enqueue")
+	handler.Retries = 1
+	handler.Timeout = 5 * time.Second
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .pagerDuty2()
+        .routingKey('LeafsNation')
+        .failover('https://events.eu.pagerduty.com/v2/enqueue')
+        .retries(1)
+        .timeout(5s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertPagerDuty2MaxPayloadSize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().PagerDuty2()
+	handler.RoutingKey = "LeafsNation"
+	handler.MaxPayloadSize = 512000
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .pagerDuty2()
+        .routingKey('LeafsNation')
+        .maxPayloadSize(512000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertSlackMaxPayloadSize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().Slack()
+	handler.Channel = "#application"
+	handler.MaxPayloadSize = 40000
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .slack()
+        .channel('#application')
+        .maxPayloadSize(40000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertPagerDuty2CustomDetails(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().PagerDuty2()
+	handler.RoutingKey = "LeafsNation"
+	handler.CustomDetail("host", `{{ index .Tags "host" }}`)
+	handler.CustomDetail("threshold", "90")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .pagerDuty2()
+        .routingKey('LeafsNation')
+        .customDetail('host', '{{ index .Tags "host" }}')
+        .customDetail('threshold', '90')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertPagerDuty2MissingLinkText(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().PagerDuty2()
@@ -842,3 +1115,38 @@ func TestAlertZenoss(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestAlertEvery(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Alert()
+	a.Every = 10 * time.Second
+	a.StateChangesOnly()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .stateChangesOnly()
+        .every(10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertAttachRecent(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().AttachRecent(5)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .attachRecent(5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
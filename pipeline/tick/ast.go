@@ -128,8 +128,26 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewQuery(parents).Build(node)
 	case *pipeline.QueryFluxNode:
 		return NewQueryFlux(parents).Build(node)
+	case *pipeline.QueryLookupNode:
+		return NewQueryLookup(parents).Build(node)
+	case *pipeline.ReorderNode:
+		return NewReorder(parents).Build(node)
+	case *pipeline.RollingNode:
+		return NewRolling(parents).Build(node)
+	case *pipeline.TrendSlopeNode:
+		return NewTrendSlope(parents).Build(node)
+	case *pipeline.BaselineNode:
+		return NewBaseline(parents).Build(node)
+	case *pipeline.SplitNode:
+		return NewSplit(parents).Build(node)
+	case *pipeline.InterpolateNode:
+		return NewInterpolate(parents).Build(node)
+	case *pipeline.OutliersNode:
+		return NewOutliers(parents).Build(node)
 	case *pipeline.SampleNode:
 		return NewSample(parents).Build(node)
+	case *pipeline.LimitNode:
+		return NewLimit(parents).Build(node)
 	case *pipeline.ShiftNode:
 		return NewShift(parents).Build(node)
 	case *pipeline.SideloadNode:
@@ -138,6 +156,8 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewStateCount(parents).Build(node)
 	case *pipeline.StateDurationNode:
 		return NewStateDuration(parents).Build(node)
+	case *pipeline.AutoscaleNode:
+		return NewAutoscale(parents).Build(node)
 	case *pipeline.SwarmAutoscaleNode:
 		return NewSwarmAutoscale(parents).Build(node)
 	case *pipeline.UDFNode:
@@ -0,0 +1,48 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// AutoscaleNode converts the webhook autoscaling pipeline node into the TICKScript AST
+type AutoscaleNode struct {
+	Function
+}
+
+// NewAutoscale creates an Autoscale function builder
+func NewAutoscale(parents []ast.Node) *AutoscaleNode {
+	return &AutoscaleNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Autoscale ast.Node
+func (n *AutoscaleNode) Build(s *pipeline.AutoscaleNode) (ast.Node, error) {
+	n.Pipe("autoscale", s.URL).
+		Dot("timeout", s.Timeout).
+		Dot("resourceName", s.ResourceName).
+		Dot("resourceNameTag", s.ResourceNameTag).
+		Dot("outputResourceNameTag", s.OutputResourceNameTag).
+		Dot("currentField", s.CurrentField).
+		Dot("max", s.Max).
+		Dot("min", s.Min).
+		Dot("replicas", s.Replicas).
+		Dot("increaseCooldown", s.IncreaseCooldown).
+		Dot("decreaseCooldown", s.DecreaseCooldown)
+
+	var headers []string
+	for k := range s.Headers {
+		headers = append(headers, k)
+	}
+	sort.Strings(headers)
+	for _, k := range headers {
+		n.Dot("header", k, s.Headers[k])
+	}
+
+	return n.prev, n.err
+}
@@ -0,0 +1,52 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestAutoscale(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	n := from.Autoscale("http://scaler.example.com/scale")
+	n.Header("Authorization", "Bearer secret")
+	n.Header("Accept", "application/json")
+	n.ResourceNameTag = "service"
+	n.OutputResourceNameTag = "resource"
+	n.CurrentField = "replicas"
+	n.Min = 2
+	n.Max = 20
+	n.Replicas = &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: ast.TokenPlus,
+			Left: &ast.ReferenceNode{
+				Reference: "replicas",
+			},
+			Right: &ast.NumberNode{
+				IsInt: true,
+				Int64: 1,
+				Base:  10,
+			},
+		},
+	}
+	n.IncreaseCooldown = time.Minute
+	n.DecreaseCooldown = 5 * time.Minute
+
+	want := `stream
+    |from()
+    |autoscale('http://scaler.example.com/scale')
+        .timeout(10s)
+        .resourceNameTag('service')
+        .outputResourceNameTag('resource')
+        .currentField('replicas')
+        .max(20)
+        .min(2)
+        .replicas(lambda: "replicas" + 1)
+        .increaseCooldown(1m)
+        .decreaseCooldown(5m)
+        .header('Accept', 'application/json')
+        .header('Authorization', 'Bearer secret')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// BaselineNode converts the BaselineNode pipeline node into the TICKScript AST
+type BaselineNode struct {
+	Function
+}
+
+// NewBaseline creates a BaselineNode function builder
+func NewBaseline(parents []ast.Node) *BaselineNode {
+	return &BaselineNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a BaselineNode ast.Node
+func (n *BaselineNode) Build(b *pipeline.BaselineNode) (ast.Node, error) {
+	n.Pipe("baseline", b.Field, b.Period).
+		Dot("bucket", b.Bucket).
+		Dot("seasons", b.Seasons).
+		Dot("as", b.As).
+		Dot("baselineAs", b.BaselineAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBaseline(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	baseline := from.Baseline("rate", 7*24*time.Hour)
+	baseline.Bucket = 30 * time.Minute
+	baseline.Seasons = 2
+	baseline.As = "diff"
+
+	want := `stream
+    |from()
+    |baseline('rate', 1w)
+        .bucket(30m)
+        .seasons(2)
+        .as('diff')
+        .baselineAs('baseline')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -25,6 +25,9 @@ func (n *ChangeDetectNode) Build(d *pipeline.ChangeDetectNode) (ast.Node, error)
 	for i, f := range d.Fields {
 		fields[i] = f
 	}
-	n.Pipe("changeDetect", fields...)
+	n.Pipe("changeDetect", fields...).
+		Dot("tolerance", d.Tolerance).
+		Dot("previousPrefix", d.PreviousPrefix).
+		Dot("maxGroups", d.MaxGroups)
 	return n.prev, n.err
 }
@@ -14,3 +14,20 @@ func TestChangeDetect(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestChangeDetectTolerance(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.ChangeDetect("value")
+	c.Tolerance = 0.5
+	c.PreviousPrefix = "previous_"
+	c.MaxGroups = 1000
+
+	want := `stream
+    |from()
+    |changeDetect('value')
+        .tolerance(0.5)
+        .previousPrefix('previous_')
+        .maxGroups(1000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -24,6 +24,7 @@ func (n *DerivativeNode) Build(d *pipeline.DerivativeNode) (ast.Node, error) {
 	n.Pipe("derivative", d.Field).
 		Dot("as", d.As).
 		Dot("unit", d.Unit).
-		DotIf("nonNegative", d.NonNegativeFlag)
+		DotIf("nonNegative", d.NonNegativeFlag).
+		DotIf("counterReset", d.CounterResetFlag)
 	return n.prev, n.err
 }
@@ -11,6 +11,7 @@ func TestDerivative(t *testing.T) {
 	d.As = "very important"
 	d.Unit = time.Hour
 	d.NonNegative()
+	d.CounterReset()
 
 	want := `stream
     |from()
@@ -18,6 +19,7 @@ func TestDerivative(t *testing.T) {
         .as('very important')
         .unit(1h)
         .nonNegative()
+        .counterReset()
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
@@ -24,7 +24,12 @@ func (n *EvalNode) Build(e *pipeline.EvalNode) (ast.Node, error) {
 	n.Pipe("eval", largs(e.Lambdas)...).
 		Dot("as", args(e.AsList)...).
 		Dot("tags", args(e.TagsList)...).
-		DotIf("quiet", e.QuietFlag)
+		DotIf("quiet", e.QuietFlag).
+		Dot("nanPolicy", e.NanPolicy).
+		Dot("nanReplacement", e.NanReplacement).
+		Dot("nanTag", e.NanTag).
+		DotIf("nowPointTime", e.NowPointTimeFlag).
+		Dot("maxDynamicNames", e.MaxDynamicNames)
 
 	if e.KeepFlag {
 		n.Dot("keep", args(e.KeepList)...)
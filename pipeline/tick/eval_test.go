@@ -43,3 +43,54 @@ func TestEval(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestEvalNanPolicy(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	eval := from.Eval(&ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: ast.TokenDiv,
+			Left: &ast.ReferenceNode{
+				Reference: "errors",
+			},
+			Right: &ast.ReferenceNode{
+				Reference: "total",
+			},
+		},
+	})
+	eval.As("error_rate")
+	eval.NanPolicy = "replace"
+	eval.NanReplacement = -1
+
+	want := `stream
+    |from()
+    |eval(lambda: "errors" / "total")
+        .as('error_rate')
+        .tags()
+        .nanPolicy('replace')
+        .nanReplacement(-1.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestEvalNowPointTime(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	eval := from.Eval(&ast.LambdaNode{
+		Expression: &ast.FunctionNode{
+			Func: "timeDiff",
+			Args: []ast.Node{
+				&ast.FunctionNode{Func: "now"},
+				&ast.ReferenceNode{Reference: "time"},
+			},
+		},
+	})
+	eval.As("age").NowPointTime()
+
+	want := `stream
+    |from()
+    |eval(lambda: timeDiff(now(), "time"))
+        .as('age')
+        .tags()
+        .nowPointTime()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -29,7 +29,9 @@ func (n *FromNode) Build(f *pipeline.FromNode) (ast.Node, error) {
 		Dot("round", f.Round).
 		Dot("truncate", f.Truncate).
 		Dot("where", f.Lambda)
-	if len(f.Dimensions) > 0 {
+	if len(f.ExcludedDimensions) > 0 {
+		n.Dot("groupByAllExcept", args(f.ExcludedDimensions)...)
+	} else if len(f.Dimensions) > 0 {
 		n.Dot("groupBy", f.Dimensions...)
 	}
 	return n.prev, n.err
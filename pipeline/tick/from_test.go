@@ -52,3 +52,16 @@ func TestFrom(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestFromGroupByAllExcept(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Measurement = "cpu"
+	from.GroupByAllExcept("instance", "pod")
+
+	want := `stream
+    |from()
+        .measurement('cpu')
+        .groupByAllExcept('instance', 'pod')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
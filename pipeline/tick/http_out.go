@@ -22,5 +22,13 @@ func NewHTTPOut(parents []ast.Node) *HTTPOutNode {
 // Build creates a HTTPOutNode ast.Node
 func (n *HTTPOutNode) Build(h *pipeline.HTTPOutNode) (ast.Node, error) {
 	n.Pipe("httpOut", h.Endpoint)
+	if len(h.FieldsList) > 0 {
+		n.Dot("fields", args(h.FieldsList)...)
+	}
+	if len(h.TagsList) > 0 {
+		n.Dot("tags", args(h.TagsList)...)
+	}
+	n.DotIf("fieldsAndTags", h.FieldsAndTagsFlag).
+		DotIf("pretty", h.PrettyFlag)
 	return n.prev, n.err
 }
@@ -14,3 +14,33 @@ func TestHTTPOut(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestHTTPOutFieldsTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.HttpOut("cpu").
+		Fields("usage_idle", "usage_user").
+		Tags("host")
+
+	want := `stream
+    |from()
+    |httpOut('cpu')
+        .fields('usage_idle', 'usage_user')
+        .tags('host')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestHTTPOutFieldsAndTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.HttpOut("cpu").
+		FieldsAndTags().
+		Pretty()
+
+	want := `stream
+    |from()
+    |httpOut('cpu')
+        .fieldsAndTags()
+        .pretty()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -26,7 +26,13 @@ func (n *HTTPPostNode) Build(h *pipeline.HTTPPostNode) (ast.Node, error) {
 	n.Pipe("httpPost", args(h.URLs)...).
 		Dot("codeField", h.CodeField).
 		DotIf("captureResponse", h.CaptureResponseFlag).
-		Dot("timeout", h.Timeout)
+		Dot("timeout", h.Timeout).
+		Dot("batchSize", h.BatchSize)
+	if h.BatchSize > 0 {
+		n.Dot("batchInterval", h.BatchInterval).
+			DotIf("batchByGroup", h.BatchByGroupFlag).
+			Dot("retryTimeout", h.RetryTimeout)
+	}
 
 	for _, e := range h.Endpoints {
 		n.Dot("endpoint", e)
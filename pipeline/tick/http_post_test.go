@@ -50,3 +50,22 @@ func TestHTTPPostEndpoint(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestHTTPPostBatchSize(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+	post := query.HttpPost("http://example.com/api/bulk").
+		BatchByGroup()
+	post.BatchSize = 500
+	post.BatchInterval = 5 * time.Second
+	post.RetryTimeout = time.Minute
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+    |httpPost('http://example.com/api/bulk')
+        .batchSize(500)
+        .batchInterval(5s)
+        .batchByGroup()
+        .retryTimeout(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -36,8 +36,13 @@ func (n *InfluxQLNode) Build(q *pipeline.InfluxQLNode) (ast.Node, error) {
 		}
 		args = append(args, q.Args...)
 	}
+	if q.Predicate != nil {
+		args = append(args, q.Predicate)
+	}
 	n.Pipe(q.Method, args...).
 		Dot("as", q.As).
-		DotIf("usePointTimes", q.PointTimes)
+		DotIf("usePointTimes", q.PointTimes).
+		Dot("approximate", q.Compression).
+		Dot("precision", q.DistinctPrecision)
 	return n.prev, n.err
 }
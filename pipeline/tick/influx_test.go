@@ -2,6 +2,8 @@ package tick_test
 
 import (
 	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
 )
 
 func TestInfluxQLBottom(t *testing.T) {
@@ -45,3 +47,66 @@ func TestInfluxQLCount(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestInfluxQLConditional(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	lambda := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "status",
+			},
+			Right: &ast.NumberNode{
+				IsInt: true,
+				Int64: 500,
+				Base:  10,
+			},
+			Operator: ast.TokenGreaterEqual,
+		},
+	}
+	from.CountIf(lambda).As = "errors"
+	from.SumIf("bytes", lambda)
+	from.MeanIf("latency", lambda)
+
+	want := `var from1 = stream
+    |from()
+
+from1
+    |meanIf('latency', lambda: "status" >= 500)
+        .as('meanIf')
+
+from1
+    |sumIf('bytes', lambda: "status" >= 500)
+        .as('sumIf')
+
+from1
+    |countIf(lambda: "status" >= 500)
+        .as('errors')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestInfluxQLPercentileApproximate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Percentile("latency", 99.0).Approximate(100)
+
+	want := `stream
+    |from()
+    |percentile('latency', 99.0)
+        .as('percentile')
+        .approximate(100.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestInfluxQLDistinctCountPrecision(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.DistinctCount("source_ip").Precision(14)
+
+	want := `stream
+    |from()
+    |distinctCount('source_ip')
+        .as('distinctCount')
+        .precision(14)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
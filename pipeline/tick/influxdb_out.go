@@ -27,11 +27,15 @@ func (n *InfluxDBOutNode) Build(db *pipeline.InfluxDBOutNode) (ast.Node, error)
 		Dot("cluster", db.Cluster).
 		Dot("database", db.Database).
 		Dot("retentionPolicy", db.RetentionPolicy).
+		Dot("bucket", db.Bucket).
+		Dot("org", db.Org).
+		Dot("orgID", db.OrgID).
 		Dot("measurement", db.Measurement).
 		Dot("writeConsistency", db.WriteConsistency).
 		Dot("precision", db.Precision).
 		Dot("buffer", db.Buffer).
 		Dot("flushInterval", db.FlushInterval).
+		Dot("retryTimeout", db.RetryTimeout).
 		DotIf("create", db.CreateFlag)
 
 	var tags []string
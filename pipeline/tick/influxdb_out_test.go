@@ -17,6 +17,7 @@ func TestInfluxDBOut(t *testing.T) {
 	influx.Precision = "ms"
 	influx.Buffer = 10
 	influx.FlushInterval = time.Second
+	influx.RetryTimeout = time.Minute
 	influx.Create()
 
 	want := `stream
@@ -29,9 +30,31 @@ func TestInfluxDBOut(t *testing.T) {
         .precision('ms')
         .buffer(10)
         .flushInterval(1s)
+        .retryTimeout(1m)
         .create()
         .tag('kapacitor', 'true')
         .tag('version', '0.2')
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestInfluxDBOutBucket(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.InfluxDBOut()
+	influx.Cluster = "influxdb2"
+	influx.Bucket = "mybucket"
+	influx.Org = "myorg"
+	influx.Measurement = "errors"
+
+	want := `stream
+    |from()
+    |influxDBOut()
+        .cluster('influxdb2')
+        .bucket('mybucket')
+        .org('myorg')
+        .measurement('errors')
+        .buffer(1000)
+        .flushInterval(10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// InterpolateNode converts the InterpolateNode pipeline node into the TICKScript AST
+type InterpolateNode struct {
+	Function
+}
+
+// NewInterpolate creates an InterpolateNode function builder
+func NewInterpolate(parents []ast.Node) *InterpolateNode {
+	return &InterpolateNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an InterpolateNode ast.Node
+func (n *InterpolateNode) Build(i *pipeline.InterpolateNode) (ast.Node, error) {
+	args := make([]interface{}, len(i.Fields))
+	for j, f := range i.Fields {
+		args[j] = f
+	}
+	n.Pipe("interpolate", args...).
+		Dot("edge", i.Edge)
+	return n.prev, n.err
+}
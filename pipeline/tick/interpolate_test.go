@@ -0,0 +1,18 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	pipe, _, query := BatchQuery("SELECT temperature, humidity FROM sensors")
+	interpolate := query.Interpolate("temperature", "humidity")
+	interpolate.Edge = "nearest"
+
+	want := `batch
+    |query('SELECT temperature, humidity FROM sensors')
+    |interpolate('temperature', 'humidity')
+        .edge('nearest')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
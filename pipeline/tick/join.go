@@ -29,9 +29,22 @@ func (n *JoinNode) Build(j *pipeline.JoinNode) (ast.Node, error) {
 		Dot("as", args(j.Names)...).
 		Dot("on", args(j.Dimensions)...).
 		Dot("delimiter", j.Delimiter).
+		DotIf("suffix", j.SuffixFlag).
+		DotIf("prefixCollisionsOnly", j.PrefixCollisionsOnlyFlag).
 		Dot("streamName", j.StreamName).
 		Dot("tolerance", j.Tolerance).
 		Dot("deleteAll", j.DeleteAll).
 		DotNotNil("fill", j.Fill)
+	for _, r := range j.Renames {
+		n.Dot("rename", r.As, r.Field, r.Name)
+	}
+	for _, fs := range j.FieldSets {
+		args := make([]interface{}, len(fs.Fields)+1)
+		args[0] = fs.As
+		for i, f := range fs.Fields {
+			args[i+1] = f
+		}
+		n.Dot("fields", args...)
+	}
 	return n.prev, n.err
 }
@@ -43,3 +43,42 @@ stream
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestJoinRename(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "cpu"
+
+	from2 := stream2.From()
+	from2.Measurement = "mem"
+
+	join := from1.Join(from2)
+	join.As("cpu", "mem").
+		Suffix().
+		PrefixCollisionsOnly().
+		Rename("mem", "value", "mem_used").
+		Rename("cpu", "value", "cpu_used").
+		Fields("mem", "value", "total")
+
+	want := `var from3 = stream
+    |from()
+        .measurement('mem')
+
+stream
+    |from()
+        .measurement('cpu')
+    |join(from3)
+        .as('cpu', 'mem')
+        .on()
+        .delimiter('.')
+        .suffix()
+        .prefixCollisionsOnly()
+        .rename('mem', 'value', 'mem_used')
+        .rename('cpu', 'value', 'cpu_used')
+        .fields('mem', 'value', 'total')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// LimitNode converts the LimitNode pipeline node into the TICKScript AST
+type LimitNode struct {
+	Function
+}
+
+// NewLimit creates a LimitNode function builder
+func NewLimit(parents []ast.Node) *LimitNode {
+	return &LimitNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a LimitNode ast.Node
+func (n *LimitNode) Build(l *pipeline.LimitNode) (ast.Node, error) {
+	n.Pipe("limit", l.Count, l.Interval).
+		Dot("burst", l.Burst)
+	return n.prev, n.err
+}
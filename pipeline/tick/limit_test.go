@@ -0,0 +1,19 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	limit := from.Limit(2, time.Minute)
+	limit.Burst = 5
+
+	want := `stream
+    |from()
+    |limit(2, 1m)
+        .burst(5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -23,7 +23,9 @@ func NewLog(parents []ast.Node) *LogNode {
 func (n *LogNode) Build(l *pipeline.LogNode) (ast.Node, error) {
 	n.Pipe("log").
 		Dot("level", l.Level).
-		Dot("prefix", l.Prefix)
+		Dot("prefix", l.Prefix).
+		DotIf("fieldsAndTags", l.FieldsAndTagsFlag).
+		DotIf("pretty", l.PrettyFlag)
 
 	return n.prev, n.err
 }
@@ -21,3 +21,19 @@ func TestLog(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestLogFieldsAndTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Log().
+		FieldsAndTags().
+		Pretty()
+
+	want := `stream
+    |from()
+    |log()
+        .level('INFO')
+        .fieldsAndTags()
+        .pretty()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
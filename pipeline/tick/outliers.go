@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// OutliersNode converts the OutliersNode pipeline node into the TICKScript AST
+type OutliersNode struct {
+	Function
+}
+
+// NewOutliers creates an OutliersNode function builder
+func NewOutliers(parents []ast.Node) *OutliersNode {
+	return &OutliersNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an OutliersNode ast.Node
+func (n *OutliersNode) Build(o *pipeline.OutliersNode) (ast.Node, error) {
+	n.Pipe("outliers", o.Field).
+		Dot("method", o.Method).
+		Dot("threshold", o.Threshold).
+		Dot("minSamples", o.MinSamples).
+		Dot("tag", o.Tag).
+		DotIf("drop", o.DropFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestOutliers(t *testing.T) {
+	pipe, _, query := BatchQuery("SELECT latency FROM requests")
+	outliers := query.Outliers("latency")
+	outliers.Method = "iqr"
+	outliers.Threshold = 3
+	outliers.MinSamples = 20
+	outliers.Drop()
+
+	want := `batch
+    |query('SELECT latency FROM requests')
+    |outliers('latency')
+        .method('iqr')
+        .threshold(3.0)
+        .minSamples(20)
+        .tag('outlier')
+        .drop()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -31,7 +31,10 @@ func (n *QueryNode) Build(q *pipeline.QueryNode) (ast.Node, error) {
 		Dot("groupBy", q.Dimensions).
 		DotIf("groupByMeasurement", q.GroupByMeasurementFlag).
 		DotNotNil("fill", q.Fill).
-		Dot("cluster", q.Cluster)
+		Dot("cluster", q.Cluster).
+		Dot("backfill", q.Backfill).
+		Dot("backfillConcurrency", q.BackfillConcurrency).
+		Dot("backfillInterval", q.BackfillInterval)
 
 	return n.prev, n.err
 }
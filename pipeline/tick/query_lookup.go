@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// QueryLookupNode converts the QueryLookupNode pipeline node into the TICKScript AST
+type QueryLookupNode struct {
+	Function
+}
+
+// NewQueryLookup creates a QueryLookupNode function builder
+func NewQueryLookup(parents []ast.Node) *QueryLookupNode {
+	return &QueryLookupNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a QueryLookupNode ast.Node
+func (n *QueryLookupNode) Build(q *pipeline.QueryLookupNode) (ast.Node, error) {
+	n.Pipe("query", q.QueryStr).
+		Dot("as", q.As).
+		Dot("cluster", q.Cluster).
+		DotZeroValueOK("cacheTTL", q.CacheTTL).
+		Dot("cacheSize", q.CacheSize).
+		Dot("errorTag", q.ErrorTag)
+
+	return n.prev, n.err
+}
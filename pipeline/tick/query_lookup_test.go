@@ -0,0 +1,40 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestQueryLookup(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	lookup := from.Query(`SELECT last("cores") FROM "hosts" WHERE "host" = '{{.host}}'`)
+	lookup.As = "cores"
+	lookup.Cluster = "inventory"
+	lookup.CacheTTL = 0
+	lookup.CacheSize = 10
+	lookup.ErrorTag = "query_error"
+
+	want := `stream
+    |from()
+    |query('SELECT last("cores") FROM "hosts" WHERE "host" = \'{{.host}}\'')
+        .as('cores')
+        .cluster('inventory')
+        .cacheTTL(0s)
+        .cacheSize(10)
+        .errorTag('query_error')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestQueryLookupDefaults(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Query(`SELECT last("cores") FROM "hosts" WHERE "host" = '{{.host}}'`)
+
+	want := `stream
+    |from()
+    |query('SELECT last("cores") FROM "hosts" WHERE "host" = \'{{.host}}\'')
+        .as('value')
+        .cacheTTL(5m)
+        .cacheSize(1000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -32,3 +32,21 @@ func TestQuery(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestQueryBackfill(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+
+	query.Every = time.Minute
+	query.Backfill = 24 * time.Hour
+	query.BackfillConcurrency = 4
+	query.BackfillInterval = time.Second
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+        .every(1m)
+        .backfill(1d)
+        .backfillConcurrency(4)
+        .backfillInterval(1s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ReorderNode converts the ReorderNode pipeline node into the TICKScript AST
+type ReorderNode struct {
+	Function
+}
+
+// NewReorder creates a ReorderNode function builder
+func NewReorder(parents []ast.Node) *ReorderNode {
+	return &ReorderNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ReorderNode ast.Node
+func (n *ReorderNode) Build(r *pipeline.ReorderNode) (ast.Node, error) {
+	n.Pipe("reorder", r.Lateness).
+		Dot("bufferSize", r.BufferSize)
+	return n.prev, n.err
+}
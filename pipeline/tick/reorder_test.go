@@ -0,0 +1,19 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReorder(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	reorder := from.Reorder(10 * time.Second)
+	reorder.BufferSize = 5000
+
+	want := `stream
+    |from()
+    |reorder(10s)
+        .bufferSize(5000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// RollingNode converts the RollingNode pipeline node into the TICKScript AST
+type RollingNode struct {
+	Function
+}
+
+// NewRolling creates a RollingNode function builder
+func NewRolling(parents []ast.Node) *RollingNode {
+	return &RollingNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a RollingNode ast.Node
+func (n *RollingNode) Build(r *pipeline.RollingNode) (ast.Node, error) {
+	method := "rollingMean"
+	if r.Aggregate == pipeline.RollingSum {
+		method = "rollingSum"
+	}
+	n.Pipe(method, r.Field, r.Count).
+		Dot("period", r.Period).
+		Dot("as", r.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,33 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollingMean(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	rolling := from.RollingMean("usage_idle", 100)
+	rolling.Period = 5 * time.Minute
+	rolling.As = "smoothed"
+
+	want := `stream
+    |from()
+    |rollingMean('usage_idle', 100)
+        .period(5m)
+        .as('smoothed')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestRollingSum(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.RollingSum("errors", 10)
+
+	want := `stream
+    |from()
+    |rollingSum('errors', 10)
+        .as('sum')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
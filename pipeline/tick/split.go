@@ -0,0 +1,44 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SplitNode converts the SplitNode pipeline node into the TICKScript AST
+type SplitNode struct {
+	Function
+}
+
+// NewSplit creates a SplitNode function builder
+func NewSplit(parents []ast.Node) *SplitNode {
+	return &SplitNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a SplitNode ast.Node
+func (n *SplitNode) Build(s *pipeline.SplitNode) (ast.Node, error) {
+	n.Pipe("split")
+	for _, p := range s.Points {
+		n.Dot("point").
+			Dot("measurement", p.Measurement).
+			Dot("offset", p.Offset)
+		keys := make([]string, 0, len(p.Tags))
+		for k := range p.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			n.Dot("tag", k, p.Tags[k])
+		}
+		for _, f := range p.Fields {
+			n.Dot("field", f.Name, f.Lambda)
+		}
+	}
+	return n.prev, n.err
+}
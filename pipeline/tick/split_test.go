@@ -0,0 +1,45 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestSplit(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	split := from.Split()
+	split.Point().
+		Tag("mode", "user").
+		Field("usage", &ast.LambdaNode{
+			Expression: &ast.ReferenceNode{
+				Reference: "usage_user",
+			},
+		})
+	p := split.Point()
+	p.Measurement = "cpu_system"
+	p.Offset = time.Millisecond
+	p.Tag("mode", "system").
+		Tag("cpu", "total").
+		Field("usage", &ast.LambdaNode{
+			Expression: &ast.ReferenceNode{
+				Reference: "usage_system",
+			},
+		})
+
+	want := `stream
+    |from()
+    |split()
+        .point()
+        .tag('mode', 'user')
+        .field('usage', lambda: "usage_user")
+        .point()
+        .measurement('cpu_system')
+        .offset(1ms)
+        .tag('cpu', 'total')
+        .tag('mode', 'system')
+        .field('usage', lambda: "usage_system")
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -22,6 +22,8 @@ func NewStats(parents []ast.Node) *StatsNode {
 // Build StatsNode ast.Node
 func (n *StatsNode) Build(s *pipeline.StatsNode) (ast.Node, error) {
 	n.Pipe("stats", s.Interval).
-		DotIf("align", s.AlignFlag)
+		DotIf("align", s.AlignFlag).
+		DotIf("silence", s.SilenceFlag).
+		Dot("maxGroups", s.MaxGroups)
 	return n.prev, n.err
 }
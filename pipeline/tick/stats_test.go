@@ -19,3 +19,20 @@ from1
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestStatsSilence(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	stats := from.Stats(time.Minute)
+	stats.Silence()
+	stats.MaxGroups = 100
+
+	want := `var from1 = stream
+    |from()
+
+from1
+    |stats(1m)
+        .silence()
+        .maxGroups(100)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// TrendSlopeNode converts the TrendSlopeNode pipeline node into the TICKScript AST
+type TrendSlopeNode struct {
+	Function
+}
+
+// NewTrendSlope creates a TrendSlopeNode function builder
+func NewTrendSlope(parents []ast.Node) *TrendSlopeNode {
+	return &TrendSlopeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a TrendSlopeNode ast.Node
+func (n *TrendSlopeNode) Build(t *pipeline.TrendSlopeNode) (ast.Node, error) {
+	n.Pipe("trendSlope", t.Field, t.Period).
+		Dot("unit", t.Unit).
+		Dot("as", t.As).
+		Dot("r2", t.R2).
+		Dot("maxPoints", t.MaxPoints)
+	return n.prev, n.err
+}
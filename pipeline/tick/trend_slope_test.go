@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrendSlope(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	trend := from.TrendSlope("used_percent", 6*time.Hour)
+	trend.Unit = time.Hour
+	trend.R2 = "r2"
+	trend.MaxPoints = 100
+
+	want := `stream
+    |from()
+    |trendSlope('used_percent', 6h)
+        .unit(1h)
+        .as('slope')
+        .r2('r2')
+        .maxPoints(100)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -27,6 +27,9 @@ func (n *WindowNode) Build(w *pipeline.WindowNode) (ast.Node, error) {
 		Dot("periodCount", w.PeriodCount).
 		Dot("everyCount", w.EveryCount).
 		DotIf("align", w.AlignFlag).
-		DotIf("fillPeriod", w.FillPeriodFlag)
+		Dot("alignEpoch", w.AlignEpoch).
+		DotIf("fillPeriod", w.FillPeriodFlag).
+		Dot("partialEvery", w.PartialEvery).
+		Dot("partialTag", w.PartialTag)
 	return n.prev, n.err
 }
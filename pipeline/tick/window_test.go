@@ -9,12 +9,15 @@ import (
 
 func TestWindowNode(t *testing.T) {
 	type args struct {
-		period      time.Duration
-		every       time.Duration
-		align       bool
-		fillPeriod  bool
-		periodCount int64
-		everyCount  int64
+		period       time.Duration
+		every        time.Duration
+		align        bool
+		alignEpoch   string
+		fillPeriod   bool
+		periodCount  int64
+		everyCount   int64
+		partialEvery time.Duration
+		partialTag   string
 	}
 	tests := []struct {
 		name string
@@ -36,6 +39,38 @@ func TestWindowNode(t *testing.T) {
         .every(1h)
         .align()
         .fillPeriod()
+`,
+		},
+		{
+			name: "window aligned to an epoch",
+			args: args{
+				period:     15 * time.Minute,
+				every:      15 * time.Minute,
+				alignEpoch: "2024-01-01T00:05:00Z",
+			},
+			want: `stream
+    |from()
+    |window()
+        .period(15m)
+        .every(15m)
+        .alignEpoch('2024-01-01T00:05:00Z')
+`,
+		},
+		{
+			name: "window with partial windows",
+			args: args{
+				period:       time.Minute,
+				every:        time.Minute,
+				partialEvery: 5 * time.Second,
+				partialTag:   "inProgress",
+			},
+			want: `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+        .partialEvery(5s)
+        .partialTag('inProgress')
 `,
 		},
 		{
@@ -62,9 +97,12 @@ func TestWindowNode(t *testing.T) {
 			w.Period = tt.args.period
 			w.Every = tt.args.every
 			w.AlignFlag = tt.args.align
+			w.AlignEpoch = tt.args.alignEpoch
 			w.FillPeriodFlag = tt.args.fillPeriod
 			w.PeriodCount = tt.args.periodCount
 			w.EveryCount = tt.args.everyCount
+			w.PartialEvery = tt.args.partialEvery
+			w.PartialTag = tt.args.partialTag
 
 			got, err := PipelineTick(pipe)
 			if err != nil {
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// DefaultTrendSlopeMaxPoints is the default maximum number of points retained per group by a TrendSlopeNode.
+const DefaultTrendSlopeMaxPoints = 1000
+
+// A TrendSlopeNode adds the slope of the least squares regression line of a field
+// over a trailing window of each group to every point of a stream.
+//
+// The slope is the rate of change of the field per unit of time,
+// fitted over the points of the group no older than the window from the current point, including the current point.
+// Optionally the coefficient of determination R² of the fit is added as well,
+// which is 1 for points on a line and close to 0 for points without a linear trend.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('disk')
+//	        .groupBy('host', 'path')
+//	    |trendSlope('used_percent', 6h)
+//	        .unit(1h)
+//	        .as('slope')
+//	        .r2('r2')
+//	    |alert()
+//	        .crit(lambda: "slope" > 0 AND (100.0 - "used_percent") / "slope" < 24.0 AND "r2" > 0.8)
+//
+// Alert when the disk of a host will fill in less than 24 hours at the rate of the last 6 hours,
+// and the usage is growing steadily.
+//
+// Until the window of the group holds at least two points with distinct times, such as for the first point of the group,
+// there is no line to fit and the slope and R² are 0, so that alerts on the R² ignore these points.
+// Points missing the field or with a non numeric field are dropped.
+// The points of a group are dropped when the group is deleted.
+//
+// At most MaxPoints points are retained per group, older points are dropped first,
+// so MaxPoints bounds the memory used by each group regardless of the window.
+//
+// The regression is computed afresh for every point, in two passes over the retained points:
+// the first computes the means of the times and values, the second the sums of the products of their deviations from the means.
+// Times are taken relative to the current point so they stay small however long the stream has run.
+// Unlike running sums, which lose precision by subtracting large nearly equal numbers,
+// the result stays accurate for long windows and for values with a large offset,
+// such as byte counts or epoch timestamps, at the cost of time proportional to the number of retained points.
+type TrendSlopeNode struct {
+	chainnode `json:"-"`
+
+	// The field of which the slope is computed.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The trailing window of points of the group to fit.
+	// tick:ignore
+	Period time.Duration `json:"period"`
+
+	// The unit of time of the slope, i.e. 1h gives the change of the field per hour.
+	// Defaults to 1s.
+	Unit time.Duration `json:"unit"`
+
+	// The name of the slope field.
+	// Defaults to slope.
+	As string `json:"as"`
+
+	// The name of the R² field.
+	// Empty does not add the R² of the fit.
+	R2 string `json:"r2"`
+
+	// The maximum number of points retained per group.
+	// Defaults to 1000.
+	MaxPoints int64 `json:"maxPoints"`
+}
+
+func newTrendSlopeNode(field string, period time.Duration) *TrendSlopeNode {
+	return &TrendSlopeNode{
+		chainnode: newBasicChainNode("trendSlope", StreamEdge, StreamEdge),
+		Field:     field,
+		Period:    period,
+		Unit:      time.Second,
+		As:        "slope",
+		MaxPoints: DefaultTrendSlopeMaxPoints,
+	}
+}
+
+// MarshalJSON converts TrendSlopeNode to JSON
+// tick:ignore
+func (n *TrendSlopeNode) MarshalJSON() ([]byte, error) {
+	type Alias TrendSlopeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Unit   string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "trendSlope",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+		Unit:   influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TrendSlopeNode
+// tick:ignore
+func (n *TrendSlopeNode) UnmarshalJSON(data []byte) error {
+	type Alias TrendSlopeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Unit   string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "trendSlope" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TrendSlopeNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *TrendSlopeNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for the trend slope")
+	}
+	if n.Period <= 0 {
+		return fmt.Errorf("window must be positive, got %v", n.Period)
+	}
+	if n.Unit <= 0 {
+		return fmt.Errorf("unit must be positive, got %v", n.Unit)
+	}
+	if n.MaxPoints < 2 {
+		return fmt.Errorf("maxPoints must be at least 2, got %d", n.MaxPoints)
+	}
+	if n.As == "" {
+		return errors.New("must specify a name for the trend slope")
+	}
+	if n.R2 == n.As {
+		return fmt.Errorf("the trend slope and R² must have different names, got %q", n.As)
+	}
+	return nil
+}
@@ -32,6 +32,39 @@ import (
 // new data and `5 minutes` of the previous period's data.
 //
 // NOTE: Because no `align` property is defined, the `window` edge is defined relative to the first data point.
+//
+// The `partialEvery` property emits the in-progress window before it is complete,
+// so that progressive aggregates can be shown with low latency.
+//
+// Example:
+//
+//	stream
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	        .partialEvery(5s)
+//	    |mean('value')
+//	    |httpOut('progress')
+//
+// This example emits the current minute every `5 seconds`, tagged with `partial=true`,
+// and the complete minute once it has passed without the tag.
+//
+// The `alignEpoch` property aligns the window edges to a shared epoch,
+// so that tasks computing windows of the same `every` emit them with identical times,
+// whenever each task started.
+//
+// Example:
+//
+//	stream
+//	    |window()
+//	        .period(15m)
+//	        .every(15m)
+//	        .alignEpoch('2024-01-01T00:05:00Z')
+//	    |mean('value')
+//	    |influxDBOut()
+//
+// The windows of this example end at 00:05, 00:20, 00:35 and 00:50 of every hour,
+// in every task using the same epoch and `every`.
 type WindowNode struct {
 	chainnode `json:"-"`
 	// The period, or length in time, of the window.
@@ -42,6 +75,12 @@ type WindowNode struct {
 	// Whether to align the window edges with the zero time
 	// tick:ignore
 	AlignFlag bool `json:"align" tick:"Align"`
+	// The epoch the window edges are aligned to, as an RFC3339 time.
+	// The edges are the epoch plus multiples of the every property,
+	// so windows with the same epoch and every have identical edges across tasks.
+	// Partial windows are aligned to multiples of partialEvery since the epoch.
+	// Setting the epoch aligns the window, the align property is not needed.
+	AlignEpoch string `json:"alignEpoch"`
 	// Whether to wait till the period is full before the first emit.
 	// tick:ignore
 	FillPeriodFlag bool `json:"fillPeriod" tick:"FillPeriod"`
@@ -51,6 +90,15 @@ type WindowNode struct {
 	// EveryCount determines how often the window is emitted based on the count of points.
 	// A value of 1 means that every new point will emit the window.
 	EveryCount int64 `json:"everyCount"`
+
+	// How often the partial window is emitted before the window is complete.
+	// Partial windows contain the points of the current window received so far.
+	// If zero, only complete windows are emitted.
+	PartialEvery time.Duration `json:"partialEvery"`
+	// The name of the tag set to "true" on partial windows.
+	// Complete windows do not have the tag.
+	// If empty the tag is named partial.
+	PartialTag string `json:"partialTag"`
 }
 
 func newWindowNode() *WindowNode {
@@ -66,16 +114,18 @@ func (n *WindowNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period       string `json:"period"`
+		Every        string `json:"every"`
+		PartialEvery string `json:"partialEvery"`
 	}{
 		TypeOf: TypeOf{
 			Type: "window",
 			ID:   n.ID(),
 		},
-		Alias:  (*Alias)(n),
-		Period: influxql.FormatDuration(n.Period),
-		Every:  influxql.FormatDuration(n.Every),
+		Alias:        (*Alias)(n),
+		Period:       influxql.FormatDuration(n.Period),
+		Every:        influxql.FormatDuration(n.Every),
+		PartialEvery: influxql.FormatDuration(n.PartialEvery),
 	}
 	return json.Marshal(raw)
 }
@@ -87,8 +137,9 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period       string `json:"period"`
+		Every        string `json:"every"`
+		PartialEvery string `json:"partialEvery"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -110,6 +161,13 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if raw.PartialEvery != "" {
+		n.PartialEvery, err = influxql.ParseDuration(raw.PartialEvery)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.setID(raw.ID)
 	return nil
 }
@@ -138,11 +196,30 @@ func (w *WindowNode) validate() error {
 	if w.PeriodCount != 0 && w.Period != 0 {
 		return errors.New("cannot specify both period and periodCount")
 	}
-	if w.PeriodCount != 0 && w.AlignFlag {
+	if w.PeriodCount != 0 && (w.AlignFlag || w.AlignEpoch != "") {
 		return errors.New("can only align windows based off time, not count")
 	}
+	if w.AlignEpoch != "" {
+		if _, err := time.Parse(time.RFC3339Nano, w.AlignEpoch); err != nil {
+			return fmt.Errorf("invalid alignEpoch %q, must be an RFC3339 time: %v", w.AlignEpoch, err)
+		}
+		if w.Every == 0 {
+			return errors.New("alignEpoch requires every to be greater than zero")
+		}
+	}
 	if w.PeriodCount != 0 && w.EveryCount <= 0 {
 		return errors.New("everyCount must be greater than zero")
 	}
+	if w.PartialEvery < 0 {
+		return errors.New("partialEvery must not be negative")
+	}
+	if w.PartialEvery > 0 {
+		if w.Period == 0 {
+			return errors.New("partialEvery requires a window based off time")
+		}
+		if w.PartialEvery >= w.Every {
+			return errors.New("partialEvery must be less than every")
+		}
+	}
 	return nil
 }
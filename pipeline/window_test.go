@@ -3,6 +3,7 @@ package pipeline
 import (
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -12,9 +13,12 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 		Period         time.Duration
 		Every          time.Duration
 		AlignFlag      bool
+		AlignEpoch     string
 		FillPeriodFlag bool
 		PeriodCount    int64
 		EveryCount     int64
+		PartialEvery   time.Duration
+		PartialTag     string
 	}
 	tests := []struct {
 		name    string
@@ -28,11 +32,14 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				Period:         time.Hour,
 				Every:          time.Minute,
 				AlignFlag:      true,
+				AlignEpoch:     "2024-01-01T00:05:00Z",
 				FillPeriodFlag: true,
 				PeriodCount:    1,
 				EveryCount:     2,
+				PartialEvery:   10 * time.Second,
+				PartialTag:     "inProgress",
 			},
-			want: `{"typeOf":"window","id":"0","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":true,"alignEpoch":"2024-01-01T00:05:00Z","fillPeriod":true,"periodCount":1,"everyCount":2,"partialTag":"inProgress","period":"1h","every":"1m","partialEvery":"10s"}`,
 		},
 		{
 			name: "only period and every",
@@ -40,7 +47,7 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				Period: time.Hour,
 				Every:  time.Minute,
 			},
-			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":false,"alignEpoch":"","fillPeriod":false,"periodCount":0,"everyCount":0,"partialTag":"","period":"1h","every":"1m","partialEvery":"0s"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -49,9 +56,12 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 			w.Period = tt.fields.Period
 			w.Every = tt.fields.Every
 			w.AlignFlag = tt.fields.AlignFlag
+			w.AlignEpoch = tt.fields.AlignEpoch
 			w.FillPeriodFlag = tt.fields.FillPeriodFlag
 			w.PeriodCount = tt.fields.PeriodCount
 			w.EveryCount = tt.fields.EveryCount
+			w.PartialEvery = tt.fields.PartialEvery
+			w.PartialTag = tt.fields.PartialTag
 			MarshalTestHelper(t, w, tt.wantErr, tt.want)
 		})
 	}
@@ -66,14 +76,17 @@ func TestWindowNode_UnmarshalJSON(t *testing.T) {
 	}{
 		{
 			name:  "all fields set",
-			input: `{"typeOf":"window","id":"0","period":"1h","every":"1m","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2}`,
+			input: `{"typeOf":"window","id":"0","period":"1h","every":"1m","align":true,"alignEpoch":"2024-01-01T00:05:00Z","fillPeriod":true,"periodCount":1,"everyCount":2,"partialEvery":"10s","partialTag":"inProgress"}`,
 			want: &WindowNode{
 				Period:         time.Hour,
 				Every:          time.Minute,
 				AlignFlag:      true,
+				AlignEpoch:     "2024-01-01T00:05:00Z",
 				FillPeriodFlag: true,
 				PeriodCount:    1,
 				EveryCount:     2,
+				PartialEvery:   10 * time.Second,
+				PartialTag:     "inProgress",
 			},
 		},
 		{
@@ -131,3 +144,45 @@ func TestWindowNode_UnmarshalJSON(t *testing.T) {
 	}
 
 }
+
+func TestWindowNode_ValidateAlignEpoch(t *testing.T) {
+	tests := []struct {
+		name string
+		w    *WindowNode
+		err  string
+	}{
+		{
+			name: "valid epoch",
+			w:    &WindowNode{Period: time.Minute, Every: time.Minute, AlignEpoch: "2024-01-01T00:05:00Z"},
+		},
+		{
+			name: "invalid epoch",
+			w:    &WindowNode{Period: time.Minute, Every: time.Minute, AlignEpoch: "2024-01-01"},
+			err:  `invalid alignEpoch "2024-01-01", must be an RFC3339 time`,
+		},
+		{
+			name: "no every",
+			w:    &WindowNode{Period: time.Minute, AlignEpoch: "2024-01-01T00:05:00Z"},
+			err:  "alignEpoch requires every to be greater than zero",
+		},
+		{
+			name: "count window",
+			w:    &WindowNode{PeriodCount: 10, EveryCount: 10, AlignEpoch: "2024-01-01T00:05:00Z"},
+			err:  "can only align windows based off time, not count",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.w.validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("unexpected error got %v exp %q", err, tt.err)
+			}
+		})
+	}
+}
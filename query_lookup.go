@@ -0,0 +1,283 @@
+package kapacitor
+
+import (
+	"container/list"
+	"encoding/json"
+	"io"
+	"strings"
+	text "text/template"
+	"time"
+
+	"github.com/influxdata/kapacitor/bufpool"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+const (
+	statsQueryLookupErrors      = "query_errors"
+	statsQueryLookupCacheHits   = "cache_hits"
+	statsQueryLookupCacheMisses = "cache_misses"
+)
+
+type QueryLookupNode struct {
+	node
+	q          *pipeline.QueryLookupNode
+	tmpl       *text.Template
+	bufferPool *bufpool.Pool
+	con        influxdb.Client
+	cache      *lookupCache
+
+	// Result of the lookup for the current batch
+	batchValue interface{}
+	batchErr   error
+
+	queryErrors *expvar.Int
+	cacheHits   *expvar.Int
+	cacheMisses *expvar.Int
+}
+
+// Create a new QueryLookupNode which adds fields with values queried from InfluxDB.
+func newQueryLookupNode(et *ExecutingTask, n *pipeline.QueryLookupNode, d NodeDiagnostic) (*QueryLookupNode, error) {
+	tmpl, err := text.New("query").Option("missingkey=zero").Parse(n.QueryStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse query template")
+	}
+	qn := &QueryLookupNode{
+		node:       node{Node: n, et: et, diag: d},
+		q:          n,
+		tmpl:       tmpl,
+		bufferPool: bufpool.New(),
+		cache:      newLookupCache(n.CacheTTL, int(n.CacheSize)),
+	}
+	qn.node.runF = qn.runQueryLookup
+	return qn, nil
+}
+
+func (n *QueryLookupNode) runQueryLookup([]byte) error {
+	n.queryErrors = &expvar.Int{}
+	n.cacheHits = &expvar.Int{}
+	n.cacheMisses = &expvar.Int{}
+	n.statMap.Set(statsQueryLookupErrors, n.queryErrors)
+	n.statMap.Set(statsQueryLookupCacheHits, n.cacheHits)
+	n.statMap.Set(statsQueryLookupCacheMisses, n.cacheMisses)
+
+	if n.et.tm.InfluxDBService == nil {
+		return errors.New("InfluxDB not configured, cannot query InfluxDB for lookup")
+	}
+	con, err := n.et.tm.InfluxDBService.NewNamedClient(n.q.Cluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to get InfluxDB client")
+	}
+	n.con = con
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// lookup returns the value for the tags, from the cache if possible.
+// A nil value means the query returned no data.
+func (n *QueryLookupNode) lookup(tags models.Tags) (interface{}, error) {
+	value, err := n.doLookup(tags)
+	if err != nil && n.q.ErrorTag != "" {
+		// The error is not returned up the pipeline, so report it here.
+		n.diag.Error("lookup failed", err, keyvalue.KV("query", n.q.QueryStr))
+	}
+	return value, err
+}
+
+func (n *QueryLookupNode) doLookup(tags models.Tags) (interface{}, error) {
+	buf := n.bufferPool.Get()
+	defer n.bufferPool.Put(buf)
+	if err := renderQuery(n.tmpl, buf, tags); err != nil {
+		return nil, errors.Wrap(err, "failed to evaluate query template")
+	}
+	q := buf.String()
+
+	now := time.Now()
+	if value, ok := n.cache.get(q, now); ok {
+		n.cacheHits.Add(1)
+		return value, nil
+	}
+	n.cacheMisses.Add(1)
+
+	n.timer.Pause()
+	resp, err := n.con.Query(influxdb.Query{Command: q})
+	n.timer.Resume()
+	if err == nil {
+		err = resp.Error()
+	}
+	if err != nil {
+		n.queryErrors.Add(1)
+		return nil, errors.Wrap(err, "failed to execute lookup query")
+	}
+	value := firstValue(resp)
+	n.cache.set(q, value, now)
+	return value, nil
+}
+
+// queryTagEscaper escapes a tag value within a single quoted InfluxQL string literal.
+var queryTagEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
+
+// renderQuery evaluates the query template with the escaped tag values,
+// so that a tag value cannot end the string literal it is in and change the query.
+func renderQuery(tmpl *text.Template, w io.Writer, tags models.Tags) error {
+	escaped := make(models.Tags, len(tags))
+	for k, v := range tags {
+		escaped[k] = queryTagEscaper.Replace(v)
+	}
+	return tmpl.Execute(w, escaped)
+}
+
+// firstValue returns the first non time value of the first row of the response.
+func firstValue(resp *influxdb.Response) interface{} {
+	for _, res := range resp.Results {
+		for _, series := range res.Series {
+			for _, values := range series.Values {
+				for i, c := range series.Columns {
+					if c == "time" || i >= len(values) || values[i] == nil {
+						continue
+					}
+					value := values[i]
+					if n, ok := value.(json.Number); ok {
+						f, err := n.Float64()
+						if err == nil {
+							value = f
+						}
+					}
+					return value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// apply adds the looked up value to the point or records the lookup error.
+func (n *QueryLookupNode) apply(p edge.FieldsTagsTimeSetter, value interface{}, err error) error {
+	if err != nil {
+		if n.q.ErrorTag == "" {
+			return err
+		}
+		tags := p.Tags().Copy()
+		tags[n.q.ErrorTag] = err.Error()
+		p.SetTags(tags)
+		return nil
+	}
+	if value != nil {
+		fields := p.Fields().Copy()
+		fields[n.q.As] = value
+		p.SetFields(fields)
+	}
+	return nil
+}
+
+func (n *QueryLookupNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	n.batchValue, n.batchErr = n.lookup(begin.Tags())
+	if n.batchErr != nil && n.q.ErrorTag == "" {
+		return nil, n.batchErr
+	}
+	return begin, nil
+}
+
+func (n *QueryLookupNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if err := n.apply(bp, n.batchValue, n.batchErr); err != nil {
+		return nil, err
+	}
+	return bp, nil
+}
+
+func (n *QueryLookupNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	n.batchValue, n.batchErr = nil, nil
+	return end, nil
+}
+
+func (n *QueryLookupNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	value, err := n.lookup(p.Tags())
+	if err := n.apply(p, value, err); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (n *QueryLookupNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *QueryLookupNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *QueryLookupNode) Done() {}
+
+// lookupCache is a size bounded LRU cache of query results that expire after a TTL.
+type lookupCache struct {
+	ttl     time.Duration
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lookupCacheEntry struct {
+	query   string
+	value   interface{}
+	expires time.Time
+}
+
+func newLookupCache(ttl time.Duration, size int) *lookupCache {
+	return &lookupCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lookupCache) get(query string, now time.Time) (interface{}, bool) {
+	e, ok := c.entries[query]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*lookupCacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.entries, query)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return entry.value, true
+}
+
+func (c *lookupCache) set(query string, value interface{}, now time.Time) {
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	if e, ok := c.entries[query]; ok {
+		entry := e.Value.(*lookupCacheEntry)
+		entry.value = value
+		entry.expires = now.Add(c.ttl)
+		c.order.MoveToFront(e)
+		return
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lookupCacheEntry).query)
+	}
+	c.entries[query] = c.order.PushFront(&lookupCacheEntry{
+		query:   query,
+		value:   value,
+		expires: now.Add(c.ttl),
+	})
+}
@@ -0,0 +1,47 @@
+package kapacitor
+
+import (
+	"bytes"
+	"testing"
+	text "text/template"
+
+	"github.com/influxdata/influxql"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestRenderQuery(t *testing.T) {
+	tmpl := text.Must(text.New("query").Option("missingkey=zero").Parse(`SELECT last("cores") FROM "hosts" WHERE "host" = '{{.host}}'`))
+	testCases := []struct {
+		host string
+		exp  string
+	}{
+		{
+			host: "serverA",
+			exp:  `SELECT last("cores") FROM "hosts" WHERE "host" = 'serverA'`,
+		},
+		{
+			host: `x' OR 1=1; DROP MEASUREMENT "hosts"; SELECT * FROM "secrets" WHERE 'a'='a`,
+			exp:  `SELECT last("cores") FROM "hosts" WHERE "host" = 'x\' OR 1=1; DROP MEASUREMENT "hosts"; SELECT * FROM "secrets" WHERE \'a\'=\'a'`,
+		},
+		{
+			host: `x\' OR 'a'='a`,
+			exp:  `SELECT last("cores") FROM "hosts" WHERE "host" = 'x\\\' OR \'a\'=\'a'`,
+		},
+	}
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := renderQuery(tmpl, &buf, models.Tags{"host": tc.host}); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.exp {
+			t.Errorf("unexpected query for host %q:\ngot %s\nexp %s", tc.host, got, tc.exp)
+		}
+		q, err := influxql.ParseQuery(buf.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(q.Statements) != 1 {
+			t.Errorf("unexpected number of statements for host %q: got %d exp 1", tc.host, len(q.Statements))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package kapacitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsReorderPointsDropped = "points_dropped"
+)
+
+type ReorderNode struct {
+	node
+	r *pipeline.ReorderNode
+
+	pointsDropped *expvar.Int
+}
+
+// Create a new ReorderNode which emits the points of a stream in time order per group.
+func newReorderNode(et *ExecutingTask, n *pipeline.ReorderNode, d NodeDiagnostic) (*ReorderNode, error) {
+	rn := &ReorderNode{
+		node:          node{Node: n, et: et, diag: d},
+		r:             n,
+		pointsDropped: new(expvar.Int),
+	}
+	rn.node.runF = rn.runReorder
+	return rn, nil
+}
+
+func (n *ReorderNode) runReorder([]byte) error {
+	n.statMap.Set(statsReorderPointsDropped, n.pointsDropped)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *ReorderNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return &reorderBuffer{
+		n: n,
+	}, nil
+}
+
+// reorderBuffer holds the points of a group sorted by time.
+type reorderBuffer struct {
+	n      *ReorderNode
+	points []edge.PointMessage
+	// Latest time of any point of the group.
+	latest time.Time
+	// Time of the last emitted point, earlier points are dropped.
+	emitted time.Time
+}
+
+func (b *reorderBuffer) BeginBatch(begin edge.BeginBatchMessage) error {
+	return edge.Forward(b.n.outs, begin)
+}
+
+func (b *reorderBuffer) BatchPoint(bp edge.BatchPointMessage) error {
+	return edge.Forward(b.n.outs, bp)
+}
+
+func (b *reorderBuffer) EndBatch(end edge.EndBatchMessage) error {
+	return edge.Forward(b.n.outs, end)
+}
+
+func (b *reorderBuffer) Point(p edge.PointMessage) error {
+	b.n.timer.Start()
+	t := p.Time()
+	if t.Before(b.emitted) {
+		b.n.pointsDropped.Add(1)
+		b.n.timer.Stop()
+		return nil
+	}
+	if t.After(b.latest) {
+		b.latest = t
+	}
+	// Insert after any points with the same time, to keep their arrival order.
+	i := sort.Search(len(b.points), func(i int) bool {
+		return b.points[i].Time().After(t)
+	})
+	b.points = append(b.points, nil)
+	copy(b.points[i+1:], b.points[i:])
+	b.points[i] = p
+
+	// Emit the points that are past the lateness and any points over the buffer size.
+	n := sort.Search(len(b.points), func(i int) bool {
+		return b.points[i].Time().After(b.latest.Add(-b.n.r.Lateness))
+	})
+	if over := len(b.points) - int(b.n.r.BufferSize); over > n {
+		n = over
+	}
+	b.n.timer.Stop()
+	return b.emit(n)
+}
+
+// emit forwards the first n buffered points.
+func (b *reorderBuffer) emit(n int) error {
+	if n == 0 {
+		return nil
+	}
+	for _, p := range b.points[:n] {
+		if err := edge.Forward(b.n.outs, p); err != nil {
+			return err
+		}
+	}
+	b.emitted = b.points[n-1].Time()
+	copy(b.points, b.points[n:])
+	for i := len(b.points) - n; i < len(b.points); i++ {
+		b.points[i] = nil
+	}
+	b.points = b.points[:len(b.points)-n]
+	return nil
+}
+
+func (b *reorderBuffer) Barrier(barrier edge.BarrierMessage) error {
+	// No points earlier than the barrier are expected, so emit them before the barrier.
+	t := barrier.Time()
+	n := sort.Search(len(b.points), func(i int) bool {
+		return b.points[i].Time().After(t)
+	})
+	if err := b.emit(n); err != nil {
+		return err
+	}
+	if t.After(b.emitted) {
+		b.emitted = t
+	}
+	return edge.Forward(b.n.outs, barrier)
+}
+
+func (b *reorderBuffer) DeleteGroup(d edge.DeleteGroupMessage) error {
+	if err := b.emit(len(b.points)); err != nil {
+		return err
+	}
+	return edge.Forward(b.n.outs, d)
+}
+
+func (b *reorderBuffer) Done() {
+	if err := b.emit(len(b.points)); err != nil {
+		b.n.diag.Error("failed to emit buffered points", err)
+	}
+}
@@ -0,0 +1,148 @@
+package kapacitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+const (
+	// Name of the statistic of the restart buffers of a task master.
+	restartBufferStatName = "restart_buffer"
+
+	statRestartPointsBuffered = "points_buffered"
+	statRestartPointsReplayed = "points_replayed"
+	statRestartPointsDropped  = "points_dropped"
+	statRestartPointsExpired  = "points_expired"
+
+	// DefaultRestartBufferMaxAge is the default time the points of a stopped task are buffered for its restart.
+	DefaultRestartBufferMaxAge = 10 * time.Second
+)
+
+// restartBufferStats count the points of the restart buffers of a task master.
+type restartBufferStats struct {
+	buffered *expvar.Int
+	replayed *expvar.Int
+	// dropped counts the oldest points dropped to make room once a buffer is full.
+	dropped *expvar.Int
+	// expired counts the points discarded because the task was not started again within the max age.
+	expired *expvar.Int
+}
+
+func newRestartBufferStats(taskMaster string) *restartBufferStats {
+	s := &restartBufferStats{
+		buffered: &expvar.Int{},
+		replayed: &expvar.Int{},
+		dropped:  &expvar.Int{},
+		expired:  &expvar.Int{},
+	}
+	_, statMap := vars.NewStatistic(restartBufferStatName, map[string]string{
+		"task_master": taskMaster,
+	})
+	statMap.Set(statRestartPointsBuffered, s.buffered)
+	statMap.Set(statRestartPointsReplayed, s.replayed)
+	statMap.Set(statRestartPointsDropped, s.dropped)
+	statMap.Set(statRestartPointsExpired, s.expired)
+	return s
+}
+
+// restartBuffer holds the most recent points of the stream of a stopped task,
+// so that they are replayed to the task once it is started again.
+type restartBuffer struct {
+	keys  map[forkKey]bool
+	stats *restartBufferStats
+
+	mu sync.Mutex
+	// points is a ring of the buffered points, oldest first from start.
+	points []edge.PointMessage
+	start  int
+	count  int
+	// closed is set once the buffer is replayed or expired, it does not buffer points anymore.
+	closed bool
+}
+
+func newRestartBuffer(keys []forkKey, size int, stats *restartBufferStats) *restartBuffer {
+	b := &restartBuffer{
+		keys:   make(map[forkKey]bool, len(keys)),
+		stats:  stats,
+		points: make([]edge.PointMessage, size),
+	}
+	for _, k := range keys {
+		b.keys[k] = true
+	}
+	return b
+}
+
+// collect buffers the point if the task read the stream of the key,
+// the key of the database and retention policy without measurement matches tasks reading all measurements.
+// Once the buffer is full the oldest point is dropped.
+func (b *restartBuffer) collect(key, allMeasurementsKey forkKey, p edge.PointMessage) {
+	if !b.keys[key] && !b.keys[allMeasurementsKey] {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	if b.count == len(b.points) {
+		b.points[b.start] = p
+		b.start = (b.start + 1) % len(b.points)
+		b.stats.dropped.Add(1)
+	} else {
+		b.points[(b.start+b.count)%len(b.points)] = p
+		b.count++
+	}
+	b.stats.buffered.Add(1)
+}
+
+// drain returns the buffered points, oldest first, and empties the buffer.
+// The buffer keeps buffering points unless it is closed.
+func (b *restartBuffer) drain() []edge.PointMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	points := make([]edge.PointMessage, b.count)
+	for i := range points {
+		points[i] = b.points[(b.start+i)%len(b.points)]
+		b.points[(b.start+i)%len(b.points)] = nil
+	}
+	b.start, b.count = 0, 0
+	return points
+}
+
+// empty returns whether the buffer has no points.
+func (b *restartBuffer) empty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count == 0
+}
+
+// take closes the buffer and returns its points, oldest first.
+func (b *restartBuffer) take() []edge.PointMessage {
+	points := b.drain()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.points = nil
+	b.closed = true
+	return points
+}
+
+// replay sends the points buffered so far to the edge of the restarted task, oldest first.
+// The points buffered meanwhile are replayed by the next call.
+func (b *restartBuffer) replay(e edge.Edge) error {
+	for _, p := range b.drain() {
+		if err := e.Collect(p); err != nil {
+			return err
+		}
+		b.stats.replayed.Add(1)
+	}
+	return nil
+}
+
+// expire discards the buffered points of a task that was not started again.
+func (b *restartBuffer) expire() {
+	b.stats.expired.Add(int64(len(b.take())))
+}
@@ -0,0 +1,102 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func newTestRestartBufferStats() *restartBufferStats {
+	return &restartBufferStats{
+		buffered: &expvar.Int{},
+		replayed: &expvar.Int{},
+		dropped:  &expvar.Int{},
+		expired:  &expvar.Int{},
+	}
+}
+
+func TestRestartBuffer_Replay(t *testing.T) {
+	stats := newTestRestartBufferStats()
+	b := newRestartBuffer([]forkKey{
+		{Database: "db", RetentionPolicy: "rp", Measurement: "cpu"},
+		{Database: "db", RetentionPolicy: "all", Measurement: ""},
+	}, 3, stats)
+
+	point := func(rp, name string, i int) edge.PointMessage {
+		return edge.NewPointMessage(name, "db", rp, models.Dimensions{}, models.Fields{"value": float64(i)}, nil, time.Unix(int64(i), 0))
+	}
+	collect := func(p edge.PointMessage) {
+		b.collect(
+			forkKey{Database: p.Database(), RetentionPolicy: p.RetentionPolicy(), Measurement: p.Name()},
+			forkKey{Database: p.Database(), RetentionPolicy: p.RetentionPolicy()},
+			p,
+		)
+	}
+	collect(point("rp", "cpu", 1))
+	// Not a stream of the task.
+	collect(point("rp", "mem", 2))
+	// The task reads all the measurements of the retention policy.
+	collect(point("all", "mem", 3))
+	collect(point("rp", "cpu", 4))
+	// The buffer is full, the oldest point is dropped.
+	collect(point("rp", "cpu", 5))
+
+	e := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+	if err := b.replay(e); err != nil {
+		t.Fatal(err)
+	}
+	// Points are buffered until the buffer is closed.
+	collect(point("rp", "cpu", 6))
+	if err := b.replay(e); err != nil {
+		t.Fatal(err)
+	}
+	b.take()
+	e.Close()
+
+	// Points are not buffered once closed.
+	collect(point("rp", "cpu", 7))
+
+	var got []float64
+	for m, ok := e.Emit(); ok; m, ok = e.Emit() {
+		got = append(got, m.(edge.PointMessage).Fields()["value"].(float64))
+	}
+	exp := []float64{3, 4, 5, 6}
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected replayed points got %v exp %v", got, exp)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("unexpected replayed points got %v exp %v", got, exp)
+		}
+	}
+	if got, exp := stats.buffered.IntValue(), int64(5); got != exp {
+		t.Errorf("unexpected buffered points got %d exp %d", got, exp)
+	}
+	if got, exp := stats.dropped.IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected dropped points got %d exp %d", got, exp)
+	}
+	if got, exp := stats.replayed.IntValue(), int64(4); got != exp {
+		t.Errorf("unexpected replayed points got %d exp %d", got, exp)
+	}
+}
+
+func TestRestartBuffer_Expire(t *testing.T) {
+	stats := newTestRestartBufferStats()
+	key := forkKey{Database: "db", RetentionPolicy: "rp", Measurement: "cpu"}
+	b := newRestartBuffer([]forkKey{key}, 10, stats)
+	for i := 0; i < 2; i++ {
+		b.collect(key, forkKey{Database: "db", RetentionPolicy: "rp"},
+			edge.NewPointMessage("cpu", "db", "rp", models.Dimensions{}, models.Fields{"value": 1.0}, nil, time.Unix(int64(i), 0)))
+	}
+	b.expire()
+	if got, exp := stats.expired.IntValue(), int64(2); got != exp {
+		t.Errorf("unexpected expired points got %d exp %d", got, exp)
+	}
+	if points := b.take(); len(points) != 0 {
+		t.Errorf("unexpected points after expiry %v", points)
+	}
+}
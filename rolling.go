@@ -0,0 +1,122 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type RollingNode struct {
+	node
+	r *pipeline.RollingNode
+}
+
+// Create a new rolling node.
+func newRollingNode(et *ExecutingTask, n *pipeline.RollingNode, d NodeDiagnostic) (*RollingNode, error) {
+	rn := &RollingNode{
+		node: node{Node: n, et: et, diag: d},
+		r:    n,
+	}
+	rn.node.runF = rn.runRolling
+	return rn, nil
+}
+
+func (n *RollingNode) runRolling([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *RollingNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &rollingGroup{n: n}),
+	), nil
+}
+
+// rollingGroup retains the values of the last points of a group in a ring buffer of at most Count values.
+type rollingGroup struct {
+	n *RollingNode
+
+	values []float64
+	times  []time.Time
+	// Index of the oldest value once the buffer is full.
+	start int
+}
+
+func (g *rollingGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *rollingGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return bp, nil
+}
+
+func (g *rollingGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *rollingGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	value, ok := numToFloat(p.Fields()[g.n.r.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute rolling aggregate",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", g.n.r.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.r.Field])),
+		)
+		return nil, nil
+	}
+	g.add(value, p.Time())
+
+	p = p.ShallowCopy()
+	fields := p.Fields().Copy()
+	fields[g.n.r.As] = g.aggregate(p.Time())
+	p.SetFields(fields)
+	return p, nil
+}
+
+// add appends the value to the buffer, replacing the oldest value once the buffer holds Count values.
+func (g *rollingGroup) add(value float64, t time.Time) {
+	if int64(len(g.values)) < g.n.r.Count {
+		g.values = append(g.values, value)
+		g.times = append(g.times, t)
+		return
+	}
+	g.values[g.start] = value
+	g.times[g.start] = t
+	g.start = (g.start + 1) % len(g.values)
+}
+
+// aggregate computes the aggregate of the buffered values within the period of t.
+func (g *rollingGroup) aggregate(t time.Time) float64 {
+	var sum float64
+	count := 0
+	for i, v := range g.values {
+		if g.n.r.Period > 0 && t.Sub(g.times[i]) > g.n.r.Period {
+			continue
+		}
+		sum += v
+		count++
+	}
+	if g.n.r.Aggregate == pipeline.RollingMean {
+		return sum / float64(count)
+	}
+	return sum
+}
+
+func (g *rollingGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *rollingGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (g *rollingGroup) Done() {}
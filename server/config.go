@@ -14,6 +14,8 @@ import (
 	"github.com/influxdata/influxdb/services/collectd"
 	"github.com/influxdata/influxdb/services/graphite"
 	"github.com/influxdata/influxdb/services/opentsdb"
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/command"
 	"github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alerta"
@@ -21,6 +23,7 @@ import (
 	"github.com/influxdata/kapacitor/services/azure"
 	"github.com/influxdata/kapacitor/services/bigpanda"
 	"github.com/influxdata/kapacitor/services/config"
+	"github.com/influxdata/kapacitor/services/config/secret"
 	"github.com/influxdata/kapacitor/services/consul"
 	"github.com/influxdata/kapacitor/services/deadman"
 	"github.com/influxdata/kapacitor/services/diagnostic"
@@ -29,6 +32,7 @@ import (
 	"github.com/influxdata/kapacitor/services/ec2"
 	"github.com/influxdata/kapacitor/services/file_discovery"
 	"github.com/influxdata/kapacitor/services/gce"
+	"github.com/influxdata/kapacitor/services/grpcapi"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/httppost"
@@ -65,9 +69,11 @@ import (
 	"github.com/influxdata/kapacitor/services/udf"
 	"github.com/influxdata/kapacitor/services/udp"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/task"
 	"github.com/influxdata/kapacitor/tlsconfig"
+	"github.com/mitchellh/copystructure"
 	"github.com/pkg/errors"
 )
 
@@ -76,6 +82,7 @@ type Config struct {
 	Alert          alert.Config      `toml:"alert"`
 	Auth           auth.Config       `toml:"auth"`
 	HTTP           httpd.Config      `toml:"http"`
+	GRPC           grpcapi.Config    `toml:"grpc"`
 	Replay         replay.Config     `toml:"replay"`
 	Storage        storage.Config    `toml:"storage"`
 	Task           task_store.Config `toml:"task"`
@@ -114,6 +121,7 @@ type Config struct {
 	Teams      teams.Config      `toml:"teams" override:"teams"`
 	Telegram   telegram.Config   `toml:"telegram" override:"telegram"`
 	VictorOps  victorops.Config  `toml:"victorops" override:"victorops"`
+	Zabbix     zabbix.Configs    `toml:"zabbix" override:"zabbix,element-key=id"`
 	Zenoss     zenoss.Config     `toml:"zenoss" override:"zenoss"`
 
 	// Discovery for scraping
@@ -139,10 +147,25 @@ type Config struct {
 	UDF       udf.Config       `toml:"udf"`
 	Deadman   deadman.Config   `toml:"deadman"`
 
-	Hostname               string `toml:"hostname"`
-	DataDir                string `toml:"data_dir"`
-	SkipConfigOverrides    bool   `toml:"skip-config-overrides"`
-	DefaultRetentionPolicy string `toml:"default-retention-policy"`
+	TaskQuotas kapacitor.TaskQuotas `toml:"task-quotas"`
+	// ExecutionPools bound the number of nodes of the tasks of each pool processing data at once.
+	ExecutionPools []kapacitor.ExecutionPoolConfig `toml:"execution-pool"`
+
+	Hostname                  string `toml:"hostname"`
+	DataDir                   string `toml:"data_dir"`
+	SkipConfigOverrides       bool   `toml:"skip-config-overrides"`
+	DefaultRetentionPolicy    string `toml:"default-retention-policy"`
+	MaxConcurrentBatchQueries int    `toml:"max-concurrent-batch-queries"`
+	// BatchQueryPriorityAging is the time a waiting batch query waits for its priority to increase by one.
+	BatchQueryPriorityAging toml.Duration `toml:"batch-query-priority-aging"`
+	// RestartBufferSize is the number of points buffered for a stream task while it restarts, 0 disables the buffering.
+	RestartBufferSize int `toml:"restart-buffer-size"`
+	// RestartBufferMaxAge is the time the points are buffered for a task stopped for a restart.
+	RestartBufferMaxAge toml.Duration `toml:"restart-buffer-max-age"`
+	// HMACKeys are the keys of the hmac lambda function by name.
+	HMACKeys map[string]string `toml:"hmac-keys"`
+	// GlobalTags are the tags added to all the points written by influxDBOut nodes and to all alert events.
+	GlobalTags map[string]string `toml:"global-tags"`
 
 	Commander command.Commander `toml:"-"`
 }
@@ -150,8 +173,10 @@ type Config struct {
 // NewConfig returns an instance of Config with reasonable defaults.
 func NewConfig() *Config {
 	c := &Config{
-		Hostname:  "localhost",
-		Commander: command.ExecCommander,
+		Hostname:                "localhost",
+		BatchQueryPriorityAging: toml.Duration(kapacitor.DefaultBatchQueryPriorityAging),
+		RestartBufferMaxAge:     toml.Duration(kapacitor.DefaultRestartBufferMaxAge),
+		Commander:               command.ExecCommander,
 	}
 
 	c.Alert = alert.NewConfig()
@@ -190,6 +215,7 @@ func NewConfig() *Config {
 	c.SNMPTrap = snmptrap.NewConfig()
 	c.Telegram = telegram.NewConfig()
 	c.VictorOps = victorops.NewConfig()
+	c.Zabbix = zabbix.Configs{zabbix.NewConfig()}
 	c.Zenoss = zenoss.NewConfig()
 
 	c.Reporting = reporting.NewConfig()
@@ -197,6 +223,7 @@ func NewConfig() *Config {
 	c.UDF = udf.NewConfig()
 	c.Deadman = deadman.NewConfig()
 	c.Load = load.NewConfig()
+	c.GRPC = grpcapi.NewConfig()
 
 	return c
 }
@@ -221,6 +248,7 @@ func NewDemoConfig() (*Config, error) {
 	c.Storage.BoltDBPath = filepath.Join(homeDir, ".kapacitor", c.Storage.BoltDBPath)
 	c.DataDir = filepath.Join(homeDir, ".kapacitor", c.DataDir)
 	c.Load.Dir = filepath.Join(homeDir, ".kapacitor", c.Load.Dir)
+	c.Alert.JournalPath = filepath.Join(homeDir, ".kapacitor", c.Alert.JournalPath)
 
 	return c, nil
 }
@@ -233,9 +261,37 @@ func (c *Config) Validate() error {
 	if c.DataDir == "" {
 		return fmt.Errorf("must configure valid data dir")
 	}
+	if c.MaxConcurrentBatchQueries < 0 {
+		return fmt.Errorf("max-concurrent-batch-queries must not be negative")
+	}
+	if c.BatchQueryPriorityAging < 0 {
+		return fmt.Errorf("batch-query-priority-aging must not be negative")
+	}
+	if c.RestartBufferSize < 0 {
+		return fmt.Errorf("restart-buffer-size must not be negative")
+	}
+	if c.RestartBufferMaxAge < 0 {
+		return fmt.Errorf("restart-buffer-max-age must not be negative")
+	}
+	for name, key := range c.HMACKeys {
+		if key == "" {
+			return fmt.Errorf("hmac-keys: key %q must not be empty", name)
+		}
+	}
+	for k, v := range c.GlobalTags {
+		if k == "" || v == "" {
+			return fmt.Errorf("global-tags: tag %q must have a non empty name and value", k)
+		}
+	}
 	if err := c.Replay.Validate(); err != nil {
 		return errors.Wrap(err, "replay")
 	}
+	if err := c.TaskQuotas.Validate(); err != nil {
+		return errors.Wrap(err, "task-quotas")
+	}
+	if err := kapacitor.ValidateExecutionPools(c.ExecutionPools); err != nil {
+		return errors.Wrap(err, "execution-pool")
+	}
 	if err := c.Auth.Validate(); err != nil {
 		return errors.Wrap(err, "auth")
 	}
@@ -257,6 +313,15 @@ func (c *Config) Validate() error {
 	if err := c.Load.Validate(); err != nil {
 		return err
 	}
+	if err := c.GRPC.Validate(); err != nil {
+		return errors.Wrap(err, "grpc")
+	}
+	if err := c.Alert.Validate(); err != nil {
+		return errors.Wrap(err, "alert")
+	}
+	if err := c.Stats.Validate(); err != nil {
+		return errors.Wrap(err, "stats")
+	}
 	// Validate the set of InfluxDB configs.
 	// All names should be unique.
 	names := make(map[string]bool, len(c.InfluxDB))
@@ -293,6 +358,11 @@ func (c *Config) Validate() error {
 			return errors.Wrap(err, "graphite")
 		}
 	}
+	for _, u := range c.UDP {
+		if err := u.Validate(); err != nil {
+			return errors.Wrap(err, "udp")
+		}
+	}
 
 	// Validate alert handlers
 	if err := c.Alerta.Validate(); err != nil {
@@ -358,6 +428,9 @@ func (c *Config) Validate() error {
 	if err := c.VictorOps.Validate(); err != nil {
 		return errors.Wrap(err, "victorops")
 	}
+	if err := c.Zabbix.Validate(); err != nil {
+		return errors.Wrap(err, "zabbix")
+	}
 	if err := c.Zenoss.Validate(); err != nil {
 		return errors.Wrap(err, "zenoss")
 	}
@@ -450,6 +523,20 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ResolveSecrets replaces the secret references in the config with their secrets,
+// see the secret package for the format of the references.
+// A copy of the config with the references unresolved is returned.
+func (c *Config) ResolveSecrets() (*Config, error) {
+	raw, err := copystructure.Copy(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to copy config")
+	}
+	if err := secret.Resolve(c); err != nil {
+		return nil, err
+	}
+	return raw.(*Config), nil
+}
+
 func (c *Config) ApplyEnvOverrides() error {
 	return c.applyEnvOverrides("KAPACITOR", "", reflect.ValueOf(c))
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/influxdata/influxdb/services/opentsdb"
 	"github.com/influxdata/influxql"
 	"github.com/influxdata/kapacitor"
+	kalert "github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/auth"
 	"github.com/influxdata/kapacitor/command"
 	iclient "github.com/influxdata/kapacitor/influxdb"
@@ -41,6 +42,7 @@ import (
 	"github.com/influxdata/kapacitor/services/file_discovery"
 	"github.com/influxdata/kapacitor/services/fluxtask"
 	"github.com/influxdata/kapacitor/services/gce"
+	"github.com/influxdata/kapacitor/services/grpcapi"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/httppost"
@@ -80,8 +82,10 @@ import (
 	"github.com/influxdata/kapacitor/services/udf"
 	"github.com/influxdata/kapacitor/services/udp"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/task/taskmodel"
+	"github.com/influxdata/kapacitor/tick/stateful"
 	"github.com/influxdata/kapacitor/uuid"
 	"github.com/influxdata/kapacitor/waiter"
 	"github.com/pkg/errors"
@@ -114,6 +118,8 @@ type Server struct {
 
 	config    *Config
 	tlsConfig *tls.Config
+	// The config with its secret references unresolved, used by the config override service.
+	rawConfig *Config
 
 	err chan error
 
@@ -134,6 +140,7 @@ type Server struct {
 	SideloadService       *sideload.Service
 	AuthService           auth.Interface
 	HTTPDService          *httpd.Service
+	GRPCService           *grpcapi.Service
 	StorageService        *storage.Service
 	AlertService          *alert.Service
 	TaskStore             *task_store.Service
@@ -174,10 +181,18 @@ type Server struct {
 
 // New returns a new instance of Server built from a config.
 func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabledAlertHandlers map[string]struct{}) (*Server, error) {
-	err := c.Validate()
+	raw, err := c.ResolveSecrets()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+	err = c.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("invalid configuration: %s. To generate a valid configuration file run `kapacitord config > kapacitor.generated.conf`.", err)
 	}
+	// The alert levels are global, define the custom levels before any task or topic handler is loaded.
+	if err := kalert.SetCustomLevels(c.Alert.CustomLevels); err != nil {
+		return nil, errors.Wrap(err, "invalid alert custom levels")
+	}
 	// Setup base TLS config used for the Kapacitor API
 	tlsConfig, err := c.TLS.Parse()
 	if err != nil {
@@ -192,6 +207,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 	}
 	s := &Server{
 		config:           c,
+		rawConfig:        raw,
 		DisabledHandlers: disabledAlertHandlers,
 		tlsConfig:        tlsConfig,
 		BuildInfo:        buildInfo,
@@ -226,11 +242,20 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 	s.Diag.Info("listing ClusterID and ServerID",
 		keyvalue.KV("cluster_id", s.ClusterID.String()), keyvalue.KV("server_id", s.ServerID.String()))
 
+	// The keys of the hmac function, with their secret references resolved.
+	stateful.SetHMACKeys(c.HMACKeys)
+
 	// Start Task Master
 	s.TaskMasterLookup = kapacitor.NewTaskMasterLookup()
 	kd := diagService.NewKapacitorHandler()
 	s.TaskMaster = kapacitor.NewTaskMaster(kapacitor.MainTaskMaster, vars.Info, kd)
 	s.TaskMaster.DefaultRetentionPolicy = c.DefaultRetentionPolicy
+	s.TaskMaster.BatchQueryLimiter = kapacitor.NewBatchQueryLimiter(c.MaxConcurrentBatchQueries, time.Duration(c.BatchQueryPriorityAging))
+	s.TaskMaster.DefaultTaskQuotas = c.TaskQuotas
+	s.TaskMaster.ExecutionPools = kapacitor.NewExecutionPools(c.ExecutionPools)
+	s.TaskMaster.GlobalTags = c.GlobalTags
+	s.TaskMaster.RestartBufferSize = c.RestartBufferSize
+	s.TaskMaster.RestartBufferMaxAge = time.Duration(c.RestartBufferMaxAge)
 	s.TaskMaster.Commander = s.Commander
 	s.TaskMasterLookup.Set(s.TaskMaster)
 	if err := s.TaskMaster.Open(); err != nil {
@@ -304,6 +329,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 	s.appendSensuService()
 	s.appendTalkService()
 	s.appendVictorOpsService()
+	s.appendZabbixService()
 	s.appendZenossService()
 
 	// Append alert service
@@ -360,6 +386,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 
 	// Append HTTPD Service last so that the API is not listening till everything else succeeded.
 	s.appendHTTPDService()
+	s.appendGRPCService()
 
 	return s, nil
 }
@@ -397,7 +424,7 @@ func (s *Server) appendStorageService() {
 
 func (s *Server) appendConfigOverrideService() {
 	d := s.DiagService.NewConfigOverrideHandler()
-	srv := config.NewService(s.config.ConfigOverride, s.config, d, s.configUpdates)
+	srv := config.NewService(s.config.ConfigOverride, s.rawConfig, d, s.configUpdates)
 	srv.HTTPDService = s.HTTPDService
 	srv.StorageService = s.StorageService
 
@@ -413,6 +440,11 @@ func (s *Server) initAlertService() {
 	srv.HTTPDService = s.HTTPDService
 	srv.StorageService = s.StorageService
 	srv.PersistTopics = s.config.Alert.PersistTopics
+	srv.HandlerConcurrency = s.config.Alert.HandlerConcurrency
+	srv.TopicHandlerConcurrency = s.config.Alert.TopicHandlerConcurrency
+	if c := s.config.Alert; c.JournalEnabled {
+		srv.Journal = kalert.NewJournal(c.JournalPath, c.JournalMaxSize, time.Duration(c.JournalRetention))
+	}
 	s.AlertService = srv
 	s.TaskMaster.AlertService = srv
 }
@@ -477,6 +509,7 @@ func (s *Server) appendLoadService() error {
 	}
 
 	srv.StorageService = s.StorageService
+	srv.HTTPDService = s.HTTPDService
 
 	s.LoadService = srv
 	s.AppendService("load", srv)
@@ -539,6 +572,19 @@ func (s *Server) appendHTTPDService() {
 	s.AppendService("httpd", s.HTTPDService)
 }
 
+func (s *Server) appendGRPCService() {
+	c := s.config.GRPC
+	if !c.Enabled {
+		return
+	}
+	d := s.DiagService.NewGRPCHandler()
+	srv := grpcapi.NewService(c, s.tlsConfig, d)
+	srv.Handler = s.HTTPDService.Handler
+
+	s.GRPCService = srv
+	s.AppendService("grpc", srv)
+}
+
 func (s *Server) appendTaskStoreService() {
 	d := s.DiagService.NewTaskStoreHandler()
 	srv := task_store.NewService(s.config.Task, d)
@@ -1090,6 +1136,18 @@ func (s *Server) appendServiceNowService() {
 	s.AppendService("servicenow", srv)
 }
 
+func (s *Server) appendZabbixService() {
+	c := s.config.Zabbix
+	d := s.DiagService.NewZabbixHandler()
+	srv := zabbix.NewService(c, d)
+
+	s.TaskMaster.ZabbixService = srv
+	s.AlertService.ZabbixService = srv
+
+	s.SetDynamicService("zabbix", srv)
+	s.AppendService("zabbix", srv)
+}
+
 func (s *Server) appendZenossService() {
 	c := s.config.Zenoss
 	d := s.DiagService.NewZenossHandler()
@@ -1122,6 +1180,10 @@ func (s *Server) Open() error {
 		return fmt.Errorf("failed to reload tasks/templates/handlers: %v", err)
 	}
 
+	if err := s.AlertService.ReplayJournal(); err != nil {
+		s.Diag.Error("failed to redeliver journaled alert events", err)
+	}
+
 	go s.watchServices()
 	go s.watchConfigUpdates()
 
@@ -1192,6 +1254,7 @@ func (s *Server) Close() error {
 	// Drain the in-flight writes and stop all tasks.
 	s.TaskMaster.Drain()
 	s.TaskMaster.StopTasks()
+	s.TaskMaster.ExecutionPools.Close()
 
 	// Close services now that all tasks are stopped.
 	for i := len(s.Services) - 1; i >= 0; i-- {
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -44,6 +45,7 @@ import (
 	"github.com/influxdata/kapacitor/services/auth"
 	"github.com/influxdata/kapacitor/services/auth/meta"
 	"github.com/influxdata/kapacitor/services/bigpanda/bigpandatest"
+	"github.com/influxdata/kapacitor/services/diagnostic"
 	"github.com/influxdata/kapacitor/services/discord/discordtest"
 	"github.com/influxdata/kapacitor/services/hipchat/hipchattest"
 	"github.com/influxdata/kapacitor/services/httppost"
@@ -51,6 +53,7 @@ import (
 	"github.com/influxdata/kapacitor/services/k8s"
 	"github.com/influxdata/kapacitor/services/kafka"
 	"github.com/influxdata/kapacitor/services/kafka/kafkatest"
+	"github.com/influxdata/kapacitor/services/load"
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/mqtt/mqtttest"
 	"github.com/influxdata/kapacitor/services/opsgenie"
@@ -77,6 +80,8 @@ import (
 	"github.com/influxdata/kapacitor/services/udf"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/influxdata/kapacitor/services/victorops/victoropstest"
+	"github.com/influxdata/kapacitor/services/zabbix"
+	"github.com/influxdata/kapacitor/services/zabbix/zabbixtest"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/services/zenoss/zenosstest"
 	"github.com/k-sone/snmpgo"
@@ -1392,6 +1397,52 @@ func TestServer_ListTasks_Fields(t *testing.T) {
 	}
 }
 
+func TestServer_ListTasks_NoLimit(t *testing.T) {
+	s, cli := OpenDefaultServer(t)
+	defer s.Close()
+	// More tasks than are read from the store at a time.
+	count := 250
+
+	tick := `stream
+    |from()
+        .measurement('test')
+`
+	dbrps := []client.DBRP{
+		{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		},
+	}
+	for i := 0; i < count; i++ {
+		_, err := cli.CreateTask(client.CreateTaskOptions{
+			ID:         fmt.Sprintf("testTaskID%03d", i),
+			Type:       client.StreamTask,
+			DBRPs:      dbrps,
+			TICKscript: tick,
+			Status:     client.Disabled,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks, err := cli.ListTasks(&client.ListTasksOptions{
+		Fields: []string{"status"},
+		Offset: 10,
+		Limit:  -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, got := count-10, len(tasks); exp != got {
+		t.Fatalf("unexpected number of tasks: exp:%d got:%d", exp, got)
+	}
+	for i, task := range tasks {
+		if exp, got := fmt.Sprintf("testTaskID%03d", i+10), task.ID; exp != got {
+			t.Errorf("unexpected task.ID i:%d exp:%s got:%s", i, exp, got)
+		}
+	}
+}
+
 func TestServer_CreateTemplate(t *testing.T) {
 	s, cli := OpenDefaultServer(t)
 	defer s.Close()
@@ -2854,6 +2905,257 @@ test value=1 0000000011
 	}
 }
 
+func TestServer_TaskLogLevels(t *testing.T) {
+	s, cli := OpenDefaultServer(t)
+	defer s.Close()
+
+	tick := `stream
+    |from()
+        .measurement('test')
+    |log()
+`
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:            "testTaskID",
+		Type:          client.StreamTask,
+		DBRPs:         []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript:    tick,
+		Status:        client.Enabled,
+		LogLevel:      "debug",
+		NodeLogLevels: map[string]string{"log2": "error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.LogLevel, "debug"; got != exp {
+		t.Fatalf("unexpected log level got %s exp %s", got, exp)
+	}
+	if got, exp := task.NodeLogLevels, map[string]string{"log2": "error"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected node log levels got %v exp %v", got, exp)
+	}
+
+	task, err = cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		LogLevel:      "default",
+		NodeLogLevels: map[string]string{"log2": "default", "from1": "info"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.LogLevel, ""; got != exp {
+		t.Fatalf("unexpected log level got %s exp %s", got, exp)
+	}
+	if got, exp := task.NodeLogLevels, map[string]string{"from1": "info"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected node log levels got %v exp %v", got, exp)
+	}
+
+	if _, err := cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		NodeLogLevels: map[string]string{"missing": "debug"},
+	}); err == nil {
+		t.Fatal("expected error setting the log level of an unknown node")
+	}
+	if _, err := cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		LogLevel: "trace",
+	}); err == nil {
+		t.Fatal("expected error setting an invalid log level")
+	} else if got, exp := err.Error(), `invalid log level "trace", must be one of debug, info or error`; got != exp {
+		t.Fatalf("unexpected error got %q exp %q", got, exp)
+	}
+}
+
+func TestServer_CancelTaskQueries(t *testing.T) {
+	c := NewConfig(t)
+	c.InfluxDB[0].Enabled = true
+	started := make(chan struct{})
+	var startOnce sync.Once
+	release := make(chan struct{})
+	db := NewInfluxDB(func(q string) *iclient.Response {
+		if strings.HasPrefix(q, "SELECT") {
+			startOnce.Do(func() { close(started) })
+			<-release
+		}
+		return nil
+	})
+	defer db.Close()
+	c.InfluxDB[0].URLs = []string{db.URL()}
+	s := OpenServer(c)
+	defer s.Close()
+	// Release blocked queries before closing the server.
+	defer close(release)
+	cli := Client(s)
+
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "testBatchTask",
+		Type: client.BatchTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: `batch
+    |query('SELECT value from mydb.myrp.cpu')
+        .period(10s)
+        .every(1s)
+`,
+		Status: client.Enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch query")
+	}
+
+	canceled, err := cli.CancelTaskQueries(task.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := canceled.Canceled, 1; got != exp {
+		t.Errorf("unexpected number of canceled queries got %d exp %d", got, exp)
+	}
+
+	// Stream tasks do not execute queries.
+	stream, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "testStreamTask",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: `stream
+    |from()
+        .measurement('cpu')
+`,
+		Status: client.Enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.CancelTaskQueries(stream.Link); err == nil {
+		t.Error("expected error canceling the queries of a stream task")
+	}
+}
+
+func TestServer_TaskQuotas(t *testing.T) {
+	s, cli := OpenDefaultServer(t)
+	defer s.Close()
+
+	tick := `stream
+    |from()
+        .measurement('test')
+    |window()
+        .period(10s)
+        .every(10s)
+`
+	quotas := client.TaskQuotas{
+		MaxPointsPerSecond: 1000,
+		MaxCardinality:     100,
+		Enforcement:        "error",
+	}
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+		Quotas:     &quotas,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.Quotas, quotas; got != exp {
+		t.Fatalf("unexpected quotas got %v exp %v", got, exp)
+	}
+	if _, ok := task.ExecutionStats.TaskStats["quota_dropped_points"]; !ok {
+		t.Errorf("expected quota stats in task stats, got %v", task.ExecutionStats.TaskStats)
+	}
+
+	// The quotas are replaced as a whole.
+	quotas = client.TaskQuotas{MaxMemoryBytes: 1 << 20}
+	task, err = cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Quotas: &quotas,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.Quotas, quotas; got != exp {
+		t.Fatalf("unexpected quotas got %v exp %v", got, exp)
+	}
+
+	if _, err := cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Quotas: &client.TaskQuotas{Enforcement: "block"},
+	}); err == nil {
+		t.Fatal("expected error setting an invalid quota enforcement")
+	}
+	if _, err := cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Quotas: &client.TaskQuotas{MaxCardinality: -1},
+	}); err == nil {
+		t.Fatal("expected error setting a negative quota")
+	}
+}
+
+func TestServer_TaskPriority(t *testing.T) {
+	c := NewConfig(t)
+	c.InfluxDB[0].Enabled = true
+	db := NewInfluxDB(func(q string) *iclient.Response {
+		return &iclient.Response{}
+	})
+	defer db.Close()
+	c.InfluxDB[0].URLs = []string{db.URL()}
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	tick := `batch
+    |query('SELECT value FROM mydb.myrp.cpu')
+        .period(10m)
+        .every(1h)
+`
+	priority := int64(5)
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.BatchTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+		Priority:   &priority,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.Priority, priority; got != exp {
+		t.Fatalf("unexpected priority got %d exp %d", got, exp)
+	}
+	if got, exp := task.ExecutionStats.NodeStats["query1"]["query_priority"], float64(priority); got != exp {
+		t.Errorf("unexpected query_priority stat got %v exp %v", got, exp)
+	}
+
+	// The priority applies to the running task.
+	priority = -1
+	task, err = cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Priority: &priority,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.Priority, priority; got != exp {
+		t.Fatalf("unexpected priority got %d exp %d", got, exp)
+	}
+	if got, exp := task.ExecutionStats.NodeStats["query1"]["query_priority"], float64(priority); got != exp {
+		t.Errorf("unexpected query_priority stat got %v exp %v", got, exp)
+	}
+
+	// Updates without a priority keep the priority.
+	task, err = cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Status: client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.Priority, priority; got != exp {
+		t.Fatalf("unexpected priority got %d exp %d", got, exp)
+	}
+}
+
 func TestServer_UpdateTaskID(t *testing.T) {
 	s, cli := OpenDefaultServer(t)
 	defer s.Close()
@@ -4925,6 +5227,85 @@ func TestServer_ReplayQuery(t *testing.T) {
 }
 
 // Test for recording and replaying a stream query where data has missing fields and tags.
+func TestServer_ReplayQuery_Cancel(t *testing.T) {
+	c := NewConfig(t)
+	c.InfluxDB[0].Enabled = true
+	started := make(chan struct{})
+	var startOnce sync.Once
+	release := make(chan struct{})
+	db := NewInfluxDB(func(q string) *iclient.Response {
+		if strings.HasPrefix(q, "SELECT") {
+			startOnce.Do(func() { close(started) })
+			<-release
+		}
+		return nil
+	})
+	defer db.Close()
+	c.InfluxDB[0].URLs = []string{db.URL()}
+	s := OpenServer(c)
+	defer s.Close()
+	// Release blocked queries before closing the server.
+	defer close(release)
+	cli := Client(s)
+
+	id := "testBatchTask"
+	_, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.BatchTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: `batch
+    |query('SELECT value from mydb.myrp.cpu')
+        .period(2s)
+        .every(2s)
+`,
+		Status: client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := cli.ReplayQuery(client.ReplayQueryOptions{
+		ID:    "replayid",
+		Query: "SELECT value from mydb.myrp.cpu",
+		Task:  id,
+		Clock: client.Fast,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for replay query")
+	}
+
+	if _, err := cli.CancelReplay(replay.Link); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for replay to finish.
+	retry := 0
+	for replay.Status == client.Running {
+		time.Sleep(100 * time.Millisecond)
+		replay, err = cli.Replay(replay.Link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		retry++
+		if retry > 10 {
+			t.Fatal("failed to cancel replay")
+		}
+	}
+	if got, exp := replay.Status, client.Canceled; got != exp {
+		t.Errorf("unexpected replay status got %v exp %v", got, exp)
+	}
+	if replay.Error != "" {
+		t.Errorf("unexpected replay error %q", replay.Error)
+	}
+}
+
 func TestServer_RecordReplayQuery_Missing(t *testing.T) {
 	c := NewConfig(t)
 	c.InfluxDB[0].Enabled = true
@@ -6241,6 +6622,63 @@ func TestServer_CreateTask_ValidIDs(t *testing.T) {
 	}
 }
 
+func TestServer_UpdateRecording(t *testing.T) {
+	s, cli := OpenDefaultServer(t)
+	defer s.Close()
+
+	_, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "testStreamTask",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: `stream
+    |from()
+        .measurement('test')
+`,
+		Status: client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording, err := cli.RecordStream(client.RecordStreamOptions{
+		ID:   "recordingid",
+		Task: "testStreamTask",
+		Stop: time.Date(1970, 1, 1, 0, 0, 10, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.Pinned {
+		t.Fatal("expected new recording not to be pinned")
+	}
+
+	pinned := true
+	recording, err = cli.UpdateRecording(recording.Link, client.UpdateRecordingOptions{
+		Pinned: &pinned,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recording.Pinned {
+		t.Error("expected updated recording to be pinned")
+	}
+	recording, err = cli.Recording(recording.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recording.Pinned {
+		t.Error("expected recording to stay pinned")
+	}
+
+	if _, err := cli.UpdateRecording(cli.RecordingLink("missing"), client.UpdateRecordingOptions{
+		Pinned: &pinned,
+	}); err == nil {
+		t.Error("expected error updating a missing recording")
+	}
+}
+
 func TestServer_CreateRecording_ValidIDs(t *testing.T) {
 	s, cli := OpenDefaultServer(t)
 	defer s.Close()
@@ -6448,33 +6886,38 @@ func TestServer_UpdateConfig(t *testing.T) {
 		return &iclient.Response{}
 	})
 	defMap := map[string]interface{}{
-		"default":                     false,
-		"disable-subscriptions":       false,
-		"enabled":                     true,
-		"excluded-subscriptions":      map[string]interface{}{"_kapacitor": []interface{}{"autogen"}},
-		"http-port":                   float64(0),
-		"insecure-skip-verify":        false,
-		"kapacitor-hostname":          "",
-		"http-shared-secret":          false,
-		"name":                        "default",
-		"password":                    true,
-		"ssl-ca":                      "",
-		"ssl-cert":                    "",
-		"ssl-key":                     "",
-		"startup-timeout":             "1h0m0s",
-		"subscription-protocol":       "http",
-		"subscription-mode":           "cluster",
-		"subscription-path":           "",
-		"subscriptions":               nil,
-		"subscriptions-sync-interval": "1m0s",
-		"timeout":                     "0s",
-		"token":                       false,
-		"udp-bind":                    "",
-		"udp-buffer":                  float64(1e3),
-		"udp-read-buffer":             float64(0),
-		"urls":                        []interface{}{db.URL()},
-		"username":                    "bob",
-		"compression":                 "gzip",
+		"default":                           false,
+		"disable-subscriptions":             false,
+		"enabled":                           true,
+		"excluded-subscriptions":            map[string]interface{}{"_kapacitor": []interface{}{"autogen"}},
+		"http-port":                         float64(0),
+		"insecure-skip-verify":              false,
+		"kapacitor-hostname":                "",
+		"http-shared-secret":                false,
+		"name":                              "default",
+		"password":                          true,
+		"ssl-ca":                            "",
+		"ssl-cert":                          "",
+		"ssl-key":                           "",
+		"startup-timeout":                   "1h0m0s",
+		"subscription-protocol":             "http",
+		"subscription-mode":                 "cluster",
+		"subscription-path":                 "",
+		"subscriptions":                     nil,
+		"subscriptions-sync-interval":       "1m0s",
+		"subscriptions-sync-retry-interval": "5s",
+		"timeout":                           "0s",
+		"token":                             false,
+		"udp-bind":                          "",
+		"udp-buffer":                        float64(1e3),
+		"udp-read-buffer":                   float64(0),
+		"udp-overflow-policy":               "block",
+		"urls":                              []interface{}{db.URL()},
+		"username":                          "bob",
+		"compression":                       "gzip",
+		"api-version":                       float64(1),
+		"org":                               "",
+		"org-id":                            "",
 	}
 
 	deepCopyMapWithReplace := func(m map[string]interface{}) func(replacements ...map[string]interface{}) map[string]interface{} {
@@ -9064,6 +9507,73 @@ func TestServer_UpdateConfig(t *testing.T) {
 	}
 }
 
+func TestServer_ConfigSecrets(t *testing.T) {
+	ts := slacktest.NewServer()
+	defer ts.Close()
+	dir := t.TempDir()
+	urlPath := filepath.Join(dir, "slack-url")
+	if err := os.WriteFile(urlPath, []byte(ts.URL+"/test/slack/url\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	channelPath := filepath.Join(dir, "slack-channel")
+	if err := os.WriteFile(channelPath, []byte("#secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig(t)
+	c.Slack[0].Enabled = true
+	c.Slack[0].URL = "${file:" + urlPath + "}"
+	c.Slack[0].Channel = "${file:" + channelPath + "}"
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	// The API returns the references and not the secrets, redacted options stay redacted.
+	element, err := cli.ConfigElement(cli.ConfigElementLink("slack", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := element.Options["url"], interface{}(true); got != exp {
+		t.Errorf("unexpected url option got %v exp %v", got, exp)
+	}
+	if got, exp := element.Options["channel"], interface{}("${file:"+channelPath+"}"); got != exp {
+		t.Errorf("unexpected channel option got %v exp %v", got, exp)
+	}
+
+	// The service uses the secrets.
+	tr, err := cli.DoServiceTest(cli.ServiceTestLink("slack"), client.ServiceTestOptions{
+		"message": "test secrets",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Success {
+		t.Fatalf("unexpected service test failure: %s", tr.Message)
+	}
+	ts.Close()
+	requests := ts.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("unexpected number of slack requests got %d exp 1", len(requests))
+	}
+	if got, exp := requests[0].URL, "/test/slack/url"; got != exp {
+		t.Errorf("unexpected slack url got %q exp %q", got, exp)
+	}
+	if got, exp := requests[0].PostData.Channel, "#secret"; got != exp {
+		t.Errorf("unexpected slack channel got %q exp %q", got, exp)
+	}
+}
+
+func TestServer_ConfigSecrets_Missing(t *testing.T) {
+	c := NewConfig(t)
+	c.Slack[0].URL = "${file:" + filepath.Join(t.TempDir(), "missing") + "}"
+	ds := diagnostic.NewService(diagnostic.NewConfig(), io.Discard, io.Discard)
+	ds.Open()
+	defer ds.Close()
+	if _, err := server.New(c, server.BuildInfo{}, ds, nil); err == nil {
+		t.Fatal("expected error creating server with a missing secret")
+	}
+}
+
 func TestServer_ListServiceTests(t *testing.T) {
 	s, cli := OpenDefaultServer(t)
 	defer s.Close()
@@ -9466,6 +9976,16 @@ func TestServer_ListServiceTests(t *testing.T) {
 					"entityID":    "testEntityID",
 				},
 			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/zabbix"},
+				Name: "zabbix",
+				Options: client.ServiceTestOptions{
+					"server": "default",
+					"host":   "kapacitor",
+					"key":    "kapacitor.alert",
+					"level":  "CRITICAL",
+				},
+			},
 			{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/zenoss"},
 				Name: "zenoss",
@@ -9831,6 +10351,14 @@ func TestServer_DoServiceTest(t *testing.T) {
 				Message: "service is not enabled",
 			},
 		},
+		{
+			service: "zabbix",
+			options: client.ServiceTestOptions{},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "zabbix server \"default\" is not enabled",
+			},
+		},
 		{
 			service: "zenoss",
 			options: client.ServiceTestOptions{},
@@ -11538,6 +12066,45 @@ func TestServer_AlertHandlers(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			handler: client.TopicHandler{
+				Kind: "zabbix",
+				Options: map[string]interface{}{
+					"host": "{{ .ID }}-host",
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts, err := zabbixtest.NewServer()
+				if err != nil {
+					return nil, err
+				}
+				ctxt := context.WithValue(context.Background(), testCtxStr("server"), ts)
+
+				c.Zabbix[0].Enabled = true
+				c.Zabbix[0].Addr = ts.Addr
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value(testCtxStr("server")).(*zabbixtest.Server)
+				ts.Close()
+				exp := []zabbix.Request{{
+					Request: "sender data",
+					Data: []zabbix.Item{{
+						Host:  "id-host",
+						Key:   "kapacitor.alert",
+						Value: "3",
+					}},
+				}}
+				got := ts.Requests()
+				for i := range got {
+					got[i].Clock, got[i].NS = 0, 0
+				}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected zabbix request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
 		{
 			handler: client.TopicHandler{
 				Kind: "zenoss",
@@ -13572,6 +14139,137 @@ options:
 
 }
 
+func TestLoadService_Watch(t *testing.T) {
+	c := NewConfig(t)
+	if err := copyFiles("testdata/load", c.Load.Dir); err != nil {
+		t.Fatal(err)
+	}
+	c.Load.Watch = true
+	c.Load.WatchInterval = toml.Duration(10 * time.Millisecond)
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	waitForTask := func(id string, exists bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			task, _ := cli.Task(cli.TaskLink(id), nil)
+			if (task.ID != "") == exists {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for task %q exists %v", id, exists)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	script := `dbrp "telegraf"."autogen"
+
+stream
+    |from()
+        .measurement('mem')
+`
+	tickFile := path.Join(c.Load.Dir, "tasks", "watched.tick")
+	if err := os.WriteFile(tickFile, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForTask("watched", true)
+
+	if err := os.Remove(tickFile); err != nil {
+		t.Fatal(err)
+	}
+	waitForTask("watched", false)
+
+	resp, err := http.Get(s.URL() + "/load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var status load.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Error != "" {
+		t.Errorf("unexpected load error %q", status.Error)
+	}
+	if got, exp := status.Removed, []string{"tasks/watched"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected removed got %v exp %v", got, exp)
+	}
+	for _, f := range status.Files {
+		if f.Status != load.FileLoaded {
+			t.Errorf("unexpected status of file %s got %s exp %s", f.File, f.Status, load.FileLoaded)
+		}
+	}
+}
+
+func TestLoadService_APIPrecedence(t *testing.T) {
+	c := NewConfig(t)
+	if err := copyFiles("testdata/load", c.Load.Dir); err != nil {
+		t.Fatal(err)
+	}
+	c.Load.Precedence = load.PrecedenceAPI
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	apiScript := `stream
+    |from()
+        .measurement('cpu')
+`
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "api_task",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		TICKscript: apiScript,
+		Status:     client.Disabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tickFile := path.Join(c.Load.Dir, "tasks", "api_task.tick")
+	fileScript := `dbrp "telegraf"."autogen"
+
+stream
+    |from()
+        .measurement('mem')
+`
+	if err := os.WriteFile(tickFile, []byte(fileScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.Reload()
+
+	task, err := cli.Task(cli.TaskLink("api_task"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := task.TICKscript, apiScript; got != exp {
+		t.Errorf("unexpected TICKscript got %q exp %q", got, exp)
+	}
+	found := false
+	for _, f := range s.LoadService.Status().Files {
+		if f.File == tickFile {
+			found = true
+			if got, exp := f.Status, load.FileSkipped; got != exp {
+				t.Errorf("unexpected file status got %s exp %s", got, exp)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("missing status of file %s", tickFile)
+	}
+
+	// Removing the file does not delete the task created via the API.
+	if err := os.Remove(tickFile); err != nil {
+		t.Fatal(err)
+	}
+	s.Reload()
+	if task, _ := cli.Task(cli.TaskLink("api_task"), nil); task.ID == "" {
+		t.Error("expected task created via the API to still exist")
+	}
+}
+
 func TestSideloadService(t *testing.T) {
 	dir := t.TempDir()
 
@@ -21,6 +21,9 @@ const (
 	NumEnabledTasksVarName  = "num_enabled_tasks"
 	NumSubscriptionsVarName = "num_subscriptions"
 
+	BatchQueriesRunningVarName = "batch_queries_running"
+	BatchQueriesQueuedVarName  = "batch_queries_queued"
+
 	UptimeVarName = "uptime"
 
 	// The name of the product
@@ -33,6 +36,9 @@ var (
 	NumEnabledTasksVar  = &kexpvar.Int{}
 	NumSubscriptionsVar = kexpvar.NewIntSum()
 
+	BatchQueriesRunningVar = &kexpvar.Int{}
+	BatchQueriesQueuedVar  = &kexpvar.Int{}
+
 	ClusterIDVar = &kexpvar.UUID{}
 	ServerIDVar  = &kexpvar.UUID{}
 	HostVar      = &kexpvar.String{}
@@ -52,6 +58,9 @@ func init() {
 	expvar.Publish(NumEnabledTasksVarName, NumEnabledTasksVar)
 	expvar.Publish(NumSubscriptionsVarName, NumSubscriptionsVar)
 
+	expvar.Publish(BatchQueriesRunningVarName, BatchQueriesRunningVar)
+	expvar.Publish(BatchQueriesQueuedVarName, BatchQueriesQueuedVar)
+
 	expvar.Publish(ClusterIDVarName, ClusterIDVar)
 	expvar.Publish(ServerIDVarName, ServerIDVar)
 	expvar.Publish(HostVarName, HostVar)
@@ -8,11 +8,13 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/influxdata/kapacitor/alert"
 	client "github.com/influxdata/kapacitor/client/v1"
 	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/uuid"
 )
 
 const (
@@ -24,7 +26,12 @@ const (
 	topicsBasePath         = httpd.BasePath + topicsPath
 	topicsBasePathAnchored = httpd.BasePath + topicsPathAnchored
 
+	silencesPath         = alertsPath + "/silences"
+	silencesPathAnchored = alertsPath + "/silences/"
+	silencesBasePath     = httpd.BasePath + silencesPath
+
 	topicEventsPath           = "events"
+	topicEventAckPath         = "ack"
 	topicHandlersPath         = "handlers"
 	topicHandlersPathAnchored = topicHandlersPath + "/"
 
@@ -32,6 +39,7 @@ const (
 	eventPattern    = "*/" + topicEventsPath + "/*"
 	handlersPattern = "*/" + topicHandlersPath
 	handlerPattern  = "*/" + topicHandlersPath + "/*"
+	ackPattern      = eventPattern + "/" + topicEventAckPath
 
 	eventsRelation   = "events"
 	handlersRelation = "handlers"
@@ -41,6 +49,7 @@ type apiServer struct {
 	Registrar    HandlerSpecRegistrar
 	Topics       Topics
 	Persister    TopicPersister
+	Silences     Silences
 	routes       []httpd.Route
 	HTTPDService interface {
 		AddRoutes([]httpd.Route) error
@@ -88,6 +97,32 @@ func (s *apiServer) Open() error {
 			Pattern:     topicsPathAnchored,
 			HandlerFunc: httpd.ServeOptions,
 		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleListSilences,
+		},
+		{
+			Method:      "POST",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleCreateSilence,
+		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleGetSilence,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleDeleteSilence,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
 	}
 
 	return s.HTTPDService.AddRoutes(s.routes)
@@ -179,6 +214,9 @@ func (s *apiServer) handleRouteTopicGet(w http.ResponseWriter, r *http.Request)
 	switch {
 	case pathMatch(eventsPattern, p):
 		s.handleListEvents(id, w, r)
+	case pathMatch(ackPattern, p):
+		event := s.eventIDFromPath(path.Dir(p))
+		s.handleGetAcknowledgement(id, event, w, r)
 	case pathMatch(eventPattern, p):
 		event := s.eventIDFromPath(p)
 		s.handleGetEvent(id, event, w, r)
@@ -195,6 +233,11 @@ func (s *apiServer) handleRouteTopicGet(w http.ResponseWriter, r *http.Request)
 func (s *apiServer) handleRouteTopicPost(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(ackPattern, p) {
+		event := s.eventIDFromPath(path.Dir(p))
+		s.handleAcknowledgeEvent(topic, event, w, r)
+		return
+	}
 	s.handleCreateHandler(topic, w, r)
 }
 
@@ -213,6 +256,11 @@ func (s *apiServer) handleRouteTopicPatch(w http.ResponseWriter, r *http.Request
 func (s *apiServer) handleRouteTopicDelete(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(ackPattern, p) {
+		event := s.eventIDFromPath(path.Dir(p))
+		s.handleUnacknowledgeEvent(topic, event, w, r)
+		return
+	}
 	handler, ok := s.handlerIDFromPath(p)
 	if !ok {
 		// We only have a topic path
@@ -232,6 +280,12 @@ func (s *apiServer) topicEventsLink(id string, r client.Relation) client.Link {
 func (s *apiServer) topicEventLink(topic, event string) client.Link {
 	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, topic, topicEventsPath, event)}
 }
+func (s *apiServer) topicEventAckLink(topic, event string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, topic, topicEventsPath, event, topicEventAckPath)}
+}
+func (s *apiServer) silenceLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(silencesBasePath, id)}
+}
 func (s *apiServer) topicHandlersLink(id string, r client.Relation) client.Link {
 	return client.Link{Relation: r, Href: path.Join(topicsBasePath, id, topicHandlersPath)}
 }
@@ -268,22 +322,30 @@ func (s *apiServer) handleGetTopic(id string, w http.ResponseWriter, r *http.Req
 
 func (s *apiServer) convertEventStateToClient(state alert.EventState) client.EventState {
 	return client.EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: client.Duration(state.Duration),
-		Level:    state.Level.String(),
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    client.Duration(state.Duration),
+		Level:       state.Level.String(),
+		Fingerprint: state.Fingerprint,
 	}
 }
 
 func (s *apiServer) convertHandlerSpec(spec HandlerSpec) client.TopicHandler {
-	return client.TopicHandler{
+	h := client.TopicHandler{
 		Link:    s.topicHandlerLink(spec.Topic, spec.ID),
 		ID:      spec.ID,
 		Kind:    spec.Kind,
 		Options: spec.Options,
 		Match:   spec.Match,
 	}
+	if spec.MinLevel != "" {
+		// Report the effective level, i.e. "critical" is reported as "CRITICAL"
+		if l, err := alert.ParseLevel(spec.MinLevel); err == nil {
+			h.MinLevel = l.String()
+		}
+	}
+	return h
 }
 
 func (s *apiServer) handleListEvents(topic string, w http.ResponseWriter, r *http.Request) {
@@ -507,3 +569,140 @@ func (s *apiServer) handleGetHandler(topic, handler string, w http.ResponseWrite
 	w.WriteHeader(http.StatusOK)
 	w.Write(httpd.MarshalJSON(h, true))
 }
+
+func (s *apiServer) convertSilence(silence Silence) client.Silence {
+	return client.Silence{
+		Link:    s.silenceLink(silence.ID),
+		ID:      silence.ID,
+		Topic:   silence.Topic,
+		Tags:    silence.Tags,
+		Comment: silence.Comment,
+		Start:   silence.Start,
+		End:     silence.End,
+	}
+}
+
+func (s *apiServer) convertAcknowledgement(ack Acknowledgement) client.Acknowledgement {
+	return client.Acknowledgement{
+		Link:    s.topicEventAckLink(ack.Topic, ack.Event),
+		Topic:   ack.Topic,
+		Event:   ack.Event,
+		Level:   ack.Level.String(),
+		Comment: ack.Comment,
+		Time:    ack.Time,
+	}
+}
+
+func (s *apiServer) handleListSilences(w http.ResponseWriter, r *http.Request) {
+	silences := s.Silences.Silences()
+	list := client.Silences{
+		Link:     client.Link{Relation: client.Self, Href: r.URL.String()},
+		Silences: make([]client.Silence, len(silences)),
+	}
+	for i, silence := range silences {
+		list.Silences[i] = s.convertSilence(silence)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	opt := client.SilenceOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if opt.Duration <= 0 {
+		httpd.HttpError(w, "silence duration must be positive", true, http.StatusBadRequest)
+		return
+	}
+	start := opt.Start
+	if start.IsZero() {
+		start = time.Now().UTC()
+	}
+	silence := Silence{
+		ID:      opt.ID,
+		Topic:   opt.Topic,
+		Tags:    opt.Tags,
+		Comment: opt.Comment,
+		Start:   start,
+		End:     start.Add(time.Duration(opt.Duration)),
+	}
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+	if err := silence.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+
+	silence, err := s.Silences.CreateSilence(silence)
+	if err == ErrSilenceExists {
+		httpd.HttpError(w, fmt.Sprintf("silence %q already exists", opt.ID), true, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to create silence: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilence(silence), true))
+}
+
+func (s *apiServer) handleGetSilence(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(strings.TrimPrefix(r.URL.Path, silencesBasePath))
+	silence, ok := s.Silences.Silence(id)
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown silence: %q", id), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilence(silence), true))
+}
+
+func (s *apiServer) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(strings.TrimPrefix(r.URL.Path, silencesBasePath))
+	if err := s.Silences.DeleteSilence(id); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete silence: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) handleAcknowledgeEvent(topic, event string, w http.ResponseWriter, r *http.Request) {
+	opt := client.AcknowledgeOptions{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+			httpd.HttpError(w, fmt.Sprint("invalid acknowledgement json: ", err.Error()), true, http.StatusBadRequest)
+			return
+		}
+	}
+	if _, ok, _ := s.Topics.EventState(topic, event); !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown event %q in topic %q", event, topic), true, http.StatusNotFound)
+		return
+	}
+	ack, err := s.Silences.AcknowledgeEvent(topic, event, opt.Comment)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to acknowledge event: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertAcknowledgement(ack), true))
+}
+
+func (s *apiServer) handleGetAcknowledgement(topic, event string, w http.ResponseWriter, r *http.Request) {
+	ack, ok := s.Silences.Acknowledgement(topic, event)
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("event %q in topic %q is not acknowledged", event, topic), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertAcknowledgement(ack), true))
+}
+
+func (s *apiServer) handleUnacknowledgeEvent(topic, event string, w http.ResponseWriter, r *http.Request) {
+	if err := s.Silences.UnacknowledgeEvent(topic, event); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to unacknowledge event: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
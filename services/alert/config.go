@@ -1,6 +1,8 @@
 package alert
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
@@ -9,21 +11,73 @@ import (
 
 const (
 	DefaultShutdownTimeout = toml.Duration(time.Second * 10)
+
+	DefaultJournalPath      = "./alert.journal"
+	DefaultJournalMaxSize   = 64 * 1024 * 1024
+	DefaultJournalRetention = toml.Duration(time.Hour)
 )
 
 type Config struct {
 	// Whether we persist the alert topics to BoltDB or not
 	PersistTopics     bool `toml:"persist-topics"`
 	TopicBufferLength int  `toml:"topic-buffer-length"`
+
+	// Whether to journal alert events so that events which were not delivered
+	// before a crash are redelivered on startup.
+	JournalEnabled bool `toml:"journal-enabled"`
+	// Path to the journal file.
+	JournalPath string `toml:"journal-path"`
+	// Size in bytes at which the journal is compacted.
+	// The oldest undelivered events are dropped if they take up more than half of the size.
+	// Zero means no limit, the journal is then compacted every 10 minutes.
+	JournalMaxSize int64 `toml:"journal-max-size"`
+	// How long undelivered events are redelivered for, zero means forever.
+	JournalRetention toml.Duration `toml:"journal-retention"`
+
+	// Custom levels more severe than CRITICAL, in increasing order of severity.
+	CustomLevels []string `toml:"custom-levels"`
+
+	// Number of events each handler of a topic handles at the same time.
+	HandlerConcurrency int `toml:"handler-concurrency"`
+	// Handler concurrency of topics by ID, overriding handler-concurrency.
+	TopicHandlerConcurrency map[string]int `toml:"topic-handler-concurrency"`
 }
 
 func NewConfig() Config {
 	return Config{
 		PersistTopics:     true,
 		TopicBufferLength: alert.DefaultEventBufferSize,
+		JournalPath:       DefaultJournalPath,
+		JournalMaxSize:    DefaultJournalMaxSize,
+		JournalRetention:  DefaultJournalRetention,
+
+		HandlerConcurrency: alert.DefaultHandlerConcurrency,
 	}
 }
 
 func (c Config) Validate() error {
+	if err := alert.ValidateCustomLevels(c.CustomLevels); err != nil {
+		return err
+	}
+	if c.HandlerConcurrency < 1 {
+		return fmt.Errorf("handler-concurrency must be >= 1, got %d", c.HandlerConcurrency)
+	}
+	for topic, concurrency := range c.TopicHandlerConcurrency {
+		if concurrency < 1 {
+			return fmt.Errorf("topic-handler-concurrency of topic %q must be >= 1, got %d", topic, concurrency)
+		}
+	}
+	if !c.JournalEnabled {
+		return nil
+	}
+	if c.JournalPath == "" {
+		return errors.New("must specify journal-path")
+	}
+	if c.JournalMaxSize < 0 {
+		return fmt.Errorf("journal-max-size must be >= 0, got %d", c.JournalMaxSize)
+	}
+	if c.JournalRetention < 0 {
+		return fmt.Errorf("journal-retention must be >= 0, got %v", time.Duration(c.JournalRetention))
+	}
 	return nil
 }
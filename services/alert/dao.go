@@ -72,6 +72,9 @@ type HandlerSpec struct {
 	Kind    string                 `json:"kind"`
 	Options map[string]interface{} `json:"options"`
 	Match   string                 `json:"match"`
+	// MinLevel is the minimum level of the events passed to the handler.
+	// Recovery events of alerts that met the minimum level are also passed to the handler.
+	MinLevel string `json:"min-level"`
 }
 
 var validHandlerID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
@@ -87,6 +90,11 @@ func (h HandlerSpec) Validate() error {
 	if h.Kind == "" {
 		return errors.New("handler Kind must not be empty")
 	}
+	if h.MinLevel != "" {
+		if _, err := alert.ParseLevel(h.MinLevel); err != nil {
+			return errors.Wrap(err, "invalid handler min-level")
+		}
+	}
 	return nil
 }
 
@@ -259,6 +267,8 @@ type EventState struct {
 	Time     time.Time     `json:"time"`
 	Duration time.Duration `json:"duration"`
 	Level    alert.Level   `json:"level"`
+	// Fingerprint of the alert, empty for states persisted by older versions.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 func (t TopicState) ObjectID() string {
@@ -344,3 +354,287 @@ func (kv *topicStateKV) List(pattern string, offset, limit int) ([]TopicState, e
 func (kv *topicStateKV) Rebuild() error {
 	return kv.store.Rebuild()
 }
+
+var (
+	ErrSilenceExists   = errors.New("silence already exists")
+	ErrNoSilenceExists = errors.New("no silence exists")
+)
+
+// Data access object for Silence data.
+type SilenceDAO interface {
+	// Retrieve a silence
+	Get(id string) (Silence, error)
+
+	// Create a silence.
+	// ErrSilenceExists is returned if a silence already exists with the same ID.
+	Create(s Silence) error
+
+	// Delete a silence.
+	// It is not an error to delete an non-existent silence.
+	Delete(id string) error
+
+	// List silences matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Silence, error)
+
+	Rebuild() error
+}
+
+const silenceVersion = 1
+
+// Silence suppresses delivery of events matching its topic and tags
+// while the current time is within [Start, End).
+type Silence struct {
+	ID string `json:"id"`
+	// Topic is a glob pattern of topics to match, empty matches all topics.
+	Topic string `json:"topic"`
+	// Tags must all be present on the event with the same values.
+	Tags    map[string]string `json:"tags"`
+	Comment string            `json:"comment"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+}
+
+var validSilenceID = validHandlerID
+
+func (s Silence) Validate() error {
+	if !validSilenceID.MatchString(s.ID) {
+		return fmt.Errorf("silence ID must contain only letters, numbers, '-', '.' and '_'. %q", s.ID)
+	}
+	if err := validatePattern(s.Topic); err != nil {
+		return errors.Wrap(err, "invalid topic pattern")
+	}
+	if !s.End.After(s.Start) {
+		return errors.New("silence end must be after start")
+	}
+	return nil
+}
+
+// Active reports whether the silence is in effect at the given time.
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.End)
+}
+
+// Expired reports whether the silence can no longer become active.
+func (s Silence) Expired(now time.Time) bool {
+	return !now.Before(s.End)
+}
+
+// Matches reports whether the topic and tags are matched by the silence.
+func (s Silence) Matches(topic string, tags map[string]string) bool {
+	if !alert.PatternMatch(s.Topic, topic) {
+		return false
+	}
+	for k, v := range s.Tags {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Silence) ObjectID() string {
+	return s.ID
+}
+
+func (s Silence) MarshalBinary() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid silence")
+	}
+	return storage.VersionJSONEncode(silenceVersion, s)
+}
+
+func (s *Silence) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		if version != silenceVersion {
+			return fmt.Errorf("unknown silence version %d: cannot decode", version)
+		}
+		return dec.Decode(s)
+	})
+}
+
+// Key/Value store based implementation of the SilenceDAO
+type silenceKV struct {
+	store *storage.IndexedStore
+}
+
+func newSilenceKV(store storage.Interface) (*silenceKV, error) {
+	c := storage.DefaultIndexedStoreConfig("silences", func() storage.BinaryObject {
+		return new(Silence)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &silenceKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *silenceKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrSilenceExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoSilenceExists
+	}
+	return err
+}
+
+func (kv *silenceKV) Get(id string) (Silence, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return Silence{}, kv.error(err)
+	}
+	s, ok := o.(*Silence)
+	if !ok {
+		return Silence{}, storage.ImpossibleTypeErr(s, o)
+	}
+	return *s, nil
+}
+
+func (kv *silenceKV) Create(s Silence) error {
+	return kv.error(kv.store.Create(&s))
+}
+
+func (kv *silenceKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *silenceKV) List(pattern string, offset, limit int) ([]Silence, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	silences := make([]Silence, len(objects))
+	for i, o := range objects {
+		s, ok := o.(*Silence)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(s, o)
+		}
+		silences[i] = *s
+	}
+	return silences, nil
+}
+
+func (kv *silenceKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrNoAcknowledgementExists = errors.New("no acknowledgement exists")
+)
+
+// Data access object for Acknowledgement data.
+type AcknowledgementDAO interface {
+	// Retrieve an acknowledgement
+	Get(topic, event string) (Acknowledgement, error)
+
+	// Put an acknowledgement, replaces any existing acknowledgement.
+	Put(a Acknowledgement) error
+
+	// Delete an acknowledgement.
+	// It is not an error to delete an non-existent acknowledgement.
+	Delete(topic, event string) error
+
+	// List acknowledgements for topics matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Acknowledgement, error)
+
+	Rebuild() error
+}
+
+const acknowledgementVersion = 1
+
+// Acknowledgement suppresses delivery of an event while it remains at the acknowledged level.
+type Acknowledgement struct {
+	Topic   string      `json:"topic"`
+	Event   string      `json:"event"`
+	Level   alert.Level `json:"level"`
+	Comment string      `json:"comment"`
+	Time    time.Time   `json:"time"`
+}
+
+func (a Acknowledgement) ObjectID() string {
+	return fullID(a.Topic, a.Event)
+}
+
+func (a Acknowledgement) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(acknowledgementVersion, a)
+}
+
+func (a *Acknowledgement) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		if version != acknowledgementVersion {
+			return fmt.Errorf("unknown acknowledgement version %d: cannot decode", version)
+		}
+		return dec.Decode(a)
+	})
+}
+
+// Key/Value store based implementation of the AcknowledgementDAO
+type acknowledgementKV struct {
+	store *storage.IndexedStore
+}
+
+func newAcknowledgementKV(store storage.Interface) (*acknowledgementKV, error) {
+	c := storage.DefaultIndexedStoreConfig("acknowledgements", func() storage.BinaryObject {
+		return new(Acknowledgement)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &acknowledgementKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *acknowledgementKV) Get(topic, event string) (Acknowledgement, error) {
+	o, err := kv.store.Get(fullID(topic, event))
+	if err != nil {
+		if err == storage.ErrNoObjectExists {
+			return Acknowledgement{}, ErrNoAcknowledgementExists
+		}
+		return Acknowledgement{}, err
+	}
+	a, ok := o.(*Acknowledgement)
+	if !ok {
+		return Acknowledgement{}, storage.ImpossibleTypeErr(a, o)
+	}
+	return *a, nil
+}
+
+func (kv *acknowledgementKV) Put(a Acknowledgement) error {
+	return kv.store.Put(&a)
+}
+
+func (kv *acknowledgementKV) Delete(topic, event string) error {
+	return kv.store.Delete(fullID(topic, event))
+}
+
+func (kv *acknowledgementKV) List(pattern string, offset, limit int) ([]Acknowledgement, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	objects, err := kv.store.List(storage.DefaultIDIndex, fullID(pattern, "*"), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	acks := make([]Acknowledgement, len(objects))
+	for i, o := range objects {
+		a, ok := o.(*Acknowledgement)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(a, o)
+		}
+		acks[i] = *a
+	}
+	return acks, nil
+}
+
+func (kv *acknowledgementKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
@@ -154,6 +154,8 @@ func easyjson7be57abeDecodeGithubComInfluxdataKapacitorServicesAlert1(in *jlexer
 			if data := in.UnsafeBytes(); in.Ok() {
 				in.AddError((out.Level).UnmarshalText(data))
 			}
+		case "fingerprint":
+			out.Fingerprint = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -193,6 +195,11 @@ func easyjson7be57abeEncodeGithubComInfluxdataKapacitorServicesAlert1(out *jwrit
 		out.RawString(prefix)
 		out.RawText((in.Level).MarshalText())
 	}
+	if in.Fingerprint != "" {
+		const prefix string = ",\"fingerprint\":"
+		out.RawString(prefix)
+		out.String(string(in.Fingerprint))
+	}
 	out.RawByte('}')
 }
 
@@ -2,11 +2,14 @@ package alert
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	text "text/template"
@@ -82,6 +85,378 @@ func (h *logHandler) Handle(event alert.Event) {
 	}
 }
 
+func (h *logHandler) RendersRunbook() bool {
+	return true
+}
+
+// Formats of the lines written by the file handler.
+const (
+	FileFormatJSON = "json"
+	FileFormatCSV  = "csv"
+)
+
+// backupTimeFormat is the format of the time suffix of rotated files, it sorts chronologically.
+const backupTimeFormat = "20060102T150405.000000000"
+
+type FileHandlerConfig struct {
+	// Absolute path of the file, it is created if it does not exist.
+	Path string      `mapstructure:"path"`
+	Mode os.FileMode `mapstructure:"mode"`
+
+	// Format of the lines, either json or csv, default json.
+	Format string `mapstructure:"format"`
+	// Fields of each line, in order.
+	// Either the name of an alert property: id, message, details, time, duration, level,
+	// previousLevel, recoverable, task, name, category or group,
+	// or a tag or field of the alert prefixed with "tags." or "fields.".
+	// Required for the csv format, for json lines only these fields are written if set.
+	Fields []string `mapstructure:"fields"`
+	// Write a line with the field names at the start of each csv file.
+	Header bool `mapstructure:"header"`
+	// Template of each line, rendered with the alert data, the tags and fields of the alert.
+	// Replaces the format and fields.
+	Template string `mapstructure:"template"`
+
+	// Rotate the file before it exceeds this size in bytes, if greater than zero.
+	MaxSize int64 `mapstructure:"max-size"`
+	// Rotate the file once it has been written to for this long, if greater than zero.
+	MaxAge time.Duration `mapstructure:"max-age"`
+	// Number of rotated files to keep, all are kept if zero.
+	MaxBackups int `mapstructure:"max-backups"`
+}
+
+func DefaultFileHandlerConfig() FileHandlerConfig {
+	return FileHandlerConfig{
+		Mode:   defaultLogFileMode,
+		Format: FileFormatJSON,
+	}
+}
+
+func (c FileHandlerConfig) Validate() error {
+	if c.Mode.Perm()&0200 == 0 {
+		return fmt.Errorf("invalid file mode %v, must be user writable", c.Mode)
+	}
+	if !filepath.IsAbs(c.Path) {
+		return fmt.Errorf("file path must be absolute: %s is not absolute", c.Path)
+	}
+	switch c.Format {
+	case FileFormatJSON:
+	case FileFormatCSV:
+		if c.Template == "" && len(c.Fields) == 0 {
+			return errors.New("csv format requires fields")
+		}
+	default:
+		return fmt.Errorf("invalid file format %q, must be one of %s or %s", c.Format, FileFormatJSON, FileFormatCSV)
+	}
+	for _, f := range c.Fields {
+		if !validFileField(f) {
+			return fmt.Errorf("invalid field %q", f)
+		}
+	}
+	if c.Header && c.Format != FileFormatCSV {
+		return errors.New("header is only supported by the csv format")
+	}
+	if c.MaxSize < 0 {
+		return errors.New("max-size must not be negative")
+	}
+	if c.MaxAge < 0 {
+		return errors.New("max-age must not be negative")
+	}
+	if c.MaxBackups < 0 {
+		return errors.New("max-backups must not be negative")
+	}
+	return nil
+}
+
+var fileAlertFields = map[string]bool{
+	"id":            true,
+	"message":       true,
+	"details":       true,
+	"time":          true,
+	"duration":      true,
+	"level":         true,
+	"previousLevel": true,
+	"recoverable":   true,
+	"task":          true,
+	"name":          true,
+	"category":      true,
+	"group":         true,
+}
+
+func validFileField(f string) bool {
+	if strings.HasPrefix(f, "tags.") || strings.HasPrefix(f, "fields.") {
+		return !strings.HasSuffix(f, ".")
+	}
+	return fileAlertFields[f]
+}
+
+// fileTemplateData is the data of the template of the file handler.
+type fileTemplateData struct {
+	alert.Data
+	TaskName string
+	Name     string
+	Category string
+	Group    string
+	Tags     map[string]string
+	Fields   map[string]interface{}
+}
+
+type fileHandler struct {
+	c    FileHandlerConfig
+	tmpl *text.Template
+	f    *appendFile
+	diag HandlerDiagnostic
+}
+
+func NewFileHandler(c FileHandlerConfig, d HandlerDiagnostic) (alert.Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	h := &fileHandler{
+		c:    c,
+		f:    openAppendFile(c.Path),
+		diag: d,
+	}
+	if c.Template != "" {
+		tmpl, err := text.New("file").Funcs(text.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(c.Template)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid template")
+		}
+		h.tmpl = tmpl
+	}
+	return h, nil
+}
+
+func (h *fileHandler) Handle(event alert.Event) {
+	line, err := h.line(event)
+	if err != nil {
+		h.diag.Error("failed to render alert event for file", err, keyvalue.KV("file", h.c.Path))
+		return
+	}
+	var header []byte
+	if h.c.Header {
+		header, err = h.csvLine(h.c.Fields)
+		if err != nil {
+			h.diag.Error("failed to render csv header", err, keyvalue.KV("file", h.c.Path))
+			return
+		}
+	}
+	if err := h.f.append(line, header, h.c); err != nil {
+		h.diag.Error("failed to append alert event to file", err, keyvalue.KV("file", h.c.Path))
+	}
+}
+
+func (h *fileHandler) RendersRunbook() bool {
+	return true
+}
+
+// line renders the event as a single line ending with a new line.
+func (h *fileHandler) line(event alert.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	switch {
+	case h.tmpl != nil:
+		data := fileTemplateData{
+			Data:     event.AlertData(),
+			TaskName: event.Data.TaskName,
+			Name:     event.Data.Name,
+			Category: event.Data.Category,
+			Group:    event.Data.Group,
+			Tags:     event.Data.Tags,
+			Fields:   event.Data.Fields,
+		}
+		if err := h.tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		// A line must not span several lines
+		line := strings.Replace(strings.TrimRight(buf.String(), "\n"), "\n", " ", -1)
+		return []byte(line + "\n"), nil
+	case h.c.Format == FileFormatCSV:
+		values := make([]string, len(h.c.Fields))
+		for i, f := range h.c.Fields {
+			values[i] = formatFileValue(fileFieldValue(event, f))
+		}
+		return h.csvLine(values)
+	case len(h.c.Fields) > 0:
+		values := make(map[string]interface{}, len(h.c.Fields))
+		for _, f := range h.c.Fields {
+			values[f] = fileFieldValue(event, f)
+		}
+		err := json.NewEncoder(&buf).Encode(values)
+		return buf.Bytes(), err
+	default:
+		err := json.NewEncoder(&buf).Encode(event.AlertData())
+		return buf.Bytes(), err
+	}
+}
+
+func (h *fileHandler) csvLine(values []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(values); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// fileFieldValue returns the value of the field of the event, nil if the event does not have it.
+func fileFieldValue(event alert.Event, f string) interface{} {
+	switch {
+	case strings.HasPrefix(f, "tags."):
+		if v, ok := event.Data.Tags[strings.TrimPrefix(f, "tags.")]; ok {
+			return v
+		}
+		return nil
+	case strings.HasPrefix(f, "fields."):
+		return event.Data.Fields[strings.TrimPrefix(f, "fields.")]
+	}
+	switch f {
+	case "id":
+		return event.State.ID
+	case "message":
+		return event.State.Message
+	case "details":
+		return event.State.Details
+	case "time":
+		return event.State.Time
+	case "duration":
+		return event.State.Duration
+	case "level":
+		return event.State.Level
+	case "previousLevel":
+		return event.AlertData().PreviousLevel
+	case "recoverable":
+		return event.Data.Recoverable
+	case "task":
+		return event.Data.TaskName
+	case "name":
+		return event.Data.Name
+	case "category":
+		return event.Data.Category
+	case "group":
+		return event.Data.Group
+	}
+	return nil
+}
+
+// formatFileValue formats a value of a csv line, missing values are empty.
+func formatFileValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// appendFile serializes the appends to a file and its rotation.
+// Handlers of the same path share the same appendFile,
+// so that lines of different handlers are never interleaved.
+type appendFile struct {
+	path string
+
+	mu sync.Mutex
+	// When the current file was first written to by this process.
+	started time.Time
+}
+
+var appendFiles = struct {
+	sync.Mutex
+	files map[string]*appendFile
+}{
+	files: make(map[string]*appendFile),
+}
+
+func openAppendFile(path string) *appendFile {
+	appendFiles.Lock()
+	defer appendFiles.Unlock()
+	f, ok := appendFiles.files[path]
+	if !ok {
+		f = &appendFile{path: path}
+		appendFiles.files[path] = f
+	}
+	return f
+}
+
+// append appends the line to the file, rotating the file first if it is due.
+// The header is written first to new files.
+func (f *appendFile) append(line, header []byte, c FileHandlerConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	var size int64
+	if info, err := os.Stat(f.path); err == nil {
+		size = info.Size()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if f.started.IsZero() {
+		f.started = now
+	}
+	if size > 0 {
+		bySize := c.MaxSize > 0 && size+int64(len(line)) > c.MaxSize
+		byAge := c.MaxAge > 0 && now.Sub(f.started) >= c.MaxAge
+		if bySize || byAge {
+			if err := f.rotate(now, c.MaxBackups); err != nil {
+				return errors.Wrap(err, "failed to rotate file")
+			}
+			size = 0
+		}
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, c.Mode)
+	if err != nil {
+		return err
+	}
+	if size == 0 && len(header) > 0 {
+		line = append(append([]byte{}, header...), line...)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// rotate renames the current file with the time as suffix and removes the oldest rotated files.
+func (f *appendFile) rotate(now time.Time, maxBackups int) error {
+	if err := os.Rename(f.path, f.path+"."+now.UTC().Format(backupTimeFormat)); err != nil {
+		return err
+	}
+	f.started = now
+	if maxBackups == 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(m, f.path+".")); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
 type ExecHandlerConfig struct {
 	Prog      string            `mapstructure:"prog"`
 	Args      []string          `mapstructure:"args"`
@@ -109,6 +484,11 @@ func NewExecHandler(c ExecHandlerConfig, d HandlerDiagnostic) alert.Handler {
 }
 
 func (h *execHandler) Handle(event alert.Event) {
+	h.Deliver(event)
+}
+
+// Deliver runs the command with the event and returns an error if the command failed.
+func (h *execHandler) Deliver(event alert.Event) error {
 	buf := h.bp.Get()
 	defer h.bp.Put(buf)
 	ad := event.AlertData()
@@ -116,7 +496,7 @@ func (h *execHandler) Handle(event alert.Event) {
 	err := json.NewEncoder(buf).Encode(ad)
 	if err != nil {
 		h.diag.Error("failed to marshal alert data json", err)
-		return
+		return err
 	}
 
 	cmd := h.commander.NewCommand(h.s)
@@ -127,13 +507,18 @@ func (h *execHandler) Handle(event alert.Event) {
 	err = cmd.Start()
 	if err != nil {
 		h.diag.Error("exec command failed", err, keyvalue.KV("output", out.String()))
-		return
+		return err
 	}
 	err = cmd.Wait()
 	if err != nil {
 		h.diag.Error("exec command failed", err, keyvalue.KV("output", out.String()))
-		return
+		return err
 	}
+	return nil
+}
+
+func (h *execHandler) RendersRunbook() bool {
+	return true
 }
 
 type TCPHandlerConfig struct {
@@ -155,6 +540,11 @@ func NewTCPHandler(c TCPHandlerConfig, d HandlerDiagnostic) alert.Handler {
 }
 
 func (h *tcpHandler) Handle(event alert.Event) {
+	h.Deliver(event)
+}
+
+// Deliver writes the event to the address and returns an error if it could not be written.
+func (h *tcpHandler) Deliver(event alert.Event) error {
 	buf := h.bp.Get()
 	defer h.bp.Put(buf)
 	ad := event.AlertData()
@@ -162,18 +552,26 @@ func (h *tcpHandler) Handle(event alert.Event) {
 	err := json.NewEncoder(buf).Encode(ad)
 	if err != nil {
 		h.diag.Error("failed to marshal alert data json", err)
-		return
+		return err
 	}
 
 	conn, err := net.Dial("tcp", h.addr)
 	if err != nil {
 		h.diag.Error("tcp handler failed to connect", err, keyvalue.KV("address", h.addr))
-		return
+		return err
 	}
 	defer conn.Close()
 
 	buf.WriteByte('\n')
-	conn.Write(buf.Bytes())
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		h.diag.Error("tcp handler failed to write", err, keyvalue.KV("address", h.addr))
+		return err
+	}
+	return nil
+}
+
+func (h *tcpHandler) RendersRunbook() bool {
+	return true
 }
 
 type AggregateHandlerConfig struct {
@@ -332,6 +730,11 @@ func (h *publishHandler) Handle(event alert.Event) {
 	}
 }
 
+// RendersRunbook reports true since the events are passed on to the handlers of the published topics.
+func (h *publishHandler) RendersRunbook() bool {
+	return true
+}
+
 // ExternalHandler wraps an existing handler that calls out to external services.
 // The events are checked for the NoExternal flag before being passed to the external handler.
 type externalHandler struct {
@@ -345,14 +748,101 @@ func newExternalHandler(h alert.Handler) *externalHandler {
 }
 
 func (h *externalHandler) Handle(event alert.Event) {
-	if !event.NoExternal {
-		h.h.Handle(event)
+	h.Deliver(event)
+}
+
+func (h *externalHandler) Deliver(event alert.Event) error {
+	if event.NoExternal {
+		return nil
+	}
+	return alert.Deliver(h.h, event)
+}
+
+func (h *externalHandler) RendersRunbook() bool {
+	return alert.RendersRunbook(h.h)
+}
+
+// Bounds of the events a level handler tracks until their recovery.
+const (
+	// levelHandlerMaxActive is the maximum number of tracked events, the oldest are dropped beyond it.
+	levelHandlerMaxActive = 10000
+	// levelHandlerActiveExpiry is how long an event that has not recovered is tracked since its last event.
+	levelHandlerActiveExpiry = 24 * time.Hour
+)
+
+// levelHandler wraps an existing handler, passing on only events with at least the minimum level.
+// The recovery event of an alert is passed on if any of its previous events were passed on,
+// so that handlers are able to resolve the alerts they were sent.
+// The number of tracked alerts is bounded, once the bound is reached the expired alerts and then the oldest are dropped.
+type levelHandler struct {
+	h        alert.Handler
+	minLevel alert.Level
+
+	mu sync.Mutex
+	// Time of the last passed on event by event ID, of the events that have not yet recovered.
+	active map[string]time.Time
+}
+
+func newLevelHandler(minLevel alert.Level, h alert.Handler) *levelHandler {
+	return &levelHandler{
+		h:        h,
+		minLevel: minLevel,
+		active:   make(map[string]time.Time),
+	}
+}
+
+func (h *levelHandler) Handle(event alert.Event) {
+	h.Deliver(event)
+}
+
+func (h *levelHandler) Deliver(event alert.Event) error {
+	if !h.pass(event) {
+		return nil
+	}
+	return alert.Deliver(h.h, event)
+}
+
+func (h *levelHandler) pass(event alert.Event) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if event.State.Level == alert.OK {
+		_, ok := h.active[event.State.ID]
+		delete(h.active, event.State.ID)
+		return ok || h.minLevel == alert.OK
+	}
+	if event.State.Level < h.minLevel {
+		return false
+	}
+	if _, ok := h.active[event.State.ID]; !ok && len(h.active) >= levelHandlerMaxActive {
+		h.expire(event.State.Time)
+	}
+	h.active[event.State.ID] = event.State.Time
+	return true
+}
+
+// expire drops the expired events, or the oldest event if none expired.
+// The caller must hold the lock.
+func (h *levelHandler) expire(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	for id, t := range h.active {
+		if now.Sub(t) > levelHandlerActiveExpiry {
+			delete(h.active, id)
+		} else if oldestID == "" || t.Before(oldest) {
+			oldestID, oldest = id, t
+		}
+	}
+	if len(h.active) >= levelHandlerMaxActive {
+		delete(h.active, oldestID)
 	}
 }
 
 type matchHandler struct {
 	h alert.Handler
 
+	// mu guards scope and expr, the workers of a topic with a handler concurrency above 1
+	// handle events at the same time.
+	mu    sync.Mutex
 	scope *stateful.Scope
 	expr  stateful.Expression
 
@@ -376,11 +866,14 @@ const (
 	durationFunc = "alertDuration"
 )
 
-var matchIdentifiers = map[string]interface{}{
-	"OK":       int64(alert.OK),
-	"INFO":     int64(alert.Info),
-	"WARNING":  int64(alert.Warning),
-	"CRITICAL": int64(alert.Critical),
+// matchIdentifier returns the value of the level identifier of a match expression,
+// i.e. OK, INFO, WARNING, CRITICAL or a custom level.
+func matchIdentifier(ident string) (interface{}, bool) {
+	l, err := alert.ParseLevel(ident)
+	if err != nil || l.String() != ident {
+		return nil, false
+	}
+	return int64(l), true
 }
 
 func newMatchHandler(match string, h alert.Handler, d HandlerDiagnostic) (*matchHandler, error) {
@@ -392,7 +885,7 @@ func newMatchHandler(match string, h alert.Handler, d HandlerDiagnostic) (*match
 	// Replace identifiers with static values
 	_, err = ast.Walk(lambda, func(n ast.Node) (ast.Node, error) {
 		if ident, ok := n.(*ast.IdentifierNode); ok {
-			v, ok := matchIdentifiers[ident.Ident]
+			v, ok := matchIdentifier(ident.Ident)
 			if !ok {
 				return nil, fmt.Errorf("unknown identifier %q", ident.Ident)
 			}
@@ -439,22 +932,33 @@ func newMatchHandler(match string, h alert.Handler, d HandlerDiagnostic) (*match
 }
 
 func (h *matchHandler) Handle(event alert.Event) {
+	h.Deliver(event)
+}
+
+func (h *matchHandler) Deliver(event alert.Event) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch r := r.(type) {
 			case string:
-				h.diag.Error("recovered from panic", errors.New(r))
+				err = errors.New(r)
 			case error:
-				h.diag.Error("recovered from panic", r)
+				err = r
+			default:
+				err = fmt.Errorf("%v", r)
 			}
+			h.diag.Error("recovered from panic", err)
 		}
 	}()
 
-	if ok, err := h.match(event); err != nil {
+	ok, err := h.match(event)
+	if err != nil {
 		h.diag.Error("failed to evaluate match expression", err)
-	} else if ok {
-		h.h.Handle(event)
+		return err
+	}
+	if !ok {
+		return nil
 	}
+	return alert.Deliver(h.h, event)
 }
 
 var changedFuncSignature = map[stateful.Domain]ast.ValueType{}
@@ -473,6 +977,8 @@ func init() {
 }
 
 func (h *matchHandler) match(event alert.Event) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	// Populate scope
 	h.scope.Reset()
 
@@ -537,6 +1043,7 @@ func (h *matchHandler) match(event alert.Event) (bool, error) {
 	}
 
 	// Set tag values on scope
+	h.scope.SetTags(event.Data.Tags)
 	for _, v := range h.vars {
 		if tag, ok := event.Data.Tags[v]; ok {
 			h.scope.Set(v, tag)
@@ -1,10 +1,18 @@
 package alert
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/keyvalue"
 )
 
 func TestMatchHandlerAlertDuration(t *testing.T) {
@@ -67,3 +75,409 @@ func TestMatchHandlerAlertDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchHandlerCustomLevel(t *testing.T) {
+	if err := alert.SetCustomLevels([]string{"FATAL"}); err != nil {
+		t.Fatal(err)
+	}
+	defer alert.SetCustomLevels(nil)
+	fatal, _ := alert.ParseLevel("FATAL")
+
+	cases := []struct {
+		level       alert.Level
+		shouldMatch bool
+	}{
+		{level: alert.Critical, shouldMatch: false},
+		{level: fatal, shouldMatch: true},
+	}
+	var h alert.Handler
+	mh, err := newMatchHandler("level() >= FATAL", h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, testCase := range cases {
+		matched, err := mh.match(alert.Event{State: alert.EventState{Level: testCase.level}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != testCase.shouldMatch {
+			t.Errorf("unexpected match of level %v got %t exp %t", testCase.level, matched, testCase.shouldMatch)
+		}
+	}
+
+	if _, err := newMatchHandler("level() >= FATAL2", h, nil); err == nil {
+		t.Error("expected unknown identifier error")
+	}
+}
+
+// countHandler counts the events it handles.
+type countHandler struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (h *countHandler) Handle(event alert.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[event.State.ID]++
+}
+
+func TestMatchHandler_Concurrency(t *testing.T) {
+	topics := alert.NewTopics(alert.DefaultEventBufferSize)
+	topics.SetHandlerConcurrency(8, nil)
+	defer topics.Close()
+
+	h := &countHandler{counts: make(map[string]int)}
+	mh, err := newMatchHandler(`level() == CRITICAL AND "host" == 'a'`, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics.RegisterHandler("topic", mh)
+
+	const count = 1000
+	for i := 0; i < count; i++ {
+		level := alert.Critical
+		if i%2 == 0 {
+			level = alert.Warning
+		}
+		host := "a"
+		if i%3 == 0 {
+			host = "b"
+		}
+		id := fmt.Sprintf("%s-%v-%d", host, level, i%16)
+		err := topics.Collect(alert.Event{
+			Topic: "topic",
+			State: alert.EventState{ID: id, Level: level, Time: time.Now()},
+			Data:  alert.EventData{Tags: map[string]string{"host": host}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	topics.Close()
+
+	exp := make(map[string]int)
+	for i := 0; i < count; i++ {
+		if i%2 != 0 && i%3 != 0 {
+			exp[fmt.Sprintf("a-%v-%d", alert.Critical, i%16)]++
+		}
+	}
+	if !reflect.DeepEqual(h.counts, exp) {
+		t.Errorf("unexpected matched events got %v exp %v", h.counts, exp)
+	}
+}
+
+type levelsHandler struct {
+	levels []alert.Level
+}
+
+func (h *levelsHandler) Handle(event alert.Event) {
+	h.levels = append(h.levels, event.State.Level)
+}
+
+func TestLevelHandler(t *testing.T) {
+	collected := []alert.Level{
+		alert.OK,
+		alert.Warning,
+		alert.Critical,
+		alert.Warning,
+		alert.OK,
+		alert.Info,
+		alert.Warning,
+		alert.OK,
+	}
+	cases := []struct {
+		minLevel alert.Level
+		exp      []alert.Level
+	}{
+		{
+			minLevel: alert.OK,
+			exp:      collected,
+		},
+		{
+			minLevel: alert.Warning,
+			exp: []alert.Level{
+				alert.Warning,
+				alert.Critical,
+				alert.Warning,
+				alert.OK,
+				alert.Warning,
+				alert.OK,
+			},
+		},
+		{
+			minLevel: alert.Critical,
+			exp: []alert.Level{
+				alert.Critical,
+				alert.OK,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.minLevel.String(), func(t *testing.T) {
+			topics := alert.NewTopics(10)
+			h := new(levelsHandler)
+			topics.RegisterHandler("topic", newLevelHandler(tc.minLevel, h))
+			for i, l := range collected {
+				if err := topics.Collect(alert.Event{
+					Topic: "topic",
+					State: alert.EventState{
+						ID:    "id",
+						Time:  time.Unix(int64(i), 0),
+						Level: l,
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			topics.Close()
+			if !reflect.DeepEqual(h.levels, tc.exp) {
+				t.Errorf("unexpected levels got %v exp %v", h.levels, tc.exp)
+			}
+		})
+	}
+}
+
+func TestLevelHandler_Active(t *testing.T) {
+	h := newLevelHandler(alert.OK, new(levelsHandler))
+	start := time.Unix(0, 0)
+	// The recovered events are no longer tracked.
+	h.Handle(alert.Event{State: alert.EventState{ID: "recovered", Time: start, Level: alert.Critical}})
+	h.Handle(alert.Event{State: alert.EventState{ID: "recovered", Time: start, Level: alert.OK}})
+	if got := len(h.active); got != 0 {
+		t.Fatalf("unexpected tracked events got %d exp 0", got)
+	}
+
+	// The expired events and then the oldest are dropped once the bound is reached.
+	h.Handle(alert.Event{State: alert.EventState{ID: "expired", Time: start, Level: alert.Critical}})
+	for i := 0; i < levelHandlerMaxActive; i++ {
+		h.Handle(alert.Event{State: alert.EventState{
+			ID:    fmt.Sprintf("id-%d", i),
+			Time:  start.Add(levelHandlerActiveExpiry + time.Duration(i+1)*time.Second),
+			Level: alert.Warning,
+		}})
+	}
+	if got, exp := len(h.active), levelHandlerMaxActive; got != exp {
+		t.Fatalf("unexpected tracked events got %d exp %d", got, exp)
+	}
+	if _, ok := h.active["expired"]; ok {
+		t.Error("expected expired event to be dropped")
+	}
+	h.Handle(alert.Event{State: alert.EventState{ID: "new", Time: start.Add(2 * levelHandlerActiveExpiry), Level: alert.Warning}})
+	if got, exp := len(h.active), levelHandlerMaxActive; got != exp {
+		t.Fatalf("unexpected tracked events got %d exp %d", got, exp)
+	}
+	if _, ok := h.active["id-0"]; ok {
+		t.Error("expected oldest event to be dropped")
+	}
+}
+
+type errorDiagnostic struct {
+	t *testing.T
+}
+
+func (d errorDiagnostic) Error(msg string, err error, ctx ...keyvalue.T) {
+	d.t.Errorf("%s: %v", msg, err)
+}
+
+func fileTestEvent(id string, level alert.Level, value float64) alert.Event {
+	return alert.Event{
+		State: alert.EventState{
+			ID:      id,
+			Message: id + " is " + level.String(),
+			Time:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name:     "cpu",
+			TaskName: "task",
+			Tags:     map[string]string{"host": "serverA"},
+			Fields:   map[string]interface{}{"value": value},
+		},
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileHandler_Formats(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name string
+		c    FileHandlerConfig
+		exp  string
+	}{
+		{
+			name: "csv",
+			c: FileHandlerConfig{
+				Format: FileFormatCSV,
+				Fields: []string{"time", "level", "id", "tags.host", "tags.missing", "fields.value", "message"},
+				Header: true,
+			},
+			exp: `time,level,id,tags.host,tags.missing,fields.value,message
+2024-01-01T00:00:00Z,CRITICAL,"cpu,serverA",serverA,,42.5,"cpu,serverA is CRITICAL"
+2024-01-01T00:00:00Z,OK,"cpu,serverA",serverA,,1,"cpu,serverA is OK"
+`,
+		},
+		{
+			name: "json fields",
+			c: FileHandlerConfig{
+				Format: FileFormatJSON,
+				Fields: []string{"level", "task", "fields.value"},
+			},
+			exp: `{"fields.value":42.5,"level":"CRITICAL","task":"task"}
+{"fields.value":1,"level":"OK","task":"task"}
+`,
+		},
+		{
+			name: "template",
+			c: FileHandlerConfig{
+				Format:   FileFormatJSON,
+				Template: "{{ .Level }} {{ index .Tags \"host\" }}\n{{ json .Fields }}\n",
+			},
+			exp: `CRITICAL serverA {"value":42.5}
+OK serverA {"value":1}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.c.Path = filepath.Join(dir, strings.Replace(tc.name, " ", "_", -1))
+			tc.c.Mode = defaultLogFileMode
+			h, err := NewFileHandler(tc.c, errorDiagnostic{t: t})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.Handle(fileTestEvent("cpu,serverA", alert.Critical, 42.5))
+			h.Handle(fileTestEvent("cpu,serverA", alert.OK, 1))
+			if got := readFile(t, tc.c.Path); got != tc.exp {
+				t.Errorf("unexpected file content got\n%s\nexp\n%s", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestFileHandler_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultFileHandlerConfig()
+	c.Path = filepath.Join(dir, "alerts.csv")
+	c.Format = FileFormatCSV
+	c.Fields = []string{"id", "level"}
+	c.Header = true
+	// The header and two lines
+	c.MaxSize = 32
+	c.MaxBackups = 2
+	h, err := NewFileHandler(c, errorDiagnostic{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		h.Handle(fileTestEvent("a", alert.Critical, 0))
+		// Rotated files are named by time
+		time.Sleep(time.Millisecond)
+	}
+	backups, err := filepath.Glob(c.Path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(backups), 2; got != exp {
+		t.Fatalf("unexpected number of rotated files got %d exp %d: %v", got, exp, backups)
+	}
+	sort.Strings(backups)
+	for _, path := range append(backups, c.Path) {
+		if got, exp := readFile(t, path), "id,level\na,CRITICAL\na,CRITICAL\n"; got != exp {
+			t.Errorf("unexpected content of %s got %q exp %q", path, got, exp)
+		}
+	}
+}
+
+func TestFileHandler_ConcurrentAppends(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultFileHandlerConfig()
+	c.Path = filepath.Join(dir, "alerts.json")
+	c.MaxSize = 4096
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		// Handlers of the same file do not interleave their lines.
+		h, err := NewFileHandler(c, errorDiagnostic{t: t})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				h.Handle(fileTestEvent("a", alert.Warning, float64(j)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	paths, err := filepath.Glob(c.Path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, path := range paths {
+		for _, line := range strings.Split(strings.TrimSuffix(readFile(t, path), "\n"), "\n") {
+			if !strings.HasPrefix(line, `{"id":"a"`) || !strings.HasSuffix(line, "}") {
+				t.Fatalf("unexpected line in %s: %q", path, line)
+			}
+			count++
+		}
+	}
+	if got, exp := count, 200; got != exp {
+		t.Errorf("unexpected number of lines got %d exp %d", got, exp)
+	}
+}
+
+func TestFileHandlerConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		c    FileHandlerConfig
+		err  string
+	}{
+		{
+			name: "relative path",
+			c:    FileHandlerConfig{Path: "alerts", Mode: 0600, Format: FileFormatJSON},
+			err:  "file path must be absolute: alerts is not absolute",
+		},
+		{
+			name: "csv without fields",
+			c:    FileHandlerConfig{Path: "/alerts", Mode: 0600, Format: FileFormatCSV},
+			err:  "csv format requires fields",
+		},
+		{
+			name: "invalid format",
+			c:    FileHandlerConfig{Path: "/alerts", Mode: 0600, Format: "xml"},
+			err:  `invalid file format "xml", must be one of json or csv`,
+		},
+		{
+			name: "invalid field",
+			c:    FileHandlerConfig{Path: "/alerts", Mode: 0600, Format: FileFormatJSON, Fields: []string{"host"}},
+			err:  `invalid field "host"`,
+		},
+		{
+			name: "json header",
+			c:    FileHandlerConfig{Path: "/alerts", Mode: 0600, Format: FileFormatJSON, Header: true},
+			err:  "header is only supported by the csv format",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.c.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tc.err {
+				t.Errorf("unexpected error got %q exp %q", got, tc.err)
+			}
+		})
+	}
+}
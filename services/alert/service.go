@@ -35,6 +35,7 @@ import (
 	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -48,6 +49,7 @@ type Diagnostic interface {
 	FoundNewHandler(key string)
 	CreatingNewHandlers(length int)
 	MigratingOldHandlerSpec(id string)
+	ReplayedJournal(count int)
 
 	Error(msg string, err error, ctx ...keyvalue.T)
 }
@@ -59,6 +61,14 @@ type Service struct {
 	topicsDAO     TopicStateDAO
 	PersistTopics bool
 
+	// Journal, if set, records alert events until they have been delivered to all handlers.
+	Journal *alert.Journal
+
+	// HandlerConcurrency is the number of events each handler of a topic handles at the same time,
+	// TopicHandlerConcurrency overrides it for the topics by ID.
+	HandlerConcurrency      int
+	TopicHandlerConcurrency map[string]int
+
 	APIServer *apiServer
 
 	handlers map[string]map[string]handler
@@ -70,6 +80,15 @@ type Service struct {
 	topics         *alert.Topics
 	EventCollector EventCollector
 
+	silenceMu   sync.RWMutex
+	silencesDAO SilenceDAO
+	acksDAO     AcknowledgementDAO
+	silences    map[string]Silence
+	acks        map[string]Acknowledgement
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+
 	HTTPDService interface {
 		AddRoutes([]httpd.Route) error
 		DelRoutes([]httpd.Route)
@@ -149,6 +168,9 @@ type Service struct {
 	ServiceNowService interface {
 		Handler(servicenow.HandlerConfig, ...keyvalue.T) alert.Handler
 	}
+	ZabbixService interface {
+		Handler(zabbix.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
+	}
 	ZenossService interface {
 		Handler(zenoss.HandlerConfig, ...keyvalue.T) alert.Handler
 	}
@@ -167,6 +189,7 @@ func NewService(d Diagnostic, disabled map[string]struct{}, topicBufLen int) *Se
 		Registrar: s,
 		Topics:    s,
 		Persister: s,
+		Silences:  s,
 		diag:      d,
 	}
 	s.EventCollector = s
@@ -201,6 +224,15 @@ func (s *Service) Open() error {
 	s.topicsDAO = topicsDAO
 	s.StorageService.Register(topicStatesAPIName, s.topicsDAO)
 
+	s.topics.SetHandlerConcurrency(s.HandlerConcurrency, s.TopicHandlerConcurrency)
+
+	if s.Journal != nil {
+		if err := s.Journal.Open(); err != nil {
+			return errors.Wrap(err, "failed to open alert journal")
+		}
+		s.topics.SetJournal(s.Journal)
+	}
+
 	// Migrate v1.2 handlers
 	if err := s.migrateHandlerSpecs(store); err != nil {
 		return err
@@ -216,6 +248,11 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	// Load saved silences and acknowledgements
+	if err := s.openSilences(); err != nil {
+		return err
+	}
+
 	s.APIServer.HTTPDService = s.HTTPDService
 	if err := s.APIServer.Open(); err != nil {
 		return err
@@ -227,10 +264,24 @@ func (s *Service) Open() error {
 func (s *Service) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.closeSilences()
 	s.topics.Close()
+	if s.Journal != nil {
+		s.Journal.Close()
+	}
 	return s.APIServer.Close()
 }
 
+// ReplayJournal redelivers the alert events that were not delivered before the last shutdown.
+// It should be called once the tasks and handlers have been loaded.
+func (s *Service) ReplayJournal() error {
+	n, err := s.topics.Replay()
+	if n > 0 {
+		s.diag.ReplayedJournal(n)
+	}
+	return err
+}
+
 const (
 	handlerSpecsStoreVersion  = "alert_topic_handler_specs"
 	handlerSpecsStoreVersion1 = "1"
@@ -386,12 +437,13 @@ func (s *Service) convertEventStatesToAlert(states map[string]EventState) map[st
 }
 func (s *Service) convertEventStateToAlert(id string, state EventState) alert.EventState {
 	return alert.EventState{
-		ID:       id,
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+		ID:          id,
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    state.Duration,
+		Level:       state.Level,
+		Fingerprint: state.Fingerprint,
 	}
 }
 
@@ -405,11 +457,12 @@ func (s *Service) convertEventStatesFromAlert(states map[string]alert.EventState
 
 func (s *Service) convertEventStateFromAlert(state alert.EventState) EventState {
 	return EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    state.Duration,
+		Level:       state.Level,
+		Fingerprint: state.Fingerprint,
 	}
 }
 
@@ -539,6 +592,9 @@ func (s *Service) DeleteTopic(topic string) error {
 	defer s.mu.Unlock()
 	delete(s.closedTopics, topic)
 	s.topics.DeleteTopic(topic)
+	if err := s.deleteTopicAcknowledgements(topic); err != nil {
+		return err
+	}
 	return s.topicsDAO.Delete(topic)
 }
 
@@ -837,6 +893,18 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 		handlerDiag := s.diag.WithHandlerContext(ctx...)
 		h = NewExecHandler(c, handlerDiag)
 		h = newExternalHandler(h)
+	case "file":
+		c := DefaultFileHandlerConfig()
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return handler{}, err
+		}
+		handlerDiag := s.diag.WithHandlerContext(ctx...)
+		h, err = NewFileHandler(c, handlerDiag)
+		if err != nil {
+			return handler{}, err
+		}
+		h = newExternalHandler(h)
 	case "hipchat":
 		c := hipchat.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
@@ -1025,6 +1093,17 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 		}
 		h = s.VictorOpsService.Handler(c, ctx...)
 		h = newExternalHandler(h)
+	case "zabbix":
+		c := zabbix.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return handler{}, err
+		}
+		h, err = s.ZabbixService.Handler(c, ctx...)
+		if err != nil {
+			return handler{}, err
+		}
+		h = newExternalHandler(h)
 	case "zenoss":
 		c := zenoss.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
@@ -1039,6 +1118,10 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 	if h == nil && err != nil {
 		return handler{}, err
 	}
+	if h != nil {
+		// Append the runbook to the message for handlers without native link support.
+		h = alert.WithRunbook(h)
+	}
 	if spec.Match != "" {
 		// Wrap handler in match handler
 		handlerDiag := s.diag.WithHandlerContext(ctx...)
@@ -1051,6 +1134,17 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 			return handler{Spec: spec, Handler: h}, err2
 		}
 	}
+	if spec.MinLevel != "" {
+		// Wrap handler in level handler
+		if h == nil {
+			panic("handler is nil, this should not happen")
+		}
+		minLevel, err2 := alert.ParseLevel(spec.MinLevel)
+		if err2 != nil {
+			return handler{Spec: spec, Handler: h}, errors.Wrap(err2, "invalid min-level")
+		}
+		h = newLevelHandler(minLevel, h)
+	}
 	return handler{Spec: spec, Handler: h}, err
 }
 
@@ -0,0 +1,295 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Public name of the silences store.
+	silencesAPIName = "silences"
+	// Public name of the acknowledgements store.
+	acknowledgementsAPIName = "acknowledgements"
+
+	// How often expired silences are removed from the store.
+	silenceExpiryInterval = time.Minute
+)
+
+// openSilences creates the silence and acknowledgement DAOs and loads their saved state.
+// Caller must have the write lock.
+func (s *Service) openSilences() error {
+	store := s.StorageService.Store(alertNamespace)
+	silencesDAO, err := newSilenceKV(store)
+	if err != nil {
+		return err
+	}
+	s.silencesDAO = silencesDAO
+	s.StorageService.Register(silencesAPIName, s.silencesDAO)
+
+	acksDAO, err := newAcknowledgementKV(store)
+	if err != nil {
+		return err
+	}
+	s.acksDAO = acksDAO
+	s.StorageService.Register(acknowledgementsAPIName, s.acksDAO)
+
+	if err := s.loadSavedSilences(); err != nil {
+		return err
+	}
+	if err := s.loadSavedAcknowledgements(); err != nil {
+		return err
+	}
+	s.topics.SetSilencer(s)
+
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go s.expireSilences()
+	return nil
+}
+
+func (s *Service) closeSilences() {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+		s.closing = nil
+	}
+}
+
+func (s *Service) loadSavedSilences() error {
+	offset := 0
+	limit := 100
+	s.silences = make(map[string]Silence)
+	for {
+		silences, err := s.silencesDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+		for _, silence := range silences {
+			s.silences[silence.ID] = silence
+		}
+		offset += limit
+		if len(silences) != limit {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadSavedAcknowledgements() error {
+	offset := 0
+	limit := 100
+	s.acks = make(map[string]Acknowledgement)
+	for {
+		acks, err := s.acksDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+		for _, ack := range acks {
+			s.acks[ack.ObjectID()] = ack
+		}
+		offset += limit
+		if len(acks) != limit {
+			break
+		}
+	}
+	return nil
+}
+
+// expireSilences periodically deletes silences that have ended.
+func (s *Service) expireSilences() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(silenceExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case now := <-ticker.C:
+			s.deleteExpiredSilences(now)
+		}
+	}
+}
+
+func (s *Service) deleteExpiredSilences(now time.Time) {
+	var expired []string
+	s.silenceMu.RLock()
+	for id, silence := range s.silences {
+		if silence.Expired(now) {
+			expired = append(expired, id)
+		}
+	}
+	s.silenceMu.RUnlock()
+	for _, id := range expired {
+		if err := s.DeleteSilence(id); err != nil {
+			s.diag.Error("failed to delete expired silence", err)
+		}
+	}
+}
+
+// Silenced reports whether the event matches an active silence
+// or has been acknowledged at its current level.
+// Acknowledgements are removed once the event changes level.
+func (s *Service) Silenced(event alert.Event) bool {
+	now := time.Now()
+	id := fullID(event.Topic, event.State.ID)
+
+	s.silenceMu.RLock()
+	ack, acked := s.acks[id]
+	if acked && ack.Level == event.State.Level {
+		s.silenceMu.RUnlock()
+		return true
+	}
+	silenced := false
+	for _, silence := range s.silences {
+		if silence.Active(now) && silence.Matches(event.Topic, event.Data.Tags) {
+			silenced = true
+			break
+		}
+	}
+	s.silenceMu.RUnlock()
+
+	if acked {
+		// The event changed level since it was acknowledged.
+		if err := s.deleteStaleAcknowledgement(event.Topic, event.State.ID, event.State.Level); err != nil {
+			s.diag.Error("failed to delete stale acknowledgement", err)
+		}
+	}
+	return silenced
+}
+
+// deleteStaleAcknowledgement deletes the acknowledgement if it is not for the given level.
+func (s *Service) deleteStaleAcknowledgement(topic, event string, level alert.Level) error {
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	id := fullID(topic, event)
+	if ack, ok := s.acks[id]; !ok || ack.Level == level {
+		return nil
+	}
+	if err := s.acksDAO.Delete(topic, event); err != nil {
+		return err
+	}
+	delete(s.acks, id)
+	return nil
+}
+
+// deleteTopicAcknowledgements removes all acknowledgements of events in the topic.
+func (s *Service) deleteTopicAcknowledgements(topic string) error {
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	for id, ack := range s.acks {
+		if ack.Topic != topic {
+			continue
+		}
+		if err := s.acksDAO.Delete(ack.Topic, ack.Event); err != nil {
+			return err
+		}
+		delete(s.acks, id)
+	}
+	return nil
+}
+
+// CreateSilence saves a new silence, generating an ID if one is not set.
+func (s *Service) CreateSilence(silence Silence) (Silence, error) {
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+	if err := silence.Validate(); err != nil {
+		return Silence{}, err
+	}
+
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	if err := s.silencesDAO.Create(silence); err != nil {
+		return Silence{}, err
+	}
+	s.silences[silence.ID] = silence
+	return silence, nil
+}
+
+// Silence returns the silence with the given ID.
+func (s *Service) Silence(id string) (Silence, bool) {
+	s.silenceMu.RLock()
+	defer s.silenceMu.RUnlock()
+	silence, ok := s.silences[id]
+	return silence, ok
+}
+
+// Silences returns all silences that have not expired, sorted by ID.
+func (s *Service) Silences() []Silence {
+	now := time.Now()
+	s.silenceMu.RLock()
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		if !silence.Expired(now) {
+			silences = append(silences, silence)
+		}
+	}
+	s.silenceMu.RUnlock()
+	sort.Slice(silences, func(i, j int) bool { return silences[i].ID < silences[j].ID })
+	return silences
+}
+
+// DeleteSilence removes a silence.
+// It is not an error to delete a non-existent silence.
+func (s *Service) DeleteSilence(id string) error {
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	if err := s.silencesDAO.Delete(id); err != nil {
+		return err
+	}
+	delete(s.silences, id)
+	return nil
+}
+
+// AcknowledgeEvent suppresses delivery of the event until it changes level.
+func (s *Service) AcknowledgeEvent(topic, event, comment string) (Acknowledgement, error) {
+	state, ok, err := s.EventState(topic, event)
+	if err != nil {
+		return Acknowledgement{}, err
+	}
+	if !ok {
+		return Acknowledgement{}, fmt.Errorf("unknown event %q in topic %q", event, topic)
+	}
+	if state.Level == alert.OK {
+		return Acknowledgement{}, errors.New("cannot acknowledge an event in the OK state")
+	}
+	ack := Acknowledgement{
+		Topic:   topic,
+		Event:   event,
+		Level:   state.Level,
+		Comment: comment,
+		Time:    time.Now().UTC(),
+	}
+
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	if err := s.acksDAO.Put(ack); err != nil {
+		return Acknowledgement{}, err
+	}
+	s.acks[ack.ObjectID()] = ack
+	return ack, nil
+}
+
+// Acknowledgement returns the acknowledgement of the event, if one exists.
+func (s *Service) Acknowledgement(topic, event string) (Acknowledgement, bool) {
+	s.silenceMu.RLock()
+	defer s.silenceMu.RUnlock()
+	ack, ok := s.acks[fullID(topic, event)]
+	return ack, ok
+}
+
+// UnacknowledgeEvent removes any acknowledgement of the event.
+func (s *Service) UnacknowledgeEvent(topic, event string) error {
+	s.silenceMu.Lock()
+	defer s.silenceMu.Unlock()
+	if err := s.acksDAO.Delete(topic, event); err != nil {
+		return err
+	}
+	delete(s.acks, fullID(topic, event))
+	return nil
+}
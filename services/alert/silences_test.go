@@ -0,0 +1,197 @@
+package alert_test
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	kalert "github.com/influxdata/kapacitor/alert"
+	client "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/services/alert"
+	"github.com/influxdata/kapacitor/services/diagnostic"
+	"github.com/influxdata/kapacitor/services/httpd/httpdtest"
+	"github.com/influxdata/kapacitor/services/storage/storagetest"
+)
+
+type countingHandler struct {
+	mu     sync.Mutex
+	events []kalert.Event
+}
+
+func (h *countingHandler) Handle(event kalert.Event) {
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+}
+
+func (h *countingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.events)
+}
+
+func openTestService(t *testing.T) (*alert.Service, *client.Client, func()) {
+	t.Helper()
+	ds := diagnostic.NewService(diagnostic.NewConfig(), io.Discard, io.Discard)
+	ds.Open()
+	s := alert.NewService(ds.NewAlertServiceHandler(), nil, 0)
+	s.StorageService = storagetest.New()
+	server := httpdtest.NewServer(testing.Verbose())
+	s.HTTPDService = server
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	cli, err := client.New(client.Config{URL: server.Server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, cli, func() {
+		s.Close()
+		server.Close()
+	}
+}
+
+func testEvent(topic, id string, level kalert.Level, tags map[string]string) kalert.Event {
+	return kalert.Event{
+		Topic: topic,
+		State: kalert.EventState{
+			ID:    id,
+			Level: level,
+			Time:  time.Now(),
+		},
+		Data: kalert.EventData{
+			Tags: tags,
+		},
+	}
+}
+
+// waitForCount waits for the asynchronous handler to have received exp events.
+func waitForCount(t *testing.T, h *countingHandler, exp int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.count() < exp && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// Allow for any unexpected extra events to arrive.
+	time.Sleep(10 * time.Millisecond)
+	if got := h.count(); got != exp {
+		t.Fatalf("unexpected number of handled events: got %d exp %d", got, exp)
+	}
+}
+
+func TestService_Silence(t *testing.T) {
+	s, cli, closeF := openTestService(t)
+	defer closeF()
+
+	h := new(countingHandler)
+	s.RegisterAnonHandler("test", h)
+
+	if err := s.Collect(testEvent("test", "a", kalert.Critical, map[string]string{"host": "serverA"})); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 1)
+
+	silence, err := cli.CreateSilence(client.SilenceOptions{
+		Topic:    "te*",
+		Tags:     map[string]string{"host": "serverA"},
+		Comment:  "maintenance",
+		Duration: client.Duration(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if silence.ID == "" {
+		t.Fatal("expected generated silence ID")
+	}
+
+	// Silenced events are recorded but not delivered
+	if err := s.Collect(testEvent("test", "a", kalert.Warning, map[string]string{"host": "serverA"})); err != nil {
+		t.Fatal(err)
+	}
+	// Events that do not match the silence are delivered
+	if err := s.Collect(testEvent("test", "b", kalert.Warning, map[string]string{"host": "serverB"})); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 2)
+
+	state, ok, err := s.EventState("test", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || state.Level != kalert.Warning {
+		t.Errorf("expected silenced event state to be recorded, got %v %v", ok, state.Level)
+	}
+
+	silences, err := cli.ListSilences()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(silences.Silences) != 1 || silences.Silences[0].ID != silence.ID {
+		t.Fatalf("unexpected silences %v", silences.Silences)
+	}
+
+	if err := cli.DeleteSilence(silence.Link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Silence(silence.Link); err == nil {
+		t.Error("expected error getting deleted silence")
+	}
+	if err := s.Collect(testEvent("test", "a", kalert.Warning, map[string]string{"host": "serverA"})); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 3)
+}
+
+func TestService_SilenceInvalid(t *testing.T) {
+	_, cli, closeF := openTestService(t)
+	defer closeF()
+
+	if _, err := cli.CreateSilence(client.SilenceOptions{Topic: "test"}); err == nil {
+		t.Error("expected error creating silence without a duration")
+	}
+	if _, err := cli.CreateSilence(client.SilenceOptions{ID: "bad id", Duration: client.Duration(time.Hour)}); err == nil {
+		t.Error("expected error creating silence with an invalid ID")
+	}
+}
+
+func TestService_Acknowledge(t *testing.T) {
+	s, cli, closeF := openTestService(t)
+	defer closeF()
+
+	h := new(countingHandler)
+	s.RegisterAnonHandler("test", h)
+
+	if err := s.Collect(testEvent("test", "a", kalert.Critical, nil)); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 1)
+
+	link := cli.TopicEventAckLink("test", "a")
+	ack, err := cli.AcknowledgeTopicEvent(link, client.AcknowledgeOptions{Comment: "looking into it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ack.Level != "CRITICAL" || ack.Comment != "looking into it" {
+		t.Errorf("unexpected acknowledgement %v", ack)
+	}
+
+	// Acknowledged at the same level, not delivered
+	if err := s.Collect(testEvent("test", "a", kalert.Critical, nil)); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 1)
+
+	// Level changed, acknowledgement is cleared and the event is delivered
+	if err := s.Collect(testEvent("test", "a", kalert.OK, nil)); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(t, h, 2)
+	if _, err := cli.TopicEventAcknowledgement(link); err == nil {
+		t.Error("expected acknowledgement to be cleared after level change")
+	}
+
+	if _, err := cli.AcknowledgeTopicEvent(cli.TopicEventAckLink("test", "missing"), client.AcknowledgeOptions{}); err == nil {
+		t.Error("expected error acknowledging unknown event")
+	}
+}
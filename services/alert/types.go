@@ -66,6 +66,25 @@ type TopicPersister interface {
 	RestoreTopic(topic string) error
 }
 
+// Silences is responsible for managing silences and acknowledgements of events.
+type Silences interface {
+	// CreateSilence saves a new silence, generating an ID if one is not set.
+	CreateSilence(silence Silence) (Silence, error)
+	// Silence returns the silence with the given ID.
+	Silence(id string) (Silence, bool)
+	// Silences returns all silences that have not expired.
+	Silences() []Silence
+	// DeleteSilence removes a silence.
+	DeleteSilence(id string) error
+
+	// AcknowledgeEvent suppresses delivery of the event until it changes level.
+	AcknowledgeEvent(topic, event, comment string) (Acknowledgement, error)
+	// Acknowledgement returns the acknowledgement of the event, if one exists.
+	Acknowledgement(topic, event string) (Acknowledgement, bool)
+	// UnacknowledgeEvent removes any acknowledgement of the event.
+	UnacknowledgeEvent(topic, event string) error
+}
+
 type handler struct {
 	Spec    HandlerSpec
 	Handler alert.Handler
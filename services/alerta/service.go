@@ -515,7 +515,7 @@ func (h *handler) Handle(event alert.Event) {
 
 	var severity string
 
-	switch event.State.Level {
+	switch event.State.Level.Builtin() {
 	case alert.OK:
 		severity = "ok"
 	case alert.Info:
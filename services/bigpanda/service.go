@@ -196,7 +196,7 @@ func (s *Service) preparePost(id string, message string, details string, level a
 	}
 
 	var status string
-	switch level {
+	switch level.Builtin() {
 	case alert.OK:
 		status = "ok"
 	case alert.Warning:
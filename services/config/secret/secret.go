@@ -0,0 +1,202 @@
+// Package secret resolves references to secrets stored outside of the configuration.
+//
+// Any string configuration value can reference a secret instead of containing it:
+//
+//	${file:/etc/kapacitor/secrets/slack-url}
+//	${vault:secret/data/kapacitor#slack-url}
+//
+// File references are replaced with the contents of the file, without trailing whitespace.
+// Vault references are replaced with the key of the secret read from the Vault HTTP API at the path,
+// using the VAULT_ADDR and VAULT_TOKEN environment variables.
+// Secrets stored in a KV version 2 secrets engine are read from the data path of the secret.
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultAddrEnv  = "VAULT_ADDR"
+	vaultTokenEnv = "VAULT_TOKEN"
+
+	vaultTimeout = 10 * time.Second
+)
+
+var referencePattern = regexp.MustCompile(`^\$\{(file|vault):(.+)\}$`)
+
+// Resolve replaces the secret references in all string values reachable from v with their secrets.
+// v must be a pointer.
+// Unexported fields are left unchanged.
+func Resolve(v interface{}) error {
+	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return errors.New("secrets can only be resolved through a pointer")
+	}
+	return reflectwalk.Walk(v, &walker{
+		resolver: &resolver{
+			vaultAddr:  os.Getenv(vaultAddrEnv),
+			vaultToken: os.Getenv(vaultTokenEnv),
+			client:     &http.Client{Timeout: vaultTimeout},
+		},
+	})
+}
+
+// ResolveValue returns a copy of the value with its secret references resolved.
+func ResolveValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	p := reflect.New(reflect.TypeOf(v))
+	p.Elem().Set(reflect.ValueOf(v))
+	if err := Resolve(p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
+
+type walker struct {
+	resolver *resolver
+	// Name of the current struct field, used in errors.
+	field string
+}
+
+func (w *walker) Struct(reflect.Value) error {
+	return nil
+}
+
+func (w *walker) StructField(f reflect.StructField, v reflect.Value) error {
+	if f.PkgPath != "" {
+		return reflectwalk.SkipEntry
+	}
+	w.field = f.Name
+	if toml := strings.Split(f.Tag.Get("toml"), ",")[0]; toml != "" && toml != "-" {
+		w.field = toml
+	}
+	return nil
+}
+
+func (w *walker) Primitive(v reflect.Value) error {
+	if v.Kind() != reflect.String || !v.CanSet() {
+		return nil
+	}
+	secret, ok, err := w.resolve(v.String())
+	if err != nil || !ok {
+		return err
+	}
+	v.SetString(secret)
+	return nil
+}
+
+func (w *walker) Map(reflect.Value) error {
+	return nil
+}
+
+func (w *walker) MapElem(m, k, v reflect.Value) error {
+	// Map values cannot be set in place.
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return nil
+	}
+	secret, ok, err := w.resolve(v.String())
+	if err != nil || !ok {
+		return err
+	}
+	m.SetMapIndex(k, reflect.ValueOf(secret).Convert(m.Type().Elem()))
+	return nil
+}
+
+func (w *walker) resolve(value string) (string, bool, error) {
+	matches := referencePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return "", false, nil
+	}
+	secret, err := w.resolver.resolve(matches[1], matches[2])
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to resolve secret %s for %q", value, w.field)
+	}
+	return secret, true, nil
+}
+
+type resolver struct {
+	vaultAddr  string
+	vaultToken string
+	client     *http.Client
+}
+
+func (r *resolver) resolve(kind, ref string) (string, error) {
+	switch kind {
+	case "file":
+		return r.file(ref)
+	case "vault":
+		return r.vault(ref)
+	default:
+		return "", fmt.Errorf("unknown secret kind %q", kind)
+	}
+}
+
+func (r *resolver) file(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), " \t\r\n"), nil
+}
+
+func (r *resolver) vault(ref string) (string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i <= 0 || i == len(ref)-1 {
+		return "", errors.New("vault references must be of the form path#key")
+	}
+	path, key := strings.Trim(ref[:i], "/"), ref[i+1:]
+	if r.vaultAddr == "" {
+		return "", fmt.Errorf("%s is not set", vaultAddrEnv)
+	}
+	req, err := http.NewRequest("GET", strings.TrimRight(r.vaultAddr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	if r.vaultToken != "" {
+		req.Header.Set("X-Vault-Token", r.vaultToken)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", errors.Wrap(err, "failed to decode vault response")
+	}
+	data := secret.Data
+	// KV version 2 secrets nest the data along with the metadata of the version.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %q", path, key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s key %q is not a string", path, key)
+	}
+	return s, nil
+}
@@ -0,0 +1,153 @@
+package secret_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/services/config/secret"
+)
+
+type testElement struct {
+	URL     string            `toml:"url"`
+	Token   string            `toml:"token"`
+	Headers map[string]string `toml:"headers"`
+	Plain   string            `toml:"plain"`
+}
+
+type testConfig struct {
+	Section  testElement   `toml:"section"`
+	Elements []testElement `toml:"elements"`
+	Names    []string      `toml:"names"`
+
+	unexported string
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slack-url")
+	if err := os.WriteFile(path, []byte("https://hooks.slack.com/secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, exp := r.Header.Get("X-Vault-Token"), "vault-token"; got != exp {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/kapacitor":
+			w.Write([]byte(`{"data":{"data":{"token":"kv2-token"},"metadata":{"version":1}}}`))
+		case "/v1/kv/kapacitor":
+			w.Write([]byte(`{"data":{"token":"kv1-token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	c := &testConfig{
+		Section: testElement{
+			URL:     "${file:" + path + "}",
+			Token:   "${vault:secret/data/kapacitor#token}",
+			Headers: map[string]string{"Authorization": "${vault:kv/kapacitor#token}"},
+			Plain:   "${env:HOME}",
+		},
+		Elements: []testElement{{
+			Token: "${vault:/kv/kapacitor#token}",
+		}},
+		Names:      []string{"${file:" + path + "}"},
+		unexported: "${file:" + path + "}",
+	}
+	if err := secret.Resolve(c); err != nil {
+		t.Fatal(err)
+	}
+	exp := &testConfig{
+		Section: testElement{
+			URL:     "https://hooks.slack.com/secret",
+			Token:   "kv2-token",
+			Headers: map[string]string{"Authorization": "kv1-token"},
+			Plain:   "${env:HOME}",
+		},
+		Elements: []testElement{{
+			Token: "kv1-token",
+		}},
+		Names:      []string{"https://hooks.slack.com/secret"},
+		unexported: "${file:" + path + "}",
+	}
+	if !reflect.DeepEqual(c, exp) {
+		t.Errorf("unexpected resolved config:\ngot %+v\nexp %+v", c, exp)
+	}
+}
+
+func TestResolveValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	raw := testElement{Token: "${file:" + path + "}"}
+	got, err := secret.ResolveValue(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.(testElement).Token != "first" {
+		t.Errorf("unexpected token got %q exp %q", got.(testElement).Token, "first")
+	}
+	if raw.Token != "${file:"+path+"}" {
+		t.Errorf("expected the raw value to be unchanged, got %q", raw.Token)
+	}
+
+	// Changes to the file are read when resolving again.
+	if err := os.WriteFile(path, []byte("second"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = secret.ResolveValue(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.(testElement).Token != "second" {
+		t.Errorf("unexpected token got %q exp %q", got.(testElement).Token, "second")
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"token":"kv1-token"}}`))
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+
+	testCases := []struct {
+		value string
+		err   string
+	}{
+		{
+			value: "${file:/does/not/exist}",
+			err:   `failed to resolve secret ${file:/does/not/exist} for "token"`,
+		},
+		{
+			value: "${vault:kv/kapacitor}",
+			err:   "vault references must be of the form path#key",
+		},
+		{
+			value: "${vault:kv/kapacitor#missing}",
+			err:   `vault secret kv/kapacitor has no key "missing"`,
+		},
+	}
+	for _, tc := range testCases {
+		err := secret.Resolve(&testElement{Token: tc.value})
+		if err == nil {
+			t.Errorf("expected error resolving %s", tc.value)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("unexpected error resolving %s got %q exp to contain %q", tc.value, err.Error(), tc.err)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	client "github.com/influxdata/kapacitor/client/v1"
 	"github.com/influxdata/kapacitor/services/config/override"
+	"github.com/influxdata/kapacitor/services/config/secret"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/pkg/errors"
@@ -237,10 +238,15 @@ func (s *Service) handleUpdateSection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// collect element values
+	// collect element values, resolving their secret references again so changed secret files are read
 	sectionList := make([]interface{}, len(newConfig[section]))
 	for i, s := range newConfig[section] {
-		sectionList[i] = s.Value()
+		v, err := secret.ResolveValue(s.Value())
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+			return
+		}
+		sectionList[i] = v
 	}
 
 	// Construct ConfigUpdate
@@ -527,7 +533,11 @@ func (s *Service) Config() (map[string][]interface{}, error) {
 	config := make(map[string][]interface{}, len(sections))
 	for name, sectionList := range sections {
 		for _, section := range sectionList {
-			config[name] = append(config[name], section.Value())
+			v, err := secret.ResolveValue(section.Value())
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve secrets for section %s", name)
+			}
+			config[name] = append(config[name], v)
 		}
 	}
 	return config, nil
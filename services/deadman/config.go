@@ -13,6 +13,8 @@ const (
 	DefaultThreshold = float64(0)
 	// Default deadman's switch id
 	DefaultId = "{{ .Group }}:NODE_NAME for task '{{ .TaskName }}'"
+	// Default maximum number of groups tracked by a per group deadman's switch
+	DefaultMaxGroups = int64(10000)
 	// Default deadman's switch message
 	DefaultMessage = "{{ .ID }} is {{ if eq .Level \"OK\" }}alive{{ else }}dead{{ end }}: {{ index .Fields \"emitted\" | printf \"%0.3f\" }} points/INTERVAL."
 )
@@ -23,6 +25,7 @@ type Config struct {
 	Id        string        `toml:"id"`
 	Message   string        `toml:"message"`
 	Global    bool          `toml:"global"`
+	MaxGroups int64         `toml:"max-groups"`
 }
 
 func NewConfig() Config {
@@ -31,5 +34,6 @@ func NewConfig() Config {
 		Threshold: DefaultThreshold,
 		Id:        DefaultId,
 		Message:   DefaultMessage,
+		MaxGroups: DefaultMaxGroups,
 	}
 }
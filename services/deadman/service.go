@@ -36,6 +36,10 @@ func (s *Service) Message() string {
 	return s.c.Message
 }
 
+func (s *Service) MaxGroups() int64 {
+	return s.c.MaxGroups
+}
+
 func (s *Service) Global() bool {
 	return s.c.Global
 }
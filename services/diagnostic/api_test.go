@@ -1,12 +1,15 @@
 package diagnostic
 
 import (
+	"bufio"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 type mockSessionsStore struct {
@@ -111,3 +114,47 @@ func TestHandleSessions_level(t *testing.T) {
 	}
 
 }
+
+func TestHandleSessions_Streamed(t *testing.T) {
+	s := NewSessionService()
+	server := httptest.NewServer(http.HandlerFunc(s.handleSessions))
+	defer server.Close()
+
+	// The response headers are only sent with the first flushed log record.
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "?lvl=info%2B")
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil {
+			errs <- err
+			return
+		}
+		lines <- line
+	}()
+
+	// Log until the record is received, each record is flushed to the client as it is logged.
+	timeout := time.After(5 * time.Second)
+	for {
+		s.SessionsStore.Each(func(session *Session) {
+			session.Info("streamed", nil, nil)
+		})
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, "msg=streamed") {
+				t.Fatalf("unexpected log line %q", line)
+			}
+			return
+		case err := <-errs:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatal("timed out waiting for the streamed log record")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
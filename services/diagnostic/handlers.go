@@ -43,6 +43,7 @@ import (
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/udp"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/udf"
 	"github.com/influxdata/kapacitor/uuid"
@@ -178,6 +179,10 @@ func (h *AlertServiceHandler) FoundNewHandler(key string) {
 	h.L.Debug("found new handler skipping", String("handler", key))
 }
 
+func (h *AlertServiceHandler) ReplayedJournal(count int) {
+	h.L.Info("redelivered undelivered alert events from journal", Int("event_count", count))
+}
+
 func (h *AlertServiceHandler) Error(msg string, err error, ctx ...keyvalue.T) {
 	Err(h.L, msg, err, ctx)
 }
@@ -186,11 +191,15 @@ func (h *AlertServiceHandler) Error(msg string, err error, ctx ...keyvalue.T) {
 
 type KapacitorHandler struct {
 	l Logger
+	// Overrides the log level of a task or node, nil for other contexts.
+	level *LevelOverride
 }
 
 func (h *KapacitorHandler) WithTaskContext(task string) kapacitor.TaskDiagnostic {
+	level := NewLevelOverride(nil)
 	return &KapacitorHandler{
-		l: h.l.With(String("task", task)),
+		l:     WithLevelOverride(h.l.With(String("task", task)), level),
+		level: level,
 	}
 }
 
@@ -201,9 +210,20 @@ func (h *KapacitorHandler) WithTaskMasterContext(tm string) kapacitor.Diagnostic
 }
 
 func (h *KapacitorHandler) WithNodeContext(node string) kapacitor.NodeDiagnostic {
+	// Nodes of a task fall back to the level of the task.
+	level := NewLevelOverride(h.level)
 	return &KapacitorHandler{
-		l: h.l.With(String("node", node)),
+		l:     WithLevelOverride(h.l.With(String("node", node)), level),
+		level: level,
+	}
+}
+
+// SetLogLevel overrides the log level of the task or node, an empty level removes the override.
+func (h *KapacitorHandler) SetLogLevel(level string) error {
+	if h.level == nil {
+		return errors.New("log level can only be set for a task or node")
 	}
+	return h.level.SetLevel(level)
 }
 
 func (h *KapacitorHandler) WithEdgeContext(task, parent, child string) kapacitor.EdgeDiagnostic {
@@ -236,6 +256,19 @@ func (h *KapacitorHandler) StoppedTaskWithError(task string, err error) {
 	h.l.Error("failed to stop task with out error", String("task", task), Error(err))
 }
 
+func (h *KapacitorHandler) QuotaExceeded(quota string, value, limit int64, enforcement string) {
+	h.l.Error("task quota exceeded",
+		String("quota", quota),
+		Int64("value", value),
+		Int64("limit", limit),
+		String("enforcement", enforcement),
+	)
+}
+
+func (h *KapacitorHandler) QuotaRestored(quota string) {
+	h.l.Info("task quota restored", String("quota", quota))
+}
+
 func (h *KapacitorHandler) TaskMasterDot(d string) {
 	h.l.Debug("listing dot", String("dot", d))
 }
@@ -269,6 +302,10 @@ func (h *KapacitorHandler) StartingBatchQuery(q string) {
 	h.l.Debug("starting next batch query", String("query", q))
 }
 
+func (h *KapacitorHandler) CanceledBatchQuery(q string) {
+	h.l.Info("canceled batch query", String("query", q))
+}
+
 func TagPairs(tags models.Tags) []Field {
 	ts := []Field{}
 	for k, v := range tags {
@@ -300,6 +337,17 @@ func FieldPairs(tags models.Fields) []Field {
 	return ts
 }
 
+func (h *KapacitorHandler) logFunc(level string) func(string, ...Field) {
+	switch level {
+	case "ERROR":
+		return h.l.Error
+	case "DEBUG":
+		return h.l.Debug
+	default:
+		return h.l.Info
+	}
+}
+
 func (h *KapacitorHandler) LogPointData(level, prefix string, point edge.PointMessage) {
 	fields := []Field{
 		String("prefix", prefix),
@@ -313,35 +361,12 @@ func (h *KapacitorHandler) LogPointData(level, prefix string, point edge.PointMe
 		Time("time", point.Time()),
 	}
 
-	var log func(string, ...Field)
-
-	switch level {
-	case "INFO":
-		log = h.l.Info
-	case "ERROR":
-		log = h.l.Error
-	case "DEBUG":
-		log = h.l.Debug
-	default:
-		log = h.l.Info
-	}
-
+	log := h.logFunc(level)
 	log("point", fields...)
 }
 
 func (h *KapacitorHandler) LogBatchData(level, prefix string, batch edge.BufferedBatchMessage) {
-	var log func(string, ...Field)
-
-	switch level {
-	case "INFO":
-		log = h.l.Info
-	case "ERROR":
-		log = h.l.Error
-	case "DEBUG":
-		log = h.l.Debug
-	default:
-		log = h.l.Info
-	}
+	log := h.logFunc(level)
 
 	begin := batch.Begin()
 	log("begin batch",
@@ -372,6 +397,13 @@ func (h *KapacitorHandler) LogBatchData(level, prefix string, batch edge.Buffere
 	)
 }
 
+func (h *KapacitorHandler) LogJSONData(level, prefix string, data []byte) {
+	h.logFunc(level)("data",
+		String("prefix", prefix),
+		String("json", string(data)),
+	)
+}
+
 func (h *KapacitorHandler) UDFLog(s string) {
 	h.l.Info("UDF log", String("text", s))
 }
@@ -482,6 +514,8 @@ func (h *HTTPDHandler) HTTP(
 	uri string,
 	proto string,
 	status int,
+	size int,
+	contentType string,
 	referer string,
 	userAgent string,
 	reqID string,
@@ -495,6 +529,8 @@ func (h *HTTPDHandler) HTTP(
 		String("uri", uri),
 		String("protocol", proto),
 		Int("status", status),
+		Int("size", size),
+		String("content-type", contentType),
 		String("referer", referer),
 		String("user-agent", userAgent),
 		String("request-id", reqID),
@@ -581,6 +617,10 @@ func (h *PagerDuty2Handler) Error(msg string, err error) {
 	h.l.Error(msg, Error(err))
 }
 
+func (h *PagerDuty2Handler) Info(msg string, ctx ...keyvalue.T) {
+	Info(h.l, msg, ctx)
+}
+
 // Slack Handler
 
 type SlackHandler struct {
@@ -822,6 +862,10 @@ func (h *HTTPPostHandler) Error(msg string, err error, ctx ...keyvalue.T) {
 	h.l.Error(msg, fields...)
 }
 
+func (h *HTTPPostHandler) Info(msg string, ctx ...keyvalue.T) {
+	Info(h.l, msg, ctx)
+}
+
 func (h *HTTPPostHandler) WithContext(ctx ...keyvalue.T) httppost.Diagnostic {
 	fields := logFieldsFromContext(ctx)
 
@@ -968,6 +1012,10 @@ func (h *ReplayHandler) Error(msg string, err error, ctx ...keyvalue.T) {
 	Err(h.l, msg, err, ctx)
 }
 
+func (h *ReplayHandler) Info(msg string, ctx ...keyvalue.T) {
+	Info(h.l, msg, ctx)
+}
+
 func (h *ReplayHandler) Debug(msg string, ctx ...keyvalue.T) {
 	Debug(h.l, msg, ctx)
 }
@@ -1070,10 +1118,38 @@ func (h *UDPHandler) StartedListening(addr string) {
 	h.l.Info("started listening on UDP", String("address", addr))
 }
 
+func (h *UDPHandler) BufferHighWaterMark(queued, size int, policy string) {
+	// There is no warn level, warnings are logged at info with a severity.
+	h.l.Info("UDP buffer is close to full, packets are lost once it is full",
+		String("severity", "warn"),
+		Int("queued", queued),
+		Int("buffer", size),
+		String("overflow_policy", policy),
+	)
+}
+
 func (h *UDPHandler) ClosedService() {
 	h.l.Info("closed service")
 }
 
+// gRPC handler
+
+type GRPCHandler struct {
+	l Logger
+}
+
+func (h *GRPCHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+func (h *GRPCHandler) StartedListening(addr string) {
+	h.l.Info("started listening on gRPC", String("address", addr))
+}
+
+func (h *GRPCHandler) ClosedService() {
+	h.l.Info("closed service")
+}
+
 // InfluxDB handler
 
 type InfluxDBHandler struct {
@@ -1108,6 +1184,14 @@ func (h *InfluxDBHandler) LinkingSubscriptions(cluster string) {
 	h.l.Debug("linking subscription for cluster", String("cluster", cluster))
 }
 
+func (h *InfluxDBHandler) CreatedSubscription(cluster, db, rp, name string) {
+	h.l.Info("created subscription", String("cluster", cluster), String("dbrp", fmt.Sprintf("%s.%s", db, rp)), String("name", name))
+}
+
+func (h *InfluxDBHandler) DroppedSubscription(cluster, db, rp, name string) {
+	h.l.Info("dropped subscription", String("cluster", cluster), String("dbrp", fmt.Sprintf("%s.%s", db, rp)), String("name", name))
+}
+
 func (h *InfluxDBHandler) StartedUDPListener(db string, rp string) {
 	h.l.Info("started UDP listener", String("dbrp", fmt.Sprintf("%s.%s", db, rp)))
 }
@@ -1362,6 +1446,10 @@ func (h *LoadHandler) Loading(el string, file string) {
 	h.l.Debug("loading object from file", String("object", el), String("file", file))
 }
 
+func (h *LoadHandler) Skipping(el string, file string, reason string) {
+	h.l.Info("skipping object from file", String("object", el), String("file", file), String("reason", reason))
+}
+
 // Session handler
 
 type SessionHandler struct {
@@ -1452,3 +1540,24 @@ func (h *ZenossHandler) WithContext(ctx ...keyvalue.T) zenoss.Diagnostic {
 func (h *ZenossHandler) Error(msg string, err error) {
 	h.l.Error(msg, Error(err))
 }
+
+// Zabbix handler
+type ZabbixHandler struct {
+	l Logger
+}
+
+func (h *ZabbixHandler) WithContext(ctx ...keyvalue.T) zabbix.Diagnostic {
+	fields := logFieldsFromContext(ctx)
+
+	return &ZabbixHandler{
+		l: h.l.With(fields...),
+	}
+}
+
+func (h *ZabbixHandler) TemplateError(err error, kv keyvalue.T) {
+	h.l.Error("failed to evaluate Zabbix template", Error(err), String(kv.Key, kv.Value))
+}
+
+func (h *ZabbixHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -88,10 +90,81 @@ func (l *MultiLogger) With(ctx ...Field) Logger {
 	return NewMultiLogger(loggers...)
 }
 
+func (l *MultiLogger) WithLevelOverride(o *LevelOverride) Logger {
+	loggers := []Logger{}
+	for _, logger := range l.loggers {
+		if ol, ok := logger.(levelOverrider); ok {
+			logger = ol.WithLevelOverride(o)
+		}
+		loggers = append(loggers, logger)
+	}
+
+	return NewMultiLogger(loggers...)
+}
+
 func defaultLevelF(lvl Level) bool {
 	return true
 }
 
+// levelOverrider is a Logger whose level can be overridden.
+type levelOverrider interface {
+	WithLevelOverride(o *LevelOverride) Logger
+}
+
+// WithLevelOverride returns a logger whose level is overridden by o, if l supports level overrides.
+func WithLevelOverride(l Logger, o *LevelOverride) Logger {
+	if ol, ok := l.(levelOverrider); ok {
+		return ol.WithLevelOverride(o)
+	}
+	return l
+}
+
+const noLevelOverride = -1
+
+// LevelOverride overrides the level of loggers, i.e. the level of a single task.
+// Without a level set the level of the parent override applies,
+// and without any level set the level of the logger applies.
+type LevelOverride struct {
+	parent *LevelOverride
+	level  int32
+}
+
+// NewLevelOverride creates a level override without a level, falling back to the parent, which may be nil.
+func NewLevelOverride(parent *LevelOverride) *LevelOverride {
+	return &LevelOverride{
+		parent: parent,
+		level:  noLevelOverride,
+	}
+}
+
+// SetLevel sets the level of the override from its name, an empty name removes the level.
+func (o *LevelOverride) SetLevel(lvl string) error {
+	level := int32(noLevelOverride)
+	switch strings.ToUpper(lvl) {
+	case "":
+	case "DEBUG":
+		level = int32(DebugLevel)
+	case "INFO":
+		level = int32(InfoLevel)
+	case "ERROR":
+		level = int32(ErrorLevel)
+	default:
+		return fmt.Errorf("invalid log level %q", lvl)
+	}
+	atomic.StoreInt32(&o.level, level)
+	return nil
+}
+
+// Level returns the level of the override and whether one is set.
+func (o *LevelOverride) Level() (Level, bool) {
+	for ; o != nil; o = o.parent {
+		if level := atomic.LoadInt32(&o.level); level != noLevelOverride {
+			return Level(level), true
+		}
+	}
+	return 0, false
+}
+
 type ServerLogger struct {
 	mu      *sync.Mutex
 	context []Field
@@ -99,6 +172,8 @@ type ServerLogger struct {
 
 	levelMu sync.RWMutex
 	levelF  func(lvl Level) bool
+
+	override *LevelOverride
 }
 
 func NewServerLogger(w io.Writer) *ServerLogger {
@@ -123,36 +198,50 @@ func (l *ServerLogger) With(ctx ...Field) Logger {
 	newCtx := make([]Field, len(l.context))
 	copy(newCtx, l.context)
 	return &ServerLogger{
-		mu:      l.mu,
-		context: append(newCtx, ctx...),
-		w:       l.w,
-		levelF:  l.levelF,
+		mu:       l.mu,
+		context:  append(newCtx, ctx...),
+		w:        l.w,
+		levelF:   l.levelF,
+		override: l.override,
 	}
 }
 
-func (l *ServerLogger) Error(msg string, ctx ...Field) {
+// WithLevelOverride returns a copy of the logger whose level is overridden by o.
+func (l *ServerLogger) WithLevelOverride(o *LevelOverride) Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return &ServerLogger{
+		mu:       l.mu,
+		context:  l.context,
+		w:        l.w,
+		levelF:   l.levelF,
+		override: o,
+	}
+}
+
+func (l *ServerLogger) enabled(lvl Level) bool {
+	if level, ok := l.override.Level(); ok {
+		return lvl >= level
+	}
 	l.levelMu.RLock()
-	logLine := l.levelF(ErrorLevel)
-	l.levelMu.RUnlock()
-	if logLine {
+	defer l.levelMu.RUnlock()
+	return l.levelF(lvl)
+}
+
+func (l *ServerLogger) Error(msg string, ctx ...Field) {
+	if l.enabled(ErrorLevel) {
 		l.Log(time.Now(), "error", msg, ctx)
 	}
 }
 
 func (l *ServerLogger) Debug(msg string, ctx ...Field) {
-	l.levelMu.RLock()
-	logLine := l.levelF(DebugLevel)
-	l.levelMu.RUnlock()
-	if logLine {
+	if l.enabled(DebugLevel) {
 		l.Log(time.Now(), "debug", msg, ctx)
 	}
 }
 
 func (l *ServerLogger) Info(msg string, ctx ...Field) {
-	l.levelMu.RLock()
-	logLine := l.levelF(InfoLevel)
-	l.levelMu.RUnlock()
-	if logLine {
+	if l.enabled(InfoLevel) {
 		l.Log(time.Now(), "info", msg, ctx)
 	}
 }
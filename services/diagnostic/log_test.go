@@ -382,6 +382,85 @@ func TestLogger_SetLeveF(t *testing.T) {
 	}
 }
 
+func TestLogger_WithLevelOverride(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := diagnostic.NewServerLogger(buf)
+	l.SetLevelF(func(lvl diagnostic.Level) bool {
+		return lvl >= diagnostic.ErrorLevel
+	})
+	msg := "the message"
+
+	task := diagnostic.NewLevelOverride(nil)
+	node := diagnostic.NewLevelOverride(task)
+	tl := diagnostic.WithLevelOverride(l, task)
+	nl := diagnostic.WithLevelOverride(l.With(diagnostic.String("node", "log2")), node)
+
+	tests := []struct {
+		name      string
+		taskLevel string
+		nodeLevel string
+		logger    diagnostic.Logger
+		exp       map[string]bool
+	}{
+		{
+			name:   "no override",
+			logger: tl,
+			exp:    map[string]bool{"debug": false, "info": false, "error": true},
+		},
+		{
+			name:      "task override",
+			taskLevel: "debug",
+			logger:    tl,
+			exp:       map[string]bool{"debug": true, "info": true, "error": true},
+		},
+		{
+			name:      "node falls back to task",
+			taskLevel: "info",
+			logger:    nl,
+			exp:       map[string]bool{"debug": false, "info": true, "error": true},
+		},
+		{
+			name:      "node override",
+			taskLevel: "info",
+			nodeLevel: "DEBUG",
+			logger:    nl,
+			exp:       map[string]bool{"debug": true, "info": true, "error": true},
+		},
+		{
+			name:      "node override below task",
+			taskLevel: "debug",
+			nodeLevel: "error",
+			logger:    nl,
+			exp:       map[string]bool{"debug": false, "info": false, "error": true},
+		},
+	}
+
+	for _, tt := range tests {
+		if err := task.SetLevel(tt.taskLevel); err != nil {
+			t.Fatal(err)
+		}
+		if err := node.SetLevel(tt.nodeLevel); err != nil {
+			t.Fatal(err)
+		}
+		logs := map[string]func(msg string, ctx ...diagnostic.Field){
+			"debug": tt.logger.Debug,
+			"info":  tt.logger.Info,
+			"error": tt.logger.Error,
+		}
+		for lvl, log := range logs {
+			buf.Reset()
+			log(msg)
+			if got := buf.Len() > 0; got != tt.exp[lvl] {
+				t.Errorf("%s: unexpected %s log got %v exp %v", tt.name, lvl, got, tt.exp[lvl])
+			}
+		}
+	}
+
+	if err := task.SetLevel("trace"); err == nil {
+		t.Error("expected error setting invalid level")
+	}
+}
+
 func TestSessionsLoggerWithoutContext(t *testing.T) {
 	now := time.Now()
 	nowStr := now.Format(diagnostic.RFC3339Milli)
@@ -363,6 +363,12 @@ func (s *Service) NewUDPHandler() *UDPHandler {
 	}
 }
 
+func (s *Service) NewGRPCHandler() *GRPCHandler {
+	return &GRPCHandler{
+		l: s.Logger.With(String("service", "grpc")),
+	}
+}
+
 func (s *Service) NewInfluxDBHandler() *InfluxDBHandler {
 	return &InfluxDBHandler{
 		l: s.Logger.With(String("service", "influxdb")),
@@ -506,6 +512,12 @@ func (s *Service) NewZenossHandler() *ZenossHandler {
 	}
 }
 
+func (s *Service) NewZabbixHandler() *ZabbixHandler {
+	return &ZabbixHandler{
+		l: s.Logger.With(String("service", "zabbix")),
+	}
+}
+
 func (s *Service) NewZapLogger(level zapcore.Level) *zap.Logger {
 	return zap.New(&zapAdapter{
 		LevelEnabler: zap.LevelEnablerFunc(func(l zapcore.Level) bool {
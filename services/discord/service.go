@@ -237,7 +237,11 @@ func (s *Service) Alert(workspace, message, username, avatarURL, embedTitle stri
 	if err != nil {
 		return err
 	}
+	return s.post(workspace, url, post)
+}
 
+// post sends the prepared post to Discord.
+func (s *Service) post(workspace, url string, post io.Reader) error {
 	client, err := s.client(workspace)
 	if err != nil {
 		return err
@@ -278,6 +282,9 @@ type HandlerConfig struct {
 	// Embed title
 	// If empty uses the default config
 	EmbedTitle string `mapstructure:"embed-title"`
+	// Maximum size in bytes of the posts, larger posts have their message truncated
+	// Zero means no limit
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 func (s *Service) preparePost(workspace, message, username, avatarURL, embedTitle string, timeVal time.Time, level alert.Level) (string, io.Reader, error) {
@@ -289,7 +296,7 @@ func (s *Service) preparePost(workspace, message, username, avatarURL, embedTitl
 		return "", nil, errors.New("service is not enabled")
 	}
 	var color int
-	switch level {
+	switch level.Builtin() {
 	case alert.Critical:
 		color = 0xF95F53 // #F95F53
 	case alert.Warning:
@@ -329,9 +336,10 @@ func (s *Service) preparePost(workspace, message, username, avatarURL, embedTitl
 }
 
 type handler struct {
-	s    *Service
-	c    HandlerConfig
-	diag Diagnostic
+	s     *Service
+	c     HandlerConfig
+	limit *alert.PayloadLimit
+	diag  Diagnostic
 
 	embedTitleTmpl *text.Template
 }
@@ -344,6 +352,7 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, er
 	return &handler{
 		s:              s,
 		c:              c,
+		limit:          alert.NewPayloadLimit("discord", c.MaxPayloadSize),
 		diag:           s.diag.WithContext(ctx...),
 		embedTitleTmpl: ettmpl,
 	}, nil
@@ -357,15 +366,27 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.TemplateError(err, keyvalue.KV("embedTitle", h.c.EmbedTitle))
 		return
 	}
-	if err := h.s.Alert(
-		h.c.Workspace,
-		event.State.Message,
-		h.c.Username,
-		h.c.AvatarURL,
-		buf.String(), // Parsed embedtitle template
-		event.State.Time,
-		event.State.Level,
-	); err != nil {
+	var url string
+	_, body, err := h.limit.Fit(event, func(e alert.Event) ([]byte, error) {
+		u, post, err := h.s.preparePost(
+			h.c.Workspace,
+			e.State.Message,
+			h.c.Username,
+			h.c.AvatarURL,
+			buf.String(), // Parsed embedtitle template
+			e.State.Time,
+			e.State.Level,
+		)
+		if err != nil {
+			return nil, err
+		}
+		url = u
+		return io.ReadAll(post)
+	})
+	if err == nil {
+		err = h.s.post(h.c.Workspace, url, bytes.NewReader(body))
+	}
+	if err != nil {
 		h.diag.Error("failed to send event to Discord", err)
 	}
 }
@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	Enabled     bool   `toml:"enabled"`
+	BindAddress string `toml:"bind-address"`
+	TLSEnabled  bool   `toml:"tls-enabled"`
+	Certificate string `toml:"tls-certificate"`
+	// Use a separate private key location, defaults to the certificate.
+	PrivateKey string `toml:"tls-private-key"`
+}
+
+func NewConfig() Config {
+	return Config{
+		Enabled:     false,
+		BindAddress: ":9093",
+		Certificate: "/etc/ssl/kapacitor.pem",
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(c.BindAddress)
+	if err != nil {
+		return errors.Wrapf(err, "invalid grpc bind address %s", c.BindAddress)
+	}
+	if pn, err := strconv.ParseInt(port, 10, 64); err != nil {
+		return errors.Wrapf(err, "invalid grpc bind address port %s", port)
+	} else if pn > 65535 || pn < 0 {
+		return fmt.Errorf("invalid grpc bind address port %d: out of range", pn)
+	}
+	if c.TLSEnabled && c.Certificate == "" {
+		return errors.New("must specify tls-certificate when tls is enabled")
+	}
+	return nil
+}
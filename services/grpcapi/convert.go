@@ -0,0 +1,220 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	client "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/services/grpcapi/kapacitorpb"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toClientDBRPs(dbrps []*kapacitorpb.DBRP) []client.DBRP {
+	if len(dbrps) == 0 {
+		return nil
+	}
+	cdbrps := make([]client.DBRP, len(dbrps))
+	for i, dbrp := range dbrps {
+		cdbrps[i] = client.DBRP{
+			Database:        dbrp.Db,
+			RetentionPolicy: dbrp.Rp,
+		}
+	}
+	return cdbrps
+}
+
+func fromClientDBRPs(cdbrps []client.DBRP) []*kapacitorpb.DBRP {
+	dbrps := make([]*kapacitorpb.DBRP, len(cdbrps))
+	for i, dbrp := range cdbrps {
+		dbrps[i] = &kapacitorpb.DBRP{
+			Db: dbrp.Database,
+			Rp: dbrp.RetentionPolicy,
+		}
+	}
+	return dbrps
+}
+
+func toClientVars(vars map[string]*kapacitorpb.Var) (client.Vars, error) {
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	cvars := make(client.Vars, len(vars))
+	for name, v := range vars {
+		cvar, err := toClientVar(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid var %s", name)
+		}
+		cvars[name] = cvar
+	}
+	return cvars, nil
+}
+
+func toClientVar(v *kapacitorpb.Var) (client.Var, error) {
+	cvar := client.Var{
+		Description: v.Description,
+	}
+	if err := cvar.Type.UnmarshalText([]byte(v.Type)); err != nil {
+		return cvar, err
+	}
+	switch value := v.Value.(type) {
+	case *kapacitorpb.Var_BoolValue:
+		cvar.Value = value.BoolValue
+	case *kapacitorpb.Var_IntValue:
+		cvar.Value = value.IntValue
+	case *kapacitorpb.Var_FloatValue:
+		cvar.Value = value.FloatValue
+	case *kapacitorpb.Var_StringValue:
+		cvar.Value = value.StringValue
+	case *kapacitorpb.Var_DurationValue:
+		cvar.Value = value.DurationValue.AsDuration()
+	case *kapacitorpb.Var_ListValue:
+		list := make([]client.Var, len(value.ListValue.Values))
+		for i, v := range value.ListValue.Values {
+			var err error
+			list[i], err = toClientVar(v)
+			if err != nil {
+				return cvar, err
+			}
+		}
+		cvar.Value = list
+	case nil:
+		return cvar, errors.New("missing value")
+	default:
+		return cvar, fmt.Errorf("unsupported value type %T", value)
+	}
+	return cvar, nil
+}
+
+func fromClientVars(cvars client.Vars) (map[string]*kapacitorpb.Var, error) {
+	if len(cvars) == 0 {
+		return nil, nil
+	}
+	vars := make(map[string]*kapacitorpb.Var, len(cvars))
+	for name, cvar := range cvars {
+		v, err := fromClientVar(cvar)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid var %s", name)
+		}
+		vars[name] = v
+	}
+	return vars, nil
+}
+
+func fromClientVar(cvar client.Var) (*kapacitorpb.Var, error) {
+	typ, err := cvar.Type.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	v := &kapacitorpb.Var{
+		Type:        string(typ),
+		Description: cvar.Description,
+	}
+	// The values of list elements are not converted when decoding the vars.
+	value := cvar.Value
+	if n, ok := value.(json.Number); ok {
+		switch cvar.Type {
+		case client.VarInt, client.VarDuration:
+			i, err := n.Int64()
+			if err != nil {
+				return nil, err
+			}
+			value = i
+			if cvar.Type == client.VarDuration {
+				value = time.Duration(i)
+			}
+		default:
+			value, err = n.Float64()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	switch value := value.(type) {
+	case bool:
+		v.Value = &kapacitorpb.Var_BoolValue{BoolValue: value}
+	case int64:
+		v.Value = &kapacitorpb.Var_IntValue{IntValue: value}
+	case float64:
+		v.Value = &kapacitorpb.Var_FloatValue{FloatValue: value}
+	case string:
+		v.Value = &kapacitorpb.Var_StringValue{StringValue: value}
+	case time.Duration:
+		v.Value = &kapacitorpb.Var_DurationValue{DurationValue: durationpb.New(value)}
+	case []client.Var:
+		list := &kapacitorpb.VarList{
+			Values: make([]*kapacitorpb.Var, len(value)),
+		}
+		for i, cvar := range value {
+			list.Values[i], err = fromClientVar(cvar)
+			if err != nil {
+				return nil, err
+			}
+		}
+		v.Value = &kapacitorpb.Var_ListValue{ListValue: list}
+	case nil:
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+	return v, nil
+}
+
+func fromClientTask(task client.Task) (*kapacitorpb.Task, error) {
+	vars, err := fromClientVars(task.Vars)
+	if err != nil {
+		return nil, err
+	}
+	t := &kapacitorpb.Task{
+		Id:         task.ID,
+		TemplateId: task.TemplateID,
+		Type:       kapacitorpb.TaskType(task.Type),
+		Dbrps:      fromClientDBRPs(task.DBRPs),
+		TickScript: task.TICKscript,
+		Vars:       vars,
+		Dot:        task.Dot,
+		Status:     kapacitorpb.TaskStatus(task.Status),
+		Executing:  task.Executing,
+		Error:      task.Error,
+		Created:    timestamppb.New(task.Created),
+		Modified:   timestamppb.New(task.Modified),
+	}
+	if !task.LastEnabled.IsZero() {
+		t.LastEnabled = timestamppb.New(task.LastEnabled)
+	}
+	return t, nil
+}
+
+// fromClientStats converts the numeric statistics, other statistics are omitted.
+func fromClientStats(id string, t time.Time, stats client.ExecutionStats) *kapacitorpb.TaskStats {
+	s := &kapacitorpb.TaskStats{
+		Id:        id,
+		Time:      timestamppb.New(t),
+		TaskStats: numericStats(stats.TaskStats),
+		NodeStats: make(map[string]*kapacitorpb.NodeStats, len(stats.NodeStats)),
+	}
+	for node, stats := range stats.NodeStats {
+		s.NodeStats[node] = &kapacitorpb.NodeStats{
+			Stats: numericStats(stats),
+		}
+	}
+	return s
+}
+
+func numericStats(stats map[string]interface{}) map[string]float64 {
+	values := make(map[string]float64, len(stats))
+	for name, stat := range stats {
+		switch v := stat.(type) {
+		case float64:
+			values[name] = v
+		case int64:
+			values[name] = float64(v)
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				values[name] = f
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,411 @@
+// Package grpcapi serves the task and alert operations of the HTTP API over gRPC.
+//
+// Each call is served by the HTTP API handler, so that requests are
+// authenticated, authorized and validated exactly like HTTP requests.
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	client "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/services/grpcapi/kapacitorpb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate protoc --proto_path=./kapacitorpb --go_out=./kapacitorpb --go-grpc_out=./kapacitorpb kapacitor.proto
+
+const (
+	// The URL is never dialed, requests are served by the HTTP handler directly.
+	localURL  = "http://localhost"
+	userAgent = "internal-grpc-service"
+
+	defaultStatsInterval = 10 * time.Second
+)
+
+type Diagnostic interface {
+	Error(msg string, err error)
+	StartedListening(addr string)
+	ClosedService()
+}
+
+type Service struct {
+	kapacitorpb.UnimplementedKapacitorServer
+
+	mu        sync.Mutex
+	config    Config
+	tlsConfig *tls.Config
+	listener  net.Listener
+	server    *grpc.Server
+	wg        sync.WaitGroup
+
+	// Handler serves the HTTP API with authentication enabled.
+	Handler http.Handler
+
+	diag Diagnostic
+}
+
+func NewService(c Config, t *tls.Config, d Diagnostic) *Service {
+	return &Service{
+		config:    c,
+		tlsConfig: t,
+		diag:      d,
+	}
+}
+
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Handler == nil {
+		return errors.New("must set the HTTP handler")
+	}
+
+	var opts []grpc.ServerOption
+	if s.config.TLSEnabled {
+		key := s.config.PrivateKey
+		if key == "" {
+			key = s.config.Certificate
+		}
+		cert, err := tls.LoadX509KeyPair(s.config.Certificate, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load certificate %s", s.config.Certificate)
+		}
+		tlsConfig := s.tlsConfig.Clone()
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.server = grpc.NewServer(opts...)
+	kapacitorpb.RegisterKapacitorServer(s.server, s)
+
+	s.diag.StartedListening(listener.Addr().String())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(listener); err != nil {
+			s.diag.Error("failed to serve grpc", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		// Stop instead of GracefulStop so that streaming calls are canceled.
+		s.server.Stop()
+		s.wg.Wait()
+		s.server = nil
+	}
+	s.diag.ClosedService()
+	return nil
+}
+
+// Addr returns the address on which the service is listening.
+func (s *Service) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// statusTransport records the status code of the last response,
+// so that errors can be converted to the matching gRPC code.
+type statusTransport struct {
+	rt   http.RoundTripper
+	code int
+}
+
+func (t *statusTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(r)
+	if err == nil {
+		t.code = resp.StatusCode
+	}
+	return resp, err
+}
+
+// call makes a call to the HTTP API with the credentials of the gRPC request.
+func (s *Service) call(ctx context.Context, f func(cli *client.Client) error) error {
+	creds, err := requestCredentials(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	t := &statusTransport{rt: client.NewLocalTransport(s.Handler)}
+	cli, err := client.New(client.Config{
+		URL:         localURL,
+		UserAgent:   userAgent,
+		Credentials: creds,
+		Transport:   t,
+	})
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err := f(cli); err != nil {
+		return status.Error(httpCode(t.code), err.Error())
+	}
+	return nil
+}
+
+// requestCredentials returns the credentials from the authorization metadata,
+// or nil if there are no credentials.
+func requestCredentials(ctx context.Context) (*client.Credentials, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	scheme, value := values[0], ""
+	if i := strings.IndexByte(scheme, ' '); i > 0 {
+		scheme, value = scheme[:i], strings.TrimSpace(scheme[i+1:])
+	}
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		return &client.Credentials{
+			Method: client.BearerAuthentication,
+			Token:  value,
+		}, nil
+	case "basic":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid basic authorization")
+		}
+		parts := strings.SplitN(string(data), ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("invalid basic authorization, expected user:password")
+		}
+		return &client.Credentials{
+			Method:   client.UserAuthentication,
+			Username: parts[0],
+			Password: parts[1],
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported authorization scheme %q", scheme)
+	}
+}
+
+func httpCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusInternalServerError:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+func (s *Service) CreateTask(ctx context.Context, r *kapacitorpb.CreateTaskRequest) (*kapacitorpb.Task, error) {
+	vars, err := toClientVars(r.Vars)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var task client.Task
+	err = s.call(ctx, func(cli *client.Client) (err error) {
+		task, err = cli.CreateTask(client.CreateTaskOptions{
+			ID:         r.Id,
+			TemplateID: r.TemplateId,
+			Type:       client.TaskType(r.Type),
+			DBRPs:      toClientDBRPs(r.Dbrps),
+			TICKscript: r.TickScript,
+			Status:     client.TaskStatus(r.Status),
+			Vars:       vars,
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromClientTask(task)
+}
+
+func (s *Service) UpdateTask(ctx context.Context, r *kapacitorpb.UpdateTaskRequest) (*kapacitorpb.Task, error) {
+	vars, err := toClientVars(r.Vars)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var task client.Task
+	err = s.call(ctx, func(cli *client.Client) (err error) {
+		task, err = cli.UpdateTask(cli.TaskLink(r.Id), client.UpdateTaskOptions{
+			TemplateID: r.TemplateId,
+			Type:       client.TaskType(r.Type),
+			DBRPs:      toClientDBRPs(r.Dbrps),
+			TICKscript: r.TickScript,
+			Status:     client.TaskStatus(r.Status),
+			Vars:       vars,
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromClientTask(task)
+}
+
+func (s *Service) GetTask(ctx context.Context, r *kapacitorpb.GetTaskRequest) (*kapacitorpb.Task, error) {
+	var task client.Task
+	err := s.call(ctx, func(cli *client.Client) (err error) {
+		task, err = cli.Task(cli.TaskLink(r.Id), nil)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromClientTask(task)
+}
+
+func (s *Service) ListTasks(ctx context.Context, r *kapacitorpb.ListTasksRequest) (*kapacitorpb.ListTasksResponse, error) {
+	var tasks []client.Task
+	err := s.call(ctx, func(cli *client.Client) (err error) {
+		tasks, err = cli.ListTasks(&client.ListTasksOptions{
+			Pattern: r.Pattern,
+			Offset:  int(r.Offset),
+			Limit:   int(r.Limit),
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &kapacitorpb.ListTasksResponse{
+		Tasks: make([]*kapacitorpb.Task, len(tasks)),
+	}
+	for i, task := range tasks {
+		resp.Tasks[i], err = fromClientTask(task)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *Service) DeleteTask(ctx context.Context, r *kapacitorpb.DeleteTaskRequest) (*kapacitorpb.DeleteTaskResponse, error) {
+	err := s.call(ctx, func(cli *client.Client) error {
+		return cli.DeleteTask(cli.TaskLink(r.Id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kapacitorpb.DeleteTaskResponse{}, nil
+}
+
+func (s *Service) StreamTaskStats(r *kapacitorpb.StreamTaskStatsRequest, stream kapacitorpb.Kapacitor_StreamTaskStatsServer) error {
+	interval := defaultStatsInterval
+	if r.Interval != nil {
+		interval = r.Interval.AsDuration()
+	}
+	if interval <= 0 {
+		return status.Errorf(codes.InvalidArgument, "interval must be positive, got %v", interval)
+	}
+	ctx := stream.Context()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var task client.Task
+		err := s.call(ctx, func(cli *client.Client) (err error) {
+			task, err = cli.Task(cli.TaskLink(r.Id), nil)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(fromClientStats(task.ID, time.Now(), task.ExecutionStats)); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) ListTopics(ctx context.Context, r *kapacitorpb.ListTopicsRequest) (*kapacitorpb.ListTopicsResponse, error) {
+	var topics client.Topics
+	err := s.call(ctx, func(cli *client.Client) (err error) {
+		topics, err = cli.ListTopics(&client.ListTopicsOptions{
+			Pattern:  r.Pattern,
+			MinLevel: r.MinLevel,
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &kapacitorpb.ListTopicsResponse{
+		Topics: make([]*kapacitorpb.Topic, len(topics.Topics)),
+	}
+	for i, t := range topics.Topics {
+		resp.Topics[i] = &kapacitorpb.Topic{
+			Id:        t.ID,
+			Level:     t.Level,
+			Collected: t.Collected,
+		}
+	}
+	return resp, nil
+}
+
+func (s *Service) ListTopicEvents(ctx context.Context, r *kapacitorpb.ListTopicEventsRequest) (*kapacitorpb.ListTopicEventsResponse, error) {
+	var events client.TopicEvents
+	err := s.call(ctx, func(cli *client.Client) (err error) {
+		events, err = cli.ListTopicEvents(cli.TopicEventsLink(r.Topic), &client.ListTopicEventsOptions{
+			MinLevel: r.MinLevel,
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &kapacitorpb.ListTopicEventsResponse{
+		Events: make([]*kapacitorpb.TopicEvent, len(events.Events)),
+	}
+	for i, e := range events.Events {
+		resp.Events[i] = &kapacitorpb.TopicEvent{
+			Id:       e.ID,
+			Message:  e.State.Message,
+			Details:  e.State.Details,
+			Time:     timestamppb.New(e.State.Time),
+			Duration: durationpb.New(time.Duration(e.State.Duration)),
+			Level:    e.State.Level,
+		}
+	}
+	return resp, nil
+}
+
+func (s *Service) DeleteTopic(ctx context.Context, r *kapacitorpb.DeleteTopicRequest) (*kapacitorpb.DeleteTopicResponse, error) {
+	err := s.call(ctx, func(cli *client.Client) error {
+		return cli.DeleteTopic(cli.TopicLink(r.Topic))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kapacitorpb.DeleteTopicResponse{}, nil
+}
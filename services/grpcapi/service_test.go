@@ -0,0 +1,127 @@
+package grpcapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	client "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/services/grpcapi"
+	"github.com/influxdata/kapacitor/services/grpcapi/kapacitorpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type diag struct{}
+
+func (diag) Error(msg string, err error)  {}
+func (diag) StartedListening(addr string) {}
+func (diag) ClosedService()               {}
+
+func newService(t *testing.T, h http.Handler) (kapacitorpb.KapacitorClient, func()) {
+	t.Helper()
+	c := grpcapi.NewConfig()
+	c.Enabled = true
+	c.BindAddress = "127.0.0.1:0"
+	s := grpcapi.NewService(c, nil, diag{})
+	s.Handler = h
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(s.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kapacitorpb.NewKapacitorClient(conn), func() {
+		conn.Close()
+		s.Close()
+	}
+}
+
+func TestService_CreateTask(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, exp := r.Header.Get("Authorization"), "Bearer secret"; got != exp {
+			t.Errorf("unexpected authorization got %q exp %q", got, exp)
+		}
+		if r.Method != "POST" || r.URL.Path != "/kapacitor/v1/tasks" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var opts client.CreateTaskOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := opts.Vars["period"].Value, time.Minute; got != exp {
+			t.Errorf("unexpected period got %v exp %v", got, exp)
+		}
+		task := client.Task{
+			ID:         opts.ID,
+			Type:       opts.Type,
+			DBRPs:      opts.DBRPs,
+			TICKscript: opts.TICKscript,
+			Vars:       opts.Vars,
+			Status:     opts.Status,
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(task)
+	})
+	cli, closeF := newService(t, h)
+	defer closeF()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	task, err := cli.CreateTask(ctx, &kapacitorpb.CreateTaskRequest{
+		Id:         "cpu",
+		Type:       kapacitorpb.TaskType_STREAM,
+		Dbrps:      []*kapacitorpb.DBRP{{Db: "telegraf", Rp: "autogen"}},
+		TickScript: "stream|from()",
+		Status:     kapacitorpb.TaskStatus_ENABLED,
+		Vars: map[string]*kapacitorpb.Var{
+			"period": {
+				Type:  "duration",
+				Value: &kapacitorpb.Var_DurationValue{DurationValue: durationpb.New(time.Minute)},
+			},
+			"hosts": {
+				Type: "list",
+				Value: &kapacitorpb.Var_ListValue{ListValue: &kapacitorpb.VarList{
+					Values: []*kapacitorpb.Var{{Type: "string", Value: &kapacitorpb.Var_StringValue{StringValue: "serverA"}}},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Id != "cpu" || task.Type != kapacitorpb.TaskType_STREAM || task.Status != kapacitorpb.TaskStatus_ENABLED {
+		t.Errorf("unexpected task %v", task)
+	}
+	if got, exp := task.Vars["period"].GetDurationValue().AsDuration(), time.Minute; got != exp {
+		t.Errorf("unexpected period got %v exp %v", got, exp)
+	}
+	if got, exp := task.Vars["hosts"].GetListValue().Values[0].GetStringValue(), "serverA"; got != exp {
+		t.Errorf("unexpected hosts got %v exp %v", got, exp)
+	}
+}
+
+func TestService_Errors(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no task exists"})
+	})
+	cli, closeF := newService(t, h)
+	defer closeF()
+
+	_, err := cli.GetTask(context.Background(), &kapacitorpb.GetTaskRequest{Id: "missing"})
+	if got, exp := status.Code(err), codes.NotFound; got != exp {
+		t.Errorf("unexpected code got %v exp %v: %v", got, exp, err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Digest secret")
+	_, err = cli.GetTask(ctx, &kapacitorpb.GetTaskRequest{Id: "missing"})
+	if got, exp := status.Code(err), codes.Unauthenticated; got != exp {
+		t.Errorf("unexpected code got %v exp %v: %v", got, exp, err)
+	}
+}
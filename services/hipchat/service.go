@@ -143,7 +143,7 @@ func (s *Service) preparePost(room, token, message string, level alert.Level) (s
 	u.RawQuery = v.Encode()
 
 	var color string
-	switch level {
+	switch level.Builtin() {
 	case alert.Warning:
 		color = "yellow"
 	case alert.Critical:
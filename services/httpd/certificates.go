@@ -0,0 +1,73 @@
+package httpd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// certificates selects a server certificate by the server name requested via SNI.
+type certificates struct {
+	def    *tls.Certificate
+	byName map[string]*tls.Certificate
+}
+
+// loadCertificates loads the default certificate and all additional certificates,
+// failing if any of them is invalid.
+func loadCertificates(cert, key string, configs []CertificateConfig) (*certificates, error) {
+	def, err := tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load certificate %s", cert)
+	}
+	c := &certificates{
+		def:    &def,
+		byName: make(map[string]*tls.Certificate),
+	}
+	for _, config := range configs {
+		key := config.PrivateKey
+		if key == "" {
+			key = config.Certificate
+		}
+		cert, err := tls.LoadX509KeyPair(config.Certificate, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load certificate %s", config.Certificate)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse certificate %s", config.Certificate)
+		}
+		cert.Leaf = leaf
+		names := leaf.DNSNames
+		if len(names) == 0 && leaf.Subject.CommonName != "" {
+			names = []string{leaf.Subject.CommonName}
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("certificate %s has no DNS names", config.Certificate)
+		}
+		for _, name := range names {
+			name = strings.ToLower(name)
+			// The first certificate for a name wins.
+			if _, ok := c.byName[name]; !ok {
+				c.byName[name] = &cert
+			}
+		}
+	}
+	return c, nil
+}
+
+// getCertificate returns the certificate matching the server name exactly or by wildcard,
+// falling back to the default certificate.
+func (c *certificates) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if cert, ok := c.byName[name]; ok {
+		return cert, nil
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if cert, ok := c.byName["*"+name[i:]]; ok {
+			return cert, nil
+		}
+	}
+	return c.def, nil
+}
@@ -0,0 +1,97 @@
+package httpd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate and its key to a single PEM file.
+func writeCertificate(t *testing.T, dir, name string, dnsNames ...string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	path := filepath.Join(dir, name+".pem")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCertificates_GetCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpd-certificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	def := writeCertificate(t, dir, "default", "localhost")
+	a := writeCertificate(t, dir, "a", "a.example.com")
+	b := writeCertificate(t, dir, "b", "*.b.example.com")
+
+	certs, err := loadCertificates(def, def, []CertificateConfig{
+		{Certificate: a},
+		{Certificate: b, PrivateKey: b},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		serverName string
+		exp        string
+	}{
+		{serverName: "a.example.com", exp: "a"},
+		{serverName: "A.Example.com.", exp: "a"},
+		{serverName: "x.b.example.com", exp: "b"},
+		{serverName: "b.example.com", exp: "default"},
+		{serverName: "other.example.com", exp: "default"},
+		{serverName: "", exp: "default"},
+	}
+	for _, tc := range testCases {
+		cert, err := certs.getCertificate(&tls.ClientHelloInfo{ServerName: tc.serverName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := leaf.Subject.CommonName; got != tc.exp {
+			t.Errorf("%q: unexpected certificate got %s exp %s", tc.serverName, got, tc.exp)
+		}
+	}
+
+	if _, err := loadCertificates(def, def, []CertificateConfig{{Certificate: filepath.Join(dir, "missing.pem")}}); err == nil {
+		t.Error("expected error loading a missing certificate")
+	}
+}
@@ -11,33 +11,87 @@ import (
 )
 
 const (
-	DefaultShutdownTimeout = toml.Duration(time.Second * 10)
+	DefaultShutdownTimeout   = toml.Duration(time.Second * 10)
+	DefaultMaxConnections    = 4096
+	DefaultReadHeaderTimeout = toml.Duration(time.Second * 10)
+	DefaultReadTimeout       = toml.Duration(time.Minute * 5)
+	DefaultIdleTimeout       = toml.Duration(time.Minute * 2)
 )
 
 type Config struct {
-	BindAddress      string        `toml:"bind-address"`
-	AuthEnabled      bool          `toml:"auth-enabled"`
-	LogEnabled       bool          `toml:"log-enabled"`
-	WriteTracing     bool          `toml:"write-tracing"`
-	PprofEnabled     bool          `toml:"pprof-enabled"`
-	HttpsEnabled     bool          `toml:"https-enabled"`
-	HttpsCertificate string        `toml:"https-certificate"`
-	HTTPSPrivateKey  string        `toml:"https-private-key"`
-	ShutdownTimeout  toml.Duration `toml:"shutdown-timeout"`
-	SharedSecret     string        `toml:"shared-secret"`
+	BindAddress  string `toml:"bind-address"`
+	AuthEnabled  bool   `toml:"auth-enabled"`
+	LogEnabled   bool   `toml:"log-enabled"`
+	WriteTracing bool   `toml:"write-tracing"`
+	PprofEnabled bool   `toml:"pprof-enabled"`
+	// Log the name of the remote host, resolved by reverse DNS, instead of its address.
+	// Lookups time out after a second and names are cached for five minutes.
+	LogReverseDNS bool `toml:"log-reverse-dns"`
+	// Require authentication for the /metrics endpoint when authentication is enabled.
+	MetricsAuthEnabled bool          `toml:"metrics-auth-enabled"`
+	HttpsEnabled       bool          `toml:"https-enabled"`
+	HttpsCertificate   string        `toml:"https-certificate"`
+	HTTPSPrivateKey    string        `toml:"https-private-key"`
+	ShutdownTimeout    toml.Duration `toml:"shutdown-timeout"`
+	SharedSecret       string        `toml:"shared-secret"`
+
+	// MaxConnections is the maximum number of concurrent connections,
+	// new connections are rejected while it is reached. Zero means no limit.
+	MaxConnections int `toml:"max-connections"`
+	// ReadHeaderTimeout is the time allowed to read the headers of a request.
+	ReadHeaderTimeout toml.Duration `toml:"read-header-timeout"`
+	// ReadTimeout is the time allowed to read a whole request, including its body.
+	ReadTimeout toml.Duration `toml:"read-timeout"`
+	// WriteTimeout is the time allowed to write a response, from the end of reading the request headers.
+	// It ends streaming responses, such as the logs, once expired, so it is disabled by default.
+	WriteTimeout toml.Duration `toml:"write-timeout"`
+	// IdleTimeout is the time an idle keep-alive connection is kept open.
+	IdleTimeout toml.Duration `toml:"idle-timeout"`
+
+	// JSONFloatPrecision is the number of significant digits of the floats of the JSON responses,
+	// such as the results of the httpOut nodes. Zero keeps the full precision.
+	JSONFloatPrecision int `toml:"json-float-precision"`
+
+	// WriteParseWorkers is the number of goroutines parsing the line protocol of a write body,
+	// large bodies are split into chunks of lines parsed concurrently. Zero is the number of CPUs.
+	WriteParseWorkers int `toml:"write-parse-workers"`
+	// MaxWriteLines is the maximum number of lines of points of a write,
+	// larger writes are rejected with the status 413. Zero means no limit.
+	MaxWriteLines int `toml:"max-write-lines"`
+
+	// RequestIDFormat is the format of the IDs generated for the requests without a Request-Id header,
+	// uuid or ulid. The Request-Id supplied by a client is kept.
+	RequestIDFormat string `toml:"request-id-format"`
+
+	// Additional certificates selected by the server name the client requests via SNI.
+	// The https-certificate is used when no additional certificate matches.
+	HTTPSCertificates []CertificateConfig `toml:"https-certificates"`
 
 	// Enable gzipped encoding
 	// NOTE: this is ignored in toml since it is only consumed by the tests
 	GZIP bool `toml:"-"`
 }
 
+// CertificateConfig is a certificate and private key pair.
+type CertificateConfig struct {
+	Certificate string `toml:"certificate"`
+	// Use a separate private key location, defaults to the certificate.
+	PrivateKey string `toml:"private-key"`
+}
+
 func NewConfig() Config {
 	return Config{
-		BindAddress:      ":9092",
-		LogEnabled:       true,
-		HttpsCertificate: "/etc/ssl/kapacitor.pem",
-		ShutdownTimeout:  DefaultShutdownTimeout,
-		GZIP:             true,
+		BindAddress:        ":9092",
+		LogEnabled:         true,
+		MetricsAuthEnabled: true,
+		HttpsCertificate:   "/etc/ssl/kapacitor.pem",
+		ShutdownTimeout:    DefaultShutdownTimeout,
+		MaxConnections:     DefaultMaxConnections,
+		ReadHeaderTimeout:  DefaultReadHeaderTimeout,
+		ReadTimeout:        DefaultReadTimeout,
+		IdleTimeout:        DefaultIdleTimeout,
+		RequestIDFormat:    RequestIDFormatUUID,
+		GZIP:               true,
 	}
 }
 
@@ -54,6 +108,38 @@ func (c Config) Validate() error {
 	} else if pn > 65535 || pn < 0 {
 		return fmt.Errorf("invalid http bind address port %d: out of range", pn)
 	}
+	if c.MaxConnections < 0 {
+		return errors.New("max-connections must not be negative")
+	}
+	if c.WriteParseWorkers < 0 {
+		return errors.New("write-parse-workers must not be negative")
+	}
+	if c.MaxWriteLines < 0 {
+		return errors.New("max-write-lines must not be negative")
+	}
+	switch c.RequestIDFormat {
+	case "", RequestIDFormatUUID, RequestIDFormatULID:
+	default:
+		return fmt.Errorf("invalid request-id-format %q, must be one of %s or %s", c.RequestIDFormat, RequestIDFormatUUID, RequestIDFormatULID)
+	}
+	if c.JSONFloatPrecision < 0 || c.JSONFloatPrecision > MaxJSONFloatPrecision {
+		return fmt.Errorf("json-float-precision must be between 0 and %d, got %d", MaxJSONFloatPrecision, c.JSONFloatPrecision)
+	}
+	for name, t := range map[string]toml.Duration{
+		"read-header-timeout": c.ReadHeaderTimeout,
+		"read-timeout":        c.ReadTimeout,
+		"write-timeout":       c.WriteTimeout,
+		"idle-timeout":        c.IdleTimeout,
+	} {
+		if t < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	for i, c := range c.HTTPSCertificates {
+		if c.Certificate == "" {
+			return fmt.Errorf("https-certificates[%d]: must specify certificate", i)
+		}
+	}
 
 	return nil
 }
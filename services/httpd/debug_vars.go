@@ -0,0 +1,126 @@
+package httpd
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+// Formats of the /debug/vars endpoint.
+const (
+	debugVarsFormatJSON       = "json"
+	debugVarsFormatLine       = "line"
+	debugVarsFormatPrometheus = "prometheus"
+)
+
+// LineProtocolContentType is the content type of the responses in line protocol.
+const LineProtocolContentType = "text/plain; charset=utf-8"
+
+// debugVarsFormat returns the format of the /debug/vars response requested with the format query parameter,
+// or else negotiated from the Accept header.
+// The Prometheus format is accepted as application/openmetrics-text or text/plain with a version parameter,
+// as sent by the Prometheus server, and line protocol as text/plain.
+// The format defaults to the JSON of the expvars, for backward compatibility.
+func debugVarsFormat(r *http.Request) (string, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		switch f {
+		case debugVarsFormatJSON, debugVarsFormatLine, debugVarsFormatPrometheus:
+			return f, nil
+		}
+		return "", fmt.Errorf("invalid format %q, must be one of %s, %s or %s", f, debugVarsFormatJSON, debugVarsFormatLine, debugVarsFormatPrometheus)
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return debugVarsFormatJSON, nil
+		case "application/openmetrics-text":
+			return debugVarsFormatPrometheus, nil
+		case "text/plain":
+			if _, ok := params["version"]; ok {
+				return debugVarsFormatPrometheus, nil
+			}
+			return debugVarsFormatLine, nil
+		}
+	}
+	return debugVarsFormatJSON, nil
+}
+
+// serveDebugVars serves the expvars as JSON, or the internal statistics as line protocol or in the Prometheus format.
+func (h *Handler) serveDebugVars(w http.ResponseWriter, r *http.Request) {
+	format, err := debugVarsFormat(r)
+	if err != nil {
+		HttpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+	if format == debugVarsFormatJSON {
+		serveExpvar(w, r)
+		return
+	}
+	data, err := vars.GetStatsData()
+	if err != nil {
+		HttpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+	if format == debugVarsFormatPrometheus {
+		w.Header().Set("Content-Type", metricsContentType)
+		err = writeMetrics(w, data)
+	} else {
+		w.Header().Set("Content-Type", LineProtocolContentType)
+		err = writeLineProtocol(w, data, time.Now())
+	}
+	if err != nil {
+		h.diag.Error("failed to write debug vars", err)
+	}
+}
+
+// serveExpvar serves registered expvar information over HTTP.
+func serveExpvar(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !first {
+			fmt.Fprintf(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprintf(w, "\n}\n")
+}
+
+// writeLineProtocol writes the statistics as line protocol, one point per statistic at the time now.
+// Values that are not numbers, strings or booleans are skipped.
+func writeLineProtocol(w io.Writer, data []vars.StatsData, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range data {
+		fields := make(imodels.Fields, len(d.Values))
+		for k, v := range d.Values {
+			switch v.(type) {
+			case int64, float64, string, bool:
+				fields[k] = v
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		p, err := imodels.NewPoint(d.Name, imodels.NewTags(d.Tags), fields, now)
+		if err != nil {
+			return err
+		}
+		bw.WriteString(p.String())
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,85 @@
+package httpd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+func TestDebugVarsFormat(t *testing.T) {
+	testCases := []struct {
+		query  string
+		accept string
+		exp    string
+		err    string
+	}{
+		{exp: debugVarsFormatJSON},
+		{accept: "*/*", exp: debugVarsFormatJSON},
+		{accept: "application/json", exp: debugVarsFormatJSON},
+		{accept: "text/plain", exp: debugVarsFormatLine},
+		{accept: "text/plain;version=0.0.4;q=0.5,*/*;q=0.1", exp: debugVarsFormatPrometheus},
+		{accept: "application/openmetrics-text;version=1.0.0", exp: debugVarsFormatPrometheus},
+		{accept: "text/html, application/json", exp: debugVarsFormatJSON},
+		{query: "format=line", accept: "application/json", exp: debugVarsFormatLine},
+		{query: "format=prometheus", exp: debugVarsFormatPrometheus},
+		{query: "format=xml", err: `invalid format "xml", must be one of json, line or prometheus`},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/kapacitor/v1/debug/vars?"+tc.query, nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		got, err := debugVarsFormat(r)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q %q: unexpected error got %v exp %s", tc.query, tc.accept, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q %q: unexpected error %v", tc.query, tc.accept, err)
+			continue
+		}
+		if got != tc.exp {
+			t.Errorf("%q %q: unexpected format got %s exp %s", tc.query, tc.accept, got, tc.exp)
+		}
+	}
+}
+
+func TestWriteLineProtocol(t *testing.T) {
+	data := []vars.StatsData{
+		{
+			Name: "kapacitor",
+			Values: map[string]interface{}{
+				"num_tasks": int64(2),
+				"version":   "1.7",
+			},
+		},
+		{
+			Name: "nodes",
+			Tags: map[string]string{"task": "cpu", "node": "stream0"},
+			Values: map[string]interface{}{
+				"avg_exec_time_ns": int64(100),
+			},
+		},
+		{
+			Name: "unsupported",
+			Values: map[string]interface{}{
+				"value": []string{"x"},
+			},
+		},
+	}
+	exp := `kapacitor num_tasks=2i,version="1.7" 60000000000
+nodes,node=stream0,task=cpu avg_exec_time_ns=100i 60000000000
+`
+	var buf bytes.Buffer
+	if err := writeLineProtocol(&buf, data, time.Unix(60, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != exp {
+		t.Errorf("unexpected line protocol got\n%s\nexp\n%s", got, exp)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"expvar"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"strings"
@@ -16,7 +17,6 @@ import (
 	"github.com/influxdata/influxdb"
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/query"
-	"github.com/influxdata/influxdb/uuid"
 	"github.com/influxdata/kapacitor/auth"
 	"github.com/influxdata/kapacitor/client/v1"
 )
@@ -30,6 +30,7 @@ const (
 	statPointsWrittenOK           = "points_written_ok"   // Number of points written OK
 	statPointsWrittenFail         = "points_written_fail" // Number of points that failed to be written
 	statAuthFail                  = "auth_fail"           // Number of requests that failed to authenticate
+	statConnectionsRejected       = "conn_rejected"       // Number of connections rejected because of the connection limit
 )
 
 const (
@@ -60,6 +61,8 @@ type Route struct {
 	NoGzip      bool
 	NoJSON      bool
 	BypassAuth  bool
+	// Never require authentication, regardless of the pprof config.
+	NoAuth bool
 }
 
 // Handler represents an HTTP handler for the Kapacitor API server.
@@ -68,6 +71,7 @@ type Handler struct {
 
 	requireAuthentication bool
 	exposePprof           bool
+	metricsAuthEnabled    bool
 	sharedSecret          string
 
 	allowGzip bool
@@ -91,6 +95,19 @@ type Handler struct {
 
 	// Log every HTTP access.
 	loggingEnabled bool
+	// Log the name of the remote host instead of its address.
+	logReverseDNS bool
+
+	// Significant digits of the floats of the JSON responses, zero keeps the full precision.
+	jsonFloatPrecision int
+
+	// Number of goroutines parsing the body of a write, zero is the number of CPUs.
+	writeParseWorkers int
+	// Maximum number of lines of a write, zero is no limit.
+	maxWriteLines int
+
+	// Format of the IDs generated for the requests without a Request-Id.
+	requestIDFormat string
 
 	statMap *expvar.Map
 }
@@ -99,22 +116,34 @@ type Handler struct {
 func NewHandler(
 	requireAuthentication,
 	pprofEnabled,
+	metricsAuthEnabled,
 	loggingEnabled,
+	logReverseDNS,
 	writeTrace,
 	allowGzip bool,
 	statMap *expvar.Map,
 	d Diagnostic,
 	sharedSecret string,
+	jsonFloatPrecision int,
+	writeParseWorkers int,
+	maxWriteLines int,
+	requestIDFormat string,
 ) *Handler {
 	h := &Handler{
 		methodMux:             make(map[string]*ServeMux),
 		requireAuthentication: requireAuthentication,
 		exposePprof:           pprofEnabled,
+		metricsAuthEnabled:    metricsAuthEnabled,
 		sharedSecret:          sharedSecret,
 		allowGzip:             allowGzip,
 		diag:                  d,
 		writeTrace:            writeTrace,
 		loggingEnabled:        loggingEnabled,
+		logReverseDNS:         logReverseDNS,
+		jsonFloatPrecision:    jsonFloatPrecision,
+		writeParseWorkers:     writeParseWorkers,
+		maxWriteLines:         maxWriteLines,
+		requestIDFormat:       requestIDFormat,
 		statMap:               statMap,
 	}
 
@@ -233,9 +262,17 @@ func NewHandler(
 		{
 			Method:      "GET",
 			Pattern:     BasePath + "/debug/vars",
-			HandlerFunc: serveExpvar,
+			HandlerFunc: h.serveDebugVars,
 			BypassAuth:  true,
 		},
+		{
+			// Prometheus metrics, at the conventional path without the base path
+			Method:      "GET",
+			Pattern:     "/metrics",
+			HandlerFunc: h.serveMetrics,
+			NoJSON:      true,
+			NoAuth:      !metricsAuthEnabled,
+		},
 	})
 
 	return h
@@ -298,7 +335,7 @@ func (h *Handler) addRawRoute(r Route) error {
 	// This is a normal handler signature so perform standard authentication/authorization.
 	if hf, ok := r.HandlerFunc.(func(http.ResponseWriter, *http.Request)); ok {
 		requireAuth := h.requireAuthentication
-		if r.BypassAuth && h.exposePprof {
+		if (r.BypassAuth && h.exposePprof) || r.NoAuth {
 			requireAuth = false
 		}
 		handler = authenticate(authorize(hf), h, requireAuth)
@@ -309,6 +346,9 @@ func (h *Handler) addRawRoute(r Route) error {
 
 	// Set basic handlers for all requests
 	if !r.NoJSON {
+		if h.jsonFloatPrecision > 0 {
+			handler = jsonFloatPrecision(handler, h.jsonFloatPrecision)
+		}
 		handler = jsonContent(handler)
 	}
 	if !r.NoGzip && h.allowGzip {
@@ -316,12 +356,12 @@ func (h *Handler) addRawRoute(r Route) error {
 	}
 	handler = versionHeader(handler, h)
 	handler = cors(handler)
-	handler = requestID(handler)
+	handler = requestID(handler, h.requestIDFormat)
 
 	if h.loggingEnabled {
-		handler = logHandler(handler, h.diag)
+		handler = logHandler(handler, h.diag, h.logReverseDNS)
 	}
-	handler = recovery(handler, h.diag) // make sure recovery is always last
+	handler = recovery(handler, h.diag, h.logReverseDNS) // make sure recovery is always last
 
 	mux, ok := h.methodMux[r.Method]
 	if !ok {
@@ -411,6 +451,16 @@ func (h *Handler) serve404(w http.ResponseWriter, r *http.Request) {
 	HttpError(w, "Not Found", true, http.StatusNotFound)
 }
 
+// readErrorStatus returns the status of the response to a request whose body could not be read.
+// Reading the body times out when the client is too slow to send it, see the read-timeout option.
+func readErrorStatus(err error) int {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return http.StatusRequestTimeout
+	}
+	return http.StatusBadRequest
+}
+
 func (h *Handler) writeError(w http.ResponseWriter, result query.Result, statusCode int) {
 	w.WriteHeader(statusCode)
 	w.Write([]byte(result.Err.Error()))
@@ -448,7 +498,7 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user auth.U
 		if h.writeTrace {
 			h.diag.Error("write handler unabled to read bytes from request body", err)
 		}
-		h.writeError(w, query.Result{Err: err}, http.StatusBadRequest)
+		h.writeError(w, query.Result{Err: err}, readErrorStatus(err))
 		return
 	}
 	h.statMap.Add(statWriteRequestBytesReceived, int64(len(b)))
@@ -462,17 +512,22 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user auth.U
 // serveWriteLine receives incoming series data in line protocol format and writes it to the database.
 func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []byte, user auth.User) {
 	qp := r.URL.Query()
-	precision := qp.Get("precision")
-	if precision == "" {
-		precision = "n"
+	precision, err := writePrecision(qp.Get("precision"))
+	if err != nil {
+		h.writeError(w, query.Result{Err: err}, http.StatusBadRequest)
+		return
 	}
 
-	points, err := models.ParsePointsWithPrecision(body, time.Now().UTC(), precision)
+	points, err := parseWritePoints(body, time.Now().UTC(), precision, h.writeParseWorkers, h.maxWriteLines)
 	if err != nil {
-		if err.Error() == "EOF" {
+		if errors.Is(err, io.EOF) {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
+		if _, ok := err.(tooManyLinesError); ok {
+			h.writeError(w, query.Result{Err: err}, http.StatusRequestEntityTooLarge)
+			return
+		}
 		h.writeError(w, query.Result{Err: err}, http.StatusBadRequest)
 		return
 	}
@@ -539,20 +594,6 @@ func servePprof(w http.ResponseWriter, r *http.Request) {
 	pprof.Index(w, r)
 }
 
-// serveExpvar serves registered expvar information over HTTP.
-func serveExpvar(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, "{\n")
-	first := true
-	expvar.Do(func(kv expvar.KeyValue) {
-		if !first {
-			fmt.Fprintf(w, ",\n")
-		}
-		first = false
-		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
-	})
-	fmt.Fprintf(w, "\n}\n")
-}
-
 // HttpError writes an error to the client in a standard format.
 func HttpError(w http.ResponseWriter, err string, pretty bool, code int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -802,6 +843,9 @@ func (w gzipResponseWriter) Write(b []byte) (int, error) {
 
 func (w gzipResponseWriter) Flush() {
 	w.Writer.(*gzip.Writer).Flush()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
 }
 
 // determines if the client can accept compressed responses, and encodes accordingly
@@ -868,32 +912,22 @@ func cors(inner http.Handler) http.Handler {
 	})
 }
 
-func requestID(inner http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		uid := uuid.TimeUUID()
-		r.Header.Set("Request-Id", uid.String())
-		w.Header().Set("Request-Id", r.Header.Get("Request-Id"))
-
-		inner.ServeHTTP(w, r)
-	})
-}
-
-func logHandler(inner http.Handler, d Diagnostic) http.Handler {
+func logHandler(inner http.Handler, d Diagnostic, reverseDNS bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		l := &responseLogger{w: w}
 		inner.ServeHTTP(l, r)
-		buildLogLine(d, l, r, start)
+		buildLogLine(d, l, r, start, reverseDNS)
 	})
 }
 
-func recovery(inner http.Handler, d Diagnostic) http.Handler {
+func recovery(inner http.Handler, d Diagnostic, reverseDNS bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		l := &responseLogger{w: w}
 		inner.ServeHTTP(l, r)
 		if err := recover(); err != nil {
-			buildLogLineError(d, l, r, start, fmt.Sprintf("%v", err))
+			buildLogLineError(d, l, r, start, fmt.Sprintf("%v", err), reverseDNS)
 		}
 	})
 }
@@ -1,8 +1,12 @@
 package httpd
 
 import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/kapacitor/auth"
 )
 
@@ -103,3 +107,31 @@ func Test_RequiredPrilegeForHTTPMethod(t *testing.T) {
 		}
 	}
 }
+
+type pointsWriter struct {
+	points []models.Point
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func TestServeWriteLine_EmptyBody(t *testing.T) {
+	pw := new(pointsWriter)
+	h := &Handler{
+		statMap:      new(expvar.Map),
+		PointsWriter: pw,
+	}
+	for _, body := range []string{"", "\n", "# comment\n"} {
+		r := httptest.NewRequest("POST", "/write?db=mydb", nil)
+		w := httptest.NewRecorder()
+		h.serveWriteLine(w, r, []byte(body), auth.AdminUser)
+		if got, exp := w.Code, http.StatusNoContent; got != exp {
+			t.Errorf("%q: unexpected status got %d exp %d: %s", body, got, exp, w.Body.String())
+		}
+	}
+	if got := len(pw.points); got != 0 {
+		t.Errorf("unexpected points written got %d exp 0", got)
+	}
+}
@@ -21,14 +21,20 @@ func NewServer(verbose bool) *Server {
 	ds.Open()
 	s := &Server{
 		Handler: httpd.NewHandler(
+			false,
 			false,
 			false,
 			verbose,
+			false,
 			verbose,
 			false,
 			statMap,
 			ds.NewHTTPDHandler(),
 			"",
+			0,
+			0,
+			0,
+			httpd.RequestIDFormatUUID,
 		),
 	}
 
@@ -0,0 +1,122 @@
+package httpd
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxJSONFloatPrecision is the largest useful number of significant digits of a float64,
+// any float64 is formatted exactly with 17 significant digits.
+const MaxJSONFloatPrecision = 17
+
+// jsonFloatPrecision rounds the floats of the JSON responses to the given number of significant digits.
+func jsonFloatPrecision(inner http.Handler, digits int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &precisionResponseWriter{ResponseWriter: w, digits: digits}
+		defer pw.close()
+		inner.ServeHTTP(pw, r)
+	})
+}
+
+// precisionResponseWriter rounds the floats of a JSON response as it is written.
+//
+// The response is scanned as it is written, so that streaming responses are rounded as well.
+// Only the numbers with a fraction or an exponent are rounded, integers are written as is.
+// Responses that are not JSON, i.e. CSV results, are written as is.
+type precisionResponseWriter struct {
+	http.ResponseWriter
+	digits int
+
+	checked bool
+	enabled bool
+
+	inString bool
+	escaped  bool
+	// number is the number being scanned, which may continue in the next write.
+	number []byte
+	buf    []byte
+}
+
+func (w *precisionResponseWriter) check() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	w.enabled = strings.Contains(w.Header().Get("Content-Type"), "json")
+}
+
+func (w *precisionResponseWriter) WriteHeader(code int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *precisionResponseWriter) Write(b []byte) (int, error) {
+	w.check()
+	if !w.enabled {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = w.buf[:0]
+	for _, c := range b {
+		if w.inString {
+			w.buf = append(w.buf, c)
+			switch {
+			case w.escaped:
+				w.escaped = false
+			case c == '\\':
+				w.escaped = true
+			case c == '"':
+				w.inString = false
+			}
+			continue
+		}
+		if isJSONNumberByte(c) && (len(w.number) > 0 || c == '-' || (c >= '0' && c <= '9')) {
+			w.number = append(w.number, c)
+			continue
+		}
+		w.appendNumber()
+		if c == '"' {
+			w.inString = true
+		}
+		w.buf = append(w.buf, c)
+	}
+	if _, err := w.ResponseWriter.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// appendNumber appends the scanned number to the buffer, rounded if it is a float.
+func (w *precisionResponseWriter) appendNumber() {
+	if len(w.number) == 0 {
+		return
+	}
+	s := string(w.number)
+	w.number = w.number[:0]
+	if strings.ContainsAny(s, ".eE") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			s = strconv.FormatFloat(f, 'g', w.digits, 64)
+		}
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func isJSONNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+func (w *precisionResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close writes the number ending the response, if any.
+func (w *precisionResponseWriter) close() {
+	if len(w.number) == 0 {
+		return
+	}
+	w.buf = w.buf[:0]
+	w.appendNumber()
+	w.ResponseWriter.Write(w.buf)
+}
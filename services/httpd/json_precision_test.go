@@ -0,0 +1,60 @@
+package httpd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONFloatPrecision(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		chunks      []string
+		want        string
+	}{
+		{
+			name:   "floats",
+			chunks: []string{`{"values":[[1.23456789,-0.000123456789,6.02214076e+23,42,1e-7]]}`},
+			want:   `{"values":[[1.23,-0.000123,6.02e+23,42,1e-07]]}`,
+		},
+		{
+			name:   "strings",
+			chunks: []string{`{"a \"1.23456\"":"3.14159","b":2.71828}`},
+			want:   `{"a \"1.23456\"":"3.14159","b":2.72}`,
+		},
+		{
+			name:   "split number",
+			chunks: []string{`[3.14`, `159,2`, `.71828]`},
+			want:   `[3.14,2.72]`,
+		},
+		{
+			name:   "trailing number",
+			chunks: []string{`3.14159`},
+			want:   `3.14`,
+		},
+		{
+			name:        "csv",
+			contentType: "text/csv",
+			chunks:      []string{"time,value\n1971-01-01T00:00:00Z,3.14159\n"},
+			want:        "time,value\n1971-01-01T00:00:00Z,3.14159\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := jsonContent(jsonFloatPrecision(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				for _, c := range tc.chunks {
+					w.Write([]byte(c))
+				}
+			}), 3))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("unexpected body got %s exp %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package httpd
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rejectWriteTimeout bounds the time spent writing the response to a rejected connection.
+const rejectWriteTimeout = time.Second
+
+const rejectBody = "too many open connections\n"
+
+// rejectResponse is written to plain HTTP connections that are rejected.
+var rejectResponse = []byte(fmt.Sprintf(
+	"HTTP/1.1 503 Service Unavailable\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\nContent-Length: %d\r\n\r\n%s",
+	len(rejectBody),
+	rejectBody,
+))
+
+// limitListener is a net.Listener that rejects new connections
+// while the maximum number of connections are open.
+// Unlike blocking in Accept, rejecting the connections does
+// not leave clients waiting in the backlog of the listener.
+type limitListener struct {
+	net.Listener
+	max  int64
+	open int64
+	// reject is called with each rejected connection before it is closed,
+	// in its own goroutine so that it does not delay accepting the next connections.
+	reject func(c net.Conn)
+}
+
+func newLimitListener(l net.Listener, max int, reject func(c net.Conn)) *limitListener {
+	return &limitListener{
+		Listener: l,
+		max:      int64(max),
+		reject:   reject,
+	}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if atomic.AddInt64(&l.open, 1) > l.max {
+			atomic.AddInt64(&l.open, -1)
+			l.rejectConn(c)
+			continue
+		}
+		return &limitConn{Conn: c, l: l}, nil
+	}
+}
+
+// rejectConn closes the rejected connection, after passing it to reject.
+func (l *limitListener) rejectConn(c net.Conn) {
+	if l.reject == nil {
+		c.Close()
+		return
+	}
+	go func() {
+		l.reject(c)
+		c.Close()
+	}()
+}
+
+// limitConn releases its slot in the limitListener once closed.
+type limitConn struct {
+	net.Conn
+	l    *limitListener
+	once sync.Once
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		atomic.AddInt64(&c.l.open, -1)
+	})
+	return err
+}
+
+// writeRejectResponse writes a 503 response to a rejected plain HTTP connection,
+// so that clients get an error instead of a reset connection.
+func writeRejectResponse(c net.Conn) {
+	c.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+	c.Write(rejectResponse)
+}
@@ -0,0 +1,143 @@
+package httpd
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected int64
+	l := newLimitListener(ln, 1, func(c net.Conn) {
+		atomic.AddInt64(&rejected, 1)
+		writeRejectResponse(c)
+	})
+	defer l.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	first, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	c := <-accepted
+
+	// The second connection is rejected with a 503 while the first is open.
+	second, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	resp, err := http.ReadResponse(bufio.NewReader(second), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, exp := resp.StatusCode, http.StatusServiceUnavailable; got != exp {
+		t.Errorf("unexpected status got %d exp %d", got, exp)
+	}
+	if got, exp := atomic.LoadInt64(&rejected), int64(1); got != exp {
+		t.Errorf("unexpected rejected connections got %d exp %d", got, exp)
+	}
+
+	// Closing the first connection frees its slot, twice is the same as once.
+	c.Close()
+	c.Close()
+	third, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer third.Close()
+	(<-accepted).Close()
+	if got, exp := atomic.LoadInt64(&rejected), int64(1); got != exp {
+		t.Errorf("unexpected rejected connections got %d exp %d", got, exp)
+	}
+}
+
+func TestLimitListener_SlowReject(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejecting := make(chan struct{})
+	release := make(chan struct{})
+	l := newLimitListener(ln, 1, func(c net.Conn) {
+		close(rejecting)
+		<-release
+	})
+	defer l.Close()
+	defer close(release)
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	first, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	c := <-accepted
+
+	second, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	<-rejecting
+
+	// The next connection is accepted while the rejected connection is still being written to.
+	c.Close()
+	third, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer third.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the connection to be accepted")
+	}
+}
+
+func TestReadErrorStatus(t *testing.T) {
+	if got, exp := readErrorStatus(&net.OpError{Op: "read", Err: timeoutError{}}), http.StatusRequestTimeout; got != exp {
+		t.Errorf("unexpected timeout status got %d exp %d", got, exp)
+	}
+	if got, exp := readErrorStatus(http.ErrBodyReadAfterClose), http.StatusBadRequest; got != exp {
+		t.Errorf("unexpected status got %d exp %d", got, exp)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -1,13 +1,33 @@
 package httpd
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// unixSocketHost is the host logged for peers connected over a unix socket.
+const unixSocketHost = "unix"
+
+const (
+	// reverseDNSTimeout bounds the time spent resolving the name of a remote host.
+	reverseDNSTimeout = time.Second
+	// reverseDNSCacheTTL is how long the name of a remote host, or its lack of one, is cached.
+	reverseDNSCacheTTL = 5 * time.Minute
+	// reverseDNSCacheSize bounds the number of remote hosts cached.
+	reverseDNSCacheSize = 10000
+)
+
+// lookupAddr resolves the names of an address, replaced in tests.
+var lookupAddr = net.DefaultResolver.LookupAddr
+
 // responseLogger is wrapper of http.ResponseWriter that keeps track of its HTTP status
-// code and body size
+// code and body size.
+// It wraps the response outside of any content encoding,
+// so the size is the number of bytes written on the wire, i.e. after gzip compression.
 type responseLogger struct {
 	w      http.ResponseWriter
 	status int
@@ -19,7 +39,9 @@ func (l *responseLogger) Header() http.Header {
 }
 
 func (l *responseLogger) Flush() {
-	l.w.(http.Flusher).Flush()
+	if f, ok := l.w.(http.Flusher); ok {
+		f.Flush()
+	}
 }
 
 func (l *responseLogger) Write(b []byte) (int, error) {
@@ -49,6 +71,11 @@ func (l *responseLogger) Size() int {
 	return l.size
 }
 
+// ContentType returns the content type of the response, as set by the handler.
+func (l *responseLogger) ContentType() string {
+	return l.w.Header().Get("Content-Type")
+}
+
 // redact any occurrence of a password parameter, 'p'
 func redactPassword(r *http.Request) {
 	q := r.URL.Query()
@@ -66,17 +93,13 @@ func redactPassword(r *http.Request) {
 //	   %h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-agent}i\"" %L %D
 //
 // Common Log Format: http://en.wikipedia.org/wiki/Common_Log_Format
-func buildLogLine(d Diagnostic, l *responseLogger, r *http.Request, start time.Time) {
+func buildLogLine(d Diagnostic, l *responseLogger, r *http.Request, start time.Time, reverseDNS bool) {
 
 	redactPassword(r)
 
 	username := parseUsername(r)
 
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-
-	if err != nil {
-		host = r.RemoteAddr
-	}
+	host := remoteHost(r.RemoteAddr, reverseDNS)
 
 	uri := r.URL.RequestURI()
 
@@ -92,6 +115,8 @@ func buildLogLine(d Diagnostic, l *responseLogger, r *http.Request, start time.T
 		uri,
 		r.Proto,
 		l.Status(),
+		l.Size(),
+		detect(l.ContentType(), "-"),
 		detect(referer, "-"),
 		detect(userAgent, "-"),
 		r.Header.Get("Request-Id"),
@@ -100,17 +125,13 @@ func buildLogLine(d Diagnostic, l *responseLogger, r *http.Request, start time.T
 
 }
 
-func buildLogLineError(d Diagnostic, l *responseLogger, r *http.Request, start time.Time, e string) {
+func buildLogLineError(d Diagnostic, l *responseLogger, r *http.Request, start time.Time, e string, reverseDNS bool) {
 
 	redactPassword(r)
 
 	username := parseUsername(r)
 
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-
-	if err != nil {
-		host = r.RemoteAddr
-	}
+	host := remoteHost(r.RemoteAddr, reverseDNS)
 
 	uri := r.URL.RequestURI()
 
@@ -135,6 +156,105 @@ func buildLogLineError(d Diagnostic, l *responseLogger, r *http.Request, start t
 	)
 }
 
+// remoteHost extracts the host of the remote address of a request.
+// IP addresses are normalized, without brackets or port,
+// and peers connected over a unix socket are logged as "unix".
+// When reverseDNS is set the host is replaced by its name, if it has one.
+func remoteHost(addr string, reverseDNS bool) string {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	} else {
+		// The address has no port
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+	if host == "" || host == "@" || strings.HasPrefix(host, "/") {
+		// Unix socket peers are unnamed, abstract or a path.
+		return unixSocketHost
+	}
+	ip := parseHostIP(host)
+	if ip == nil {
+		return host
+	}
+	host = ip.String()
+	if reverseDNS {
+		if name := lookupHostName(host); name != "" {
+			return name
+		}
+	}
+	return host
+}
+
+// parseHostIP parses an IP address, ignoring any IPv6 zone.
+func parseHostIP(host string) net.IP {
+	if i := strings.LastIndexByte(host, '%'); i > 0 {
+		host = host[:i]
+	}
+	return net.ParseIP(host)
+}
+
+// lookupHostName returns the name of the IP address, or an empty string if it has none.
+// Names are cached so that only the first request of a remote host waits on its lookup.
+func lookupHostName(ip string) string {
+	now := time.Now()
+	if name, ok := hostNames.get(ip, now); ok {
+		return name
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reverseDNSTimeout)
+	defer cancel()
+	var name string
+	if names, err := lookupAddr(ctx, ip); err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+	hostNames.set(ip, name, now)
+	return name
+}
+
+var hostNames = newHostNameCache()
+
+// hostNameCache caches the names of IP addresses for reverseDNSCacheTTL.
+type hostNameCache struct {
+	mu      sync.Mutex
+	entries map[string]hostNameEntry
+}
+
+type hostNameEntry struct {
+	name    string
+	expires time.Time
+}
+
+func newHostNameCache() *hostNameCache {
+	return &hostNameCache{
+		entries: make(map[string]hostNameEntry),
+	}
+}
+
+func (c *hostNameCache) get(ip string, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[ip]
+	if !ok || now.After(e.expires) {
+		return "", false
+	}
+	return e.name, true
+}
+
+func (c *hostNameCache) set(ip, name string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= reverseDNSCacheSize {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= reverseDNSCacheSize {
+			c.entries = make(map[string]hostNameEntry)
+		}
+	}
+	c.entries[ip] = hostNameEntry{name: name, expires: now.Add(reverseDNSCacheTTL)}
+}
+
 // detect detects the first presence of a non blank string and returns it
 func detect(values ...string) string {
 	for _, v := range values {
@@ -0,0 +1,197 @@
+package httpd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoteHost(t *testing.T) {
+	testCases := []struct {
+		addr string
+		exp  string
+	}{
+		{addr: "192.168.0.1:4242", exp: "192.168.0.1"},
+		{addr: "192.168.0.1", exp: "192.168.0.1"},
+		{addr: "[::1]:4242", exp: "::1"},
+		{addr: "[::1]", exp: "::1"},
+		{addr: "::1", exp: "::1"},
+		{addr: "[2001:DB8:0:0::1]:4242", exp: "2001:db8::1"},
+		{addr: "2001:db8::1", exp: "2001:db8::1"},
+		{addr: "[fe80::1%eth0]:4242", exp: "fe80::1"},
+		{addr: "[::ffff:10.0.0.1]:4242", exp: "10.0.0.1"},
+		{addr: "", exp: "unix"},
+		{addr: "@", exp: "unix"},
+		{addr: "/var/run/kapacitor.sock", exp: "unix"},
+		{addr: "example.com:4242", exp: "example.com"},
+		{addr: "example.com", exp: "example.com"},
+	}
+	for _, tc := range testCases {
+		if got := remoteHost(tc.addr, false); got != tc.exp {
+			t.Errorf("unexpected host for %q got %q exp %q", tc.addr, got, tc.exp)
+		}
+	}
+}
+
+func TestRemoteHost_ReverseDNS(t *testing.T) {
+	defer func(f func(context.Context, string) ([]string, error)) { lookupAddr = f }(lookupAddr)
+	hostNames = newHostNameCache()
+	lookups := 0
+	lookupAddr = func(_ context.Context, addr string) ([]string, error) {
+		lookups++
+		if addr == "10.0.0.1" {
+			return []string{"host.example.com."}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	if got, exp := remoteHost("10.0.0.1:4242", true), "host.example.com"; got != exp {
+		t.Errorf("unexpected host got %q exp %q", got, exp)
+	}
+	// Hosts without a name are logged by address.
+	if got, exp := remoteHost("[::1]:4242", true), "::1"; got != exp {
+		t.Errorf("unexpected host got %q exp %q", got, exp)
+	}
+	// Unix socket peers are not resolved.
+	if got, exp := remoteHost("@", true), "unix"; got != exp {
+		t.Errorf("unexpected host got %q exp %q", got, exp)
+	}
+	// Names and their absence are cached.
+	if got, exp := remoteHost("10.0.0.1:4243", true), "host.example.com"; got != exp {
+		t.Errorf("unexpected host got %q exp %q", got, exp)
+	}
+	if got, exp := remoteHost("[::1]:4243", true), "::1"; got != exp {
+		t.Errorf("unexpected host got %q exp %q", got, exp)
+	}
+	if lookups != 2 {
+		t.Errorf("unexpected number of lookups got %d exp 2", lookups)
+	}
+}
+
+func TestHostNameCache(t *testing.T) {
+	c := newHostNameCache()
+	now := time.Now()
+	c.set("10.0.0.1", "host.example.com", now)
+	if name, ok := c.get("10.0.0.1", now.Add(reverseDNSCacheTTL)); !ok || name != "host.example.com" {
+		t.Errorf("unexpected cached name got %q %t", name, ok)
+	}
+	if _, ok := c.get("10.0.0.1", now.Add(reverseDNSCacheTTL+time.Second)); ok {
+		t.Error("expected the cached name to expire")
+	}
+	for i := 0; i < reverseDNSCacheSize+1; i++ {
+		c.set(strconv.Itoa(i), "", now)
+	}
+	if len(c.entries) > reverseDNSCacheSize {
+		t.Errorf("unexpected cache size got %d max %d", len(c.entries), reverseDNSCacheSize)
+	}
+}
+
+// hostDiagnostic records the hosts logged.
+type hostDiagnostic struct {
+	Diagnostic
+	hosts []string
+}
+
+func (d *hostDiagnostic) HTTP(host, _ string, _ time.Time, _, _, _ string, _, _ int, _, _, _, _ string, _ time.Duration) {
+	d.hosts = append(d.hosts, host)
+}
+
+func (d *hostDiagnostic) RecoveryError(_, _, host, _ string, _ time.Time, _, _, _ string, _ int, _, _, _ string, _ time.Duration) {
+	d.hosts = append(d.hosts, host)
+}
+
+func TestBuildLogLine_SameHost(t *testing.T) {
+	for _, addr := range []string{"[::1]:4242", "::1", "@", "10.0.0.1"} {
+		d := &hostDiagnostic{}
+		r := httptest.NewRequest("GET", "/kapacitor/v1/ping", nil)
+		r.RemoteAddr = addr
+		l := &responseLogger{w: httptest.NewRecorder()}
+		buildLogLine(d, l, r, time.Now(), false)
+		buildLogLineError(d, l, r, time.Now(), "error", false)
+		if len(d.hosts) != 2 {
+			t.Fatalf("expected two log lines, got %d", len(d.hosts))
+		}
+		if d.hosts[0] != d.hosts[1] {
+			t.Errorf("different hosts logged for %q: %q and %q", addr, d.hosts[0], d.hosts[1])
+		}
+	}
+}
+
+type responseDiagnostic struct {
+	Diagnostic
+	size        int
+	contentType string
+}
+
+func (d *responseDiagnostic) HTTP(_, _ string, _ time.Time, _, _, _ string, _, size int, contentType, _, _, _ string, _ time.Duration) {
+	d.size = size
+	d.contentType = contentType
+}
+
+func TestLogHandler_StreamedResponse(t *testing.T) {
+	d := &responseDiagnostic{}
+	h := logHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		for _, chunk := range []string{"name,tags,time,value\n", "cpu,,1971-01-01T00:00:00Z,1\n", "cpu,,1971-01-01T00:00:01Z,2\n"} {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	}), d, false)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/kapacitor/v1/tasks/cpu/data", nil))
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+	if got, exp := d.size, rec.Body.Len(); got != exp {
+		t.Errorf("unexpected logged size got %d exp %d", got, exp)
+	}
+	if got, exp := d.contentType, "text/csv"; got != exp {
+		t.Errorf("unexpected logged content type got %q exp %q", got, exp)
+	}
+}
+
+func TestLogHandler_ResponseSize(t *testing.T) {
+	body := strings.Repeat("cpu,host=serverA value=1\n", 100)
+	h := func(d Diagnostic) http.Handler {
+		return logHandler(gzipFilter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(body))
+		})), d, false)
+	}
+	for _, tc := range []struct {
+		name           string
+		acceptEncoding string
+		encoding       string
+	}{
+		{name: "plain"},
+		{name: "gzip", acceptEncoding: "gzip", encoding: "gzip"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &responseDiagnostic{}
+			r := httptest.NewRequest("GET", "/kapacitor/v1/tasks/cpu/data", nil)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h(d).ServeHTTP(rec, r)
+			if got, exp := rec.Header().Get("Content-Encoding"), tc.encoding; got != exp {
+				t.Fatalf("unexpected content encoding got %q exp %q", got, exp)
+			}
+			// The logged size is the size of the response as written, compressed or not.
+			if got, exp := d.size, rec.Body.Len(); got != exp {
+				t.Errorf("unexpected logged size got %d exp %d", got, exp)
+			}
+			if tc.encoding == "" && d.size != len(body) {
+				t.Errorf("unexpected logged size of the plain response got %d exp %d", d.size, len(body))
+			}
+			if tc.encoding != "" && d.size >= len(body) {
+				t.Errorf("expected the logged size of the compressed response to be smaller than %d, got %d", len(body), d.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package httpd
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+const (
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	metricsNamespace   = "kapacitor"
+)
+
+// serveMetrics serves the internal statistics in the Prometheus text exposition format.
+func (h *Handler) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	data, err := vars.GetStatsData()
+	if err != nil {
+		HttpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	if err := writeMetrics(w, data); err != nil {
+		h.diag.Error("failed to write metrics", err)
+	}
+}
+
+type metricSample struct {
+	labels string
+	value  string
+}
+
+// writeMetrics writes the statistics in the Prometheus text exposition format.
+//
+// Each statistic value is a metric named kapacitor_<statistic name>_<value name>,
+// labeled with the tags of the statistic, i.e. the task and node of node statistics.
+// The global statistics are named kapacitor_<value name>.
+// Metric names are converted to snake case and all metrics are untyped,
+// as the statistics do not record whether they are counters or gauges.
+func writeMetrics(w io.Writer, data []vars.StatsData) error {
+	metrics := make(map[string][]metricSample)
+	for _, d := range data {
+		prefix := metricsNamespace + "_"
+		if d.Name != metricsNamespace {
+			prefix += metricName(d.Name) + "_"
+		}
+		labels := metricLabels(d.Tags)
+		for k, v := range d.Values {
+			value, ok := metricValue(v)
+			if !ok {
+				continue
+			}
+			name := prefix + metricName(k)
+			metrics[name] = append(metrics[name], metricSample{
+				labels: labels,
+				value:  value,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		samples := metrics[name]
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i].labels < samples[j].labels
+		})
+		bw.WriteString("# TYPE ")
+		bw.WriteString(name)
+		bw.WriteString(" untyped\n")
+		for _, s := range samples {
+			bw.WriteString(name)
+			bw.WriteString(s.labels)
+			bw.WriteByte(' ')
+			bw.WriteString(s.value)
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// metricName converts a statistic name to a valid snake case metric name,
+// i.e. HeapAlloc becomes heap_alloc and points-received becomes points_received.
+func metricName(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		switch {
+		case unicode.IsUpper(r):
+			// Start a new word unless this is part of an acronym, i.e. NumGC becomes num_gc.
+			if i > 0 && rs[i-1] != '_' && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if i == 0 && unicode.IsDigit(r) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// metricLabels formats tags as a sorted Prometheus label set.
+func metricLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(metricName(k))
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(tags[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func metricValue(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), true
+	}
+	return "", false
+}
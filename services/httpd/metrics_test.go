@@ -0,0 +1,81 @@
+package httpd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	data := []vars.StatsData{
+		{
+			Name: "kapacitor",
+			Values: map[string]interface{}{
+				"num_tasks": int64(2),
+				"uptime":    1.5,
+			},
+		},
+		{
+			Name: "nodes",
+			Tags: map[string]string{"task": "cpu", "node": "stream0", "host": "a\"b"},
+			Values: map[string]interface{}{
+				"emitted":           int64(10),
+				"avg_exec_time_ns":  int64(100),
+				"unsupported_value": "x",
+			},
+		},
+		{
+			Name: "nodes",
+			Tags: map[string]string{"task": "cpu", "node": "alert2", "host": "a\"b"},
+			Values: map[string]interface{}{
+				"emitted": int64(3),
+			},
+		},
+		{
+			Name: "runtime",
+			Values: map[string]interface{}{
+				"HeapAlloc": int64(1024),
+				"NumGC":     int64(4),
+			},
+		},
+	}
+	exp := `# TYPE kapacitor_nodes_avg_exec_time_ns untyped
+kapacitor_nodes_avg_exec_time_ns{host="a\"b",node="stream0",task="cpu"} 100
+# TYPE kapacitor_nodes_emitted untyped
+kapacitor_nodes_emitted{host="a\"b",node="alert2",task="cpu"} 3
+kapacitor_nodes_emitted{host="a\"b",node="stream0",task="cpu"} 10
+# TYPE kapacitor_num_tasks untyped
+kapacitor_num_tasks 2
+# TYPE kapacitor_runtime_heap_alloc untyped
+kapacitor_runtime_heap_alloc 1024
+# TYPE kapacitor_runtime_num_gc untyped
+kapacitor_runtime_num_gc 4
+# TYPE kapacitor_uptime untyped
+kapacitor_uptime 1.5
+`
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != exp {
+		t.Errorf("unexpected metrics\ngot:\n%s\nexp:\n%s", got, exp)
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	testCases := map[string]string{
+		"emitted":         "emitted",
+		"HeapAlloc":       "heap_alloc",
+		"NumGC":           "num_gc",
+		"PauseTotalNs":    "pause_total_ns",
+		"points-received": "points_received",
+		"cluster_id":      "cluster_id",
+		"9lives":          "_9lives",
+	}
+	for name, exp := range testCases {
+		if got := metricName(name); got != exp {
+			t.Errorf("unexpected metric name for %q got %q exp %q", name, got, exp)
+		}
+	}
+}
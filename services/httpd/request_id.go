@@ -0,0 +1,98 @@
+package httpd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/influxdb/uuid"
+)
+
+// Formats of the generated request IDs.
+const (
+	// RequestIDFormatUUID generates time based UUIDs, i.e. 8b57288c-c7d1-11f1-8001-9e2f869c9c83.
+	RequestIDFormatUUID = "uuid"
+	// RequestIDFormatULID generates ULIDs, which sort lexically by time, i.e. 01JA2Z3KQ5VH4C8N6W0XG7RMTB.
+	RequestIDFormatULID = "ulid"
+)
+
+// maxRequestIDLength is the maximum length of a request ID supplied by a client.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the request of the context, empty if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID sets the Request-Id header of the request and the response,
+// and attaches the ID to the context of the request.
+// A Request-Id supplied by the client is kept, otherwise an ID of the format is generated.
+func requestID(inner http.Handler, format string) http.Handler {
+	generate := newTimeUUID
+	if format == RequestIDFormatULID {
+		generate = newULID
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("Request-Id")
+		if !validRequestID(id) {
+			id = generate()
+			r.Header.Set("Request-Id", id)
+		}
+		w.Header().Set("Request-Id", id)
+
+		inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// validRequestID reports whether a request ID supplied by a client can be kept,
+// it must not be empty nor too long, and only have printable ASCII characters so it is safe to log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newTimeUUID() string {
+	return uuid.TimeUUID().String()
+}
+
+// crockfordBase32 is the alphabet of ULIDs, without I, L, O and U.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID generates a ULID, a 48 bit timestamp in milliseconds followed by 80 random bits,
+// encoded in 26 characters of Crockford's base32.
+func newULID() string {
+	var id [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint64(id[:8], ms<<16)
+	if _, err := rand.Read(id[6:]); err != nil {
+		// The random source is unavailable, fallback to a UUID which is unique anyway.
+		return newTimeUUID()
+	}
+	return encodeULID(id)
+}
+
+// encodeULID encodes the 128 bits of the ID in 26 characters of 5 bits,
+// the first character holds the 3 most significant bits.
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,105 @@
+package httpd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		header   string
+		keep     bool
+		generate func(id string) bool
+	}{
+		{
+			name:   "client id",
+			format: RequestIDFormatUUID,
+			header: "client-1234",
+			keep:   true,
+		},
+		{
+			name:   "uuid",
+			format: RequestIDFormatUUID,
+			generate: func(id string) bool {
+				return len(id) == 36 && strings.Count(id, "-") == 4
+			},
+		},
+		{
+			name:   "ulid",
+			format: RequestIDFormatULID,
+			generate: func(id string) bool {
+				return len(id) == 26 && strings.Trim(id, crockfordBase32) == ""
+			},
+		},
+		{
+			name:   "invalid client id",
+			format: RequestIDFormatULID,
+			header: "client\n1234",
+			generate: func(id string) bool {
+				return len(id) == 26
+			},
+		},
+		{
+			name:   "too long client id",
+			format: RequestIDFormatUUID,
+			header: strings.Repeat("a", maxRequestIDLength+1),
+			generate: func(id string) bool {
+				return len(id) == 36
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ctxID, headerID string
+			h := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = RequestIDFromContext(r.Context())
+				headerID = r.Header.Get("Request-Id")
+			}), tc.format)
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Request-Id", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get("Request-Id")
+			if ctxID != id || headerID != id {
+				t.Errorf("unexpected request ids, response %q context %q header %q", id, ctxID, headerID)
+			}
+			// The logger reads the ID from the header of the request.
+			if got := req.Header.Get("Request-Id"); got != id {
+				t.Errorf("unexpected logged request id got %q exp %q", got, id)
+			}
+			if tc.keep {
+				if id != tc.header {
+					t.Errorf("unexpected request id got %q exp %q", id, tc.header)
+				}
+			} else if !tc.generate(id) {
+				t.Errorf("unexpected generated request id %q", id)
+			}
+		})
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var zero, max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	if got, exp := encodeULID(zero), "00000000000000000000000000"; got != exp {
+		t.Errorf("unexpected ULID got %s exp %s", got, exp)
+	}
+	if got, exp := encodeULID(max), "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"; got != exp {
+		t.Errorf("unexpected ULID got %s exp %s", got, exp)
+	}
+	// ULIDs generated later sort after earlier ones, once the millisecond changed.
+	a := encodeULID([16]byte{0, 0, 0, 0, 0, 1})
+	b := encodeULID([16]byte{0, 0, 0, 0, 0, 2})
+	if a >= b {
+		t.Errorf("expected %s to sort before %s", a, b)
+	}
+}
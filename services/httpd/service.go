@@ -33,6 +33,8 @@ type Diagnostic interface {
 		uri string,
 		proto string,
 		status int,
+		size int,
+		contentType string,
 		referer string,
 		userAgent string,
 		reqID string,
@@ -64,6 +66,7 @@ type Service struct {
 	cert      string
 	tlsConfig *tls.Config
 	key       string
+	certs     []CertificateConfig
 	err       chan error
 
 	externalURL string
@@ -79,6 +82,12 @@ type Service struct {
 	stop            chan chan struct{}
 	shutdownTimeout time.Duration
 
+	maxConnections    int
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+
 	Handler *Handler
 	// LocalHandler handler is used internally only for the local transport clients.
 	// It does not have authentication enabled.
@@ -107,19 +116,33 @@ func NewService(c Config, hostname string, t *tls.Config, d Diagnostic) *Service
 		https:           c.HttpsEnabled,
 		cert:            c.HttpsCertificate,
 		key:             c.HTTPSPrivateKey,
+		certs:           c.HTTPSCertificates,
 		externalURL:     u.String(),
 		err:             make(chan error, 1),
 		tlsConfig:       t,
 		shutdownTimeout: time.Duration(c.ShutdownTimeout),
+
+		maxConnections:    c.MaxConnections,
+		readHeaderTimeout: time.Duration(c.ReadHeaderTimeout),
+		readTimeout:       time.Duration(c.ReadTimeout),
+		writeTimeout:      time.Duration(c.WriteTimeout),
+		idleTimeout:       time.Duration(c.IdleTimeout),
+
 		Handler: NewHandler(
 			c.AuthEnabled,
 			c.PprofEnabled,
+			c.MetricsAuthEnabled,
 			c.LogEnabled,
+			c.LogReverseDNS,
 			c.WriteTracing,
 			c.GZIP,
 			statMap,
 			d,
 			c.SharedSecret,
+			c.JSONFloatPrecision,
+			c.WriteParseWorkers,
+			c.MaxWriteLines,
+			c.RequestIDFormat,
 		),
 		LocalHandler: NewHandler(
 			false,
@@ -127,9 +150,15 @@ func NewService(c Config, hostname string, t *tls.Config, d Diagnostic) *Service
 			false,
 			false,
 			false,
+			false,
+			false,
 			localStatMap,
 			d,
 			"",
+			0,
+			0,
+			0,
+			RequestIDFormatUUID,
 		),
 		diag:                  d,
 		httpServerErrorLogger: d.NewHTTPServerErrorLogger(),
@@ -148,37 +177,56 @@ func (s *Service) Open() error {
 	s.diag.StartingService()
 	s.diag.AuthenticationEnabled(s.Handler.requireAuthentication)
 
-	// Open listener.
+	var tlsConfig *tls.Config
 	if s.https {
-		cert, err := tls.LoadX509KeyPair(s.cert, s.key)
+		certs, err := loadCertificates(s.cert, s.key, s.certs)
 		if err != nil {
 			return err
 		}
 
-		tlsConfig := s.tlsConfig.Clone()
-		tlsConfig.Certificates = []tls.Certificate{cert}
-		listener, err := tls.Listen("tcp", s.addr, tlsConfig)
-		if err != nil {
-			return err
+		tlsConfig = s.tlsConfig.Clone()
+		tlsConfig.Certificates = []tls.Certificate{*certs.def}
+		if len(s.certs) > 0 {
+			tlsConfig.GetCertificate = certs.getCertificate
 		}
+	}
 
+	// Open listener.
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if s.maxConnections > 0 {
+		// Limit the connections before the TLS handshake,
+		// so rejected connections do not cost a handshake.
+		reject := func(net.Conn) {
+			s.Handler.statMap.Add(statConnectionsRejected, 1)
+		}
+		if !s.https {
+			reject = func(c net.Conn) {
+				s.Handler.statMap.Add(statConnectionsRejected, 1)
+				writeRejectResponse(c)
+			}
+		}
+		listener = newLimitListener(listener, s.maxConnections, reject)
+	}
+	if s.https {
 		s.diag.ListeningOn(listener.Addr().String(), "https")
-		s.ln = listener
+		s.ln = tls.NewListener(listener, tlsConfig)
 	} else {
-		listener, err := net.Listen("tcp", s.addr)
-		if err != nil {
-			return err
-		}
-
 		s.diag.ListeningOn(listener.Addr().String(), "http")
 		s.ln = listener
 	}
 
 	// Define server
 	s.server = &http.Server{
-		Handler:   s.Handler,
-		ConnState: s.connStateHandler,
-		ErrorLog:  s.httpServerErrorLogger,
+		Handler:           s.Handler,
+		ConnState:         s.connStateHandler,
+		ErrorLog:          s.httpServerErrorLogger,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		ReadTimeout:       s.readTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
 	}
 
 	s.new = make(chan net.Conn)
@@ -0,0 +1,93 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// How many array elements are written between flushes of the response.
+const arrayWriterFlushCount = 100
+
+// ArrayWriter writes a JSON object with a single array property incrementally,
+// so that large lists are sent as they are produced instead of being buffered in memory.
+// The output is identical to MarshalJSON of the equivalent object.
+type ArrayWriter struct {
+	w      http.ResponseWriter
+	pretty bool
+	count  int
+	err    error
+}
+
+// NewArrayWriter starts a JSON object with the array property key.
+// Close must be called to complete the object.
+func NewArrayWriter(w http.ResponseWriter, key string, pretty bool) *ArrayWriter {
+	a := &ArrayWriter{
+		w:      w,
+		pretty: pretty,
+	}
+	k, _ := json.Marshal(key)
+	if pretty {
+		a.write([]byte("{\n    "))
+		a.write(k)
+		a.write([]byte(": ["))
+	} else {
+		a.write([]byte("{"))
+		a.write(k)
+		a.write([]byte(":["))
+	}
+	return a
+}
+
+func (a *ArrayWriter) write(b []byte) {
+	if a.err != nil {
+		return
+	}
+	_, a.err = a.w.Write(b)
+}
+
+// Write appends v to the array.
+// Once a write has failed all subsequent writes return the same error.
+func (a *ArrayWriter) Write(v interface{}) error {
+	var b []byte
+	var err error
+	if a.pretty {
+		b, err = json.MarshalIndent(v, "        ", "    ")
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	if a.count > 0 {
+		a.write([]byte(","))
+	}
+	if a.pretty {
+		a.write([]byte("\n        "))
+	}
+	a.write(b)
+	a.count++
+	if a.count%arrayWriterFlushCount == 0 {
+		a.Flush()
+	}
+	return a.err
+}
+
+// Flush sends any buffered data to the client.
+func (a *ArrayWriter) Flush() {
+	if f, ok := a.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close ends the array and the object.
+func (a *ArrayWriter) Close() error {
+	if a.pretty {
+		if a.count > 0 {
+			a.write([]byte("\n    "))
+		}
+		a.write([]byte("]\n}"))
+	} else {
+		a.write([]byte("]}"))
+	}
+	return a.err
+}
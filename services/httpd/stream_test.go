@@ -0,0 +1,55 @@
+package httpd
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestArrayWriter(t *testing.T) {
+	type item struct {
+		ID   string            `json:"id"`
+		Tags map[string]string `json:"tags"`
+	}
+	type response struct {
+		Items []item `json:"items"`
+	}
+	testCases := []struct {
+		name  string
+		items []item
+	}{
+		{
+			name:  "empty",
+			items: []item{},
+		},
+		{
+			name:  "one",
+			items: []item{{ID: "a", Tags: map[string]string{"host": "serverA"}}},
+		},
+		{
+			name: "many",
+			items: []item{
+				{ID: "a"},
+				{ID: "b", Tags: map[string]string{"host": "serverB"}},
+				{ID: "c", Tags: map[string]string{}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		for _, pretty := range []bool{true, false} {
+			rec := httptest.NewRecorder()
+			aw := NewArrayWriter(rec, "items", pretty)
+			for _, i := range tc.items {
+				if err := aw.Write(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			exp := string(MarshalJSON(response{tc.items}, pretty))
+			if got := rec.Body.String(); got != exp {
+				t.Errorf("%s pretty=%v: unexpected output:\ngot\n%s\nexp\n%s", tc.name, pretty, got, exp)
+			}
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package httpd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+)
+
+// minWriteParseChunk is the smallest number of bytes of a write body parsed by a worker,
+// so that small bodies are parsed without the overhead of the workers.
+var minWriteParseChunk = 64 * 1024
+
+// tooManyLinesError is returned when a write body has more lines than the max-write-lines.
+type tooManyLinesError struct {
+	max int
+}
+
+func (e tooManyLinesError) Error() string {
+	return fmt.Sprintf("write has more than the maximum of %d lines", e.max)
+}
+
+// writePrecision returns the precision of the parser for the precision of a write,
+// which is the unit of the timestamps of the points and the precision the times of the points without timestamp are truncated to.
+// The precisions default to nanoseconds, and are named as by InfluxDB or as Go durations, i.e. u or us for microseconds.
+func writePrecision(precision string) (string, error) {
+	switch precision {
+	case "", "n", "ns":
+		return "n", nil
+	case "u", "us", "µs":
+		return "u", nil
+	case "ms", "s", "m", "h":
+		return precision, nil
+	default:
+		return "", fmt.Errorf("invalid precision %q, must be one of n, u, ms, s, m or h", precision)
+	}
+}
+
+// writeChunk is a range of lines of a write body, parsed by a worker.
+type writeChunk struct {
+	start, end int
+
+	// next is the position after the last line scanned from the start of the chunk,
+	// the end of the chunk unless the chunk ends within a quoted string.
+	next int
+	// newlines is the number of newlines of the chunk.
+	newlines int
+
+	points []models.Point
+	// failed are the lines that fail to parse and their line numbers relative to the chunk.
+	failed []writeLineError
+}
+
+// writeLineError is the error of a line that fails to parse.
+type writeLineError struct {
+	line int
+	err  error
+}
+
+func (e writeLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e writeLineError) Unwrap() error {
+	return e.err
+}
+
+// writeParseError is the error of a write body with lines that fail to parse, one per line.
+type writeParseError []writeLineError
+
+func (e writeParseError) Error() string {
+	lines := make([]string, len(e))
+	for i, l := range e {
+		lines[i] = l.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Is reports whether the error of any of the lines is target.
+func (e writeParseError) Is(target error) bool {
+	for _, l := range e {
+		if errors.Is(l, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWritePoints parses the line protocol of a write body with up to workers goroutines.
+//
+// The body is split at newlines into chunks that are scanned and parsed concurrently,
+// and the points are returned in the order of the body.
+// A chunk is valid if the lines of the previous chunk end at its start,
+// otherwise the newline splitting them is within a quoted string and the body is parsed as a single chunk.
+// The errors report the line numbers of the lines that fail to parse in the body.
+// When maxLines is positive, a body with more lines of points than maxLines fails with a tooManyLinesError,
+// the lines are then counted before any is parsed and counting stops at the first line over the limit.
+func parseWritePoints(body []byte, now time.Time, precision string, workers, maxLines int) ([]models.Point, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunkSize := len(body) / workers
+	if chunkSize < minWriteParseChunk {
+		chunkSize = minWriteParseChunk
+	}
+	var chunks []*writeChunk
+	if maxLines > 0 {
+		var err error
+		if chunks, err = scanWriteChunks(body, chunkSize, maxLines); err != nil {
+			return nil, err
+		}
+	} else {
+		chunks = splitWriteChunks(body, chunkSize)
+	}
+
+	if len(chunks) > 1 {
+		// The chunks are parsed without scanning when they were split at the ends of the lines.
+		scan := maxLines <= 0
+		var wg sync.WaitGroup
+		wg.Add(len(chunks))
+		for _, c := range chunks {
+			go func(c *writeChunk) {
+				defer wg.Done()
+				c.parse(body, now, precision, scan)
+			}(c)
+		}
+		wg.Wait()
+		for _, c := range chunks[:len(chunks)-1] {
+			if c.next != c.end {
+				chunks = []*writeChunk{{start: 0, end: len(body)}}
+				break
+			}
+		}
+	}
+	if len(chunks) == 1 {
+		chunks[0].parse(body, now, precision, false)
+	}
+
+	count := 0
+	for _, c := range chunks {
+		count += len(c.points)
+	}
+	var failed writeParseError
+	line := 1
+	for _, c := range chunks {
+		for _, f := range c.failed {
+			failed = append(failed, writeLineError{line: line + f.line, err: f.err})
+		}
+		line += c.newlines
+	}
+	if len(failed) > 0 {
+		return nil, failed
+	}
+	if len(chunks) == 1 {
+		return chunks[0].points, nil
+	}
+	points := make([]models.Point, 0, count)
+	for _, c := range chunks {
+		points = append(points, c.points...)
+	}
+	return points, nil
+}
+
+// splitWriteChunks splits the body at the first newlines after every chunkSize bytes.
+// The newlines may be within quoted strings, the chunks are validated when they are scanned.
+func splitWriteChunks(body []byte, chunkSize int) []*writeChunk {
+	var chunks []*writeChunk
+	for start := 0; start < len(body) || len(chunks) == 0; {
+		end := len(body)
+		if start+chunkSize < len(body) {
+			if i := bytes.IndexByte(body[start+chunkSize:], '\n'); i >= 0 {
+				end = start + chunkSize + i + 1
+			}
+		}
+		chunks = append(chunks, &writeChunk{start: start, end: end})
+		start = end
+	}
+	return chunks
+}
+
+// scanWriteChunks scans the lines of the body and splits it at the ends of the first lines after every chunkSize bytes.
+// It fails with a tooManyLinesError as soon as the body has more than maxLines lines of points.
+func scanWriteChunks(body []byte, chunkSize, maxLines int) ([]*writeChunk, error) {
+	var chunks []*writeChunk
+	lines := 0
+	start := 0
+	for pos := 0; pos < len(body); {
+		end := scanWriteLine(body, pos)
+		if isPointLine(body[pos:end]) {
+			lines++
+			if lines > maxLines {
+				return nil, tooManyLinesError{max: maxLines}
+			}
+		}
+		pos = end + 1
+		if pos > len(body) {
+			pos = len(body)
+		}
+		if pos-start >= chunkSize || pos == len(body) {
+			chunks = append(chunks, &writeChunk{start: start, end: pos, next: pos})
+			start = pos
+		}
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, &writeChunk{start: 0, end: len(body)})
+	}
+	return chunks, nil
+}
+
+// parse parses the points of the chunk, scanning its lines first if scan is set.
+// If any line fails to parse the lines are parsed one by one, to report the line numbers of the failures.
+func (c *writeChunk) parse(body []byte, now time.Time, precision string, scan bool) {
+	c.newlines = bytes.Count(body[c.start:c.end], []byte{'\n'})
+	if scan {
+		c.scan(body)
+	}
+
+	buf := body[c.start:c.end]
+	var err error
+	c.points, err = models.ParsePointsWithPrecision(buf, now, precision)
+	c.failed = nil
+	if err == nil {
+		return
+	}
+	c.points = nil
+	line := 0
+	for pos := 0; pos < len(buf); {
+		end := scanWriteLine(buf, pos)
+		if _, err := models.ParsePointsWithPrecision(buf[pos:end], now, precision); err != nil {
+			c.failed = append(c.failed, writeLineError{line: line, err: err})
+		}
+		line += bytes.Count(buf[pos:end], []byte{'\n'}) + 1
+		pos = end + 1
+	}
+	if len(c.failed) == 0 {
+		// The lines of a chunk ending within a quoted string only fail to parse as a whole.
+		c.failed = []writeLineError{{err: err}}
+	}
+}
+
+// scan sets the position after the last line of the chunk.
+func (c *writeChunk) scan(body []byte) {
+	pos := c.start
+	for pos < c.end {
+		pos = scanWriteLine(body, pos) + 1
+	}
+	c.next = pos
+	if c.next > len(body) {
+		c.next = len(body)
+	}
+}
+
+// isPointLine returns whether the line is a point, i.e. is not blank or a comment.
+func isPointLine(line []byte) bool {
+	line = bytes.TrimLeft(line, " \t\r")
+	return len(line) > 0 && line[0] != '#'
+}
+
+// scanWriteLine returns the position of the newline ending the line starting at i, or the length of buf.
+// As when the points are parsed, newlines within the quoted string field values do not end the line.
+func scanWriteLine(buf []byte, i int) int {
+	quoted := false
+	fields := false
+	equals := 0
+	commas := 0
+	for i < len(buf) {
+		// skip past escaped characters
+		if buf[i] == '\\' && i+2 < len(buf) {
+			i += 2
+			continue
+		}
+		if buf[i] == ' ' {
+			fields = true
+		}
+		if fields {
+			if !quoted && buf[i] == '=' {
+				i++
+				equals++
+				continue
+			} else if !quoted && buf[i] == ',' {
+				i++
+				commas++
+				continue
+			} else if buf[i] == '"' && equals > commas {
+				i++
+				quoted = !quoted
+				continue
+			}
+		}
+		if buf[i] == '\n' && !quoted {
+			break
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,227 @@
+package httpd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWritePoints(t *testing.T) {
+	defer func(size int) { minWriteParseChunk = size }(minWriteParseChunk)
+	minWriteParseChunk = 1
+
+	var body bytes.Buffer
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&body, "cpu,host=h%d value=%d %d\n", i, i, i)
+		if i%10 == 0 {
+			body.WriteString("# comment\n\n")
+		}
+	}
+	body.WriteString("logs message=\"line one\nline two\" 100\n")
+
+	for _, workers := range []int{1, 4, 16} {
+		points, err := parseWritePoints(body.Bytes(), time.Now(), "n", workers, 200)
+		if err != nil {
+			t.Fatalf("%d workers: unexpected error %v", workers, err)
+		}
+		if got, exp := len(points), 101; got != exp {
+			t.Fatalf("%d workers: unexpected number of points got %d exp %d", workers, got, exp)
+		}
+		for i, p := range points[:100] {
+			if got, exp := p.UnixNano(), int64(i); got != exp {
+				t.Errorf("%d workers: unexpected order of point %d got time %d", workers, i, got)
+			}
+		}
+		fields, err := points[100].Fields()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := fields["message"], "line one\nline two"; got != exp {
+			t.Errorf("%d workers: unexpected message got %q exp %q", workers, got, exp)
+		}
+	}
+}
+
+func TestParseWritePoints_LineNumbers(t *testing.T) {
+	defer func(size int) { minWriteParseChunk = size }(minWriteParseChunk)
+	minWriteParseChunk = 1
+
+	testCases := []struct {
+		body string
+		exp  string
+	}{
+		{
+			body: `cpu value=1 1
+cpu value=2 2
+cpu value= 3
+
+# comment
+cpu value=4 4
+cpu,host value=5 5
+`,
+			exp: `line 3: unable to parse 'cpu value= 3': missing field value
+line 7: unable to parse 'cpu,host value=5 5': missing tag value`,
+		},
+		{
+			// The newline in the quoted string is not a line of its own, but counts in the line numbers.
+			body: `cpu value=1 1
+logs message="a
+b" 2
+cpu value= 3
+`,
+			exp: `line 4: unable to parse 'cpu value= 3': missing field value`,
+		},
+	}
+	for i, tc := range testCases {
+		for _, workers := range []int{1, 3} {
+			_, err := parseWritePoints([]byte(tc.body), time.Now(), "n", workers, 0)
+			if err == nil {
+				t.Fatalf("%d %d workers: expected error", i, workers)
+			}
+			if got := err.Error(); got != tc.exp {
+				t.Errorf("%d %d workers: unexpected error got\n%s\nexp\n%s", i, workers, got, tc.exp)
+			}
+		}
+	}
+}
+
+func TestWriteParseError_Is(t *testing.T) {
+	err := writeParseError{
+		{line: 1, err: errors.New("missing field value")},
+		{line: 3, err: io.EOF},
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Error("expected the error of a line to match")
+	}
+	if errors.Is(err[:1], io.EOF) {
+		t.Error("unexpected match of the error of a line")
+	}
+	if got, exp := err.Error(), "line 1: missing field value\nline 3: EOF"; got != exp {
+		t.Errorf("unexpected error got %q exp %q", got, exp)
+	}
+}
+
+func TestParseWritePoints_MaxLines(t *testing.T) {
+	defer func(size int) { minWriteParseChunk = size }(minWriteParseChunk)
+	minWriteParseChunk = 1
+
+	body := []byte("cpu value=1 1\n# comment\n\ncpu value=2 2\ncpu value=3 3\n")
+	for _, workers := range []int{1, 3} {
+		if _, err := parseWritePoints(body, time.Now(), "n", workers, 3); err != nil {
+			t.Errorf("%d workers: unexpected error %v", workers, err)
+		}
+		_, err := parseWritePoints(body, time.Now(), "n", workers, 2)
+		if _, ok := err.(tooManyLinesError); !ok {
+			t.Fatalf("%d workers: unexpected error got %v exp tooManyLinesError", workers, err)
+		}
+		if got, exp := err.Error(), "write has more than the maximum of 2 lines"; !strings.Contains(got, exp) {
+			t.Errorf("%d workers: unexpected error got %q exp %q", workers, got, exp)
+		}
+	}
+}
+
+func TestParseWritePoints_MaxLinesBeforeParsing(t *testing.T) {
+	defer func(size int) { minWriteParseChunk = size }(minWriteParseChunk)
+	minWriteParseChunk = 1
+
+	// The lines after the limit are not parsed, so their errors are not reported.
+	body := []byte("cpu value=1 1\ncpu value=2 2\ncpu value=3 3\nnot a point\n")
+	for _, workers := range []int{1, 3} {
+		_, err := parseWritePoints(body, time.Now(), "n", workers, 2)
+		if _, ok := err.(tooManyLinesError); !ok {
+			t.Errorf("%d workers: unexpected error got %v exp tooManyLinesError", workers, err)
+		}
+	}
+
+	// The chunks are split at the ends of the lines, not at the newlines within quoted strings.
+	body = []byte("cpu value=\"a\nb\" 1\ncpu value=\"c\" 2\n")
+	for _, workers := range []int{1, 3} {
+		points, err := parseWritePoints(body, time.Now(), "n", workers, 2)
+		if err != nil {
+			t.Fatalf("%d workers: unexpected error %v", workers, err)
+		}
+		if len(points) != 2 {
+			t.Fatalf("%d workers: unexpected number of points got %d exp 2", workers, len(points))
+		}
+		fields, err := points[0].Fields()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := fields["value"], "a\nb"; got != exp {
+			t.Errorf("%d workers: unexpected value got %q exp %q", workers, got, exp)
+		}
+	}
+}
+
+// BenchmarkParseWritePoints compares parsing with a single worker and with a worker per CPU,
+// run it with -cpu 1,2,4,8 to see the scaling with the number of CPUs.
+func BenchmarkParseWritePoints(b *testing.B) {
+	var body bytes.Buffer
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&body, "cpu,host=server%d,region=us-west usage_user=%d.5,usage_system=%di,state=\"ok\" %d\n", i%100, i, i, i)
+	}
+	for _, bc := range []struct {
+		name    string
+		workers int
+	}{
+		{name: "single", workers: 1},
+		{name: "cpus", workers: 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(body.Len()))
+			for i := 0; i < b.N; i++ {
+				if _, err := parseWritePoints(body.Bytes(), time.Now(), "n", bc.workers, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWritePoints_Precision(t *testing.T) {
+	now := time.Date(2017, 1, 2, 3, 4, 5, 678901234, time.UTC)
+	testCases := []struct {
+		precision string
+		timestamp string
+		exp       time.Time
+		// The time of the points without timestamp, truncated to the precision.
+		expNow time.Time
+	}{
+		{precision: "", timestamp: "1500000000123456789", exp: time.Unix(0, 1500000000123456789), expNow: now},
+		{precision: "n", timestamp: "1500000000123456789", exp: time.Unix(0, 1500000000123456789), expNow: now},
+		{precision: "ns", timestamp: "1500000000123456789", exp: time.Unix(0, 1500000000123456789), expNow: now},
+		{precision: "u", timestamp: "1500000000123456", exp: time.Unix(0, 1500000000123456000), expNow: now.Truncate(time.Microsecond)},
+		{precision: "us", timestamp: "1500000000123456", exp: time.Unix(0, 1500000000123456000), expNow: now.Truncate(time.Microsecond)},
+		{precision: "µs", timestamp: "1500000000123456", exp: time.Unix(0, 1500000000123456000), expNow: now.Truncate(time.Microsecond)},
+		{precision: "ms", timestamp: "1500000000123", exp: time.Unix(0, 1500000000123000000), expNow: now.Truncate(time.Millisecond)},
+		{precision: "s", timestamp: "1500000000", exp: time.Unix(1500000000, 0), expNow: now.Truncate(time.Second)},
+		{precision: "m", timestamp: "25000000", exp: time.Unix(1500000000, 0), expNow: now.Truncate(time.Minute)},
+		{precision: "h", timestamp: "416666", exp: time.Unix(416666*3600, 0), expNow: now.Truncate(time.Hour)},
+	}
+	for _, tc := range testCases {
+		precision, err := writePrecision(tc.precision)
+		if err != nil {
+			t.Fatalf("precision %q: unexpected error %v", tc.precision, err)
+		}
+		body := fmt.Sprintf("cpu value=1 %s\ncpu value=2\n", tc.timestamp)
+		points, err := parseWritePoints([]byte(body), now, precision, 1, 0)
+		if err != nil {
+			t.Fatalf("precision %q: unexpected error %v", tc.precision, err)
+		}
+		if got, exp := points[0].Time(), tc.exp; !got.Equal(exp) {
+			t.Errorf("precision %q: unexpected time got %v exp %v", tc.precision, got, exp)
+		}
+		if got, exp := points[1].Time(), tc.expNow; !got.Equal(exp) {
+			t.Errorf("precision %q: unexpected default time got %v exp %v", tc.precision, got, exp)
+		}
+	}
+	for _, p := range []string{"x", "d", "1s", "N"} {
+		if _, err := writePrecision(p); err == nil {
+			t.Errorf("precision %q: expected error", p)
+		}
+	}
+}
@@ -2,13 +2,12 @@ package httppost
 
 import (
 	"bytes"
-	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
+	"net/url"
 	"strings"
 	"sync"
 	"text/template"
@@ -23,6 +22,7 @@ import (
 type Diagnostic interface {
 	WithContext(ctx ...keyvalue.T) Diagnostic
 	Error(msg string, err error, ctx ...keyvalue.T)
+	Info(msg string, ctx ...keyvalue.T)
 }
 
 // Only one of name and url should be non-empty
@@ -263,13 +263,26 @@ type HandlerConfig struct {
 	CaptureResponse     bool              `mapstructure:"capture-response"`
 	Timeout             time.Duration     `mapstructure:"timeout"`
 	SkipSSLVerification bool              `mapstructure:"skip-ssl-verification"`
+
+	// Failover is the ordered list of the endpoints to POST to when the endpoint or URL fails,
+	// each is either the name of a configured endpoint or a URL.
+	Failover []string `mapstructure:"failover"`
+	// Retries is the number of times the POST to an endpoint is retried before failing over to the next endpoint.
+	Retries int `mapstructure:"retries"`
+	// MaxPayloadSize is the maximum size in bytes of the bodies of the POSTs,
+	// larger bodies have the data of the alert dropped and then its details and message truncated.
+	// Zero means no limit.
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 type handler struct {
 	s *Service
 
-	endpoint *Endpoint
-	headers  map[string]string
+	// endpoints are the endpoint or URL followed by the failover endpoints.
+	endpoints []*Endpoint
+	failover  *alert.Failover
+	limit     *alert.PayloadLimit
+	headers   map[string]string
 
 	captureResponse bool
 
@@ -282,20 +295,42 @@ type handler struct {
 
 func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, error) {
 
-	e, ok := s.Endpoint(c.Endpoint)
+	e, name, ok := s.handlerEndpoint(c.Endpoint)
 	if !ok {
 		if c.URL == "" {
 			return nil, errors.New("An appropriately formatted url must be specified if an endpoint is not specified")
 		}
-		tmpl, err := GetTemplate(c.URL, "")
+		var err error
+		e, name, err = urlEndpoint(c.URL)
 		if err != nil {
 			return nil, err
 		}
-		e = NewEndpoint(tmpl, nil, BasicAuth{}, nil, nil)
+	}
+	endpoints := []*Endpoint{e}
+	names := []string{name}
+	for _, f := range c.Failover {
+		e, name, ok := s.handlerEndpoint(f)
+		if !ok {
+			var err error
+			e, name, err = urlEndpoint(f)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid failover endpoint %q", f)
+			}
+		}
+		endpoints = append(endpoints, e)
+		names = append(names, name)
+	}
+	if c.Retries < 0 {
+		return nil, fmt.Errorf("retries must be positive, got %d", c.Retries)
+	}
+	if c.MaxPayloadSize < 0 {
+		return nil, fmt.Errorf("max payload size must be positive, got %d", c.MaxPayloadSize)
 	}
 	return &handler{
 		s:                   s,
-		endpoint:            e,
+		endpoints:           endpoints,
+		failover:            alert.NewFailover("httppost", names, c.Retries),
+		limit:               alert.NewPayloadLimit("httppost", c.MaxPayloadSize),
 		diag:                s.diag.WithContext(ctx...),
 		headers:             c.Headers,
 		captureResponse:     c.CaptureResponse,
@@ -304,8 +339,28 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, er
 	}, nil
 }
 
-func (h *handler) NewHTTPRequest(body io.Reader, tmplCTX interface{}) (req *http.Request, err error) {
-	req, err = h.endpoint.NewHTTPRequest(body, tmplCTX)
+// handlerEndpoint returns the configured endpoint of the name and the name of its stats.
+func (s *Service) handlerEndpoint(name string) (*Endpoint, string, bool) {
+	e, ok := s.Endpoint(name)
+	return e, name, ok
+}
+
+// urlEndpoint returns an endpoint posting to the URL template and the name of its stats,
+// which is the host of the URL so that credentials or tokens in the URL are not exposed.
+func urlEndpoint(u string) (*Endpoint, string, error) {
+	tmpl, err := GetTemplate(u, "")
+	if err != nil {
+		return nil, "", err
+	}
+	name := "url"
+	if pu, err := url.Parse(u); err == nil && pu.Host != "" {
+		name = pu.Host
+	}
+	return NewEndpoint(tmpl, nil, BasicAuth{}, nil, nil), name, nil
+}
+
+func (h *handler) NewHTTPRequest(e *Endpoint, body io.Reader, tmplCTX interface{}) (req *http.Request, err error) {
+	req, err = e.NewHTTPRequest(body, tmplCTX)
 	if err != nil {
 		return
 	}
@@ -318,43 +373,36 @@ func (h *handler) NewHTTPRequest(body io.Reader, tmplCTX interface{}) (req *http
 }
 
 func (h *handler) Handle(event alert.Event) {
-	var err error
-
-	// Construct the body of the HTTP request
-	body := new(bytes.Buffer)
 	ad := event.AlertData()
 
-	var contentType string
-	if h.endpoint.AlertTemplate() != nil {
-		err := h.endpoint.AlertTemplate().Execute(body, ad)
-		if err != nil {
-			h.diag.Error("failed to execute alert template", err)
-			return
-		}
-	} else {
-		err = json.NewEncoder(body).Encode(ad)
-		if err != nil {
-			h.diag.Error("failed to marshal alert data json", err)
-			return
+	// Construct the bodies of the HTTP requests, the endpoints may have different alert templates.
+	// The bodies larger than the max payload size are built from the event truncated to fit.
+	bodies := make([][]byte, len(h.endpoints))
+	contentTypes := make([]string, len(h.endpoints))
+	for i, e := range h.endpoints {
+		var err error
+		if e.AlertTemplate() != nil {
+			_, bodies[i], err = h.limit.Fit(event, func(ev alert.Event) ([]byte, error) {
+				body := new(bytes.Buffer)
+				err := e.AlertTemplate().Execute(body, ev.AlertData())
+				return body.Bytes(), err
+			})
+			if err != nil {
+				h.diag.Error("failed to execute alert template", err)
+				return
+			}
+		} else {
+			_, bodies[i], err = h.limit.Fit(event, func(ev alert.Event) ([]byte, error) {
+				body := new(bytes.Buffer)
+				err := json.NewEncoder(body).Encode(ev.AlertData())
+				return body.Bytes(), err
+			})
+			if err != nil {
+				h.diag.Error("failed to marshal alert data json", err)
+				return
+			}
+			contentTypes[i] = "application/json"
 		}
-		contentType = "application/json"
-	}
-
-	req, err := h.NewHTTPRequest(body, ad)
-	if err != nil {
-		h.diag.Error("failed to create HTTP request", err)
-		return
-	}
-
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
-	}
-
-	// Set timeout
-	if h.timeout > 0 {
-		ctx, cancel := context.WithTimeout(req.Context(), h.timeout)
-		defer cancel()
-		req = req.WithContext(ctx)
 	}
 
 	// Setup HTTP client
@@ -366,12 +414,43 @@ func (h *handler) Handle(event alert.Event) {
 	}
 
 	httpClient := khttp.NewDefaultClientWithTLS(tlsConfig, khttp.DefaultValidator)
+	// The timeout applies to each attempt and replaces the default timeout of the client,
+	// an attempt that times out is retried as any failed attempt.
+	if h.timeout > 0 {
+		httpClient.Timeout = h.timeout
+	}
+
+	i, err := h.failover.Deliver(func(i int) error {
+		return h.post(httpClient, h.endpoints[i], bodies[i], contentTypes[i], ad)
+	}, func(endpoint string, err error) {
+		h.diag.Error("failed to POST alert data", err, keyvalue.KV("endpoint", endpoint))
+	})
+	if err != nil {
+		if len(h.endpoints) > 1 {
+			h.diag.Error("failed to POST alert data to all failover endpoints", err)
+		}
+		return
+	}
+	if i > 0 {
+		h.diag.Info("POST alert data to failover endpoint", keyvalue.KV("endpoint", h.failover.Endpoint(i)))
+	}
+}
+
+// post POSTs the body to the endpoint, returning an error if the request fails or does not return a 2xx status code.
+func (h *handler) post(httpClient *http.Client, e *Endpoint, body []byte, contentType string, ad alert.Data) error {
+	req, err := h.NewHTTPRequest(e, bytes.NewReader(body), ad)
+	if err != nil {
+		return errors.Wrap(err, "failed to create HTTP request")
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	// Execute the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		h.diag.Error("failed to POST alert data", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -387,7 +466,11 @@ func (h *handler) Handle(event alert.Event) {
 		} else {
 			err = errors.New("unknown error, use .captureResponse() to capture the HTTP response")
 		}
-		h.diag.Error("POST returned non 2xx status code", err, keyvalue.KV("code", strconv.Itoa(resp.StatusCode)))
+		return errors.Wrapf(err, "POST returned non 2xx status code %d", resp.StatusCode)
 	}
+	return nil
+}
 
+func (h *handler) RendersRunbook() bool {
+	return true
 }
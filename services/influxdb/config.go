@@ -16,8 +16,12 @@ const (
 	// Maximum time to try and connect to InfluxDB during startup.
 	DefaultStartUpTimeout           = 5 * time.Minute
 	DefaultSubscriptionSyncInterval = 1 * time.Minute
+	// Initial time to wait to retry a failed subscriptions sync.
+	DefaultSubscriptionSyncRetryInterval = 5 * time.Second
 
 	DefaultSubscriptionProtocol = "http"
+
+	DefaultAPIVersion = 1
 )
 
 type SubscriptionMode int
@@ -59,10 +63,21 @@ type Config struct {
 	UDPBind                  string              `toml:"udp-bind" override:"udp-bind"`
 	UDPBuffer                int                 `toml:"udp-buffer" override:"udp-buffer"`
 	UDPReadBuffer            int                 `toml:"udp-read-buffer" override:"udp-read-buffer"`
+	UDPOverflowPolicy        string              `toml:"udp-overflow-policy" override:"udp-overflow-policy"`
 	StartUpTimeout           toml.Duration       `toml:"startup-timeout" override:"startup-timeout"`
 	SubscriptionSyncInterval toml.Duration       `toml:"subscriptions-sync-interval" override:"subscriptions-sync-interval"`
 	SubscriptionPath         string              `toml:"subscription-path" override:"subscription-path"`
 	Compression              string              `toml:"compression" override:"compression"`
+
+	// SubscriptionSyncRetryInterval is the initial time to wait to retry a failed subscriptions sync,
+	// doubled on each consecutive failure up to the sync interval.
+	SubscriptionSyncRetryInterval toml.Duration `toml:"subscriptions-sync-retry-interval" override:"subscriptions-sync-retry-interval"`
+
+	// APIVersion is the version of the InfluxDB API of the connection, either 1 or 2.
+	APIVersion int `toml:"api-version" override:"api-version"`
+	// Org and OrgID are the default InfluxDB 2.x organization of writes and Flux queries.
+	Org   string `toml:"org" override:"org"`
+	OrgID string `toml:"org-id" override:"org-id"`
 }
 
 func NewConfig() Config {
@@ -79,18 +94,24 @@ func (c *Config) Init() {
 		stats.DefaultDatabse: []string{stats.DefaultRetentionPolicy},
 	}
 	c.UDPBuffer = udp.DefaultBuffer
+	c.UDPOverflowPolicy = udp.DefaultOverflowPolicy
 	c.StartUpTimeout = toml.Duration(DefaultStartUpTimeout)
 	c.SubscriptionProtocol = DefaultSubscriptionProtocol
 	c.SubscriptionSyncInterval = toml.Duration(DefaultSubscriptionSyncInterval)
+	c.SubscriptionSyncRetryInterval = toml.Duration(DefaultSubscriptionSyncRetryInterval)
 	c.SubscriptionMode = ClusterMode
 	c.SubscriptionPath = ""
 	c.Compression = "gzip"
+	c.APIVersion = DefaultAPIVersion
 }
 
 func (c *Config) ApplyConditionalDefaults() {
 	if c.UDPBuffer == 0 {
 		c.UDPBuffer = udp.DefaultBuffer
 	}
+	if c.UDPOverflowPolicy == "" {
+		c.UDPOverflowPolicy = udp.DefaultOverflowPolicy
+	}
 	if c.StartUpTimeout == 0 {
 		c.StartUpTimeout = toml.Duration(DefaultStartUpTimeout)
 	}
@@ -100,9 +121,15 @@ func (c *Config) ApplyConditionalDefaults() {
 	if c.SubscriptionSyncInterval == toml.Duration(0) {
 		c.SubscriptionSyncInterval = toml.Duration(DefaultSubscriptionSyncInterval)
 	}
+	if c.SubscriptionSyncRetryInterval == toml.Duration(0) {
+		c.SubscriptionSyncRetryInterval = toml.Duration(DefaultSubscriptionSyncRetryInterval)
+	}
 	if c.Compression == "" {
 		c.Compression = "gzip"
 	}
+	if c.APIVersion == 0 {
+		c.APIVersion = DefaultAPIVersion
+	}
 }
 
 var validNamePattern = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
@@ -132,15 +159,39 @@ func (c Config) Validate() error {
 	default:
 		return fmt.Errorf("invalid subscription protocol, must be one of 'udp', 'http' or 'https', got %q: %v", c.SubscriptionProtocol, c)
 	}
+	if c.SubscriptionSyncRetryInterval < 0 {
+		return fmt.Errorf("subscriptions-sync-retry-interval must be positive, got %v", time.Duration(c.SubscriptionSyncRetryInterval))
+	}
+	if err := udp.ValidateOverflowPolicy(c.UDPOverflowPolicy); err != nil {
+		return fmt.Errorf("udp-overflow-policy: %v", err)
+	}
 	switch c.Compression {
 	case "gzip", "none":
 	default:
 		return fmt.Errorf("Invalid compression, must be one of 'gzip' or 'none', got %s", c.Compression)
 	}
+	switch c.APIVersion {
+	case 0, 1:
+	case 2:
+		if c.Token == "" {
+			return errors.New("api-version 2 requires a token")
+		}
+		if c.HttpSharedSecret {
+			return errors.New("api-version 2 does not support http-shared-secret")
+		}
+	default:
+		return fmt.Errorf("invalid api-version, must be one of 1 or 2, got %d", c.APIVersion)
+	}
 
 	return nil
 }
 
+// subscriptionsDisabled returns whether subscriptions are disabled,
+// InfluxDB 2.x does not support subscriptions.
+func (c Config) subscriptionsDisabled() bool {
+	return c.DisableSubscriptions || c.APIVersion == 2
+}
+
 func (m SubscriptionMode) MarshalText() ([]byte, error) {
 	switch m {
 	case ClusterMode:
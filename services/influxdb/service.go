@@ -61,6 +61,8 @@ type Diagnostic interface {
 	InsecureSkipVerify(urls []string)
 	UnlinkingSubscriptions(cluster string)
 	LinkingSubscriptions(cluster string)
+	CreatedSubscription(cluster, db, rp, name string)
+	DroppedSubscription(cluster, db, rp, name string)
 	StartedUDPListener(db string, rp string)
 }
 
@@ -397,8 +399,10 @@ type influxdbCluster struct {
 	udpBind                  string
 	udpBuffer                int
 	udpReadBuffer            int
+	udpOverflowPolicy        string
 	startupTimeout           time.Duration
 	subscriptionSyncInterval time.Duration
+	subscriptionSyncRetry    time.Duration
 	subscriptionMode         SubscriptionMode
 	subscriptionPath         string
 	disableSubs              bool
@@ -410,8 +414,9 @@ type influxdbCluster struct {
 	// subName is cached copy of the name of the subscriptions.
 	subName string
 
-	subSyncTicker *time.Ticker
-	services      map[subEntry]openCloser
+	// subSyncStop stops watching the subscriptions.
+	subSyncStop chan struct{}
+	services    map[subEntry]openCloser
 
 	randReader io.Reader
 
@@ -482,13 +487,15 @@ func newInfluxDBCluster(c Config, hostname string, ider IDer, httpPort int, useT
 		udpBind:                  c.UDPBind,
 		udpBuffer:                c.UDPBuffer,
 		udpReadBuffer:            c.UDPReadBuffer,
+		udpOverflowPolicy:        c.UDPOverflowPolicy,
 		startupTimeout:           time.Duration(c.StartUpTimeout),
 		subscriptionSyncInterval: time.Duration(c.SubscriptionSyncInterval),
+		subscriptionSyncRetry:    time.Duration(c.SubscriptionSyncRetryInterval),
 		subscriptionMode:         c.SubscriptionMode,
 		subscriptionPath:         c.SubscriptionPath,
 		ider:                     ider,
 		subName:                  subName,
-		disableSubs:              c.DisableSubscriptions,
+		disableSubs:              c.subscriptionsDisabled(),
 		protocol:                 c.SubscriptionProtocol,
 		runningSubs:              make(map[subEntry]bool, len(c.Subscriptions)),
 		services:                 make(map[subEntry]openCloser, len(c.Subscriptions)),
@@ -528,6 +535,9 @@ func httpConfig(c Config) (influxdb.Config, error) {
 		Timeout:     time.Duration(c.Timeout),
 		Transport:   tr,
 		Credentials: credentials,
+		APIVersion:  c.APIVersion,
+		Org:         c.Org,
+		OrgID:       c.OrgID,
 	}, nil
 }
 
@@ -581,9 +591,7 @@ func (c *influxdbCluster) Close() error {
 	}
 	c.opened = false
 
-	if c.subSyncTicker != nil {
-		c.subSyncTicker.Stop()
-	}
+	c.stopWatchingSubs()
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -664,6 +672,11 @@ func (c *influxdbCluster) Update(conf Config) error {
 		// UDP read buffer changed
 		resetServices()
 	}
+	if c.udpOverflowPolicy != conf.UDPOverflowPolicy {
+		c.udpOverflowPolicy = conf.UDPOverflowPolicy
+		// UDP overflow policy changed
+		resetServices()
+	}
 
 	// If the cluster is open and either the subscription name changed or the subscriptions are now disabled,
 	// we need to unlink existing subscriptions.
@@ -671,9 +684,9 @@ func (c *influxdbCluster) Update(conf Config) error {
 	oldSubName := c.subName
 	c.subName = newSubName
 	oldDisableSubscriptions := c.disableSubs
-	c.disableSubs = conf.DisableSubscriptions
+	c.disableSubs = conf.subscriptionsDisabled()
 	if c.opened &&
-		((conf.DisableSubscriptions && oldDisableSubscriptions != conf.DisableSubscriptions) ||
+		((c.disableSubs && oldDisableSubscriptions != c.disableSubs) ||
 			newSubName != oldSubName) {
 		go func() {
 			c.mu.Lock()
@@ -686,8 +699,10 @@ func (c *influxdbCluster) Update(conf Config) error {
 	}
 
 	// Check if subscriptions sync interval changed.
-	if i := time.Duration(conf.SubscriptionSyncInterval); c.subscriptionSyncInterval != i {
+	i, r := time.Duration(conf.SubscriptionSyncInterval), time.Duration(conf.SubscriptionSyncRetryInterval)
+	if c.subscriptionSyncInterval != i || c.subscriptionSyncRetry != r {
 		c.subscriptionSyncInterval = i
+		c.subscriptionSyncRetry = r
 		c.watchSubs()
 	}
 
@@ -727,20 +742,53 @@ func (c *influxdbCluster) Update(conf Config) error {
 	return nil
 }
 
-// watchSubs setups the goroutine to watch the subscriptions and continuously link them.
+// watchSubs setups the goroutine to watch the subscriptions and continuously link them,
+// so that subscriptions dropped from InfluxDB, e.g. when it is restarted with a new meta store, are registered again.
+// A failed sync is retried after the retry interval, doubled on each consecutive failure up to the sync interval.
 // The caller must have the lock.
 func (c *influxdbCluster) watchSubs() {
-	if c.subSyncTicker != nil {
-		c.subSyncTicker.Stop()
+	c.stopWatchingSubs()
+	if c.disableSubs || c.subscriptionSyncInterval == 0 {
+		return
 	}
-	if !c.disableSubs && c.subscriptionSyncInterval != 0 {
-		c.subSyncTicker = time.NewTicker(c.subscriptionSyncInterval)
-		ticker := c.subSyncTicker
-		go func() {
-			for range ticker.C {
-				c.LinkSubscriptions()
+	stop := make(chan struct{})
+	c.subSyncStop = stop
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = c.subscriptionSyncRetry
+	b.MaxInterval = c.subscriptionSyncInterval
+	b.MaxElapsedTime = 0
+	b.Reset()
+	interval := c.subscriptionSyncInterval
+	go func() {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
 			}
-		}()
+			next := interval
+			if err := c.LinkSubscriptions(); err != nil {
+				next = b.NextBackOff()
+				c.diag.Error("failed to sync subscriptions, retrying", err,
+					keyvalue.KV("cluster", c.clusterName),
+					keyvalue.KV("retry", next.String()),
+				)
+			} else {
+				b.Reset()
+			}
+			timer.Reset(next)
+		}
+	}()
+}
+
+// stopWatchingSubs stops the goroutine watching the subscriptions, if any.
+// The caller must have the lock.
+func (c *influxdbCluster) stopWatchingSubs() {
+	if c.subSyncStop != nil {
+		close(c.subSyncStop)
+		c.subSyncStop = nil
 	}
 }
 
@@ -1226,8 +1274,11 @@ func (c *influxdbCluster) createSub(name, cluster, rp, mode string, destinations
 			Mode:            strings.ToUpper(mode),
 		},
 	)
-	return errors.Wrapf(err, "creating sub %s for db %q and rp %q", name, cluster, rp)
-
+	if err != nil {
+		return errors.Wrapf(err, "creating sub %s for db %q and rp %q", name, cluster, rp)
+	}
+	c.diag.CreatedSubscription(c.clusterName, cluster, rp, name)
+	return nil
 }
 func (c *influxdbCluster) dropSub(name, cluster, rp string) (err error) {
 	_, err = c.execQuery(
@@ -1237,6 +1288,9 @@ func (c *influxdbCluster) dropSub(name, cluster, rp string) (err error) {
 			RetentionPolicy: rp,
 		},
 	)
+	if err == nil {
+		c.diag.DroppedSubscription(c.clusterName, cluster, rp, name)
+	}
 	return
 }
 
@@ -1248,6 +1302,7 @@ func (c *influxdbCluster) startUDPListener(se subEntry, port string) (*net.UDPAd
 	conf.RetentionPolicy = se.rp
 	conf.Buffer = c.udpBuffer
 	conf.ReadBuffer = c.udpReadBuffer
+	conf.OverflowPolicy = c.udpOverflowPolicy
 
 	d := c.diag.WithUDPContext(se.db, se.rp)
 	service := udp.NewService(conf, d)
@@ -9,11 +9,13 @@ import (
 	"log"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/toml"
 	"github.com/influxdata/influxql"
 	influxcli "github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/services/diagnostic"
@@ -1049,6 +1051,106 @@ func TestService_Open_LinkSubscriptions(t *testing.T) {
 	}
 }
 
+func TestService_Open_APIVersion2(t *testing.T) {
+	configs := NewDefaultTestConfigs(nil)
+	configs[0].APIVersion = 2
+	configs[0].Token = "mytoken"
+	configs[0].Org = "myorg"
+	if err := configs[0].Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s, _, cs := NewTestService(configs, "localhost", false)
+	var created influxcli.Config
+	cs.CreateFunc = func(config influxcli.Config) (influxcli.ClientUpdater, error) {
+		created = config
+		return influxDBClient{
+			clusterName: testClusterName,
+			QueryFunc: func(clusterName string, q influxcli.Query) (*influxcli.Response, error) {
+				t.Errorf("unexpected query %q, InfluxDB 2.x does not support subscriptions", q.Command)
+				return &influxcli.Response{}, nil
+			},
+		}, nil
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if got, exp := created.APIVersion, 2; got != exp {
+		t.Errorf("unexpected api version got %d exp %d", got, exp)
+	}
+	if got, exp := created.Org, "myorg"; got != exp {
+		t.Errorf("unexpected org got %s exp %s", got, exp)
+	}
+	if got, exp := created.Credentials, (influxcli.Credentials{Method: influxcli.TokenAuthentication, Token: "mytoken"}); got != exp {
+		t.Errorf("unexpected credentials got %v exp %v", got, exp)
+	}
+
+	configs[0].Token = ""
+	if err := configs[0].Validate(); err == nil {
+		t.Error("expected error for api-version 2 without a token")
+	}
+}
+
+func TestService_SyncSubscriptions_Retry(t *testing.T) {
+	const interval = 200 * time.Millisecond
+	configs := NewDefaultTestConfigs(nil)
+	configs[0].SubscriptionSyncInterval = toml.Duration(interval)
+	configs[0].SubscriptionSyncRetryInterval = toml.Duration(10 * time.Millisecond)
+	s, _, cs := NewTestService(configs, "localhost", false)
+
+	var failures, syncs, creates int32
+	cs.QueryFunc = func(clusterName string, q influxcli.Query) (*influxcli.Response, error) {
+		switch {
+		case q.Command == "SHOW DATABASES":
+			atomic.AddInt32(&syncs, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				return nil, errors.New("connection refused")
+			}
+			return &influxcli.Response{
+				Results: []influxcli.Result{{
+					Series: []models.Row{{Values: [][]interface{}{{"db"}}}},
+				}},
+			}, nil
+		case strings.HasPrefix(q.Command, "SHOW RETENTION POLICIES ON"):
+			return &influxcli.Response{
+				Results: []influxcli.Result{{
+					Series: []models.Row{{Values: [][]interface{}{{"rp"}}}},
+				}},
+			}, nil
+		case strings.HasPrefix(q.Command, "CREATE SUBSCRIPTION"):
+			atomic.AddInt32(&creates, 1)
+		}
+		// The subscriptions are never listed, as if InfluxDB dropped them.
+		return &influxcli.Response{}, nil
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	opened := time.Now()
+	if got := atomic.LoadInt32(&creates); got != 1 {
+		t.Fatalf("unexpected subscriptions created on open got %d exp 1", got)
+	}
+
+	// The next three syncs fail, they are retried before the next sync interval.
+	atomic.StoreInt32(&failures, 3)
+	timeout := time.After(10 * time.Second)
+	for atomic.LoadInt32(&creates) < 2 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for the subscription to be created again")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if elapsed := time.Since(opened); elapsed > 3*interval {
+		t.Errorf("subscription created again after %v, expected the failed syncs to be retried sooner than the sync interval %v", elapsed, interval)
+	}
+	if got := atomic.LoadInt32(&syncs); got < 5 {
+		t.Errorf("unexpected syncs got %d exp at least 5", got)
+	}
+}
+
 func validate(
 	t *testing.T,
 	testName string,
@@ -2,22 +2,44 @@ package load
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
 )
 
 const taskDir = "tasks"
 const templateDir = "templates"
 const handlerDir = "handlers"
 
+const (
+	// PrecedenceFile updates objects created via the API
+	// when a file with the same ID is loaded.
+	PrecedenceFile = "file"
+	// PrecedenceAPI skips files of objects created via the API.
+	PrecedenceAPI = "api"
+)
+
+const DefaultWatchInterval = toml.Duration(10 * time.Second)
+
 type Config struct {
 	Enabled bool   `toml:"enabled"`
 	Dir     string `toml:"dir"`
+	// Watch the directory and reload the files when they change.
+	Watch bool `toml:"watch"`
+	// How often to check the directory for changes.
+	WatchInterval toml.Duration `toml:"watch-interval"`
+	// Whether files or the API take precedence for objects with the same ID.
+	Precedence string `toml:"precedence"`
 }
 
 func NewConfig() Config {
 	return Config{
-		Enabled: false,
-		Dir:     "./load",
+		Enabled:       false,
+		Dir:           "./load",
+		WatchInterval: DefaultWatchInterval,
+		Precedence:    PrecedenceFile,
 	}
 }
 
@@ -34,6 +56,16 @@ func (c Config) Validate() error {
 		return errors.New("dir must be an absolute path")
 	}
 
+	if c.Watch && c.WatchInterval <= 0 {
+		return errors.New("watch-interval must be positive")
+	}
+
+	switch c.Precedence {
+	case PrecedenceFile, PrecedenceAPI:
+	default:
+		return fmt.Errorf("invalid precedence %q, must be %q or %q", c.Precedence, PrecedenceFile, PrecedenceAPI)
+	}
+
 	return nil
 }
 
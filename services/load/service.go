@@ -10,11 +10,13 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/influxdata/kapacitor/client/v1"
 	kexpvar "github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/server/vars"
+	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/pkg/errors"
 )
@@ -30,18 +32,70 @@ const (
 	loadAPIName = "load"
 	// The storage namespace for all configuration override data.
 	loadNamespace = "load_items"
+
+	loadPath = "/load"
+)
+
+// Status of a file after the last load.
+const (
+	FileLoaded  = "loaded"
+	FileSkipped = "skipped"
+	FileError   = "error"
 )
 
+// errAPIManaged is returned when a file is skipped because the
+// object was created via the API and the API takes precedence.
+var errAPIManaged = errors.New("object is managed via the API")
+
 type Diagnostic interface {
 	Debug(msg string)
 	Error(msg string, err error)
 	Loading(thing string, file string)
+	Skipping(thing string, file string, reason string)
+}
+
+// FileStatus is the result of loading a single file.
+type FileStatus struct {
+	File   string `json:"file"`
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Status is the result of the last load of the directory.
+type Status struct {
+	LastLoad time.Time    `json:"last-load"`
+	Error    string       `json:"error,omitempty"`
+	Files    []FileStatus `json:"files"`
+	// IDs of the tasks, templates and topic handlers removed because their files were removed.
+	Removed []string `json:"removed"`
 }
 
 type Service struct {
 	mu     sync.Mutex
 	config Config
 
+	// loadMu serializes loads from the watcher and reloads.
+	loadMu sync.Mutex
+
+	// Objects previously loaded from files, used to resolve conflicts with the API.
+	managed struct {
+		tasks     map[string]bool
+		templates map[string]bool
+		handlers  map[string]bool
+	}
+
+	statusMu    sync.RWMutex
+	status      Status
+	pending     Status
+	fingerprint string
+	loaded      bool
+
+	routes  []httpd.Route
+	closing chan struct{}
+	wg      sync.WaitGroup
+
 	cli        *client.Client
 	statsKey   string
 	statMap    *kexpvar.Map
@@ -57,6 +111,10 @@ type Service struct {
 		Store(namespace string) storage.Interface
 		Register(name string, store storage.StoreActioner)
 	}
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
 
 	diag Diagnostic
 }
@@ -102,13 +160,132 @@ func (s *Service) Open() error {
 	}
 	s.items = items
 	s.StorageService.Register(loadAPIName, s.items)
+
+	if s.HTTPDService != nil {
+		s.routes = []httpd.Route{
+			{
+				Method:      "GET",
+				Pattern:     loadPath,
+				HandlerFunc: s.handleStatus,
+			},
+		}
+		if err := s.HTTPDService.AddRoutes(s.routes); err != nil {
+			return errors.Wrap(err, "failed to add API routes")
+		}
+	}
+
+	if s.config.Enabled && s.config.Watch {
+		s.closing = make(chan struct{})
+		s.wg.Add(1)
+		go s.watch()
+	}
 	return nil
 }
 
 func (s *Service) Close() error {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+	}
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
 	return nil
 }
 
+// Status returns the status of the last load.
+func (s *Service) Status() Status {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	return s.status
+}
+
+func (s *Service) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Write(httpd.MarshalJSON(s.Status(), true))
+}
+
+// watch polls the directory and reloads the files when they change.
+func (s *Service) watch() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.config.WatchInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			fingerprint, err := s.dirFingerprint()
+			if err != nil {
+				s.diag.Error("failed to check load directory for changes", err)
+				continue
+			}
+			s.statusMu.RLock()
+			changed := s.loaded && fingerprint != s.fingerprint
+			s.statusMu.RUnlock()
+			if changed {
+				s.diag.Debug("load directory changed, reloading")
+				// Errors are logged and reported in the status.
+				s.Load()
+			}
+		}
+	}
+}
+
+// dirFingerprint summarizes the names, sizes and modification times
+// of the files in the directory, so that changes can be detected.
+func (s *Service) dirFingerprint() (string, error) {
+	var b strings.Builder
+	for _, dir := range []string{s.config.tasksDir(), s.config.templatesDir(), s.config.handlersDir()} {
+		files, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "%s/%s %d %d\n", dir, file.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String(), nil
+}
+
+// setFileStatus records the result of loading a file.
+// It returns the error to report, if any.
+func (s *Service) setFileStatus(kind, file, id string, err error) error {
+	fs := FileStatus{
+		File:   file,
+		Kind:   kind,
+		ID:     id,
+		Status: FileLoaded,
+	}
+	switch {
+	case err == errAPIManaged:
+		fs.Status = FileSkipped
+		fs.Error = err.Error()
+		s.diag.Skipping(kind, file, err.Error())
+		err = nil
+	case err != nil:
+		fs.Status = FileError
+		fs.Error = err.Error()
+	}
+	s.pending.Files = append(s.pending.Files, fs)
+	return err
+}
+
+// conflicts reports whether an existing object must be left alone
+// because it was created via the API and the API takes precedence.
+func (s *Service) conflicts(managed map[string]bool, id string) bool {
+	return s.config.Precedence == PrecedenceAPI && !managed[id]
+}
+
 // taskFiles gets a slice of all files with the .tick file extension
 // and any associated files with .json, .yml, and .yaml file extentions
 // in the configured task directory.
@@ -208,11 +385,45 @@ func (s *Service) Load() error {
 		return nil
 	}
 
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	// Take the fingerprint first so that changes made during the load trigger another one.
+	fingerprint, err := s.dirFingerprint()
+	if err != nil {
+		s.diag.Error("failed to check load directory for changes", err)
+	}
+
 	s.mu.Lock()
 	s.tasks = map[string]bool{}
 	s.templates = map[string]bool{}
 	s.handlers = map[string]bool{}
 	s.mu.Unlock()
+	s.pending = Status{
+		LastLoad: time.Now().UTC(),
+		Files:    []FileStatus{},
+		Removed:  []string{},
+	}
+
+	err = s.loadAndRemove()
+	if err != nil {
+		s.pending.Error = err.Error()
+	}
+
+	s.statusMu.Lock()
+	s.status = s.pending
+	s.fingerprint = fingerprint
+	s.loaded = true
+	s.statusMu.Unlock()
+	return err
+}
+
+func (s *Service) loadAndRemove() error {
+	if err := s.loadManaged(); err != nil {
+		s.diag.Error("failed to list loaded files", err)
+		s.errorCount.Add(1)
+		return err
+	}
 
 	if err := s.load(); err != nil {
 		s.diag.Error("failed to load new files", err)
@@ -229,6 +440,36 @@ func (s *Service) Load() error {
 	return nil
 }
 
+// loadManaged lists the objects previously loaded from files.
+func (s *Service) loadManaged() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadedTasks()
+	if err != nil {
+		return err
+	}
+	templates, err := s.loadedTemplates()
+	if err != nil {
+		return err
+	}
+	handlers, err := s.loadedHandlers()
+	if err != nil {
+		return err
+	}
+	s.managed.tasks = toSet(tasks)
+	s.managed.templates = toSet(templates)
+	s.managed.handlers = toSet(handlers)
+	return nil
+}
+
+func toSet(xs []string) map[string]bool {
+	m := make(map[string]bool, len(xs))
+	for _, x := range xs {
+		m[x] = true
+	}
+	return m
+}
+
 func (s *Service) load() error {
 
 	if _, err := os.ReadDir(s.config.Dir); os.IsNotExist(err) {
@@ -236,24 +477,26 @@ func (s *Service) load() error {
 		return nil
 	}
 
+	// Load every file even if some fail, so that the status of each file is known.
+	var loadErr error
 	s.diag.Debug("loading templates")
 	err := s.loadTemplates()
 	if err != nil && !os.IsNotExist(err) {
-		return err
+		loadErr = err
 	}
 	s.diag.Debug("loading tasks")
 	err = s.loadTasks()
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to load tasks: %v", err)
+	if err != nil && !os.IsNotExist(err) && loadErr == nil {
+		loadErr = fmt.Errorf("failed to load tasks: %v", err)
 	}
 
 	s.diag.Debug("loading handlers")
 	err = s.loadHandlers()
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	if err != nil && !os.IsNotExist(err) && loadErr == nil {
+		loadErr = err
 	}
 
-	return nil
+	return loadErr
 }
 
 func (s *Service) loadTasks() error {
@@ -262,41 +505,46 @@ func (s *Service) loadTasks() error {
 		return err
 	}
 
+	var loadErr error
 	for _, f := range ticks {
 		s.diag.Loading("task", f)
-		if err := s.loadTask(f); err != nil {
-			return fmt.Errorf("failed to load file %s: %s", f, err.Error())
+		id, err := s.loadTask(f)
+		if err := s.setFileStatus("task", f, id, err); err != nil && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load file %s: %s", f, err.Error())
 		}
 	}
 
 	for _, v := range templateTasks {
 		s.diag.Loading("template task", v)
-		if err := s.loadVars(v); err != nil {
-			return fmt.Errorf("failed to load file %s: %s", v, err.Error())
+		id, err := s.loadVars(v)
+		if err := s.setFileStatus("template task", v, id, err); err != nil && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load file %s: %s", v, err.Error())
 		}
 	}
 
-	return nil
+	return loadErr
 }
 
-func (s *Service) loadTask(f string) error {
+func (s *Service) loadTask(f string) (string, error) {
+	id := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
 	file, err := os.Open(f)
 	if err != nil {
-		return fmt.Errorf("failed to open file %v: %v", f, err)
+		return id, fmt.Errorf("failed to open file %v: %v", f, err)
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read file %v: %v", f, err)
+		return id, fmt.Errorf("failed to read file %v: %v", f, err)
 	}
 
 	script := string(data)
-	fn := file.Name()
-	id := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
 
 	l := s.cli.TaskLink(id)
 	task, _ := s.cli.Task(l, nil)
+	if task.ID != "" && s.conflicts(s.managed.tasks, id) {
+		return id, errAPIManaged
+	}
 	if task.ID == "" {
 		o := client.CreateTaskOptions{
 			ID:         id,
@@ -305,7 +553,7 @@ func (s *Service) loadTask(f string) error {
 		}
 
 		if _, err := s.cli.CreateTask(o); err != nil {
-			return fmt.Errorf("failed to create task: %v", err)
+			return id, fmt.Errorf("failed to create task: %v", err)
 		}
 	} else {
 		o := client.UpdateTaskOptions{
@@ -313,17 +561,17 @@ func (s *Service) loadTask(f string) error {
 			TICKscript: script,
 		}
 		if _, err := s.cli.UpdateTask(l, o); err != nil {
-			return fmt.Errorf("failed to create task: %v", err)
+			return id, fmt.Errorf("failed to create task: %v", err)
 		}
 
 		// do reload
 		_, err := s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Disabled})
 		if err != nil {
-			return err
+			return id, err
 		}
 		_, err = s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Enabled})
 		if err != nil {
-			return err
+			return id, err
 		}
 
 	}
@@ -331,11 +579,11 @@ func (s *Service) loadTask(f string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if err := s.items.Set(newTaskItem(id)); err != nil {
-		return err
+		return id, err
 	}
 	s.tasks[id] = true
 
-	return nil
+	return id, nil
 }
 
 func (s *Service) loadTemplates() error {
@@ -344,34 +592,38 @@ func (s *Service) loadTemplates() error {
 		return err
 	}
 
+	var loadErr error
 	for _, f := range files {
 		s.diag.Loading("template", f)
-		if err := s.loadTemplate(f); err != nil {
-			return fmt.Errorf("failed to load file %s: %s", f, err.Error())
+		id, err := s.loadTemplate(f)
+		if err := s.setFileStatus("template", f, id, err); err != nil && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load file %s: %s", f, err.Error())
 		}
 	}
-	return nil
+	return loadErr
 }
 
-func (s *Service) loadTemplate(f string) error {
+func (s *Service) loadTemplate(f string) (string, error) {
+	id := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
 	file, err := os.Open(f)
 	if err != nil {
-		return fmt.Errorf("failed to open file %v: %v", f, err)
+		return id, fmt.Errorf("failed to open file %v: %v", f, err)
 	}
 
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read file %v: %v", f, err)
+		return id, fmt.Errorf("failed to read file %v: %v", f, err)
 	}
 
 	script := string(data)
-	fn := file.Name()
-	id := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
 
 	l := s.cli.TemplateLink(id)
 	task, _ := s.cli.Template(l, nil)
+	if task.ID != "" && s.conflicts(s.managed.templates, id) {
+		return id, errAPIManaged
+	}
 	if task.ID == "" {
 
 		o := client.CreateTemplateOptions{
@@ -380,7 +632,7 @@ func (s *Service) loadTemplate(f string) error {
 		}
 
 		if _, err := s.cli.CreateTemplate(o); err != nil {
-			return fmt.Errorf("failed to create template: %v", err)
+			return id, fmt.Errorf("failed to create template: %v", err)
 		}
 	} else {
 
@@ -389,92 +641,93 @@ func (s *Service) loadTemplate(f string) error {
 			TICKscript: script,
 		}
 		if _, err := s.cli.UpdateTemplate(l, o); err != nil {
-			return fmt.Errorf("failed to update template: %v", err)
+			return id, fmt.Errorf("failed to update template: %v", err)
 		}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if err := s.items.Set(newTemplateItem(id)); err != nil {
-		return err
+		return id, err
 	}
 	s.templates[id] = true
-	return nil
+	return id, nil
 }
 
-func (s *Service) loadVars(f string) error {
+func (s *Service) loadVars(f string) (string, error) {
+	id := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
 	file, err := os.Open(f)
 	if err != nil {
-		return fmt.Errorf("failed to open file %v: %v", f, err)
+		return id, fmt.Errorf("failed to open file %v: %v", f, err)
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read file %v: %v", f, err)
+		return id, fmt.Errorf("failed to read file %v: %v", f, err)
 	}
 
-	fn := file.Name()
-	id := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
-
 	fileVars := client.TaskVars{}
 	switch ext := path.Ext(f); ext {
 	case ".yaml", ".yml":
 		if err := yaml.Unmarshal(data, &fileVars); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal yaml task vars file %q", f)
+			return id, errors.Wrapf(err, "failed to unmarshal yaml task vars file %q", f)
 		}
 	case ".json":
 		if err := json.Unmarshal(data, &fileVars); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal json task vars file %q", f)
+			return id, errors.Wrapf(err, "failed to unmarshal json task vars file %q", f)
 		}
 	default:
-		return errors.New("bad file extension. Must be YAML or JSON")
+		return id, errors.New("bad file extension. Must be YAML or JSON")
 	}
 
 	l := s.cli.TaskLink(id)
 	task, _ := s.cli.Task(l, nil)
+	if task.ID != "" && s.conflicts(s.managed.tasks, id) {
+		return id, errAPIManaged
+	}
 	if task.ID == "" {
 		var o client.CreateTaskOptions
 		o, err = fileVars.CreateTaskOptions()
 		if err != nil {
-			return fmt.Errorf("failed to initialize create task options: %v", err)
+			return id, fmt.Errorf("failed to initialize create task options: %v", err)
 		}
 
 		o.ID = id
 		o.Status = client.Enabled
 		if _, err := s.cli.CreateTask(o); err != nil {
-			return fmt.Errorf("failed to create task: %v", err)
+			return id, fmt.Errorf("failed to create task: %v", err)
 		}
 	} else {
 		var o client.UpdateTaskOptions
 		o, err := fileVars.UpdateTaskOptions()
 		if err != nil {
-			return fmt.Errorf("failed to initialize create task options: %v", err)
+			return id, fmt.Errorf("failed to initialize create task options: %v", err)
 		}
 
 		o.ID = id
 		if _, err := s.cli.UpdateTask(l, o); err != nil {
-			return fmt.Errorf("failed to create task: %v", err)
+			return id, fmt.Errorf("failed to create task: %v", err)
 		}
 		// do reload
 		_, err = s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Disabled})
 		if err != nil {
-			return err
+			return id, err
 		}
 		_, err = s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Enabled})
 		if err != nil {
-			return err
+			return id, err
 		}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if err := s.items.Set(newTaskItem(id)); err != nil {
-		return err
+		return id, err
 	}
 	s.tasks[id] = true
 
-	return nil
+	return id, nil
 }
 
 func (s *Service) loadHandlers() error {
@@ -483,62 +736,68 @@ func (s *Service) loadHandlers() error {
 		return err
 	}
 
+	var loadErr error
 	for _, f := range files {
 		s.diag.Loading("handler", f)
-		if err := s.loadHandler(f); err != nil {
-			return fmt.Errorf("failed to load file %s: %s", f, err.Error())
+		id, err := s.loadHandler(f)
+		if err := s.setFileStatus("handler", f, id, err); err != nil && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load file %s: %s", f, err.Error())
 		}
 	}
-	return nil
+	return loadErr
 }
 
-func (s *Service) loadHandler(f string) error {
+func (s *Service) loadHandler(f string) (string, error) {
 	file, err := os.Open(f)
 	if err != nil {
-		return fmt.Errorf("failed to open file %v: %v", f, err)
+		return "", fmt.Errorf("failed to open file %v: %v", f, err)
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read file %v: %v", f, err)
+		return "", fmt.Errorf("failed to read file %v: %v", f, err)
 	}
 
 	var o client.TopicHandlerOptions
 	switch ext := path.Ext(f); ext {
 	case ".yaml", ".yml":
 		if err := yaml.Unmarshal(data, &o); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal yaml task vars file %q", f)
+			return "", errors.Wrapf(err, "failed to unmarshal yaml task vars file %q", f)
 		}
 	case ".json":
 		if err := json.Unmarshal(data, &o); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal json task vars file %q", f)
+			return "", errors.Wrapf(err, "failed to unmarshal json task vars file %q", f)
 		}
 	default:
-		return errors.New("bad file extension. Must be YAML or JSON")
+		return "", errors.New("bad file extension. Must be YAML or JSON")
 	}
 
+	id := path.Join(o.Topic, o.ID)
 	l := s.cli.TopicHandlerLink(o.Topic, o.ID)
 	handler, _ := s.cli.TopicHandler(l)
+	if handler.ID != "" && s.conflicts(s.managed.handlers, id) {
+		return id, errAPIManaged
+	}
 	if handler.ID == "" {
 		_, err := s.cli.CreateTopicHandler(s.cli.TopicHandlersLink(o.Topic), o)
 		if err != nil {
-			return err
+			return id, err
 		}
 	} else {
 		_, err := s.cli.ReplaceTopicHandler(l, o)
 		if err != nil {
-			return err
+			return id, err
 		}
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.handlers[path.Join(o.Topic, o.ID)] = true
+	s.handlers[id] = true
 	if err := s.items.Set(newTopicHandlerItem(o.Topic, o.ID)); err != nil {
-		return err
+		return id, err
 	}
 
-	return nil
+	return id, nil
 }
 
 func (s *Service) removeMissing() error {
@@ -569,7 +828,13 @@ func (s *Service) removeTasks() error {
 
 	for _, id := range diff(s.tasks, loadedTasks) {
 		l := s.cli.TaskLink(id)
-		if err := s.cli.DeleteTask(l); err != nil {
+		// The task may have already been deleted via the API.
+		if task, _ := s.cli.Task(l, nil); task.ID != "" {
+			if err := s.cli.DeleteTask(l); err != nil {
+				return err
+			}
+		}
+		if err := s.removeItem(newTaskItem(id)); err != nil {
 			return err
 		}
 	}
@@ -587,7 +852,12 @@ func (s *Service) removeTemplates() error {
 	}
 	for _, id := range diff(s.templates, loadedTemplates) {
 		l := s.cli.TemplateLink(id)
-		if err := s.cli.DeleteTemplate(l); err != nil {
+		if template, _ := s.cli.Template(l, nil); template.ID != "" {
+			if err := s.cli.DeleteTemplate(l); err != nil {
+				return err
+			}
+		}
+		if err := s.removeItem(newTemplateItem(id)); err != nil {
 			return err
 		}
 	}
@@ -608,13 +878,27 @@ func (s *Service) removeHandlers() error {
 			return fmt.Errorf("expected id to be topicID/handlerID but it was: \"%s\"", id)
 		}
 		l := s.cli.TopicHandlerLink(topicID, handlerID)
-		if err := s.cli.DeleteTopicHandler(l); err != nil {
+		if handler, _ := s.cli.TopicHandler(l); handler.ID != "" {
+			if err := s.cli.DeleteTopicHandler(l); err != nil {
+				return err
+			}
+		}
+		if err := s.removeItem(newTopicHandlerItem(topicID, handlerID)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// removeItem forgets an object whose file was removed.
+func (s *Service) removeItem(i Item) error {
+	if err := s.items.Delete(i.ID); err != nil {
+		return err
+	}
+	s.pending.Removed = append(s.pending.Removed, i.ID)
+	return nil
+}
+
 func diff(m map[string]bool, xs []string) []string {
 	diffs := []string{}
 
@@ -661,7 +945,7 @@ func (s *Service) loadedHandlers() ([]string, error) {
 	}
 	handlers := []string{}
 	for _, item := range items {
-		handlers = append(handlers, strings.TrimLeft(item.ID, "handlers/"))
+		handlers = append(handlers, strings.TrimPrefix(item.ID, handlersStr+"/"))
 	}
 
 	return handlers, nil
@@ -216,7 +216,7 @@ func (h *handler) Handle(event alert.Event) {
 	case alert.OK:
 		messageType = "RECOVERY"
 	default:
-		messageType = event.State.Level.String()
+		messageType = event.State.Level.Builtin().String()
 	}
 	if err := h.s.Alert(
 		h.c.TeamsList,
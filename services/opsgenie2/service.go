@@ -111,12 +111,13 @@ func (s *Service) Test(options interface{}) error {
 		o.EntityID,
 		time.Now(),
 		"",
+		"",
 		models.Result{},
 	)
 }
 
-func (s *Service) Alert(teams []string, recipients []string, recoveryAction string, level alert.Level, message, entityID string, t time.Time, eventDetails string, details models.Result) error {
-	req, err := s.preparePost(teams, recipients, recoveryAction, level, message, entityID, t, eventDetails, details)
+func (s *Service) Alert(teams []string, recipients []string, recoveryAction string, level alert.Level, message, entityID string, t time.Time, eventDetails, runbook string, details models.Result) error {
+	req, err := s.preparePost(teams, recipients, recoveryAction, level, message, entityID, t, eventDetails, runbook, details)
 	if err != nil {
 		return errors.Wrap(err, "failed to prepare API request")
 	}
@@ -143,7 +144,7 @@ func (s *Service) Alert(teams []string, recipients []string, recoveryAction stri
 	return nil
 }
 
-func (s *Service) preparePost(teams []string, recipients []string, recoveryAction string, level alert.Level, message, entityID string, t time.Time, eventDetails string, details models.Result) (*http.Request, error) {
+func (s *Service) preparePost(teams []string, recipients []string, recoveryAction string, level alert.Level, message, entityID string, t time.Time, eventDetails, runbook string, details models.Result) (*http.Request, error) {
 	c := s.config()
 	if !c.Enabled {
 		return nil, errors.New("service is not enabled")
@@ -176,7 +177,7 @@ func (s *Service) preparePost(teams []string, recipients []string, recoveryActio
 		ogData["note"] = message
 	default:
 		var priority string
-		switch level {
+		switch level.Builtin() {
 		case alert.Info:
 			priority = "P5"
 		case alert.Warning:
@@ -207,6 +208,9 @@ func (s *Service) preparePost(teams []string, recipients []string, recoveryActio
 		ogDetails := make(map[string]string)
 		ogDetails["Monitoring Tool"] = "Kapacitor"
 		ogDetails["Level"] = level.String()
+		if runbook != "" {
+			ogDetails["Runbook"] = runbook
+		}
 
 		if len(details.Series) > 0 {
 			row := details.Series[0]
@@ -300,8 +304,13 @@ func (h *handler) Handle(event alert.Event) {
 		event.State.ID,
 		event.State.Time,
 		event.State.Details,
+		event.State.Runbook,
 		event.Data.Result,
 	); err != nil {
 		h.diag.Error("failed to send event to OpsGenie", err)
 	}
 }
+
+func (h *handler) RendersRunbook() bool {
+	return true
+}
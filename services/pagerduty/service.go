@@ -99,7 +99,11 @@ func (s *Service) Alert(serviceKey, incidentKey, desc string, level alert.Level,
 	if err != nil {
 		return err
 	}
+	return s.post(url, post)
+}
 
+// post sends the prepared post to PagerDuty.
+func (s *Service) post(url string, post io.Reader) error {
 	resp, err := http.Post(url, "application/json", post)
 	if err != nil {
 		return err
@@ -130,7 +134,7 @@ func (s *Service) preparePost(serviceKey, incidentKey, desc string, level alert.
 	}
 
 	var eventType string
-	switch level {
+	switch level.Builtin() {
 	case alert.Warning, alert.Critical:
 		eventType = "trigger"
 	case alert.Info:
@@ -168,30 +172,49 @@ type HandlerConfig struct {
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	ServiceKey string `mapstructure:"service-key"`
+
+	// MaxPayloadSize is the maximum size in bytes of the events,
+	// larger events have their details and then their description truncated.
+	// Zero means no limit.
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 type handler struct {
-	s    *Service
-	c    HandlerConfig
-	diag Diagnostic
+	s     *Service
+	c     HandlerConfig
+	limit *alert.PayloadLimit
+	diag  Diagnostic
 }
 
 func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 	return &handler{
-		s:    s,
-		c:    c,
-		diag: s.diag.WithContext(ctx...),
+		s:     s,
+		c:     c,
+		limit: alert.NewPayloadLimit("pagerduty", c.MaxPayloadSize),
+		diag:  s.diag.WithContext(ctx...),
 	}
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
-		h.c.ServiceKey,
-		event.State.ID,
-		event.State.Message,
-		event.State.Level,
-		event.State.Details,
-	); err != nil {
+	var url string
+	_, body, err := h.limit.Fit(event, func(e alert.Event) ([]byte, error) {
+		u, post, err := h.s.preparePost(
+			h.c.ServiceKey,
+			e.State.ID,
+			e.State.Message,
+			e.State.Level,
+			e.State.Details,
+		)
+		if err != nil {
+			return nil, err
+		}
+		url = u
+		return io.ReadAll(post)
+	})
+	if err == nil {
+		err = h.s.post(url, bytes.NewReader(body))
+	}
+	if err != nil {
 		h.diag.Error("failed to send event to PagerDuty", err)
 	}
 }
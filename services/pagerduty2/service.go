@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync/atomic"
 	text "text/template"
 	"time"
@@ -98,6 +99,7 @@ type AlertPayload struct {
 type Diagnostic interface {
 	WithContext(ctx ...keyvalue.T) Diagnostic
 	Error(msg string, err error)
+	Info(msg string, ctx ...keyvalue.T)
 }
 
 // Service is the default struct for the HTTP service
@@ -214,11 +216,15 @@ func (s *Service) Test(options interface{}) error {
 // The req headers are now required with the API v2:
 // https://v2.developer.pagerduty.com/docs/migrating-to-api-v2
 func (s *Service) Alert(routingKey string, links []LinkTemplate, alertID, desc string, level alert.Level, timestamp time.Time, data alert.EventData) error {
-	url, post, err := s.preparePost(routingKey, links, alertID, desc, level, timestamp, data)
+	url, post, err := s.preparePost(routingKey, links, alertID, desc, level, timestamp, data, nil)
 	if err != nil {
 		return err
 	}
+	return s.post(http.DefaultClient, url, post)
+}
 
+// post POSTs the payload of an event to the URL of the PagerDuty events API.
+func (s *Service) post(client *http.Client, url string, post io.Reader) error {
 	req, err := http.NewRequest("POST", url, post)
 	if err != nil {
 		return err
@@ -226,7 +232,7 @@ func (s *Service) Alert(routingKey string, links []LinkTemplate, alertID, desc s
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/vnd.pagerduty+json;version=2")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -253,7 +259,8 @@ func (s *Service) Alert(routingKey string, links []LinkTemplate, alertID, desc s
 }
 
 // preparePost is a helper method that sets up the payload for transmission to PagerDuty
-func (s *Service) preparePost(routingKey string, links []LinkTemplate, alertID, desc string, level alert.Level, timestamp time.Time, data alert.EventData) (string, io.Reader, error) {
+// The details are added to the custom details of the payload, replacing the result or recent details of the same key.
+func (s *Service) preparePost(routingKey string, links []LinkTemplate, alertID, desc string, level alert.Level, timestamp time.Time, data alert.EventData, details map[string]string) (string, io.Reader, error) {
 	c := s.config()
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
@@ -262,7 +269,7 @@ func (s *Service) preparePost(routingKey string, links []LinkTemplate, alertID,
 	var severity string
 	eventType := "trigger"
 
-	switch level {
+	switch level.Builtin() {
 	case alert.Warning:
 		severity = "warning"
 	case alert.Critical:
@@ -294,6 +301,12 @@ func (s *Service) preparePost(routingKey string, links []LinkTemplate, alertID,
 
 	ap.Payload.CustomDetails = make(map[string]interface{})
 	ap.Payload.CustomDetails["result"] = data.Result
+	if data.Recent != nil {
+		ap.Payload.CustomDetails["recent"] = data.Recent
+	}
+	for k, v := range details {
+		ap.Payload.CustomDetails[k] = v
+	}
 
 	ap.Payload.Class = data.TaskName
 	ap.Payload.Severity = severity
@@ -336,12 +349,33 @@ type HandlerConfig struct {
 	// Defaults to the value in the configuration if empty.
 	RoutingKey string         `mapstructure:"routing-key"`
 	Links      []LinkTemplate `mapstructure:"links"`
+
+	// Failover is the ordered list of the URLs of the events API to post to when the configured URL fails,
+	// for example the events API of another service region.
+	Failover []string `mapstructure:"failover"`
+	// Retries is the number of times the post to a URL is retried before failing over to the next URL.
+	Retries int `mapstructure:"retries"`
+	// Timeout is the timeout of each post to a URL, a post that times out is retried as any failed post.
+	// Zero means no timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// CustomDetails maps the keys of the custom details of the events to templates of their values,
+	// for example {"host": "{{ index .Tags \"host\" }}"}.
+	CustomDetails map[string]string `mapstructure:"custom-details"`
+
+	// MaxPayloadSize is the maximum size in bytes of the events,
+	// larger events have their result and recent points dropped and then their summary truncated.
+	// Zero means no limit.
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 type handler struct {
-	s    *Service
-	c    HandlerConfig
-	diag Diagnostic
+	s     *Service
+	c     HandlerConfig
+	limit *alert.PayloadLimit
+	diag  Diagnostic
+
+	detailTmpls map[string]*text.Template
 }
 
 // Handler is a bound method to the Service struct that returns the appropriate alert handler for PagerDuty
@@ -361,10 +395,40 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, er
 			c.Links[i].textTmpl = textTmpl
 		}
 	}
+	for _, f := range c.Failover {
+		if _, err := url.Parse(f); err != nil {
+			return nil, fmt.Errorf("invalid failover URL %q: %v", f, err)
+		}
+	}
+	if c.Retries < 0 {
+		return nil, fmt.Errorf("retries must be positive, got %d", c.Retries)
+	}
+	if c.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %v", c.Timeout)
+	}
+	if c.MaxPayloadSize < 0 {
+		return nil, fmt.Errorf("max payload size must be positive, got %d", c.MaxPayloadSize)
+	}
+	var detailTmpls map[string]*text.Template
+	if len(c.CustomDetails) > 0 {
+		detailTmpls = make(map[string]*text.Template, len(c.CustomDetails))
+		for k, v := range c.CustomDetails {
+			if k == "" {
+				return nil, errors.New("custom detail key must not be empty")
+			}
+			tmpl, err := text.New("customDetail").Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template of custom detail %q: %v", k, err)
+			}
+			detailTmpls[k] = tmpl
+		}
+	}
 	return &handler{
-		s:    s,
-		c:    c,
-		diag: s.diag.WithContext(ctx...),
+		s:           s,
+		c:           c,
+		limit:       alert.NewPayloadLimit("pagerduty2", c.MaxPayloadSize),
+		diag:        s.diag.WithContext(ctx...),
+		detailTmpls: detailTmpls,
 	}, nil
 }
 
@@ -395,16 +459,82 @@ func (h *handler) Handle(event alert.Event) {
 			h.c.Links[i].Text = h.c.Links[i].Href
 		}
 	}
+	var details map[string]string
+	if len(h.detailTmpls) > 0 {
+		details = make(map[string]string, len(h.detailTmpls))
+		for k, tmpl := range h.detailTmpls {
+			err := tmpl.Execute(&textBuf, td)
+			if err != nil {
+				h.diag.Error("failed to handle event", fmt.Errorf("custom detail %q: %v", k, err))
+				return
+			}
+			details[k] = textBuf.String()
+			textBuf.Reset()
+		}
+	}
+	links := h.c.Links
+	if event.State.Runbook != "" {
+		links = append(links[:len(links):len(links)], LinkTemplate{
+			Href: event.State.Runbook,
+			Text: "Runbook",
+		})
+	}
 
-	if err := h.s.Alert(
-		h.c.RoutingKey,
-		h.c.Links,
-		event.State.ID,
-		event.State.Message,
-		event.State.Level,
-		event.State.Time,
-		event.Data,
-	); err != nil {
+	var primary string
+	_, body, err := h.limit.Fit(event, func(e alert.Event) ([]byte, error) {
+		u, post, err := h.s.preparePost(
+			h.c.RoutingKey,
+			links,
+			e.State.ID,
+			e.State.Message,
+			e.State.Level,
+			e.State.Time,
+			e.Data,
+			details,
+		)
+		if err != nil {
+			return nil, err
+		}
+		primary = u
+		return io.ReadAll(post)
+	})
+	if err != nil {
 		h.diag.Error("failed to send event to PagerDuty", err)
+		return
+	}
+
+	urls := append([]string{primary}, h.c.Failover...)
+	names := make([]string, len(urls))
+	for i, u := range urls {
+		names[i] = endpointName(u)
 	}
+	// The failover is created for each event since the configured URL may be updated.
+	failover := alert.NewFailover("pagerduty2", names, h.c.Retries)
+	client := &http.Client{Timeout: h.c.Timeout}
+	i, err := failover.Deliver(func(i int) error {
+		return h.s.post(client, urls[i], bytes.NewReader(body))
+	}, func(endpoint string, err error) {
+		h.diag.Error("failed to send event to PagerDuty", fmt.Errorf("endpoint %s: %v", endpoint, err))
+	})
+	if err != nil {
+		if len(urls) > 1 {
+			h.diag.Error("failed to send event to all PagerDuty failover endpoints", err)
+		}
+		return
+	}
+	if i > 0 {
+		h.diag.Info("sent event to PagerDuty failover endpoint", keyvalue.KV("endpoint", names[i]))
+	}
+}
+
+// endpointName returns the name of the stats of a URL, which is its host so that any token in the URL is not exposed.
+func endpointName(u string) string {
+	if pu, err := url.Parse(u); err == nil && pu.Host != "" {
+		return pu.Host
+	}
+	return "url"
+}
+
+func (h *handler) RendersRunbook() bool {
+	return true
 }
@@ -135,7 +135,7 @@ func (s *Service) Alert(message, device, title, URL, URLTitle, sound string, use
 // priority returns the pushover priority as defined by the Pushover API
 // documentation https://pushover.net/api
 func priority(level alert.Level) int {
-	switch level {
+	switch level.Builtin() {
 	case alert.OK:
 		// send as -2 to generate no notification/alert
 		return -2
@@ -2,21 +2,47 @@ package replay
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
 )
 
 type Config struct {
 	Dir string `toml:"dir"`
+
+	// Retention of the recordings, zero limits mean no limit.
+	// The oldest recordings are deleted once a limit is exceeded, pinned recordings are never deleted.
+	MaxRecordings     int           `toml:"max-recordings"`
+	MaxRecordingAge   toml.Duration `toml:"max-recording-age"`
+	MaxRecordingsSize toml.Size     `toml:"max-recordings-size"`
+	// How often the recordings are checked against the retention limits.
+	RetentionCheckInterval toml.Duration `toml:"retention-check-interval"`
 }
 
 func (c Config) Validate() error {
 	if c.Dir == "" {
 		return fmt.Errorf("must specify dir")
 	}
+	if c.MaxRecordings < 0 {
+		return fmt.Errorf("max-recordings must not be negative")
+	}
+	if c.MaxRecordingAge < 0 {
+		return fmt.Errorf("max-recording-age must not be negative")
+	}
+	if c.RetentionEnabled() && c.RetentionCheckInterval <= 0 {
+		return fmt.Errorf("retention-check-interval must be positive")
+	}
 	return nil
 }
 
+// RetentionEnabled reports whether any retention limit is set.
+func (c Config) RetentionEnabled() bool {
+	return c.MaxRecordings > 0 || c.MaxRecordingAge > 0 || c.MaxRecordingsSize > 0
+}
+
 func NewConfig() Config {
 	return Config{
-		Dir: "./replay",
+		Dir:                    "./replay",
+		RetentionCheckInterval: toml.Duration(time.Hour),
 	}
 }
@@ -0,0 +1,189 @@
+package replay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// replayControl is a clock that replays data at a speed multiple of the real clock
+// and allows the replay to be paused, resumed and stepped.
+//
+// The replay time only advances while the replay is running.
+// While paused the replay may be stepped, allowing a number of points
+// (or batches for batch replays) to be emitted before it is paused again.
+// A canceled replay stops emitting points and its queries are canceled through its context.
+type replayControl struct {
+	mu sync.Mutex
+
+	zero time.Time
+	// Whether the replay waits for the real clock, otherwise points are emitted as fast as possible.
+	real  bool
+	speed float64
+
+	paused bool
+	// Number of points that may be emitted before the replay is paused.
+	steps int
+	// Replay time and wall time when the replay was last resumed or stepped.
+	resumedAt   time.Time
+	resumedWall time.Time
+
+	// Time of the latest emitted point, relative to the zero time.
+	position time.Duration
+	emitted  int64
+
+	// changed is closed and replaced when the replay state changes.
+	changed chan struct{}
+	closing chan struct{}
+	closed  bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newReplayControl(real bool, speed float64, paused bool) *replayControl {
+	if speed <= 0 {
+		speed = 1
+	}
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &replayControl{
+		zero:        now,
+		real:        real,
+		speed:       speed,
+		paused:      paused,
+		resumedAt:   now,
+		resumedWall: now,
+		changed:     make(chan struct{}),
+		closing:     make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (c *replayControl) Zero() time.Time {
+	return c.zero
+}
+
+func (c *replayControl) Set(t time.Time) {}
+
+// Until waits until the replay time t has arrived and the replay is not paused.
+func (c *replayControl) Until(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for !c.closed {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if c.paused {
+			if c.steps > 0 {
+				c.steps--
+				if c.steps == 0 {
+					// Continue from the stepped point once resumed.
+					c.resumedAt = t
+				}
+				break
+			}
+		} else {
+			if !c.real {
+				break
+			}
+			d := time.Duration(float64(t.Sub(c.now())) / c.speed)
+			if d <= 0 {
+				break
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+		changed := c.changed
+		c.mu.Unlock()
+		select {
+		case <-timeout:
+		case <-changed:
+		case <-c.closing:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		c.mu.Lock()
+	}
+	if p := t.Sub(c.zero); p > c.position {
+		c.position = p
+	}
+	c.emitted++
+}
+
+// now returns the current replay time of a running replay.
+func (c *replayControl) now() time.Time {
+	return c.resumedAt.Add(time.Duration(float64(time.Since(c.resumedWall)) * c.speed))
+}
+
+func (c *replayControl) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Pause pauses the replay at the current replay time.
+func (c *replayControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return
+	}
+	c.resumedAt = c.now()
+	c.paused = true
+	c.notify()
+}
+
+// Resume resumes a paused replay.
+func (c *replayControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	c.steps = 0
+	c.resumedWall = time.Now()
+	c.notify()
+}
+
+// Step emits the next n points and pauses the replay afterwards.
+func (c *replayControl) Step(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.resumedAt = c.now()
+		c.paused = true
+	}
+	c.steps += n
+	c.resumedWall = time.Now()
+	c.notify()
+}
+
+// Cancel cancels the replay, releasing any waiting points and canceling its context.
+func (c *replayControl) Cancel() {
+	c.Close()
+}
+
+// Context returns the context of the replay, which is done once the replay is canceled or closed.
+func (c *replayControl) Context() context.Context {
+	return c.ctx
+}
+
+// Close releases any waiting points and the context of the replay once it has finished.
+func (c *replayControl) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.closing)
+	}
+	c.cancel()
+}
+
+// State returns the state of the replay.
+func (c *replayControl) State() (paused bool, position time.Duration, emitted int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused, c.position, c.emitted
+}
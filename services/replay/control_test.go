@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplayControl_PauseStepResume(t *testing.T) {
+	c := newReplayControl(false, 1, true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 5; i++ {
+			c.Until(c.Zero().Add(time.Duration(i) * time.Second))
+		}
+	}()
+
+	waitEmitted := func(exp int64) {
+		t.Helper()
+		timeout := time.After(time.Second)
+		for {
+			_, _, emitted := c.State()
+			if emitted == exp {
+				return
+			}
+			select {
+			case <-timeout:
+				t.Fatalf("unexpected number of emitted points got %d exp %d", emitted, exp)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	// The replay is paused until stepped
+	time.Sleep(10 * time.Millisecond)
+	waitEmitted(0)
+
+	c.Step(2)
+	waitEmitted(2)
+	paused, position, _ := c.State()
+	if !paused {
+		t.Error("expected replay to be paused after stepping")
+	}
+	if exp := 2 * time.Second; position != exp {
+		t.Errorf("unexpected position got %v exp %v", position, exp)
+	}
+
+	c.Resume()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replay to finish")
+	}
+	waitEmitted(5)
+	if _, position, _ := c.State(); position != 5*time.Second {
+		t.Errorf("unexpected position got %v exp %v", position, 5*time.Second)
+	}
+}
+
+func TestReplayControl_Speed(t *testing.T) {
+	c := newReplayControl(true, 20, false)
+	start := time.Now()
+	c.Until(c.Zero().Add(time.Second))
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("unexpected elapsed time replaying 1s at speed 20: %v", elapsed)
+	}
+}
+
+func TestReplayControl_Close(t *testing.T) {
+	c := newReplayControl(true, 1, true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Until(c.Zero().Add(time.Hour))
+	}()
+	c.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for closed replay")
+	}
+}
+
+func TestReplayControl_Cancel(t *testing.T) {
+	c := newReplayControl(true, 1, true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Until(c.Zero().Add(time.Hour))
+	}()
+
+	c.Cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("canceled replay did not release waiting points")
+	}
+	if err := c.Context().Err(); err != context.Canceled {
+		t.Errorf("unexpected context error got %v exp %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,257 @@
+package replay
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	kclient "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCSVTimeColumn        = "time"
+	defaultCSVMeasurementColumn = "name"
+	// Column containing tags in the key=value format of the InfluxDB CLI.
+	csvTagsColumn = "tags"
+)
+
+// csvColumns describes how the columns of a CSV header map onto points.
+type csvColumns struct {
+	time        int
+	measurement int
+	tags        int
+	tagKeys     map[int]string
+	fieldKeys   map[int]string
+}
+
+func newCSVColumns(header []string, opt kclient.RecordCSVOptions) (csvColumns, error) {
+	timeColumn := opt.TimeColumn
+	if timeColumn == "" {
+		timeColumn = defaultCSVTimeColumn
+	}
+	measurementColumn := opt.MeasurementColumn
+	if measurementColumn == "" {
+		measurementColumn = defaultCSVMeasurementColumn
+	}
+	tags := make(map[string]bool, len(opt.Tags))
+	for _, t := range opt.Tags {
+		tags[t] = true
+	}
+	fields := make(map[string]bool, len(opt.Fields))
+	for _, f := range opt.Fields {
+		fields[f] = true
+	}
+
+	c := csvColumns{
+		time:        -1,
+		measurement: -1,
+		tags:        -1,
+		tagKeys:     make(map[int]string),
+		fieldKeys:   make(map[int]string),
+	}
+	for i, name := range header {
+		switch {
+		case name == timeColumn:
+			c.time = i
+		case name == measurementColumn && opt.Measurement == "":
+			c.measurement = i
+		case name == csvTagsColumn && !tags[name] && !fields[name]:
+			c.tags = i
+		case tags[name]:
+			c.tagKeys[i] = name
+		case len(fields) == 0 || fields[name]:
+			c.fieldKeys[i] = name
+		}
+	}
+	if c.time < 0 {
+		return c, fmt.Errorf("missing time column %q", timeColumn)
+	}
+	if opt.Measurement == "" && c.measurement < 0 {
+		return c, fmt.Errorf("missing measurement column %q and no measurement provided", measurementColumn)
+	}
+	for _, t := range opt.Tags {
+		if !contains(header, t) {
+			return c, fmt.Errorf("missing tag column %q", t)
+		}
+	}
+	for _, f := range opt.Fields {
+		if !contains(header, f) {
+			return c, fmt.Errorf("missing field column %q", f)
+		}
+	}
+	if len(c.fieldKeys) == 0 {
+		return c, errors.New("no field columns")
+	}
+	return c, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSVTime parses a time value in one of the supported time formats.
+func parseCSVTime(value, format string) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case "", "rfc3339":
+		return time.Parse(time.RFC3339Nano, value)
+	case "epoch-s":
+		unit = time.Second
+	case "epoch-ms":
+		unit = time.Millisecond
+	case "epoch-us":
+		unit = time.Microsecond
+	case "epoch-ns":
+		unit = time.Nanosecond
+	default:
+		return time.Parse(format, value)
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, i*int64(unit)).UTC(), nil
+}
+
+// parseCSVValue converts a field value to a float, bool or string.
+// Integers are read as floats as the CSV does not record the type of the value.
+func parseCSVValue(value string) interface{} {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return value
+}
+
+// parseCSVTags parses tags of the form key=value,key=value.
+func parseCSVTags(value string, tags models.Tags) error {
+	if value == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid tag %q", pair)
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return nil
+}
+
+// readCSVPoints reads the rows of CSV data as points ordered by time.
+// The first row must be a header naming the columns.
+func readCSVPoints(r io.Reader, opt kclient.RecordCSVOptions) ([]edge.PointMessage, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("missing CSV header")
+		}
+		return nil, err
+	}
+	columns, err := newCSVColumns(header, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CSV header")
+	}
+
+	var points []edge.PointMessage
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// csv.ParseError already includes the line number
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		t, err := parseCSVTime(record[columns.time], opt.TimeFormat)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid time %q: %v", line, record[columns.time], err)
+		}
+		name := opt.Measurement
+		if name == "" {
+			name = record[columns.measurement]
+			if name == "" {
+				return nil, fmt.Errorf("line %d: missing measurement", line)
+			}
+		}
+		tags := make(models.Tags, len(columns.tagKeys))
+		if columns.tags >= 0 {
+			if err := parseCSVTags(record[columns.tags], tags); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		for i, k := range columns.tagKeys {
+			if v := record[i]; v != "" {
+				tags[k] = v
+			}
+		}
+		fields := make(models.Fields, len(columns.fieldKeys))
+		for i, k := range columns.fieldKeys {
+			if v := record[i]; v != "" {
+				fields[k] = parseCSVValue(v)
+			}
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: no field values", line)
+		}
+		points = append(points, edge.NewPointMessage(
+			name,
+			opt.Database,
+			opt.RetentionPolicy,
+			models.Dimensions{},
+			fields,
+			tags,
+			t.UTC(),
+		))
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time().Before(points[j].Time())
+	})
+	return points, nil
+}
+
+// csvBatches groups points into a batch per series, in order of first appearance.
+func csvBatches(points []edge.PointMessage) []edge.BufferedBatchMessage {
+	var batches []edge.BufferedBatchMessage
+	index := make(map[string]int)
+	for _, p := range points {
+		dims := models.Dimensions{ByName: true, TagNames: models.SortedKeys(p.Tags())}
+		key := string(models.ToGroupID(p.Name(), p.Tags(), dims))
+		i, ok := index[key]
+		if !ok {
+			i = len(batches)
+			index[key] = i
+			batches = append(batches, edge.NewBufferedBatchMessage(
+				edge.NewBeginBatchMessage(p.Name(), p.Tags(), false, time.Time{}, 0),
+				nil,
+				edge.NewEndBatchMessage(),
+			))
+		}
+		b := batches[i]
+		b.SetPoints(append(b.Points(), edge.NewBatchPointMessage(p.Fields(), p.Tags(), p.Time())))
+		if p.Time().After(b.Begin().Time()) {
+			b.Begin().SetTime(p.Time())
+		}
+	}
+	for _, b := range batches {
+		b.Begin().SetSizeHint(len(b.Points()))
+	}
+	return batches
+}
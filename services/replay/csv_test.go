@@ -0,0 +1,139 @@
+package replay
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	kclient "github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestReadCSVPoints(t *testing.T) {
+	data := `name,tags,time,value,status
+cpu,host=serverB,1971-01-01T00:00:01Z,2,ok
+cpu,host=serverA,1971-01-01T00:00:00Z,1,
+cpu,host=serverA,1971-01-01T00:00:02Z,3.5,warn
+`
+	points, err := readCSVPoints(strings.NewReader(data), kclient.RecordCSVOptions{
+		Database:        "db",
+		RetentionPolicy: "rp",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(points), 3; got != exp {
+		t.Fatalf("unexpected number of points: got %d exp %d", got, exp)
+	}
+	// Points are ordered by time
+	for i, exp := range []struct {
+		tags   models.Tags
+		fields models.Fields
+	}{
+		{tags: models.Tags{"host": "serverA"}, fields: models.Fields{"value": 1.0}},
+		{tags: models.Tags{"host": "serverB"}, fields: models.Fields{"value": 2.0, "status": "ok"}},
+		{tags: models.Tags{"host": "serverA"}, fields: models.Fields{"value": 3.5, "status": "warn"}},
+	} {
+		p := points[i]
+		if got, exp := p.Time(), time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC); !got.Equal(exp) {
+			t.Errorf("%d: unexpected time: got %v exp %v", i, got, exp)
+		}
+		if p.Name() != "cpu" || p.Database() != "db" || p.RetentionPolicy() != "rp" {
+			t.Errorf("%d: unexpected point meta %s %s %s", i, p.Name(), p.Database(), p.RetentionPolicy())
+		}
+		if !reflect.DeepEqual(p.Tags(), exp.tags) {
+			t.Errorf("%d: unexpected tags: got %v exp %v", i, p.Tags(), exp.tags)
+		}
+		if !reflect.DeepEqual(p.Fields(), exp.fields) {
+			t.Errorf("%d: unexpected fields: got %v exp %v", i, p.Fields(), exp.fields)
+		}
+	}
+
+	batches := csvBatches(points)
+	if got, exp := len(batches), 2; got != exp {
+		t.Fatalf("unexpected number of batches: got %d exp %d", got, exp)
+	}
+	if got, exp := len(batches[0].Points()), 2; got != exp {
+		t.Errorf("unexpected number of points in first batch: got %d exp %d", got, exp)
+	}
+	if got, exp := batches[0].Begin().Time(), time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC); !got.Equal(exp) {
+		t.Errorf("unexpected batch time: got %v exp %v", got, exp)
+	}
+}
+
+func TestReadCSVPoints_Mapping(t *testing.T) {
+	data := `ts,host,region,usage,ignored
+1000,serverA,west,10,x
+`
+	points, err := readCSVPoints(strings.NewReader(data), kclient.RecordCSVOptions{
+		Measurement: "cpu",
+		TimeColumn:  "ts",
+		TimeFormat:  "epoch-ms",
+		Tags:        []string{"host", "region"},
+		Fields:      []string{"usage"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 {
+		t.Fatalf("unexpected number of points: %d", len(points))
+	}
+	p := points[0]
+	if got, exp := p.Time(), time.Unix(1, 0).UTC(); !got.Equal(exp) {
+		t.Errorf("unexpected time: got %v exp %v", got, exp)
+	}
+	if exp := (models.Tags{"host": "serverA", "region": "west"}); !reflect.DeepEqual(p.Tags(), exp) {
+		t.Errorf("unexpected tags: got %v exp %v", p.Tags(), exp)
+	}
+	if exp := (models.Fields{"usage": 10.0}); !reflect.DeepEqual(p.Fields(), exp) {
+		t.Errorf("unexpected fields: got %v exp %v", p.Fields(), exp)
+	}
+}
+
+func TestReadCSVPoints_Errors(t *testing.T) {
+	testCases := []struct {
+		data string
+		opt  kclient.RecordCSVOptions
+		err  string
+	}{
+		{
+			data: "",
+			err:  "missing CSV header",
+		},
+		{
+			data: "name,value\ncpu,1\n",
+			err:  `invalid CSV header: missing time column "time"`,
+		},
+		{
+			data: "time,value\n1971-01-01T00:00:00Z,1\n",
+			err:  `invalid CSV header: missing measurement column "name" and no measurement provided`,
+		},
+		{
+			data: "name,time,value\ncpu,1971-01-01T00:00:00Z,1\ncpu,yesterday,2\n",
+			err:  `line 3: invalid time "yesterday"`,
+		},
+		{
+			data: "name,tags,time,value\ncpu,host,1971-01-01T00:00:00Z,1\n",
+			err:  `line 2: invalid tag "host"`,
+		},
+		{
+			data: "name,time,value\ncpu,1971-01-01T00:00:00Z\n",
+			err:  "record on line 2: wrong number of fields",
+		},
+		{
+			data: "name,time,value\ncpu,1971-01-01T00:00:00Z,\n",
+			err:  "line 2: no field values",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := readCSVPoints(strings.NewReader(tc.data), tc.opt)
+		if err == nil {
+			t.Errorf("%q: expected error %q", tc.data, tc.err)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%q: unexpected error: got %q exp %q", tc.data, err.Error(), tc.err)
+		}
+	}
+}
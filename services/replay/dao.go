@@ -59,6 +59,7 @@ const (
 	Failed Status = iota
 	Running
 	Finished
+	Canceled
 )
 
 type RecordingType int
@@ -78,6 +79,8 @@ type Recording struct {
 	Error    string
 	Status   Status
 	Progress float64
+	// Pinned recordings are never deleted by the retention of the recordings.
+	Pinned bool
 }
 
 type rawRecording Recording
@@ -229,6 +232,12 @@ type Replay struct {
 	Error         string
 	Status        Status
 	Progress      float64
+	// Speed multiplier of the clock
+	Speed float64
+	// Offset of the latest replayed point from the start of the replay
+	Position time.Duration
+	// Number of replayed points, or batches for batch tasks
+	Emitted int64
 	// Stores snapshot of finished replayed Task status
 	ExecutionStats ExecutionStats
 }
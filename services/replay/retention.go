@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/keyvalue"
+)
+
+// retention are the limits of the recordings kept by the service.
+type retention struct {
+	maxCount int
+	maxAge   time.Duration
+	maxSize  int64
+}
+
+// expired returns the recordings to delete to stay within the limits, oldest first.
+//
+// Pinned and running recordings are never deleted and do not count toward the limits.
+func (r retention) expired(recordings []Recording, now time.Time) []Recording {
+	candidates := make([]Recording, 0, len(recordings))
+	for _, recording := range recordings {
+		if recording.Pinned || recording.Status == Running {
+			continue
+		}
+		candidates = append(candidates, recording)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Date.Before(candidates[j].Date)
+	})
+
+	var size int64
+	for _, recording := range candidates {
+		size += recording.Size
+	}
+	i := 0
+	for ; i < len(candidates); i++ {
+		recording := candidates[i]
+		tooOld := r.maxAge > 0 && now.Sub(recording.Date) > r.maxAge
+		tooMany := r.maxCount > 0 && len(candidates)-i > r.maxCount
+		tooBig := r.maxSize > 0 && size > r.maxSize
+		if !tooOld && !tooMany && !tooBig {
+			break
+		}
+		size -= recording.Size
+	}
+	return candidates[:i]
+}
+
+// runRetention periodically deletes the recordings exceeding the retention limits until the service is closed.
+func (s *Service) runRetention(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.applyRetention(time.Now())
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyRetention deletes the recordings exceeding the retention limits.
+func (s *Service) applyRetention(now time.Time) {
+	var recordings []Recording
+	limit := 100
+	offset := 0
+	for {
+		page, err := s.recordings.List("", offset, limit)
+		if err != nil {
+			s.diag.Error("failed to retrieve recordings", err)
+			return
+		}
+		recordings = append(recordings, page...)
+		if len(page) != limit {
+			break
+		}
+		offset += limit
+	}
+
+	for _, recording := range s.retention.expired(recordings, now) {
+		if err := s.deleteRecording(recording); err != nil {
+			s.diag.Error("failed to delete expired recording", err, keyvalue.KV("recording_id", recording.ID))
+			continue
+		}
+		s.diag.Info("deleted expired recording",
+			keyvalue.KV("recording_id", recording.ID),
+			keyvalue.KV("date", recording.Date.Format(time.RFC3339)),
+			keyvalue.KV("size", strconv.FormatInt(recording.Size, 10)),
+		)
+	}
+}
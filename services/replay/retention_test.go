@@ -0,0 +1,59 @@
+package replay
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetention_Expired(t *testing.T) {
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	recordings := []Recording{
+		{ID: "day2", Date: now.Add(-2 * 24 * time.Hour), Size: 100, Status: Finished},
+		{ID: "day5", Date: now.Add(-5 * 24 * time.Hour), Size: 100, Status: Failed},
+		{ID: "day9-pinned", Date: now.Add(-9 * 24 * time.Hour), Size: 1000, Status: Finished, Pinned: true},
+		{ID: "day8", Date: now.Add(-8 * 24 * time.Hour), Size: 100, Status: Finished},
+		{ID: "running", Date: now.Add(-9 * 24 * time.Hour), Size: 100, Status: Running},
+		{ID: "day1", Date: now.Add(-1 * 24 * time.Hour), Size: 100, Status: Finished},
+	}
+	testCases := []struct {
+		name      string
+		retention retention
+		exp       []string
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name:      "max count",
+			retention: retention{maxCount: 2},
+			exp:       []string{"day8", "day5"},
+		},
+		{
+			name:      "max age",
+			retention: retention{maxAge: 4 * 24 * time.Hour},
+			exp:       []string{"day8", "day5"},
+		},
+		{
+			name:      "max size",
+			retention: retention{maxSize: 250},
+			exp:       []string{"day8", "day5"},
+		},
+		{
+			name:      "strictest limit",
+			retention: retention{maxCount: 3, maxAge: 6 * 24 * time.Hour, maxSize: 150},
+			exp:       []string{"day8", "day5", "day2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, recording := range tc.retention.expired(recordings, now) {
+				got = append(got, recording.ID)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected expired recordings got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package replay
 import (
 	"archive/zip"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,12 +15,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/influxql"
 	"github.com/influxdata/kapacitor"
 	kclient "github.com/influxdata/kapacitor/client/v1"
-	"github.com/influxdata/kapacitor/clock"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/keyvalue"
@@ -41,6 +42,7 @@ const (
 	recordStreamPath       = recordingsPath + "/stream"
 	recordBatchPath        = recordingsPath + "/batch"
 	recordQueryPath        = recordingsPath + "/query"
+	recordCSVPath          = recordingsPath + "/csv"
 
 	replaysPath         = "/replays"
 	replaysPathAnchored = "/replays/"
@@ -52,6 +54,7 @@ var validID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
 
 type Diagnostic interface {
 	Error(msg string, err error, ctx ...keyvalue.T)
+	Info(msg string, ctx ...keyvalue.T)
 	Debug(msg string, ctx ...keyvalue.T)
 }
 
@@ -90,14 +93,35 @@ type Service struct {
 		Stream(name string) (kapacitor.StreamCollector, error)
 	}
 
+	// Controls of the running replays
+	controlsMu sync.RWMutex
+	controls   map[string]*replayControl
+
+	// Retention of the recordings, checked every retentionInterval if enabled.
+	retention         retention
+	retentionEnabled  bool
+	retentionInterval time.Duration
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+
 	diag Diagnostic
 }
 
 // Create a new replay master.
 func NewService(conf Config, d Diagnostic) *Service {
 	return &Service{
-		saveDir: conf.Dir,
-		diag:    d,
+		saveDir:  conf.Dir,
+		controls: make(map[string]*replayControl),
+		retention: retention{
+			maxCount: conf.MaxRecordings,
+			maxAge:   time.Duration(conf.MaxRecordingAge),
+			maxSize:  int64(conf.MaxRecordingsSize),
+		},
+		retentionEnabled:  conf.RetentionEnabled(),
+		retentionInterval: time.Duration(conf.RetentionCheckInterval),
+		closing:           make(chan struct{}),
+		diag:              d,
 	}
 }
 
@@ -140,6 +164,11 @@ func (s *Service) Open() error {
 	s.markFailedRecordings()
 	s.markFailedReplays()
 
+	if s.retentionEnabled {
+		s.wg.Add(1)
+		go s.runRetention(s.retentionInterval)
+	}
+
 	// Setup routes
 	s.routes = []httpd.Route{
 		{
@@ -152,6 +181,11 @@ func (s *Service) Open() error {
 			Pattern:     recordingsPathAnchored,
 			HandlerFunc: s.handleDeleteRecording,
 		},
+		{
+			Method:      "PATCH",
+			Pattern:     recordingsPathAnchored,
+			HandlerFunc: s.handleUpdateRecording,
+		},
 		{
 			Method:      "OPTIONS",
 			Pattern:     recordingsPathAnchored,
@@ -177,6 +211,11 @@ func (s *Service) Open() error {
 			Pattern:     recordQueryPath,
 			HandlerFunc: s.handleRecordQuery,
 		},
+		{
+			Method:      "POST",
+			Pattern:     recordCSVPath,
+			HandlerFunc: s.handleRecordCSV,
+		},
 		{
 			Method:      "GET",
 			Pattern:     replaysPathAnchored,
@@ -187,6 +226,11 @@ func (s *Service) Open() error {
 			Pattern:     replaysPathAnchored,
 			HandlerFunc: s.handleDeleteReplay,
 		},
+		{
+			Method:      "POST",
+			Pattern:     replaysPathAnchored,
+			HandlerFunc: s.handleControlReplay,
+		},
 		{
 			Method:      "OPTIONS",
 			Pattern:     replaysPathAnchored,
@@ -346,6 +390,16 @@ func (s *Service) markFailedReplays() {
 
 func (s *Service) Close() error {
 	s.HTTPDService.DelRoutes(s.routes)
+
+	close(s.closing)
+	s.wg.Wait()
+
+	// Release any paused replays
+	s.controlsMu.RLock()
+	defer s.controlsMu.RUnlock()
+	for _, c := range s.controls {
+		c.Close()
+	}
 	return nil
 }
 
@@ -388,6 +442,7 @@ func convertRecording(recording Recording) kclient.Recording {
 		Error:    recording.Error,
 		Status:   status,
 		Progress: recording.Progress,
+		Pinned:   recording.Pinned,
 	}
 }
 
@@ -404,7 +459,7 @@ func replayLink(id string) kclient.Link {
 	return kclient.Link{Relation: kclient.Self, Href: path.Join(httpd.BasePath, "replays", id)}
 }
 
-func convertReplay(replay Replay) kclient.Replay {
+func (s *Service) convertReplay(replay Replay) kclient.Replay {
 	var clk kclient.Clock
 	stats := kclient.ExecutionStats{}
 	switch replay.Clock {
@@ -423,8 +478,12 @@ func convertReplay(replay Replay) kclient.Replay {
 		status = kclient.Finished
 		stats.TaskStats = replay.ExecutionStats.TaskStats
 		stats.NodeStats = replay.ExecutionStats.NodeStats
+	case Canceled:
+		status = kclient.Canceled
 	}
 
+	paused, position, emitted := s.replayState(replay)
+
 	return kclient.Replay{
 		Link:           replayLink(replay.ID),
 		ID:             replay.ID,
@@ -432,14 +491,29 @@ func convertReplay(replay Replay) kclient.Replay {
 		Task:           replay.TaskID,
 		RecordingTime:  replay.RecordingTime,
 		Clock:          clk,
+		Speed:          replay.Speed,
 		Date:           replay.Date,
 		Error:          replay.Error,
 		Status:         status,
 		Progress:       replay.Progress,
+		Paused:         paused,
+		Position:       kclient.Duration(position),
+		Emitted:        emitted,
 		ExecutionStats: stats,
 	}
 }
 
+// replayState returns the current state of the replay, from its control if it is running.
+func (s *Service) replayState(replay Replay) (paused bool, position time.Duration, emitted int64) {
+	s.controlsMu.RLock()
+	c, ok := s.controls[replay.ID]
+	s.controlsMu.RUnlock()
+	if ok {
+		return c.State()
+	}
+	return false, replay.Position, replay.Emitted
+}
+
 var allRecordingFields = []string{
 	"link",
 	"id",
@@ -449,6 +523,7 @@ var allRecordingFields = []string{
 	"error",
 	"status",
 	"progress",
+	"pinned",
 }
 
 func (s *Service) handleListRecordings(w http.ResponseWriter, r *http.Request) {
@@ -522,6 +597,8 @@ func (s *Service) handleListRecordings(w http.ResponseWriter, r *http.Request) {
 				}
 			case "progress":
 				value = recording.Progress
+			case "pinned":
+				value = recording.Pinned
 			default:
 				httpd.HttpError(w, fmt.Sprintf("unsupported field %q", field), true, http.StatusBadRequest)
 				return
@@ -571,24 +648,56 @@ func (s *Service) handleDeleteRecording(w http.ResponseWriter, r *http.Request)
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
-	err = s.recordings.Delete(rid)
-	if err != nil {
+	if err := s.deleteRecording(recording); err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteRecording deletes the recording and its data.
+func (s *Service) deleteRecording(recording Recording) error {
+	if err := s.recordings.Delete(recording.ID); err != nil {
+		return err
+	}
 	ds, err := parseDataSourceURL(recording.DataURL)
 	if err != nil {
-		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return err
+	}
+	return ds.Remove()
+}
+
+func (s *Service) handleUpdateRecording(w http.ResponseWriter, r *http.Request) {
+	rid, err := s.recordingIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	var opt kclient.UpdateRecordingOptions
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
 
-	err = ds.Remove()
+	recording, err := s.recordings.Get(rid)
+	if err == ErrNoRecordingExists {
+		httpd.HttpError(w, fmt.Sprintf("recording %s does not exist", rid), true, http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		httpd.HttpError(w, "error finding recording: "+err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	if opt.Pinned != nil {
+		recording.Pinned = *opt.Pinned
+	}
+	if err := s.recordings.Replace(recording); err != nil {
+		httpd.HttpError(w, "failed to update recording: "+err.Error(), true, http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Write(httpd.MarshalJSON(convertRecording(recording), true))
 }
 
 func (s *Service) dataURLFromID(id, ext string) url.URL {
@@ -751,6 +860,71 @@ func (s *Service) handleRecordQuery(w http.ResponseWriter, req *http.Request) {
 	w.Write(httpd.MarshalJSON(convertRecording(recording), true))
 }
 
+func (s *Service) handleRecordCSV(w http.ResponseWriter, req *http.Request) {
+	var opt kclient.RecordCSVOptions
+	dec := json.NewDecoder(req.Body)
+	err := dec.Decode(&opt)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if opt.ID == "" {
+		opt.ID = uuid.New().String()
+	}
+	if !validID.MatchString(opt.ID) {
+		httpd.HttpError(w, fmt.Sprintf("recording ID must contain only letters, numbers, '-', '.' and '_'. %q", opt.ID), true, http.StatusBadRequest)
+		return
+	}
+	var dataUrl url.URL
+	var typ RecordingType
+	switch opt.Type {
+	case kclient.StreamTask:
+		if opt.Database == "" || opt.RetentionPolicy == "" {
+			httpd.HttpError(w, "must provide database and retention policy for stream recordings", true, http.StatusBadRequest)
+			return
+		}
+		dataUrl = s.dataURLFromID(opt.ID, streamEXT)
+		typ = StreamRecording
+	case kclient.BatchTask:
+		dataUrl = s.dataURLFromID(opt.ID, batchEXT)
+		typ = BatchRecording
+	default:
+		httpd.HttpError(w, "must provide recording type of stream or batch", true, http.StatusBadRequest)
+		return
+	}
+
+	// Read all rows before creating the recording so malformed data is reported to the client.
+	points, err := readCSVPoints(strings.NewReader(opt.Data), opt)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid CSV data: %s", err), true, http.StatusBadRequest)
+		return
+	}
+
+	recording := Recording{
+		ID:      opt.ID,
+		DataURL: dataUrl.String(),
+		Type:    typ,
+		Date:    time.Now(),
+		Status:  Running,
+	}
+	err = s.recordings.Create(recording)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+
+	ds, _ := parseDataSourceURL(dataUrl.String())
+	s.updateRecordingResult(recording, ds, s.saveCSVRecording(ds, typ, points))
+	recording, err = s.recordings.Get(recording.ID)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(httpd.MarshalJSON(convertRecording(recording), true))
+}
+
 func (s *Service) updateRecordingResult(recording Recording, ds DataSource, err error) {
 	recording.Status = Finished
 	if err != nil {
@@ -759,6 +933,10 @@ func (s *Service) updateRecordingResult(recording Recording, ds DataSource, err
 	}
 	recording.Date = time.Now()
 	recording.Progress = 1.0
+	// Keep the recording pinned if it was pinned while running.
+	if stored, err := s.recordings.Get(recording.ID); err == nil {
+		recording.Pinned = stored.Pinned
+	}
 	recording.Size, err = ds.Size()
 	if err != nil {
 		s.diag.Error("failed to determine size of recording", err, keyvalue.KV("recording_id", recording.ID))
@@ -770,9 +948,18 @@ func (s *Service) updateRecordingResult(recording Recording, ds DataSource, err
 	}
 }
 
-func (s *Service) updateReplayResult(replay *Replay, err error) {
+func (s *Service) updateReplayResult(replay *Replay, c *replayControl, err error) {
+	s.controlsMu.Lock()
+	delete(s.controls, replay.ID)
+	s.controlsMu.Unlock()
+	c.Close()
+	_, replay.Position, replay.Emitted = c.State()
+
 	replay.Status = Finished
-	if err != nil {
+	switch {
+	case err == errReplayCanceled:
+		replay.Status = Canceled
+	case err != nil:
 		replay.Status = Failed
 		replay.Error = err.Error()
 	}
@@ -785,6 +972,27 @@ func (s *Service) updateReplayResult(replay *Replay, err error) {
 	}
 }
 
+// replayClock returns the clock type of a replay, validating the speed multiplier.
+func replayClock(c kclient.Clock, speed float64) (Clock, error) {
+	if speed < 0 {
+		return 0, fmt.Errorf("invalid speed %v, must be positive", speed)
+	}
+	switch c {
+	case kclient.Real:
+		return Real, nil
+	case kclient.Fast:
+		return Fast, nil
+	default:
+		return 0, fmt.Errorf("invalid clock type %v", c)
+	}
+}
+
+func (s *Service) addReplayControl(id string, c *replayControl) {
+	s.controlsMu.Lock()
+	s.controls[id] = c
+	s.controlsMu.Unlock()
+}
+
 func (s *Service) handleReplay(w http.ResponseWriter, req *http.Request) {
 	id, err := s.replayIDFromPath(req.URL.Path)
 	if err != nil {
@@ -802,7 +1010,61 @@ func (s *Service) handleReplay(w http.ResponseWriter, req *http.Request) {
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
-	w.Write(httpd.MarshalJSON(convertReplay(replay), true))
+	w.Write(httpd.MarshalJSON(s.convertReplay(replay), true))
+}
+
+// handleControlReplay pauses, resumes, steps or cancels a running replay.
+// The path is of the form /replays/<id>/<action>, where action is one of pause, resume, step or cancel.
+// A step emits the number of points given by the count parameter, defaulting to one point.
+func (s *Service) handleControlReplay(w http.ResponseWriter, req *http.Request) {
+	p, err := s.replayIDFromPath(req.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	id, action := path.Split(p)
+	id = strings.TrimSuffix(id, "/")
+	if id == "" {
+		httpd.HttpError(w, "must specify replay id and action on path", true, http.StatusBadRequest)
+		return
+	}
+	replay, err := s.replays.Get(id)
+	if err != nil {
+		httpd.HttpError(w, "could not find replay: "+err.Error(), true, http.StatusNotFound)
+		return
+	}
+	s.controlsMu.RLock()
+	c, ok := s.controls[id]
+	s.controlsMu.RUnlock()
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("replay %q is not running", id), true, http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "pause":
+		c.Pause()
+	case "resume":
+		c.Resume()
+	case "step":
+		count := int64(1)
+		if countStr := req.URL.Query().Get("count"); countStr != "" {
+			count, err = strconv.ParseInt(countStr, 10, 64)
+			if err != nil || count < 1 {
+				httpd.HttpError(w, fmt.Sprintf("invalid count parameter %q must be a positive integer", countStr), true, http.StatusBadRequest)
+				return
+			}
+		}
+		c.Step(int(count))
+	case "cancel":
+		c.Cancel()
+	default:
+		httpd.HttpError(w, fmt.Sprintf("unknown replay action %q", action), true, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(httpd.MarshalJSON(s.convertReplay(replay), true))
 }
 
 func (s *Service) handleDeleteReplay(w http.ResponseWriter, req *http.Request) {
@@ -823,10 +1085,14 @@ var allReplayFields = []string{
 	"task",
 	"recording-time",
 	"clock",
+	"speed",
 	"date",
 	"error",
 	"status",
 	"progress",
+	"paused",
+	"position",
+	"emitted",
 }
 
 func (s *Service) handleListReplays(w http.ResponseWriter, r *http.Request) {
@@ -868,6 +1134,7 @@ func (s *Service) handleListReplays(w http.ResponseWriter, r *http.Request) {
 
 	rs := make([]map[string]interface{}, len(replays))
 	for i, replay := range replays {
+		paused, position, emitted := s.replayState(replay)
 		rs[i] = make(map[string]interface{}, len(fields))
 		for _, field := range fields {
 			var value interface{}
@@ -901,9 +1168,19 @@ func (s *Service) handleListReplays(w http.ResponseWriter, r *http.Request) {
 					value = kclient.Running
 				case Finished:
 					value = kclient.Finished
+				case Canceled:
+					value = kclient.Canceled
 				}
 			case "progress":
 				value = replay.Progress
+			case "speed":
+				value = replay.Speed
+			case "paused":
+				value = paused
+			case "position":
+				value = kclient.Duration(position)
+			case "emitted":
+				value = emitted
 			default:
 				httpd.HttpError(w, fmt.Sprintf("unsupported field %q", field), true, http.StatusBadRequest)
 				return
@@ -946,19 +1223,12 @@ func (s *Service) handleCreateReplay(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var clk clock.Clock
-	var clockType Clock
-	switch opt.Clock {
-	case kclient.Real:
-		clk = clock.Wall()
-		clockType = Real
-	case kclient.Fast:
-		clk = clock.Fast()
-		clockType = Fast
-	default:
-		httpd.HttpError(w, fmt.Sprintf("invalid clock type %v", opt.Clock), true, http.StatusBadRequest)
+	clockType, err := replayClock(opt.Clock, opt.Speed)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
+	clk := newReplayControl(clockType == Real, opt.Speed, opt.Paused)
 
 	// Successfully started replay
 	replay := Replay{
@@ -967,19 +1237,21 @@ func (s *Service) handleCreateReplay(w http.ResponseWriter, req *http.Request) {
 		TaskID:         opt.Task,
 		RecordingTime:  opt.RecordingTime,
 		Clock:          clockType,
+		Speed:          clk.speed,
 		Date:           time.Now(),
 		Status:         Running,
 		ExecutionStats: ExecutionStats{},
 	}
 	s.replays.Create(replay)
+	s.addReplayControl(replay.ID, clk)
 
 	go func(replay Replay) {
 		err := s.doReplayFromRecording(&replay, t, recording, clk, opt.RecordingTime)
-		s.updateReplayResult(&replay, err)
+		s.updateReplayResult(&replay, clk, err)
 	}(replay)
 
 	w.WriteHeader(http.StatusCreated)
-	w.Write(httpd.MarshalJSON(convertReplay(replay), true))
+	w.Write(httpd.MarshalJSON(s.convertReplay(replay), true))
 }
 
 func (s *Service) handleReplayBatch(w http.ResponseWriter, req *http.Request) {
@@ -1006,19 +1278,12 @@ func (s *Service) handleReplayBatch(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var clk clock.Clock
-	var clockType Clock
-	switch opt.Clock {
-	case kclient.Real:
-		clk = clock.Wall()
-		clockType = Real
-	case kclient.Fast:
-		clk = clock.Fast()
-		clockType = Fast
-	default:
-		httpd.HttpError(w, fmt.Sprintf("invalid clock type %v", opt.Clock), true, http.StatusBadRequest)
+	clockType, err := replayClock(opt.Clock, opt.Speed)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
+	clk := newReplayControl(clockType == Real, opt.Speed, opt.Paused)
 	if t.Type == kapacitor.StreamTask {
 		httpd.HttpError(w, fmt.Sprintf("cannot replay batch against stream task: %s", opt.Task), true, http.StatusBadRequest)
 		return
@@ -1030,6 +1295,7 @@ func (s *Service) handleReplayBatch(w http.ResponseWriter, req *http.Request) {
 		TaskID:        opt.Task,
 		RecordingTime: opt.RecordingTime,
 		Clock:         clockType,
+		Speed:         clk.speed,
 		Date:          time.Now(),
 		Status:        Running,
 	}
@@ -1038,14 +1304,15 @@ func (s *Service) handleReplayBatch(w http.ResponseWriter, req *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	s.addReplayControl(replay.ID, clk)
 
 	go func(replay Replay) {
 		err := s.doLiveBatchReplay(&replay, t, clk, opt.RecordingTime, opt.Start, opt.Stop)
-		s.updateReplayResult(&replay, err)
+		s.updateReplayResult(&replay, clk, err)
 	}(replay)
 
 	w.WriteHeader(http.StatusCreated)
-	w.Write(httpd.MarshalJSON(convertReplay(replay), true))
+	w.Write(httpd.MarshalJSON(s.convertReplay(replay), true))
 }
 
 func (r *Service) handleReplayQuery(w http.ResponseWriter, req *http.Request) {
@@ -1074,25 +1341,19 @@ func (r *Service) handleReplayQuery(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var clk clock.Clock
-	var clockType Clock
-	switch opt.Clock {
-	case kclient.Real:
-		clk = clock.Wall()
-		clockType = Real
-	case kclient.Fast:
-		clk = clock.Fast()
-		clockType = Fast
-	default:
-		httpd.HttpError(w, fmt.Sprintf("invalid clock type %v", opt.Clock), true, http.StatusBadRequest)
+	clockType, err := replayClock(opt.Clock, opt.Speed)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
+	clk := newReplayControl(clockType == Real, opt.Speed, opt.Paused)
 
 	replay := Replay{
 		ID:            opt.ID,
 		TaskID:        opt.Task,
 		RecordingTime: opt.RecordingTime,
 		Clock:         clockType,
+		Speed:         clk.speed,
 		Date:          time.Now(),
 		Status:        Running,
 	}
@@ -1101,17 +1362,18 @@ func (r *Service) handleReplayQuery(w http.ResponseWriter, req *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	r.addReplayControl(replay.ID, clk)
 
 	go func(replay Replay) {
 		err := r.doLiveQueryReplay(&replay, t, clk, opt.RecordingTime, opt.Query, opt.Cluster)
-		r.updateReplayResult(&replay, err)
+		r.updateReplayResult(&replay, clk, err)
 	}(replay)
 
 	w.WriteHeader(http.StatusCreated)
-	w.Write(httpd.MarshalJSON(convertReplay(replay), true))
+	w.Write(httpd.MarshalJSON(r.convertReplay(replay), true))
 }
 
-func (r *Service) doReplayFromRecording(replay *Replay, task *kapacitor.Task, recording Recording, clk clock.Clock, recTime bool) error {
+func (r *Service) doReplayFromRecording(replay *Replay, task *kapacitor.Task, recording Recording, clk *replayControl, recTime bool) error {
 	dataSource, err := parseDataSourceURL(recording.DataURL)
 	if err != nil {
 		return errors.Wrap(err, "load data source")
@@ -1128,28 +1390,28 @@ func (r *Service) doReplayFromRecording(replay *Replay, task *kapacitor.Task, re
 			if err != nil {
 				return errors.Wrap(err, "stream start")
 			}
-			replayC = kapacitor.ReplayStreamFromIO(clk, f, stream, recTime, precision)
+			replayC = kapacitor.ReplayStreamFromIO(clk, f, cancelStreamCollector{StreamCollector: stream, ctx: clk.Context()}, recTime, precision)
 		case kapacitor.BatchTask:
 			fs, err := dataSource.BatchReaders()
 			if err != nil {
 				return errors.Wrap(err, "data source open")
 			}
-			collectors := tm.BatchCollectors(task.ID)
+			collectors := cancelBatchCollectors(clk.Context(), tm.BatchCollectors(task.ID))
 			replayC = kapacitor.ReplayBatchFromIO(clk, fs, collectors, recTime)
 		}
 		return <-replayC
 	}
-	return r.doReplay(replay, task, runReplay)
+	return r.doReplay(clk.Context(), replay, task, runReplay)
 
 }
 
-func (r *Service) doLiveBatchReplay(replay *Replay, task *kapacitor.Task, clk clock.Clock, recTime bool, start, stop time.Time) error {
+func (r *Service) doLiveBatchReplay(replay *Replay, task *kapacitor.Task, clk *replayControl, recTime bool, start, stop time.Time) error {
 	runReplay := func(tm *kapacitor.TaskMaster) error {
-		sources, recordErrC, err := r.startRecordBatch(task, start, stop)
+		sources, recordErrC, err := r.startRecordBatch(clk.Context(), task, start, stop)
 		if err != nil {
 			return err
 		}
-		collectors := tm.BatchCollectors(task.ID)
+		collectors := cancelBatchCollectors(clk.Context(), tm.BatchCollectors(task.ID))
 		replayErrC := kapacitor.ReplayBatchFromChan(clk, sources, collectors, recTime)
 		for i := 0; i < 2; i++ {
 			var err error
@@ -1163,10 +1425,10 @@ func (r *Service) doLiveBatchReplay(replay *Replay, task *kapacitor.Task, clk cl
 		}
 		return nil
 	}
-	return r.doReplay(replay, task, runReplay)
+	return r.doReplay(clk.Context(), replay, task, runReplay)
 }
 
-func (r *Service) doLiveQueryReplay(replay *Replay, task *kapacitor.Task, clk clock.Clock, recTime bool, query, cluster string) error {
+func (r *Service) doLiveQueryReplay(replay *Replay, task *kapacitor.Task, clk *replayControl, recTime bool, query, cluster string) error {
 	runReplay := func(tm *kapacitor.TaskMaster) error {
 		var replayErrC <-chan error
 		runErrC := make(chan error, 1)
@@ -1174,19 +1436,19 @@ func (r *Service) doLiveQueryReplay(replay *Replay, task *kapacitor.Task, clk cl
 		case kapacitor.StreamTask:
 			source := make(chan edge.PointMessage)
 			go func() {
-				runErrC <- r.runQueryStream(source, query, cluster)
+				runErrC <- r.runQueryStream(clk.Context(), source, query, cluster)
 			}()
 			stream, err := tm.Stream(replay.ID)
 			if err != nil {
 				return errors.Wrap(err, "stream start")
 			}
-			replayErrC = kapacitor.ReplayStreamFromChan(clk, source, stream, recTime)
+			replayErrC = kapacitor.ReplayStreamFromChan(clk, source, cancelStreamCollector{StreamCollector: stream, ctx: clk.Context()}, recTime)
 		case kapacitor.BatchTask:
 			source := make(chan edge.BufferedBatchMessage)
 			go func() {
-				runErrC <- r.runQueryBatch(source, query, cluster)
+				runErrC <- r.runQueryBatch(clk.Context(), source, query, cluster)
 			}()
-			collectors := tm.BatchCollectors(task.ID)
+			collectors := cancelBatchCollectors(clk.Context(), tm.BatchCollectors(task.ID))
 			replayErrC = kapacitor.ReplayBatchFromChan(clk, []<-chan edge.BufferedBatchMessage{source}, collectors, recTime)
 		}
 		for i := 0; i < 2; i++ {
@@ -1201,10 +1463,47 @@ func (r *Service) doLiveQueryReplay(replay *Replay, task *kapacitor.Task, clk cl
 		}
 		return nil
 	}
-	return r.doReplay(replay, task, runReplay)
+	return r.doReplay(clk.Context(), replay, task, runReplay)
+}
+
+// errReplayCanceled is returned by doReplay when the context of the replay has been canceled.
+var errReplayCanceled = errors.New("replay canceled")
+
+// cancelStreamCollector stops collecting the points of a replay once it has been canceled.
+type cancelStreamCollector struct {
+	kapacitor.StreamCollector
+	ctx context.Context
 }
 
-func (r *Service) doReplay(replay *Replay, task *kapacitor.Task, runReplay func(tm *kapacitor.TaskMaster) error) error {
+func (c cancelStreamCollector) CollectPoint(p edge.PointMessage) error {
+	if c.ctx.Err() != nil {
+		return errReplayCanceled
+	}
+	return c.StreamCollector.CollectPoint(p)
+}
+
+// cancelBatchCollectors wraps the collectors so that they stop collecting the batches of a replay once it has been canceled.
+func cancelBatchCollectors(ctx context.Context, collectors []kapacitor.BatchCollector) []kapacitor.BatchCollector {
+	wrapped := make([]kapacitor.BatchCollector, len(collectors))
+	for i, c := range collectors {
+		wrapped[i] = cancelBatchCollector{BatchCollector: c, ctx: ctx}
+	}
+	return wrapped
+}
+
+type cancelBatchCollector struct {
+	kapacitor.BatchCollector
+	ctx context.Context
+}
+
+func (c cancelBatchCollector) CollectBatch(b edge.BufferedBatchMessage) error {
+	if c.ctx.Err() != nil {
+		return errReplayCanceled
+	}
+	return c.BatchCollector.CollectBatch(b)
+}
+
+func (r *Service) doReplay(ctx context.Context, replay *Replay, task *kapacitor.Task, runReplay func(tm *kapacitor.TaskMaster) error) error {
 	// Create new isolated task master
 	tm := r.TaskMaster.New(replay.ID)
 	r.TaskMasterLookup.Set(tm)
@@ -1227,6 +1526,9 @@ func (r *Service) doReplay(replay *Replay, task *kapacitor.Task, runReplay func(
 
 	// Run the replay
 	err = runReplay(tm)
+	if ctx.Err() != nil {
+		return errReplayCanceled
+	}
 	if err != nil {
 		return errors.Wrap(err, "running replay")
 	}
@@ -1339,7 +1641,7 @@ func (b batchArchive) Close() error {
 
 // Record a series of batch queries defined by a batch task
 func (s *Service) doRecordBatch(dataSource DataSource, t *kapacitor.Task, start, stop time.Time) error {
-	sources, recordErrC, err := s.startRecordBatch(t, start, stop)
+	sources, recordErrC, err := s.startRecordBatch(context.Background(), t, start, stop)
 	if err != nil {
 		return err
 	}
@@ -1360,7 +1662,7 @@ func (s *Service) doRecordBatch(dataSource DataSource, t *kapacitor.Task, start,
 	return nil
 }
 
-func (s *Service) startRecordBatch(t *kapacitor.Task, start, stop time.Time) ([]<-chan edge.BufferedBatchMessage, <-chan error, error) {
+func (s *Service) startRecordBatch(ctx context.Context, t *kapacitor.Task, start, stop time.Time) ([]<-chan edge.BufferedBatchMessage, <-chan error, error) {
 	// We do not open the task master so it does not need to be closed
 	et, err := kapacitor.NewExecutingTask(s.TaskMaster.New(""), t)
 	if err != nil {
@@ -1397,6 +1699,7 @@ func (s *Service) startRecordBatch(t *kapacitor.Task, start, stop time.Time) ([]
 
 				query := influxdb.Query{
 					Command: q.String(),
+					Context: ctx,
 				}
 				resp, err := cli.Query(query)
 				if err != nil {
@@ -1414,7 +1717,12 @@ func (s *Service) startRecordBatch(t *kapacitor.Task, start, stop time.Time) ([]
 						if b.Begin().Time().IsZero() || !q.IsGroupedByTime() {
 							b.Begin().SetTime(q.StopTime())
 						}
-						source <- b
+						select {
+						case source <- b:
+						case <-ctx.Done():
+							errors <- ctx.Err()
+							return
+						}
 					}
 				}
 			}
@@ -1459,7 +1767,7 @@ func (r *Service) doRecordQuery(dataSource DataSource, q string, typ RecordingTy
 	case StreamRecording:
 		points := make(chan edge.PointMessage)
 		go func() {
-			errC <- r.runQueryStream(points, q, cluster)
+			errC <- r.runQueryStream(context.Background(), points, q, cluster)
 		}()
 		go func() {
 			errC <- r.saveStreamQuery(dataSource, points, precision)
@@ -1467,7 +1775,7 @@ func (r *Service) doRecordQuery(dataSource DataSource, q string, typ RecordingTy
 	case BatchRecording:
 		batches := make(chan edge.BufferedBatchMessage)
 		go func() {
-			errC <- r.runQueryBatch(batches, q, cluster)
+			errC <- r.runQueryBatch(context.Background(), batches, q, cluster)
 		}()
 		go func() {
 			errC <- r.saveBatchQuery(dataSource, batches)
@@ -1482,9 +1790,9 @@ func (r *Service) doRecordQuery(dataSource DataSource, q string, typ RecordingTy
 	return nil
 }
 
-func (r *Service) runQueryStream(source chan<- edge.PointMessage, q, cluster string) error {
+func (r *Service) runQueryStream(ctx context.Context, source chan<- edge.PointMessage, q, cluster string) error {
 	defer close(source)
-	dbrp, resp, err := r.execQuery(q, cluster)
+	dbrp, resp, err := r.execQuery(ctx, q, cluster)
 	if err != nil {
 		return err
 	}
@@ -1543,7 +1851,11 @@ func (r *Service) runQueryStream(source chan<- edge.PointMessage, q, cluster str
 						bp.Tags(),
 						bp.Time(),
 					)
-					source <- p
+					select {
+					case source <- p:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
 				}
 				// Remove written points
 				batches[b].SetPoints(batches[b].Points()[i:])
@@ -1554,9 +1866,9 @@ func (r *Service) runQueryStream(source chan<- edge.PointMessage, q, cluster str
 	return nil
 }
 
-func (r *Service) runQueryBatch(source chan<- edge.BufferedBatchMessage, q string, cluster string) error {
+func (r *Service) runQueryBatch(ctx context.Context, source chan<- edge.BufferedBatchMessage, q string, cluster string) error {
 	defer close(source)
-	_, resp, err := r.execQuery(q, cluster)
+	_, resp, err := r.execQuery(ctx, q, cluster)
 	if err != nil {
 		return err
 	}
@@ -1567,7 +1879,11 @@ func (r *Service) runQueryBatch(source chan<- edge.BufferedBatchMessage, q strin
 			return err
 		}
 		for _, batch := range batches {
-			source <- batch
+			select {
+			case source <- batch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	}
 	return nil
@@ -1608,7 +1924,44 @@ func (s *Service) saveStreamQuery(dataSource DataSource, points <-chan edge.Poin
 	return sw.Close()
 }
 
-func (s *Service) execQuery(q, cluster string) (kapacitor.DBRP, *influxdb.Response, error) {
+// Save points read from CSV data as a recording.
+// Batch recordings contain a batch per series.
+func (s *Service) saveCSVRecording(dataSource DataSource, typ RecordingType, points []edge.PointMessage) error {
+	switch typ {
+	case StreamRecording:
+		sw, err := dataSource.StreamWriter()
+		if err != nil {
+			return err
+		}
+		for _, p := range points {
+			if err := kapacitor.WritePointForRecording(sw, p, precision); err != nil {
+				sw.Close()
+				return err
+			}
+		}
+		return sw.Close()
+	case BatchRecording:
+		archiver, err := dataSource.BatchArchiver()
+		if err != nil {
+			return err
+		}
+		w, err := archiver.Archive(0)
+		if err != nil {
+			archiver.Close()
+			return err
+		}
+		for _, b := range csvBatches(points) {
+			if err := kapacitor.WriteBatchForRecording(w, b); err != nil {
+				archiver.Close()
+				return err
+			}
+		}
+		return archiver.Close()
+	}
+	return fmt.Errorf("unknown recording type %v", typ)
+}
+
+func (s *Service) execQuery(ctx context.Context, q, cluster string) (kapacitor.DBRP, *influxdb.Response, error) {
 	// Parse query to determine dbrp
 	dbrp := kapacitor.DBRP{}
 	stmt, err := influxql.ParseStatement(q)
@@ -1634,6 +1987,7 @@ func (s *Service) execQuery(q, cluster string) (kapacitor.DBRP, *influxdb.Respon
 	}
 	query := influxdb.Query{
 		Command: q,
+		Context: ctx,
 	}
 	resp, err := con.Query(query)
 	if err != nil {
@@ -134,7 +134,7 @@ func (s *Service) prepareData(name, source, output string, handlers []string, me
 	}
 
 	var status int
-	switch level {
+	switch level.Builtin() {
 	case alert.OK:
 		status = 0
 	case alert.Info:
@@ -273,7 +273,7 @@ func (s *Service) preparePost(url, alertID, message string, level alert.Level, d
 
 	// convert event level to ServiceNow severity (OK (5), Warning (4), Minor (3), Major (2), Critical (1))
 	severity := 0
-	switch level {
+	switch level.Builtin() {
 	case alert.OK:
 		fallthrough
 	case alert.Info:
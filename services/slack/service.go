@@ -195,6 +195,13 @@ type attachment struct {
 	Color     string   `json:"color"`
 	Text      string   `json:"text"`
 	Mrkdwn_in []string `json:"mrkdwn_in"`
+	Actions   []action `json:"actions,omitempty"`
+}
+
+type action struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
 }
 
 type testOptions struct {
@@ -221,15 +228,19 @@ func (s *Service) Test(options interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
-	return s.Alert(o.Workspace, o.Channel, o.Message, o.Username, o.IconEmoji, o.Level)
+	return s.Alert(o.Workspace, o.Channel, o.Message, "", o.Username, o.IconEmoji, o.Level)
 }
 
-func (s *Service) Alert(workspace, channel, message, username, iconEmoji string, level alert.Level) error {
-	url, token, post, err := s.preparePost(workspace, channel, message, username, iconEmoji, level)
+func (s *Service) Alert(workspace, channel, message, runbook, username, iconEmoji string, level alert.Level) error {
+	url, token, post, err := s.preparePost(workspace, channel, message, runbook, username, iconEmoji, level)
 	if err != nil {
 		return err
 	}
+	return s.post(workspace, url, token, post)
+}
 
+// post sends the prepared post to Slack.
+func (s *Service) post(workspace, url, token string, post io.Reader) error {
 	client, err := s.client(workspace)
 	if err != nil {
 		return err
@@ -266,7 +277,7 @@ func (s *Service) Alert(workspace, channel, message, username, iconEmoji string,
 	return nil
 }
 
-func (s *Service) preparePost(workspace, channel, message, username, iconEmoji string, level alert.Level) (string, string, io.Reader, error) {
+func (s *Service) preparePost(workspace, channel, message, runbook, username, iconEmoji string, level alert.Level) (string, string, io.Reader, error) {
 	c, err := s.config(workspace)
 	if err != nil {
 		return "", "", nil, err
@@ -278,7 +289,7 @@ func (s *Service) preparePost(workspace, channel, message, username, iconEmoji s
 		channel = c.Channel
 	}
 	var color string
-	switch level {
+	switch level.Builtin() {
 	case alert.Warning:
 		color = "warning"
 	case alert.Critical:
@@ -292,6 +303,13 @@ func (s *Service) preparePost(workspace, channel, message, username, iconEmoji s
 		Color:     color,
 		Mrkdwn_in: []string{"text"},
 	}
+	if runbook != "" {
+		a.Actions = []action{{
+			Type: "button",
+			Text: "Runbook",
+			URL:  runbook,
+		}}
+	}
 	postData := make(map[string]interface{})
 	postData["channel"] = channel
 	postData["text"] = ""
@@ -333,32 +351,54 @@ type HandlerConfig struct {
 	// IconEmoji is an emoji name surrounded in ':' characters.
 	// The emoji image will replace the normal user icon for the slack bot.
 	IconEmoji string `mapstructure:"icon-emoji"`
+
+	// MaxPayloadSize is the maximum size in bytes of the posts, larger posts have their message truncated.
+	// Zero means no limit.
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 type handler struct {
-	s    *Service
-	c    HandlerConfig
-	diag Diagnostic
+	s     *Service
+	c     HandlerConfig
+	limit *alert.PayloadLimit
+	diag  Diagnostic
 }
 
 func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 	return &handler{
-		s:    s,
-		c:    c,
-		diag: s.diag.WithContext(ctx...),
+		s:     s,
+		c:     c,
+		limit: alert.NewPayloadLimit("slack", c.MaxPayloadSize),
+		diag:  s.diag.WithContext(ctx...),
 	}
 }
 
 func (h *handler) Handle(event alert.Event) {
-
-	if err := h.s.Alert(
-		h.c.Workspace,
-		h.c.Channel,
-		event.State.Message,
-		h.c.Username,
-		h.c.IconEmoji,
-		event.State.Level,
-	); err != nil {
+	var url, token string
+	_, body, err := h.limit.Fit(event, func(e alert.Event) ([]byte, error) {
+		u, t, post, err := h.s.preparePost(
+			h.c.Workspace,
+			h.c.Channel,
+			e.State.Message,
+			e.State.Runbook,
+			h.c.Username,
+			h.c.IconEmoji,
+			e.State.Level,
+		)
+		if err != nil {
+			return nil, err
+		}
+		url, token = u, t
+		return io.ReadAll(post)
+	})
+	if err == nil {
+		err = h.s.post(h.c.Workspace, url, token, bytes.NewReader(body))
+	}
+	if err != nil {
 		h.diag.Error("failed to send event", err)
 	}
 }
+
+func (h *handler) RendersRunbook() bool {
+	return true
+}
@@ -63,4 +63,11 @@ type Attachment struct {
 	Color     string   `json:"color"`
 	Text      string   `json:"text"`
 	Mrkdwn_in []string `json:"mrkdwn_in"`
+	Actions   []Action `json:"actions,omitempty"`
+}
+
+type Action struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
 }
@@ -1,6 +1,8 @@
 package stats
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
@@ -12,6 +14,16 @@ const (
 	DefaultStatsInterval           = toml.Duration(10 * time.Second)
 	DefaultTimingSampleRate        = 0.10
 	DefaultTimingMovingAverageSize = 1000
+	DefaultRuntimeStatsInterval    = toml.Duration(30 * time.Second)
+	DefaultLevel                   = LevelNode
+)
+
+// Levels of detail of the reported stats.
+const (
+	// LevelNode reports all stats, including the stats of every node and edge of every task.
+	LevelNode = "node"
+	// LevelTask reports all stats except the stats of the nodes and edges of the tasks.
+	LevelTask = "task"
 )
 
 type Config struct {
@@ -21,6 +33,14 @@ type Config struct {
 	RetentionPolicy         string        `toml:"retention-policy"`
 	TimingSampleRate        float64       `toml:"timing-sample-rate"`
 	TimingMovingAverageSize int           `toml:"timing-movavg-size"`
+	// RuntimeStatsInterval is how often Go runtime and GC metrics are sampled.
+	// A zero value disables runtime sampling.
+	RuntimeStatsInterval toml.Duration `toml:"runtime-stats-interval"`
+	// Level is the level of detail of the reported stats, one of "node" or "task".
+	// Large deployments can use the "task" level to not report the node and edge stats.
+	Level string `toml:"level"`
+	// Exclude is a list of the names of the stats measurements that are not reported.
+	Exclude []string `toml:"exclude"`
 }
 
 func NewConfig() Config {
@@ -31,5 +51,22 @@ func NewConfig() Config {
 		StatsInterval:           DefaultStatsInterval,
 		TimingSampleRate:        DefaultTimingSampleRate,
 		TimingMovingAverageSize: DefaultTimingMovingAverageSize,
+		RuntimeStatsInterval:    DefaultRuntimeStatsInterval,
+		Level:                   DefaultLevel,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.StatsInterval <= 0 {
+		return errors.New("stats-interval must be positive")
+	}
+	if c.RuntimeStatsInterval < 0 {
+		return errors.New("runtime-stats-interval must not be negative")
+	}
+	switch c.Level {
+	case "", LevelNode, LevelTask:
+	default:
+		return fmt.Errorf("invalid level %q, must be one of %q or %q", c.Level, LevelNode, LevelTask)
 	}
+	return nil
 }
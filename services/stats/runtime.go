@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+const (
+	// RuntimeStatsName is the measurement name of the sampled runtime stats.
+	RuntimeStatsName = "go_runtime"
+
+	statNumGoroutine  = "num_goroutine"
+	statHeapAlloc     = "heap_alloc"
+	statHeapInUse     = "heap_inuse"
+	statHeapObjects   = "heap_objects"
+	statNextGC        = "next_gc"
+	statNumGC         = "num_gc"
+	statGCCPUFraction = "gc_cpu_fraction"
+	statGCPauseP50    = "gc_pause_p50_ns"
+	statGCPauseP90    = "gc_pause_p90_ns"
+	statGCPauseP99    = "gc_pause_p99_ns"
+	statGCPauseMax    = "gc_pause_max_ns"
+)
+
+// runtimeStats periodically samples the Go runtime and publishes the
+// results as a statistic so they are reported along with all other stats.
+type runtimeStats struct {
+	statsKey string
+	stats    *kexpvar.Map
+
+	numGoroutine  *kexpvar.Int
+	heapAlloc     *kexpvar.Int
+	heapInUse     *kexpvar.Int
+	heapObjects   *kexpvar.Int
+	nextGC        *kexpvar.Int
+	numGC         *kexpvar.Int
+	gcCPUFraction *kexpvar.Float
+	gcPauseP50    *kexpvar.Int
+	gcPauseP90    *kexpvar.Int
+	gcPauseP99    *kexpvar.Int
+	gcPauseMax    *kexpvar.Int
+
+	// Reused between samples, index i holds the i-th percentile.
+	gcStats debug.GCStats
+}
+
+func newRuntimeStats() *runtimeStats {
+	r := &runtimeStats{
+		numGoroutine:  &kexpvar.Int{},
+		heapAlloc:     &kexpvar.Int{},
+		heapInUse:     &kexpvar.Int{},
+		heapObjects:   &kexpvar.Int{},
+		nextGC:        &kexpvar.Int{},
+		numGC:         &kexpvar.Int{},
+		gcCPUFraction: &kexpvar.Float{},
+		gcPauseP50:    &kexpvar.Int{},
+		gcPauseP90:    &kexpvar.Int{},
+		gcPauseP99:    &kexpvar.Int{},
+		gcPauseMax:    &kexpvar.Int{},
+		gcStats: debug.GCStats{
+			PauseQuantiles: make([]time.Duration, 101),
+		},
+	}
+	r.statsKey, r.stats = vars.NewStatistic(RuntimeStatsName, nil)
+	r.stats.Set(statNumGoroutine, r.numGoroutine)
+	r.stats.Set(statHeapAlloc, r.heapAlloc)
+	r.stats.Set(statHeapInUse, r.heapInUse)
+	r.stats.Set(statHeapObjects, r.heapObjects)
+	r.stats.Set(statNextGC, r.nextGC)
+	r.stats.Set(statNumGC, r.numGC)
+	r.stats.Set(statGCCPUFraction, r.gcCPUFraction)
+	r.stats.Set(statGCPauseP50, r.gcPauseP50)
+	r.stats.Set(statGCPauseP90, r.gcPauseP90)
+	r.stats.Set(statGCPauseP99, r.gcPauseP99)
+	r.stats.Set(statGCPauseMax, r.gcPauseMax)
+	return r
+}
+
+// sample reads the current runtime state and updates the published values.
+func (r *runtimeStats) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r.numGoroutine.Set(int64(runtime.NumGoroutine()))
+	r.heapAlloc.Set(int64(m.HeapAlloc))
+	r.heapInUse.Set(int64(m.HeapInuse))
+	r.heapObjects.Set(int64(m.HeapObjects))
+	r.nextGC.Set(int64(m.NextGC))
+	r.numGC.Set(int64(m.NumGC))
+	r.gcCPUFraction.Set(m.GCCPUFraction)
+
+	debug.ReadGCStats(&r.gcStats)
+	q := r.gcStats.PauseQuantiles
+	r.gcPauseP50.Set(int64(q[50]))
+	r.gcPauseP90.Set(int64(q[90]))
+	r.gcPauseP99.Set(int64(q[99]))
+	r.gcPauseMax.Set(int64(q[100]))
+}
+
+func (r *runtimeStats) close() {
+	vars.DeleteStatistic(r.statsKey)
+}
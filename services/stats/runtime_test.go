@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+func TestRuntimeStats_Sample(t *testing.T) {
+	r := newRuntimeStats()
+	defer r.close()
+	r.sample()
+
+	data, err := vars.GetStatsData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values map[string]interface{}
+	for _, d := range data {
+		if d.Name == RuntimeStatsName {
+			values = d.Values
+		}
+	}
+	if values == nil {
+		t.Fatalf("missing %s stats", RuntimeStatsName)
+	}
+	for _, k := range []string{statNumGoroutine, statHeapInUse, statNextGC} {
+		v, ok := values[k].(int64)
+		if !ok {
+			t.Fatalf("missing or wrong type for %s: %v", k, values[k])
+		}
+		if v <= 0 {
+			t.Errorf("expected positive value for %s, got %d", k, v)
+		}
+	}
+	if _, ok := values[statGCPauseP99].(int64); !ok {
+		t.Errorf("missing %s", statGCPauseP99)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c.RuntimeStatsInterval = -1
+	if err := c.Validate(); err == nil {
+		t.Error("expected error for negative runtime-stats-interval")
+	}
+}
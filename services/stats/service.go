@@ -34,6 +34,10 @@ import (
 	"github.com/influxdata/kapacitor/timer"
 )
 
+// taskLevelExcluded are the names of the stats that are not reported at the task level,
+// see the stats of kapacitor.node and kapacitor.edge.
+var taskLevelExcluded = []string{"nodes", "edges"}
+
 type Diagnostic interface {
 	Error(msg string, err error)
 }
@@ -51,10 +55,15 @@ type Service struct {
 	interval time.Duration
 	db       string
 	rp       string
+	// excluded are the names of the stats that are not reported.
+	excluded map[string]bool
 
 	timingSampleRate    float64
 	timingMovingAvgSize int
 
+	runtimeInterval time.Duration
+	runtime         *runtimeStats
+
 	open    bool
 	closing chan struct{}
 	mu      sync.Mutex
@@ -64,12 +73,23 @@ type Service struct {
 }
 
 func NewService(c Config, d Diagnostic) *Service {
+	excluded := make(map[string]bool, len(c.Exclude))
+	for _, name := range c.Exclude {
+		excluded[name] = true
+	}
+	if c.Level == LevelTask {
+		for _, name := range taskLevelExcluded {
+			excluded[name] = true
+		}
+	}
 	return &Service{
 		interval:            time.Duration(c.StatsInterval),
 		db:                  c.Database,
 		rp:                  c.RetentionPolicy,
+		excluded:            excluded,
 		timingSampleRate:    c.TimingSampleRate,
 		timingMovingAvgSize: c.TimingMovingAverageSize,
+		runtimeInterval:     time.Duration(c.RuntimeStatsInterval),
 		diag:                d,
 	}
 }
@@ -85,6 +105,13 @@ func (s *Service) Open() (err error) {
 	s.closing = make(chan struct{})
 	s.wg.Add(1)
 	go s.sendStats()
+	if s.runtimeInterval > 0 {
+		s.runtime = newRuntimeStats()
+		// Take an initial sample so the first report is complete.
+		s.runtime.sample()
+		s.wg.Add(1)
+		go s.sampleRuntime()
+	}
 	return
 }
 
@@ -97,6 +124,10 @@ func (s *Service) Close() error {
 	s.open = false
 	close(s.closing)
 	s.wg.Wait()
+	if s.runtime != nil {
+		s.runtime.close()
+		s.runtime = nil
+	}
 	s.stream.Close()
 	return nil
 }
@@ -115,6 +146,20 @@ func (s *Service) sendStats() {
 	}
 }
 
+func (s *Service) sampleRuntime() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.runtimeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.runtime.sample()
+		}
+	}
+}
+
 func (s *Service) reportStats() {
 	now := time.Now().UTC()
 	data, err := vars.GetStatsData()
@@ -123,6 +168,9 @@ func (s *Service) reportStats() {
 		return
 	}
 	for _, stat := range data {
+		if s.excluded[stat.Name] {
+			continue
+		}
 		p := edge.NewPointMessage(
 			stat.Name,
 			s.db,
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/influxdata/kapacitor/edge"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+type names struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+func (n *names) CollectPoint(p edge.PointMessage) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.names[p.Name()] = true
+	return nil
+}
+
+func (n *names) Close() error { return nil }
+
+func TestService_ReportStats_Level(t *testing.T) {
+	for _, name := range []string{"nodes", "edges", "topics"} {
+		key, statMap := vars.NewStatistic(name, map[string]string{"test": t.Name()})
+		statMap.Set("value", &kexpvar.Int{})
+		defer vars.DeleteStatistic(key)
+	}
+	testCases := []struct {
+		level    string
+		exclude  []string
+		reported map[string]bool
+	}{
+		{
+			level:    LevelNode,
+			reported: map[string]bool{"nodes": true, "edges": true, "topics": true},
+		},
+		{
+			level:    LevelTask,
+			reported: map[string]bool{"nodes": false, "edges": false, "topics": true},
+		},
+		{
+			level:    LevelNode,
+			exclude:  []string{"topics"},
+			reported: map[string]bool{"nodes": true, "edges": true, "topics": false},
+		},
+	}
+	for _, tc := range testCases {
+		c := NewConfig()
+		c.Level = tc.level
+		c.Exclude = tc.exclude
+		s := NewService(c, nil)
+		collector := &names{names: make(map[string]bool)}
+		s.stream = collector
+		s.reportStats()
+		for name, exp := range tc.reported {
+			if got := collector.names[name]; got != exp {
+				t.Errorf("level %s exclude %v: unexpected reported %s got %t exp %t", tc.level, tc.exclude, name, got, exp)
+			}
+		}
+	}
+}
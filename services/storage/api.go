@@ -69,6 +69,10 @@ func (s *APIServer) Close() error {
 }
 
 func (s *APIServer) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if s.DB == nil {
+		httpd.HttpError(w, "backup is only supported by the bolt storage backend", true, http.StatusNotImplemented)
+		return
+	}
 	err := s.DB.View(func(tx *bolt.Tx) error {
 		w.Header().Set("Content-Type", "application/octet-stream")
 		w.Header().Set("Content-Disposition", `attachment; filename="kapacitor.db"`)
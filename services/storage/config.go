@@ -1,21 +1,58 @@
 package storage
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// BoltBackend stores the data in a local boltdb file.
+	BoltBackend = "bolt"
+	// PostgresBackend stores the data in a table of a Postgres database,
+	// which can be shared by several Kapacitor servers.
+	PostgresBackend = "postgres"
+
+	DefaultPostgresTable = "kapacitor_kv"
+)
+
+var validTableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
 type Config struct {
+	// Storage backend, one of "bolt" or "postgres".
+	Backend string `toml:"backend"`
+
 	// Path to a boltdb database file.
 	BoltDBPath string `toml:"boltdb"`
+
+	// Connection string of the Postgres database.
+	PostgresDSN string `toml:"postgres-dsn"`
+	// Table of the Postgres database to store the data in.
+	PostgresTable string `toml:"postgres-table"`
 }
 
 func NewConfig() Config {
 	return Config{
-		BoltDBPath: "./kapacitor.db",
+		Backend:       BoltBackend,
+		BoltDBPath:    "./kapacitor.db",
+		PostgresTable: DefaultPostgresTable,
 	}
 }
 
 func (c Config) Validate() error {
-	if c.BoltDBPath == "" {
-		return fmt.Errorf("must specify storage 'boltdb' path")
+	switch c.Backend {
+	case "", BoltBackend:
+		if c.BoltDBPath == "" {
+			return fmt.Errorf("must specify storage 'boltdb' path")
+		}
+	case PostgresBackend:
+		if c.PostgresDSN == "" {
+			return fmt.Errorf("must specify storage 'postgres-dsn'")
+		}
+		if !validTableName.MatchString(c.PostgresTable) {
+			return fmt.Errorf("invalid storage 'postgres-table' %q", c.PostgresTable)
+		}
+	default:
+		return fmt.Errorf("unknown storage backend %q, must be one of %q or %q", c.Backend, BoltBackend, PostgresBackend)
 	}
 	return nil
 }
@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/services/storage"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		c     func(c *storage.Config)
+		valid bool
+	}{
+		{
+			name:  "default",
+			c:     func(c *storage.Config) {},
+			valid: true,
+		},
+		{
+			name:  "missing boltdb path",
+			c:     func(c *storage.Config) { c.BoltDBPath = "" },
+			valid: false,
+		},
+		{
+			name: "postgres",
+			c: func(c *storage.Config) {
+				c.Backend = storage.PostgresBackend
+				c.PostgresDSN = "postgres://localhost/kapacitor"
+			},
+			valid: true,
+		},
+		{
+			name:  "postgres missing dsn",
+			c:     func(c *storage.Config) { c.Backend = storage.PostgresBackend },
+			valid: false,
+		},
+		{
+			name: "postgres invalid table",
+			c: func(c *storage.Config) {
+				c.Backend = storage.PostgresBackend
+				c.PostgresDSN = "postgres://localhost/kapacitor"
+				c.PostgresTable = "kv; DROP TABLE kv"
+			},
+			valid: false,
+		},
+		{
+			name:  "unknown backend",
+			c:     func(c *storage.Config) { c.Backend = "etcd" },
+			valid: false,
+		},
+	}
+	for _, tc := range testCases {
+		c := storage.NewConfig()
+		tc.c(&c)
+		err := c.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// postgresMaxAttempts is the number of times an update is attempted when it conflicts with concurrent updates.
+const postgresMaxAttempts = 10
+
+// Postgres implementation of Store.
+// All namespaces are stored in a single table, keyed by namespace and key,
+// so that several Kapacitor servers can share the same database.
+type Postgres struct {
+	db        *sql.DB
+	table     string
+	namespace string
+}
+
+func NewPostgres(db *sql.DB, table, namespace string) *Postgres {
+	return &Postgres{
+		db:        db,
+		table:     table,
+		namespace: namespace,
+	}
+}
+
+// CreatePostgresTable creates the table of the key/value data if it does not exist.
+func CreatePostgresTable(db *sql.DB, table string) error {
+	_, err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	namespace TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value BYTEA NOT NULL,
+	PRIMARY KEY (namespace, key)
+)`, table))
+	return err
+}
+
+// queryer is implemented by both sql.DB and sql.Tx.
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (p *Postgres) View(f func(tx ReadOnlyTx) error) error {
+	return DoView(p, f)
+}
+
+// Update runs f in a serializable transaction, as Kapacitor servers sharing the database may update the same keys.
+// If the transaction conflicts with a concurrent transaction it is rolled back and f is called again.
+func (p *Postgres) Update(f func(tx Tx) error) error {
+	var err error
+	for i := 0; i < postgresMaxAttempts; i++ {
+		err = DoUpdate(p, f)
+		if !isSerializationFailure(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isSerializationFailure reports whether the transaction failed because of a concurrent transaction
+// and can be retried.
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
+}
+
+func (p *Postgres) put(q queryer, key string, value []byte) error {
+	_, err := q.Exec(fmt.Sprintf(
+		`INSERT INTO %s (namespace, key, value) VALUES ($1, $2, $3) ON CONFLICT (namespace, key) DO UPDATE SET value = EXCLUDED.value`,
+		p.table,
+	), p.namespace, key, value)
+	return err
+}
+
+func (p *Postgres) Put(key string, value []byte) error {
+	return p.put(p.db, key, value)
+}
+
+func (p *Postgres) get(q queryer, key string) (*KeyValue, error) {
+	var value []byte
+	err := q.QueryRow(fmt.Sprintf(`SELECT value FROM %s WHERE namespace = $1 AND key = $2`, p.table), p.namespace, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoKeyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &KeyValue{
+		Key:   key,
+		Value: value,
+	}, nil
+}
+
+func (p *Postgres) Get(key string) (*KeyValue, error) {
+	return p.get(p.db, key)
+}
+
+func (p *Postgres) delete(q queryer, key string) error {
+	_, err := q.Exec(fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1 AND key = $2`, p.table), p.namespace, key)
+	return err
+}
+
+func (p *Postgres) Delete(key string) error {
+	return p.delete(p.db, key)
+}
+
+func (p *Postgres) exists(q queryer, key string) (bool, error) {
+	var exists bool
+	err := q.QueryRow(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE namespace = $1 AND key = $2)`, p.table), p.namespace, key).Scan(&exists)
+	return exists, err
+}
+
+func (p *Postgres) Exists(key string) (bool, error) {
+	return p.exists(p.db, key)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (p *Postgres) list(q queryer, prefix string) ([]*KeyValue, error) {
+	// Order by the bytes of the keys, the same as the bolt store.
+	rows, err := q.Query(fmt.Sprintf(
+		`SELECT key, value FROM %s WHERE namespace = $1 AND key LIKE $2 ORDER BY key COLLATE "C"`,
+		p.table,
+	), p.namespace, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var kvs []*KeyValue
+	for rows.Next() {
+		kv := &KeyValue{}
+		if err := rows.Scan(&kv.Key, &kv.Value); err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, rows.Err()
+}
+
+func (p *Postgres) List(prefix string) ([]*KeyValue, error) {
+	return p.list(p.db, prefix)
+}
+
+func (p *Postgres) BeginTx() (Tx, error) {
+	return p.newTx(false)
+}
+
+func (p *Postgres) BeginReadOnlyTx() (ReadOnlyTx, error) {
+	return p.newTx(true)
+}
+
+func (p *Postgres) newTx(readOnly bool) (*postgresTx, error) {
+	opts := &sql.TxOptions{ReadOnly: readOnly}
+	if !readOnly {
+		opts.Isolation = sql.LevelSerializable
+	}
+	tx, err := p.db.BeginTx(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTx{
+		p:  p,
+		tx: tx,
+	}, nil
+}
+
+// postgresTx wraps an underlying sql.Tx type to implement the Tx interface.
+type postgresTx struct {
+	p  *Postgres
+	tx *sql.Tx
+}
+
+func (t *postgresTx) Get(key string) (*KeyValue, error) {
+	return t.p.get(t.tx, key)
+}
+
+func (t *postgresTx) Exists(key string) (bool, error) {
+	return t.p.exists(t.tx, key)
+}
+
+func (t *postgresTx) List(prefix string) ([]*KeyValue, error) {
+	return t.p.list(t.tx, prefix)
+}
+
+func (t *postgresTx) Put(key string, value []byte) error {
+	return t.p.put(t.tx, key, value)
+}
+
+func (t *postgresTx) Delete(key string) error {
+	return t.p.delete(t.tx, key)
+}
+
+func (t *postgresTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *postgresTx) Rollback() error {
+	return t.tx.Rollback()
+}
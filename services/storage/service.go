@@ -1,11 +1,13 @@
 package storage
 
 import (
+	"database/sql"
 	"os"
 	"path"
 	"sync"
 
 	"github.com/influxdata/kapacitor/services/httpd"
+	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 )
@@ -15,9 +17,14 @@ type Diagnostic interface {
 }
 
 type Service struct {
-	dbpath string
+	backend string
+	dbpath  string
+
+	postgresDSN   string
+	postgresTable string
 
 	boltdb *bolt.DB
+	sqldb  *sql.DB
 	stores map[string]Interface
 	mu     sync.Mutex
 
@@ -36,9 +43,12 @@ type Service struct {
 
 func NewService(conf Config, d Diagnostic) *Service {
 	return &Service{
-		dbpath: conf.BoltDBPath,
-		diag:   d,
-		stores: make(map[string]Interface),
+		backend:       conf.Backend,
+		dbpath:        conf.BoltDBPath,
+		postgresDSN:   conf.PostgresDSN,
+		postgresTable: conf.PostgresTable,
+		diag:          d,
+		stores:        make(map[string]Interface),
 	}
 }
 
@@ -49,15 +59,16 @@ const (
 func (s *Service) Open() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	err := os.MkdirAll(path.Dir(s.dbpath), 0755)
-	if err != nil {
-		return errors.Wrapf(err, "mkdir dirs %q", s.dbpath)
-	}
-	db, err := bolt.Open(s.dbpath, 0600, nil)
-	if err != nil {
-		return errors.Wrapf(err, "open boltdb @ %q", s.dbpath)
+	switch s.backend {
+	case PostgresBackend:
+		if err := s.openPostgres(); err != nil {
+			return err
+		}
+	default:
+		if err := s.openBolt(); err != nil {
+			return err
+		}
 	}
-	s.boltdb = db
 
 	s.registrar = NewStorageResitrar()
 	s.apiServer = &APIServer{
@@ -76,6 +87,36 @@ func (s *Service) Open() error {
 	return nil
 }
 
+func (s *Service) openBolt() error {
+	err := os.MkdirAll(path.Dir(s.dbpath), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "mkdir dirs %q", s.dbpath)
+	}
+	db, err := bolt.Open(s.dbpath, 0600, nil)
+	if err != nil {
+		return errors.Wrapf(err, "open boltdb @ %q", s.dbpath)
+	}
+	s.boltdb = db
+	return nil
+}
+
+func (s *Service) openPostgres() error {
+	db, err := sql.Open("postgres", s.postgresDSN)
+	if err != nil {
+		return errors.Wrap(err, "open postgres")
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return errors.Wrap(err, "connect to postgres")
+	}
+	if err := CreatePostgresTable(db, s.postgresTable); err != nil {
+		db.Close()
+		return errors.Wrapf(err, "create postgres table %q", s.postgresTable)
+	}
+	s.sqldb = db
+	return nil
+}
+
 func (s *Service) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -87,6 +128,9 @@ func (s *Service) Close() error {
 	if s.boltdb != nil {
 		return s.boltdb.Close()
 	}
+	if s.sqldb != nil {
+		return s.sqldb.Close()
+	}
 	return nil
 }
 
@@ -102,7 +146,11 @@ func (s *Service) store(name string) Interface {
 	if store, ok := s.stores[name]; ok {
 		return store
 	} else {
-		store = NewBolt(s.boltdb, name)
+		if s.sqldb != nil {
+			store = NewPostgres(s.sqldb, s.postgresTable, name)
+		} else {
+			store = NewBolt(s.boltdb, name)
+		}
 		s.stores[name] = store
 		return store
 	}
@@ -2,12 +2,16 @@ package storage_test
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/influxdata/kapacitor/services/storage"
+	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 )
@@ -24,6 +28,15 @@ var stores = map[string]createStoreCloser{
 	"mem":  newMemStore,
 }
 
+// The postgres store is only tested when a database is available.
+const postgresDSNEnv = "KAPACITOR_TEST_POSTGRES_DSN"
+
+func init() {
+	if os.Getenv(postgresDSNEnv) != "" {
+		stores["postgres"] = newPostgres
+	}
+}
+
 type storeCloser interface {
 	Store(namespace string) storage.Interface
 	Close()
@@ -81,6 +94,36 @@ func (s memStore) Store(name string) storage.Interface {
 func (s memStore) Close() {
 }
 
+type postgresDB struct {
+	db    *sql.DB
+	table string
+}
+
+func newPostgres() (storeCloser, error) {
+	db, err := sql.Open("postgres", os.Getenv(postgresDSNEnv))
+	if err != nil {
+		return nil, err
+	}
+	table := fmt.Sprintf("kapacitor_test_%d", time.Now().UnixNano())
+	if err := storage.CreatePostgresTable(db, table); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return postgresDB{
+		db:    db,
+		table: table,
+	}, nil
+}
+
+func (p postgresDB) Store(namespace string) storage.Interface {
+	return storage.NewPostgres(p.db, p.table, namespace)
+}
+
+func (p postgresDB) Close() {
+	_, _ = p.db.Exec("DROP TABLE " + p.table)
+	_ = p.db.Close()
+}
+
 func TestStorage_CRUD(t *testing.T) {
 	for name, sc := range stores {
 		t.Run(name, func(t *testing.T) {
@@ -313,3 +356,61 @@ func TestStorage_Update_Concurrent(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_Update_ConcurrentIncrement(t *testing.T) {
+	for name, sc := range stores {
+		t.Run(name, func(t *testing.T) {
+			db, err := sc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			// Each worker increments the same counter, no increment may be lost.
+			w := 10 // number of workers
+			i := 10 // number of increments per worker
+			increment := func(s storage.Interface) error {
+				return s.Update(func(tx storage.Tx) error {
+					count := 0
+					kv, err := tx.Get("counter")
+					if err == nil {
+						if count, err = strconv.Atoi(string(kv.Value)); err != nil {
+							return err
+						}
+					} else if err != storage.ErrNoKeyExists {
+						return err
+					}
+					return tx.Put("counter", []byte(strconv.Itoa(count+1)))
+				})
+			}
+			errs := make(chan error, w)
+			for x := 0; x < w; x++ {
+				s := db.Store("increment")
+				go func() {
+					for y := 0; y < i; y++ {
+						if err := increment(s); err != nil {
+							errs <- err
+							return
+						}
+					}
+					errs <- nil
+				}()
+			}
+			for x := 0; x < w; x++ {
+				if err := <-errs; err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got *storage.KeyValue
+			if err := db.Store("increment").View(func(tx storage.ReadOnlyTx) error {
+				got, err = tx.Get("counter")
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if exp := strconv.Itoa(w * i); string(got.Value) != exp {
+				t.Errorf("unexpected counter got %s exp %s", got.Value, exp)
+			}
+		})
+	}
+}
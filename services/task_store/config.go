@@ -1,6 +1,7 @@
 package task_store
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
@@ -10,15 +11,42 @@ type Config struct {
 	// Deprecated, only needed to find old db and migrate
 	Dir              string        `toml:"dir"`
 	SnapshotInterval toml.Duration `toml:"snapshot-interval"`
+
+	// Number of enabled tasks started at once when Kapacitor starts.
+	StartupConcurrency int `toml:"startup-concurrency"`
+	// Tasks with at least this priority are critical,
+	// the ready endpoint reports Kapacitor as ready once all of the critical tasks started.
+	// Tasks are started in order of priority so the critical tasks start first.
+	// The default of 0 makes no task critical, tasks have a priority of 0 unless set.
+	CriticalPriority int64 `toml:"critical-priority"`
+	// Number of times a task that failed to start when Kapacitor starts is retried.
+	StartupRetries int `toml:"startup-retries"`
+	// Time between the retries of the tasks that failed to start.
+	StartupRetryInterval toml.Duration `toml:"startup-retry-interval"`
 }
 
 func NewConfig() Config {
 	return Config{
-		Dir:              "./tasks",
-		SnapshotInterval: toml.Duration(time.Minute),
+		Dir:                  "./tasks",
+		SnapshotInterval:     toml.Duration(time.Minute),
+		StartupConcurrency:   1,
+		StartupRetries:       3,
+		StartupRetryInterval: toml.Duration(10 * time.Second),
 	}
 }
 
 func (c Config) Validate() error {
+	if c.StartupConcurrency < 1 {
+		return fmt.Errorf("startup-concurrency must be at least 1, got %d", c.StartupConcurrency)
+	}
+	if c.CriticalPriority < 0 {
+		return fmt.Errorf("critical-priority must not be negative, got %d", c.CriticalPriority)
+	}
+	if c.StartupRetries < 0 {
+		return fmt.Errorf("startup-retries must not be negative, got %d", c.StartupRetries)
+	}
+	if c.StartupRetryInterval < 0 {
+		return fmt.Errorf("startup-retry-interval must not be negative, got %v", time.Duration(c.StartupRetryInterval))
+	}
 	return nil
 }
@@ -127,6 +127,14 @@ type Task struct {
 	TemplateID string
 	// Set of vars for a templated task
 	Vars map[string]Var
+	// Log level of the task, empty to use the log level of the server.
+	LogLevel string
+	// Log levels of nodes of the task by node name.
+	NodeLogLevels map[string]string
+	// Resource quotas of the task, zero quotas use the default quotas.
+	Quotas TaskQuotas
+	// Priority of the batch queries of the task, higher first.
+	Priority int64
 	// Last error the task had either while defining or executing.
 	Error string
 	// Status of the task
@@ -141,6 +149,15 @@ type Task struct {
 
 type rawTask Task
 
+// TaskQuotas limit the resources used by a task, a zero limit means no limit.
+type TaskQuotas struct {
+	MaxPointsPerSecond int64
+	MaxCardinality     int64
+	MaxMemoryBytes     int64
+	// Enforcement is either throttle or error, empty to use the default enforcement.
+	Enforcement string
+}
+
 func (t Task) ObjectID() string {
 	return t.ID
 }
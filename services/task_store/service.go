@@ -8,8 +8,11 @@ import (
 	"net/http"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor"
@@ -54,7 +57,17 @@ type Service struct {
 	snapshots        SnapshotDAO
 	routes           []httpd.Route
 	snapshotInterval time.Duration
-	StorageService   interface {
+
+	startupConcurrency   int
+	criticalPriority     int64
+	startupRetries       int
+	startupRetryInterval time.Duration
+	readiness            *readiness
+	// closing is closed when the service closes, stopping the retries of the tasks that failed to start.
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	StorageService interface {
 		Store(namespace string) storage.Interface
 		Register(name string, store storage.StoreActioner)
 	}
@@ -74,9 +87,14 @@ type Service struct {
 
 func NewService(conf Config, d Diagnostic) *Service {
 	return &Service{
-		snapshotInterval: time.Duration(conf.SnapshotInterval),
-		diag:             d,
-		oldDBDir:         conf.Dir,
+		snapshotInterval:     time.Duration(conf.SnapshotInterval),
+		startupConcurrency:   conf.StartupConcurrency,
+		criticalPriority:     conf.CriticalPriority,
+		startupRetries:       conf.StartupRetries,
+		startupRetryInterval: time.Duration(conf.StartupRetryInterval),
+		readiness:            newReadiness(),
+		diag:                 d,
+		oldDBDir:             conf.Dir,
 	}
 }
 
@@ -104,8 +122,15 @@ func (ts *Service) Open() error {
 		return err
 	}
 
+	ts.closing = make(chan struct{})
+
 	// Define API routes
 	ts.routes = []httpd.Route{
+		{
+			Method:      "GET",
+			Pattern:     readyPath,
+			HandlerFunc: ts.handleReady,
+		},
 		{
 			Method:      "GET",
 			Pattern:     tasksPathAnchored,
@@ -127,6 +152,11 @@ func (ts *Service) Open() error {
 			Pattern:     tasksPathAnchored,
 			HandlerFunc: ts.handleUpdateTask,
 		},
+		{
+			Method:      "POST",
+			Pattern:     tasksPathAnchored,
+			HandlerFunc: ts.handleCancelTaskQueries,
+		},
 		{
 			Method:      "GET",
 			Pattern:     tasksPath,
@@ -176,7 +206,7 @@ func (ts *Service) Open() error {
 	}
 
 	numTasks := int64(0)
-	numEnabledTasks := int64(0)
+	var enabled []Task
 
 	// Count all tasks
 	offset := 0
@@ -190,14 +220,7 @@ func (ts *Service) Open() error {
 		for _, task := range tasks {
 			numTasks++
 			if task.Status == Enabled {
-				numEnabledTasks++
-				ts.diag.StartingTask(task.ID)
-				err = ts.startTask(task)
-				if err != nil {
-					ts.diag.Error("failed to start enabled task", err, keyvalue.KV("task", task.ID))
-				} else {
-					ts.diag.StartedTask(task.ID)
-				}
+				enabled = append(enabled, task)
 			}
 		}
 		if len(tasks) != limit {
@@ -208,8 +231,9 @@ func (ts *Service) Open() error {
 
 	// Set expvars
 	vars.NumTasksVar.Set(numTasks)
-	vars.NumEnabledTasksVar.Set(numEnabledTasks)
+	vars.NumEnabledTasksVar.Set(int64(len(enabled)))
 
+	ts.startEnabledTasks(enabled)
 	return nil
 }
 
@@ -370,6 +394,10 @@ func (ts *Service) migrate() error {
 
 func (ts *Service) Close() error {
 	ts.HTTPDService.DelRoutes(ts.routes)
+	if ts.closing != nil {
+		close(ts.closing)
+		ts.wg.Wait()
+	}
 	return nil
 }
 
@@ -494,8 +522,23 @@ var allTaskFields = []string{
 	"last-enabled",
 	"vars",
 	"template-id",
+	"log-level",
+	"node-log-levels",
+	"quotas",
+	"priority",
 }
 
+var validTaskFields = func() map[string]bool {
+	valid := make(map[string]bool, len(allTaskFields))
+	for _, f := range allTaskFields {
+		valid[f] = true
+	}
+	return valid
+}()
+
+// Number of tasks read from the store at a time when listing tasks.
+const taskListPageSize = 100
+
 const tasksBasePathAnchored = httpd.BasePath + tasksPathAnchored
 
 func (ts *Service) taskIDFromPath(path string) (string, error) {
@@ -564,115 +607,161 @@ func (ts *Service) handleListTasks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	rawTasks, err := ts.tasks.List(pattern, int(offset), int(limit))
+	for _, field := range fields {
+		if !validTaskFields[field] {
+			httpd.HttpError(w, fmt.Sprintf("unsupported field %q", field), true, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Read the first page before writing anything so that errors can still be returned.
+	// A negative limit lists all tasks.
+	unlimited := limit < 0
+	page := limit
+	if unlimited || page > taskListPageSize {
+		page = taskListPageSize
+	}
+	rawTasks, err := ts.tasks.List(pattern, int(offset), int(page))
 	if err != nil {
 		httpd.HttpError(w, fmt.Sprintf("failed to list tasks with pattern %q: %s", pattern, err), true, http.StatusBadRequest)
 		return
 	}
-	tasks := make([]map[string]interface{}, len(rawTasks))
 
+	// Stream the tasks a page at a time so that large lists are not buffered in memory.
 	tm := ts.TaskMasterLookup.Main()
+	aw := httpd.NewArrayWriter(w, "tasks", true)
+	for {
+		for _, task := range rawTasks {
+			if err := aw.Write(ts.taskFields(tm, task, fields, scriptFormat, dotView)); err != nil {
+				ts.diag.Error("failed to write task list", err)
+				return
+			}
+		}
+		offset += int64(len(rawTasks))
+		if int64(len(rawTasks)) < page {
+			break
+		}
+		if !unlimited {
+			limit -= int64(len(rawTasks))
+			if limit <= 0 {
+				break
+			}
+			if page > limit {
+				page = limit
+			}
+		}
+		rawTasks, err = ts.tasks.List(pattern, int(offset), int(page))
+		if err != nil {
+			// The response has already started, the truncated list is all that can be returned.
+			ts.diag.Error("failed to list tasks", err, keyvalue.KV("pattern", pattern))
+			break
+		}
+	}
+	if err := aw.Close(); err != nil {
+		ts.diag.Error("failed to write task list", err)
+	}
+}
 
-	for i, task := range rawTasks {
-		tasks[i] = make(map[string]interface{}, len(fields))
-		executing := tm.IsExecuting(task.ID)
-		for _, field := range fields {
-			var value interface{}
-			switch field {
-			case "id":
-				value = task.ID
-			case "link":
-				value = ts.taskLink(task.ID)
-			case "type":
-				switch task.Type {
-				case StreamTask:
-					value = client.StreamTask
-				case BatchTask:
-					value = client.BatchTask
+// taskFields returns the requested fields of the task.
+func (ts *Service) taskFields(tm *kapacitor.TaskMaster, task Task, fields []string, scriptFormat, dotView string) map[string]interface{} {
+	values := make(map[string]interface{}, len(fields))
+	executing := tm.IsExecuting(task.ID)
+	for _, field := range fields {
+		var value interface{}
+		switch field {
+		case "id":
+			value = task.ID
+		case "link":
+			value = ts.taskLink(task.ID)
+		case "type":
+			switch task.Type {
+			case StreamTask:
+				value = client.StreamTask
+			case BatchTask:
+				value = client.BatchTask
+			}
+		case "dbrps":
+			dbrps := make([]client.DBRP, len(task.DBRPs))
+			for i, dbrp := range task.DBRPs {
+				dbrps[i] = client.DBRP{
+					Database:        dbrp.Database,
+					RetentionPolicy: dbrp.RetentionPolicy,
 				}
-			case "dbrps":
-				dbrps := make([]client.DBRP, len(task.DBRPs))
-				for i, dbrp := range task.DBRPs {
-					dbrps[i] = client.DBRP{
-						Database:        dbrp.Database,
-						RetentionPolicy: dbrp.RetentionPolicy,
-					}
+			}
+			value = dbrps
+		case "script":
+			value = task.TICKscript
+			if scriptFormat == "formatted" {
+				formatted, err := tick.Format(task.TICKscript)
+				if err == nil {
+					// Only format if it succeeded.
+					// Otherwise a change in syntax may prevent task retrieval.
+					value = formatted
 				}
-				value = dbrps
-			case "script":
-				value = task.TICKscript
-				if scriptFormat == "formatted" {
-					formatted, err := tick.Format(task.TICKscript)
-					if err == nil {
-						// Only format if it succeeded.
-						// Otherwise a change in syntax may prevent task retrieval.
-						value = formatted
-					}
+			}
+		case "executing":
+			value = executing
+		case "dot":
+			if executing {
+				value = tm.ExecutingDot(task.ID, dotView == "labels")
+			} else {
+				kt, err := ts.newKapacitorTask(task)
+				if err != nil {
+					break
 				}
-			case "executing":
-				value = executing
-			case "dot":
-				if executing {
-					value = tm.ExecutingDot(task.ID, dotView == "labels")
+				value = string(kt.Dot())
+			}
+		case "stats":
+			if executing {
+				s, err := tm.ExecutionStats(task.ID)
+				if err != nil {
+					ts.diag.Error("failed to retriete stats for task", err, keyvalue.KV("task", task.ID))
 				} else {
-					kt, err := ts.newKapacitorTask(task)
-					if err != nil {
-						break
-					}
-					value = string(kt.Dot())
-				}
-			case "stats":
-				if executing {
-					s, err := tm.ExecutionStats(task.ID)
-					if err != nil {
-						ts.diag.Error("failed to retriete stats for task", err, keyvalue.KV("task", task.ID))
-					} else {
-						value = client.ExecutionStats{
-							TaskStats: s.TaskStats,
-							NodeStats: s.NodeStats,
-						}
+					value = client.ExecutionStats{
+						TaskStats: s.TaskStats,
+						NodeStats: s.NodeStats,
 					}
 				}
-			case "error":
-				value = task.Error
-			case "status":
-				switch task.Status {
-				case Disabled:
-					value = client.Disabled
-				case Enabled:
-					value = client.Enabled
-				}
-			case "created":
-				value = task.Created
-			case "modified":
-				value = task.Modified
-			case "last-enabled":
-				value = task.LastEnabled
-			case "template-id":
-				if len(task.TemplateID) == 0 {
-					continue
-				}
-				value = task.TemplateID
-			case "vars":
-				vars, err := ts.convertToClientVars(task.Vars)
-				if err != nil {
-					ts.diag.Error("failed to get vars for task", err, keyvalue.KV("task", task.ID))
-					break
-				}
-				value = vars
-			default:
-				httpd.HttpError(w, fmt.Sprintf("unsupported field %q", field), true, http.StatusBadRequest)
-				return
 			}
-			tasks[i][field] = value
-		}
-	}
-
-	type response struct {
-		Tasks []map[string]interface{} `json:"tasks"`
-	}
-
-	w.Write(httpd.MarshalJSON(response{tasks}, true))
+		case "error":
+			value = task.Error
+		case "status":
+			switch task.Status {
+			case Disabled:
+				value = client.Disabled
+			case Enabled:
+				value = client.Enabled
+			}
+		case "created":
+			value = task.Created
+		case "modified":
+			value = task.Modified
+		case "last-enabled":
+			value = task.LastEnabled
+		case "template-id":
+			if len(task.TemplateID) == 0 {
+				continue
+			}
+			value = task.TemplateID
+		case "vars":
+			vars, err := ts.convertToClientVars(task.Vars)
+			if err != nil {
+				ts.diag.Error("failed to get vars for task", err, keyvalue.KV("task", task.ID))
+				break
+			}
+			value = vars
+		case "log-level":
+			value = task.LogLevel
+		case "node-log-levels":
+			value = task.NodeLogLevels
+		case "quotas":
+			value = convertQuotasToClient(task.Quotas)
+		case "priority":
+			value = task.Priority
+		}
+		values[field] = value
+	}
+	return values
 }
 
 var validTaskID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
@@ -767,6 +856,21 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
+
+	// Set log levels
+	newTask.LogLevel = strings.ToLower(task.LogLevel)
+	newTask.NodeLogLevels = mergeNodeLogLevels(nil, task.NodeLogLevels)
+
+	// Set quotas
+	if task.Quotas != nil {
+		newTask.Quotas = convertQuotasToService(*task.Quotas)
+	}
+
+	// Set priority
+	if task.Priority != nil {
+		newTask.Priority = *task.Priority
+	}
+
 	// Check for parity between tickscript and dbrp
 
 	pn, err := newProgramNodeFromTickscript(newTask.TICKscript)
@@ -788,7 +892,7 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	// Validate task
 	_, err = ts.newKapacitorTask(newTask)
 	if err != nil {
-		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		httpd.HttpError(w, invalidTaskMessage(err), true, http.StatusBadRequest)
 		return
 	}
 
@@ -974,6 +1078,34 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 	statusChanged := previousStatus != updated.Status
 
+	// Update log levels
+	previousLogLevel, previousNodeLogLevels := updated.LogLevel, updated.NodeLogLevels
+	switch level := strings.ToLower(task.LogLevel); level {
+	case "":
+	case defaultLogLevel:
+		updated.LogLevel = ""
+	default:
+		updated.LogLevel = level
+	}
+	if task.NodeLogLevels != nil {
+		updated.NodeLogLevels = mergeNodeLogLevels(updated.NodeLogLevels, task.NodeLogLevels)
+	}
+	logLevelsChanged := previousLogLevel != updated.LogLevel || !reflect.DeepEqual(previousNodeLogLevels, updated.NodeLogLevels)
+
+	// Update quotas
+	previousQuotas := updated.Quotas
+	if task.Quotas != nil {
+		updated.Quotas = convertQuotasToService(*task.Quotas)
+	}
+	quotasChanged := previousQuotas != updated.Quotas
+
+	// Update priority
+	previousPriority := updated.Priority
+	if task.Priority != nil {
+		updated.Priority = *task.Priority
+	}
+	priorityChanged := previousPriority != updated.Priority
+
 	// Set vars
 	if len(task.Vars) > 0 {
 		updated.Vars, err = ts.convertToServiceVars(task.Vars)
@@ -997,7 +1129,7 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	// Validate task
 	_, err = ts.newKapacitorTask(updated)
 	if err != nil {
-		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		httpd.HttpError(w, invalidTaskMessage(err), true, http.StatusBadRequest)
 		return
 	}
 
@@ -1013,6 +1145,7 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			httpd.HttpError(w, fmt.Sprintf("failed to create new task during ID change: %s", err.Error()), true, http.StatusInternalServerError)
 			return
 		}
+		ts.readiness.drop(original.ID)
 		if err := ts.tasks.Delete(original.ID); err != nil {
 			ts.diag.Error(
 				"failed to delete old task definition during ID change",
@@ -1034,6 +1167,27 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			httpd.HttpError(w, fmt.Sprintf("failed to replace task definition: %s", err.Error()), true, http.StatusInternalServerError)
 			return
 		}
+		// Apply the log levels to the executing task without restarting it.
+		if logLevelsChanged && !statusChanged && updated.Status == Enabled {
+			if err := ts.TaskMasterLookup.Main().SetTaskLogLevels(updated.ID, updated.LogLevel, updated.NodeLogLevels); err != nil {
+				httpd.HttpError(w, fmt.Sprintf("failed to set task log levels: %s", err.Error()), true, http.StatusInternalServerError)
+				return
+			}
+		}
+		// Apply the quotas to the executing task without restarting it.
+		if quotasChanged && !statusChanged && updated.Status == Enabled {
+			if err := ts.TaskMasterLookup.Main().SetTaskQuotas(updated.ID, convertQuotasToKapacitor(updated.Quotas)); err != nil {
+				httpd.HttpError(w, fmt.Sprintf("failed to set task quotas: %s", err.Error()), true, http.StatusInternalServerError)
+				return
+			}
+		}
+		// Apply the priority to the executing task without restarting it.
+		if priorityChanged && !statusChanged && updated.Status == Enabled {
+			if err := ts.TaskMasterLookup.Main().SetTaskPriority(updated.ID, updated.Priority); err != nil {
+				httpd.HttpError(w, fmt.Sprintf("failed to set task priority: %s", err.Error()), true, http.StatusInternalServerError)
+				return
+			}
+		}
 	}
 
 	if statusChanged {
@@ -1048,7 +1202,9 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			}
 		case Disabled:
 			vars.NumEnabledTasksVar.Add(-1)
-			ts.stopTask(original.ID)
+			ts.readiness.drop(original.ID)
+			// Disabling is how tasks are reloaded, buffer their points in case they are enabled again.
+			ts.stopTaskForRestart(original.ID)
 		}
 	}
 
@@ -1136,6 +1292,10 @@ func (ts *Service) convertTask(t Task, scriptFormat, dotView string, tm *kapacit
 		DBRPs:          dbrps,
 		TICKscript:     script,
 		Vars:           vars,
+		LogLevel:       t.LogLevel,
+		NodeLogLevels:  t.NodeLogLevels,
+		Quotas:         convertQuotasToClient(t.Quotas),
+		Priority:       t.Priority,
 		Status:         status,
 		Dot:            dot,
 		Executing:      executing,
@@ -1411,6 +1571,43 @@ func (ts *Service) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleCancelTaskQueries cancels the batch queries a task is currently executing.
+// The path is of the form /tasks/<id>/cancel-query.
+func (ts *Service) handleCancelTaskQueries(w http.ResponseWriter, r *http.Request) {
+	p, err := ts.taskIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	id, action := path.Split(p)
+	id = strings.TrimSuffix(id, "/")
+	if id == "" || action != "cancel-query" {
+		httpd.HttpError(w, fmt.Sprintf("unknown task action on path %q", r.URL.Path), true, http.StatusNotFound)
+		return
+	}
+	task, err := ts.tasks.Get(id)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
+		return
+	}
+	if task.Type != BatchTask {
+		httpd.HttpError(w, fmt.Sprintf("task %q is not a batch task", id), true, http.StatusBadRequest)
+		return
+	}
+	tm := ts.TaskMasterLookup.Main()
+	if !tm.IsExecuting(id) {
+		httpd.HttpError(w, fmt.Sprintf("task %q is not executing", id), true, http.StatusBadRequest)
+		return
+	}
+	canceled, err := tm.CancelBatchQueries(id)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(httpd.MarshalJSON(client.CanceledQueries{Canceled: canceled}, true))
+}
+
 func (ts *Service) deleteTask(id string) error {
 	// Delete associated snapshot
 	ts.snapshots.Delete(id)
@@ -1430,6 +1627,7 @@ func (ts *Service) deleteTask(id string) error {
 		}
 	}
 	vars.NumTasksVar.Add(-1)
+	ts.readiness.drop(id)
 	if task.Status == Enabled {
 		vars.NumEnabledTasksVar.Add(-1)
 		ts.TaskMasterLookup.Main().DeleteTask(id)
@@ -1915,7 +2113,7 @@ func (ts *Service) updateAllAssociatedTasks(old, new Template, taskIds []string)
 			return fmt.Errorf("error updating associated task %s: %s", taskId, err)
 		}
 		if task.Status == Enabled {
-			ts.stopTask(taskId)
+			ts.stopTaskForRestart(taskId)
 			err := ts.startTask(task)
 			if err != nil {
 				return fmt.Errorf("error reloading associated task %s: %s", taskId, err)
@@ -1959,13 +2157,91 @@ func (ts *Service) newKapacitorTask(task Task) (*kapacitor.Task, error) {
 	if err != nil {
 		return nil, err
 	}
-	return ts.TaskMasterLookup.Main().NewTask(task.ID,
+	t, err := ts.TaskMasterLookup.Main().NewTask(task.ID,
 		task.TICKscript,
 		tt,
 		dbrps,
 		ts.snapshotInterval,
 		vars,
 	)
+	if err != nil {
+		return nil, err
+	}
+	t.LogLevel = task.LogLevel
+	t.NodeLogLevels = task.NodeLogLevels
+	if err := t.ValidateLogLevels(); err != nil {
+		return nil, logLevelError{err}
+	}
+	t.Quotas = convertQuotasToKapacitor(task.Quotas)
+	if err := t.Quotas.Validate(); err != nil {
+		return nil, err
+	}
+	t.Priority = task.Priority
+	return t, nil
+}
+
+func convertQuotasToService(q client.TaskQuotas) TaskQuotas {
+	return TaskQuotas{
+		MaxPointsPerSecond: q.MaxPointsPerSecond,
+		MaxCardinality:     q.MaxCardinality,
+		MaxMemoryBytes:     q.MaxMemoryBytes,
+		Enforcement:        strings.ToLower(q.Enforcement),
+	}
+}
+
+func convertQuotasToClient(q TaskQuotas) client.TaskQuotas {
+	return client.TaskQuotas{
+		MaxPointsPerSecond: q.MaxPointsPerSecond,
+		MaxCardinality:     q.MaxCardinality,
+		MaxMemoryBytes:     q.MaxMemoryBytes,
+		Enforcement:        q.Enforcement,
+	}
+}
+
+func convertQuotasToKapacitor(q TaskQuotas) kapacitor.TaskQuotas {
+	return kapacitor.TaskQuotas{
+		MaxPointsPerSecond: q.MaxPointsPerSecond,
+		MaxCardinality:     q.MaxCardinality,
+		MaxMemoryBytes:     q.MaxMemoryBytes,
+		Enforcement:        q.Enforcement,
+	}
+}
+
+// logLevelError is an invalid log level of a task or of its nodes.
+type logLevelError struct {
+	error
+}
+
+// invalidTaskMessage returns the message of the error of a task which failed to validate.
+func invalidTaskMessage(err error) string {
+	if _, ok := err.(logLevelError); ok {
+		return err.Error()
+	}
+	return "invalid TICKscript: " + err.Error()
+}
+
+// defaultLogLevel removes the log level of a task or node when updating a task.
+const defaultLogLevel = "default"
+
+// mergeNodeLogLevels returns the node log levels with the updates applied,
+// an update to the default level removes the level of the node.
+func mergeNodeLogLevels(levels, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(levels)+len(updates))
+	for name, level := range levels {
+		merged[name] = level
+	}
+	for name, level := range updates {
+		level = strings.ToLower(level)
+		if level == defaultLogLevel || level == "" {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = level
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
 }
 
 func (ts *Service) templateTask(template Template) (*kapacitor.Template, error) {
@@ -1997,6 +2273,11 @@ func (ts *Service) startTask(task Task) error {
 	tm := ts.TaskMasterLookup.Main()
 	// Start the task
 	et, err := tm.StartTask(t)
+	if err == kapacitor.ErrTaskAlreadyRunning {
+		// Started meanwhile, through the API or by the retries of the tasks that failed to start.
+		ts.readiness.started(t.ID)
+		return nil
+	}
 	if err != nil {
 		ts.saveLastError(t.ID, err.Error())
 		return err
@@ -2010,6 +2291,7 @@ func (ts *Service) startTask(task Task) error {
 			return err
 		}
 	}
+	ts.readiness.started(t.ID)
 
 	go func() {
 		// Wait for task to finish
@@ -2035,6 +2317,11 @@ func (ts *Service) stopTask(id string) {
 	ts.TaskMasterLookup.Main().StopTask(id)
 }
 
+// stopTaskForRestart stops a task that is started again, buffering the points of its stream meanwhile.
+func (ts *Service) stopTaskForRestart(id string) {
+	ts.TaskMasterLookup.Main().StopTaskForRestart(id)
+}
+
 // Save last error from task.
 func (ts *Service) saveLastError(id string, errStr string) error {
 	task, err := ts.tasks.Get(id)
@@ -0,0 +1,199 @@
+package task_store
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/services/httpd"
+)
+
+const readyPath = "/ready"
+
+// readiness tracks the critical tasks which have not started since the service opened.
+// The server is ready once the enabled tasks are listed and every critical task has started,
+// or is no longer enabled.
+type readiness struct {
+	mu     sync.Mutex
+	listed bool
+	// pending are the critical tasks which have not started, true once a start failed.
+	pending map[string]bool
+}
+
+func newReadiness() *readiness {
+	return &readiness{
+		pending: make(map[string]bool),
+	}
+}
+
+// setCritical sets the critical tasks of the startup load.
+func (r *readiness) setCritical(ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.pending[id] = false
+	}
+	r.listed = true
+}
+
+// started records that the task started.
+func (r *readiness) started(id string) {
+	r.drop(id)
+}
+
+// failed records that the task failed to start.
+func (r *readiness) failed(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[id]; ok {
+		r.pending[id] = true
+	}
+}
+
+// drop records that the task no longer needs to start, because it was disabled or deleted.
+func (r *readiness) drop(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// status returns whether the server is ready, the number of critical tasks not started and the sorted IDs of those which failed to start.
+func (r *readiness) status() (ready bool, pending int, failed []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, f := range r.pending {
+		if f {
+			failed = append(failed, id)
+		}
+	}
+	sort.Strings(failed)
+	return r.listed && len(r.pending) == 0, len(r.pending), failed
+}
+
+type readyResponse struct {
+	Ready   bool     `json:"ready"`
+	Pending int      `json:"pending"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// handleReady responds 200 once the critical tasks have started and 503 until then.
+func (ts *Service) handleReady(w http.ResponseWriter, r *http.Request) {
+	ready, pending, failed := ts.readiness.status()
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(code)
+	w.Write(httpd.MarshalJSON(readyResponse{
+		Ready:   ready,
+		Pending: pending,
+		Failed:  failed,
+	}, true))
+}
+
+// startEnabledTasks starts the enabled tasks in order of priority, highest first,
+// using up to startupConcurrency goroutines.
+// The tasks which fail to start are retried in the background.
+func (ts *Service) startEnabledTasks(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Priority > tasks[j].Priority
+	})
+	ts.readiness.setCritical(criticalTasks(tasks, ts.criticalPriority))
+
+	work := make(chan Task)
+	var (
+		mu     sync.Mutex
+		failed []Task
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < ts.startupConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range work {
+				if err := ts.startEnabledTask(task); err != nil {
+					mu.Lock()
+					failed = append(failed, task)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, task := range tasks {
+		work <- task
+	}
+	close(work)
+	wg.Wait()
+
+	if len(failed) > 0 && ts.startupRetries > 0 {
+		sort.SliceStable(failed, func(i, j int) bool {
+			return failed[i].Priority > failed[j].Priority
+		})
+		ts.wg.Add(1)
+		go func() {
+			defer ts.wg.Done()
+			ts.retryEnabledTasks(failed)
+		}()
+	}
+}
+
+// criticalTasks returns the IDs of the tasks with at least the critical priority,
+// none if the critical priority is 0.
+func criticalTasks(tasks []Task, criticalPriority int64) []string {
+	if criticalPriority == 0 {
+		return nil
+	}
+	var critical []string
+	for _, task := range tasks {
+		if task.Priority >= criticalPriority {
+			critical = append(critical, task.ID)
+		}
+	}
+	return critical
+}
+
+func (ts *Service) startEnabledTask(task Task) error {
+	ts.diag.StartingTask(task.ID)
+	if err := ts.startTask(task); err != nil {
+		ts.diag.Error("failed to start enabled task", err, keyvalue.KV("task", task.ID))
+		ts.readiness.failed(task.ID)
+		return err
+	}
+	ts.diag.StartedTask(task.ID)
+	return nil
+}
+
+// retryEnabledTasks retries starting the tasks up to startupRetries times, startupRetryInterval apart.
+func (ts *Service) retryEnabledTasks(tasks []Task) {
+	tm := ts.TaskMasterLookup.Main()
+	for attempt := 1; attempt <= ts.startupRetries && len(tasks) > 0; attempt++ {
+		select {
+		case <-ts.closing:
+			return
+		case <-time.After(ts.startupRetryInterval):
+		}
+		var failed []Task
+		for _, task := range tasks {
+			// The task may have been updated, started, disabled or deleted meanwhile.
+			current, err := ts.tasks.Get(task.ID)
+			if err != nil || current.Status != Enabled {
+				ts.readiness.drop(task.ID)
+				continue
+			}
+			if tm.IsExecuting(current.ID) {
+				ts.readiness.started(current.ID)
+				continue
+			}
+			if err := ts.startEnabledTask(current); err != nil {
+				failed = append(failed, current)
+			}
+		}
+		tasks = failed
+	}
+	for _, task := range tasks {
+		ts.diag.Error("failed to start enabled task after retries", fmt.Errorf("gave up after %d retries", ts.startupRetries), keyvalue.KV("task", task.ID))
+	}
+}
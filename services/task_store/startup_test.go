@@ -0,0 +1,67 @@
+package task_store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	r := newReadiness()
+	if ready, _, _ := r.status(); ready {
+		t.Fatal("expected not ready before the tasks are listed")
+	}
+
+	r.setCritical([]string{"c", "a", "b"})
+	r.failed("b")
+	r.failed("a")
+	// Not critical
+	r.failed("x")
+	r.started("c")
+	ready, pending, failed := r.status()
+	if ready {
+		t.Error("expected not ready while critical tasks are pending")
+	}
+	if pending != 2 {
+		t.Errorf("unexpected pending got %d exp 2", pending)
+	}
+	if exp := []string{"a", "b"}; !reflect.DeepEqual(failed, exp) {
+		t.Errorf("unexpected failed got %v exp %v", failed, exp)
+	}
+
+	r.started("a")
+	r.drop("b")
+	ready, pending, failed = r.status()
+	if !ready || pending != 0 || len(failed) != 0 {
+		t.Errorf("expected ready got ready %t pending %d failed %v", ready, pending, failed)
+	}
+}
+
+func TestReadiness_NoCriticalTasks(t *testing.T) {
+	r := newReadiness()
+	r.setCritical(nil)
+	if ready, _, _ := r.status(); !ready {
+		t.Error("expected ready without critical tasks")
+	}
+}
+
+func TestCriticalTasks(t *testing.T) {
+	tasks := []Task{
+		{ID: "high", Priority: 10},
+		{ID: "threshold", Priority: 5},
+		{ID: "low", Priority: 1},
+		{ID: "default"},
+	}
+	testCases := []struct {
+		criticalPriority int64
+		exp              []string
+	}{
+		{criticalPriority: 0, exp: nil},
+		{criticalPriority: 5, exp: []string{"high", "threshold"}},
+		{criticalPriority: 11, exp: nil},
+	}
+	for _, tc := range testCases {
+		if got := criticalTasks(tasks, tc.criticalPriority); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("critical priority %d: unexpected critical tasks got %v exp %v", tc.criticalPriority, got, tc.exp)
+		}
+	}
+}
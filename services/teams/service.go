@@ -99,7 +99,11 @@ func (s *Service) Alert(channelURL, alertTopic, alertID, message string, level a
 	if err != nil {
 		return err
 	}
+	return s.post(url, post)
+}
 
+// post sends the prepared post to Teams.
+func (s *Service) post(url string, post io.Reader) error {
 	resp, err := http.Post(url, "application/json", post)
 	if err != nil {
 		return err
@@ -163,7 +167,7 @@ func (s *Service) preparePost(channelURL, alertTopic, alertID, message string, l
 	}
 
 	var color string
-	switch level {
+	switch level.Builtin() {
 	case alert.Warning:
 		color = "FFA533"
 	case alert.Critical:
@@ -194,30 +198,48 @@ type HandlerConfig struct {
 	// Teams channel webhook URL used to post messages.
 	// If empty uses the channel URL from the configuration.
 	ChannelURL string `mapstructure:"channel-url"`
+
+	// MaxPayloadSize is the maximum size in bytes of the posts, larger posts have their message truncated.
+	// Zero means no limit.
+	MaxPayloadSize int `mapstructure:"max-payload-size"`
 }
 
 type handler struct {
-	s    *Service
-	c    HandlerConfig
-	diag Diagnostic
+	s     *Service
+	c     HandlerConfig
+	limit *alert.PayloadLimit
+	diag  Diagnostic
 }
 
 func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 	return &handler{
-		s:    s,
-		c:    c,
-		diag: s.diag.WithContext(ctx...),
+		s:     s,
+		c:     c,
+		limit: alert.NewPayloadLimit("teams", c.MaxPayloadSize),
+		diag:  s.diag.WithContext(ctx...),
 	}
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
-		h.c.ChannelURL,
-		event.Topic,
-		event.State.ID,
-		event.State.Message,
-		event.State.Level,
-	); err != nil {
+	var url string
+	_, body, err := h.limit.Fit(event, func(e alert.Event) ([]byte, error) {
+		u, post, err := h.s.preparePost(
+			h.c.ChannelURL,
+			e.Topic,
+			e.State.ID,
+			e.State.Message,
+			e.State.Level,
+		)
+		if err != nil {
+			return nil, err
+		}
+		url = u
+		return io.ReadAll(post)
+	})
+	if err == nil {
+		err = h.s.post(url, bytes.NewReader(body))
+	}
+	if err != nil {
 		h.diag.Error("failed to send event to Teams", err)
 	}
 }
@@ -6,6 +6,21 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor"
+)
+
+const (
+	// DefaultRequestTimeout is the default time a socket UDF has to accept and to answer a request.
+	DefaultRequestTimeout = 10 * time.Second
+	// DefaultBreakerThreshold is the default number of consecutive request timeouts
+	// after which the circuit breaker of a socket UDF opens.
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerProbeInterval is the default interval at which an open circuit breaker
+	// sends a request to the UDF to check whether it has recovered.
+	DefaultBreakerProbeInterval = 30 * time.Second
+
+	BreakerModeDrop = "drop"
+	BreakerModePass = "pass"
 )
 
 type Config struct {
@@ -18,6 +33,19 @@ type FunctionConfig struct {
 
 	// Config for connecting to domain socket
 	Socket string `toml:"socket"`
+	// Maximum time the socket UDF has to accept and to answer each request.
+	// Defaults to DefaultRequestTimeout.
+	RequestTimeout toml.Duration `toml:"request-timeout"`
+	// Number of consecutive request timeouts after which the circuit breaker opens.
+	// Defaults to DefaultBreakerThreshold.
+	BreakerThreshold int `toml:"breaker-threshold"`
+	// How often an open circuit breaker probes the UDF for recovery.
+	// Defaults to DefaultBreakerProbeInterval.
+	BreakerProbeInterval toml.Duration `toml:"breaker-probe-interval"`
+	// What to do with data while the circuit breaker is open,
+	// either "drop" it or "pass" it through unchanged.
+	// Defaults to "drop".
+	BreakerMode string `toml:"breaker-mode"`
 
 	// Config for creating process
 	Prog string            `toml:"prog"`
@@ -50,6 +78,42 @@ func (c FunctionConfig) Validate() error {
 		}
 	} else if c.Prog == "" {
 		return errors.New("must set either prog or socket")
+	} else if c.RequestTimeout != 0 || c.BreakerThreshold != 0 || c.BreakerProbeInterval != 0 || c.BreakerMode != "" {
+		return errors.New("request timeout and breaker options are only valid with socket config")
+	}
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("request-timeout must not be negative: %s", c.RequestTimeout)
+	}
+	if c.BreakerThreshold < 0 {
+		return fmt.Errorf("breaker-threshold must not be negative: %d", c.BreakerThreshold)
+	}
+	if c.BreakerProbeInterval < 0 {
+		return fmt.Errorf("breaker-probe-interval must not be negative: %s", c.BreakerProbeInterval)
+	}
+	switch c.BreakerMode {
+	case "", BreakerModeDrop, BreakerModePass:
+	default:
+		return fmt.Errorf("invalid breaker-mode %q, must be one of %q or %q", c.BreakerMode, BreakerModeDrop, BreakerModePass)
 	}
 	return nil
 }
+
+// Breaker returns the circuit breaker configuration of a socket UDF with defaults applied.
+func (c FunctionConfig) Breaker() kapacitor.UDFSocketBreaker {
+	b := kapacitor.UDFSocketBreaker{
+		Timeout:       time.Duration(c.RequestTimeout),
+		Threshold:     c.BreakerThreshold,
+		ProbeInterval: time.Duration(c.BreakerProbeInterval),
+		PassThrough:   c.BreakerMode == BreakerModePass,
+	}
+	if b.Timeout == 0 {
+		b.Timeout = DefaultRequestTimeout
+	}
+	if b.Threshold == 0 {
+		b.Threshold = DefaultBreakerThreshold
+	}
+	if b.ProbeInterval == 0 {
+		b.ProbeInterval = DefaultBreakerProbeInterval
+	}
+	return b
+}
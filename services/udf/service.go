@@ -79,6 +79,7 @@ func (s *Service) Create(
 			kapacitor.NewSocketConn(conf.Socket),
 			d,
 			time.Duration(conf.Timeout),
+			conf.Breaker(),
 			abortCallback,
 		), nil
 	} else {
@@ -1,9 +1,21 @@
 package udp
 
+import (
+	"fmt"
+)
+
 const (
 	// The number of packets to buffer when reading off the socket.
 	// A buffer of this size will be allocated for each instance of a UDP service.
 	DefaultBuffer int = 1e3
+
+	// OverflowBlock stops reading off the socket while the buffer is full,
+	// the packets are then dropped by the OS once its read buffer is full.
+	OverflowBlock = "block"
+	// OverflowDrop drops and counts the packets read off the socket while the buffer is full.
+	OverflowDrop = "drop"
+
+	DefaultOverflowPolicy = OverflowBlock
 )
 
 type Config struct {
@@ -11,6 +23,8 @@ type Config struct {
 	BindAddress string `toml:"bind-address"`
 	ReadBuffer  int    `toml:"read-buffer"`
 	Buffer      int    `toml:"buffer"`
+	// OverflowPolicy is what happens to the packets read while the buffer is full, one of block or drop.
+	OverflowPolicy string `toml:"overflow-policy"`
 
 	Database        string `toml:"database"`
 	RetentionPolicy string `toml:"retention-policy"`
@@ -20,6 +34,31 @@ type Config struct {
 // default values set.
 func (c *Config) WithDefaults() *Config {
 	d := *c
-	d.Buffer = DefaultBuffer
+	if d.Buffer == 0 {
+		d.Buffer = DefaultBuffer
+	}
+	if d.OverflowPolicy == "" {
+		d.OverflowPolicy = DefaultOverflowPolicy
+	}
 	return &d
 }
+
+func (c Config) Validate() error {
+	if c.Buffer < 0 {
+		return fmt.Errorf("buffer must not be negative, got %d", c.Buffer)
+	}
+	if c.ReadBuffer < 0 {
+		return fmt.Errorf("read-buffer must not be negative, got %d", c.ReadBuffer)
+	}
+	return ValidateOverflowPolicy(c.OverflowPolicy)
+}
+
+// ValidateOverflowPolicy returns an error if the policy is not empty, block or drop.
+func ValidateOverflowPolicy(policy string) error {
+	switch policy {
+	case "", OverflowBlock, OverflowDrop:
+		return nil
+	default:
+		return fmt.Errorf("invalid overflow policy %q, must be one of %s or %s", policy, OverflowBlock, OverflowDrop)
+	}
+}
@@ -25,11 +25,20 @@ const (
 	statReadFail          = "read_fail"
 	statPointsTransmitted = "points_tx"
 	statTransmitFail      = "tx_fail"
+	// Packets dropped by the drop overflow policy because the buffer was full.
+	statPacketsDropped = "packets_dropped"
+	// Packets dropped by the OS because its read buffer was full, where the OS reports them.
+	statOSPacketsDropped = "os_packets_dropped"
 )
 
+// highWaterMark is the fraction of the buffer filled at which the HighWaterMark diagnostic is reported,
+// it is reported again once the buffer has been drained below half.
+const highWaterMark = 0.9
+
 type Diagnostic interface {
 	Error(msg string, err error, ctx ...keyvalue.T)
 	StartedListening(addr string)
+	BufferHighWaterMark(queued, size int, policy string)
 	ClosedService()
 }
 
@@ -42,6 +51,8 @@ type Service struct {
 	wg      sync.WaitGroup
 	done    chan struct{}
 	packets chan []byte
+	// Whether the OS reports the packets dropped by the socket
+	dropCount bool
 
 	config Config
 
@@ -100,6 +111,13 @@ func (s *Service) Open() (err error) {
 		}
 	}
 
+	// Count the packets dropped by the OS where it reports them.
+	s.dropCount, err = enableDropCount(s.conn)
+	if err != nil {
+		s.Diag.Error("failed to enable the UDP drop count, packets dropped by the OS are not counted", err)
+		s.dropCount = false
+	}
+
 	s.Diag.StartedListening(s.addr.String())
 
 	// Start reading and processing packets
@@ -117,6 +135,13 @@ func (s *Service) serve() {
 	defer close(s.packets)
 
 	buf := make([]byte, UDPPacketSize)
+	var oob []byte
+	if s.dropCount {
+		oob = make([]byte, oobSize)
+	}
+	// The number of packets dropped by the OS, as last reported
+	var osDropped uint32
+	highWater := false
 	for {
 
 		select {
@@ -127,7 +152,7 @@ func (s *Service) serve() {
 			// Keep processing.
 		}
 
-		n, _, err := s.conn.ReadFromUDP(buf)
+		n, oobn, _, _, err := s.conn.ReadMsgUDP(buf, oob)
 		if err != nil {
 			if !strings.Contains(err.Error(), "use of closed network connection") {
 				s.statMap.Add(statReadFail, 1)
@@ -135,10 +160,34 @@ func (s *Service) serve() {
 			}
 			continue
 		}
+		if oobn > 0 {
+			if dropped, ok := parseDropCount(oob[:oobn]); ok {
+				// The count is cumulative and wraps around.
+				s.statMap.Add(statOSPacketsDropped, int64(dropped-osDropped))
+				osDropped = dropped
+			}
+		}
 		s.statMap.Add(statBytesReceived, int64(n))
 		p := make([]byte, n)
 		copy(p, buf[:n])
-		s.packets <- p
+
+		queued := len(s.packets)
+		if !highWater && float64(queued) >= highWaterMark*float64(s.config.Buffer) {
+			highWater = true
+			s.Diag.BufferHighWaterMark(queued, s.config.Buffer, s.config.OverflowPolicy)
+		} else if highWater && queued <= s.config.Buffer/2 {
+			highWater = false
+		}
+
+		if s.config.OverflowPolicy == OverflowDrop {
+			select {
+			case s.packets <- p:
+			default:
+				s.statMap.Add(statPacketsDropped, 1)
+			}
+		} else {
+			s.packets <- p
+		}
 	}
 }
 
@@ -0,0 +1,110 @@
+package udp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+)
+
+type testDiag struct {
+	mu         sync.Mutex
+	highWaters int
+}
+
+func (d *testDiag) Error(msg string, err error, ctx ...keyvalue.T) {}
+func (d *testDiag) StartedListening(addr string)                   {}
+func (d *testDiag) ClosedService()                                 {}
+func (d *testDiag) BufferHighWaterMark(queued, size int, policy string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.highWaters++
+}
+
+// blockingWriter blocks writes until it is released.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w blockingWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	<-w.release
+	return nil
+}
+
+func TestService_OverflowDrop(t *testing.T) {
+	diag := &testDiag{}
+	s := NewService(Config{
+		BindAddress:    "127.0.0.1:0",
+		Buffer:         2,
+		OverflowPolicy: OverflowDrop,
+		Database:       "db",
+	}, diag)
+	w := blockingWriter{release: make(chan struct{})}
+	s.PointsWriter = w
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer close(w.release)
+
+	conn, err := net.DialUDP("udp", nil, s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// One packet is being written, two are buffered, the rest are dropped.
+	// The second packet may arrive before the first is taken off the buffer, then one more is dropped.
+	const sent = 10
+	for i := 0; i < sent; i++ {
+		if _, err := conn.Write([]byte("cpu value=1 1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout := time.After(5 * time.Second)
+	dropped := func() int64 {
+		if v, ok := s.statMap.Get(statPacketsDropped).(*expvar.Int); ok {
+			return v.IntValue()
+		}
+		return 0
+	}
+	for dropped() < sent-3 {
+		select {
+		case <-timeout:
+			t.Fatalf("unexpected dropped packets got %d exp at least %d", dropped(), sent-3)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if got := dropped(); got > sent-2 {
+		t.Errorf("unexpected dropped packets got %d exp at most %d", got, sent-2)
+	}
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if diag.highWaters != 1 {
+		t.Errorf("unexpected high water mark reports got %d exp 1", diag.highWaters)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		c     Config
+		valid bool
+	}{
+		{c: Config{}, valid: true},
+		{c: Config{Buffer: 10, OverflowPolicy: OverflowDrop}, valid: true},
+		{c: Config{OverflowPolicy: OverflowBlock}, valid: true},
+		{c: Config{OverflowPolicy: "discard"}},
+		{c: Config{Buffer: -1}},
+		{c: Config{ReadBuffer: -1}},
+	}
+	for i, tc := range testCases {
+		if err := tc.c.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%d: unexpected validation error %v", i, err)
+		}
+	}
+}
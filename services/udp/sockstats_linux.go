@@ -0,0 +1,44 @@
+package udp
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// oobSize is the size of the out of band data holding the drop count of the socket.
+var oobSize = syscall.CmsgSpace(4)
+
+// enableDropCount asks the OS to report the number of packets dropped by the socket along with each packet read,
+// it reports whether the drop count is supported.
+func enableDropCount(conn *net.UDPConn) (bool, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RXQ_OVFL, 1)
+	}); err != nil {
+		return false, err
+	}
+	if serr != nil {
+		return false, serr
+	}
+	return true, nil
+}
+
+// parseDropCount returns the number of packets dropped by the socket since it was opened from the out of band data of a packet.
+func parseDropCount(oob []byte) (uint32, bool) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0, false
+	}
+	for _, m := range msgs {
+		if m.Header.Level == syscall.SOL_SOCKET && m.Header.Type == syscall.SO_RXQ_OVFL && len(m.Data) >= 4 {
+			// The count is a native endian uint32.
+			return *(*uint32)(unsafe.Pointer(&m.Data[0])), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package udp
+
+import "net"
+
+// oobSize is the size of the out of band data holding the drop count of the socket.
+var oobSize = 0
+
+// enableDropCount reports that the drop count of the socket is not supported.
+func enableDropCount(conn *net.UDPConn) (bool, error) {
+	return false, nil
+}
+
+// parseDropCount returns the number of packets dropped by the socket since it was opened from the out of band data of a packet.
+func parseDropCount(oob []byte) (uint32, bool) {
+	return 0, false
+}
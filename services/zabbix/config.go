@@ -0,0 +1,76 @@
+package zabbix
+
+import (
+	"net"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultID      = "default"
+	DefaultAddr    = "localhost:10051"
+	DefaultKey     = "kapacitor.alert"
+	DefaultTimeout = 10 * time.Second
+)
+
+type Config struct {
+	// Whether Zabbix integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// ID is a unique identifier for this Zabbix config.
+	ID string `toml:"id" override:"id"`
+	// The host:port address of the Zabbix server or proxy trapper.
+	Addr string `toml:"addr" override:"addr"`
+	// The default Zabbix host of the items.
+	Host string `toml:"host" override:"host"`
+	// The default key of the Zabbix trapper item.
+	Key string `toml:"key" override:"key"`
+	// Timeout on network operations with the Zabbix server.
+	// If 0 a default of 10s will be used.
+	Timeout toml.Duration `toml:"timeout" override:"timeout"`
+}
+
+func NewConfig() Config {
+	return Config{
+		ID:      DefaultID,
+		Addr:    DefaultAddr,
+		Key:     DefaultKey,
+		Timeout: toml.Duration(DefaultTimeout),
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.ID == "" {
+		return errors.New("id must not be empty")
+	}
+	if c.Addr == "" {
+		return errors.New("must specify the Zabbix server address")
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return errors.Wrapf(err, "invalid address %q", c.Addr)
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+type Configs []Config
+
+func (cs Configs) Validate() error {
+	ids := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if ids[c.ID] {
+			return errors.Errorf("duplicate id %q", c.ID)
+		}
+		ids[c.ID] = true
+	}
+	return nil
+}
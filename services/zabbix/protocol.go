@@ -0,0 +1,122 @@
+package zabbix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// The Zabbix protocol frames every packet with a header, followed by the data:
+//
+//	<"ZBXD"><flags:1><data length:4><reserved:4><data>
+//
+// Large packets use 8 byte lengths instead.
+// See https://www.zabbix.com/documentation/current/en/manual/appendix/protocols/header_datalen
+const (
+	protocolFlag   byte = 0x01
+	compressedFlag byte = 0x02
+	largeFlag      byte = 0x04
+
+	// maxPacketSize limits the size of responses read from the server.
+	maxPacketSize = 1 << 20
+)
+
+var protocolMagic = []byte("ZBXD")
+
+// Item is a value of a Zabbix trapper item.
+type Item struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+	NS    int    `json:"ns"`
+}
+
+// Request is a sender data request.
+type Request struct {
+	Request string `json:"request"`
+	Data    []Item `json:"data"`
+	Clock   int64  `json:"clock"`
+	NS      int    `json:"ns"`
+}
+
+// Response is the response of the Zabbix server to a sender data request.
+type Response struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+var failedPattern = regexp.MustCompile(`failed: (\d+)`)
+
+// Err returns an error if the server failed any of the items of the request.
+func (r Response) Err() error {
+	if r.Response != "success" {
+		return fmt.Errorf("zabbix server responded with %q: %s", r.Response, r.Info)
+	}
+	if m := failedPattern.FindStringSubmatch(r.Info); m != nil {
+		if failed, _ := strconv.Atoi(m[1]); failed > 0 {
+			return fmt.Errorf("zabbix server failed to process items: %s", r.Info)
+		}
+	}
+	return nil
+}
+
+// WritePacket writes the data to w framed with the Zabbix protocol header.
+func WritePacket(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	buf.Grow(len(protocolMagic) + 9 + len(data))
+	buf.Write(protocolMagic)
+	buf.WriteByte(protocolFlag)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadPacket reads the data of a packet framed with the Zabbix protocol header from r.
+func ReadPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, len(protocolMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Wrap(err, "failed to read zabbix header")
+	}
+	if !bytes.Equal(header[:len(protocolMagic)], protocolMagic) {
+		return nil, fmt.Errorf("invalid zabbix header %q", header[:len(protocolMagic)])
+	}
+	flags := header[len(protocolMagic)]
+	if flags&protocolFlag == 0 {
+		return nil, fmt.Errorf("invalid zabbix header flags %#x", flags)
+	}
+	if flags&compressedFlag != 0 {
+		return nil, errors.New("compressed zabbix packets are not supported")
+	}
+
+	var size uint64
+	if flags&largeFlag != 0 {
+		var lengths [2]uint64
+		if err := binary.Read(r, binary.LittleEndian, &lengths); err != nil {
+			return nil, errors.Wrap(err, "failed to read zabbix data length")
+		}
+		size = lengths[0]
+	} else {
+		var lengths [2]uint32
+		if err := binary.Read(r, binary.LittleEndian, &lengths); err != nil {
+			return nil, errors.Wrap(err, "failed to read zabbix data length")
+		}
+		size = uint64(lengths[0])
+	}
+	if size > maxPacketSize {
+		return nil, fmt.Errorf("zabbix packet of %d bytes exceeds the maximum of %d bytes", size, maxPacketSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.Wrap(err, "failed to read zabbix data")
+	}
+	return data, nil
+}
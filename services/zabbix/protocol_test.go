@@ -0,0 +1,105 @@
+package zabbix_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/services/zabbix"
+)
+
+func TestPacket_RoundTrip(t *testing.T) {
+	data := []byte(`{"request":"sender data","data":[]}`)
+	var buf bytes.Buffer
+	if err := zabbix.WritePacket(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	header := buf.Bytes()[:13]
+	if got, exp := string(header[:4]), "ZBXD"; got != exp {
+		t.Errorf("unexpected magic got %q exp %q", got, exp)
+	}
+	if got, exp := header[4], byte(0x01); got != exp {
+		t.Errorf("unexpected flags got %#x exp %#x", got, exp)
+	}
+	if got, exp := binary.LittleEndian.Uint32(header[5:9]), uint32(len(data)); got != exp {
+		t.Errorf("unexpected data length got %d exp %d", got, exp)
+	}
+	got, err := zabbix.ReadPacket(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("unexpected data got %q exp %q", got, data)
+	}
+}
+
+func TestReadPacket_Large(t *testing.T) {
+	data := []byte(`{"response":"success"}`)
+	var buf bytes.Buffer
+	buf.WriteString("ZBXD")
+	buf.WriteByte(0x01 | 0x04)
+	binary.Write(&buf, binary.LittleEndian, uint64(len(data)))
+	binary.Write(&buf, binary.LittleEndian, uint64(0))
+	buf.Write(data)
+	got, err := zabbix.ReadPacket(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("unexpected data got %q exp %q", got, data)
+	}
+}
+
+func TestReadPacket_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		packet string
+		err    string
+	}{
+		{
+			name:   "invalid magic",
+			packet: "HTTP/1.1 400",
+			err:    "invalid zabbix header",
+		},
+		{
+			name:   "compressed",
+			packet: "ZBXD\x03\x00\x00\x00\x00\x00\x00\x00\x00",
+			err:    "compressed zabbix packets are not supported",
+		},
+		{
+			name:   "short data",
+			packet: "ZBXD\x01\x10\x00\x00\x00\x00\x00\x00\x00{}",
+			err:    "failed to read zabbix data",
+		},
+		{
+			name:   "too large",
+			packet: "ZBXD\x01\x00\x00\x00\x10\x00\x00\x00\x00",
+			err:    "exceeds the maximum",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := zabbix.ReadPacket(strings.NewReader(tc.packet))
+		if err == nil {
+			t.Errorf("%s: expected error", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: unexpected error got %q exp to contain %q", tc.name, err.Error(), tc.err)
+		}
+	}
+}
+
+func TestResponse_Err(t *testing.T) {
+	ok := zabbix.Response{Response: "success", Info: "processed: 1; failed: 0; total: 1; seconds spent: 0.000055"}
+	if err := ok.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	failed := zabbix.Response{Response: "success", Info: "processed: 0; failed: 1; total: 1; seconds spent: 0.000055"}
+	if err := failed.Err(); err == nil {
+		t.Error("expected error for failed items")
+	}
+	if err := (zabbix.Response{Response: "failed"}).Err(); err == nil {
+		t.Error("expected error for failed response")
+	}
+}
@@ -0,0 +1,271 @@
+package zabbix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	text "text/template"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/pkg/errors"
+)
+
+type Diagnostic interface {
+	WithContext(ctx ...keyvalue.T) Diagnostic
+	TemplateError(err error, kv keyvalue.T)
+	Error(msg string, err error)
+}
+
+type Service struct {
+	mu      sync.RWMutex
+	configs map[string]Config
+	diag    Diagnostic
+}
+
+func NewService(cs Configs, d Diagnostic) *Service {
+	configs := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		configs[c.ID] = c
+	}
+	return &Service{
+		configs: configs,
+		diag:    d,
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, nc := range newConfigs {
+		c, ok := nc.(Config)
+		if !ok {
+			return fmt.Errorf("unexpected config object type, got %T exp %T", nc, c)
+		}
+		cs[i] = c
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	configs := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		configs[c.ID] = c
+	}
+	s.mu.Lock()
+	s.configs = configs
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) config(id string) (Config, error) {
+	if id == "" {
+		id = DefaultID
+	}
+	s.mu.RLock()
+	c, ok := s.configs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return Config{}, fmt.Errorf("unknown zabbix server %q", id)
+	}
+	if !c.Enabled {
+		return Config{}, fmt.Errorf("zabbix server %q is not enabled", id)
+	}
+	return c, nil
+}
+
+type testOptions struct {
+	Server string      `json:"server"`
+	Host   string      `json:"host"`
+	Key    string      `json:"key"`
+	Level  alert.Level `json:"level"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	return &testOptions{
+		Server: DefaultID,
+		Host:   "kapacitor",
+		Key:    DefaultKey,
+		Level:  alert.Critical,
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	return s.Send(o.Server, []Item{{
+		Host:  o.Host,
+		Key:   o.Key,
+		Value: LevelValue(o.Level),
+		Clock: time.Now().Unix(),
+	}})
+}
+
+// LevelValue returns the item value of an alert level,
+// 0 for OK, 1 for INFO, 2 for WARNING and 3 for CRITICAL,
+// so that Zabbix triggers can compare the level numerically.
+func LevelValue(level alert.Level) string {
+	return strconv.Itoa(int(level))
+}
+
+// Send sends the items to the Zabbix server with the id using the sender protocol.
+func (s *Service) Send(id string, items []Item) error {
+	c, err := s.config(id)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].Host == "" {
+			items[i].Host = c.Host
+		}
+		if items[i].Key == "" {
+			items[i].Key = c.Key
+		}
+		if items[i].Host == "" {
+			return errors.New("must specify a zabbix host")
+		}
+		if items[i].Key == "" {
+			return errors.New("must specify a zabbix item key")
+		}
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(Request{
+		Request: "sender data",
+		Data:    items,
+		Clock:   now.Unix(),
+		NS:      now.Nanosecond(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal zabbix request")
+	}
+
+	timeout := time.Duration(c.Timeout)
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout("tcp", c.Addr, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	if err := WritePacket(conn, data); err != nil {
+		return errors.Wrap(err, "failed to write zabbix request")
+	}
+	respData, err := ReadPacket(conn)
+	if err != nil {
+		return err
+	}
+	var resp Response
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return errors.Wrap(err, "failed to decode zabbix response")
+	}
+	return resp.Err()
+}
+
+type HandlerConfig struct {
+	// The ID of the Zabbix server config.
+	// If empty uses the default config.
+	Server string `mapstructure:"server"`
+
+	// The Zabbix host of the item, a template of the alert data.
+	// If empty uses the host from the configuration.
+	Host string `mapstructure:"host"`
+
+	// The key of the Zabbix trapper item, a template of the alert data.
+	// If empty uses the key from the configuration.
+	Key string `mapstructure:"key"`
+
+	// The value of the item, a template of the alert data.
+	// If empty the value is the numeric alert level.
+	Value string `mapstructure:"value"`
+}
+
+type handler struct {
+	s    *Service
+	c    HandlerConfig
+	diag Diagnostic
+
+	hostTmpl  *text.Template
+	keyTmpl   *text.Template
+	valueTmpl *text.Template
+}
+
+func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, error) {
+	hostTmpl, err := text.New("host").Parse(c.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse host template")
+	}
+	keyTmpl, err := text.New("key").Parse(c.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse key template")
+	}
+	var valueTmpl *text.Template
+	if c.Value != "" {
+		valueTmpl, err = text.New("value").Parse(c.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse value template")
+		}
+	}
+	return &handler{
+		s:         s,
+		c:         c,
+		diag:      s.diag.WithContext(ctx...),
+		hostTmpl:  hostTmpl,
+		keyTmpl:   keyTmpl,
+		valueTmpl: valueTmpl,
+	}, nil
+}
+
+func (h *handler) Handle(event alert.Event) {
+	td := event.TemplateData()
+	var buf bytes.Buffer
+	if err := h.hostTmpl.Execute(&buf, td); err != nil {
+		h.diag.TemplateError(err, keyvalue.KV("host", h.c.Host))
+		return
+	}
+	host := buf.String()
+
+	buf.Reset()
+	if err := h.keyTmpl.Execute(&buf, td); err != nil {
+		h.diag.TemplateError(err, keyvalue.KV("key", h.c.Key))
+		return
+	}
+	key := buf.String()
+
+	value := LevelValue(event.State.Level)
+	if h.valueTmpl != nil {
+		buf.Reset()
+		if err := h.valueTmpl.Execute(&buf, td); err != nil {
+			h.diag.TemplateError(err, keyvalue.KV("value", h.c.Value))
+			return
+		}
+		value = buf.String()
+	}
+
+	if err := h.s.Send(h.c.Server, []Item{{
+		Host:  host,
+		Key:   key,
+		Value: value,
+		Clock: event.State.Time.Unix(),
+		NS:    event.State.Time.Nanosecond(),
+	}}); err != nil {
+		h.diag.Error("failed to send event to Zabbix", err)
+	}
+}
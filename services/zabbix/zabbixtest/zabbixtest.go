@@ -0,0 +1,82 @@
+package zabbixtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/influxdata/kapacitor/services/zabbix"
+)
+
+// Server is a Zabbix trapper that records the items it receives.
+type Server struct {
+	l      net.Listener
+	Addr   string
+	wg     sync.WaitGroup
+	closed bool
+
+	mu       sync.Mutex
+	requests []zabbix.Request
+}
+
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		l:    l,
+		Addr: l.Addr().String(),
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run()
+	}()
+	return s, nil
+}
+
+func (s *Server) Requests() []zabbix.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) Close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.l.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) run() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		func() {
+			defer conn.Close()
+			data, err := zabbix.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			var r zabbix.Request
+			if err := json.Unmarshal(data, &r); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.requests = append(s.requests, r)
+			s.mu.Unlock()
+
+			resp, _ := json.Marshal(zabbix.Response{
+				Response: "success",
+				Info:     fmt.Sprintf("processed: %d; failed: 0; total: %d; seconds spent: 0.000055", len(r.Data), len(r.Data)),
+			})
+			zabbix.WritePacket(conn, resp)
+		}()
+	}
+}
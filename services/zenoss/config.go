@@ -41,7 +41,7 @@ func (s *SeverityMap) CustomMapStructure(m map[string]interface{}) error {
 
 func (s *SeverityMap) ValueFor(level alert.Level) interface{} {
 	var severity interface{}
-	switch level {
+	switch level.Builtin() {
 	case alert.OK:
 		severity = s.OK
 	case alert.Info:
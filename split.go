@@ -0,0 +1,151 @@
+package kapacitor
+
+import (
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+type SplitNode struct {
+	node
+	s *pipeline.SplitNode
+
+	// expressions are the expressions of the fields of each point.
+	expressions [][]stateful.Expression
+	scopePools  []stateful.ScopePool
+}
+
+// Create a new SplitNode, which emits several points for each point.
+func newSplitNode(et *ExecutingTask, n *pipeline.SplitNode, d NodeDiagnostic) (*SplitNode, error) {
+	sn := &SplitNode{
+		node:        node{Node: n, et: et, diag: d},
+		s:           n,
+		expressions: make([][]stateful.Expression, len(n.Points)),
+		scopePools:  make([]stateful.ScopePool, len(n.Points)),
+	}
+	for i, p := range n.Points {
+		sn.expressions[i] = make([]stateful.Expression, len(p.Fields))
+		nodes := make([]ast.Node, len(p.Fields))
+		for j, f := range p.Fields {
+			expr, err := stateful.NewExpression(f.Lambda.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to compile expression of field %q of point %d: %v", f.Name, i, err)
+			}
+			sn.expressions[i][j] = expr
+			nodes[j] = f.Lambda.Expression
+		}
+		sn.scopePools[i] = stateful.NewScopePool(ast.FindReferenceVariables(nodes...))
+	}
+	sn.node.runF = sn.runSplit
+	return sn, nil
+}
+
+func (n *SplitNode) runSplit([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *SplitNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	expressions := make([][]stateful.Expression, len(n.expressions))
+	for i, exprs := range n.expressions {
+		expressions[i] = make([]stateful.Expression, len(exprs))
+		for j, expr := range exprs {
+			expressions[i][j] = expr.CopyReset()
+		}
+	}
+	return &splitGroup{
+		n:           n,
+		expressions: expressions,
+	}, nil
+}
+
+// splitGroup emits the points split from the points of a group, with the stateful expressions of the group.
+type splitGroup struct {
+	n           *SplitNode
+	expressions [][]stateful.Expression
+}
+
+func (g *splitGroup) BeginBatch(begin edge.BeginBatchMessage) error {
+	return nil
+}
+
+func (g *splitGroup) BatchPoint(bp edge.BatchPointMessage) error {
+	return nil
+}
+
+func (g *splitGroup) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (g *splitGroup) Point(p edge.PointMessage) error {
+	g.n.timer.Start()
+	defer g.n.timer.Stop()
+
+	for i, sp := range g.n.s.Points {
+		fields, err := g.fields(i, p)
+		if err != nil {
+			if !g.n.s.QuietFlag {
+				g.n.diag.Error("error evaluating expression", err)
+			}
+			continue
+		}
+		np := p.ShallowCopy()
+		if sp.Measurement != "" {
+			np.SetName(sp.Measurement)
+		}
+		if len(sp.Tags) > 0 {
+			tags := p.Tags().Copy()
+			for k, v := range sp.Tags {
+				tags[k] = v
+			}
+			np.SetTags(tags)
+		}
+		np.SetFields(fields)
+		np.SetTime(p.Time().Add(sp.Offset))
+
+		g.n.timer.Pause()
+		err = edge.Forward(g.n.outs, np)
+		g.n.timer.Resume()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fields evaluates the fields of the point i split from the point p.
+func (g *splitGroup) fields(i int, p edge.PointMessage) (models.Fields, error) {
+	sp := g.n.s.Points[i]
+	vars := g.n.scopePools[i].Get()
+	defer g.n.scopePools[i].Put(vars)
+	if err := fillScope(vars, g.n.scopePools[i].ReferenceVariables(), p); err != nil {
+		return nil, err
+	}
+	fields := make(models.Fields, len(sp.Fields))
+	for j, f := range sp.Fields {
+		v, err := g.expressions[i][j].Eval(vars)
+		if err != nil {
+			return nil, fmt.Errorf("field %q of point %d: %v", f.Name, i, err)
+		}
+		fields[f.Name] = v
+	}
+	return fields, nil
+}
+
+func (g *splitGroup) Barrier(b edge.BarrierMessage) error {
+	return edge.Forward(g.n.outs, b)
+}
+
+func (g *splitGroup) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(g.n.outs, d)
+}
+
+func (g *splitGroup) Done() {}
@@ -3,10 +3,12 @@ package kapacitor
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 )
 
@@ -17,6 +19,14 @@ type StatsNode struct {
 	closing chan struct{}
 	closed  bool
 	mu      sync.Mutex
+
+	// Last emitted count and the time it changed for each tracked group.
+	silences map[models.GroupID]*groupSilence
+}
+
+type groupSilence struct {
+	emitted int64
+	seen    time.Time
 }
 
 // Create a new  FromNode which filters data from a source.
@@ -37,6 +47,9 @@ func newStatsNode(et *ExecutingTask, n *pipeline.StatsNode, d NodeDiagnostic) (*
 		en:      en,
 		closing: make(chan struct{}),
 	}
+	if n.SilenceFlag {
+		sn.silences = make(map[models.GroupID]*groupSilence)
+	}
 	sn.node.runF = sn.runStats
 	sn.node.stopF = sn.stopStats
 	return sn, nil
@@ -91,6 +104,9 @@ func (n *StatsNode) emit(now time.Time) error {
 		t = t.Round(n.s.Interval)
 	}
 	stats := n.en.nodeStatsByGroup()
+	if n.s.SilenceFlag {
+		n.addSilence(stats, t)
+	}
 	for _, stat := range stats {
 		point := edge.NewPointMessage(
 			name, "", "",
@@ -111,6 +127,45 @@ func (n *StatsNode) emit(now time.Time) error {
 	return nil
 }
 
+// addSilence sets the silence field on the stats of the tracked groups
+// and removes the stats of the groups beyond the maximum number of groups.
+func (n *StatsNode) addSilence(stats map[models.GroupID]nodeStats, now time.Time) {
+	// Forget groups that have been deleted
+	for id := range n.silences {
+		if _, ok := stats[id]; !ok {
+			delete(n.silences, id)
+		}
+	}
+	var untracked []models.GroupID
+	for id, stat := range stats {
+		s, ok := n.silences[id]
+		if !ok {
+			untracked = append(untracked, id)
+			continue
+		}
+		if emitted, _ := stat.Fields["emitted"].(int64); emitted != s.emitted {
+			s.emitted = emitted
+			s.seen = now
+		}
+		stat.Fields["silence"] = now.Sub(s.seen).Seconds()
+	}
+	// Track new groups in a consistent order so the same groups are dropped when over the limit.
+	sort.Slice(untracked, func(i, j int) bool { return untracked[i] < untracked[j] })
+	for _, id := range untracked {
+		if n.s.MaxGroups > 0 && int64(len(n.silences)) >= n.s.MaxGroups {
+			delete(stats, id)
+			continue
+		}
+		stat := stats[id]
+		emitted, _ := stat.Fields["emitted"].(int64)
+		n.silences[id] = &groupSilence{
+			emitted: emitted,
+			seen:    now,
+		}
+		stat.Fields["silence"] = 0.0
+	}
+}
+
 func (n *StatsNode) stopStats() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -0,0 +1,71 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestStatsNode_Silence(t *testing.T) {
+	n := &StatsNode{
+		s: &pipeline.StatsNode{
+			SilenceFlag: true,
+			MaxGroups:   2,
+		},
+		silences: make(map[models.GroupID]*groupSilence),
+	}
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := func(emitted map[models.GroupID]int64) map[models.GroupID]nodeStats {
+		s := make(map[models.GroupID]nodeStats, len(emitted))
+		for id, e := range emitted {
+			s[id] = nodeStats{Fields: models.Fields{"emitted": e}}
+		}
+		return s
+	}
+	silences := func(stats map[models.GroupID]nodeStats) map[models.GroupID]float64 {
+		s := make(map[models.GroupID]float64, len(stats))
+		for id, stat := range stats {
+			s[id] = stat.Fields["silence"].(float64)
+		}
+		return s
+	}
+
+	testCases := []struct {
+		emitted map[models.GroupID]int64
+		exp     map[models.GroupID]float64
+	}{
+		{
+			// Group c is beyond the limit
+			emitted: map[models.GroupID]int64{"a": 1, "b": 1, "c": 1},
+			exp:     map[models.GroupID]float64{"a": 0, "b": 0},
+		},
+		{
+			emitted: map[models.GroupID]int64{"a": 2, "b": 1, "c": 2},
+			exp:     map[models.GroupID]float64{"a": 0, "b": 10},
+		},
+		{
+			emitted: map[models.GroupID]int64{"a": 2, "b": 1, "c": 3},
+			exp:     map[models.GroupID]float64{"a": 10, "b": 20},
+		},
+		{
+			// Group b resumed
+			emitted: map[models.GroupID]int64{"a": 2, "b": 5, "c": 4},
+			exp:     map[models.GroupID]float64{"a": 20, "b": 0},
+		},
+		{
+			// Group a was deleted, making room for group c
+			emitted: map[models.GroupID]int64{"b": 5, "c": 5},
+			exp:     map[models.GroupID]float64{"b": 10, "c": 0},
+		},
+	}
+	for i, tc := range testCases {
+		s := stats(tc.emitted)
+		n.addSilence(s, start.Add(time.Duration(i)*10*time.Second))
+		if got := silences(s); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%d: unexpected silences got %v exp %v", i, got, tc.exp)
+		}
+	}
+}
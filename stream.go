@@ -28,6 +28,11 @@ func newStreamNode(et *ExecutingTask, n *pipeline.StreamNode, d NodeDiagnostic)
 
 func (n *StreamNode) runSourceStream([]byte) error {
 	for m, ok := n.ins[0].Emit(); ok; m, ok = n.ins[0].Emit() {
+		if admitted, err := n.et.quota.admitMessage(m); err != nil {
+			return err
+		} else if !admitted {
+			continue
+		}
 		for _, child := range n.outs {
 			err := child.Collect(m)
 			if err != nil {
@@ -60,7 +65,7 @@ func newFromNode(et *ExecutingTask, n *pipeline.FromNode, d NodeDiagnostic) (*Fr
 		name: n.Measurement,
 	}
 	sn.node.runF = sn.runStream
-	sn.allDimensions, sn.tagNames = determineTagNames(n.Dimensions, nil)
+	sn.allDimensions, sn.tagNames = determineTagNames(n.Dimensions, n.ExcludedDimensions)
 
 	if n.Lambda != nil {
 		expr, err := stateful.NewExpression(n.Lambda.Expression)
@@ -106,7 +111,7 @@ func (n *FromNode) Point(p edge.PointMessage) (edge.Message, error) {
 		}
 		p.SetDimensions(models.Dimensions{
 			ByName:   n.s.GroupByMeasurementFlag,
-			TagNames: computeTagNames(p.Tags(), n.allDimensions, n.tagNames, nil),
+			TagNames: computeTagNames(p.Tags(), n.allDimensions, n.tagNames, n.s.ExcludedDimensions),
 		})
 		return p, nil
 	}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
@@ -16,6 +18,12 @@ import (
 type TaskDiagnostic interface {
 	WithNodeContext(node string) NodeDiagnostic
 
+	// SetLogLevel overrides the log level of the task, an empty level removes the override.
+	SetLogLevel(level string) error
+
+	QuotaExceeded(quota string, value, limit int64, enforcement string)
+	QuotaRestored(quota string)
+
 	Error(msg string, err error, ctx ...keyvalue.T)
 }
 
@@ -79,6 +87,48 @@ type Task struct {
 	Type             TaskType
 	DBRPs            []DBRP
 	SnapshotInterval time.Duration
+	// Log level of the task, empty to use the log level of the server.
+	LogLevel string
+	// Log levels of nodes by name, nodes without a level use the level of the task.
+	NodeLogLevels map[string]string
+	// Resource quotas of the task, zero quotas use the default quotas of the task master.
+	Quotas TaskQuotas
+	// Priority of the batch queries of the task when waiting for a free query slot, higher first.
+	// Tasks default to priority 0, negative priorities run after the tasks of the default priority.
+	Priority int64
+}
+
+// ValidateLogLevels checks that the log levels are valid and set only for nodes of the task.
+func (t *Task) ValidateLogLevels() error {
+	if !validLogLevel(t.LogLevel) {
+		return fmt.Errorf("invalid log level %q, must be one of debug, info or error", t.LogLevel)
+	}
+	if len(t.NodeLogLevels) == 0 {
+		return nil
+	}
+	names := make(map[string]bool, t.Pipeline.Len())
+	_ = t.Pipeline.Walk(func(n pipeline.Node) error {
+		names[n.Name()] = true
+		return nil
+	})
+	for name, level := range t.NodeLogLevels {
+		if !names[name] {
+			return fmt.Errorf("cannot set log level of unknown node %q", name)
+		}
+		if !validLogLevel(level) {
+			return fmt.Errorf("invalid log level %q for node %s, must be one of debug, info or error", level, name)
+		}
+	}
+	return nil
+}
+
+func validLogLevel(level string) bool {
+	switch strings.ToLower(level) {
+	case "", "debug", "info", "error":
+		return true
+	default:
+		return false
+	}
 }
 
 func (t *Task) Dot() []byte {
@@ -100,6 +150,40 @@ func (t *Task) Measurements() []string {
 	return measurements
 }
 
+// forkKeys returns the keys of the streams of points that the from nodes of the task can match,
+// so that the task only receives the points of the databases, retention policies and measurements it reads.
+func (t *Task) forkKeys() []forkKey {
+	keys := make([]forkKey, 0)
+
+	_ = t.Pipeline.Walk(func(node pipeline.Node) error {
+		from, ok := node.(*pipeline.FromNode)
+		if !ok {
+			return nil
+		}
+		for _, dbrp := range t.DBRPs {
+			if from.Database != "" && from.Database != dbrp.Database {
+				continue
+			}
+			if from.RetentionPolicy != "" && from.RetentionPolicy != dbrp.RetentionPolicy {
+				continue
+			}
+			keys = append(keys, forkKey{
+				Database:        dbrp.Database,
+				RetentionPolicy: dbrp.RetentionPolicy,
+				Measurement:     from.Measurement,
+			})
+		}
+		return nil
+	})
+
+	if len(keys) == 0 {
+		// None of the from nodes can match the points of the dbrps of the task,
+		// the task still needs a fork for its stream to be closed when it is stopped.
+		return forkKeys(t.DBRPs, t.Measurements())
+	}
+	return uniqueForkKeys(keys)
+}
+
 // ----------------------------------
 // ExecutingTask
 
@@ -115,30 +199,68 @@ type ExecutingTask struct {
 	stopping chan struct{}
 	wg       sync.WaitGroup
 	diag     TaskDiagnostic
+	// node diagnostics by node name
+	nodeDiags map[string]NodeDiagnostic
 
 	// Mutex for throughput var
 	tmu        sync.RWMutex
 	throughput float64
+
+	quota *taskQuota
+
+	// priority of the batch queries of the task, updated while the task is running.
+	priority int64
+
+	// pool of the task, nil if the task is not assigned to an execution pool.
+	pool *executionPool
 }
 
 // Create a new  task from a defined kapacitor.
 func NewExecutingTask(tm *TaskMaster, t *Task) (*ExecutingTask, error) {
 	d := tm.diag.WithTaskContext(t.ID)
 	et := &ExecutingTask{
-		tm:      tm,
-		Task:    t,
-		outputs: make(map[string]Output),
-		lookup:  make(map[pipeline.ID]Node),
-		diag:    d,
+		tm:        tm,
+		Task:      t,
+		outputs:   make(map[string]Output),
+		lookup:    make(map[pipeline.ID]Node),
+		diag:      d,
+		nodeDiags: make(map[string]NodeDiagnostic),
+		priority:  t.Priority,
+		pool:      tm.ExecutionPools.poolOf(t.ID),
+	}
+	if err := t.Quotas.Validate(); err != nil {
+		return nil, err
 	}
 	err := et.link()
 	if err != nil {
 		return nil, err
 	}
+	if err := et.setLogLevels(t.LogLevel, t.NodeLogLevels); err != nil {
+		return nil, err
+	}
 	return et, nil
 }
 
+// setLogLevels overrides the log levels of the task and its nodes.
+// Nodes missing from nodeLevels use the level of the task.
+func (et *ExecutingTask) setLogLevels(level string, nodeLevels map[string]string) error {
+	if err := et.diag.SetLogLevel(level); err != nil {
+		return err
+	}
+	for name, d := range et.nodeDiags {
+		if err := d.SetLogLevel(nodeLevels[name]); err != nil {
+			return fmt.Errorf("node %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
 // walks the entire pipeline applying function f.
+// queryPriority returns the priority of the batch queries of the task.
+func (et *ExecutingTask) queryPriority() int64 {
+	return atomic.LoadInt64(&et.priority)
+}
+
 func (et *ExecutingTask) walk(f func(n Node) error) error {
 	for _, n := range et.nodes {
 		err := f(n)
@@ -166,6 +288,7 @@ func (et *ExecutingTask) link() error {
 	// Walk Pipeline and create equivalent executing nodes
 	err := et.Task.Pipeline.Walk(func(n pipeline.Node) error {
 		d := et.diag.WithNodeContext(n.Name())
+		et.nodeDiags[n.Name()] = d
 		en, err := et.createNode(n, d)
 		if err != nil {
 			return err
@@ -210,6 +333,7 @@ func (et *ExecutingTask) start(ins []edge.StatsEdge, snapshot *TaskSnapshot) err
 		validSnapshot = err == nil
 	}
 
+	et.quota = newTaskQuota(et.Task.ID, et.Task.Quotas.WithDefaults(et.tm.DefaultTaskQuotas), et.diag)
 	err := et.walk(func(n Node) error {
 		if validSnapshot {
 			n.start(snapshot.NodeSnapshots[n.Name()])
@@ -229,6 +353,12 @@ func (et *ExecutingTask) start(ins []edge.StatsEdge, snapshot *TaskSnapshot) err
 	// Start calcThroughput
 	et.wg.Add(1)
 	go et.calcThroughput()
+	// Start monitorQuotas
+	et.wg.Add(1)
+	go et.monitorQuotas()
+	if et.pool != nil {
+		et.pool.tasks.Add(1)
+	}
 	return nil
 }
 
@@ -243,6 +373,10 @@ func (et *ExecutingTask) stop() (err error) {
 		return nil
 	})
 	et.wg.Wait()
+	et.quota.close()
+	if et.pool != nil {
+		et.pool.tasks.Add(-1)
+	}
 	return
 }
 
@@ -274,6 +408,17 @@ func (et *ExecutingTask) BatchCount() (int, error) {
 	return batcher.Count(), nil
 }
 
+// CancelBatchQueries cancels the queries the batcher is currently executing,
+// returning the number of queries that were canceled.
+func (et *ExecutingTask) CancelBatchQueries() (int, error) {
+	if et.Task.Type != BatchTask {
+		return 0, ErrWrongTaskType
+	}
+
+	batcher := et.source.(*BatchNode)
+	return batcher.CancelQueries(), nil
+}
+
 // Get the next `num` batch queries that the batcher will run starting at time `start`.
 func (et *ExecutingTask) BatchQueries(start, stop time.Time) ([]BatchQueries, error) {
 	if et.Task.Type != BatchTask {
@@ -348,6 +493,11 @@ func (et *ExecutingTask) ExecutionStats() (ExecutionStats, error) {
 
 	// Fill the task stats
 	executionStats.TaskStats["throughput"] = et.getThroughput()
+	if et.quota != nil {
+		for k, v := range et.quota.stats() {
+			executionStats.TaskStats[k] = v
+		}
+	}
 
 	// Fill the nodes stats
 	err := et.walk(func(node Node) error {
@@ -443,6 +593,32 @@ func (et *ExecutingTask) calcThroughput() {
 	}
 }
 
+// monitorQuotas periodically checks the cardinality and memory quotas of the task.
+func (et *ExecutingTask) monitorQuotas() {
+	defer et.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			var cardinality, buffered int64
+			_ = et.walk(func(n Node) error {
+				stats := n.stats()
+				if c, ok := stats[statCardinalityGauge].(int64); ok {
+					cardinality += c
+				}
+				if b, ok := stats[statBufferedPoints].(int64); ok {
+					buffered += b
+				}
+				return nil
+			})
+			et.quota.check(cardinality, buffered)
+		case <-et.stopping:
+			return
+		}
+	}
+}
+
 // Create a  node from a given pipeline node.
 func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node, err error) {
 	switch t := p.(type) {
@@ -482,6 +658,8 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newWhereNode(et, t, d)
 	case *pipeline.SampleNode:
 		n, err = newSampleNode(et, t, d)
+	case *pipeline.LimitNode:
+		n, err = newLimitNode(et, t, d)
 	case *pipeline.DerivativeNode:
 		n, err = newDerivativeNode(et, t, d)
 	case *pipeline.ChangeDetectNode:
@@ -504,6 +682,8 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newDeleteNode(et, t, d)
 	case *pipeline.CombineNode:
 		n, err = newCombineNode(et, t, d)
+	case *pipeline.AutoscaleNode:
+		n, err = newWebhookAutoscaleNode(et, t, d)
 	case *pipeline.K8sAutoscaleNode:
 		n, err = newK8sAutoscaleNode(et, t, d)
 	case *pipeline.SwarmAutoscaleNode:
@@ -516,6 +696,22 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newStateCountNode(et, t, d)
 	case *pipeline.SideloadNode:
 		n, err = newSideloadNode(et, t, d)
+	case *pipeline.QueryLookupNode:
+		n, err = newQueryLookupNode(et, t, d)
+	case *pipeline.ReorderNode:
+		n, err = newReorderNode(et, t, d)
+	case *pipeline.RollingNode:
+		n, err = newRollingNode(et, t, d)
+	case *pipeline.TrendSlopeNode:
+		n, err = newTrendSlopeNode(et, t, d)
+	case *pipeline.BaselineNode:
+		n, err = newBaselineNode(et, t, d)
+	case *pipeline.SplitNode:
+		n, err = newSplitNode(et, t, d)
+	case *pipeline.InterpolateNode:
+		n, err = newInterpolateNode(et, t, d)
+	case *pipeline.OutliersNode:
+		n, err = newOutliersNode(et, t, d)
 	case *pipeline.TrickleNode:
 		n = newTrickleNode(et, t, d)
 	case *pipeline.BarrierNode:
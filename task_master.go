@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/influxdb/tsdb"
@@ -45,6 +46,7 @@ import (
 	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
+	"github.com/influxdata/kapacitor/services/zabbix"
 	"github.com/influxdata/kapacitor/services/zenoss"
 	"github.com/influxdata/kapacitor/tick"
 	"github.com/influxdata/kapacitor/tick/stateful"
@@ -87,6 +89,7 @@ type UDFService interface {
 
 var ErrTaskMasterClosed = errors.New("TaskMaster is closed")
 var ErrTaskMasterOpen = errors.New("TaskMaster is open")
+var ErrTaskAlreadyRunning = errors.New("task is already running")
 
 type deleteHook func(*TaskMaster)
 
@@ -224,6 +227,9 @@ type TaskMaster struct {
 		StateChangesOnly() bool
 		Handler(servicenow.HandlerConfig, ...keyvalue.T) alert.Handler
 	}
+	ZabbixService interface {
+		Handler(zabbix.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
+	}
 	ZenossService interface {
 		Global() bool
 		StateChangesOnly() bool
@@ -234,6 +240,25 @@ type TaskMaster struct {
 
 	DefaultRetentionPolicy string
 
+	// BatchQueryLimiter limits the batch queries executing at once across all tasks.
+	BatchQueryLimiter *BatchQueryLimiter
+
+	// DefaultTaskQuotas are the quotas of tasks that do not set their own.
+	DefaultTaskQuotas TaskQuotas
+
+	// ExecutionPools bound the number of nodes of the tasks of each pool processing data at once.
+	ExecutionPools *ExecutionPools
+
+	// GlobalTags are the tags added to all the points written by influxDBOut nodes and to all alert events.
+	GlobalTags map[string]string
+
+	// RestartBufferSize is the number of points of its stream buffered for a stream task while it restarts,
+	// 0 disables the buffering.
+	RestartBufferSize int
+	// RestartBufferMaxAge is the time the points are buffered for a task stopped for a restart,
+	// they are discarded if the task is not started again in time.
+	RestartBufferMaxAge time.Duration
+
 	// Incoming streams
 	writePointsIn StreamCollector
 	writesClosed  bool
@@ -252,6 +277,13 @@ type TaskMaster struct {
 	// we have only the task id, and they are called after the task is deleted from TaskMaster.tasks
 	taskToForkKeys map[string][]forkKey
 
+	// Buffers of the points of the streams of tasks stopped for a restart
+	restartBuffers     map[string]*restartBuffer
+	restartBufferStats *restartBufferStats
+	// Buffers being replayed to the started tasks, the points of their streams are buffered
+	// rather than sent to the edges of the tasks until the buffers are empty.
+	replaying map[string]*restartBuffer
+
 	// Set of incoming batches
 	batches map[string][]BatchCollector
 
@@ -286,6 +318,8 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 		forks:          make(map[forkKey]map[string]edge.Edge),
 		forkStats:      make(map[forkKey]*expvar.Int),
 		taskToForkKeys: make(map[string][]forkKey),
+		restartBuffers: make(map[string]*restartBuffer),
+		replaying:      make(map[string]*restartBuffer),
 		batches:        make(map[string][]BatchCollector),
 		tasks:          make(map[string]*ExecutingTask),
 		deleteHooks:    make(map[string][]deleteHook),
@@ -301,6 +335,12 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 func (tm *TaskMaster) New(id string) *TaskMaster {
 	n := NewTaskMaster(id, tm.ServerInfo, tm.diag)
 	n.DefaultRetentionPolicy = tm.DefaultRetentionPolicy
+	n.BatchQueryLimiter = tm.BatchQueryLimiter
+	n.DefaultTaskQuotas = tm.DefaultTaskQuotas
+	n.ExecutionPools = tm.ExecutionPools
+	n.GlobalTags = tm.GlobalTags
+	n.RestartBufferSize = tm.RestartBufferSize
+	n.RestartBufferMaxAge = tm.RestartBufferMaxAge
 	n.HTTPDService = tm.HTTPDService
 	n.TaskStore = tm.TaskStore
 	n.DeadmanService = tm.DeadmanService
@@ -331,6 +371,7 @@ func (tm *TaskMaster) New(id string) *TaskMaster {
 	n.SideloadService = tm.SideloadService
 	n.TeamsService = tm.TeamsService
 	n.ServiceNowService = tm.ServiceNowService
+	n.ZabbixService = tm.ZabbixService
 	n.ZenossService = tm.ZenossService
 	return n
 }
@@ -523,6 +564,9 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 	if len(t.DBRPs) == 0 {
 		return nil, errors.New("task does contain any dbrps")
 	}
+	if _, ok := tm.tasks[t.ID]; ok {
+		return nil, ErrTaskAlreadyRunning
+	}
 	tm.diag.StartingTask(t.ID)
 	et, err := NewExecutingTask(tm, t)
 	if err != nil {
@@ -532,7 +576,7 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 	var ins []edge.StatsEdge
 	switch et.Task.Type {
 	case StreamTask:
-		e, err := tm.newFork(et.Task.ID, et.Task.DBRPs, et.Task.Measurements())
+		e, err := tm.newFork(et.Task.ID, et.Task.forkKeys())
 		if err != nil {
 			return nil, err
 		}
@@ -564,6 +608,17 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 		return nil, err
 	}
 
+	// Replay the points received while the task was restarting
+	if b, ok := tm.restartBuffers[t.ID]; ok {
+		delete(tm.restartBuffers, t.ID)
+		if et.Task.Type == StreamTask {
+			tm.replaying[t.ID] = b
+			go tm.replayRestartBuffer(t.ID, b, ins[0])
+		} else {
+			b.expire()
+		}
+	}
+
 	tm.tasks[et.Task.ID] = et
 	tm.diag.StartedTask(t.ID)
 	tm.diag.TaskMasterDot(string(t.Dot()))
@@ -581,9 +636,82 @@ func (tm *TaskMaster) StopTask(id string) error {
 	return tm.stopTask(id)
 }
 
+// StopTaskForRestart stops a task that is started again shortly.
+// If the restart buffer is enabled the recent points of the stream of a stream task are buffered while it is stopped,
+// and replayed to the task once it is started, unless it is not started within the max age of the buffer.
+func (tm *TaskMaster) StopTaskForRestart(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if et, ok := tm.tasks[id]; ok && et.Task.Type == StreamTask && tm.RestartBufferSize > 0 {
+		tm.discardRestartBuffer(id)
+		if tm.restartBufferStats == nil {
+			tm.restartBufferStats = newRestartBufferStats(tm.id)
+		}
+		b := newRestartBuffer(tm.taskToForkKeys[id], tm.RestartBufferSize, tm.restartBufferStats)
+		tm.restartBuffers[id] = b
+		if tm.RestartBufferMaxAge > 0 {
+			time.AfterFunc(tm.RestartBufferMaxAge, func() {
+				tm.mu.Lock()
+				defer tm.mu.Unlock()
+				if tm.restartBuffers[id] == b {
+					tm.discardRestartBuffer(id)
+				}
+			})
+		}
+	}
+	return tm.stopTask(id)
+}
+
+// replayRestartBuffer replays the restart buffer of a started task to the edge of its stream.
+// Only the read lock is held while replaying, as when points are written,
+// so that writes, including those the task depends on, are not blocked by the replay.
+// The points received meanwhile are buffered after the replayed points and replayed in turn,
+// once the buffer is empty the points are sent to the edge of the task again.
+func (tm *TaskMaster) replayRestartBuffer(id string, b *restartBuffer, e edge.Edge) {
+	for {
+		tm.mu.RLock()
+		if tm.replaying[id] != b {
+			// The task was stopped, which closed its edge.
+			tm.mu.RUnlock()
+			return
+		}
+		err := b.replay(e)
+		tm.mu.RUnlock()
+
+		tm.mu.Lock()
+		if tm.replaying[id] != b {
+			tm.mu.Unlock()
+			return
+		}
+		// Points are only buffered with the read lock held, so the buffer stays empty.
+		if err != nil || b.empty() {
+			delete(tm.replaying, id)
+			if err != nil {
+				// The edge of the task was aborted
+				b.expire()
+			} else {
+				b.take()
+			}
+			tm.mu.Unlock()
+			return
+		}
+		tm.mu.Unlock()
+	}
+}
+
+// internal discardRestartBuffer function. The caller must have acquired
+// the lock in order to call this function
+func (tm *TaskMaster) discardRestartBuffer(id string) {
+	if b, ok := tm.restartBuffers[id]; ok {
+		delete(tm.restartBuffers, id)
+		b.expire()
+	}
+}
+
 func (tm *TaskMaster) DeleteTask(id string) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	tm.discardRestartBuffer(id)
 	if err := tm.stopTask(id); err != nil {
 		return err
 	}
@@ -594,6 +722,10 @@ func (tm *TaskMaster) DeleteTask(id string) error {
 // internal stopTask function. The caller must have acquired
 // the lock in order to call this function
 func (tm *TaskMaster) stopTask(id string) (err error) {
+	if b, ok := tm.replaying[id]; ok {
+		delete(tm.replaying, id)
+		b.expire()
+	}
 	if et, ok := tm.tasks[id]; ok {
 
 		delete(tm.tasks, id)
@@ -629,6 +761,65 @@ func (tm *TaskMaster) registerDeleteHookForTask(id string, hook deleteHook) {
 	tm.deleteHooks[id] = append(tm.deleteHooks[id], hook)
 }
 
+// SetTaskLogLevels overrides the log levels of an executing task and its nodes.
+// Levels of nodes that are not part of the executing task are ignored.
+func (tm *TaskMaster) SetTaskLogLevels(id, level string, nodeLevels map[string]string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	et, ok := tm.tasks[id]
+	if !ok {
+		return fmt.Errorf("unknown task %s", id)
+	}
+	if err := et.setLogLevels(level, nodeLevels); err != nil {
+		return err
+	}
+	et.Task.LogLevel = level
+	et.Task.NodeLogLevels = nodeLevels
+	return nil
+}
+
+// SetTaskQuotas replaces the quotas of an executing task.
+func (tm *TaskMaster) SetTaskQuotas(id string, quotas TaskQuotas) error {
+	if err := quotas.Validate(); err != nil {
+		return err
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	et, ok := tm.tasks[id]
+	if !ok {
+		return fmt.Errorf("unknown task %s", id)
+	}
+	et.quota.setQuotas(quotas.WithDefaults(tm.DefaultTaskQuotas))
+	et.Task.Quotas = quotas
+	return nil
+}
+
+// SetTaskPriority replaces the batch query priority of an executing task,
+// queries already waiting for a slot keep their priority.
+func (tm *TaskMaster) SetTaskPriority(id string, priority int64) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	et, ok := tm.tasks[id]
+	if !ok {
+		return fmt.Errorf("unknown task %s", id)
+	}
+	atomic.StoreInt64(&et.priority, priority)
+	et.Task.Priority = priority
+	return nil
+}
+
+// CancelBatchQueries cancels the queries the batch task is currently executing,
+// returning the number of queries that were canceled.
+func (tm *TaskMaster) CancelBatchQueries(id string) (int, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	et, ok := tm.tasks[id]
+	if !ok {
+		return 0, fmt.Errorf("unknown task %s", id)
+	}
+	return et.CancelBatchQueries()
+}
+
 func (tm *TaskMaster) IsExecuting(id string) bool {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
@@ -742,12 +933,23 @@ func (tm *TaskMaster) forkPoint(p edge.PointMessage) {
 	}
 
 	// Merge the results to the forks map
-	for _, edge := range tm.forks[key] {
-		_ = edge.Collect(p)
+	for id, edge := range tm.forks[key] {
+		if _, ok := tm.replaying[id]; !ok {
+			_ = edge.Collect(p)
+		}
+	}
+
+	for id, edge := range tm.forks[emptyMeasurementKey] {
+		if _, ok := tm.replaying[id]; !ok {
+			_ = edge.Collect(p)
+		}
 	}
 
-	for _, edge := range tm.forks[emptyMeasurementKey] {
-		_ = edge.Collect(p)
+	for _, b := range tm.restartBuffers {
+		b.collect(key, emptyMeasurementKey, p)
+	}
+	for _, b := range tm.replaying {
+		b.collect(key, emptyMeasurementKey, p)
 	}
 
 	c, ok := tm.forkStats[key]
@@ -825,7 +1027,7 @@ func (tm *TaskMaster) WriteKapacitorPoint(p edge.PointMessage) error {
 func (tm *TaskMaster) NewFork(taskName string, dbrps []DBRP, measurements []string) (edge.StatsEdge, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	return tm.newFork(taskName, dbrps, measurements)
+	return tm.newFork(taskName, forkKeys(dbrps, measurements))
 }
 
 func forkKeys(dbrps []DBRP, measurements []string) []forkKey {
@@ -843,11 +1045,27 @@ func forkKeys(dbrps []DBRP, measurements []string) []forkKey {
 		}
 	}
 
-	return keys
+	return uniqueForkKeys(keys)
+}
+
+// uniqueForkKeys removes the duplicate keys.
+// A key of a measurement is kept along with the key of all measurements of its database and retention policy,
+// as before the routing by from nodes, such a task receives the points of the measurement once per key.
+func uniqueForkKeys(keys []forkKey) []forkKey {
+	seen := make(map[forkKey]bool, len(keys))
+	unique := make([]forkKey, 0, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+	return unique
 }
 
 // internal newFork, must have acquired lock before calling.
-func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []string) (edge.StatsEdge, error) {
+func (tm *TaskMaster) newFork(taskName string, keys []forkKey) (edge.StatsEdge, error) {
 	if tm.closed {
 		return nil, ErrTaskMasterClosed
 	}
@@ -855,7 +1073,7 @@ func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []stri
 	d := tm.diag.WithEdgeContext(taskName, "stream", "stream0")
 	e := newEdge(taskName, "stream", "stream0", pipeline.StreamEdge, defaultEdgeBufferSize, d)
 
-	for _, key := range forkKeys(dbrps, measurements) {
+	for _, key := range keys {
 		tm.taskToForkKeys[taskName] = append(tm.taskToForkKeys[taskName], key)
 
 		// Add the task to the tasksMap if it doesn't exists
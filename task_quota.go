@@ -0,0 +1,285 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+// Enforcements of the quotas of a task.
+const (
+	// QuotaEnforcementThrottle drops the points of the task while a quota is exceeded.
+	// While only the cardinality quota is exceeded, only the points that would create new groups are dropped.
+	QuotaEnforcementThrottle = "throttle"
+	// QuotaEnforcementError stops the task with an error once a quota is exceeded.
+	QuotaEnforcementError = "error"
+)
+
+// Names of the quotas of a task.
+const (
+	QuotaPointsPerSecond = "max-points-per-second"
+	QuotaCardinality     = "max-cardinality"
+	QuotaMemoryBytes     = "max-memory-bytes"
+)
+
+const (
+	statQuotaDroppedPoints = "dropped_points"
+	statQuotaExceeded      = "exceeded"
+	statQuotaCardinality   = "cardinality"
+	statQuotaMemoryBytes   = "memory_estimate_bytes"
+)
+
+// The memory used by a task is estimated from the number of points buffered by its windows
+// and the number of groups of its nodes, using the typical size of each.
+const (
+	estimatedPointBytes = 256
+	estimatedGroupBytes = 1024
+)
+
+// TaskQuotas limit the resources used by a task, so that one task cannot starve the others.
+// A zero limit means no limit.
+type TaskQuotas struct {
+	// MaxPointsPerSecond is the maximum rate of points ingested by the task.
+	// The points of the batches of batch tasks are counted.
+	MaxPointsPerSecond int64 `toml:"max-points-per-second"`
+	// MaxCardinality is the maximum number of groups, summed over the nodes of the task.
+	MaxCardinality int64 `toml:"max-cardinality"`
+	// MaxMemoryBytes is the maximum estimated memory of the task.
+	// The estimate accounts for the points buffered by windows and the groups of the nodes of the task.
+	MaxMemoryBytes int64 `toml:"max-memory-bytes"`
+	// Enforcement is what happens once a quota is exceeded, either throttle or error.
+	// Defaults to throttle.
+	Enforcement string `toml:"enforcement"`
+}
+
+// Validate returns an error if the quotas are invalid.
+func (q TaskQuotas) Validate() error {
+	if q.MaxPointsPerSecond < 0 {
+		return fmt.Errorf("%s must not be negative", QuotaPointsPerSecond)
+	}
+	if q.MaxCardinality < 0 {
+		return fmt.Errorf("%s must not be negative", QuotaCardinality)
+	}
+	if q.MaxMemoryBytes < 0 {
+		return fmt.Errorf("%s must not be negative", QuotaMemoryBytes)
+	}
+	switch q.Enforcement {
+	case "", QuotaEnforcementThrottle, QuotaEnforcementError:
+	default:
+		return fmt.Errorf("invalid quota enforcement %q, must be one of %q or %q", q.Enforcement, QuotaEnforcementThrottle, QuotaEnforcementError)
+	}
+	return nil
+}
+
+// WithDefaults returns the quotas with the zero values replaced by the defaults.
+func (q TaskQuotas) WithDefaults(defaults TaskQuotas) TaskQuotas {
+	if q.MaxPointsPerSecond == 0 {
+		q.MaxPointsPerSecond = defaults.MaxPointsPerSecond
+	}
+	if q.MaxCardinality == 0 {
+		q.MaxCardinality = defaults.MaxCardinality
+	}
+	if q.MaxMemoryBytes == 0 {
+		q.MaxMemoryBytes = defaults.MaxMemoryBytes
+	}
+	if q.Enforcement == "" {
+		q.Enforcement = defaults.Enforcement
+	}
+	return q
+}
+
+// QuotaExceededError is the error of a task stopped because it exceeded one of its quotas.
+type QuotaExceededError struct {
+	Quota string
+	Value int64
+	Limit int64
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf("task exceeded quota %s: %d > %d", e.Quota, e.Value, e.Limit)
+}
+
+// taskQuota enforces the quotas of an executing task.
+// The rate of points is checked as the points are ingested,
+// the cardinality and memory are checked periodically by check.
+type taskQuota struct {
+	diag TaskDiagnostic
+
+	mu     sync.Mutex
+	quotas TaskQuotas
+	// Start and number of points of the current one second window of the rate of points.
+	windowStart  time.Time
+	windowPoints int64
+	// exceeded is the quota currently exceeded, if any, by quota name.
+	exceeded map[string]QuotaExceededError
+
+	statsKey      string
+	droppedPoints *kexpvar.Int
+	exceededCount *kexpvar.Int
+	cardinality   *kexpvar.Int
+	memoryBytes   *kexpvar.Int
+}
+
+func newTaskQuota(task string, quotas TaskQuotas, d TaskDiagnostic) *taskQuota {
+	q := &taskQuota{
+		diag:          d,
+		quotas:        quotas,
+		exceeded:      make(map[string]QuotaExceededError),
+		droppedPoints: &kexpvar.Int{},
+		exceededCount: &kexpvar.Int{},
+		cardinality:   &kexpvar.Int{},
+		memoryBytes:   &kexpvar.Int{},
+	}
+	var statMap *kexpvar.Map
+	q.statsKey, statMap = vars.NewStatistic("task_quotas", map[string]string{
+		"task": task,
+	})
+	statMap.Set(statQuotaDroppedPoints, q.droppedPoints)
+	statMap.Set(statQuotaExceeded, q.exceededCount)
+	statMap.Set(statQuotaCardinality, q.cardinality)
+	statMap.Set(statQuotaMemoryBytes, q.memoryBytes)
+	return q
+}
+
+func (q *taskQuota) close() {
+	vars.DeleteStatistic(q.statsKey)
+}
+
+// stats returns the usage of the task, reported with the stats of the task.
+func (q *taskQuota) stats() map[string]interface{} {
+	q.mu.Lock()
+	exceeded := make([]string, 0, len(q.exceeded))
+	for quota := range q.exceeded {
+		exceeded = append(exceeded, quota)
+	}
+	q.mu.Unlock()
+	sort.Strings(exceeded)
+	return map[string]interface{}{
+		"quota_dropped_points":  q.droppedPoints.IntValue(),
+		"quota_exceeded_count":  q.exceededCount.IntValue(),
+		"quotas_exceeded":       exceeded,
+		"cardinality":           q.cardinality.IntValue(),
+		"memory_estimate_bytes": q.memoryBytes.IntValue(),
+	}
+}
+
+func (q *taskQuota) setQuotas(quotas TaskQuotas) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.quotas = quotas
+	// Quotas that are no longer exceeded are cleared by the next check.
+}
+
+// admitMessage returns whether the message is passed on to the task,
+// or an error if the task must stop.
+func (q *taskQuota) admitMessage(m edge.Message) (bool, error) {
+	switch m := m.(type) {
+	case edge.PointMessage:
+		return q.admit(1, time.Now())
+	case edge.BufferedBatchMessage:
+		return q.admit(int64(len(m.Points())), time.Now())
+	default:
+		return true, nil
+	}
+}
+
+func (q *taskQuota) admit(points int64, now time.Time) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.quotas.Enforcement == QuotaEnforcementError {
+		for _, err := range q.exceeded {
+			return false, err
+		}
+	}
+	if now.Sub(q.windowStart) >= time.Second {
+		q.windowStart = now
+		q.windowPoints = 0
+		if _, ok := q.exceeded[QuotaPointsPerSecond]; ok {
+			delete(q.exceeded, QuotaPointsPerSecond)
+			q.diag.QuotaRestored(QuotaPointsPerSecond)
+		}
+	}
+	q.windowPoints += points
+	if max := q.quotas.MaxPointsPerSecond; max > 0 && q.windowPoints > max {
+		if err := q.exceed(QuotaPointsPerSecond, q.windowPoints, max); err != nil {
+			return false, err
+		}
+	}
+	// The cardinality quota is enforced by refusing new groups, see allowNewGroup.
+	for quota := range q.exceeded {
+		if quota != QuotaCardinality {
+			q.droppedPoints.Add(points)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// allowNewGroup returns whether a node of the task may create a new group for a message of the given number of points.
+// New groups are refused while the cardinality quota is exceeded, the points of existing groups are still admitted.
+func (q *taskQuota) allowNewGroup(points int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.exceeded[QuotaCardinality]; ok {
+		q.droppedPoints.Add(points)
+		return false
+	}
+	return true
+}
+
+// exceed records that the quota is exceeded.
+// It returns an error if the task must stop. The caller must hold the lock.
+func (q *taskQuota) exceed(quota string, value, limit int64) error {
+	err := QuotaExceededError{Quota: quota, Value: value, Limit: limit}
+	if _, ok := q.exceeded[quota]; !ok {
+		q.exceededCount.Add(1)
+		q.diag.QuotaExceeded(quota, value, limit, q.enforcement())
+	}
+	q.exceeded[quota] = err
+	if q.quotas.Enforcement == QuotaEnforcementError {
+		return err
+	}
+	return nil
+}
+
+func (q *taskQuota) enforcement() string {
+	if q.quotas.Enforcement == "" {
+		return QuotaEnforcementThrottle
+	}
+	return q.quotas.Enforcement
+}
+
+// check updates the usage of the task and whether the cardinality and memory quotas are exceeded.
+func (q *taskQuota) check(cardinality, bufferedPoints int64) {
+	memory := bufferedPoints*estimatedPointBytes + cardinality*estimatedGroupBytes
+	q.cardinality.Set(cardinality)
+	q.memoryBytes.Set(memory)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, c := range []struct {
+		quota string
+		value int64
+		limit int64
+	}{
+		{quota: QuotaCardinality, value: cardinality, limit: q.quotas.MaxCardinality},
+		{quota: QuotaMemoryBytes, value: memory, limit: q.quotas.MaxMemoryBytes},
+	} {
+		if c.limit > 0 && c.value > c.limit {
+			q.exceed(c.quota, c.value, c.limit)
+		} else if _, ok := q.exceeded[c.quota]; ok {
+			delete(q.exceeded, c.quota)
+			q.diag.QuotaRestored(c.quota)
+		}
+	}
+	// The rate of points is also restored when no points are ingested.
+	if _, ok := q.exceeded[QuotaPointsPerSecond]; ok && time.Since(q.windowStart) >= time.Second {
+		delete(q.exceeded, QuotaPointsPerSecond)
+		q.diag.QuotaRestored(QuotaPointsPerSecond)
+	}
+}
@@ -0,0 +1,130 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/keyvalue"
+)
+
+type quotaDiagnostic struct {
+	exceeded []string
+	restored []string
+}
+
+func (d *quotaDiagnostic) WithNodeContext(node string) NodeDiagnostic { return nil }
+func (d *quotaDiagnostic) SetLogLevel(level string) error             { return nil }
+func (d *quotaDiagnostic) Error(msg string, err error, ctx ...keyvalue.T) {
+}
+func (d *quotaDiagnostic) QuotaExceeded(quota string, value, limit int64, enforcement string) {
+	d.exceeded = append(d.exceeded, quota)
+}
+func (d *quotaDiagnostic) QuotaRestored(quota string) {
+	d.restored = append(d.restored, quota)
+}
+
+func TestTaskQuota_PointsPerSecondThrottle(t *testing.T) {
+	d := &quotaDiagnostic{}
+	q := newTaskQuota("TestTaskQuota_PointsPerSecondThrottle", TaskQuotas{MaxPointsPerSecond: 2}, d)
+	defer q.close()
+
+	now := time.Unix(0, 0)
+	for i, exp := range []bool{true, true, false, false} {
+		admitted, err := q.admit(1, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if admitted != exp {
+			t.Errorf("unexpected admitted for point %d got %t exp %t", i, admitted, exp)
+		}
+	}
+	if got, exp := q.droppedPoints.IntValue(), int64(2); got != exp {
+		t.Errorf("unexpected dropped points got %d exp %d", got, exp)
+	}
+	if got, exp := q.exceededCount.IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected exceeded count got %d exp %d", got, exp)
+	}
+
+	// The quota is restored in the next second.
+	admitted, err := q.admit(1, now.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !admitted {
+		t.Error("expected point to be admitted once the quota is restored")
+	}
+	if len(d.exceeded) != 1 || len(d.restored) != 1 {
+		t.Errorf("unexpected events exceeded %v restored %v", d.exceeded, d.restored)
+	}
+}
+
+func TestTaskQuota_PointsPerSecondError(t *testing.T) {
+	d := &quotaDiagnostic{}
+	q := newTaskQuota("TestTaskQuota_PointsPerSecondError", TaskQuotas{MaxPointsPerSecond: 2, Enforcement: QuotaEnforcementError}, d)
+	defer q.close()
+
+	now := time.Unix(0, 0)
+	if _, err := q.admit(2, now); err != nil {
+		t.Fatal(err)
+	}
+	_, err := q.admit(1, now)
+	exp := QuotaExceededError{Quota: QuotaPointsPerSecond, Value: 3, Limit: 2}
+	if err != exp {
+		t.Fatalf("unexpected error got %v exp %v", err, exp)
+	}
+}
+
+func TestTaskQuota_Check(t *testing.T) {
+	d := &quotaDiagnostic{}
+	q := newTaskQuota("TestTaskQuota_Check", TaskQuotas{MaxCardinality: 10, MaxMemoryBytes: 100 * estimatedPointBytes}, d)
+	defer q.close()
+
+	q.check(5, 10)
+	if admitted, _ := q.admit(1, time.Now()); !admitted {
+		t.Error("expected point to be admitted within the quotas")
+	}
+	if got, exp := q.memoryBytes.IntValue(), int64(10*estimatedPointBytes+5*estimatedGroupBytes); got != exp {
+		t.Errorf("unexpected memory estimate got %d exp %d", got, exp)
+	}
+
+	q.check(11, 200)
+	if admitted, _ := q.admit(1, time.Now()); admitted {
+		t.Error("expected point to be dropped while the quotas are exceeded")
+	}
+	if got, exp := d.exceeded, []string{QuotaCardinality, QuotaMemoryBytes}; len(got) != len(exp) || got[0] != exp[0] || got[1] != exp[1] {
+		t.Errorf("unexpected exceeded quotas got %v exp %v", got, exp)
+	}
+
+	// The quotas are no longer exceeded once they are raised.
+	q.setQuotas(TaskQuotas{})
+	q.check(11, 200)
+	if admitted, _ := q.admit(1, time.Now()); !admitted {
+		t.Error("expected point to be admitted once the quotas are removed")
+	}
+	if got, exp := len(d.restored), 2; got != exp {
+		t.Errorf("unexpected restored quotas got %d exp %d", got, exp)
+	}
+}
+
+func TestTaskQuota_CardinalityThrottle(t *testing.T) {
+	d := &quotaDiagnostic{}
+	q := newTaskQuota("TestTaskQuota_CardinalityThrottle", TaskQuotas{MaxCardinality: 10}, d)
+	defer q.close()
+
+	q.check(11, 0)
+	// The points of existing groups are still admitted, only new groups are refused.
+	if admitted, _ := q.admit(1, time.Now()); !admitted {
+		t.Error("expected point to be admitted while only the cardinality quota is exceeded")
+	}
+	if q.allowNewGroup(3) {
+		t.Error("expected new group to be refused while the cardinality quota is exceeded")
+	}
+	if got, exp := q.droppedPoints.IntValue(), int64(3); got != exp {
+		t.Errorf("unexpected dropped points got %d exp %d", got, exp)
+	}
+
+	q.check(10, 0)
+	if !q.allowNewGroup(1) {
+		t.Error("expected new group to be allowed once the cardinality quota is restored")
+	}
+}
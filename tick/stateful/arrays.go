@@ -0,0 +1,203 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Arrays are float64 slices, used to carry multi-value results between the nodes of a task.
+// InfluxDB fields are scalar, so arrays cannot be written to InfluxDB.
+
+// array constructs an array from numeric values, for example array("p50", "p90", "p99").
+// Ints are converted to floats.
+type array struct {
+}
+
+func (array) Reset() {}
+
+func (array) Call(args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("array expects at least one argument")
+	}
+	a := make([]float64, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case float64:
+			a[i] = v
+		case int64:
+			a[i] = float64(v)
+		default:
+			return nil, fmt.Errorf("cannot add %T to an array, must be float or int", arg)
+		}
+	}
+	return a, nil
+}
+
+var arrayFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Array Function Signature,
+// every combination of floats and ints of one to maxArgs arguments.
+func init() {
+	var add func(d Domain, n int)
+	add = func(d Domain, n int) {
+		for _, t := range []ast.ValueType{ast.TFloat, ast.TInt} {
+			d[n] = t
+			arrayFuncSignature[d] = ast.TArray
+			if n+1 < maxArgs {
+				add(d, n+1)
+			}
+		}
+	}
+	add(Domain{}, 0)
+}
+
+func (array) Signature() map[Domain]ast.ValueType {
+	return arrayFuncSignature
+}
+
+// arg0Array returns the array of the first argument of the function.
+func arg0Array(name string, args []interface{}, count int) ([]float64, error) {
+	if len(args) != count {
+		if count == 1 {
+			return nil, fmt.Errorf("%s expects exactly one argument", name)
+		}
+		return nil, fmt.Errorf("%s expects exactly %d arguments", name, count)
+	}
+	a, ok := args[0].([]float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to %s, must be array", args[0], name)
+	}
+	return a, nil
+}
+
+// arrayIndex returns the element of an array at an index, for example arrayIndex("quantiles", 1).
+// Negative indexes count from the end of the array, -1 is the last element.
+type arrayIndex struct {
+}
+
+func (arrayIndex) Reset() {}
+
+func (arrayIndex) Call(args ...interface{}) (interface{}, error) {
+	a, err := arg0Array("arrayIndex", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to arrayIndex, must be int", args[1])
+	}
+	if i < 0 {
+		i += int64(len(a))
+	}
+	if i < 0 || i >= int64(len(a)) {
+		return nil, fmt.Errorf("index %d out of range of array of length %d", args[1], len(a))
+	}
+	return a[i], nil
+}
+
+var arrayIndexFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Array Index Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TArray
+	d[1] = ast.TInt
+	arrayIndexFuncSignature[d] = ast.TFloat
+}
+
+func (arrayIndex) Signature() map[Domain]ast.ValueType {
+	return arrayIndexFuncSignature
+}
+
+// arrayLen returns the number of elements of an array.
+type arrayLen struct {
+}
+
+func (arrayLen) Reset() {}
+
+func (arrayLen) Call(args ...interface{}) (interface{}, error) {
+	a, err := arg0Array("arrayLen", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(a)), nil
+}
+
+var arrayLenFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Array Length Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TArray
+	arrayLenFuncSignature[d] = ast.TInt
+}
+
+func (arrayLen) Signature() map[Domain]ast.ValueType {
+	return arrayLenFuncSignature
+}
+
+// arrayAggregate aggregates the elements of an array into a float, such as their sum or max.
+// The aggregates other than the sum are undefined for empty arrays and return an error.
+type arrayAggregate struct {
+	name      string
+	aggregate func([]float64) float64
+	// allowEmpty is whether the aggregate of an empty array is defined.
+	allowEmpty bool
+}
+
+func (arrayAggregate) Reset() {}
+
+func (f arrayAggregate) Call(args ...interface{}) (interface{}, error) {
+	a, err := arg0Array(f.name, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(a) == 0 && !f.allowEmpty {
+		return nil, fmt.Errorf("cannot compute %s of an empty array", f.name)
+	}
+	return f.aggregate(a), nil
+}
+
+var arrayAggregateFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Array Aggregate Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TArray
+	arrayAggregateFuncSignature[d] = ast.TFloat
+}
+
+func (arrayAggregate) Signature() map[Domain]ast.ValueType {
+	return arrayAggregateFuncSignature
+}
+
+func arraySum(a []float64) float64 {
+	var sum float64
+	for _, v := range a {
+		sum += v
+	}
+	return sum
+}
+
+func arrayMean(a []float64) float64 {
+	return arraySum(a) / float64(len(a))
+}
+
+func arrayMax(a []float64) float64 {
+	max := math.Inf(-1)
+	for _, v := range a {
+		max = math.Max(max, v)
+	}
+	return max
+}
+
+func arrayMin(a []float64) float64 {
+	min := math.Inf(+1)
+	for _, v := range a {
+		min = math.Min(min, v)
+	}
+	return min
+}
@@ -0,0 +1,48 @@
+package stateful
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Array(t *testing.T) {
+	funcs := NewFunctions()
+	quantiles := []float64{1.5, 4, 2.5}
+	testCases := []struct {
+		f    string
+		args []interface{}
+		exp  interface{}
+		err  string
+	}{
+		{f: "array", args: []interface{}{1.5, int64(4), 2.5}, exp: quantiles},
+		{f: "arrayIndex", args: []interface{}{quantiles, int64(1)}, exp: 4.0},
+		{f: "arrayIndex", args: []interface{}{quantiles, int64(-1)}, exp: 2.5},
+		{f: "arrayIndex", args: []interface{}{quantiles, int64(3)}, err: "index 3 out of range of array of length 3"},
+		{f: "arrayIndex", args: []interface{}{quantiles, int64(-4)}, err: "index -4 out of range of array of length 3"},
+		{f: "arrayLen", args: []interface{}{quantiles}, exp: int64(3)},
+		{f: "arrayLen", args: []interface{}{[]float64{}}, exp: int64(0)},
+		{f: "arraySum", args: []interface{}{quantiles}, exp: 8.0},
+		{f: "arraySum", args: []interface{}{[]float64{}}, exp: 0.0},
+		{f: "arrayMean", args: []interface{}{quantiles}, exp: 8.0 / 3},
+		{f: "arrayMean", args: []interface{}{[]float64{}}, err: "cannot compute arrayMean of an empty array"},
+		{f: "arrayMax", args: []interface{}{quantiles}, exp: 4.0},
+		{f: "arrayMin", args: []interface{}{quantiles}, exp: 1.5},
+		{f: "arrayMax", args: []interface{}{1.0}, err: "cannot pass float64 as first arg to arrayMax, must be array"},
+	}
+	for i, tc := range testCases {
+		result, err := funcs[tc.f].Call(tc.args...)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%d: unexpected error from %s got %v exp %q", i, tc.f, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(result, tc.exp) {
+			t.Errorf("%d: unexpected result from %s(%v) got %v exp %v", i, tc.f, tc.args, result, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// holidayDateLayout is the layout of the dates of holiday sets.
+const holidayDateLayout = "2006-01-02"
+
+// maxCachedHolidaySets bounds the number of parsed holiday sets kept,
+// the cache is emptied once it is full.
+const maxCachedHolidaySets = 64
+
+var (
+	// locations are the loaded time zones by name, loading a zone reads the zoneinfo database.
+	locations sync.Map
+
+	holidaySetsMu sync.Mutex
+	// holidaySets are the parsed holiday sets by their string.
+	holidaySets = make(map[string]map[string]bool)
+)
+
+// loadLocation returns the time zone of the IANA name, UTC for an empty name.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// parseClock returns the seconds from midnight of a time of day of the form HH:MM, up to 24:00.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time of day %q, must be of the form HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time of day %q, must be between 00:00 and 24:00", s)
+	}
+	return (h*60 + m) * 60, nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	d, ok := weekdayNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("invalid day %q, must be one of Mon, Tue, Wed, Thu, Fri, Sat or Sun", s)
+	}
+	return d, nil
+}
+
+// parseDays returns the days of a comma separated list of days and ranges of days, for example Mon-Fri or Mon,Wed-Fri.
+// Ranges may wrap around the end of the week, Fri-Mon is Friday to Monday.
+func parseDays(s string) (days [7]bool, err error) {
+	if strings.TrimSpace(s) == "" {
+		return days, errors.New("must specify the days of business hours")
+	}
+	for _, item := range strings.Split(s, ",") {
+		first, last := item, item
+		if i := strings.Index(item, "-"); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+		from, err := parseWeekday(first)
+		if err != nil {
+			return days, err
+		}
+		to, err := parseWeekday(last)
+		if err != nil {
+			return days, err
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// businessHours are the hours from start to end, in seconds from midnight, of the days of the week.
+// An end before the start spans midnight, the hours belong to the day they start.
+type businessHours struct {
+	start, end int
+	days       [7]bool
+}
+
+func parseBusinessHours(start, end, days string) (businessHours, error) {
+	var b businessHours
+	var err error
+	if b.start, err = parseClock(start); err != nil {
+		return b, err
+	}
+	if b.end, err = parseClock(end); err != nil {
+		return b, err
+	}
+	if b.start == b.end {
+		return b, fmt.Errorf("business hours from %s to %s are empty", start, end)
+	}
+	b.days, err = parseDays(days)
+	return b, err
+}
+
+// contains reports whether the time, in the time zone of the business hours, is within the business hours.
+func (b businessHours) contains(t time.Time) bool {
+	h, m, s := t.Clock()
+	clock := (h*60+m)*60 + s
+	day := t.Weekday()
+	if b.start < b.end {
+		return b.days[day] && clock >= b.start && clock < b.end
+	}
+	// The hours span midnight, after midnight they belong to the previous day.
+	return (b.days[day] && clock >= b.start) || (b.days[(day+6)%7] && clock < b.end)
+}
+
+// isBusinessHours returns whether a time is within business hours,
+// for example isBusinessHours("time", 'Europe/Paris', '09:00', '18:00', 'Mon-Fri').
+type isBusinessHours struct{}
+
+func (isBusinessHours) Reset() {}
+
+func (isBusinessHours) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 5 {
+		return nil, errors.New("isBusinessHours expects exactly five arguments")
+	}
+	t, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to isBusinessHours, must be time", args[0])
+	}
+	strs := make([]string, 4)
+	for i, arg := range args[1:] {
+		if strs[i], ok = arg.(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as arg %d to isBusinessHours, must be string", arg, i+2)
+		}
+	}
+	loc, err := loadLocation(strs[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseBusinessHours(strs[1], strs[2], strs[3])
+	if err != nil {
+		return nil, err
+	}
+	return b.contains(t.In(loc)), nil
+}
+
+// ValidateArgs checks the time zone, the times of day and the days which are string literals.
+func (isBusinessHours) ValidateArgs(args []ast.Node) error {
+	if len(args) != 5 {
+		return errors.New("isBusinessHours expects exactly five arguments")
+	}
+	if s, ok := args[1].(*ast.StringNode); ok {
+		if _, err := loadLocation(s.Literal); err != nil {
+			return err
+		}
+	}
+	for _, arg := range args[2:4] {
+		if s, ok := arg.(*ast.StringNode); ok {
+			if _, err := parseClock(s.Literal); err != nil {
+				return err
+			}
+		}
+	}
+	if s, ok := args[4].(*ast.StringNode); ok {
+		if _, err := parseDays(s.Literal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var isBusinessHoursFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	d := Domain{}
+	d[0] = ast.TTime
+	d[1] = ast.TString
+	d[2] = ast.TString
+	d[3] = ast.TString
+	d[4] = ast.TString
+	isBusinessHoursFuncSignature[d] = ast.TBool
+}
+
+func (isBusinessHours) Signature() map[Domain]ast.ValueType {
+	return isBusinessHoursFuncSignature
+}
+
+// parseHolidaySet returns the dates of a holiday set, dates of the form YYYY-MM-DD separated by commas or white space.
+func parseHolidaySet(s string) (map[string]bool, error) {
+	holidaySetsMu.Lock()
+	set, ok := holidaySets[s]
+	holidaySetsMu.Unlock()
+	if ok {
+		return set, nil
+	}
+	dates := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	set = make(map[string]bool, len(dates))
+	for _, date := range dates {
+		if _, err := time.Parse(holidayDateLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q, must be a date of the form YYYY-MM-DD", date)
+		}
+		set[date] = true
+	}
+	holidaySetsMu.Lock()
+	defer holidaySetsMu.Unlock()
+	if len(holidaySets) >= maxCachedHolidaySets {
+		holidaySets = make(map[string]map[string]bool)
+	}
+	holidaySets[s] = set
+	return set, nil
+}
+
+// isHoliday returns whether the date of a time or a date of the form YYYY-MM-DD is in a holiday set,
+// for example isHoliday("time", "holidays", 'Europe/Paris').
+// The date of a time is its date in the time zone, UTC by default.
+type isHoliday struct{}
+
+func (isHoliday) Reset() {}
+
+func (isHoliday) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errors.New("isHoliday expects two or three arguments")
+	}
+	holidays, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to isHoliday, must be string", args[1])
+	}
+	var tz string
+	if len(args) == 3 {
+		if tz, ok = args[2].(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as third arg to isHoliday, must be string", args[2])
+		}
+	}
+	var date string
+	switch a := args[0].(type) {
+	case time.Time:
+		loc, err := loadLocation(tz)
+		if err != nil {
+			return nil, err
+		}
+		date = a.In(loc).Format(holidayDateLayout)
+	case string:
+		if _, err := time.Parse(holidayDateLayout, a); err != nil {
+			return nil, fmt.Errorf("invalid date %q, must be of the form YYYY-MM-DD", a)
+		}
+		date = a
+	default:
+		return nil, fmt.Errorf("cannot pass %T as first arg to isHoliday, must be time or string", args[0])
+	}
+	set, err := parseHolidaySet(holidays)
+	if err != nil {
+		return nil, err
+	}
+	return set[date], nil
+}
+
+// ValidateArgs checks the holiday set and the time zone which are string literals.
+func (isHoliday) ValidateArgs(args []ast.Node) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("isHoliday expects two or three arguments")
+	}
+	if s, ok := args[1].(*ast.StringNode); ok {
+		if _, err := parseHolidaySet(s.Literal); err != nil {
+			return err
+		}
+	}
+	if len(args) == 3 {
+		if s, ok := args[2].(*ast.StringNode); ok {
+			if _, err := loadLocation(s.Literal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var isHolidayFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	for _, t := range []ast.ValueType{ast.TTime, ast.TString} {
+		d := Domain{}
+		d[0] = t
+		d[1] = ast.TString
+		isHolidayFuncSignature[d] = ast.TBool
+		d[2] = ast.TString
+		isHolidayFuncSignature[d] = ast.TBool
+	}
+}
+
+func (isHoliday) Signature() map[Domain]ast.ValueType {
+	return isHolidayFuncSignature
+}
@@ -0,0 +1,141 @@
+package stateful
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_IsBusinessHours(t *testing.T) {
+	f := NewFunctions()["isBusinessHours"]
+	// Monday 2026-10-12 at 07:30 UTC is 09:30 in Paris.
+	monday := time.Date(2026, 10, 12, 7, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		t          time.Time
+		tz         string
+		start, end string
+		days       string
+		exp        bool
+	}{
+		{t: monday, tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Mon-Fri", exp: true},
+		{t: monday, tz: "UTC", start: "09:00", end: "18:00", days: "Mon-Fri", exp: false},
+		{t: monday, tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Tue-Fri", exp: false},
+		{t: monday, tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Sat,Sun,Mon", exp: true},
+		{t: monday, tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Fri-Mon", exp: true},
+		// The end is excluded.
+		{t: monday.Add(8*time.Hour + 30*time.Minute), tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Mon-Fri", exp: false},
+		{t: monday.Add(8*time.Hour + 29*time.Minute), tz: "Europe/Paris", start: "09:00", end: "18:00", days: "Mon-Fri", exp: true},
+		// Overnight hours belong to the day they start.
+		{t: monday, tz: "UTC", start: "22:00", end: "08:00", days: "Sun", exp: true},
+		{t: monday, tz: "UTC", start: "22:00", end: "08:00", days: "Mon", exp: false},
+		{t: monday.Add(15 * time.Hour), tz: "UTC", start: "22:00", end: "08:00", days: "Mon", exp: true},
+		{t: monday, tz: "UTC", start: "00:00", end: "24:00", days: "mon", exp: true},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.t, tc.tz, tc.start, tc.end, tc.days)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(bool); got != tc.exp {
+			t.Errorf("%d: unexpected result from isBusinessHours(%v, %s, %s, %s, %s) got %t exp %t", i, tc.t, tc.tz, tc.start, tc.end, tc.days, got, tc.exp)
+		}
+	}
+}
+
+func Test_IsHoliday(t *testing.T) {
+	f := NewFunctions()["isHoliday"]
+	// 2026-12-24 at 23:30 UTC is already Christmas in Paris.
+	christmasEve := time.Date(2026, 12, 24, 23, 30, 0, 0, time.UTC)
+	holidays := "2026-12-25, 2027-01-01\n2026-05-01"
+	testCases := []struct {
+		args []interface{}
+		exp  bool
+	}{
+		{args: []interface{}{christmasEve, holidays}, exp: false},
+		{args: []interface{}{christmasEve, holidays, "Europe/Paris"}, exp: true},
+		{args: []interface{}{"2026-05-01", holidays}, exp: true},
+		{args: []interface{}{"2026-05-02", holidays}, exp: false},
+		{args: []interface{}{"2026-05-01", ""}, exp: false},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.args...)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(bool); got != tc.exp {
+			t.Errorf("%d: unexpected result from isHoliday(%v) got %t exp %t", i, tc.args, got, tc.exp)
+		}
+	}
+
+	if _, err := f.Call("2026-05-01", "2026-13-01"); err == nil || !strings.Contains(err.Error(), "invalid holiday") {
+		t.Errorf("unexpected error for an invalid holiday set %v", err)
+	}
+}
+
+func Test_Calendar_ValidateArgs(t *testing.T) {
+	tm := &ast.ReferenceNode{Reference: "time"}
+	str := func(s string) ast.Node { return &ast.StringNode{Literal: s} }
+	testCases := []struct {
+		f    string
+		args []ast.Node
+		err  string
+	}{
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, str("America/New_York"), str("09:00"), str("17:30"), str("Mon-Fri")},
+		},
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, str("Mars/Olympus"), str("09:00"), str("17:30"), str("Mon-Fri")},
+			err:  `unknown time zone "Mars/Olympus"`,
+		},
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, str("UTC"), str("9am"), str("17:30"), str("Mon-Fri")},
+			err:  `invalid time of day "9am"`,
+		},
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, str("UTC"), str("09:00"), str("24:30"), str("Mon-Fri")},
+			err:  `invalid time of day "24:30"`,
+		},
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, str("UTC"), str("09:00"), str("17:00"), str("Monday-Fri")},
+			err:  `invalid day "Monday"`,
+		},
+		{
+			f:    "isBusinessHours",
+			args: []ast.Node{tm, &ast.ReferenceNode{Reference: "tz"}, str("09:00"), str("17:00"), str("Mon")},
+		},
+		{
+			f:    "isHoliday",
+			args: []ast.Node{tm, str("2026-12-25,2027-01-01"), str("Europe/Paris")},
+		},
+		{
+			f:    "isHoliday",
+			args: []ast.Node{tm, str("2026-12-25,Christmas")},
+			err:  `invalid holiday "Christmas"`,
+		},
+		{
+			f:    "isHoliday",
+			args: []ast.Node{tm, &ast.ReferenceNode{Reference: "holidays"}, str("Nowhere")},
+			err:  `unknown time zone "Nowhere"`,
+		},
+	}
+	funcs := NewFunctions()
+	for i, tc := range testCases {
+		err := funcs[tc.f].(ArgsValidator).ValidateArgs(tc.args)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %v exp %q", i, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MaxCountTrueSize is the largest number of previous conditions retained by the countTrue function,
+// which bounds its memory to a byte per condition per group.
+const MaxCountTrueSize = 10000
+
+// boolToInt converts a boolean to 1 if true and 0 if false.
+type boolToInt struct{}
+
+func (boolToInt) Reset() {}
+
+func (boolToInt) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("boolToInt expects exactly one argument")
+	}
+	b, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T to boolToInt, must be bool", args[0])
+	}
+	if b {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+var boolToIntFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	d := Domain{}
+	d[0] = ast.TBool
+	boolToIntFuncSignature[d] = ast.TInt
+}
+
+func (boolToInt) Signature() map[Domain]ast.ValueType {
+	return boolToIntFuncSignature
+}
+
+// intToBool converts a number to true if it is not zero and false if it is zero.
+// Unlike bool, which only converts 0 and 1, any number converts, NaN is an error.
+type intToBool struct{}
+
+func (intToBool) Reset() {}
+
+func (intToBool) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("intToBool expects exactly one argument")
+	}
+	switch a := args[0].(type) {
+	case int64:
+		return a != 0, nil
+	case float64:
+		if math.IsNaN(a) {
+			return nil, errors.New("cannot convert NaN to boolean")
+		}
+		return a != 0, nil
+	default:
+		return nil, fmt.Errorf("cannot pass %T to intToBool, must be int or float", args[0])
+	}
+}
+
+var intToBoolFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	d := Domain{}
+	for _, t := range []ast.ValueType{ast.TInt, ast.TFloat} {
+		d[0] = t
+		intToBoolFuncSignature[d] = ast.TBool
+	}
+}
+
+func (intToBool) Signature() map[Domain]ast.ValueType {
+	return intToBoolFuncSignature
+}
+
+// countTrue counts the true conditions among the last size points of the group, including the point,
+// for example countTrue("failed", 10) > 3 is true when more than 3 of the last 10 checks failed.
+// Until the group has size points the count is of all of its points.
+type countTrue struct {
+	// conditions is a ring buffer of the previous conditions, next is the index of the oldest condition once it is full.
+	conditions []bool
+	next       int
+	count      int64
+}
+
+func (c *countTrue) Reset() {
+	c.conditions = c.conditions[:0]
+	c.next = 0
+	c.count = 0
+}
+
+func (c *countTrue) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("countTrue expects exactly two arguments")
+	}
+	cond, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to countTrue, must be bool", args[0])
+	}
+	size, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to countTrue, must be int", args[1])
+	}
+	if err := validateCountTrueSize(size); err != nil {
+		return nil, err
+	}
+
+	if len(c.conditions) < int(size) {
+		c.conditions = append(c.conditions, cond)
+	} else {
+		if c.conditions[c.next] {
+			c.count--
+		}
+		c.conditions[c.next] = cond
+		c.next = (c.next + 1) % int(size)
+	}
+	if cond {
+		c.count++
+	}
+	return c.count, nil
+}
+
+func validateCountTrueSize(size int64) error {
+	if size < 1 || size > MaxCountTrueSize {
+		return fmt.Errorf("countTrue size must be between 1 and %d, got %d", MaxCountTrueSize, size)
+	}
+	return nil
+}
+
+// ValidateArgs checks that the size is an int literal within the bounds, so that the memory of the function is bounded.
+func (c *countTrue) ValidateArgs(args []ast.Node) error {
+	if len(args) != 2 {
+		return errors.New("countTrue expects exactly two arguments")
+	}
+	n, ok := args[1].(*ast.NumberNode)
+	if !ok || !n.IsInt {
+		return errors.New("the size of countTrue must be an int literal")
+	}
+	return validateCountTrueSize(n.Int64)
+}
+
+var countTrueFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	d := Domain{}
+	d[0] = ast.TBool
+	d[1] = ast.TInt
+	countTrueFuncSignature[d] = ast.TInt
+}
+
+func (c *countTrue) Signature() map[Domain]ast.ValueType {
+	return countTrueFuncSignature
+}
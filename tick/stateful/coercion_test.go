@@ -0,0 +1,122 @@
+package stateful
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_BoolToInt(t *testing.T) {
+	f := NewFunctions()["boolToInt"]
+	for b, exp := range map[bool]int64{true: 1, false: 0} {
+		result, err := f.Call(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != exp {
+			t.Errorf("unexpected result from boolToInt(%t) got %v exp %d", b, result, exp)
+		}
+	}
+	if _, err := f.Call(int64(1)); err == nil {
+		t.Error("expected error passing an int to boolToInt")
+	}
+}
+
+func Test_IntToBool(t *testing.T) {
+	f := NewFunctions()["intToBool"]
+	testCases := []struct {
+		value interface{}
+		exp   bool
+	}{
+		{value: int64(0), exp: false},
+		{value: int64(1), exp: true},
+		{value: int64(-3), exp: true},
+		{value: 0.0, exp: false},
+		{value: math.Copysign(0, -1), exp: false},
+		{value: 0.5, exp: true},
+		{value: math.Inf(1), exp: true},
+	}
+	for _, tc := range testCases {
+		result, err := f.Call(tc.value)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", tc.value, err)
+		}
+		if result != tc.exp {
+			t.Errorf("unexpected result from intToBool(%v) got %v exp %t", tc.value, result, tc.exp)
+		}
+	}
+	if _, err := f.Call(math.NaN()); err == nil {
+		t.Error("expected error converting NaN")
+	}
+	if _, err := f.Call("1"); err == nil {
+		t.Error("expected error passing a string to intToBool")
+	}
+}
+
+func Test_CountTrue(t *testing.T) {
+	f := NewFunctions()["countTrue"]
+	testCases := []struct {
+		cond bool
+		exp  int64
+	}{
+		{cond: true, exp: 1},
+		{cond: false, exp: 1},
+		{cond: true, exp: 2},
+		// The first condition leaves the window of 3.
+		{cond: true, exp: 2},
+		{cond: false, exp: 2},
+		{cond: false, exp: 1},
+		{cond: false, exp: 0},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.cond, int64(3))
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(int64); got != tc.exp {
+			t.Errorf("%d: unexpected result from countTrue(%t, 3) got %d exp %d", i, tc.cond, got, tc.exp)
+		}
+	}
+
+	f.Reset()
+	result, err := f.Call(false, int64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != int64(0) {
+		t.Errorf("unexpected result after reset got %v exp 0", result)
+	}
+
+	if _, err := f.Call(int64(1), int64(3)); err == nil {
+		t.Error("expected error passing an int as the condition")
+	}
+}
+
+func Test_CountTrue_ValidateArgs(t *testing.T) {
+	cond := &ast.ReferenceNode{Reference: "failed"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{args: []ast.Node{cond, &ast.NumberNode{IsInt: true, Int64: 10}}},
+		{args: []ast.Node{cond, &ast.NumberNode{IsInt: true, Int64: 0}}, err: "countTrue size must be between 1 and 10000"},
+		{args: []ast.Node{cond, &ast.NumberNode{IsInt: true, Int64: MaxCountTrueSize + 1}}, err: "countTrue size must be between 1 and 10000"},
+		{args: []ast.Node{cond, &ast.ReferenceNode{Reference: "size"}}, err: "must be an int literal"},
+		{args: []ast.Node{cond}, err: "exactly two arguments"},
+	}
+	f := NewFunctions()["countTrue"].(ArgsValidator)
+	for i, tc := range testCases {
+		err := f.ValidateArgs(tc.args)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %v exp %q", i, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// debounce holds the last stable value of the group until the value has differed from it for count consecutive points,
+// for example debounce("state", 3) ignores changes of the state lasting less than three points.
+// The new stable value is the value of the last of these points. The first value of a group is stable.
+// The state of the function is the stable value and the number of points it has differed for, whatever count is.
+type debounce struct {
+	stable  interface{}
+	changes int64
+}
+
+func (d *debounce) Reset() {
+	d.stable = nil
+	d.changes = 0
+}
+
+func (d *debounce) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("debounce expects exactly two arguments")
+	}
+	switch args[0].(type) {
+	case float64, int64, string, bool:
+	default:
+		return nil, fmt.Errorf("cannot pass %T as first arg to debounce, must be float, int, string or bool", args[0])
+	}
+	count, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to debounce, must be int", args[1])
+	}
+	if err := validateDebounceCount(count); err != nil {
+		return nil, err
+	}
+
+	v := args[0]
+	switch {
+	case d.stable == nil || v == d.stable:
+		d.stable = v
+		d.changes = 0
+	default:
+		d.changes++
+		if d.changes >= count {
+			d.stable = v
+			d.changes = 0
+		}
+	}
+	return d.stable, nil
+}
+
+func validateDebounceCount(count int64) error {
+	if count < 1 {
+		return fmt.Errorf("debounce count must be positive, got %d", count)
+	}
+	return nil
+}
+
+// ValidateArgs checks that the count is a positive int literal.
+func (d *debounce) ValidateArgs(args []ast.Node) error {
+	if len(args) != 2 {
+		return errors.New("debounce expects exactly two arguments")
+	}
+	n, ok := args[1].(*ast.NumberNode)
+	if !ok || !n.IsInt {
+		return errors.New("the count of debounce must be an int literal")
+	}
+	return validateDebounceCount(n.Int64)
+}
+
+var debounceFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Debounce Function Signature
+func init() {
+	d := Domain{}
+	d[1] = ast.TInt
+	for _, t := range []ast.ValueType{ast.TFloat, ast.TInt, ast.TString, ast.TBool} {
+		d[0] = t
+		debounceFuncSignature[d] = t
+	}
+}
+
+func (d *debounce) Signature() map[Domain]ast.ValueType {
+	return debounceFuncSignature
+}
@@ -0,0 +1,93 @@
+package stateful
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_Debounce(t *testing.T) {
+	f := NewFunctions()["debounce"]
+	testCases := []struct {
+		value interface{}
+		exp   interface{}
+	}{
+		{value: 1.0, exp: 1.0},
+		// Single spike
+		{value: 9.0, exp: 1.0},
+		{value: 1.0, exp: 1.0},
+		// Changes for three points
+		{value: 5.0, exp: 1.0},
+		{value: 6.0, exp: 1.0},
+		{value: 7.0, exp: 7.0},
+		{value: 7.0, exp: 7.0},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.value, int64(3))
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if result != tc.exp {
+			t.Errorf("%d: unexpected result from debounce(%v, 3) got %v exp %v", i, tc.value, result, tc.exp)
+		}
+	}
+
+	f.Reset()
+	for i, tc := range []struct {
+		value string
+		exp   string
+	}{
+		{value: "ok", exp: "ok"},
+		{value: "down", exp: "ok"},
+		{value: "down", exp: "down"},
+	} {
+		result, err := f.Call(tc.value, int64(2))
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if result != tc.exp {
+			t.Errorf("%d: unexpected result from debounce(%q, 2) got %v exp %q", i, tc.value, result, tc.exp)
+		}
+	}
+}
+
+func Test_Debounce_ValidateArgs(t *testing.T) {
+	value := &ast.ReferenceNode{Reference: "value"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{
+			args: []ast.Node{value, &ast.NumberNode{IsInt: true, Int64: 3}},
+		},
+		{
+			args: []ast.Node{value, &ast.NumberNode{IsInt: true, Int64: 0}},
+			err:  "debounce count must be positive, got 0",
+		},
+		{
+			args: []ast.Node{value, &ast.ReferenceNode{Reference: "count"}},
+			err:  "the count of debounce must be an int literal",
+		},
+		{
+			args: []ast.Node{value},
+			err:  "debounce expects exactly two arguments",
+		},
+	}
+	for i, tc := range testCases {
+		_, err := NewEvalFunctionNode(&ast.FunctionNode{Func: "debounce", Args: tc.args})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %q exp %q", i, err.Error(), tc.err)
+		}
+	}
+}
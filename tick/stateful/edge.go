@@ -0,0 +1,101 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Kinds of transitions detected by the edge function.
+const (
+	// EdgeRising is a transition from false to true.
+	EdgeRising = "rising"
+	// EdgeFalling is a transition from true to false.
+	EdgeFalling = "falling"
+	// EdgeBoth is a transition either way.
+	EdgeBoth = "both"
+)
+
+// edge is true when the condition of the group changed since the previous point in the direction of the kind,
+// for example edge("value" > 80, 'rising') is true only for the first point above 80 after points at or below 80.
+// The first point of a group sets the previous condition and is not an edge.
+// The state of the function is the previous condition.
+type edge struct {
+	last    bool
+	hasLast bool
+}
+
+func (e *edge) Reset() {
+	e.last = false
+	e.hasLast = false
+}
+
+func (e *edge) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, errors.New("edge expects one or two arguments")
+	}
+	cond, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to edge, must be bool", args[0])
+	}
+	kind := EdgeRising
+	if len(args) > 1 {
+		if kind, ok = args[1].(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as second arg to edge, must be string", args[1])
+		}
+		if err := validateEdgeKind(kind); err != nil {
+			return nil, err
+		}
+	}
+
+	last, hasLast := e.last, e.hasLast
+	e.last, e.hasLast = cond, true
+	if !hasLast || cond == last {
+		return false, nil
+	}
+	switch kind {
+	case EdgeRising:
+		return cond, nil
+	case EdgeFalling:
+		return !cond, nil
+	default:
+		return true, nil
+	}
+}
+
+func validateEdgeKind(kind string) error {
+	switch kind {
+	case EdgeRising, EdgeFalling, EdgeBoth:
+		return nil
+	}
+	return fmt.Errorf("invalid kind %q of edge, must be one of %s, %s or %s", kind, EdgeRising, EdgeFalling, EdgeBoth)
+}
+
+// ValidateArgs checks the kind when it is a literal.
+func (e *edge) ValidateArgs(args []ast.Node) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("edge expects one or two arguments")
+	}
+	if len(args) > 1 {
+		if s, ok := args[1].(*ast.StringNode); ok {
+			return validateEdgeKind(s.Literal)
+		}
+	}
+	return nil
+}
+
+var edgeFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Edge Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TBool
+	edgeFuncSignature[d] = ast.TBool
+	d[1] = ast.TString
+	edgeFuncSignature[d] = ast.TBool
+}
+
+func (e *edge) Signature() map[Domain]ast.ValueType {
+	return edgeFuncSignature
+}
@@ -0,0 +1,88 @@
+package stateful
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_Edge(t *testing.T) {
+	conds := []bool{true, true, false, false, true, false}
+	testCases := []struct {
+		kind string
+		exp  []bool
+	}{
+		{kind: EdgeRising, exp: []bool{false, false, false, false, true, false}},
+		{kind: EdgeFalling, exp: []bool{false, false, true, false, false, true}},
+		{kind: EdgeBoth, exp: []bool{false, false, true, false, true, true}},
+	}
+	for _, tc := range testCases {
+		f := NewFunctions()["edge"]
+		for i, cond := range conds {
+			result, err := f.Call(cond, tc.kind)
+			if err != nil {
+				t.Fatalf("%s %d: unexpected error %v", tc.kind, i, err)
+			}
+			if result != tc.exp[i] {
+				t.Errorf("%s %d: unexpected result from edge(%t) got %v exp %t", tc.kind, i, cond, result, tc.exp[i])
+			}
+		}
+	}
+}
+
+func Test_Edge_Reset(t *testing.T) {
+	f := NewFunctions()["edge"]
+	if _, err := f.Call(false); err != nil {
+		t.Fatal(err)
+	}
+	f.Reset()
+	// The first point after a reset is not an edge.
+	result, err := f.Call(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != false {
+		t.Errorf("unexpected edge after reset got %v exp false", result)
+	}
+	if result, _ := f.Call(false); result != false {
+		t.Errorf("unexpected rising edge got %v exp false", result)
+	}
+	if result, _ := f.Call(true); result != true {
+		t.Errorf("expected a rising edge by default got %v", result)
+	}
+
+	if _, err := f.Call(int64(1)); err == nil {
+		t.Error("expected error passing an int as the condition")
+	}
+	if _, err := f.Call(true, "up"); err == nil {
+		t.Error("expected error passing an invalid kind")
+	}
+}
+
+func Test_Edge_ValidateArgs(t *testing.T) {
+	cond := &ast.ReferenceNode{Reference: "up"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{args: []ast.Node{cond}},
+		{args: []ast.Node{cond, &ast.StringNode{Literal: "both"}}},
+		{args: []ast.Node{cond, &ast.ReferenceNode{Reference: "kind"}}},
+		{args: []ast.Node{cond, &ast.StringNode{Literal: "up"}}, err: `invalid kind "up" of edge`},
+		{args: []ast.Node{}, err: "one or two arguments"},
+	}
+	f := NewFunctions()["edge"].(ArgsValidator)
+	for i, tc := range testCases {
+		err := f.ValidateArgs(tc.args)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %v exp %q", i, err, tc.err)
+		}
+	}
+}
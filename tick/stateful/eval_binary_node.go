@@ -164,6 +164,10 @@ func (n *EvalBinaryNode) EvalMissing(scope *Scope, executionState ExecutionState
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: n.constReturnType}
 }
 
+func (n *EvalBinaryNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: n.constReturnType}
+}
+
 func (e *EvalBinaryNode) EvalDuration(scope *Scope, executionState ExecutionState) (time.Duration, error) {
 	result, err := e.eval(scope, executionState)
 	if err != nil {
@@ -52,6 +52,10 @@ func (n *EvalBoolNode) EvalMissing(scope *Scope, executionState ExecutionState)
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TBool}
 }
 
+func (n *EvalBoolNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TBool}
+}
+
 func (n *EvalBoolNode) IsDynamic() bool {
 	return false
 }
@@ -52,6 +52,10 @@ func (n *EvalDurationNode) EvalMissing(scope *Scope, executionState ExecutionSta
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TDuration}
 }
 
+func (n *EvalDurationNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TDuration}
+}
+
 func (n *EvalDurationNode) IsDynamic() bool {
 	return false
 }
@@ -52,6 +52,10 @@ func (n *EvalFloatNode) EvalMissing(scope *Scope, executionState ExecutionState)
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TFloat}
 }
 
+func (n *EvalFloatNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TFloat}
+}
+
 func (n *EvalFloatNode) IsDynamic() bool {
 	return false
 }
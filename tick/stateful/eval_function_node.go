@@ -13,6 +13,10 @@ import (
 type EvalFunctionNode struct {
 	funcName       string
 	argsEvaluators []NodeEvaluator
+
+	// referenceFunc and reference are set for functions of a reference, see referenceFunc.
+	referenceFunc referenceFunc
+	reference     string
 }
 
 func NewEvalFunctionNode(funcNode *ast.FunctionNode) (*EvalFunctionNode, error) {
@@ -20,6 +24,16 @@ func NewEvalFunctionNode(funcNode *ast.FunctionNode) (*EvalFunctionNode, error)
 		funcName: funcNode.Func,
 	}
 
+	if v, ok := builtinFuncs[funcNode.Func].(ArgsValidator); ok {
+		if err := v.ValidateArgs(funcNode.Args); err != nil {
+			return nil, fmt.Errorf("invalid arguments to %s: %v", funcNode.Func, err)
+		}
+	}
+	if f, ok := builtinFuncs[funcNode.Func].(referenceFunc); ok {
+		evalFuncNode.referenceFunc = f
+		evalFuncNode.reference = funcNode.Args[0].(*ast.ReferenceNode).Reference
+	}
+
 	evalFuncNode.argsEvaluators = make([]NodeEvaluator, 0, len(funcNode.Args))
 	for i, argNode := range funcNode.Args {
 		argEvaluator, err := createNodeEvaluator(argNode)
@@ -43,6 +57,9 @@ func (n *EvalFunctionNode) String() string {
 }
 
 func (n *EvalFunctionNode) Type(scope ReadOnlyScope) (ast.ValueType, error) {
+	if n.referenceFunc != nil {
+		return ast.TBool, nil
+	}
 	f := lookupFunc(n.funcName, builtinFuncs, scope)
 	if f == nil {
 		return ast.InvalidType, fmt.Errorf("undefined function: %q", n.funcName)
@@ -64,6 +81,9 @@ func (n *EvalFunctionNode) Type(scope ReadOnlyScope) (ast.ValueType, error) {
 	}
 
 	retType, ok := signature[domain]
+	if !ok && n.funcName == "if" {
+		retType, ok = ifMissingBranchType(domain)
+	}
 	if !ok {
 		args := []string{}
 		missing := []string{}
@@ -97,6 +117,9 @@ func (n *EvalFunctionNode) IsDynamic() bool {
 
 // callFunction - core method for evaluating function where all NodeEvaluator methods should use
 func (n *EvalFunctionNode) callFunction(scope *Scope, executionState ExecutionState) (interface{}, error) {
+	if n.referenceFunc != nil {
+		return n.callReference(scope), nil
+	}
 	args := make([]interface{}, 0, len(n.argsEvaluators))
 	for i, argEvaluator := range n.argsEvaluators {
 		value, err := eval(argEvaluator, scope, executionState)
@@ -120,6 +143,13 @@ func (n *EvalFunctionNode) callFunction(scope *Scope, executionState ExecutionSt
 	return ret, nil
 }
 
+// callReference calls the function with the value of its reference in scope.
+func (n *EvalFunctionNode) callReference(scope *Scope) bool {
+	value, ok := scope.variables[n.reference]
+	missing := !ok || value == empty || value == ast.MissingValue
+	return n.referenceFunc.callReference(value, missing)
+}
+
 func (n *EvalFunctionNode) EvalRegex(scope *Scope, executionState ExecutionState) (*regexp.Regexp, error) {
 	refValue, err := n.callFunction(scope, executionState)
 	if err != nil {
@@ -199,6 +229,9 @@ func (n *EvalFunctionNode) EvalInt(scope *Scope, executionState ExecutionState)
 }
 
 func (n *EvalFunctionNode) EvalBool(scope *Scope, executionState ExecutionState) (bool, error) {
+	if n.referenceFunc != nil {
+		return n.callReference(scope), nil
+	}
 	refValue, err := n.callFunction(scope, executionState)
 	if err != nil {
 		return false, err
@@ -223,6 +256,19 @@ func (n *EvalFunctionNode) EvalMissing(scope *Scope, executionState ExecutionSta
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TypeOf(refValue)}
 }
 
+func (n *EvalFunctionNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	refValue, err := n.callFunction(scope, executionState)
+	if err != nil {
+		return nil, err
+	}
+
+	if arrayValue, isArray := refValue.([]float64); isArray {
+		return arrayValue, nil
+	}
+
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TypeOf(refValue)}
+}
+
 // eval - generic evaluation until we have reflection/introspection capabillities so we can know the type of args
 // and return type, we can remove this entirely
 func eval(n NodeEvaluator, scope *Scope, executionState ExecutionState) (interface{}, error) {
@@ -246,6 +292,8 @@ func eval(n NodeEvaluator, scope *Scope, executionState ExecutionState) (interfa
 		return n.EvalTime(scope, executionState)
 	case ast.TDuration:
 		return n.EvalDuration(scope, executionState)
+	case ast.TArray:
+		return n.EvalArray(scope, executionState)
 	case ast.TMissing:
 		v, err := n.EvalMissing(scope, executionState)
 		if err != nil && !strings.Contains(err.Error(), "missing value") {
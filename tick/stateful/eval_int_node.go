@@ -52,6 +52,10 @@ func (n *EvalIntNode) EvalMissing(scope *Scope, executionState ExecutionState) (
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TInt}
 }
 
+func (n *EvalIntNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TInt}
+}
+
 func (n *EvalIntNode) IsDynamic() bool {
 	return false
 }
@@ -136,3 +136,15 @@ func (n *EvalLambdaNode) EvalMissing(scope *Scope, _ ExecutionState) (*ast.Missi
 
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TBool, ActualType: typ}
 }
+
+func (n *EvalLambdaNode) EvalArray(scope *Scope, _ ExecutionState) ([]float64, error) {
+	typ, err := n.Type(scope)
+	if err != nil {
+		return nil, err
+	}
+	if typ == ast.TArray {
+		return n.nodeEvaluator.EvalArray(scope, n.state)
+	}
+
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: typ}
+}
@@ -168,6 +168,24 @@ func (n *EvalReferenceNode) EvalBool(scope *Scope, executionState ExecutionState
 	return false, ErrTypeGuardFailed{RequestedType: ast.TBool, ActualType: ast.TypeOf(refValue)}
 }
 
+func (n *EvalReferenceNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	refValue, err := n.getReferenceValue(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if arrayValue, isArray := refValue.([]float64); isArray {
+		return arrayValue, nil
+	}
+
+	refType := ast.TypeOf(refValue)
+	if refType == ast.TMissing {
+		return nil, fmt.Errorf("reference \"%s\" is missing value", n.Node.Reference)
+	}
+
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TypeOf(refValue)}
+}
+
 func (n *EvalReferenceNode) EvalMissing(scope *Scope, executionState ExecutionState) (*ast.Missing, error) {
 	refValue, err := n.getReferenceValue(scope)
 	if err != nil {
@@ -51,6 +51,10 @@ func (n *EvalRegexNode) EvalMissing(scope *Scope, executionState ExecutionState)
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TRegex}
 }
 
+func (n *EvalRegexNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TRegex}
+}
+
 func (n *EvalRegexNode) IsDynamic() bool {
 	return false
 }
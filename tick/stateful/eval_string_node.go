@@ -51,6 +51,10 @@ func (n *EvalStringNode) EvalMissing(scope *Scope, executionState ExecutionState
 	return nil, ErrTypeGuardFailed{RequestedType: ast.TMissing, ActualType: ast.TString}
 }
 
+func (n *EvalStringNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: ast.TString}
+}
+
 func (n *EvalStringNode) IsDynamic() bool {
 	return false
 }
@@ -71,6 +71,10 @@ func (n *EvalUnaryNode) EvalMissing(scope *Scope, executionState ExecutionState)
 	return nil, fmt.Errorf("reference \"%s\" is missing value", ref.Node.Reference)
 }
 
+func (n *EvalUnaryNode) EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: ast.TArray, ActualType: n.constReturnType}
+}
+
 func (n *EvalUnaryNode) EvalDuration(scope *Scope, executionState ExecutionState) (time.Duration, error) {
 	typ, err := n.Type(scope)
 	if err != nil {
@@ -90,6 +90,10 @@ func (se *expression) EvalMissing(scope *Scope) (*ast.Missing, error) {
 	return se.nodeEvaluator.EvalMissing(scope, se.executionState)
 }
 
+func (se *expression) EvalArray(scope *Scope) ([]float64, error) {
+	return se.nodeEvaluator.EvalArray(scope, se.executionState)
+}
+
 func (se *expression) Eval(scope *Scope) (result interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -146,6 +150,12 @@ func (se *expression) Eval(scope *Scope) (result interface{}, err error) {
 			return nil, err
 		}
 		return result, err
+	case ast.TArray:
+		result, err = se.EvalArray(scope)
+		if err != nil {
+			return nil, err
+		}
+		return result, err
 	default:
 		err = fmt.Errorf("expression returned unexpected type %s", typ)
 		return
@@ -46,6 +46,19 @@ func BenchmarkEvalBool_OneOperator_UnaryNode_ReferenceNode(b *testing.B) {
 	})
 }
 
+func BenchmarkEvalBool_FunctionNode_Exists(b *testing.B) {
+
+	scope := stateful.NewScope()
+	scope.Set("value", float64(20))
+
+	benchmarkEvalBool(b, scope, &ast.FunctionNode{
+		Func: "exists",
+		Args: []ast.Node{&ast.ReferenceNode{
+			Reference: "value",
+		}},
+	})
+}
+
 func BenchmarkEvalBool_OneOperator_NumberFloat64_NumberFloat64(b *testing.B) {
 
 	emptyScope := stateful.NewScope()
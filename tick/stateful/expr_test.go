@@ -48,6 +48,164 @@ func TestExpression_EvalNum_KeepsFunctionsState(t *testing.T) {
 
 }
 
+func TestExpression_EvalString_TagFunction(t *testing.T) {
+	se := mustCompileExpression(&ast.FunctionNode{
+		Func: "tag",
+		Args: []ast.Node{&ast.StringNode{Literal: "region"}},
+	})
+
+	scope := stateful.NewScope()
+	scope.SetTags(map[string]string{"region": "us-west"})
+	result, err := se.EvalString(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if exp := "us-west"; result != exp {
+		t.Errorf("unexpected result got %q exp %q", result, exp)
+	}
+
+	// Missing tags are empty
+	scope.SetTags(map[string]string{"host": "serverA"})
+	result, err = se.EvalString(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Errorf("unexpected result for missing tag got %q exp empty string", result)
+	}
+
+	// Reset clears the tags
+	scope.SetTags(map[string]string{"region": "us-west"})
+	scope.Reset()
+	result, err = se.EvalString(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Errorf("unexpected result after reset got %q exp empty string", result)
+	}
+}
+
+func TestExpression_EvalDuration_NowFunction(t *testing.T) {
+	// timeDiff(now(), "t")
+	se := mustCompileExpression(&ast.FunctionNode{
+		Func: "timeDiff",
+		Args: []ast.Node{
+			&ast.FunctionNode{Func: "now"},
+			&ast.ReferenceNode{Reference: "t"},
+		},
+	})
+
+	pointTime := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	scope := stateful.NewScope()
+	scope.Set("t", pointTime)
+	result, err := se.Eval(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if d, ok := result.(time.Duration); !ok || d < time.Since(pointTime)-time.Minute {
+		t.Errorf("unexpected wall clock age %v", result)
+	}
+
+	// now returns the time set on the scope
+	scope.SetNow(pointTime.Add(time.Minute))
+	result, err = se.Eval(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if exp := time.Minute; result != exp {
+		t.Errorf("unexpected result got %v exp %v", result, exp)
+	}
+
+	// Reset uses the wall clock again
+	scope.Reset()
+	scope.Set("t", pointTime)
+	result, err = se.Eval(scope)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+	if result == time.Minute {
+		t.Errorf("unexpected result after reset %v", result)
+	}
+}
+
+func TestExpression_TagSetFunctions(t *testing.T) {
+	scope := stateful.NewScope()
+	scope.SetTags(map[string]string{"region": "us-west", "host": "serverA", "dc": "b"})
+
+	count := mustCompileExpression(&ast.FunctionNode{Func: "tagCount"})
+	if result, err := count.EvalInt(scope); err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	} else if exp := int64(3); result != exp {
+		t.Errorf("unexpected tagCount got %d exp %d", result, exp)
+	}
+
+	stringTests := []struct {
+		fn  *ast.FunctionNode
+		exp string
+	}{
+		{
+			fn:  &ast.FunctionNode{Func: "tagKeys"},
+			exp: "dc,host,region",
+		},
+		{
+			fn:  &ast.FunctionNode{Func: "tagKeys", Args: []ast.Node{&ast.StringNode{Literal: "|"}}},
+			exp: "dc|host|region",
+		},
+		{
+			fn:  &ast.FunctionNode{Func: "tagsString"},
+			exp: "dc=b,host=serverA,region=us-west",
+		},
+		{
+			fn:  &ast.FunctionNode{Func: "tagsString", Args: []ast.Node{&ast.StringNode{Literal: " "}}},
+			exp: "dc=b host=serverA region=us-west",
+		},
+	}
+	for _, tc := range stringTests {
+		se := mustCompileExpression(tc.fn)
+		// Evaluate repeatedly since the order of the tags map is random.
+		for i := 0; i < 10; i++ {
+			result, err := se.EvalString(scope)
+			if err != nil {
+				t.Fatalf("%s: Got unexpected error: %v", tc.fn.Func, err)
+			}
+			if result != tc.exp {
+				t.Fatalf("%s: unexpected result got %q exp %q", tc.fn.Func, result, tc.exp)
+			}
+		}
+	}
+
+	matching := func(pattern string) stateful.Expression {
+		return mustCompileExpression(&ast.FunctionNode{
+			Func: "hasTagMatching",
+			Args: []ast.Node{&ast.RegexNode{Regex: regexp.MustCompile(pattern), Literal: pattern}},
+		})
+	}
+	if result, err := matching("^ho").EvalBool(scope); err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	} else if !result {
+		t.Error("expected a tag matching ^ho")
+	}
+	if result, err := matching("^zone$").EvalBool(scope); err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	} else if result {
+		t.Error("expected no tag matching ^zone$")
+	}
+
+	// Functions of no tags
+	scope.Reset()
+	if result, err := count.EvalInt(scope); err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	} else if result != 0 {
+		t.Errorf("unexpected tagCount after reset got %d exp 0", result)
+	}
+	if result, err := mustCompileExpression(&ast.FunctionNode{Func: "tagsString"}).EvalString(scope); err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	} else if result != "" {
+		t.Errorf("unexpected tagsString after reset got %q exp empty string", result)
+	}
+}
+
 func TestExpression_EvalBool_BinaryNodeWithDurationNode(t *testing.T) {
 	leftValues := []interface{}{time.Duration(5), time.Duration(10)}
 	rightValues := []interface{}{time.Duration(5), time.Duration(10), int64(5)}
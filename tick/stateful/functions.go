@@ -1,6 +1,10 @@
 package stateful
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -23,7 +27,7 @@ var ErrNotInt = errors.New("value is not an int")
 // Increment this value if you create a builtin function with more than
 // the current value of maxArgs.
 const (
-	maxArgs = 4
+	maxArgs = 5
 )
 
 type ErrMissingType struct {
@@ -122,6 +126,12 @@ type Func interface {
 	Signature() map[Domain]ast.ValueType
 }
 
+// ArgsValidator is implemented by the functions that validate their arguments
+// when the expression is created, so that invalid arguments fail when the task starts.
+type ArgsValidator interface {
+	ValidateArgs(args []ast.Node) error
+}
+
 func FuncDomains(f Func) Domains {
 	ds := []Domain{}
 
@@ -147,6 +157,8 @@ func init() {
 	statelessFuncs["float"] = float{}
 	statelessFuncs["string"] = str{}
 	statelessFuncs["duration"] = duration{}
+	statelessFuncs["boolToInt"] = boolToInt{}
+	statelessFuncs["intToBool"] = intToBool{}
 
 	// Math functions
 	statelessFuncs["abs"] = newMath1("abs", math.Abs)
@@ -200,9 +212,11 @@ func init() {
 	statelessFuncs["strHasSuffix"] = newString2Bool("strHasSuffix", strings.HasSuffix)
 	statelessFuncs["strIndex"] = newString2Int("strIndex", strings.Index)
 	statelessFuncs["strIndexAny"] = newString2Int("strIndexAny", strings.IndexAny)
+	statelessFuncs["strJaroWinkler"] = strJaroWinkler{}
 	statelessFuncs["strLastIndex"] = newString2Int("strLastIndex", strings.LastIndex)
 	statelessFuncs["strLastIndexAny"] = newString2Int("strLastIndexAny", strings.LastIndexAny)
 	statelessFuncs["strLength"] = strLength{}
+	statelessFuncs["strLevenshtein"] = newString2Int("strLevenshtein", levenshtein)
 	statelessFuncs["strReplace"] = strReplace{}
 	statelessFuncs["strSubstring"] = strSubstring{}
 	statelessFuncs["strToLower"] = newString1String("strToLower", strings.ToLower)
@@ -213,12 +227,23 @@ func init() {
 	statelessFuncs["strTrimRight"] = newString2String("strTrimRight", strings.TrimRight)
 	statelessFuncs["strTrimSpace"] = newString1String("strTrimSpace", strings.TrimSpace)
 	statelessFuncs["strTrimSuffix"] = newString2String("strTrimSuffix", strings.TrimSuffix)
+	statelessFuncs["sprintf"] = sprintf{}
 
 	// Regex functions
 	statelessFuncs["regexReplace"] = regexReplace{}
+	statelessFuncs["regexExtract"] = regexExtract{}
+
+	// Bitwise functions
+	statelessFuncs["bitAnd"] = newInt2Int("bitAnd", func(a, b int64) int64 { return a & b })
+	statelessFuncs["bitOr"] = newInt2Int("bitOr", func(a, b int64) int64 { return a | b })
+	statelessFuncs["bitShift"] = newInt2Int("bitShift", bitShift)
+	statelessFuncs["bitTest"] = bitTest{}
+	statelessFuncs["parseInt"] = parseInt{}
 
 	// Missing functions
 	statelessFuncs["isPresent"] = isPresent{}
+	statelessFuncs["exists"] = exists{}
+	statelessFuncs["isNull"] = isNull{}
 
 	// Time functions
 	statelessFuncs["unixNano"] = unixNano{}
@@ -228,21 +253,45 @@ func init() {
 	statelessFuncs["day"] = day{}
 	statelessFuncs["month"] = month{}
 	statelessFuncs["year"] = year{}
-	statelessFuncs["now"] = now{}
+	statelessFuncs["timeDiff"] = timeDiff{}
+
+	// Calendar functions
+	statelessFuncs["isBusinessHours"] = isBusinessHours{}
+	statelessFuncs["isHoliday"] = isHoliday{}
 
 	// Humanize functions
 	statelessFuncs["humanBytes"] = humanBytes{}
 
+	// Geo functions
+	statelessFuncs["haversine"] = haversine{}
+
 	// Conditionals
 	statelessFuncs["if"] = ifFunc{}
 
+	// Unit functions
+	statelessFuncs["convert"] = convert{}
+
+	// Hash functions
+	statelessFuncs["sha256"] = hashFunc{name: "sha256", new: sha256.New}
+	statelessFuncs["md5"] = hashFunc{name: "md5", new: md5.New}
+	statelessFuncs["hmac"] = hmacFunc{}
+
+	// Array functions
+	statelessFuncs["array"] = array{}
+	statelessFuncs["arrayIndex"] = arrayIndex{}
+	statelessFuncs["arrayLen"] = arrayLen{}
+	statelessFuncs["arraySum"] = arrayAggregate{name: "arraySum", aggregate: arraySum, allowEmpty: true}
+	statelessFuncs["arrayMean"] = arrayAggregate{name: "arrayMean", aggregate: arrayMean}
+	statelessFuncs["arrayMax"] = arrayAggregate{name: "arrayMax", aggregate: arrayMax}
+	statelessFuncs["arrayMin"] = arrayAggregate{name: "arrayMin", aggregate: arrayMin}
+
 	// Create map of builtin functions after all functions have been added to statelessFuncs
 	builtinFuncs = NewFunctions()
 }
 
 // Return set of built-in Funcs
 func NewFunctions() Funcs {
-	funcs := make(Funcs, len(statelessFuncs)+3)
+	funcs := make(Funcs, len(statelessFuncs)+5)
 	for n, f := range statelessFuncs {
 		funcs[n] = f
 	}
@@ -252,6 +301,14 @@ func NewFunctions() Funcs {
 	funcs["count"] = &count{}
 	funcs["spread"] = &spread{min: math.Inf(+1), max: math.Inf(-1)}
 	funcs["rand"] = NewRand()
+	funcs["distanceFromLast"] = &distanceFromLast{}
+	funcs["lookup"] = &lookup{}
+	funcs["accumulate"] = &accumulate{}
+	funcs["percentileRank"] = &percentileRank{}
+	funcs["debounce"] = &debounce{}
+	funcs["rate"] = &rate{}
+	funcs["countTrue"] = &countTrue{}
+	funcs["edge"] = &edge{}
 
 	return funcs
 }
@@ -790,6 +847,71 @@ func (regexReplace) Signature() map[Domain]ast.ValueType {
 
 func (m regexReplace) Reset() {}
 
+// regexExtract returns a captured group of the first match of a regex,
+// the group is selected either by index or by name.
+// If there is no match an empty string is returned.
+type regexExtract struct {
+}
+
+func (m regexExtract) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) != 3 {
+		return 0, errors.New("regexExtract expects exactly three arguments")
+	}
+	pattern, ok := args[0].(*regexp.Regexp)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as first arg to regexExtract, must be regex", args[0])
+		return
+	}
+	src, ok := args[1].(string)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as second arg to regexExtract, must be string", args[1])
+		return
+	}
+	var group int
+	switch g := args[2].(type) {
+	case int64:
+		group = int(g)
+		if group < 0 || group > pattern.NumSubexp() {
+			err = fmt.Errorf("regex %s has no group %d", pattern, group)
+			return
+		}
+	case string:
+		group = pattern.SubexpIndex(g)
+		if group < 0 {
+			err = fmt.Errorf("regex %s has no group named %q", pattern, g)
+			return
+		}
+	default:
+		err = fmt.Errorf("cannot pass %T as third arg to regexExtract, must be int64 or string", args[2])
+		return
+	}
+	match := pattern.FindStringSubmatch(src)
+	if match == nil {
+		return "", nil
+	}
+	v = match[group]
+	return
+}
+
+var regexExtractFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Regex Extract Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TRegex
+	d[1] = ast.TString
+	d[2] = ast.TInt
+	regexExtractFuncSignature[d] = ast.TString
+	d[2] = ast.TString
+	regexExtractFuncSignature[d] = ast.TString
+}
+
+func (regexExtract) Signature() map[Domain]ast.ValueType {
+	return regexExtractFuncSignature
+}
+
+func (m regexExtract) Reset() {}
+
 type boolean struct {
 }
 
@@ -1398,32 +1520,34 @@ func (year) Signature() map[Domain]ast.ValueType {
 	return timeFuncSignature
 }
 
-var nowFuncSignature = map[Domain]ast.ValueType{}
-
-// Initialize Now function signature
-func init() {
-	d := Domain{}
-	nowFuncSignature[d] = ast.TTime
+var timeDiffFuncSignature = map[Domain]ast.ValueType{
+	{ast.TTime, ast.TTime}: ast.TDuration,
 }
 
-type now struct {
+type timeDiff struct {
 }
 
-func (now) Reset() {
+func (timeDiff) Reset() {
 }
 
-// Return the current local time.
-func (now) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 0 {
-		return 0, errors.New("now expects exactly zero argument")
+// Return the duration from the second time to the first time.
+func (timeDiff) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) != 2 {
+		return 0, errors.New("timeDiff expects exactly two arguments")
 	}
-	v = time.Now()
-
-	return
+	a, ok := args[0].(time.Time)
+	if !ok {
+		return 0, fmt.Errorf("cannot convert %T to time.Time", args[0])
+	}
+	b, ok := args[1].(time.Time)
+	if !ok {
+		return 0, fmt.Errorf("cannot convert %T to time.Time", args[1])
+	}
+	return a.Sub(b), nil
 }
 
-func (now) Signature() map[Domain]ast.ValueType {
-	return nowFuncSignature
+func (timeDiff) Signature() map[Domain]ast.ValueType {
+	return timeDiffFuncSignature
 }
 
 type humanBytes struct {
@@ -1482,17 +1606,43 @@ func (ifFunc) Call(args ...interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("first argument to if must be a condition with type of bool - got %T", args[0])
 	}
 
-	if reflect.TypeOf(args[1]) != reflect.TypeOf(args[2]) {
+	_, missing1 := args[1].(*ast.Missing)
+	_, missing2 := args[2].(*ast.Missing)
+	if !missing1 && !missing2 && reflect.TypeOf(args[1]) != reflect.TypeOf(args[2]) {
 		return nil, fmt.Errorf("Different return types are not supported - second argument is %T and third argument is %T", args[1], args[2])
 	}
 
+	// The branch that is not returned may be missing, i.e. if(exists("value"), "value", 0.0)
 	if condition {
+		if missing1 {
+			return nil, errors.New("second argument to if is missing")
+		}
 		return args[1], nil
 	}
-
+	if missing2 {
+		return nil, errors.New("third argument to if is missing")
+	}
 	return args[2], nil
 }
 
+// ifMissingBranchType returns the type of if when one of its branches is missing,
+// which is the type of the other branch.
+func ifMissingBranchType(domain Domain) (ast.ValueType, bool) {
+	if domain[0] != ast.TBool {
+		return ast.InvalidType, false
+	}
+	t := domain[1]
+	switch {
+	case domain[1] == ast.TMissing && domain[2] != ast.TMissing:
+		t = domain[2]
+	case domain[2] == ast.TMissing && domain[1] != ast.TMissing:
+	default:
+		return ast.InvalidType, false
+	}
+	retType, ok := ifFuncSignature[Domain{ast.TBool, t, t}]
+	return retType, ok
+}
+
 var ifFuncSignature = map[Domain]ast.ValueType{}
 
 // Initialize If Function Signature
@@ -1520,6 +1670,107 @@ func (ifFunc) Signature() map[Domain]ast.ValueType {
 	return ifFuncSignature
 }
 
+// lookup maps a key to a value of a table given as a JSON object,
+// for example lookup("region", '{"us": 1, "eu": 2}', 0).
+// Keys missing from the table return the default value,
+// the values of the table must have the type of the default value.
+// The table is parsed on the first call and reused as long as it does not change.
+type lookup struct {
+	raw   string
+	typ   ast.ValueType
+	table map[string]interface{}
+}
+
+func (l *lookup) Reset() {}
+
+func (l *lookup) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, errors.New("lookup expects exactly three arguments")
+	}
+	key, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("first argument to lookup must be a string key - got %T", args[0])
+	}
+	raw, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("second argument to lookup must be a JSON object string - got %T", args[1])
+	}
+	def := args[2]
+	typ := ast.TypeOf(def)
+	if l.table == nil || l.raw != raw || l.typ != typ {
+		table, err := parseLookupTable(raw, typ)
+		if err != nil {
+			return nil, err
+		}
+		l.raw, l.typ, l.table = raw, typ, table
+	}
+	if v, ok := l.table[key]; ok {
+		return v, nil
+	}
+	return def, nil
+}
+
+// parseLookupTable parses the JSON object of a lookup table,
+// converting its values to the type typ.
+func parseLookupTable(raw string, typ ast.ValueType) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewBufferString(raw))
+	dec.UseNumber()
+	var values map[string]interface{}
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid lookup table, must be a JSON object: %v", err)
+	}
+	table := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		var value interface{}
+		var err error
+		switch typ {
+		case ast.TFloat:
+			n, ok := v.(json.Number)
+			if !ok {
+				err = fmt.Errorf("expected %s value, got %v", typ, v)
+				break
+			}
+			value, err = n.Float64()
+		case ast.TInt:
+			n, ok := v.(json.Number)
+			if !ok {
+				err = fmt.Errorf("expected %s value, got %v", typ, v)
+				break
+			}
+			value, err = n.Int64()
+		case ast.TString, ast.TBool:
+			if ast.TypeOf(v) != typ {
+				err = fmt.Errorf("expected %s value, got %v", typ, v)
+			}
+			value = v
+		default:
+			return nil, fmt.Errorf("lookup does not support default values of type %s", typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of key %q in lookup table: %v", k, err)
+		}
+		table[k] = value
+	}
+	return table, nil
+}
+
+var lookupFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Lookup Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TString
+	d[1] = ast.TString
+	for _, t := range []ast.ValueType{ast.TFloat, ast.TInt, ast.TString, ast.TBool} {
+		d[2] = t
+		lookupFuncSignature[d] = t
+	}
+}
+
+func (l *lookup) Signature() map[Domain]ast.ValueType {
+	return lookupFuncSignature
+}
+
 type isPresent struct {
 }
 
@@ -1556,3 +1807,541 @@ func init() {
 func (isPresent) Signature() map[Domain]ast.ValueType {
 	return isPresentFuncSignature
 }
+
+// referenceFunc is a function of a single field or tag reference.
+// It is called with the value of the reference in scope, without evaluating nor type checking it,
+// so that it can tell missing values and null values apart.
+// The call is a single lookup in the scope and does not allocate, so it is cheap on hot paths.
+type referenceFunc interface {
+	// callReference is called with the value of the reference, nil for a null value,
+	// and whether the reference is missing.
+	callReference(value interface{}, missing bool) bool
+}
+
+// validateReferenceArgs checks that the arguments are a single field or tag reference.
+func validateReferenceArgs(name string, args []ast.Node) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s expects exactly one argument", name)
+	}
+	if _, ok := args[0].(*ast.ReferenceNode); !ok {
+		return fmt.Errorf("%s expects a field or tag reference, i.e. %s(\"value\")", name, name)
+	}
+	return nil
+}
+
+// exists reports whether the point has a field or tag with a value, i.e. exists("value").
+//
+// A missing value is a field or tag the point does not have at all,
+// a null value is a field the point has but without a value, which only some sources produce,
+// the null values of query results are dropped so they are missing values.
+// exists is false for both, isNull is only true for null values.
+type exists struct {
+}
+
+func (exists) Reset() {}
+
+func (exists) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("exists expects exactly one argument")
+	}
+	_, missing := args[0].(*ast.Missing)
+	return exists{}.callReference(args[0], missing), nil
+}
+
+func (exists) callReference(value interface{}, missing bool) bool {
+	return !missing && value != nil
+}
+
+func (exists) ValidateArgs(args []ast.Node) error {
+	return validateReferenceArgs("exists", args)
+}
+
+func (exists) Signature() map[Domain]ast.ValueType {
+	return isPresentFuncSignature
+}
+
+// isNull reports whether the point has a field whose value is null, i.e. isNull("value").
+// It is false for missing fields, see exists for the difference between missing and null values.
+type isNull struct {
+}
+
+func (isNull) Reset() {}
+
+func (isNull) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("isNull expects exactly one argument")
+	}
+	_, missing := args[0].(*ast.Missing)
+	return isNull{}.callReference(args[0], missing), nil
+}
+
+func (isNull) callReference(value interface{}, missing bool) bool {
+	return !missing && value == nil
+}
+
+func (isNull) ValidateArgs(args []ast.Node) error {
+	return validateReferenceArgs("isNull", args)
+}
+
+func (isNull) Signature() map[Domain]ast.ValueType {
+	return isPresentFuncSignature
+}
+
+// Mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// Meters per distance unit
+var distanceUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.344,
+}
+
+// haversineDistance returns the great-circle distance in meters between two points given in degrees.
+func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// distanceUnit returns the number of meters per unit of the optional unit argument.
+func distanceUnit(name string, args []interface{}) (float64, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	unit, ok := args[0].(string)
+	if !ok {
+		return 0, fmt.Errorf("cannot pass %T as unit to %s, must be string", args[0], name)
+	}
+	m, ok := distanceUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid unit %q for %s, must be one of 'm', 'km' or 'mi'", unit, name)
+	}
+	return m, nil
+}
+
+// floatArgs converts the arguments to floats.
+func floatArgs(name string, args []interface{}) ([]float64, error) {
+	floats := make([]float64, len(args))
+	for i, a := range args {
+		f, ok := a.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot pass %T to %s, must be float64", a, name)
+		}
+		floats[i] = f
+	}
+	return floats, nil
+}
+
+type haversine struct {
+}
+
+func (haversine) Reset() {}
+
+// Computes the great-circle distance between two coordinates in degrees,
+// in meters or an optional unit 'm', 'km' or 'mi'.
+func (haversine) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 4 && len(args) != 5 {
+		return 0, errors.New("haversine expects four or five arguments")
+	}
+	c, err := floatArgs("haversine", args[:4])
+	if err != nil {
+		return nil, err
+	}
+	unit, err := distanceUnit("haversine", args[4:])
+	if err != nil {
+		return nil, err
+	}
+	return haversineDistance(c[0], c[1], c[2], c[3]) / unit, nil
+}
+
+var haversineFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Haversine Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TFloat
+	d[1] = ast.TFloat
+	d[2] = ast.TFloat
+	d[3] = ast.TFloat
+	haversineFuncSignature[d] = ast.TFloat
+	d[4] = ast.TString
+	haversineFuncSignature[d] = ast.TFloat
+}
+
+func (haversine) Signature() map[Domain]ast.ValueType {
+	return haversineFuncSignature
+}
+
+// distanceFromLast only keeps the previous coordinates,
+// so the state per group is constant in size.
+type distanceFromLast struct {
+	lat, lon float64
+	hasLast  bool
+}
+
+func (d *distanceFromLast) Reset() {
+	d.lat = 0
+	d.lon = 0
+	d.hasLast = false
+}
+
+// Computes the distance from the previous coordinates in degrees,
+// in meters or an optional unit 'm', 'km' or 'mi'.
+// The first call returns zero.
+func (d *distanceFromLast) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return 0, errors.New("distanceFromLast expects two or three arguments")
+	}
+	c, err := floatArgs("distanceFromLast", args[:2])
+	if err != nil {
+		return nil, err
+	}
+	unit, err := distanceUnit("distanceFromLast", args[2:])
+	if err != nil {
+		return nil, err
+	}
+	dist := 0.0
+	if d.hasLast {
+		dist = haversineDistance(d.lat, d.lon, c[0], c[1]) / unit
+	}
+	d.lat, d.lon, d.hasLast = c[0], c[1], true
+	return dist, nil
+}
+
+var distanceFromLastFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize DistanceFromLast Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TFloat
+	d[1] = ast.TFloat
+	distanceFromLastFuncSignature[d] = ast.TFloat
+	d[2] = ast.TString
+	distanceFromLastFuncSignature[d] = ast.TFloat
+}
+
+func (d *distanceFromLast) Signature() map[Domain]ast.ValueType {
+	return distanceFromLastFuncSignature
+}
+
+// accumulate counts the points matching a condition until a reset condition matches,
+// for example accumulate("status" != 200, "status" == 200) is the number of consecutive errors.
+// It only keeps the running count, so the state per group is constant in size.
+//
+// Eval keeps a count per group: a new group, including a group that was deleted and seen again,
+// starts counting from zero.
+type accumulate struct {
+	n int64
+}
+
+func (a *accumulate) Reset() {
+	a.n = 0
+}
+
+// Increments the count when the first argument is true and resets it to zero when the second argument is true,
+// returning the count. The reset condition takes precedence, so the point resetting the count is not counted.
+// The count stops at the largest int instead of overflowing.
+func (a *accumulate) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return 0, errors.New("accumulate expects exactly two arguments")
+	}
+	increment, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to accumulate, must be bool", args[0])
+	}
+	reset, ok := args[1].(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to accumulate, must be bool", args[1])
+	}
+	switch {
+	case reset:
+		a.n = 0
+	case increment && a.n < math.MaxInt64:
+		a.n++
+	}
+	return a.n, nil
+}
+
+var accumulateFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Accumulate Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TBool
+	d[1] = ast.TBool
+	accumulateFuncSignature[d] = ast.TInt
+}
+
+func (a *accumulate) Signature() map[Domain]ast.ValueType {
+	return accumulateFuncSignature
+}
+
+type int2IntFunc func(int64, int64) int64
+type int2Int struct {
+	name string
+	f    int2IntFunc
+}
+
+func newInt2Int(name string, f int2IntFunc) int2Int {
+	return int2Int{
+		name: name,
+		f:    f,
+	}
+}
+
+func (m int2Int) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) != 2 {
+		return 0, errors.New(m.name + " expects exactly two arguments")
+	}
+	a0, ok := args[0].(int64)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as first arg to %s, must be int64", args[0], m.name)
+		return
+	}
+	a1, ok := args[1].(int64)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as second arg to %s, must be int64", args[1], m.name)
+		return
+	}
+	v = m.f(a0, a1)
+	return
+}
+
+var int2IntFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Int2Int Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TInt
+	d[1] = ast.TInt
+	int2IntFuncSignature[d] = ast.TInt
+}
+
+func (m int2Int) Signature() map[Domain]ast.ValueType {
+	return int2IntFuncSignature
+}
+
+func (m int2Int) Reset() {}
+
+// bitShift shifts the bits of the two's complement value left by n bits,
+// or right by -n bits if n is negative, preserving the sign.
+// Bits shifted beyond 64 bits are discarded.
+func bitShift(value, n int64) int64 {
+	if n >= 0 {
+		if n >= 64 {
+			return 0
+		}
+		return value << uint(n)
+	}
+	if n <= -64 {
+		n = -63
+	}
+	return value >> uint(-n)
+}
+
+type bitTest struct {
+}
+
+// Reports whether bit n of the two's complement value is set, the least significant bit is bit 0.
+func (bitTest) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) != 2 {
+		return false, errors.New("bitTest expects exactly two arguments")
+	}
+	value, ok := args[0].(int64)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as first arg to bitTest, must be int64", args[0])
+		return
+	}
+	n, ok := args[1].(int64)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as second arg to bitTest, must be int64", args[1])
+		return
+	}
+	if n < 0 || n > 63 {
+		err = fmt.Errorf("bit %d out of range for bitTest, must be within [0,63]", n)
+		return
+	}
+	v = value&(1<<uint(n)) != 0
+	return
+}
+
+var bitTestFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize BitTest Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TInt
+	d[1] = ast.TInt
+	bitTestFuncSignature[d] = ast.TBool
+}
+
+func (bitTest) Signature() map[Domain]ast.ValueType {
+	return bitTestFuncSignature
+}
+
+func (bitTest) Reset() {}
+
+type parseInt struct {
+}
+
+// Parses the string as an integer in the given base, within [2,36].
+// With base 0 the base is implied by the prefix of the string, i.e. "0x" for base 16.
+// Values that overflow 64 bits are an error.
+func (parseInt) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) != 2 {
+		return 0, errors.New("parseInt expects exactly two arguments")
+	}
+	str, ok := args[0].(string)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as first arg to parseInt, must be string", args[0])
+		return
+	}
+	base, ok := args[1].(int64)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as second arg to parseInt, must be int64", args[1])
+		return
+	}
+	if base != 0 && (base < 2 || base > 36) {
+		err = fmt.Errorf("invalid base %d for parseInt, must be 0 or within [2,36]", base)
+		return
+	}
+	i, err := strconv.ParseInt(str, int(base), 64)
+	if err != nil {
+		return nil, err
+	}
+	v = i
+	return
+}
+
+var parseIntFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize ParseInt Function Signature
+func init() {
+	d := Domain{}
+	d[0] = ast.TString
+	d[1] = ast.TInt
+	parseIntFuncSignature[d] = ast.TInt
+}
+
+func (parseInt) Signature() map[Domain]ast.ValueType {
+	return parseIntFuncSignature
+}
+
+func (parseInt) Reset() {}
+
+type sprintf struct {
+}
+
+// sprintfVerbs are the verbs accepted for each type of argument to sprintf.
+var sprintfVerbs = map[ast.ValueType]string{
+	ast.TFloat:    "vbeEfFgGxX",
+	ast.TInt:      "vbcdoOqxXU",
+	ast.TString:   "vsqxX",
+	ast.TBool:     "vt",
+	ast.TDuration: "vsd",
+	ast.TTime:     "vs",
+}
+
+// Formats the arguments according to the format using the verbs of the Go fmt package.
+// Durations are formatted as e.g. 1m30s with %s or %v and as nanoseconds with %d,
+// times are formatted as RFC3339 with %s or %v.
+// Missing, extra or mistyped arguments are an error.
+// At most 4 arguments are formatted, as functions take at most maxArgs arguments including the format.
+func (sprintf) Call(args ...interface{}) (v interface{}, err error) {
+	if len(args) < 1 {
+		return nil, errors.New("sprintf expects at least one argument")
+	}
+	if len(args) > maxArgs {
+		return nil, fmt.Errorf("sprintf formats at most %d arguments, got %d", maxArgs-1, len(args)-1)
+	}
+	format, ok := args[0].(string)
+	if !ok {
+		err = fmt.Errorf("cannot pass %T as first arg to sprintf, must be string", args[0])
+		return
+	}
+	verbs, err := parseSprintfFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(args)-1)
+	copy(values, args[1:])
+	if len(verbs) != len(values) {
+		return nil, fmt.Errorf("format %q for sprintf expects %d arguments, got %d", format, len(verbs), len(values))
+	}
+	for i, verb := range verbs {
+		t := ast.TypeOf(values[i])
+		if !strings.ContainsRune(sprintfVerbs[t], verb) {
+			return nil, fmt.Errorf("cannot format %s arg %d to sprintf with %%%c", t, i+2, verb)
+		}
+		if tm, ok := values[i].(time.Time); ok {
+			values[i] = tm.Format(time.RFC3339Nano)
+		}
+	}
+	v = fmt.Sprintf(format, values...)
+	return
+}
+
+// parseSprintfFormat returns the verbs of the format in order.
+func parseSprintfFormat(format string) ([]rune, error) {
+	var verbs []rune
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		// Skip flags, width and precision
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i == len(format) {
+			return nil, fmt.Errorf("format %q for sprintf ends with an incomplete verb", format)
+		}
+		switch format[i] {
+		case '%':
+		case '*', '[':
+			return nil, fmt.Errorf("format %q for sprintf uses * or explicit argument indexes, which are not supported", format)
+		default:
+			verbs = append(verbs, rune(format[i]))
+		}
+	}
+	return verbs, nil
+}
+
+var sprintfFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Sprintf Function Signature
+func init() {
+	types := []ast.ValueType{
+		ast.TFloat,
+		ast.TInt,
+		ast.TString,
+		ast.TBool,
+		ast.TTime,
+		ast.TDuration,
+	}
+	var add func(d Domain, n int)
+	add = func(d Domain, n int) {
+		sprintfFuncSignature[d] = ast.TString
+		if n == maxArgs {
+			return
+		}
+		for _, t := range types {
+			d[n] = t
+			add(d, n+1)
+		}
+	}
+	d := Domain{}
+	d[0] = ast.TString
+	add(d, 1)
+}
+
+func (sprintf) Signature() map[Domain]ast.ValueType {
+	return sprintfFuncSignature
+}
+
+func (sprintf) Reset() {}
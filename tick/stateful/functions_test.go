@@ -2,10 +2,13 @@ package stateful
 
 import (
 	"errors"
+	"math"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/influxdata/kapacitor/tick/ast"
 	"github.com/zeebo/mwc"
 )
 
@@ -527,6 +530,16 @@ func Test_If(t *testing.T) {
 			args: []interface{}{false, 1, "1"},
 			err:  errors.New("Different return types are not supported - second argument is int and third argument is string"),
 		},
+
+		// missing branch not returned
+		{
+			args: []interface{}{false, ast.MissingValue, 2},
+			exp:  2,
+		},
+		{
+			args: []interface{}{true, ast.MissingValue, 2},
+			err:  errors.New("second argument to if is missing"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -550,6 +563,74 @@ func Test_If(t *testing.T) {
 	}
 }
 
+func Test_ExistsIsNull(t *testing.T) {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		exists bool
+		isNull bool
+	}{
+		{name: "value", value: 1.0, exists: true},
+		{name: "null", value: nil, isNull: true},
+		{name: "missing", value: ast.MissingValue},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scope := NewScope()
+			scope.Set("value", tc.value)
+			for fn, exp := range map[string]bool{"exists": tc.exists, "isNull": tc.isNull} {
+				se, err := NewExpression(&ast.FunctionNode{
+					Func: fn,
+					Args: []ast.Node{&ast.ReferenceNode{Reference: "value"}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := se.EvalBool(scope)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != exp {
+					t.Errorf("unexpected result from %s(\"value\") got %v exp %v", fn, got, exp)
+				}
+			}
+		})
+	}
+
+	// The default is only evaluated when the value is missing.
+	scope := NewScope()
+	scope.Set("value", ast.MissingValue)
+	se, err := NewExpression(&ast.FunctionNode{
+		Func: "if",
+		Args: []ast.Node{
+			&ast.FunctionNode{Func: "exists", Args: []ast.Node{&ast.ReferenceNode{Reference: "value"}}},
+			&ast.ReferenceNode{Reference: "value"},
+			&ast.NumberNode{IsFloat: true, Float64: 42},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []interface{}{ast.MissingValue, 1.0} {
+		scope.Set("value", value)
+		got, err := se.EvalFloat(scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := 42.0
+		if value != ast.MissingValue {
+			exp = 1.0
+		}
+		if got != exp {
+			t.Errorf("unexpected result from if(exists(\"value\"), \"value\", 42.0) got %v exp %v", got, exp)
+		}
+	}
+
+	if _, err := NewEvalFunctionNode(&ast.FunctionNode{Func: "exists", Args: []ast.Node{&ast.StringNode{Literal: "value"}}}); err == nil {
+		t.Error("expected error passing a string to exists")
+	}
+}
+
 func Test_StatelessFuncs(t *testing.T) {
 
 	testCases := []struct {
@@ -888,6 +969,186 @@ func Test_StatelessFuncs(t *testing.T) {
 			args: []interface{}{""},
 			err:  errors.New("regexReplace expects exactly three arguments"),
 		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(\w+) status=(\d+)`), "ts=1 user=bob status=404", int64(2)},
+			exp:  "404",
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(\w+) status=(\d+)`), "ts=1 user=bob status=404", int64(0)},
+			exp:  "user=bob status=404",
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(?P<user>\w+)`), "ts=1 user=bob status=404", "user"},
+			exp:  "bob",
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(?P<user>\w+)`), "ts=1 status=404", "user"},
+			exp:  "",
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(\w+)`), "user=bob", int64(2)},
+			err:  errors.New("regex user=(\\w+) has no group 2"),
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{regexp.MustCompile(`user=(?P<user>\w+)`), "user=bob", "name"},
+			err:  errors.New(`regex user=(?P<user>\w+) has no group named "name"`),
+		},
+		{
+			name: "regexExtract",
+			args: []interface{}{""},
+			err:  errors.New("regexExtract expects exactly three arguments"),
+		},
+		{
+			name: "bitAnd",
+			args: []interface{}{int64(0xF0F), int64(0x0FF)},
+			exp:  int64(0x00F),
+		},
+		{
+			name: "bitAnd",
+			args: []interface{}{int64(-1), int64(0x10)},
+			exp:  int64(0x10),
+		},
+		{
+			name: "bitAnd",
+			args: []interface{}{1.0, int64(1)},
+			err:  errors.New("cannot pass float64 as first arg to bitAnd, must be int64"),
+		},
+		{
+			name: "bitOr",
+			args: []interface{}{int64(0xF00), int64(0x00F)},
+			exp:  int64(0xF0F),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(0x3), int64(4)},
+			exp:  int64(0x30),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(0x30), int64(-4)},
+			exp:  int64(0x3),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(-16), int64(-2)},
+			exp:  int64(-4),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(1), int64(63)},
+			exp:  int64(math.MinInt64),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(1), int64(64)},
+			exp:  int64(0),
+		},
+		{
+			name: "bitShift",
+			args: []interface{}{int64(-1), int64(-100)},
+			exp:  int64(-1),
+		},
+		{
+			name: "bitTest",
+			args: []interface{}{int64(0x4), int64(2)},
+			exp:  true,
+		},
+		{
+			name: "bitTest",
+			args: []interface{}{int64(0x4), int64(1)},
+			exp:  false,
+		},
+		{
+			name: "bitTest",
+			args: []interface{}{int64(-1), int64(63)},
+			exp:  true,
+		},
+		{
+			name: "bitTest",
+			args: []interface{}{int64(1), int64(64)},
+			err:  errors.New("bit 64 out of range for bitTest, must be within [0,63]"),
+		},
+		{
+			name: "timeDiff",
+			args: []interface{}{time.Date(2020, 1, 1, 0, 1, 30, 0, time.UTC), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			exp:  90 * time.Second,
+		},
+		{
+			name: "timeDiff",
+			args: []interface{}{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)},
+			exp:  -time.Minute,
+		},
+		{
+			name: "timeDiff",
+			args: []interface{}{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			err:  errors.New("timeDiff expects exactly two arguments"),
+		},
+		{
+			name: "parseInt",
+			args: []interface{}{"ff", int64(16)},
+			exp:  int64(255),
+		},
+		{
+			name: "parseInt",
+			args: []interface{}{"-101", int64(2)},
+			exp:  int64(-5),
+		},
+		{
+			name: "parseInt",
+			args: []interface{}{"0x1F", int64(0)},
+			exp:  int64(31),
+		},
+		{
+			name: "parseInt",
+			args: []interface{}{"ffffffffffffffff", int64(16)},
+			err:  errors.New(`strconv.ParseInt: parsing "ffffffffffffffff": value out of range`),
+		},
+		{
+			name: "parseInt",
+			args: []interface{}{"10", int64(1)},
+			err:  errors.New("invalid base 1 for parseInt, must be 0 or within [2,36]"),
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"%s on %s: %.1f%% (%d cores)", "cpu", "serverA", 93.25, int64(8)},
+			exp:  "cpu on serverA: 93.2% (8 cores)",
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"%s on %s: %.1f%% (%d cores, busy: %t)", "cpu", "serverA", 93.25, int64(8), true},
+			err:  errors.New("sprintf formats at most 4 arguments, got 5"),
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"down for %v since %s", time.Minute + 30*time.Second, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+			exp:  "down for 1m30s since 2021-01-02T03:04:05Z",
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"no verbs"},
+			exp:  "no verbs",
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"%s and %s", "one"},
+			err:  errors.New(`format "%s and %s" for sprintf expects 2 arguments, got 1`),
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"%d", "one"},
+			err:  errors.New("cannot format string arg 2 to sprintf with %d"),
+		},
+		{
+			name: "sprintf",
+			args: []interface{}{"%*d", int64(3), int64(1)},
+			err:  errors.New(`format "%*d" for sprintf uses * or explicit argument indexes, which are not supported`),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -916,6 +1177,113 @@ func Test_StatelessFuncs(t *testing.T) {
 
 }
 
+func Test_Haversine(t *testing.T) {
+	testCases := []struct {
+		args []interface{}
+		exp  float64
+		err  error
+	}{
+		// London to Paris
+		{args: []interface{}{51.5074, -0.1278, 48.8566, 2.3522}, exp: 343556.53},
+		{args: []interface{}{51.5074, -0.1278, 48.8566, 2.3522, "m"}, exp: 343556.53},
+		{args: []interface{}{51.5074, -0.1278, 48.8566, 2.3522, "km"}, exp: 343.56},
+		{args: []interface{}{51.5074, -0.1278, 48.8566, 2.3522, "mi"}, exp: 213.48},
+		{args: []interface{}{10.0, 20.0, 10.0, 20.0}, exp: 0},
+		{args: []interface{}{10.0, 20.0, 10.0}, err: errors.New("haversine expects four or five arguments")},
+		{args: []interface{}{10.0, 20.0, 10.0, "20"}, err: errors.New("cannot pass string to haversine, must be float64")},
+		{args: []interface{}{10.0, 20.0, 10.0, 20.0, "ft"}, err: errors.New("invalid unit \"ft\" for haversine, must be one of 'm', 'km' or 'mi'")},
+	}
+	f := statelessFuncs["haversine"]
+	for _, tc := range testCases {
+		result, err := f.Call(tc.args...)
+		if tc.err != nil {
+			if err == nil {
+				t.Errorf("expected error from haversine(%v) got nil exp %s", tc.args, tc.err)
+			} else if got, exp := err.Error(), tc.err.Error(); got != exp {
+				t.Errorf("unexpected error from haversine(%v) got %s exp %s", tc.args, got, exp)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("unexpected error from haversine(%v) %s", tc.args, err)
+			continue
+		}
+		if got := math.Round(result.(float64)*100) / 100; got != tc.exp {
+			t.Errorf("unexpected result from haversine(%v) got %v exp %v", tc.args, got, tc.exp)
+		}
+	}
+}
+
+func Test_DistanceFromLast(t *testing.T) {
+	f := NewFunctions()["distanceFromLast"]
+	testCases := []struct {
+		args []interface{}
+		exp  float64
+	}{
+		{args: []interface{}{0.0, 0.0}, exp: 0},
+		{args: []interface{}{0.0, 1.0}, exp: 111195.08},
+		{args: []interface{}{0.0, 1.0, "km"}, exp: 0},
+		{args: []interface{}{1.0, 1.0, "km"}, exp: 111.2},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.args...)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := math.Round(result.(float64)*100) / 100; got != tc.exp {
+			t.Errorf("%d: unexpected result from distanceFromLast(%v) got %v exp %v", i, tc.args, got, tc.exp)
+		}
+	}
+
+	// After a reset there is no previous location
+	f.Reset()
+	result, err := f.Call(50.0, 50.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != 0.0 {
+		t.Errorf("unexpected result after reset got %v exp 0", result)
+	}
+}
+
+func Test_Accumulate(t *testing.T) {
+	f := NewFunctions()["accumulate"]
+	testCases := []struct {
+		increment, reset bool
+		exp              int64
+	}{
+		{increment: true, exp: 1},
+		{increment: false, exp: 1},
+		{increment: true, exp: 2},
+		{increment: true, reset: true, exp: 0},
+		{increment: true, exp: 1},
+		{reset: true, exp: 0},
+		{exp: 0},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.increment, tc.reset)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(int64); got != tc.exp {
+			t.Errorf("%d: unexpected result from accumulate(%v, %v) got %d exp %d", i, tc.increment, tc.reset, got, tc.exp)
+		}
+	}
+
+	f.Call(true, false)
+	f.Reset()
+	result, err := f.Call(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != int64(0) {
+		t.Errorf("unexpected result after reset got %v exp 0", result)
+	}
+
+	if _, err := f.Call(1.0, false); err == nil {
+		t.Error("expected error passing a float as the increment condition")
+	}
+}
+
 func Test_Rand_zeros(t *testing.T) {
 	f := NewRand()
 	// seed with a known value to force determinism.
@@ -958,3 +1326,53 @@ func Test_Rand_zeros(t *testing.T) {
 		}
 	}
 }
+
+func Test_Lookup(t *testing.T) {
+	f := NewFunctions()["lookup"]
+	table := `{"us": 1, "eu": 2}`
+	testCases := []struct {
+		args []interface{}
+		exp  interface{}
+	}{
+		{args: []interface{}{"us", table, int64(0)}, exp: int64(1)},
+		{args: []interface{}{"eu", table, int64(0)}, exp: int64(2)},
+		{args: []interface{}{"asia", table, int64(-1)}, exp: int64(-1)},
+		{args: []interface{}{"eu", table, 0.5}, exp: 2.0},
+		{args: []interface{}{"b", `{"a": "x", "b": "y"}`, ""}, exp: "y"},
+		{args: []interface{}{"a", `{"a": true}`, false}, exp: true},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.args...)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if result != tc.exp {
+			t.Errorf("%d: unexpected result from lookup(%v) got %v(%T) exp %v(%T)", i, tc.args, result, result, tc.exp, tc.exp)
+		}
+	}
+}
+
+func Test_Lookup_Errors(t *testing.T) {
+	testCases := []struct {
+		args []interface{}
+		err  string
+	}{
+		{args: []interface{}{"us", `[1, 2]`, int64(0)}, err: "invalid lookup table, must be a JSON object"},
+		{args: []interface{}{"us", `{"us": 1.5}`, int64(0)}, err: `invalid value of key "us" in lookup table`},
+		{args: []interface{}{"us", `{"us": "a"}`, 0.0}, err: `invalid value of key "us" in lookup table: expected float value, got a`},
+		{args: []interface{}{"us", `{"us": 1}`, ""}, err: `invalid value of key "us" in lookup table: expected string value, got 1`},
+		{args: []interface{}{int64(1), `{}`, ""}, err: "first argument to lookup must be a string key"},
+		{args: []interface{}{"us", `{}`}, err: "lookup expects exactly three arguments"},
+	}
+	for i, tc := range testCases {
+		f := NewFunctions()["lookup"]
+		_, err := f.Call(tc.args...)
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %q exp prefix %q", i, err.Error(), tc.err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package stateful
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Encodings of the digests of the hash functions.
+const (
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+)
+
+var (
+	hmacKeysMu sync.RWMutex
+	// hmacKeys are the keys of the hmac function by name.
+	hmacKeys map[string][]byte
+)
+
+// SetHMACKeys sets the named keys of the hmac function.
+// The keys come from the configuration, so that they can be secret references instead of being part of the tasks.
+func SetHMACKeys(keys map[string]string) {
+	k := make(map[string][]byte, len(keys))
+	for name, key := range keys {
+		k[name] = []byte(key)
+	}
+	hmacKeysMu.Lock()
+	defer hmacKeysMu.Unlock()
+	hmacKeys = k
+}
+
+// lookupHMACKey returns the key of the name, or an error if it is not configured.
+func lookupHMACKey(name string) ([]byte, error) {
+	hmacKeysMu.RLock()
+	defer hmacKeysMu.RUnlock()
+	key, ok := hmacKeys[name]
+	if !ok {
+		names := make([]string, 0, len(hmacKeys))
+		for n := range hmacKeys {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown hmac key %q, configured keys are [%s]", name, strings.Join(names, ", "))
+	}
+	return key, nil
+}
+
+// hashInput returns the bytes hashed for a value, ints are hashed as their decimal representation.
+func hashInput(name string, v interface{}) ([]byte, error) {
+	switch a := v.(type) {
+	case string:
+		return []byte(a), nil
+	case int64:
+		return strconv.AppendInt(nil, a, 10), nil
+	default:
+		return nil, fmt.Errorf("cannot hash %T with %s, must be string or int", v, name)
+	}
+}
+
+// encodeDigest encodes the digest as hex, unless the encoding is base64.
+func encodeDigest(digest []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", encodingHex:
+		return hex.EncodeToString(digest), nil
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(digest), nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q, must be one of %s or %s", encoding, encodingHex, encodingBase64)
+	}
+}
+
+// validateEncodingArg checks that the encoding argument is a string literal of a known encoding.
+func validateEncodingArg(name string, arg ast.Node) error {
+	s, ok := arg.(*ast.StringNode)
+	if !ok {
+		return fmt.Errorf("the encoding of %s must be a string literal", name)
+	}
+	_, err := encodeDigest(nil, s.Literal)
+	return err
+}
+
+// hashFunc returns the digest of a value,
+// for example sha256("user_id") or md5("user_id", 'base64').
+// The digest is hex encoded unless base64 is given as the encoding.
+type hashFunc struct {
+	name string
+	new  func() hash.Hash
+}
+
+func (hashFunc) Reset() {}
+
+func (f hashFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("%s expects one or two arguments", f.name)
+	}
+	in, err := hashInput(f.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	var encoding string
+	if len(args) == 2 {
+		var ok bool
+		if encoding, ok = args[1].(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as second arg to %s, must be string", args[1], f.name)
+		}
+	}
+	h := f.new()
+	h.Write(in)
+	return encodeDigest(h.Sum(nil), encoding)
+}
+
+// ValidateArgs checks that the encoding, if any, is a string literal of a known encoding.
+func (f hashFunc) ValidateArgs(args []ast.Node) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%s expects one or two arguments", f.name)
+	}
+	if len(args) == 2 {
+		return validateEncodingArg(f.name, args[1])
+	}
+	return nil
+}
+
+var hashFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Hash Function Signature
+func init() {
+	for _, t := range []ast.ValueType{ast.TString, ast.TInt} {
+		d := Domain{}
+		d[0] = t
+		hashFuncSignature[d] = ast.TString
+		d[1] = ast.TString
+		hashFuncSignature[d] = ast.TString
+	}
+}
+
+func (hashFunc) Signature() map[Domain]ast.ValueType {
+	return hashFuncSignature
+}
+
+// hmacFunc returns the HMAC-SHA256 of a value with a key of the configuration,
+// for example hmac("user_id", 'users') or hmac("user_id", 'users', 'base64').
+// The key is referenced by name so that it is not part of the task.
+// The digest is hex encoded unless base64 is given as the encoding.
+type hmacFunc struct {
+}
+
+func (hmacFunc) Reset() {}
+
+func (hmacFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errors.New("hmac expects two or three arguments")
+	}
+	in, err := hashInput("hmac", args[0])
+	if err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to hmac, must be string", args[1])
+	}
+	key, err := lookupHMACKey(name)
+	if err != nil {
+		return nil, err
+	}
+	var encoding string
+	if len(args) == 3 {
+		if encoding, ok = args[2].(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as third arg to hmac, must be string", args[2])
+		}
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(in)
+	return encodeDigest(h.Sum(nil), encoding)
+}
+
+// ValidateArgs checks that the key is a string literal of a configured key
+// and that the encoding, if any, is a string literal of a known encoding.
+func (hmacFunc) ValidateArgs(args []ast.Node) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("hmac expects two or three arguments")
+	}
+	s, ok := args[1].(*ast.StringNode)
+	if !ok {
+		return errors.New("the key of hmac must be a string literal")
+	}
+	if _, err := lookupHMACKey(s.Literal); err != nil {
+		return err
+	}
+	if len(args) == 3 {
+		return validateEncodingArg("hmac", args[2])
+	}
+	return nil
+}
+
+var hmacFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize HMAC Function Signature
+func init() {
+	for _, t := range []ast.ValueType{ast.TString, ast.TInt} {
+		d := Domain{}
+		d[0] = t
+		d[1] = ast.TString
+		hmacFuncSignature[d] = ast.TString
+		d[2] = ast.TString
+		hmacFuncSignature[d] = ast.TString
+	}
+}
+
+func (hmacFunc) Signature() map[Domain]ast.ValueType {
+	return hmacFuncSignature
+}
@@ -0,0 +1,98 @@
+package stateful
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_Hash(t *testing.T) {
+	SetHMACKeys(map[string]string{"users": "secret"})
+	defer SetHMACKeys(nil)
+
+	funcs := NewFunctions()
+	testCases := []struct {
+		f    string
+		args []interface{}
+		exp  string
+	}{
+		{f: "sha256", args: []interface{}{"abc"}, exp: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{f: "sha256", args: []interface{}{"abc", "base64"}, exp: "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0="},
+		{f: "sha256", args: []interface{}{int64(42)}, exp: "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049"},
+		{f: "md5", args: []interface{}{"abc", "hex"}, exp: "900150983cd24fb0d6963f7d28e17f72"},
+		{f: "hmac", args: []interface{}{"alice", "users"}, exp: "4360c67bc81025114044578d7c4e8e0f02fd0cae99f22d603390e8f9dc9888f8"},
+		{f: "hmac", args: []interface{}{int64(42), "users", "base64"}, exp: "k8Eh56pDeh4B48USxvDOPIIag5Al3KRAj4VhbeSq7nA="},
+	}
+	for i, tc := range testCases {
+		result, err := funcs[tc.f].Call(tc.args...)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(string); got != tc.exp {
+			t.Errorf("%d: unexpected result from %s(%v) got %s exp %s", i, tc.f, tc.args, got, tc.exp)
+		}
+	}
+}
+
+func Test_Hash_ValidateArgs(t *testing.T) {
+	SetHMACKeys(map[string]string{"users": "secret"})
+	defer SetHMACKeys(nil)
+
+	value := &ast.ReferenceNode{Reference: "user"}
+	testCases := []struct {
+		f    string
+		args []ast.Node
+		err  string
+	}{
+		{
+			f:    "sha256",
+			args: []ast.Node{value, &ast.StringNode{Literal: "base64"}},
+		},
+		{
+			f:    "md5",
+			args: []ast.Node{value, &ast.StringNode{Literal: "base32"}},
+			err:  `unknown encoding "base32"`,
+		},
+		{
+			f:    "sha256",
+			args: []ast.Node{value, &ast.ReferenceNode{Reference: "encoding"}},
+			err:  "the encoding of sha256 must be a string literal",
+		},
+		{
+			f:    "hmac",
+			args: []ast.Node{value, &ast.StringNode{Literal: "users"}, &ast.StringNode{Literal: "hex"}},
+		},
+		{
+			f:    "hmac",
+			args: []ast.Node{value, &ast.StringNode{Literal: "orders"}},
+			err:  `unknown hmac key "orders", configured keys are [users]`,
+		},
+		{
+			f:    "hmac",
+			args: []ast.Node{value, &ast.ReferenceNode{Reference: "key"}},
+			err:  "the key of hmac must be a string literal",
+		},
+		{
+			f:    "hmac",
+			args: []ast.Node{value},
+			err:  "hmac expects two or three arguments",
+		},
+	}
+	for i, tc := range testCases {
+		_, err := NewEvalFunctionNode(&ast.FunctionNode{Func: tc.f, Args: tc.args})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %q exp %q", i, err.Error(), tc.err)
+		}
+	}
+}
@@ -37,6 +37,7 @@ type NodeEvaluator interface {
 	EvalTime(scope *Scope, executionState ExecutionState) (time.Time, error)
 	EvalDuration(scope *Scope, executionState ExecutionState) (time.Duration, error)
 	EvalMissing(scope *Scope, executionState ExecutionState) (*ast.Missing, error)
+	EvalArray(scope *Scope, executionState ExecutionState) ([]float64, error)
 
 	// Type returns the type of ast.ValueType
 	Type(scope ReadOnlyScope) (ast.ValueType, error)
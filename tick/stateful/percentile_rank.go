@@ -0,0 +1,117 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MaxPercentileRankSize is the largest number of previous values retained by the percentileRank function,
+// which bounds its memory to 8 bytes per value per group.
+const MaxPercentileRankSize = 10000
+
+// percentileRankWarmUp is the rank returned until the window of previous values is full,
+// so that thresholds on either side of the median do not fire during the warm-up.
+const percentileRankWarmUp = 0.5
+
+// percentileRank computes the percentile rank, from 0 to 1, of a value among the previous values of the group,
+// for example percentileRank("value", 100) ranks the value among the 100 previous values.
+//
+// The previous values are a sliding window of the last size values, so the rank is deterministic
+// and reflects the recent distribution only. Equal values count as half below the value,
+// so a value equal to all the previous values has a rank of 0.5.
+// Until the window holds size values the rank is 0.5.
+type percentileRank struct {
+	// values is a ring buffer of the previous values, next is the index of the oldest value once it is full.
+	values []float64
+	next   int
+}
+
+func (p *percentileRank) Reset() {
+	p.values = p.values[:0]
+	p.next = 0
+}
+
+func (p *percentileRank) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("percentileRank expects exactly two arguments")
+	}
+	var v float64
+	switch a := args[0].(type) {
+	case float64:
+		v = a
+	case int64:
+		v = float64(a)
+	default:
+		return nil, fmt.Errorf("cannot pass %T as first arg to percentileRank, must be float or int", args[0])
+	}
+	size, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to percentileRank, must be int", args[1])
+	}
+	if err := validatePercentileRankSize(size); err != nil {
+		return nil, err
+	}
+
+	rank := percentileRankWarmUp
+	if len(p.values) == int(size) {
+		var below float64
+		for _, prev := range p.values {
+			switch {
+			case prev < v:
+				below++
+			case prev == v:
+				below += 0.5
+			}
+		}
+		rank = below / float64(len(p.values))
+	}
+	p.add(v, int(size))
+	return rank, nil
+}
+
+// add adds the value to the window, replacing the oldest value once the window is full.
+func (p *percentileRank) add(v float64, size int) {
+	if len(p.values) < size {
+		p.values = append(p.values, v)
+		return
+	}
+	p.values[p.next] = v
+	p.next = (p.next + 1) % size
+}
+
+func validatePercentileRankSize(size int64) error {
+	if size < 1 || size > MaxPercentileRankSize {
+		return fmt.Errorf("percentileRank size must be between 1 and %d, got %d", MaxPercentileRankSize, size)
+	}
+	return nil
+}
+
+// ValidateArgs checks that the size is an int literal within the bounds, so that the memory of the function is bounded.
+func (p *percentileRank) ValidateArgs(args []ast.Node) error {
+	if len(args) != 2 {
+		return errors.New("percentileRank expects exactly two arguments")
+	}
+	n, ok := args[1].(*ast.NumberNode)
+	if !ok || !n.IsInt {
+		return errors.New("the size of percentileRank must be an int literal")
+	}
+	return validatePercentileRankSize(n.Int64)
+}
+
+var percentileRankFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize PercentileRank Function Signature
+func init() {
+	d := Domain{}
+	d[1] = ast.TInt
+	for _, t := range []ast.ValueType{ast.TFloat, ast.TInt} {
+		d[0] = t
+		percentileRankFuncSignature[d] = ast.TFloat
+	}
+}
+
+func (p *percentileRank) Signature() map[Domain]ast.ValueType {
+	return percentileRankFuncSignature
+}
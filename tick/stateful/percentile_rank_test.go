@@ -0,0 +1,92 @@
+package stateful
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_PercentileRank(t *testing.T) {
+	f := NewFunctions()["percentileRank"]
+	testCases := []struct {
+		value interface{}
+		exp   float64
+	}{
+		// Warm-up until 4 values are retained
+		{value: 1.0, exp: 0.5},
+		{value: 2.0, exp: 0.5},
+		{value: int64(3), exp: 0.5},
+		{value: 4.0, exp: 0.5},
+		// Window 1, 2, 3, 4
+		{value: 10.0, exp: 1},
+		// Window 2, 3, 4, 10
+		{value: 0.0, exp: 0},
+		// Window 3, 4, 10, 0
+		{value: 4.0, exp: 0.625},
+		// Window 4, 10, 0, 4
+		{value: 5.0, exp: 0.75},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.value, int64(4))
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(float64); got != tc.exp {
+			t.Errorf("%d: unexpected result from percentileRank(%v, 4) got %v exp %v", i, tc.value, got, tc.exp)
+		}
+	}
+
+	f.Reset()
+	result, err := f.Call(100.0, int64(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.(float64); got != percentileRankWarmUp {
+		t.Errorf("unexpected result after reset got %v exp %v", got, percentileRankWarmUp)
+	}
+}
+
+func Test_PercentileRank_ValidateArgs(t *testing.T) {
+	value := &ast.ReferenceNode{Reference: "value"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{
+			args: []ast.Node{value, &ast.NumberNode{IsInt: true, Int64: 100}},
+		},
+		{
+			args: []ast.Node{value, &ast.NumberNode{IsInt: true, Int64: 0}},
+			err:  "percentileRank size must be between 1 and 10000, got 0",
+		},
+		{
+			args: []ast.Node{value, &ast.NumberNode{IsInt: true, Int64: 20000}},
+			err:  "percentileRank size must be between 1 and 10000, got 20000",
+		},
+		{
+			args: []ast.Node{value, &ast.ReferenceNode{Reference: "size"}},
+			err:  "the size of percentileRank must be an int literal",
+		},
+		{
+			args: []ast.Node{value},
+			err:  "percentileRank expects exactly two arguments",
+		},
+	}
+	for i, tc := range testCases {
+		_, err := NewEvalFunctionNode(&ast.FunctionNode{Func: "percentileRank", Args: tc.args})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %q exp %q", i, err.Error(), tc.err)
+		}
+	}
+}
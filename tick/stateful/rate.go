@@ -0,0 +1,162 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Reset policies of the rate function, what a decrease of the value means.
+const (
+	// RateResetCounter treats a decrease as a counter reset, the value counted up from zero since the previous point.
+	RateResetCounter = "counter"
+	// RateResetNull returns null for a decrease, like the nonNegative derivative.
+	RateResetNull = "null"
+	// RateResetNone returns the negative rate of a decrease.
+	RateResetNone = "none"
+)
+
+// rate is the rate of change of a value per unit of time since the previous point,
+// for example rate("requests", "time", 1s, 'counter', 5m).
+// It only keeps the previous value and time, so the state per group is constant in size.
+//
+// The rate is null, i.e. NaN handled by the nanPolicy of eval, for the first point,
+// when the time since the previous point is more than maxGap, and for a decrease with the null reset policy.
+type rate struct {
+	value   float64
+	time    time.Time
+	hasLast bool
+}
+
+func (r *rate) Reset() {
+	r.value = 0
+	r.time = time.Time{}
+	r.hasLast = false
+}
+
+func (r *rate) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 2 || len(args) > 5 {
+		return nil, errors.New("rate expects two to five arguments")
+	}
+	var value float64
+	switch a := args[0].(type) {
+	case float64:
+		value = a
+	case int64:
+		value = float64(a)
+	default:
+		return nil, fmt.Errorf("cannot pass %T as first arg to rate, must be float or int", args[0])
+	}
+	t, ok := args[1].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to rate, must be time", args[1])
+	}
+	unit := time.Second
+	if len(args) > 2 {
+		if unit, ok = args[2].(time.Duration); !ok {
+			return nil, fmt.Errorf("cannot pass %T as third arg to rate, must be duration", args[2])
+		}
+		if unit <= 0 {
+			return nil, fmt.Errorf("the unit of rate must be positive, got %v", unit)
+		}
+	}
+	policy := RateResetCounter
+	if len(args) > 3 {
+		if policy, ok = args[3].(string); !ok {
+			return nil, fmt.Errorf("cannot pass %T as fourth arg to rate, must be string", args[3])
+		}
+		if err := validateRateResetPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+	var maxGap time.Duration
+	if len(args) > 4 {
+		if maxGap, ok = args[4].(time.Duration); !ok {
+			return nil, fmt.Errorf("cannot pass %T as fifth arg to rate, must be duration", args[4])
+		}
+	}
+
+	if !r.hasLast {
+		r.value, r.time, r.hasLast = value, t, true
+		return math.NaN(), nil
+	}
+	elapsed := t.Sub(r.time)
+	if elapsed <= 0 {
+		// The point is not after the previous point, keep the previous point.
+		return math.NaN(), nil
+	}
+	previous := r.value
+	r.value, r.time = value, t
+	if maxGap > 0 && elapsed > maxGap {
+		// The point starts over after the gap, a decrease across a gap is not a reset.
+		return math.NaN(), nil
+	}
+	diff := value - previous
+	if diff < 0 {
+		switch policy {
+		case RateResetCounter:
+			diff = value
+		case RateResetNull:
+			return math.NaN(), nil
+		}
+	}
+	return diff / (float64(elapsed) / float64(unit)), nil
+}
+
+func validateRateResetPolicy(policy string) error {
+	switch policy {
+	case RateResetCounter, RateResetNull, RateResetNone:
+		return nil
+	}
+	return fmt.Errorf("invalid reset policy %q of rate, must be one of %s, %s or %s", policy, RateResetCounter, RateResetNull, RateResetNone)
+}
+
+// ValidateArgs checks the unit, the reset policy and the max gap which are literals.
+func (r *rate) ValidateArgs(args []ast.Node) error {
+	if len(args) < 2 || len(args) > 5 {
+		return errors.New("rate expects two to five arguments")
+	}
+	if len(args) > 2 {
+		if d, ok := args[2].(*ast.DurationNode); ok && d.Dur <= 0 {
+			return fmt.Errorf("the unit of rate must be positive, got %v", d.Dur)
+		}
+	}
+	if len(args) > 3 {
+		if s, ok := args[3].(*ast.StringNode); ok {
+			if err := validateRateResetPolicy(s.Literal); err != nil {
+				return err
+			}
+		}
+	}
+	if len(args) > 4 {
+		if d, ok := args[4].(*ast.DurationNode); ok && d.Dur < 0 {
+			return fmt.Errorf("the max gap of rate must not be negative, got %v", d.Dur)
+		}
+	}
+	return nil
+}
+
+var rateFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Rate Function Signature
+func init() {
+	for _, t := range []ast.ValueType{ast.TFloat, ast.TInt} {
+		d := Domain{}
+		d[0] = t
+		d[1] = ast.TTime
+		rateFuncSignature[d] = ast.TFloat
+		d[2] = ast.TDuration
+		rateFuncSignature[d] = ast.TFloat
+		d[3] = ast.TString
+		rateFuncSignature[d] = ast.TFloat
+		d[4] = ast.TDuration
+		rateFuncSignature[d] = ast.TFloat
+	}
+}
+
+func (r *rate) Signature() map[Domain]ast.ValueType {
+	return rateFuncSignature
+}
@@ -0,0 +1,142 @@
+package stateful
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_Rate(t *testing.T) {
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	nan := math.NaN()
+	type point struct {
+		value interface{}
+		t     time.Duration
+		exp   float64
+	}
+	testCases := []struct {
+		name   string
+		args   []interface{}
+		points []point
+	}{
+		{
+			name: "default",
+			points: []point{
+				{value: 10.0, t: 0, exp: nan},
+				{value: 20.0, t: 10 * time.Second, exp: 1},
+				{value: int64(50), t: 20 * time.Second, exp: 3},
+				// The counter was reset, it counted 5 from zero.
+				{value: 5.0, t: 25 * time.Second, exp: 1},
+			},
+		},
+		{
+			name: "unit",
+			args: []interface{}{time.Minute},
+			points: []point{
+				{value: 10.0, t: 0, exp: nan},
+				{value: 20.0, t: 10 * time.Second, exp: 60},
+			},
+		},
+		{
+			name: "reset null",
+			args: []interface{}{time.Second, RateResetNull},
+			points: []point{
+				{value: 10.0, t: 0, exp: nan},
+				{value: 5.0, t: 5 * time.Second, exp: nan},
+				{value: 15.0, t: 10 * time.Second, exp: 2},
+			},
+		},
+		{
+			name: "reset none",
+			args: []interface{}{time.Second, RateResetNone},
+			points: []point{
+				{value: 10.0, t: 0, exp: nan},
+				{value: 5.0, t: 5 * time.Second, exp: -1},
+			},
+		},
+		{
+			name: "max gap",
+			args: []interface{}{time.Second, RateResetCounter, 30 * time.Second},
+			points: []point{
+				{value: 10.0, t: 0, exp: nan},
+				{value: 40.0, t: 30 * time.Second, exp: 1},
+				// A decrease across the gap is not a reset.
+				{value: 5.0, t: 2 * time.Minute, exp: nan},
+				// The point after the gap is the previous point.
+				{value: 15.0, t: 2*time.Minute + 10*time.Second, exp: 1},
+			},
+		},
+		{
+			name: "not after previous",
+			points: []point{
+				{value: 10.0, t: 10 * time.Second, exp: nan},
+				{value: 0.0, t: 5 * time.Second, exp: nan},
+				{value: 20.0, t: 10 * time.Second, exp: nan},
+				{value: 30.0, t: 20 * time.Second, exp: 2},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		f := NewFunctions()["rate"]
+		for i, p := range tc.points {
+			args := append([]interface{}{p.value, start.Add(p.t)}, tc.args...)
+			result, err := f.Call(args...)
+			if err != nil {
+				t.Fatalf("%s %d: unexpected error %v", tc.name, i, err)
+			}
+			got := result.(float64)
+			if math.IsNaN(p.exp) {
+				if !math.IsNaN(got) {
+					t.Errorf("%s %d: unexpected rate got %v exp null", tc.name, i, got)
+				}
+			} else if got != p.exp {
+				t.Errorf("%s %d: unexpected rate got %v exp %v", tc.name, i, got, p.exp)
+			}
+		}
+	}
+
+	f := NewFunctions()["rate"]
+	f.Call(1.0, start)
+	f.Reset()
+	if result, err := f.Call(2.0, start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	} else if !math.IsNaN(result.(float64)) {
+		t.Errorf("unexpected rate after reset got %v exp null", result)
+	}
+
+	if _, err := f.Call(1.0, start, time.Second, "restart"); err == nil || !strings.Contains(err.Error(), "invalid reset policy") {
+		t.Errorf("unexpected error for an invalid reset policy %v", err)
+	}
+}
+
+func Test_Rate_ValidateArgs(t *testing.T) {
+	value := &ast.ReferenceNode{Reference: "value"}
+	tm := &ast.ReferenceNode{Reference: "time"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{args: []ast.Node{value, tm}},
+		{args: []ast.Node{value, tm, &ast.DurationNode{Dur: time.Minute}, &ast.StringNode{Literal: "null"}, &ast.DurationNode{Dur: time.Hour}}},
+		{args: []ast.Node{value}, err: "two to five arguments"},
+		{args: []ast.Node{value, tm, &ast.DurationNode{Dur: 0}}, err: "unit of rate must be positive"},
+		{args: []ast.Node{value, tm, &ast.DurationNode{Dur: time.Second}, &ast.StringNode{Literal: "restart"}}, err: `invalid reset policy "restart"`},
+		{args: []ast.Node{value, tm, &ast.DurationNode{Dur: time.Second}, &ast.StringNode{Literal: "none"}, &ast.DurationNode{Dur: -time.Second}}, err: "max gap of rate must not be negative"},
+	}
+	f := NewFunctions()["rate"].(ArgsValidator)
+	for i, tc := range testCases {
+		err := f.ValidateArgs(tc.args)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %v exp %q", i, err, tc.err)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package stateful
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/influxdata/kapacitor/tick/ast"
 )
@@ -33,15 +37,197 @@ type Scope struct {
 
 	dynamicMethods map[string]DynamicMethod
 	dynamicFuncs   map[string]*DynamicFunc
+
+	// Tags of the data being evaluated, accessed with the tag function.
+	tags map[string]string
+
+	// Time returned by the now function, the wall clock time when zero.
+	now time.Time
 }
 
 // Initialize a new Scope object.
 func NewScope() *Scope {
-	return &Scope{
+	s := &Scope{
 		variables:      make(map[string]interface{}),
 		dynamicMethods: make(map[string]DynamicMethod),
 		dynamicFuncs:   make(map[string]*DynamicFunc),
 	}
+	s.dynamicFuncs[tagFuncName] = &DynamicFunc{
+		F:   s.tag,
+		Sig: tagFuncSignature,
+	}
+	s.dynamicFuncs[tagCountFuncName] = &DynamicFunc{
+		F:   s.tagCount,
+		Sig: tagCountFuncSignature,
+	}
+	s.dynamicFuncs[tagKeysFuncName] = &DynamicFunc{
+		F:   s.tagKeys,
+		Sig: tagsJoinFuncSignature,
+	}
+	s.dynamicFuncs[tagsStringFuncName] = &DynamicFunc{
+		F:   s.tagsString,
+		Sig: tagsJoinFuncSignature,
+	}
+	s.dynamicFuncs[hasTagMatchingFuncName] = &DynamicFunc{
+		F:   s.hasTagMatching,
+		Sig: hasTagMatchingFuncSignature,
+	}
+	s.dynamicFuncs[nowFuncName] = &DynamicFunc{
+		F:   s.nowFunc,
+		Sig: nowFuncSignature,
+	}
+	return s
+}
+
+const tagFuncName = "tag"
+
+var tagFuncSignature = map[Domain]ast.ValueType{
+	{ast.TString}: ast.TString,
+}
+
+// tag returns the value of the tag named by the single argument,
+// or an empty string if the tag does not exist.
+// It is a map lookup on the tags of the scope and does not allocate,
+// so it is cheap enough to use on hot paths.
+func (s *Scope) tag(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("tag expects exactly one argument, got %d", len(args))
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("tag expects a string argument, got %T", args[0])
+	}
+	return s.tags[name], nil
+}
+
+const (
+	tagCountFuncName       = "tagCount"
+	tagKeysFuncName        = "tagKeys"
+	tagsStringFuncName     = "tagsString"
+	hasTagMatchingFuncName = "hasTagMatching"
+)
+
+var tagCountFuncSignature = map[Domain]ast.ValueType{
+	{}: ast.TInt,
+}
+
+// tagsJoinFuncSignature is the signature of the functions joining the tags,
+// with an optional separator.
+var tagsJoinFuncSignature = map[Domain]ast.ValueType{
+	{}:            ast.TString,
+	{ast.TString}: ast.TString,
+}
+
+var hasTagMatchingFuncSignature = map[Domain]ast.ValueType{
+	{ast.TRegex}: ast.TBool,
+}
+
+// tagCount returns the number of tags of the scope.
+func (s *Scope) tagCount(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, errors.New("tagCount expects exactly zero argument")
+	}
+	return int64(len(s.tags)), nil
+}
+
+// tagKeys returns the sorted keys of the tags of the scope,
+// joined by the optional separator argument, which defaults to a comma.
+func (s *Scope) tagKeys(args ...interface{}) (interface{}, error) {
+	sep, err := tagsSeparator(tagKeysFuncName, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Join(s.sortedTagKeys(), sep), nil
+}
+
+// tagsString returns the key=value pairs of the tags of the scope sorted by key,
+// joined by the optional separator argument, which defaults to a comma.
+// Keys and values are not escaped, so the string is only unambiguous
+// if they do not contain the separator or an equal sign.
+func (s *Scope) tagsString(args ...interface{}) (interface{}, error) {
+	sep, err := tagsSeparator(tagsStringFuncName, args)
+	if err != nil {
+		return nil, err
+	}
+	keys := s.sortedTagKeys()
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + s.tags[k]
+	}
+	return strings.Join(pairs, sep), nil
+}
+
+// hasTagMatching returns whether the key of any tag of the scope matches the regex argument.
+func (s *Scope) hasTagMatching(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("hasTagMatching expects exactly one argument, got %d", len(args))
+	}
+	re, ok := args[0].(*regexp.Regexp)
+	if !ok {
+		return nil, fmt.Errorf("hasTagMatching expects a regex argument, got %T", args[0])
+	}
+	for k := range s.tags {
+		if re.MatchString(k) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// tagsSeparator returns the optional separator argument of the function, which defaults to a comma.
+func tagsSeparator(name string, args []interface{}) (string, error) {
+	switch len(args) {
+	case 0:
+		return ",", nil
+	case 1:
+		sep, ok := args[0].(string)
+		if !ok {
+			return "", fmt.Errorf("%s expects a string argument, got %T", name, args[0])
+		}
+		return sep, nil
+	default:
+		return "", fmt.Errorf("%s expects zero or one argument, got %d", name, len(args))
+	}
+}
+
+func (s *Scope) sortedTagKeys() []string {
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const nowFuncName = "now"
+
+var nowFuncSignature = map[Domain]ast.ValueType{
+	{}: ast.TTime,
+}
+
+// nowFunc returns the time set with SetNow,
+// or the current local time if none was set.
+func (s *Scope) nowFunc(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, errors.New("now expects exactly zero argument")
+	}
+	if !s.now.IsZero() {
+		return s.now, nil
+	}
+	return time.Now(), nil
+}
+
+// SetNow sets the time returned by the now function,
+// for example to the time of the data so that results do not depend on the wall clock.
+// The zero time uses the wall clock.
+func (s *Scope) SetNow(t time.Time) {
+	s.now = t
+}
+
+// SetTags sets the tags that are accessed with the tag functions.
+// The map is referenced, not copied.
+func (s *Scope) SetTags(tags map[string]string) {
+	s.tags = tags
 }
 
 func (s *Scope) References() []string {
@@ -81,6 +267,8 @@ func (s *Scope) Reset() {
 	for name := range s.variables {
 		s.Set(name, empty)
 	}
+	s.tags = nil
+	s.now = time.Time{}
 }
 
 func (s *Scope) SetDynamicMethod(name string, m DynamicMethod) {
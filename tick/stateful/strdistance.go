@@ -0,0 +1,158 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// levenshtein returns the Levenshtein distance between the strings,
+// the minimum number of single rune insertions, deletions or substitutions changing one into the other.
+// It ranges from 0 for equal strings to the rune length of the longer string.
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+	// Distances from the prefixes of ra to the prefixes of rb, one row at a time.
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d := diagonal + cost
+			if row[j]+1 < d {
+				d = row[j] + 1
+			}
+			if row[j-1]+1 < d {
+				d = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], d
+		}
+	}
+	return row[len(rb)]
+}
+
+const (
+	// Scale of the bonus of the common prefix of the Jaro-Winkler similarity.
+	jaroWinklerPrefixScale = 0.1
+	// Maximum length of the common prefix given a bonus.
+	jaroWinklerMaxPrefix = 4
+)
+
+// jaroWinkler returns the Jaro-Winkler similarity of the strings, from 0 for strings without common runes to 1 for equal strings.
+// The Jaro similarity counts the runes in common within half the length of the longer string of each other
+// and the transpositions among them, the Winkler bonus favors strings with a common prefix of up to 4 runes.
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := len(ra)
+	if len(rb) > window {
+		window = len(rb)
+	}
+	window = window/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := i-window, i+window+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(rb) {
+			hi = len(rb)
+		}
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Half the number of matching runes in a different order.
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions/2))/m) / 3
+
+	prefix := 0
+	for prefix < jaroWinklerMaxPrefix && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*jaroWinklerPrefixScale*(1-jaro)
+}
+
+// strJaroWinkler is the Jaro-Winkler similarity of two strings.
+type strJaroWinkler struct{}
+
+func (strJaroWinkler) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("strJaroWinkler expects exactly two arguments")
+	}
+	a, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as first arg to strJaroWinkler, must be string", args[0])
+	}
+	b, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to strJaroWinkler, must be string", args[1])
+	}
+	return jaroWinkler(a, b), nil
+}
+
+var strJaroWinklerFuncSignature = map[Domain]ast.ValueType{}
+
+func init() {
+	d := Domain{}
+	d[0] = ast.TString
+	d[1] = ast.TString
+	strJaroWinklerFuncSignature[d] = ast.TFloat
+}
+
+func (strJaroWinkler) Signature() map[Domain]ast.ValueType {
+	return strJaroWinklerFuncSignature
+}
+
+func (strJaroWinkler) Reset() {}
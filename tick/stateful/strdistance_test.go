@@ -0,0 +1,59 @@
+package stateful
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_StrDistance(t *testing.T) {
+	funcs := NewFunctions()
+	levenshteinCases := []struct {
+		a, b string
+		exp  int64
+	}{
+		{a: "", b: "", exp: 0},
+		{a: "", b: "abc", exp: 3},
+		{a: "abc", b: "abc", exp: 0},
+		{a: "kitten", b: "sitting", exp: 3},
+		{a: "flaw", b: "lawn", exp: 2},
+		{a: "café", b: "cafe", exp: 1},
+	}
+	for _, tc := range levenshteinCases {
+		for _, args := range [][]interface{}{{tc.a, tc.b}, {tc.b, tc.a}} {
+			got, err := funcs["strLevenshtein"].Call(args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.(int64) != tc.exp {
+				t.Errorf("unexpected strLevenshtein(%q, %q) got %v exp %d", args[0], args[1], got, tc.exp)
+			}
+		}
+	}
+
+	jaroWinklerCases := []struct {
+		a, b string
+		exp  float64
+	}{
+		{a: "", b: "", exp: 1},
+		{a: "", b: "a", exp: 0},
+		{a: "abc", b: "xyz", exp: 0},
+		{a: "MARTHA", b: "MARHTA", exp: 0.9611},
+		{a: "DWAYNE", b: "DUANE", exp: 0.84},
+		{a: "DIXON", b: "DICKSONX", exp: 0.8133},
+	}
+	for _, tc := range jaroWinklerCases {
+		for _, args := range [][]interface{}{{tc.a, tc.b}, {tc.b, tc.a}} {
+			got, err := funcs["strJaroWinkler"].Call(args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.(float64)-tc.exp) > 1e-4 {
+				t.Errorf("unexpected strJaroWinkler(%q, %q) got %v exp %v", args[0], args[1], got, tc.exp)
+			}
+		}
+	}
+
+	if _, err := funcs["strJaroWinkler"].Call("a", int64(1)); err == nil || err.Error() != "cannot pass int64 as second arg to strJaroWinkler, must be string" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
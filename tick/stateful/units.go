@@ -0,0 +1,169 @@
+package stateful
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Dimensions of the units of the unit registry.
+const (
+	dimensionDataSize    = "data size"
+	dimensionTime        = "time"
+	dimensionTemperature = "temperature"
+)
+
+// unit is a unit of the unit registry.
+// A value v in the unit is (v - offset) * num / den in the base unit of its dimension,
+// which are bytes, seconds and degrees Celsius.
+// The factor is kept as a fraction so that conversions such as between Fahrenheit and Celsius are exact for exact values.
+type unit struct {
+	dimension string
+	num       float64
+	den       float64
+	offset    float64
+}
+
+// units is the registry of the units known to the convert function.
+var units = map[string]unit{
+	// Data sizes, SI and binary prefixes
+	"bit":   {dimension: dimensionDataSize, num: 1, den: 8},
+	"kbit":  {dimension: dimensionDataSize, num: 1e3, den: 8},
+	"Mbit":  {dimension: dimensionDataSize, num: 1e6, den: 8},
+	"Gbit":  {dimension: dimensionDataSize, num: 1e9, den: 8},
+	"B":     {dimension: dimensionDataSize, num: 1, den: 1},
+	"bytes": {dimension: dimensionDataSize, num: 1, den: 1},
+	"kB":    {dimension: dimensionDataSize, num: 1e3, den: 1},
+	"MB":    {dimension: dimensionDataSize, num: 1e6, den: 1},
+	"GB":    {dimension: dimensionDataSize, num: 1e9, den: 1},
+	"TB":    {dimension: dimensionDataSize, num: 1e12, den: 1},
+	"PB":    {dimension: dimensionDataSize, num: 1e15, den: 1},
+	"KiB":   {dimension: dimensionDataSize, num: 1 << 10, den: 1},
+	"MiB":   {dimension: dimensionDataSize, num: 1 << 20, den: 1},
+	"GiB":   {dimension: dimensionDataSize, num: 1 << 30, den: 1},
+	"TiB":   {dimension: dimensionDataSize, num: 1 << 40, den: 1},
+	"PiB":   {dimension: dimensionDataSize, num: 1 << 50, den: 1},
+
+	// Time
+	"ns":  {dimension: dimensionTime, num: 1, den: 1e9},
+	"us":  {dimension: dimensionTime, num: 1, den: 1e6},
+	"ms":  {dimension: dimensionTime, num: 1, den: 1e3},
+	"s":   {dimension: dimensionTime, num: 1, den: 1},
+	"min": {dimension: dimensionTime, num: 60, den: 1},
+	"h":   {dimension: dimensionTime, num: 3600, den: 1},
+	"d":   {dimension: dimensionTime, num: 86400, den: 1},
+	"w":   {dimension: dimensionTime, num: 604800, den: 1},
+
+	// Temperature
+	"C": {dimension: dimensionTemperature, num: 1, den: 1},
+	"K": {dimension: dimensionTemperature, num: 1, den: 1, offset: 273.15},
+	"F": {dimension: dimensionTemperature, num: 5, den: 9, offset: 32},
+}
+
+// unitNames returns the sorted names of the units of the registry.
+func unitNames() string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// lookupUnits returns the units to convert from and to, or an error if they are unknown or incompatible.
+func lookupUnits(from, to string) (unit, unit, error) {
+	f, ok := units[from]
+	if !ok {
+		return unit{}, unit{}, fmt.Errorf("unknown unit %q, must be one of %s", from, unitNames())
+	}
+	t, ok := units[to]
+	if !ok {
+		return unit{}, unit{}, fmt.Errorf("unknown unit %q, must be one of %s", to, unitNames())
+	}
+	if f.dimension != t.dimension {
+		return unit{}, unit{}, fmt.Errorf("cannot convert %s unit %q to %s unit %q", f.dimension, from, t.dimension, to)
+	}
+	return f, t, nil
+}
+
+// convertUnit converts the value from a unit to another unit of the same dimension.
+func convertUnit(v float64, from, to unit) float64 {
+	if from == to {
+		return v
+	}
+	base := (v - from.offset) * from.num / from.den
+	return base*to.den/to.num + to.offset
+}
+
+// convert converts a value between units of the unit registry,
+// for example convert("used", 'B', 'GiB') or convert("temp", 'F', 'C').
+// The units must be string literals, so that unknown or incompatible units fail when the task starts.
+type convert struct {
+}
+
+func (convert) Reset() {}
+
+func (convert) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, errors.New("convert expects exactly three arguments")
+	}
+	var v float64
+	switch a := args[0].(type) {
+	case float64:
+		v = a
+	case int64:
+		v = float64(a)
+	default:
+		return nil, fmt.Errorf("cannot convert %T, must be float or int", args[0])
+	}
+	from, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as second arg to convert, must be string", args[1])
+	}
+	to, ok := args[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass %T as third arg to convert, must be string", args[2])
+	}
+	f, t, err := lookupUnits(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return convertUnit(v, f, t), nil
+}
+
+// ValidateArgs checks that the units are string literals of compatible units of the registry.
+func (convert) ValidateArgs(args []ast.Node) error {
+	if len(args) != 3 {
+		return errors.New("convert expects exactly three arguments")
+	}
+	var names [2]string
+	for i, arg := range args[1:] {
+		s, ok := arg.(*ast.StringNode)
+		if !ok {
+			return fmt.Errorf("the %s unit of convert must be a string literal", [2]string{"from", "to"}[i])
+		}
+		names[i] = s.Literal
+	}
+	_, _, err := lookupUnits(names[0], names[1])
+	return err
+}
+
+var convertFuncSignature = map[Domain]ast.ValueType{}
+
+// Initialize Convert Function Signature
+func init() {
+	d := Domain{}
+	d[1] = ast.TString
+	d[2] = ast.TString
+	for _, t := range []ast.ValueType{ast.TFloat, ast.TInt} {
+		d[0] = t
+		convertFuncSignature[d] = ast.TFloat
+	}
+}
+
+func (convert) Signature() map[Domain]ast.ValueType {
+	return convertFuncSignature
+}
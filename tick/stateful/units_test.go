@@ -0,0 +1,81 @@
+package stateful
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func Test_Convert(t *testing.T) {
+	f := NewFunctions()["convert"]
+	testCases := []struct {
+		args []interface{}
+		exp  float64
+	}{
+		{args: []interface{}{int64(3 << 30), "B", "GiB"}, exp: 3},
+		{args: []interface{}{2.5, "GB", "MB"}, exp: 2500},
+		{args: []interface{}{1.0, "MiB", "kB"}, exp: 1048.576},
+		{args: []interface{}{int64(100), "Mbit", "MB"}, exp: 12.5},
+		{args: []interface{}{1500.0, "ms", "s"}, exp: 1.5},
+		{args: []interface{}{2.0, "d", "h"}, exp: 48},
+		{args: []interface{}{212.0, "F", "C"}, exp: 100},
+		{args: []interface{}{-40.0, "C", "F"}, exp: -40},
+		{args: []interface{}{0.0, "C", "K"}, exp: 273.15},
+		{args: []interface{}{300.0, "K", "K"}, exp: 300},
+	}
+	for i, tc := range testCases {
+		result, err := f.Call(tc.args...)
+		if err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got := result.(float64); math.Abs(got-tc.exp) > 1e-9 {
+			t.Errorf("%d: unexpected result from convert(%v) got %v exp %v", i, tc.args, got, tc.exp)
+		}
+	}
+}
+
+func Test_Convert_ValidateArgs(t *testing.T) {
+	value := &ast.ReferenceNode{Reference: "value"}
+	testCases := []struct {
+		args []ast.Node
+		err  string
+	}{
+		{
+			args: []ast.Node{value, &ast.StringNode{Literal: "B"}, &ast.StringNode{Literal: "GiB"}},
+		},
+		{
+			args: []ast.Node{value, &ast.StringNode{Literal: "B"}, &ast.StringNode{Literal: "C"}},
+			err:  `cannot convert data size unit "B" to temperature unit "C"`,
+		},
+		{
+			args: []ast.Node{value, &ast.StringNode{Literal: "parsec"}, &ast.StringNode{Literal: "m"}},
+			err:  `unknown unit "parsec"`,
+		},
+		{
+			args: []ast.Node{value, &ast.StringNode{Literal: "B"}, &ast.ReferenceNode{Reference: "unit"}},
+			err:  "the to unit of convert must be a string literal",
+		},
+		{
+			args: []ast.Node{value, &ast.StringNode{Literal: "B"}},
+			err:  "convert expects exactly three arguments",
+		},
+	}
+	for i, tc := range testCases {
+		_, err := NewEvalFunctionNode(&ast.FunctionNode{Func: "convert", Args: tc.args})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: unexpected error got %q exp %q", i, err.Error(), tc.err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type TrendSlopeNode struct {
+	node
+	t *pipeline.TrendSlopeNode
+}
+
+// Create a new trend slope node.
+func newTrendSlopeNode(et *ExecutingTask, n *pipeline.TrendSlopeNode, d NodeDiagnostic) (*TrendSlopeNode, error) {
+	tn := &TrendSlopeNode{
+		node: node{Node: n, et: et, diag: d},
+		t:    n,
+	}
+	tn.node.runF = tn.runTrendSlope
+	return tn, nil
+}
+
+func (n *TrendSlopeNode) runTrendSlope([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *TrendSlopeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &trendSlopeGroup{n: n}),
+	), nil
+}
+
+// trendSlopeGroup retains the values of the points of a group within the window, oldest first.
+type trendSlopeGroup struct {
+	n *TrendSlopeNode
+
+	values []float64
+	times  []time.Time
+}
+
+func (g *trendSlopeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *trendSlopeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return bp, nil
+}
+
+func (g *trendSlopeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *trendSlopeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	value, ok := numToFloat(p.Fields()[g.n.t.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute trend slope",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", g.n.t.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.t.Field])),
+		)
+		return nil, nil
+	}
+	g.add(value, p.Time())
+
+	slope, r2 := g.fit(p.Time())
+	p = p.ShallowCopy()
+	fields := p.Fields().Copy()
+	fields[g.n.t.As] = slope
+	if g.n.t.R2 != "" {
+		fields[g.n.t.R2] = r2
+	}
+	p.SetFields(fields)
+	return p, nil
+}
+
+// add appends the value and drops the values outside of the window or beyond MaxPoints.
+func (g *trendSlopeGroup) add(value float64, t time.Time) {
+	g.values = append(g.values, value)
+	g.times = append(g.times, t)
+
+	i := 0
+	for i < len(g.times) && t.Sub(g.times[i]) > g.n.t.Period {
+		i++
+	}
+	if excess := len(g.times) - i - int(g.n.t.MaxPoints); excess > 0 {
+		i += excess
+	}
+	if i > 0 {
+		g.values = g.values[i:]
+		g.times = g.times[i:]
+	}
+}
+
+// fit computes the slope per unit and R² of the least squares regression line of the values.
+// Both are 0 if the values do not have at least two distinct times.
+//
+// The sums are computed from the deviations from the means, with times in seconds relative to t,
+// which avoids the loss of precision of the equivalent formula using the sums of the squares.
+func (g *trendSlopeGroup) fit(t time.Time) (slope, r2 float64) {
+	count := float64(len(g.values))
+	if count < 2 {
+		return 0, 0
+	}
+	var meanX, meanY float64
+	for i, v := range g.values {
+		meanX += g.times[i].Sub(t).Seconds()
+		meanY += v
+	}
+	meanX /= count
+	meanY /= count
+
+	var sxx, sxy, syy float64
+	for i, v := range g.values {
+		dx := g.times[i].Sub(t).Seconds() - meanX
+		dy := v - meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return 0, 0
+	}
+	slope = sxy / sxx * g.n.t.Unit.Seconds()
+	// Constant values lie exactly on the flat regression line.
+	r2 = 1
+	if syy != 0 {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+	return slope, r2
+}
+
+func (g *trendSlopeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *trendSlopeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (g *trendSlopeGroup) Done() {}
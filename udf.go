@@ -2,6 +2,7 @@ package kapacitor
 
 import (
 	"bufio"
+	"expvar"
 	"fmt"
 	"io"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"github.com/cenkalti/backoff"
 	"github.com/influxdata/kapacitor/command"
 	"github.com/influxdata/kapacitor/edge"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/udf"
 	"github.com/influxdata/kapacitor/udf/agent"
@@ -55,6 +57,12 @@ func newUDFNode(et *ExecutingTask, n *pipeline.UDFNode, d NodeDiagnostic) (*UDFN
 
 var errNodeAborted = errors.New("node aborted")
 
+// udfStatser is implemented by UDFs that maintain their own statistics,
+// which are reported as statistics of the node.
+type udfStatser interface {
+	Stats() map[string]expvar.Var
+}
+
 func (n *UDFNode) stopUDF() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -88,6 +96,11 @@ func (n *UDFNode) runUDF(snapshot []byte) (err error) {
 			return err
 		}
 	}
+	if st, ok := n.udf.(udfStatser); ok {
+		for name, v := range st.Stats() {
+			n.statMap.Set(name, v)
+		}
+	}
 
 	forwardErr := make(chan error, 1)
 	go func() {
@@ -277,6 +290,32 @@ func (p *UDFProcess) In() chan<- edge.Message            { return p.server.In()
 func (p *UDFProcess) Out() <-chan edge.Message           { return p.server.Out() }
 func (p *UDFProcess) Info() (udf.Info, error)            { return p.server.Info() }
 
+const (
+	statsUDFRequestTimeouts = "udf_request_timeouts"
+	statsUDFShortCircuited  = "udf_short_circuited"
+	statsUDFBreakerOpen     = "udf_breaker_open"
+)
+
+// UDFSocketBreaker configures how a UDFSocket protects a task from a UDF that stops accepting or answering requests.
+//
+// Each request must be accepted by the UDF within Timeout,
+// and the UDF must respond within Timeout of the oldest request it has not yet answered.
+// A keepalive request follows the data sent to the UDF so that UDFs that do not output data for each request
+// still respond.
+// After Threshold consecutive timeouts the breaker opens and data is no longer sent to the UDF,
+// instead it is dropped or, if PassThrough is set, forwarded unchanged.
+// While open, one request is sent to the UDF every ProbeInterval to check whether it has recovered,
+// if it is answered in time the breaker closes again.
+type UDFSocketBreaker struct {
+	// Timeout for each request, a value of zero disables timeouts.
+	Timeout       time.Duration
+	Threshold     int
+	ProbeInterval time.Duration
+	PassThrough   bool
+}
+
+var errUDFRequestTimeout = errors.New("UDF request timed out")
+
 type UDFSocket struct {
 	taskName string
 	nodeName string
@@ -286,7 +325,24 @@ type UDFSocket struct {
 
 	diag          udf.Diagnostic
 	timeout       time.Duration
+	breaker       UDFSocketBreaker
 	abortCallback func()
+
+	inMsg    chan edge.Message
+	outMsg   chan edge.Message
+	aborting chan struct{}
+	inGroup  sync.WaitGroup
+	outGroup sync.WaitGroup
+
+	// Breaker state, only accessed by the goroutine writing to the server.
+	consecutiveTimeouts int
+	openedAt            time.Time
+	// Time the oldest request not yet answered by the UDF was sent, zero if all have been answered.
+	unansweredSince time.Time
+
+	requestTimeouts *kexpvar.Int
+	shortCircuited  *kexpvar.Int
+	breakerOpen     *kexpvar.Int
 }
 
 type Socket interface {
@@ -301,15 +357,20 @@ func NewUDFSocket(
 	socket Socket,
 	d udf.Diagnostic,
 	timeout time.Duration,
+	breaker UDFSocketBreaker,
 	abortCallback func(),
 ) *UDFSocket {
 	return &UDFSocket{
-		taskName:      taskName,
-		nodeName:      nodeName,
-		socket:        socket,
-		diag:          d,
-		timeout:       timeout,
-		abortCallback: abortCallback,
+		taskName:        taskName,
+		nodeName:        nodeName,
+		socket:          socket,
+		diag:            d,
+		timeout:         timeout,
+		breaker:         breaker,
+		abortCallback:   abortCallback,
+		requestTimeouts: &kexpvar.Int{},
+		shortCircuited:  &kexpvar.Int{},
+		breakerOpen:     &kexpvar.Int{},
 	}
 }
 
@@ -322,6 +383,10 @@ func (s *UDFSocket) Open() error {
 	out := s.socket.Out()
 	outBuf := bufio.NewReader(out)
 
+	s.inMsg = make(chan edge.Message)
+	s.outMsg = make(chan edge.Message)
+	s.aborting = make(chan struct{})
+
 	s.server = udf.NewServer(
 		s.taskName,
 		s.nodeName,
@@ -329,13 +394,29 @@ func (s *UDFSocket) Open() error {
 		in,
 		s.diag,
 		s.timeout,
-		s.abortCallback,
+		s.aborted,
 		func() { s.socket.Close() },
 	)
-	return s.server.Start()
+	if err := s.server.Start(); err != nil {
+		return err
+	}
+
+	s.inGroup.Add(1)
+	go s.writeMessages()
+	s.outGroup.Add(1)
+	go s.readMessages()
+	go func() {
+		s.inGroup.Wait()
+		s.outGroup.Wait()
+		close(s.outMsg)
+	}()
+	return nil
 }
 
 func (s *UDFSocket) Close() error {
+	// The owner has stopped writing, finish sending the remaining data to the server.
+	close(s.inMsg)
+	s.inGroup.Wait()
 	if err := s.server.Stop(); err != nil {
 		// Always close the socket
 		s.socket.Close()
@@ -347,13 +428,204 @@ func (s *UDFSocket) Close() error {
 	return nil
 }
 
-func (s *UDFSocket) Abort(err error)                    { s.server.Abort(err) }
-func (s *UDFSocket) Init(options []*agent.Option) error { return s.server.Init(options) }
-func (s *UDFSocket) Snapshot() ([]byte, error)          { return s.server.Snapshot() }
-func (s *UDFSocket) Restore(snapshot []byte) error      { return s.server.Restore(snapshot) }
-func (s *UDFSocket) In() chan<- edge.Message            { return s.server.In() }
-func (s *UDFSocket) Out() <-chan edge.Message           { return s.server.Out() }
-func (s *UDFSocket) Info() (udf.Info, error)            { return s.server.Info() }
+// aborted is called by the server when it aborts,
+// it must stop all writes to the server before returning.
+func (s *UDFSocket) aborted() {
+	if s.abortCallback != nil {
+		s.abortCallback()
+	}
+	close(s.aborting)
+	s.inGroup.Wait()
+}
+
+// Stats returns the request timeout and breaker statistics of the UDF.
+func (s *UDFSocket) Stats() map[string]expvar.Var {
+	return map[string]expvar.Var{
+		statsUDFRequestTimeouts: s.requestTimeouts,
+		statsUDFShortCircuited:  s.shortCircuited,
+		statsUDFBreakerOpen:     s.breakerOpen,
+	}
+}
+
+// writeMessages sends the messages written by the owner to the server.
+// Batches are buffered so that the breaker only ever sends or skips whole batches.
+func (s *UDFSocket) writeMessages() {
+	defer s.inGroup.Done()
+	var begin edge.BeginBatchMessage
+	var points []edge.BatchPointMessage
+	for m := range s.inMsg {
+		switch msg := m.(type) {
+		case edge.BeginBatchMessage:
+			begin = msg
+			points = make([]edge.BatchPointMessage, 0, msg.SizeHint())
+			continue
+		case edge.BatchPointMessage:
+			points = append(points, msg)
+			continue
+		case edge.EndBatchMessage:
+			m = edge.NewBufferedBatchMessage(begin, points, msg)
+			begin, points = nil, nil
+		}
+		if !s.write(m) {
+			return
+		}
+	}
+}
+
+// write sends a message to the server, it returns false if the server has aborted.
+func (s *UDFSocket) write(m edge.Message) bool {
+	if s.breaker.Timeout <= 0 {
+		select {
+		case s.server.In() <- m:
+			return true
+		case <-s.aborting:
+			return false
+		}
+	}
+	s.checkResponse()
+	// While the breaker is open only send a probe once every ProbeInterval.
+	if s.isOpen() && time.Since(s.openedAt) < s.breaker.ProbeInterval {
+		return s.shortCircuit(m)
+	}
+	timer := time.NewTimer(s.breaker.Timeout)
+	defer timer.Stop()
+	select {
+	case s.server.In() <- m:
+		if s.unansweredSince.IsZero() {
+			s.unansweredSince = time.Now()
+			s.server.RequestKeepalive()
+		}
+		if s.isOpen() {
+			// The breaker remains open until the probe is answered.
+			s.openedAt = time.Now()
+		}
+		return true
+	case <-timer.C:
+		s.timedOut()
+		return s.shortCircuit(m)
+	case <-s.aborting:
+		return false
+	}
+}
+
+// checkResponse checks whether the UDF has answered the requests sent to it within the timeout.
+// A response closes the breaker, a missing response counts as a timeout.
+func (s *UDFSocket) checkResponse() {
+	if s.unansweredSince.IsZero() {
+		return
+	}
+	if s.server.LastResponse().After(s.unansweredSince) {
+		s.unansweredSince = time.Time{}
+		s.consecutiveTimeouts = 0
+		s.breakerOpen.Set(0)
+		return
+	}
+	if time.Since(s.unansweredSince) > s.breaker.Timeout {
+		// Count the timeout once, the next request starts a new wait for a response.
+		s.unansweredSince = time.Time{}
+		s.timedOut()
+	}
+}
+
+// timedOut counts a request timeout and opens the breaker once the threshold is reached.
+func (s *UDFSocket) timedOut() {
+	s.requestTimeouts.Add(1)
+	s.consecutiveTimeouts++
+	if s.isOpen() {
+		if s.breakerOpen.IntValue() == 0 {
+			s.diag.Error("opening circuit breaker", errUDFRequestTimeout)
+		}
+		s.openedAt = time.Now()
+		s.breakerOpen.Set(1)
+	}
+}
+
+func (s *UDFSocket) isOpen() bool {
+	return s.breaker.Threshold > 0 && s.consecutiveTimeouts >= s.breaker.Threshold
+}
+
+// shortCircuit drops the message or forwards it unchanged.
+func (s *UDFSocket) shortCircuit(m edge.Message) bool {
+	s.shortCircuited.Add(1)
+	if !s.breaker.PassThrough {
+		return true
+	}
+	select {
+	case s.outMsg <- m:
+		return true
+	case <-s.aborting:
+		return false
+	}
+}
+
+// readMessages forwards the messages produced by the UDF to the owner.
+func (s *UDFSocket) readMessages() {
+	defer s.outGroup.Done()
+	for m := range s.server.Out() {
+		select {
+		case s.outMsg <- m:
+		case <-s.aborting:
+			return
+		}
+	}
+}
+
+// udfResponse is the result of a request to the UDF.
+type udfResponse struct {
+	value interface{}
+	err   error
+}
+
+// request performs a request/response call to the UDF within the request timeout.
+// The UDF is aborted if it does not respond in time, as its state is then unknown.
+// The result is sent back over a channel, so a request that times out cannot write to the variables of the caller.
+func (s *UDFSocket) request(f func() (interface{}, error)) (interface{}, error) {
+	if s.breaker.Timeout <= 0 {
+		return f()
+	}
+	respC := make(chan udfResponse, 1)
+	go func() {
+		v, err := f()
+		respC <- udfResponse{value: v, err: err}
+	}()
+	timer := time.NewTimer(s.breaker.Timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-respC:
+		return resp.value, resp.err
+	case <-timer.C:
+		s.requestTimeouts.Add(1)
+		s.server.Abort(errUDFRequestTimeout)
+		return nil, errUDFRequestTimeout
+	}
+}
+
+func (s *UDFSocket) Abort(err error) { s.server.Abort(err) }
+func (s *UDFSocket) Init(options []*agent.Option) error {
+	_, err := s.request(func() (interface{}, error) { return nil, s.server.Init(options) })
+	return err
+}
+func (s *UDFSocket) Snapshot() ([]byte, error) {
+	v, err := s.request(func() (interface{}, error) { return s.server.Snapshot() })
+	if err != nil {
+		return nil, err
+	}
+	snapshot, _ := v.([]byte)
+	return snapshot, nil
+}
+func (s *UDFSocket) Restore(snapshot []byte) error {
+	_, err := s.request(func() (interface{}, error) { return nil, s.server.Restore(snapshot) })
+	return err
+}
+func (s *UDFSocket) In() chan<- edge.Message  { return s.inMsg }
+func (s *UDFSocket) Out() <-chan edge.Message { return s.outMsg }
+func (s *UDFSocket) Info() (udf.Info, error) {
+	v, err := s.request(func() (interface{}, error) { return s.server.Info() })
+	if err != nil {
+		return udf.Info{}, err
+	}
+	return v.(udf.Info), nil
+}
 
 type socket struct {
 	path string
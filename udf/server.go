@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
@@ -71,6 +72,10 @@ type Server struct {
 
 	keepalive        chan int64
 	keepaliveTimeout time.Duration
+	// Keepalive requests asked for by the owner, see RequestKeepalive.
+	keepaliveRequested chan struct{}
+	// Time in unix nanoseconds of the last response from the UDF.
+	lastResponse int64
 
 	taskID string
 	nodeID string
@@ -106,22 +111,23 @@ func NewServer(
 	killCallback func(),
 ) *Server {
 	s := &Server{
-		taskID:           taskID,
-		nodeID:           nodeID,
-		in:               in,
-		out:              out,
-		diag:             d,
-		requests:         make(chan *agent.Request),
-		keepalive:        make(chan int64, 1),
-		keepaliveTimeout: timeout,
-		abortCallback:    abortCallback,
-		killCallback:     killCallback,
-		inMsg:            make(chan edge.Message),
-		outMsg:           make(chan edge.Message),
-		infoResponse:     make(chan *agent.Response, 1),
-		initResponse:     make(chan *agent.Response, 1),
-		snapshotResponse: make(chan *agent.Response, 1),
-		restoreResponse:  make(chan *agent.Response, 1),
+		taskID:             taskID,
+		nodeID:             nodeID,
+		in:                 in,
+		out:                out,
+		diag:               d,
+		requests:           make(chan *agent.Request),
+		keepalive:          make(chan int64, 1),
+		keepaliveTimeout:   timeout,
+		keepaliveRequested: make(chan struct{}, 1),
+		abortCallback:      abortCallback,
+		killCallback:       killCallback,
+		inMsg:              make(chan edge.Message),
+		outMsg:             make(chan edge.Message),
+		infoResponse:       make(chan *agent.Response, 1),
+		initResponse:       make(chan *agent.Response, 1),
+		snapshotResponse:   make(chan *agent.Response, 1),
+		restoreResponse:    make(chan *agent.Response, 1),
 	}
 
 	return s
@@ -130,6 +136,23 @@ func NewServer(
 func (s *Server) In() chan<- edge.Message {
 	return s.inMsg
 }
+
+// RequestKeepalive asks the UDF for a keepalive response once the data already sent to it has been written,
+// it does not wait for the request to be written.
+// As the UDF handles requests in order, a response received after the keepalive request
+// shows that the UDF has handled the data sent before it, see LastResponse.
+func (s *Server) RequestKeepalive() {
+	select {
+	case s.keepaliveRequested <- struct{}{}:
+	default:
+		// A keepalive request is already pending.
+	}
+}
+
+// LastResponse returns the time the last response of any kind was received from the UDF.
+func (s *Server) LastResponse() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastResponse))
+}
 func (s *Server) Out() <-chan edge.Message {
 	return s.outMsg
 }
@@ -486,6 +509,15 @@ func (s *Server) writeData() error {
 			} else {
 				s.requests = nil
 			}
+		case <-s.keepaliveRequested:
+			req := &agent.Request{Message: &agent.Request_Keepalive{
+				Keepalive: &agent.KeepaliveRequest{
+					Time: time.Now().UnixNano(),
+				},
+			}}
+			if err := s.writeRequest(req); err != nil {
+				return err
+			}
 		case <-s.aborting:
 			return s.err
 		}
@@ -672,6 +704,7 @@ func (s *Server) readResponse() (*agent.Response, error) {
 }
 
 func (s *Server) handleResponse(response *agent.Response) error {
+	atomic.StoreInt64(&s.lastResponse, time.Now().UnixNano())
 	// Always reset the keepalive timer since we received a response
 	select {
 	case s.keepalive <- time.Now().UnixNano():
@@ -30,7 +30,7 @@ func init() {
 func newUDFSocket(name string) (*kapacitor.UDFSocket, *udf_test.IO) {
 	uio := udf_test.NewIO()
 	d := kapacitorDiag.WithNodeContext(name)
-	u := kapacitor.NewUDFSocket(name, "testNode", newTestSocket(uio), d, 0, nil)
+	u := kapacitor.NewUDFSocket(name, "testNode", newTestSocket(uio), d, 0, kapacitor.UDFSocketBreaker{}, nil)
 	return u, uio
 }
 
@@ -245,6 +245,186 @@ func testUDF_WriteBatch(u udf.Interface, uio *udf_test.IO, t *testing.T) {
 	}
 }
 
+func TestUDFSocket_Breaker(t *testing.T) {
+	uio := udf_test.NewIO()
+	d := kapacitorDiag.WithNodeContext("Breaker")
+	u := kapacitor.NewUDFSocket("Breaker", "testNode", newTestSocket(uio), d, 0, kapacitor.UDFSocketBreaker{
+		Timeout:       50 * time.Millisecond,
+		Threshold:     2,
+		ProbeInterval: time.Hour,
+		PassThrough:   true,
+	}, nil)
+
+	go func() {
+		req := <-uio.Requests
+		if _, ok := req.Message.(*agent.Request_Init); !ok {
+			t.Errorf("expected init message got %T", req.Message)
+		}
+		uio.Responses <- &agent.Response{
+			Message: &agent.Response_Init{
+				Init: &agent.InitResponse{
+					Success: true,
+				},
+			},
+		}
+		// Stop reading requests so that the UDF hangs
+	}()
+
+	if err := u.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Init(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	point := func(i int) edge.PointMessage {
+		return edge.NewPointMessage(
+			"test",
+			"db",
+			"rp",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			nil,
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+		)
+	}
+	// The first point is accepted, the UDF then blocks on the keepalive request that follows it.
+	u.In() <- point(0)
+	time.Sleep(10 * time.Millisecond)
+	// The next point times out and the first point is not answered in time so the breaker opens,
+	// the last is passed through without trying the UDF.
+	for i := 1; i < 4; i++ {
+		p := point(i)
+		u.In() <- p
+		if rp := <-u.Out(); !reflect.DeepEqual(rp, p) {
+			t.Errorf("unexpected passed through point got: %v exp %v", rp, p)
+		}
+	}
+
+	stats := u.Stats()
+	for name, exp := range map[string]string{
+		"udf_request_timeouts": "2",
+		"udf_short_circuited":  "3",
+		"udf_breaker_open":     "1",
+	} {
+		if got := stats[name].String(); got != exp {
+			t.Errorf("unexpected %s got: %s exp %s", name, got, exp)
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		u.Close()
+		close(closed)
+	}()
+	// read all requests and wait till the chan is closed
+	for range uio.Requests {
+	}
+	close(uio.Responses)
+	<-closed
+	if err := <-uio.ErrC; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUDFSocket_Breaker_Response(t *testing.T) {
+	uio := udf_test.NewIO()
+	d := kapacitorDiag.WithNodeContext("BreakerResponse")
+	u := kapacitor.NewUDFSocket("BreakerResponse", "testNode", newTestSocket(uio), d, 0, kapacitor.UDFSocketBreaker{
+		Timeout:       50 * time.Millisecond,
+		Threshold:     2,
+		ProbeInterval: time.Hour,
+		PassThrough:   true,
+	}, nil)
+
+	requests := make(chan *agent.Request, 100)
+	go func() {
+		req := <-uio.Requests
+		if _, ok := req.Message.(*agent.Request_Init); !ok {
+			t.Errorf("expected init message got %T", req.Message)
+		}
+		uio.Responses <- &agent.Response{
+			Message: &agent.Response_Init{
+				Init: &agent.InitResponse{
+					Success: true,
+				},
+			},
+		}
+		// Keep accepting requests without ever answering them.
+		for req := range uio.Requests {
+			requests <- req
+		}
+		close(requests)
+	}()
+
+	if err := u.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Init(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	point := func(i int) edge.PointMessage {
+		return edge.NewPointMessage(
+			"test",
+			"db",
+			"rp",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			nil,
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+		)
+	}
+	// Each point is accepted but not answered within the timeout.
+	u.In() <- point(0)
+	time.Sleep(60 * time.Millisecond)
+	u.In() <- point(1)
+	time.Sleep(60 * time.Millisecond)
+	// The second timeout opens the breaker and the points are passed through.
+	for i := 2; i < 4; i++ {
+		p := point(i)
+		u.In() <- p
+		if rp := <-u.Out(); !reflect.DeepEqual(rp, p) {
+			t.Errorf("unexpected passed through point got: %v exp %v", rp, p)
+		}
+	}
+
+	stats := u.Stats()
+	for name, exp := range map[string]string{
+		"udf_request_timeouts": "2",
+		"udf_short_circuited":  "2",
+		"udf_breaker_open":     "1",
+	} {
+		if got := stats[name].String(); got != exp {
+			t.Errorf("unexpected %s got: %s exp %s", name, got, exp)
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		u.Close()
+		close(closed)
+	}()
+	// The points and their keepalive requests were sent to the UDF.
+	var points, keepalives int
+	for req := range requests {
+		switch req.Message.(type) {
+		case *agent.Request_Point:
+			points++
+		case *agent.Request_Keepalive:
+			keepalives++
+		}
+	}
+	if points != 2 || keepalives != 2 {
+		t.Errorf("unexpected requests got %d points and %d keepalives exp 2 and 2", points, keepalives)
+	}
+	close(uio.Responses)
+	<-closed
+	if err := <-uio.ErrC; err != nil {
+		t.Error(err)
+	}
+}
+
 type testCommander struct {
 	uio *udf_test.IO
 }
@@ -6,13 +6,25 @@ import (
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 )
 
+// defaultPartialTag is the name of the tag of partial windows if none is configured.
+const defaultPartialTag = "partial"
+
+const statBufferedPoints = "buffered_points"
+
 type WindowNode struct {
 	node
 	w *pipeline.WindowNode
+
+	// The epoch the window edges are aligned to, the zero time unless configured.
+	epoch time.Time
+
+	// The number of points buffered by the windows of all groups.
+	buffered *kexpvar.Int
 }
 
 // Create a new  WindowNode, which windows data for a period of time and emits the window.
@@ -21,8 +33,16 @@ func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, d NodeDiagnostic)
 		return nil, errors.New("window node must have either a non zero period or non zero period count")
 	}
 	wn := &WindowNode{
-		w:    n,
-		node: node{Node: n, et: et, diag: d},
+		w:        n,
+		node:     node{Node: n, et: et, diag: d},
+		buffered: &kexpvar.Int{},
+	}
+	if n.AlignEpoch != "" {
+		epoch, err := time.Parse(time.RFC3339Nano, n.AlignEpoch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alignEpoch %q: %v", n.AlignEpoch, err)
+		}
+		wn.epoch = epoch
 	}
 	wn.node.runF = wn.runWindow
 	return wn, nil
@@ -31,6 +51,7 @@ func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, d NodeDiagnostic)
 func (n *WindowNode) runWindow([]byte) (err error) {
 	consumer := edge.NewGroupedConsumer(n.ins[0], n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	n.statMap.Set(statBufferedPoints, n.buffered)
 	err = consumer.Consume()
 	return
 }
@@ -59,8 +80,12 @@ func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge
 			group,
 			n.w.Period,
 			n.w.Every,
-			n.w.AlignFlag,
+			n.w.AlignFlag || n.w.AlignEpoch != "",
+			n.epoch,
 			n.w.FillPeriodFlag,
+			n.w.PartialEvery,
+			n.partialTag(),
+			n.buffered,
 			n.diag,
 		), nil
 	case n.w.PeriodCount != 0:
@@ -70,6 +95,7 @@ func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge
 			int(n.w.PeriodCount),
 			int(n.w.EveryCount),
 			n.w.FillPeriodFlag,
+			n.buffered,
 			n.diag,
 		), nil
 	default:
@@ -77,16 +103,30 @@ func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge
 	}
 }
 
+func (n *WindowNode) partialTag() string {
+	if n.w.PartialTag == "" {
+		return defaultPartialTag
+	}
+	return n.w.PartialTag
+}
+
 type windowByTime struct {
 	name  string
 	group edge.GroupInfo
 
 	nextEmit time.Time
 
+	// Partial windows are emitted every partialEvery until the window is emitted.
+	partialEvery time.Duration
+	partialTag   string
+	nextPartial  time.Time
+
 	buf *windowTimeBuffer
 
 	align,
 	fillPeriod bool
+	// The epoch the window edges are aligned to.
+	epoch time.Time
 
 	period time.Duration
 	every  time.Duration
@@ -100,43 +140,101 @@ func newWindowByTime(
 	group edge.GroupInfo,
 	period,
 	every time.Duration,
-	align,
+	align bool,
+	epoch time.Time,
 	fillPeriod bool,
+	partialEvery time.Duration,
+	partialTag string,
+	buffered *kexpvar.Int,
 	d NodeDiagnostic,
 
 ) *windowByTime {
+	w := &windowByTime{
+		name:         name,
+		group:        group,
+		partialEvery: partialEvery,
+		partialTag:   partialTag,
+		buf:          &windowTimeBuffer{buffered: buffered, diag: d},
+		align:        align,
+		fillPeriod:   fillPeriod,
+		epoch:        epoch,
+		period:       period,
+		every:        every,
+		diag:         d,
+	}
 	// Determine nextEmit time.
-	var nextEmit time.Time
 	if fillPeriod {
-		nextEmit = t.Add(period)
+		w.nextEmit = t.Add(period)
 		if align {
-			firstPeriod := nextEmit
+			firstPeriod := w.nextEmit
 			// Needs to be aligned with Every and be greater than now+Period
-			nextEmit = nextEmit.Truncate(every)
-			if !nextEmit.After(firstPeriod) {
+			w.nextEmit = w.truncate(w.nextEmit, every)
+			if !w.nextEmit.After(firstPeriod) {
 				// This means we will drop the first few points
-				nextEmit = nextEmit.Add(every)
+				w.nextEmit = w.nextEmit.Add(every)
 			}
 		}
 	} else {
-		nextEmit = t.Add(every)
+		w.nextEmit = t.Add(every)
 		if align {
-			nextEmit = nextEmit.Truncate(every)
+			w.nextEmit = w.truncate(w.nextEmit, every)
 		}
 	}
-	return &windowByTime{
-		name:       name,
-		group:      group,
-		nextEmit:   nextEmit,
-		buf:        &windowTimeBuffer{diag: d},
-		align:      align,
-		fillPeriod: fillPeriod,
-		period:     period,
-		every:      every,
-		diag:       d,
+	w.setNextPartial(t)
+	return w
+}
+
+// truncate returns t rounded down to a multiple of d since the epoch.
+func (w *windowByTime) truncate(t time.Time, d time.Duration) time.Time {
+	if w.epoch.IsZero() {
+		return t.Truncate(d)
+	}
+	offset := t.Sub(w.epoch) % d
+	if offset < 0 {
+		offset += d
+	}
+	return t.Add(-offset)
+}
+
+// setNextPartial sets the time of the next partial window after t.
+func (w *windowByTime) setNextPartial(t time.Time) {
+	if w.partialEvery == 0 {
+		return
+	}
+	w.nextPartial = t.Add(w.partialEvery)
+	if w.align {
+		w.nextPartial = w.truncate(w.nextPartial, w.partialEvery)
 	}
 }
 
+// partial returns the current incomplete window as a batch message, if a partial window is due at t.
+func (w *windowByTime) partial(t time.Time) edge.Message {
+	if w.partialEvery == 0 || t.Before(w.nextPartial) {
+		return nil
+	}
+	w.setNextPartial(t)
+	// The points purged are not part of the next window either.
+	w.buf.purge(w.nextEmit.Add(-1*w.period), true)
+
+	tags := make(models.Tags, len(w.group.Tags)+1)
+	for k, v := range w.group.Tags {
+		tags[k] = v
+	}
+	tags[w.partialTag] = "true"
+	points := w.buf.points()
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(
+			w.name,
+			tags,
+			w.group.Dimensions.ByName,
+			t,
+			len(points),
+		),
+		points,
+		edge.NewEndBatchMessage(),
+	)
+}
+
 func (w *windowByTime) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
 	return nil, errors.New("window does not support batch data")
 }
@@ -174,8 +272,11 @@ func (w *windowByTime) Barrier(b edge.BarrierMessage) (msg edge.Message, err err
 			// This is dependent on the current time not the last time we emitted.
 			w.nextEmit = b.Time().Add(w.every)
 			if w.align {
-				w.nextEmit = w.nextEmit.Truncate(w.every)
+				w.nextEmit = w.truncate(w.nextEmit, w.every)
 			}
+			w.setNextPartial(b.Time())
+		} else {
+			msg = w.partial(b.Time())
 		}
 	}
 	return
@@ -183,7 +284,9 @@ func (w *windowByTime) Barrier(b edge.BarrierMessage) (msg edge.Message, err err
 func (w *windowByTime) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
 	return d, nil
 }
-func (w *windowByTime) Done() {}
+func (w *windowByTime) Done() {
+	w.buf.buffered.Add(-int64(w.buf.size))
+}
 
 func (w *windowByTime) Point(p edge.PointMessage) (msg edge.Message, err error) {
 	if w.every == 0 {
@@ -215,11 +318,16 @@ func (w *windowByTime) Point(p edge.PointMessage) (msg edge.Message, err error)
 			// This is dependent on the current time not the last time we emitted.
 			w.nextEmit = p.Time().Add(w.every)
 			if w.align {
-				w.nextEmit = w.nextEmit.Truncate(w.every)
+				w.nextEmit = w.truncate(w.nextEmit, w.every)
 			}
+			w.setNextPartial(p.Time())
+			// Insert point after.
+			w.buf.insert(p)
+		} else {
+			// Insert point before, the partial window includes the point.
+			w.buf.insert(p)
+			msg = w.partial(p.Time())
 		}
-		// Insert point after.
-		w.buf.insert(p)
 	}
 	return
 }
@@ -247,7 +355,9 @@ type windowTimeBuffer struct {
 	start  int
 	stop   int
 	size   int
-	diag   NodeDiagnostic
+	// buffered is the number of points buffered by all the windows of the node.
+	buffered *kexpvar.Int
+	diag     NodeDiagnostic
 }
 
 // Insert a single point into the buffer.
@@ -289,6 +399,7 @@ func (b *windowTimeBuffer) insert(p edge.PointMessage) {
 	}
 	b.size++
 	b.stop++
+	b.buffered.Add(1)
 }
 
 // Purge expired data from the window.
@@ -303,6 +414,10 @@ func (b *windowTimeBuffer) purge(oldest time.Time, inclusive bool) {
 	if l == 0 {
 		return
 	}
+	size := b.size
+	defer func() {
+		b.buffered.Add(int64(b.size - size))
+	}()
 	if b.start < b.stop {
 		for ; b.start < b.stop; b.start++ {
 			if include(b.window[b.start].Time()) {
@@ -369,6 +484,8 @@ type windowByCount struct {
 	nextEmit int
 	size     int
 	count    int
+	// buffered is the number of points buffered by all the windows of the node.
+	buffered *kexpvar.Int
 
 	diag NodeDiagnostic
 }
@@ -379,6 +496,7 @@ func newWindowByCount(
 	period,
 	every int,
 	fillPeriod bool,
+	buffered *kexpvar.Int,
 	d NodeDiagnostic,
 ) *windowByCount {
 	// Determine the first nextEmit index
@@ -393,6 +511,7 @@ func newWindowByCount(
 		period:   period,
 		every:    every,
 		nextEmit: nextEmit,
+		buffered: buffered,
 		diag:     d,
 	}
 }
@@ -412,7 +531,9 @@ func (w *windowByCount) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 func (w *windowByCount) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
 	return d, nil
 }
-func (w *windowByCount) Done() {}
+func (w *windowByCount) Done() {
+	w.buffered.Add(-int64(w.size))
+}
 
 func (w *windowByCount) Point(p edge.PointMessage) (msg edge.Message, err error) {
 	w.buf[w.stop] = edge.BatchPointFromPoint(p)
@@ -421,6 +542,7 @@ func (w *windowByCount) Point(p edge.PointMessage) (msg edge.Message, err error)
 		w.start = (w.start + 1) % w.period
 	} else {
 		w.size++
+		w.buffered.Add(1)
 	}
 	w.count++
 	//Check if its time to emit
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/stretchr/testify/assert"
 )
@@ -12,7 +13,7 @@ import (
 func TestWindowBufferByTime(t *testing.T) {
 	assert := assert.New(t)
 
-	buf := &windowTimeBuffer{}
+	buf := &windowTimeBuffer{buffered: &kexpvar.Int{}}
 
 	size := 100
 
@@ -32,6 +33,7 @@ func TestWindowBufferByTime(t *testing.T) {
 		assert.Equal(i, buf.size)
 		assert.Equal(0, buf.start)
 		assert.Equal(i, buf.stop)
+		assert.Equal(int64(i), buf.buffered.IntValue())
 	}
 
 	// purge entire buffer
@@ -43,6 +45,7 @@ func TestWindowBufferByTime(t *testing.T) {
 		assert.Equal(size-i, buf.size, "i: %d", i)
 		assert.Equal(i, buf.start, "i: %d", i)
 		assert.Equal(size, buf.stop, "i: %d", i)
+		assert.Equal(int64(size-i), buf.buffered.IntValue(), "i: %d", i)
 
 		points := buf.points()
 		if assert.Equal(size-i, len(points)) {
@@ -127,6 +130,7 @@ func TestWindowBufferByCount(t *testing.T) {
 			tc.period,
 			tc.every,
 			tc.fillPeriod,
+			&kexpvar.Int{},
 			&nodeDiagnostic{},
 		)
 
@@ -195,3 +199,95 @@ func TestWindowBufferByCount(t *testing.T) {
 		}
 	}
 }
+
+func TestWindowByTimePartial(t *testing.T) {
+	assert := assert.New(t)
+
+	group := edge.GroupInfo{
+		ID:   "host=serverA",
+		Tags: models.Tags{"host": "serverA"},
+	}
+	start := time.Unix(0, 0).UTC()
+	w := newWindowByTime("cpu", start, group, 10*time.Second, 10*time.Second, true, time.Time{}, false, 3*time.Second, defaultPartialTag, &kexpvar.Int{}, nil)
+
+	type emit struct {
+		at      int
+		partial bool
+		points  int
+	}
+	var got []emit
+	for i := 0; i <= 12; i++ {
+		p := edge.NewPointMessage(
+			"cpu", "db", "rp",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			models.Tags{"host": "serverA"},
+			start.Add(time.Duration(i)*time.Second),
+		)
+		msg, err := w.Point(p)
+		if !assert.NoError(err) || msg == nil {
+			continue
+		}
+		b := msg.(edge.BufferedBatchMessage)
+		got = append(got, emit{
+			at:      i,
+			partial: b.Tags()[defaultPartialTag] == "true",
+			points:  len(b.Points()),
+		})
+		assert.Equal("serverA", b.Tags()["host"])
+	}
+
+	exp := []emit{
+		// Partial windows include the point that triggered them.
+		{at: 3, partial: true, points: 4},
+		{at: 6, partial: true, points: 7},
+		{at: 9, partial: true, points: 10},
+		// The complete window does not include the point at the window boundary.
+		{at: 10, partial: false, points: 10},
+		{at: 12, partial: true, points: 3},
+	}
+	assert.Equal(exp, got)
+	// The group tags are not modified by partial windows.
+	assert.Equal(models.Tags{"host": "serverA"}, group.Tags)
+}
+
+func TestWindowByTimeAlignEpoch(t *testing.T) {
+	assert := assert.New(t)
+
+	group := edge.GroupInfo{
+		ID:   "host=serverA",
+		Tags: models.Tags{"host": "serverA"},
+	}
+	epoch := time.Date(2024, 1, 1, 0, 0, 3, 0, time.UTC)
+	// Windows starting at different times have the same edges.
+	for _, offset := range []int{0, 4, 7} {
+		start := epoch.Add(time.Duration(offset) * time.Second)
+		w := newWindowByTime("cpu", start, group, 10*time.Second, 10*time.Second, true, epoch, false, 0, defaultPartialTag, &kexpvar.Int{}, nil)
+		var got []time.Time
+		for i := offset; i <= 30; i++ {
+			p := edge.NewPointMessage(
+				"cpu", "db", "rp",
+				models.Dimensions{},
+				models.Fields{"value": float64(i)},
+				models.Tags{"host": "serverA"},
+				epoch.Add(time.Duration(i)*time.Second),
+			)
+			msg, err := w.Point(p)
+			if !assert.NoError(err) || msg == nil {
+				continue
+			}
+			got = append(got, msg.(edge.BufferedBatchMessage).Begin().Time())
+		}
+		exp := []time.Time{
+			epoch.Add(10 * time.Second),
+			epoch.Add(20 * time.Second),
+			epoch.Add(30 * time.Second),
+		}
+		assert.Equal(exp, got, "offset %d", offset)
+	}
+
+	// Times before the epoch are aligned too.
+	before := epoch.Add(-25 * time.Second)
+	w := newWindowByTime("cpu", before, group, 10*time.Second, 10*time.Second, true, epoch, false, 0, defaultPartialTag, &kexpvar.Int{}, nil)
+	assert.Equal(epoch.Add(-20*time.Second), w.nextEmit)
+}